@@ -3,10 +3,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -17,10 +20,13 @@ import (
 	"device-service/internal/database"
 	"device-service/internal/driver"
 	"device-service/internal/model"
+	"device-service/internal/protocol"
 	"device-service/internal/repository"
 	"device-service/internal/routes"
 	"device-service/internal/service"
+	"device-service/internal/tracing"
 	"device-service/internal/utils"
+	pkgdriver "device-service/pkg/driver"
 )
 
 // Application represents the main application
@@ -36,12 +42,22 @@ type Application struct {
 	discoveryService *service.DiscoveryService
 
 	// Repositories
-	deviceRepo    repository.DeviceRepository
-	operationRepo repository.OperationRepository
-	offlineRepo   repository.OfflineRepository
+	deviceRepo      repository.DeviceRepository
+	operationRepo   repository.OperationRepository
+	offlineRepo     repository.OfflineRepository
+	statusEventRepo repository.StatusEventRepository
+	inventoryRepo   repository.InventoryRepository
 
-	// Driver registry
+	// Driver registry and live driver pool
 	driverRegistry *driver.Registry
+	driverPool     *driver.Pool
+	configCipher   *utils.ConfigCipher
+
+	// Background services
+	background *service.BackgroundManager
+
+	// Flushes pending trace spans on shutdown
+	shutdownTracing func(context.Context) error
 }
 
 // @title Device Service API
@@ -94,9 +110,17 @@ func NewApplication() (*Application, error) {
 	serviceLogger := utils.NewServiceLogger(logger, "device-service")
 	serviceLogger.LogServiceStart(cfg.App.Version, cfg)
 
+	// Initialize tracing before anything starts spans
+	shutdownTracing, err := tracing.Setup(context.Background(), &cfg.Tracing, cfg.App.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+
 	app := &Application{
-		config: cfg,
-		logger: logger,
+		config:          cfg,
+		logger:          logger,
+		background:      service.NewBackgroundManager(logger),
+		shutdownTracing: shutdownTracing,
 	}
 
 	// Initialize components
@@ -148,6 +172,8 @@ func (app *Application) initializeRepositories() error {
 	app.deviceRepo = repository.NewDeviceRepository(app.database, app.logger)
 	app.operationRepo = repository.NewOperationRepository(app.database, app.logger)
 	app.offlineRepo = repository.NewOfflineRepository(app.database, app.logger)
+	app.statusEventRepo = repository.NewStatusEventRepository(app.database, app.logger)
+	app.inventoryRepo = repository.NewInventoryRepository(app.database, app.logger)
 
 	app.logger.Info("Repositories initialized successfully")
 	return nil
@@ -155,33 +181,88 @@ func (app *Application) initializeRepositories() error {
 
 // initializeDriverRegistry sets up device driver registry
 func (app *Application) initializeDriverRegistry() error {
-	app.driverRegistry = driver.NewRegistry(app.logger)
+	app.driverRegistry = driver.DefaultRegistry(app.logger)
+
+	// Apply centrally configured connection policy
+	connCfg := app.config.Device.Connection
+	app.driverRegistry.SetConnectionPolicy(toConnectionPolicy(connCfg.ConnectionPolicyConfig))
+	for brand := range connCfg.Drivers {
+		// Settings a brand leaves out follow the default policy
+		app.driverRegistry.SetBrandConnectionPolicy(
+			model.DeviceBrand(strings.ToUpper(brand)),
+			toConnectionPolicy(connCfg.BrandPolicy(brand)),
+		)
+	}
 
 	// Register all supported drivers
 	driver.RegisterDefaultDrivers(app.driverRegistry, app.logger)
 
+	// TLS files named in device configs stay inside the certificate directory
+	protocol.SetTLSCertDir(app.config.Device.TLSCertDir)
+
+	// Secret connection config values are decrypted only when a driver connects
+	if len(app.config.Security.SecretConfigKeys) > 0 {
+		model.SetSecretConfigKeys(app.config.Security.SecretConfigKeys)
+	}
+	if key := app.config.Security.ConfigEncryptionKey; key != "" {
+		configCipher, err := utils.NewConfigCipher(key)
+		if err != nil {
+			return fmt.Errorf("failed to initialize config encryption: %w", err)
+		}
+		app.configCipher = configCipher
+		app.driverRegistry.SetConfigCipher(configCipher)
+	} else {
+		app.logger.Warn("Config encryption key not set, secret connection config values are stored unencrypted")
+	}
+
+	// Live drivers are shared by device and operation services
+	breakerCfg := app.config.Device.CircuitBreaker
+	app.driverPool = driver.NewPool(app.driverRegistry, app.logger)
+	app.driverPool.SetCircuitBreaker(breakerCfg.FailureThreshold, breakerCfg.Cooldown)
+	poolCfg := app.config.Device.Pool
+	app.driverPool.SetLimits(poolCfg.MaxIdlePerDevice, poolCfg.IdleTTL, poolCfg.MaxOpen)
+
 	app.logger.Info("Driver registry initialized successfully",
 		zap.Int("registered_drivers", len(app.driverRegistry.ListDrivers())),
 	)
 	return nil
 }
 
+// toConnectionPolicy converts connection policy configuration to a driver policy
+func toConnectionPolicy(cfg config.ConnectionPolicyConfig) pkgdriver.ConnectionPolicy {
+	return pkgdriver.ConnectionPolicy{
+		ConnectTimeout:    cfg.ConnectTimeout,
+		MaxRetries:        cfg.MaxRetries,
+		InitialBackoff:    cfg.InitialBackoff,
+		MaxBackoff:        cfg.MaxBackoff,
+		BackoffMultiplier: cfg.BackoffMultiplier,
+		BackoffJitter:     cfg.BackoffJitter,
+		PingTimeout:       cfg.PingTimeout,
+		ConnectStrategy:   pkgdriver.ConnectStrategy(cfg.ConnectStrategy),
+	}
+}
+
 // initializeServices creates service instances
 func (app *Application) initializeServices() error {
 	// Create device service
 	app.deviceService = service.NewDeviceService(
 		app.deviceRepo,
 		app.operationRepo,
+		app.statusEventRepo,
+		app.inventoryRepo,
 		app.driverRegistry,
+		app.driverPool,
 		app.config,
 		app.logger,
 	)
+	app.deviceService.SetConfigCipher(app.configCipher)
 
 	// Create operation service
 	app.operationService = service.NewOperationService(
 		app.operationRepo,
 		app.deviceRepo,
 		app.driverRegistry,
+		app.driverPool,
 		app.config,
 		app.logger,
 	)
@@ -210,6 +291,9 @@ func (app *Application) initializeServer() error {
 		app.deviceService,
 		app.operationService,
 		app.discoveryService,
+		app.driverRegistry,
+		app.driverPool,
+		app.background,
 	)
 
 	// Setup router with all routes
@@ -235,120 +319,98 @@ func (app *Application) initializeServer() error {
 // startBackgroundServices starts background services
 func (app *Application) startBackgroundServices() {
 	// Start device health monitoring
-	//go app.startDeviceHealthMonitoring()
+	//app.background.Start("device_health_monitoring", app.config.Device.HealthCheckInterval, 30*time.Second, app.runDeviceHealthMonitoring)
 
 	// Start offline operation sync
-	go app.startOfflineSync()
-
-	// Start cleanup service
-	go app.startCleanupService()
-
-	app.logger.Info("Background services started")
-}
+	app.background.Start("offline_sync", app.config.Offline.SyncInterval, 60*time.Second, app.runOfflineSync)
 
-// startDeviceHealthMonitoring starts device health monitoring
-func (app *Application) startDeviceHealthMonitoring() {
-	ticker := time.NewTicker(app.config.Device.HealthCheckInterval)
-	defer ticker.Stop()
+	// Start cleanup service (every hour)
+	app.background.Start("cleanup", 1*time.Hour, 10*time.Minute, app.runCleanup)
 
-	app.logger.Info("Device health monitoring started",
-		zap.Duration("interval", app.config.Device.HealthCheckInterval),
-	)
+	// Close driver connections that stayed idle too long
+	if poolCfg := app.config.Device.Pool; poolCfg.IdleTTL > 0 && poolCfg.EvictionInterval > 0 {
+		app.background.Start("driver_pool_eviction", poolCfg.EvictionInterval, 30*time.Second, app.runPoolEviction)
+	}
 
-	for range ticker.C {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Close drivers of devices deleted or taken offline out of band
+	if interval := app.config.Device.Pool.ReconcileInterval; interval > 0 {
+		app.background.Start("driver_pool_reconciliation", interval, time.Minute, app.runPoolReconciliation)
+	}
 
-		// Get all online devices
-		devices, err := app.deviceRepo.ListByStatus(ctx, model.DeviceStatusOnline)
-		if err != nil {
-			app.logger.Error("Failed to get online devices for health check", zap.Error(err))
-			cancel()
-			continue
-		}
+	app.logger.Info("Background services started")
+}
 
-		// Check health of each device
-		for _, device := range devices {
-			go app.checkDeviceHealth(ctx, device)
-		}
+// runDeviceHealthMonitoring checks health of all online devices
+func (app *Application) runDeviceHealthMonitoring(ctx context.Context) error {
+	// Get all online devices
+	devices, err := app.deviceRepo.ListByStatus(ctx, model.DeviceStatusOnline)
+	if err != nil {
+		return fmt.Errorf("failed to get online devices for health check: %w", err)
+	}
 
-		cancel()
+	// Check health of each device
+	var wg sync.WaitGroup
+	for _, device := range devices {
+		wg.Add(1)
+		go func(device *model.Device) {
+			defer wg.Done()
+			defer utils.RecoverPanic(app.logger, zap.String("goroutine", "check_device_health"), zap.String("device_id", device.DeviceID))
+			app.checkDeviceHealth(ctx, device)
+		}(device)
 	}
+	wg.Wait()
+
+	return nil
 }
 
 // checkDeviceHealth checks health of a single device
 func (app *Application) checkDeviceHealth(ctx context.Context, device *model.Device) {
-	// Create driver instance
-	driverInstance, err := app.driverRegistry.CreateDriver(device, device.ConnectionConfig)
-	if err != nil {
-		app.logger.Error("Failed to create driver for health check",
-			zap.Error(err),
-			zap.String("device_id", device.DeviceID),
-		)
-		return
-	}
-
-	// Ping device
-	startTime := time.Now()
-	err = driverInstance.Ping(ctx)
-	responseTime := time.Since(startTime)
+	app.deviceService.CheckDeviceHealth(ctx, device)
+}
 
-	// Update device health
+// runOfflineSync synchronizes pending offline operations
+func (app *Application) runOfflineSync(ctx context.Context) error {
+	// Dead-letter operations queued too long to still be relevant, so they
+	// are never synced. A failure doesn't hold up the sync; operations past
+	// the max queue age are left for the next cycle to expire.
+	expired, err := app.offlineRepo.ExpireStale(ctx, app.config.Offline.MaxQueueAge)
 	if err != nil {
-		// Device is not responding
-		app.deviceRepo.UpdateStatus(ctx, device.ID, model.DeviceStatusError)
-		app.logger.Warn("Device health check failed",
-			zap.String("device_id", device.DeviceID),
-			zap.Error(err),
-		)
+		app.logger.Error("Failed to expire stale offline operations", zap.Error(err))
 	} else {
-		// Device is healthy
-		app.deviceRepo.UpdateLastPing(ctx, device.ID, time.Now())
-
-		// Create health log
-		health := &model.DeviceHealth{
-			DeviceID:    device.ID,
-			HealthScore: 100,
-			RecordedAt:  time.Now(),
-		}
-
-		responseTimeMs := int(responseTime.Milliseconds())
-		health.ResponseTime = &responseTimeMs
-
-		app.deviceRepo.CreateHealthLog(ctx, health)
+		app.operationService.ReportExpiredOfflineOperations(ctx, expired)
 	}
-}
-
-// startOfflineSync starts offline operation synchronization
-func (app *Application) startOfflineSync() {
-	ticker := time.NewTicker(app.config.Offline.SyncInterval)
-	defer ticker.Stop()
-
-	app.logger.Info("Offline sync started",
-		zap.Duration("interval", app.config.Offline.SyncInterval),
-	)
-
-	for range ticker.C {
-		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 
-		// Get pending offline operations
-		operations, err := app.offlineRepo.GetPendingOperations(ctx, app.config.Offline.RetryAttempts)
-		if err != nil {
-			app.logger.Error("Failed to get pending offline operations", zap.Error(err))
-			cancel()
-			continue
-		}
-
-		// Process each operation
-		for _, operation := range operations {
-			go app.syncOfflineOperation(ctx, operation)
-		}
+	// Claim pending offline operations; ones still claimed by an earlier,
+	// slower cycle are left to it
+	operations, err := app.offlineRepo.ClaimPendingOperations(ctx, app.config.Offline.RetryAttempts, app.config.Offline.ClaimTTL)
+	if err != nil {
+		return fmt.Errorf("failed to claim pending offline operations: %w", err)
+	}
 
-		cancel()
+	// Process each operation
+	var wg sync.WaitGroup
+	for _, operation := range operations {
+		wg.Add(1)
+		go func(operation *model.OfflineOperation) {
+			defer wg.Done()
+			defer utils.RecoverPanic(app.logger, zap.String("goroutine", "sync_offline_operation"), zap.String("operation_id", operation.ID.String()))
+			app.syncOfflineOperation(ctx, operation)
+		}(operation)
 	}
+	wg.Wait()
+
+	return nil
 }
 
 // syncOfflineOperation syncs a single offline operation
 func (app *Application) syncOfflineOperation(ctx context.Context, operation *model.OfflineOperation) {
+	// Never sync an operation past the max queue age, should expiring it
+	// have failed; released, it is dead-lettered by the next cycle
+	if maxAge := app.config.Offline.MaxQueueAge; maxAge > 0 && time.Since(operation.CreatedAt) > maxAge {
+		app.releaseOfflineClaim(ctx, operation)
+		return
+	}
+
 	// Get device
 	device, err := app.deviceRepo.GetByID(ctx, operation.DeviceID)
 	if err != nil {
@@ -361,7 +423,9 @@ func (app *Application) syncOfflineOperation(ctx context.Context, operation *mod
 
 	// Check if device is online
 	if device.Status != model.DeviceStatusOnline {
-		return // Skip if device is not online
+		// Skip if device is not online; the next cycle may retry right away
+		app.releaseOfflineClaim(ctx, operation)
+		return
 	}
 
 	// Create operation request
@@ -373,7 +437,7 @@ func (app *Application) syncOfflineOperation(ctx context.Context, operation *mod
 	}
 
 	// Execute operation
-	_, err = app.operationService.ExecuteOperation(ctx, operationReq)
+	_, err = app.operationService.ExecuteOperation(model.WithOperationSource(ctx, model.OperationSourceOfflineSync), operationReq)
 	if err != nil {
 		// Mark as failed
 		app.offlineRepo.MarkFailed(ctx, operation.ID, operation.SyncAttempts+1)
@@ -390,35 +454,65 @@ func (app *Application) syncOfflineOperation(ctx context.Context, operation *mod
 	}
 }
 
-// startCleanupService starts cleanup service for old records
-func (app *Application) startCleanupService() {
-	// Run cleanup every hour
-	ticker := time.NewTicker(1 * time.Hour)
-	defer ticker.Stop()
+// releaseOfflineClaim releases this cycle's claim on an offline operation it
+// didn't sync
+func (app *Application) releaseOfflineClaim(ctx context.Context, operation *model.OfflineOperation) {
+	if err := app.offlineRepo.ReleaseClaim(ctx, operation.ID); err != nil {
+		app.logger.Warn("Failed to release offline operation claim",
+			zap.Error(err),
+			zap.String("operation_id", operation.ID.String()),
+		)
+	}
+}
+
+// runCleanup removes old operations and expired offline operations
+func (app *Application) runCleanup(ctx context.Context) error {
+	var errs []error
 
-	app.logger.Info("Cleanup service started")
+	// Cleanup old operations (30 days)
+	oldDate := time.Now().AddDate(0, 0, -30)
+	deletedOps, err := app.operationRepo.DeleteOldOperations(ctx, oldDate)
+	if err != nil {
+		app.logger.Error("Failed to cleanup old operations", zap.Error(err))
+		errs = append(errs, err)
+	} else if deletedOps > 0 {
+		app.logger.Info("Cleaned up old operations", zap.Int64("deleted", deletedOps))
+	}
 
-	for range ticker.C {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	// Cleanup expired offline operations, keeping dead letters for the max queue age
+	deletedOffline, err := app.offlineRepo.DeleteExpired(ctx, app.config.Offline.MaxQueueAge)
+	if err != nil {
+		app.logger.Error("Failed to cleanup expired offline operations", zap.Error(err))
+		errs = append(errs, err)
+	} else if deletedOffline > 0 {
+		app.logger.Info("Cleaned up expired offline operations", zap.Int64("deleted", deletedOffline))
+	}
 
-		// Cleanup old operations (30 days)
-		oldDate := time.Now().AddDate(0, 0, -30)
-		deletedOps, err := app.operationRepo.DeleteOldOperations(ctx, oldDate)
-		if err != nil {
-			app.logger.Error("Failed to cleanup old operations", zap.Error(err))
-		} else if deletedOps > 0 {
-			app.logger.Info("Cleaned up old operations", zap.Int64("deleted", deletedOps))
-		}
+	return errors.Join(errs...)
+}
 
-		// Cleanup expired offline operations
-		deletedOffline, err := app.offlineRepo.DeleteExpired(ctx)
-		if err != nil {
-			app.logger.Error("Failed to cleanup expired offline operations", zap.Error(err))
-		} else if deletedOffline > 0 {
-			app.logger.Info("Cleaned up expired offline operations", zap.Int64("deleted", deletedOffline))
-		}
+// runPoolEviction evicts idle drivers from the driver pool
+func (app *Application) runPoolEviction(ctx context.Context) error {
+	if evicted := app.driverPool.EvictIdle(); evicted > 0 {
+		app.logger.Info("Evicted idle drivers", zap.Int("evicted", evicted))
+	}
+	return nil
+}
+
+// runPoolReconciliation closes cached drivers of devices that are no longer active
+func (app *Application) runPoolReconciliation(ctx context.Context) error {
+	_, err := app.deviceService.ReconcileDriverPool(ctx)
+	return err
+}
+
+// recoverInterruptedOperations settles operations a previous run left in
+// PENDING or PROCESSING
+func (app *Application) recoverInterruptedOperations() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
-		cancel()
+	if _, err := app.operationService.RecoverInterruptedOperations(ctx, time.Now()); err != nil {
+		app.logger.Error("Failed to recover interrupted operations", zap.Error(err))
 	}
 }
 
@@ -441,6 +535,10 @@ func (app *Application) shutdown() {
 	serviceLogger := utils.NewServiceLogger(app.logger, "device-service")
 	serviceLogger.LogServiceStop("shutdown signal received")
 
+	// Stop background services
+	app.background.Stop()
+	app.logger.Info("Background services stopped")
+
 	// Shutdown HTTP server
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -451,6 +549,11 @@ func (app *Application) shutdown() {
 		app.logger.Info("HTTP server stopped")
 	}
 
+	// Export the spans of the last requests
+	if err := app.shutdownTracing(ctx); err != nil {
+		app.logger.Error("Tracing shutdown error", zap.Error(err))
+	}
+
 	// Close database connection
 	if app.database != nil {
 		if err := app.database.Close(); err != nil {
@@ -469,8 +572,13 @@ func (app *Application) shutdown() {
 }
 
 func (app *Application) Start() error {
+	// Settle operations the previous run left unfinished before new ones arrive
+	app.recoverInterruptedOperations()
+
 	// Start server in goroutine
 	go func() {
+		defer utils.RecoverPanic(app.logger, zap.String("goroutine", "http_server"))
+
 		app.logger.Info("Starting HTTP server",
 			zap.String("address", app.server.Addr),
 		)
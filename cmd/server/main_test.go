@@ -0,0 +1,70 @@
+// cmd/server/main_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"device-service/internal/config"
+	"device-service/internal/model"
+	"device-service/internal/repository"
+)
+
+// fakeOfflineRepository fails expiry and hands out fixed operations to claim
+type fakeOfflineRepository struct {
+	repository.OfflineRepository
+
+	pending  []*model.OfflineOperation
+	mu       sync.Mutex
+	claimed  bool
+	released []uuid.UUID
+}
+
+func (r *fakeOfflineRepository) ExpireStale(ctx context.Context, maxAge time.Duration) ([]*model.OfflineOperation, error) {
+	return nil, errors.New("database unavailable")
+}
+
+func (r *fakeOfflineRepository) ClaimPendingOperations(ctx context.Context, maxAttempts int, claimTTL time.Duration) ([]*model.OfflineOperation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.claimed = true
+	return r.pending, nil
+}
+
+func (r *fakeOfflineRepository) ReleaseClaim(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.released = append(r.released, id)
+	return nil
+}
+
+func TestRunOfflineSyncContinuesWhenExpiryFails(t *testing.T) {
+	stale := &model.OfflineOperation{
+		ID:        uuid.New(),
+		DeviceID:  uuid.New(),
+		CreatedAt: time.Now().Add(-2 * time.Hour),
+	}
+	repo := &fakeOfflineRepository{pending: []*model.OfflineOperation{stale}}
+	app := &Application{
+		config:      &config.Config{Offline: config.OfflineConfig{MaxQueueAge: time.Hour}},
+		logger:      zap.NewNop(),
+		offlineRepo: repo,
+	}
+
+	if err := app.runOfflineSync(context.Background()); err != nil {
+		t.Fatalf("runOfflineSync() error = %v", err)
+	}
+	if !repo.claimed {
+		t.Fatal("pending operations were not claimed after expiry failed")
+	}
+	// The stale operation is released for the next expiry, not synced
+	if len(repo.released) != 1 || repo.released[0] != stale.ID {
+		t.Errorf("released claims = %v, want %v", repo.released, stale.ID)
+	}
+}
@@ -3,6 +3,7 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
 	"strings"
 	"time"
 
@@ -18,18 +19,67 @@ type Config struct {
 	Offline  OfflineConfig  `mapstructure:"offline"`
 	Security SecurityConfig `mapstructure:"security"`
 	Logging  LoggingConfig  `mapstructure:"logging"`
+	Tracing  TracingConfig  `mapstructure:"tracing"`
 	Device   DeviceConfig   `mapstructure:"device"`
 	App      AppConfig      `mapstructure:"app"`
 }
 
 // ServerConfig represents HTTP server configuration
 type ServerConfig struct {
-	Host         string        `mapstructure:"host" validate:"required"`
-	Port         string        `mapstructure:"port" validate:"required"`
-	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
-	WriteTimeout time.Duration `mapstructure:"write_timeout"`
-	IdleTimeout  time.Duration `mapstructure:"idle_timeout"`
-	TLS          TLSConfig     `mapstructure:"tls"`
+	Host           string          `mapstructure:"host" validate:"required"`
+	Port           string          `mapstructure:"port" validate:"required"`
+	ReadTimeout    time.Duration   `mapstructure:"read_timeout"`
+	WriteTimeout   time.Duration   `mapstructure:"write_timeout"`
+	IdleTimeout    time.Duration   `mapstructure:"idle_timeout"`
+	RequestTimeout time.Duration   `mapstructure:"request_timeout"` // API request deadline, above every operation timeout and below write_timeout so the 504 still reaches the client; 0 disables it
+	TLS            TLSConfig       `mapstructure:"tls"`
+	WebSocket      WebSocketConfig `mapstructure:"websocket"`
+	MsgPackEnabled bool            `mapstructure:"msgpack_enabled"` // serve msgpack to API clients sending Accept: application/msgpack
+	BodyLimit      BodyLimitConfig `mapstructure:"body_limit"`
+
+	FieldMapping FieldMappingConfig `mapstructure:"field_mapping"`
+}
+
+// BodyLimitConfig bounds request body sizes. Routes listed in LargeRoutes
+// (route patterns, e.g. /api/v1/devices/import) get LargeBytes, every other
+// route DefaultBytes.
+type BodyLimitConfig struct {
+	DefaultBytes int64    `mapstructure:"default_bytes"`
+	LargeBytes   int64    `mapstructure:"large_bytes"`
+	LargeRoutes  []string `mapstructure:"large_routes"`
+}
+
+// FieldMappingConfig renames operation data fields sent by legacy clients to
+// their canonical names before the request is validated. Clients identify
+// themselves with ClientHeader.
+type FieldMappingConfig struct {
+	ClientHeader string             `mapstructure:"client_header"`
+	Rules        []FieldMappingRule `mapstructure:"rules"`
+}
+
+// FieldMappingRule renames fields of one client's requests. An empty client
+// matches every client and empty routes match every API route. Routes are
+// route patterns, e.g. /api/v1/devices/:device_id/print.
+type FieldMappingRule struct {
+	Client string        `mapstructure:"client"`
+	Routes []string      `mapstructure:"routes"`
+	Fields []FieldRename `mapstructure:"fields"`
+}
+
+// FieldRename maps a legacy field name to its canonical name. Names are
+// kept as a list rather than a map because config keys lose their case.
+type FieldRename struct {
+	From string `mapstructure:"from"`
+	To   string `mapstructure:"to"`
+}
+
+// WebSocketConfig represents WebSocket keep-alive configuration. A client
+// that stays silent, pongs included, for PongTimeout is disconnected, so
+// PingInterval must be shorter.
+type WebSocketConfig struct {
+	PingInterval   time.Duration `mapstructure:"ping_interval"`
+	PongTimeout    time.Duration `mapstructure:"pong_timeout"`
+	AllowedOrigins []string      `mapstructure:"allowed_origins"` // browser origins allowed to connect; empty allows the service's own host only
 }
 
 // TLSConfig represents TLS configuration
@@ -79,18 +129,26 @@ type OfflineConfig struct {
 	MaxQueueSize  int           `mapstructure:"max_queue_size"`
 	RetryAttempts int           `mapstructure:"retry_attempts"`
 	RetryDelay    time.Duration `mapstructure:"retry_delay"`
+	ClaimTTL      time.Duration `mapstructure:"claim_ttl"`     // how long a sync holds an operation before another may retry it
+	MaxQueueAge   time.Duration `mapstructure:"max_queue_age"` // older pending operations are dead-lettered instead of synced; 0 disables
 }
 
 // SecurityConfig represents security configuration
 type SecurityConfig struct {
 	JWTSecret          string        `mapstructure:"jwt_secret" validate:"required"`
 	JWTExpiration      time.Duration `mapstructure:"jwt_expiration"`
+	AuthEnabled        bool          `mapstructure:"auth_enabled"` // require bearer tokens on /api/v1
 	DeviceAuthRequired bool          `mapstructure:"device_auth_required"`
 	CertValidation     bool          `mapstructure:"cert_validation"`
 	AllowedOrigins     []string      `mapstructure:"allowed_origins"`
 	RateLimitEnabled   bool          `mapstructure:"rate_limit_enabled"`
 	RateLimitRequests  int           `mapstructure:"rate_limit_requests"`
 	RateLimitWindow    time.Duration `mapstructure:"rate_limit_window"`
+
+	// ConfigEncryptionKey encrypts secret connection config values at rest;
+	// when empty, secrets are stored as given but still redacted in responses
+	ConfigEncryptionKey string   `mapstructure:"config_encryption_key"`
+	SecretConfigKeys    []string `mapstructure:"secret_config_keys"`
 }
 
 // LoggingConfig represents logging configuration
@@ -102,18 +160,197 @@ type LoggingConfig struct {
 	MaxBackups int    `mapstructure:"max_backups"`
 	MaxAge     int    `mapstructure:"max_age"`
 	Compress   bool   `mapstructure:"compress"`
+
+	BodyCapture BodyCaptureConfig `mapstructure:"body_capture"`
+}
+
+// BodyCaptureConfig controls request/response body logging. It is meant for
+// debugging incidents and is disabled by default.
+type BodyCaptureConfig struct {
+	Enabled      bool     `mapstructure:"enabled"`
+	MaxBodyBytes int      `mapstructure:"max_body_bytes"`
+	RedactFields []string `mapstructure:"redact_fields"`
+}
+
+// TracingConfig configures OpenTelemetry tracing. Spans are exported to an
+// OTLP/HTTP collector and the trace context is taken from incoming W3C
+// traceparent headers.
+type TracingConfig struct {
+	Enabled     bool              `mapstructure:"enabled"`
+	ServiceName string            `mapstructure:"service_name"`
+	Endpoint    string            `mapstructure:"endpoint"` // collector host:port
+	Insecure    bool              `mapstructure:"insecure"` // plain HTTP to the collector
+	Headers     map[string]string `mapstructure:"headers"`  // sent with every export, e.g. collector credentials
+	// SampleRatio is the fraction of new traces recorded. Traces started by a
+	// sampled caller are always recorded.
+	SampleRatio   float64       `mapstructure:"sample_ratio"`
+	ExportTimeout time.Duration `mapstructure:"export_timeout"`
 }
 
 // DeviceConfig represents device-specific configuration
 type DeviceConfig struct {
-	DiscoveryInterval   time.Duration    `mapstructure:"discovery_interval"`
-	HealthCheckInterval time.Duration    `mapstructure:"health_check_interval"`
-	PingInterval        time.Duration    `mapstructure:"ping_interval"`
-	OperationTimeout    time.Duration    `mapstructure:"operation_timeout"`
-	MaxRetryAttempts    int              `mapstructure:"max_retry_attempts"`
-	RetryDelay          time.Duration    `mapstructure:"retry_delay"`
-	SupportedBrands     []string         `mapstructure:"supported_brands"`
-	DefaultPort         DevicePortConfig `mapstructure:"default_ports"`
+	DiscoveryInterval      time.Duration          `mapstructure:"discovery_interval"`
+	HealthCheckInterval    time.Duration          `mapstructure:"health_check_interval"`
+	HeartbeatTimeout       time.Duration          `mapstructure:"heartbeat_timeout"` // active polling resumes after this long without an agent heartbeat
+	PingInterval           time.Duration          `mapstructure:"ping_interval"`
+	OperationTimeout       time.Duration          `mapstructure:"operation_timeout"`
+	OperationTimeouts      OperationTimeoutMatrix `mapstructure:"operation_timeouts"`
+	MaxQueueDepth          int                    `mapstructure:"max_queue_depth"`    // operations waiting per device, 0 means unbounded
+	PrintDedupWindow       time.Duration          `mapstructure:"print_dedup_window"` // identical print requests to a device within it are printed once, 0 disables
+	MaxRetryAttempts       int                    `mapstructure:"max_retry_attempts"`
+	RetryDelay             time.Duration          `mapstructure:"retry_delay"`
+	SupportedBrands        []string               `mapstructure:"supported_brands"`
+	AutoSetupMinConfidence float64                `mapstructure:"auto_setup_min_confidence"` // discovered devices below this are not auto-registered unless the request's min_confidence lowers it
+	DefaultPort            DevicePortConfig       `mapstructure:"default_ports"`
+	Connection             ConnectionConfig       `mapstructure:"connection"`
+	CircuitBreaker         CircuitBreakerConfig   `mapstructure:"circuit_breaker"`
+	ErrorRateAnomaly       ErrorRateAnomalyConfig `mapstructure:"error_rate_anomaly"`
+	Lock                   DeviceLockConfig       `mapstructure:"lock"`
+	ScanSession            ScanSessionConfig      `mapstructure:"scan_session"`
+	Pool                   DriverPoolConfig       `mapstructure:"pool"`
+	Discovery              DiscoveryConfig        `mapstructure:"discovery"`
+	TLSCertDir             string                 `mapstructure:"tls_cert_dir"` // TLS files of device configs are read from it, empty rejects them
+}
+
+// OperationTimeoutMatrix holds operation timeouts keyed by lower case
+// connection type (serial, usb, tcp, bluetooth) and operation type. The
+// "default" connection key applies to every connection type and the "default"
+// operation key to the other operations of its connection type. An operation
+// runs with the first timeout found for its connection type and operation
+// type, the default connection and operation type, its connection type's
+// default, then device.operation_timeout.
+type OperationTimeoutMatrix map[string]map[string]time.Duration
+
+// DiscoveryConfig represents network discovery scan configuration. The TCP
+// scanner probes every host of the network ranges on each port.
+type DiscoveryConfig struct {
+	NetworkRanges  []string      `mapstructure:"network_ranges"` // CIDR blocks, at most 65536 hosts in total
+	Ports          []int         `mapstructure:"ports"`
+	Concurrency    int           `mapstructure:"concurrency"` // probes in flight at once
+	ConnectTimeout time.Duration `mapstructure:"connect_timeout"`
+	ScanTimeout    time.Duration `mapstructure:"scan_timeout"` // a scan running longer returns partial results
+}
+
+// DeviceLockConfig represents device reservation lease limits. A lease not
+// renewed within its TTL expires and frees the device.
+type DeviceLockConfig struct {
+	DefaultTTL time.Duration `mapstructure:"default_ttl"`
+	MaxTTL     time.Duration `mapstructure:"max_ttl"`
+}
+
+// ScanSessionConfig represents continuous scan session limits. A session
+// ends after the idle timeout passes without a scan, and in any case after
+// the max duration.
+type ScanSessionConfig struct {
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+	MaxDuration time.Duration `mapstructure:"max_duration"`
+}
+
+// DriverPoolConfig represents live driver pool limits. An idle TTL of zero
+// disables eviction and a max open of zero leaves the pool unbounded. A
+// reconcile interval of zero disables closing the drivers of devices that
+// were deleted or taken offline or into maintenance.
+type DriverPoolConfig struct {
+	MaxIdlePerDevice  int           `mapstructure:"max_idle_per_device"`
+	IdleTTL           time.Duration `mapstructure:"idle_ttl"`
+	MaxOpen           int           `mapstructure:"max_open"`
+	EvictionInterval  time.Duration `mapstructure:"eviction_interval"`
+	ReconcileInterval time.Duration `mapstructure:"reconcile_interval"`
+}
+
+// ErrorRateAnomalyConfig represents the early warning for a device whose
+// error rate rises sharply. The error rate over the last window is compared
+// with the rate before it; a rise of at least the threshold raises a warning,
+// even while the health score still looks fine. A threshold of zero disables
+// the detection.
+type ErrorRateAnomalyConfig struct {
+	Window        time.Duration `mapstructure:"window"`
+	Threshold     float64       `mapstructure:"threshold"`      // error rate increase, 0.0-1.0
+	MinOperations int64         `mapstructure:"min_operations"` // fewer operations in the window aren't judged
+}
+
+// CircuitBreakerConfig represents per-device circuit breaker configuration.
+// A failure threshold of zero disables the breaker.
+type CircuitBreakerConfig struct {
+	FailureThreshold int           `mapstructure:"failure_threshold"`
+	Cooldown         time.Duration `mapstructure:"cooldown"`
+}
+
+// ConnectionConfig represents driver connection policy configuration.
+// Drivers holds per-brand overrides keyed by brand name.
+type ConnectionConfig struct {
+	ConnectionPolicyConfig `mapstructure:",squash"`
+	Drivers                map[string]ConnectionPolicyOverride `mapstructure:"drivers"`
+}
+
+// BrandPolicy returns the connection policy of a brand: its override merged
+// field by field onto the default policy. Settings the override leaves out,
+// and a zero connect timeout, follow the default. Brands match regardless of
+// case, since viper lowercases the keys.
+func (c ConnectionConfig) BrandPolicy(brand string) ConnectionPolicyConfig {
+	policy := c.ConnectionPolicyConfig
+	var override ConnectionPolicyOverride
+	found := false
+	for key, value := range c.Drivers {
+		if strings.EqualFold(key, brand) {
+			override, found = value, true
+			break
+		}
+	}
+	if !found {
+		return policy
+	}
+
+	if override.ConnectTimeout != nil && *override.ConnectTimeout > 0 {
+		policy.ConnectTimeout = *override.ConnectTimeout
+	}
+	if override.MaxRetries != nil {
+		policy.MaxRetries = *override.MaxRetries
+	}
+	if override.InitialBackoff != nil {
+		policy.InitialBackoff = *override.InitialBackoff
+	}
+	if override.MaxBackoff != nil {
+		policy.MaxBackoff = *override.MaxBackoff
+	}
+	if override.BackoffMultiplier != nil {
+		policy.BackoffMultiplier = *override.BackoffMultiplier
+	}
+	if override.BackoffJitter != nil {
+		policy.BackoffJitter = *override.BackoffJitter
+	}
+	if override.PingTimeout != nil {
+		policy.PingTimeout = *override.PingTimeout
+	}
+	if override.ConnectStrategy != nil && *override.ConnectStrategy != "" {
+		policy.ConnectStrategy = *override.ConnectStrategy
+	}
+	return policy
+}
+
+// ConnectionPolicyConfig represents connect and ping timeouts and retry/backoff settings
+type ConnectionPolicyConfig struct {
+	ConnectTimeout    time.Duration `mapstructure:"connect_timeout"`
+	MaxRetries        int           `mapstructure:"max_retries"`
+	InitialBackoff    time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff        time.Duration `mapstructure:"max_backoff"`
+	BackoffMultiplier float64       `mapstructure:"backoff_multiplier"`
+	BackoffJitter     float64       `mapstructure:"backoff_jitter"`   // 0-1, spreads retries of devices that failed together
+	PingTimeout       time.Duration `mapstructure:"ping_timeout"`     // bounds a single health ping
+	ConnectStrategy   string        `mapstructure:"connect_strategy"` // eager (connect while creating the driver) or lazy (on first use)
+}
+
+// ConnectionPolicyOverride represents a brand's connection policy settings;
+// nil settings follow the default policy
+type ConnectionPolicyOverride struct {
+	ConnectTimeout    *time.Duration `mapstructure:"connect_timeout"`
+	MaxRetries        *int           `mapstructure:"max_retries"`
+	InitialBackoff    *time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff        *time.Duration `mapstructure:"max_backoff"`
+	BackoffMultiplier *float64       `mapstructure:"backoff_multiplier"`
+	BackoffJitter     *float64       `mapstructure:"backoff_jitter"`
+	PingTimeout       *time.Duration `mapstructure:"ping_timeout"`
+	ConnectStrategy   *string        `mapstructure:"connect_strategy"`
 }
 
 // DevicePortConfig represents default port configurations
@@ -203,9 +440,18 @@ func setDefaults() {
 	viper.SetDefault("server.host", "0.0.0.0")
 	viper.SetDefault("server.port", "8084")
 	viper.SetDefault("server.read_timeout", "30s")
-	viper.SetDefault("server.write_timeout", "30s")
+	viper.SetDefault("server.write_timeout", "130s")
 	viper.SetDefault("server.idle_timeout", "120s")
+	viper.SetDefault("server.request_timeout", "120s")
 	viper.SetDefault("server.tls.enabled", false)
+	viper.SetDefault("server.websocket.ping_interval", "54s")
+	viper.SetDefault("server.websocket.pong_timeout", "60s")
+	viper.SetDefault("server.websocket.allowed_origins", []string{})
+	viper.SetDefault("server.msgpack_enabled", true)
+	viper.SetDefault("server.body_limit.default_bytes", 1<<20)
+	viper.SetDefault("server.body_limit.large_bytes", 16<<20)
+	viper.SetDefault("server.body_limit.large_routes", []string{"/api/v1/devices/import"})
+	viper.SetDefault("server.field_mapping.client_header", "X-Client-ID")
 
 	// Database defaults
 	viper.SetDefault("database.host", "localhost")
@@ -239,14 +485,20 @@ func setDefaults() {
 	viper.SetDefault("offline.max_queue_size", 10000)
 	viper.SetDefault("offline.retry_attempts", 3)
 	viper.SetDefault("offline.retry_delay", "5s")
+	viper.SetDefault("offline.claim_ttl", "5m")
+	viper.SetDefault("offline.max_queue_age", "24h")
 
 	// Security defaults
 	viper.SetDefault("security.jwt_expiration", "24h")
+	viper.SetDefault("security.auth_enabled", false)
 	viper.SetDefault("security.device_auth_required", true)
 	viper.SetDefault("security.cert_validation", true)
 	viper.SetDefault("security.rate_limit_enabled", true)
 	viper.SetDefault("security.rate_limit_requests", 100)
 	viper.SetDefault("security.rate_limit_window", "1m")
+	viper.SetDefault("security.secret_config_keys", []string{
+		"password", "secret", "token", "api_key", "private_key", "pin", "credentials", "passphrase",
+	})
 
 	// Logging defaults
 	viper.SetDefault("logging.level", "info")
@@ -256,14 +508,42 @@ func setDefaults() {
 	viper.SetDefault("logging.max_backups", 3)
 	viper.SetDefault("logging.max_age", 28)
 	viper.SetDefault("logging.compress", true)
+	viper.SetDefault("logging.body_capture.enabled", false)
+	viper.SetDefault("logging.body_capture.max_body_bytes", 4096)
+	viper.SetDefault("logging.body_capture.redact_fields", []string{
+		"password", "secret", "token", "authorization", "api_key",
+		"card_number", "pan", "cvv", "pin", "track_data",
+	})
+
+	// Tracing defaults
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.service_name", "device-service")
+	viper.SetDefault("tracing.endpoint", "localhost:4318")
+	viper.SetDefault("tracing.insecure", true)
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+	viper.SetDefault("tracing.export_timeout", "10s")
 
 	// Device defaults
 	viper.SetDefault("device.discovery_interval", "60s")
 	viper.SetDefault("device.health_check_interval", "10s")
+	viper.SetDefault("device.heartbeat_timeout", "30s")
 	viper.SetDefault("device.ping_interval", "5s")
 	viper.SetDefault("device.operation_timeout", "30s")
+	viper.SetDefault("device.operation_timeouts.default.payment", "60s")
+	viper.SetDefault("device.operation_timeouts.default.print", "30s")
+	viper.SetDefault("device.operation_timeouts.default.scan", "30s")
+	viper.SetDefault("device.operation_timeouts.usb.print", "10s")
+	viper.SetDefault("device.operation_timeouts.usb.default", "10s")
+	viper.SetDefault("device.operation_timeouts.serial.print", "20s")
+	viper.SetDefault("device.operation_timeouts.tcp.payment", "90s")
+	viper.SetDefault("device.operation_timeouts.bluetooth.print", "45s")
+	viper.SetDefault("device.operation_timeouts.bluetooth.default", "45s")
+	viper.SetDefault("device.max_queue_depth", 50)
+	viper.SetDefault("device.print_dedup_window", "0s")
 	viper.SetDefault("device.max_retry_attempts", 3)
 	viper.SetDefault("device.retry_delay", "2s")
+	viper.SetDefault("device.auto_setup_min_confidence", 0.6)
+	viper.SetDefault("device.tls_cert_dir", "")
 	viper.SetDefault("device.supported_brands", []string{
 		"EPSON", "STAR", "INGENICO", "PAX", "CITIZEN", "BIXOLON", "VERIFONE", "GENERIC",
 	})
@@ -286,6 +566,35 @@ func setDefaults() {
 	viper.SetDefault("device.default_ports.bluetooth.scan_timeout", "30s")
 	viper.SetDefault("device.default_ports.bluetooth.connect_timeout", "20s")
 
+	// Driver connection policy defaults
+	viper.SetDefault("device.connection.connect_timeout", "15s")
+	viper.SetDefault("device.connection.max_retries", 2)
+	viper.SetDefault("device.connection.initial_backoff", "1s")
+	viper.SetDefault("device.connection.max_backoff", "10s")
+	viper.SetDefault("device.connection.backoff_multiplier", 2.0)
+	viper.SetDefault("device.connection.backoff_jitter", 0.5)
+	viper.SetDefault("device.connection.ping_timeout", "3s")
+	viper.SetDefault("device.connection.connect_strategy", "eager")
+	viper.SetDefault("device.circuit_breaker.failure_threshold", 5)
+	viper.SetDefault("device.circuit_breaker.cooldown", "30s")
+	viper.SetDefault("device.error_rate_anomaly.window", "5m")
+	viper.SetDefault("device.error_rate_anomaly.threshold", 0.2)
+	viper.SetDefault("device.error_rate_anomaly.min_operations", 10)
+	viper.SetDefault("device.lock.default_ttl", "60s")
+	viper.SetDefault("device.lock.max_ttl", "15m")
+	viper.SetDefault("device.scan_session.idle_timeout", "60s")
+	viper.SetDefault("device.scan_session.max_duration", "30m")
+	viper.SetDefault("device.pool.max_idle_per_device", 1)
+	viper.SetDefault("device.pool.idle_ttl", "0s")
+	viper.SetDefault("device.pool.max_open", 0)
+	viper.SetDefault("device.pool.eviction_interval", "1m")
+	viper.SetDefault("device.pool.reconcile_interval", "5m")
+	viper.SetDefault("device.discovery.network_ranges", []string{"192.168.1.0/24", "10.0.0.0/24"})
+	viper.SetDefault("device.discovery.ports", []int{9100, 8080, 23, 80, 443})
+	viper.SetDefault("device.discovery.concurrency", 64)
+	viper.SetDefault("device.discovery.connect_timeout", "3s")
+	viper.SetDefault("device.discovery.scan_timeout", "60s")
+
 	// App defaults
 	viper.SetDefault("app.name", "device-service")
 	viper.SetDefault("app.version", "1.0.0")
@@ -312,6 +621,92 @@ func validate(config *Config) error {
 		return fmt.Errorf("app.app_id is required")
 	}
 
+	// Validate WebSocket keep-alive
+	ws := config.Server.WebSocket
+	if ws.PingInterval <= 0 || ws.PongTimeout <= 0 {
+		return fmt.Errorf("server.websocket.ping_interval and pong_timeout must be positive")
+	}
+	if ws.PingInterval >= ws.PongTimeout {
+		return fmt.Errorf("server.websocket.ping_interval (%s) must be less than pong_timeout (%s)", ws.PingInterval, ws.PongTimeout)
+	}
+
+	limit := config.Server.BodyLimit
+	if limit.DefaultBytes <= 0 || limit.LargeBytes < limit.DefaultBytes {
+		return fmt.Errorf("server.body_limit.default_bytes must be positive and at most large_bytes")
+	}
+
+	// An offline sync claim must outlive the longest operation (payments, 60s)
+	if config.Offline.ClaimTTL < time.Minute {
+		return fmt.Errorf("offline.claim_ttl must be at least 1m, got %s", config.Offline.ClaimTTL)
+	}
+	if config.Offline.MaxQueueAge < 0 {
+		return fmt.Errorf("offline.max_queue_age must not be negative, got %s", config.Offline.MaxQueueAge)
+	}
+
+	// Validate reconnect jitter
+	connection := config.Device.Connection
+	if connection.BackoffJitter < 0 || connection.BackoffJitter > 1 {
+		return fmt.Errorf("device.connection.backoff_jitter must be between 0 and 1, got %g", connection.BackoffJitter)
+	}
+	if !validConnectStrategy(connection.ConnectStrategy) {
+		return fmt.Errorf("device.connection.connect_strategy must be eager or lazy, got %q", connection.ConnectStrategy)
+	}
+	for brand := range connection.Drivers {
+		policy := connection.BrandPolicy(brand)
+		if policy.BackoffJitter < 0 || policy.BackoffJitter > 1 {
+			return fmt.Errorf("device.connection.drivers.%s.backoff_jitter must be between 0 and 1, got %g", brand, policy.BackoffJitter)
+		}
+		if !validConnectStrategy(policy.ConnectStrategy) {
+			return fmt.Errorf("device.connection.drivers.%s.connect_strategy must be eager or lazy, got %q", brand, policy.ConnectStrategy)
+		}
+	}
+
+	anomaly := config.Device.ErrorRateAnomaly
+	if anomaly.Threshold < 0 || anomaly.Threshold > 1 {
+		return fmt.Errorf("device.error_rate_anomaly.threshold must be between 0 and 1, got %g", anomaly.Threshold)
+	}
+	if anomaly.Threshold > 0 && anomaly.Window < config.Device.HealthCheckInterval {
+		return fmt.Errorf("device.error_rate_anomaly.window (%s) must be at least health_check_interval (%s)", anomaly.Window, config.Device.HealthCheckInterval)
+	}
+
+	lock := config.Device.Lock
+	if lock.DefaultTTL < time.Second || lock.MaxTTL < lock.DefaultTTL {
+		return fmt.Errorf("device.lock.default_ttl must be at least 1s and at most max_ttl")
+	}
+
+	scanSession := config.Device.ScanSession
+	if scanSession.IdleTimeout <= 0 || scanSession.MaxDuration < scanSession.IdleTimeout {
+		return fmt.Errorf("device.scan_session.idle_timeout must be positive and at most max_duration")
+	}
+
+	if config.Device.PrintDedupWindow < 0 {
+		return fmt.Errorf("device.print_dedup_window must not be negative, got %s", config.Device.PrintDedupWindow)
+	}
+
+	if err := validateOperationTimeouts(config.Device.OperationTimeouts); err != nil {
+		return err
+	}
+
+	if err := validateRequestTimeout(&config.Server, &config.Device); err != nil {
+		return err
+	}
+
+	if err := validateDiscovery(&config.Device.Discovery); err != nil {
+		return err
+	}
+
+	if err := validateFieldMapping(&config.Server.FieldMapping); err != nil {
+		return err
+	}
+
+	tracing := config.Tracing
+	if tracing.Enabled && tracing.Endpoint == "" {
+		return fmt.Errorf("tracing.endpoint is required when tracing is enabled")
+	}
+	if tracing.SampleRatio < 0 || tracing.SampleRatio > 1 {
+		return fmt.Errorf("tracing.sample_ratio must be between 0 and 1, got %g", tracing.SampleRatio)
+	}
+
 	// Validate environment
 	validEnvs := []string{"development", "staging", "production", "test"}
 	isValidEnv := false
@@ -341,6 +736,108 @@ func validate(config *Config) error {
 	return nil
 }
 
+// validConnectStrategy reports whether strategy names a driver connect strategy
+func validConnectStrategy(strategy string) bool {
+	return strategy == "eager" || strategy == "lazy"
+}
+
+// validateOperationTimeouts checks that the timeout matrix only names known
+// connection types and holds positive timeouts
+func validateOperationTimeouts(timeouts OperationTimeoutMatrix) error {
+	for connectionType, operations := range timeouts {
+		switch connectionType {
+		case "default", "serial", "usb", "tcp", "bluetooth":
+		default:
+			return fmt.Errorf("device.operation_timeouts: unknown connection type %q", connectionType)
+		}
+		for operationType, timeout := range operations {
+			if timeout <= 0 {
+				return fmt.Errorf("device.operation_timeouts.%s.%s must be positive, got %s", connectionType, operationType, timeout)
+			}
+		}
+	}
+	return nil
+}
+
+// validateRequestTimeout checks that the request deadline only catches stuck
+// handlers: an operation must hit its own timeout first, and the 504 must be
+// written before the server's write timeout
+func validateRequestTimeout(server *ServerConfig, device *DeviceConfig) error {
+	if server.RequestTimeout <= 0 {
+		return nil
+	}
+
+	longest := device.OperationTimeout
+	for _, operations := range device.OperationTimeouts {
+		for _, timeout := range operations {
+			longest = max(longest, timeout)
+		}
+	}
+	if server.RequestTimeout <= longest {
+		return fmt.Errorf("server.request_timeout (%s) must exceed the longest operation timeout (%s)", server.RequestTimeout, longest)
+	}
+	if server.WriteTimeout > 0 && server.RequestTimeout >= server.WriteTimeout {
+		return fmt.Errorf("server.request_timeout (%s) must be less than write_timeout (%s)", server.RequestTimeout, server.WriteTimeout)
+	}
+	return nil
+}
+
+// validateDiscovery checks the network discovery scan configuration
+func validateDiscovery(discovery *DiscoveryConfig) error {
+	hosts := 0
+	for _, cidr := range discovery.NetworkRanges {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return fmt.Errorf("device.discovery.network_ranges: invalid CIDR %q", cidr)
+		}
+		hostBits := prefix.Addr().BitLen() - prefix.Bits()
+		if hostBits > 16 {
+			return fmt.Errorf("device.discovery.network_ranges: %s is larger than a /16", cidr)
+		}
+		hosts += 1 << hostBits
+	}
+	if hosts > 1<<16 {
+		return fmt.Errorf("device.discovery.network_ranges cover %d hosts, at most 65536 allowed", hosts)
+	}
+
+	for _, port := range discovery.Ports {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("device.discovery.ports: invalid port %d", port)
+		}
+	}
+	if discovery.Concurrency < 1 {
+		return fmt.Errorf("device.discovery.concurrency must be at least 1")
+	}
+	if discovery.ConnectTimeout <= 0 || discovery.ScanTimeout <= 0 {
+		return fmt.Errorf("device.discovery.connect_timeout and scan_timeout must be positive")
+	}
+	return nil
+}
+
+// validateFieldMapping checks that every rename names both fields and that a
+// rule doesn't rename one field twice
+func validateFieldMapping(mapping *FieldMappingConfig) error {
+	if len(mapping.Rules) > 0 && mapping.ClientHeader == "" {
+		return fmt.Errorf("server.field_mapping.client_header is required when rules are set")
+	}
+	for i, rule := range mapping.Rules {
+		if len(rule.Fields) == 0 {
+			return fmt.Errorf("server.field_mapping.rules[%d] has no fields", i)
+		}
+		seen := make(map[string]bool, len(rule.Fields))
+		for _, rename := range rule.Fields {
+			if rename.From == "" || rename.To == "" {
+				return fmt.Errorf("server.field_mapping.rules[%d] fields need both from and to", i)
+			}
+			if seen[rename.From] {
+				return fmt.Errorf("server.field_mapping.rules[%d] renames %q twice", i, rename.From)
+			}
+			seen[rename.From] = true
+		}
+	}
+	return nil
+}
+
 // GetDatabaseDSN returns the database connection string
 func (c *Config) GetDatabaseDSN() string {
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
@@ -0,0 +1,170 @@
+// internal/config/config_test.go
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestConnectionConfigBrandPolicy(t *testing.T) {
+	defaults := ConnectionPolicyConfig{
+		ConnectTimeout:    15 * time.Second,
+		MaxRetries:        2,
+		InitialBackoff:    time.Second,
+		MaxBackoff:        10 * time.Second,
+		BackoffMultiplier: 2,
+		BackoffJitter:     0.5,
+		PingTimeout:       3 * time.Second,
+		ConnectStrategy:   "eager",
+	}
+
+	tests := []struct {
+		name     string
+		override *ConnectionPolicyOverride
+		want     func(*ConnectionPolicyConfig)
+	}{
+		{
+			name: "brand without override",
+		},
+		{
+			name:     "empty override keeps every default",
+			override: &ConnectionPolicyOverride{},
+		},
+		{
+			name:     "single setting",
+			override: &ConnectionPolicyOverride{MaxRetries: ptr(5)},
+			want:     func(p *ConnectionPolicyConfig) { p.MaxRetries = 5 },
+		},
+		{
+			name:     "explicit zero retries",
+			override: &ConnectionPolicyOverride{MaxRetries: ptr(0)},
+			want:     func(p *ConnectionPolicyConfig) { p.MaxRetries = 0 },
+		},
+		{
+			name:     "zero connect timeout uses the default",
+			override: &ConnectionPolicyOverride{ConnectTimeout: ptr(time.Duration(0)), PingTimeout: ptr(time.Second)},
+			want:     func(p *ConnectionPolicyConfig) { p.PingTimeout = time.Second },
+		},
+		{
+			name:     "empty connect strategy uses the default",
+			override: &ConnectionPolicyOverride{ConnectStrategy: ptr("")},
+		},
+		{
+			name: "every setting",
+			override: &ConnectionPolicyOverride{
+				ConnectTimeout:    ptr(5 * time.Second),
+				MaxRetries:        ptr(1),
+				InitialBackoff:    ptr(200 * time.Millisecond),
+				MaxBackoff:        ptr(2 * time.Second),
+				BackoffMultiplier: ptr(1.5),
+				BackoffJitter:     ptr(0.0),
+				PingTimeout:       ptr(500 * time.Millisecond),
+				ConnectStrategy:   ptr("lazy"),
+			},
+			want: func(p *ConnectionPolicyConfig) {
+				*p = ConnectionPolicyConfig{
+					ConnectTimeout:    5 * time.Second,
+					MaxRetries:        1,
+					InitialBackoff:    200 * time.Millisecond,
+					MaxBackoff:        2 * time.Second,
+					BackoffMultiplier: 1.5,
+					BackoffJitter:     0,
+					PingTimeout:       500 * time.Millisecond,
+					ConnectStrategy:   "lazy",
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connection := ConnectionConfig{
+				ConnectionPolicyConfig: defaults,
+				Drivers:                map[string]ConnectionPolicyOverride{},
+			}
+			if tt.override != nil {
+				connection.Drivers["KODPOS"] = *tt.override
+			}
+
+			want := defaults
+			if tt.want != nil {
+				tt.want(&want)
+			}
+
+			if got := connection.BrandPolicy("KODPOS"); got != want {
+				t.Fatalf("BrandPolicy() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestConnectionConfigDecodesPartialOverrides(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	err := v.ReadConfig(strings.NewReader(`
+connect_timeout: "15s"
+max_retries: 2
+ping_timeout: "3s"
+connect_strategy: "eager"
+drivers:
+  KODPOS:
+    connect_timeout: "5s"
+    max_retries: 0
+`))
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+
+	var connection ConnectionConfig
+	if err := v.Unmarshal(&connection); err != nil {
+		t.Fatalf("failed to decode config: %v", err)
+	}
+
+	got := connection.BrandPolicy("KODPOS")
+	want := ConnectionPolicyConfig{
+		ConnectTimeout:  5 * time.Second,
+		MaxRetries:      0,
+		PingTimeout:     3 * time.Second,
+		ConnectStrategy: "eager",
+	}
+	if got != want {
+		t.Fatalf("BrandPolicy() = %+v, want %+v", got, want)
+	}
+}
+
+func TestValidateRequestTimeout(t *testing.T) {
+	device := DeviceConfig{
+		OperationTimeout: 30 * time.Second,
+		OperationTimeouts: OperationTimeoutMatrix{
+			"default": {"payment": 60 * time.Second},
+			"tcp":     {"payment": 90 * time.Second},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		request time.Duration
+		write   time.Duration
+		wantErr bool
+	}{
+		{name: "above every operation timeout", request: 120 * time.Second, write: 130 * time.Second},
+		{name: "disabled", request: 0, write: 30 * time.Second},
+		{name: "cuts the longest operation short", request: 90 * time.Second, write: 130 * time.Second, wantErr: true},
+		{name: "cuts the default operation timeout short", request: 25 * time.Second, write: 130 * time.Second, wantErr: true},
+		{name: "not below write timeout", request: 120 * time.Second, write: 120 * time.Second, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := ServerConfig{RequestTimeout: tt.request, WriteTimeout: tt.write}
+			if err := validateRequestTimeout(&server, &device); (err != nil) != tt.wantErr {
+				t.Fatalf("validateRequestTimeout() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
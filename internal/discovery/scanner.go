@@ -5,6 +5,8 @@ import (
 	"context"
 	"device-service/internal/model"
 	"fmt"
+	"sort"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -29,6 +31,15 @@ type DiscoveredDevice struct {
 	Location       string                 `json:"location,omitempty"`
 }
 
+// ScannerRun describes how a single scanner did during a scan
+type ScannerRun struct {
+	Scanner      string `json:"scanner"`
+	Ran          bool   `json:"ran"` // false when the scanner was not available
+	DurationMs   int64  `json:"duration_ms"`
+	DevicesFound int    `json:"devices_found"`
+	Error        string `json:"error,omitempty"`
+}
+
 // ScannerManager manages all device scanners - Facade Pattern
 type ScannerManager struct {
 	scanners map[string]DeviceScanner
@@ -50,19 +61,29 @@ func (sm *ScannerManager) RegisterScanner(scanner DeviceScanner) {
 	sm.logger.Info("Scanner registered", zap.String("type", scannerType))
 }
 
-// ScanAll scans all registered scanner types
-func (sm *ScannerManager) ScanAll(ctx context.Context) ([]*DiscoveredDevice, error) {
+// ScanAll scans all registered scanner types. A failing scanner does not
+// fail the scan; its error is reported in its run.
+func (sm *ScannerManager) ScanAll(ctx context.Context) ([]*DiscoveredDevice, []*ScannerRun, error) {
 	var allDevices []*DiscoveredDevice
 
-	for scannerType, scanner := range sm.scanners {
+	scannerTypes := make([]string, 0, len(sm.scanners))
+	for scannerType := range sm.scanners {
+		scannerTypes = append(scannerTypes, scannerType)
+	}
+	sort.Strings(scannerTypes)
+
+	runs := make([]*ScannerRun, 0, len(scannerTypes))
+	for _, scannerType := range scannerTypes {
+		scanner := sm.scanners[scannerType]
 		if !scanner.IsAvailable() {
 			sm.logger.Debug("Scanner not available, skipping", zap.String("type", scannerType))
+			runs = append(runs, &ScannerRun{Scanner: scannerType, Error: "scanner not available"})
 			continue
 		}
 
-		devices, err := scanner.Scan(ctx)
-		if err != nil {
-			sm.logger.Error("Scanner failed", zap.String("type", scannerType), zap.Error(err))
+		devices, run := sm.runScanner(ctx, scannerType, scanner)
+		runs = append(runs, run)
+		if run.Error != "" {
 			continue
 		}
 
@@ -73,21 +94,45 @@ func (sm *ScannerManager) ScanAll(ctx context.Context) ([]*DiscoveredDevice, err
 		)
 	}
 
-	return allDevices, nil
+	return allDevices, runs, nil
 }
 
 // ScanByType scans specific scanner type
-func (sm *ScannerManager) ScanByType(ctx context.Context, scannerType string) ([]*DiscoveredDevice, error) {
+func (sm *ScannerManager) ScanByType(ctx context.Context, scannerType string) ([]*DiscoveredDevice, []*ScannerRun, error) {
 	scanner, exists := sm.scanners[scannerType]
 	if !exists {
-		return nil, fmt.Errorf("scanner type not found: %s", scannerType)
+		return nil, nil, fmt.Errorf("scanner type not found: %s", scannerType)
 	}
 
 	if !scanner.IsAvailable() {
-		return nil, fmt.Errorf("scanner not available: %s", scannerType)
+		return nil, nil, fmt.Errorf("scanner not available: %s", scannerType)
 	}
 
-	return scanner.Scan(ctx)
+	devices, run := sm.runScanner(ctx, scannerType, scanner)
+	if run.Error != "" {
+		return nil, []*ScannerRun{run}, fmt.Errorf("%s scanner failed: %s", scannerType, run.Error)
+	}
+	return devices, []*ScannerRun{run}, nil
+}
+
+// runScanner runs a single scanner and times it
+func (sm *ScannerManager) runScanner(ctx context.Context, scannerType string, scanner DeviceScanner) ([]*DiscoveredDevice, *ScannerRun) {
+	start := time.Now()
+	devices, err := scanner.Scan(ctx)
+
+	run := &ScannerRun{
+		Scanner:      scannerType,
+		Ran:          true,
+		DurationMs:   time.Since(start).Milliseconds(),
+		DevicesFound: len(devices),
+	}
+	if err != nil {
+		sm.logger.Error("Scanner failed", zap.String("type", scannerType), zap.Error(err))
+		run.Error = err.Error()
+		run.DevicesFound = 0
+		return nil, run
+	}
+	return devices, run
 }
 
 // GetAvailableScanners returns list of available scanner types
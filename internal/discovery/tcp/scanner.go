@@ -3,13 +3,24 @@ package tcp
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
 
 	"device-service/internal/discovery"
+	"device-service/internal/model"
 )
 
+// maxScanHosts bounds the hosts of a single scan, a /16
+const maxScanHosts = 1 << 16
+
 // Scanner implements TCP network device scanning
 type Scanner struct {
 	logger  *zap.Logger
@@ -20,11 +31,30 @@ type Scanner struct {
 // Config for TCP scanner
 type Config struct {
 	ScanTimeout   time.Duration `json:"scan_timeout"`
-	NetworkRanges []string      `json:"network_ranges"`
+	NetworkRanges []string      `json:"network_ranges"` // CIDR blocks
 	CommonPorts   []int         `json:"common_ports"`
 	ConnTimeout   time.Duration `json:"connection_timeout"`
+	Concurrency   int           `json:"concurrency"` // probes in flight at once
+}
+
+// portProfile describes what an open port suggests about a device
+type portProfile struct {
+	deviceType   model.DeviceType
+	capabilities []string
+	confidence   float64
+}
+
+// portProfiles are the ports devices are commonly reached on. An open port
+// only hints at the device type, so confidence stays low.
+var portProfiles = map[int]portProfile{
+	9100: {deviceType: model.DeviceTypePrinter, capabilities: []string{"PRINT"}, confidence: 0.5}, // raw ESC/POS
+	515:  {deviceType: model.DeviceTypePrinter, capabilities: []string{"PRINT"}, confidence: 0.3}, // LPD
+	631:  {deviceType: model.DeviceTypePrinter, capabilities: []string{"PRINT"}, confidence: 0.3}, // IPP
 }
 
+// unknownPortProfile is used for configured ports without a profile
+var unknownPortProfile = portProfile{deviceType: model.DeviceTypePOS, confidence: 0.2}
+
 // NewScanner creates a new TCP scanner
 func NewScanner(logger *zap.Logger, config *Config) *Scanner {
 	if config == nil {
@@ -33,8 +63,12 @@ func NewScanner(logger *zap.Logger, config *Config) *Scanner {
 			NetworkRanges: []string{"192.168.1.0/24", "10.0.0.0/24"},
 			CommonPorts:   []int{9100, 8080, 23, 80, 443},
 			ConnTimeout:   3 * time.Second,
+			Concurrency:   64,
 		}
 	}
+	if config.Concurrency < 1 {
+		config.Concurrency = 1
+	}
 
 	return &Scanner{
 		logger:  logger.With(zap.String("scanner", "tcp")),
@@ -54,21 +88,140 @@ func (s *Scanner) IsAvailable() bool {
 	return true
 }
 
-// Scan performs TCP network device discovery
+// Scan performs TCP network device discovery: every host of the configured
+// network ranges is probed on each configured port, and each port accepting
+// a connection is reported as a device. A scan running past its timeout
+// returns what it found so far.
 func (s *Scanner) Scan(ctx context.Context) ([]*discovery.DiscoveredDevice, error) {
-	s.logger.Info("Starting TCP network scan")
+	hosts, err := expandRanges(s.config.NetworkRanges)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Starting TCP network scan",
+		zap.Strings("network_ranges", s.config.NetworkRanges),
+		zap.Ints("ports", s.config.CommonPorts),
+		zap.Int("hosts", len(hosts)),
+	)
 
-	// Implementation would scan network ranges for devices
-	// This is a placeholder for the actual implementation
+	scanCtx := ctx
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		scanCtx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	addresses := make(chan string)
+	found := make(chan *discovery.DiscoveredDevice)
 
-	var discovered []*discovery.DiscoveredDevice
+	var wg sync.WaitGroup
+	for i := 0; i < s.config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for address := range addresses {
+				if device := s.probe(scanCtx, address); device != nil {
+					found <- device
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(addresses)
+		for _, host := range hosts {
+			for _, port := range s.config.CommonPorts {
+				select {
+				case addresses <- net.JoinHostPort(host.String(), strconv.Itoa(port)):
+				case <-scanCtx.Done():
+					return
+				}
+			}
+		}
+	}()
 
-	// TODO: Implement actual TCP scanning logic
-	// - Parse network ranges
-	// - Scan common ports
-	// - Test for printer/POS protocols
-	// - Identify device types
+	go func() {
+		wg.Wait()
+		close(found)
+	}()
+
+	discovered := []*discovery.DiscoveredDevice{}
+	for device := range found {
+		discovered = append(discovered, device)
+	}
+	sort.Slice(discovered, func(i, j int) bool {
+		a, _ := netip.ParseAddrPort(discovered[i].Location)
+		b, _ := netip.ParseAddrPort(discovered[j].Location)
+		return a.Compare(b) < 0
+	})
+
+	if err := ctx.Err(); err != nil {
+		return discovered, err
+	}
+	if errors.Is(scanCtx.Err(), context.DeadlineExceeded) {
+		s.logger.Warn("TCP scan timed out, results are partial", zap.Duration("scan_timeout", s.timeout))
+	}
 
 	s.logger.Info("TCP scan completed", zap.Int("devices_found", len(discovered)))
 	return discovered, nil
 }
+
+// probe connects to address and describes the device listening there, or
+// returns nil when nothing accepts the connection
+func (s *Scanner) probe(ctx context.Context, address string) *discovery.DiscoveredDevice {
+	dialer := net.Dialer{Timeout: s.config.ConnTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil
+	}
+	conn.Close()
+
+	host, portStr, _ := net.SplitHostPort(address)
+	port, _ := strconv.Atoi(portStr)
+
+	profile, ok := portProfiles[port]
+	if !ok {
+		profile = unknownPortProfile
+	}
+
+	return &discovery.DiscoveredDevice{
+		ConnectionType: model.ConnectionTypeTCP,
+		ConnectionInfo: map[string]interface{}{
+			"host": host,
+			"port": port,
+		},
+		Brand:        model.BrandGeneric,
+		Model:        fmt.Sprintf("Network device on port %d", port),
+		DeviceType:   profile.deviceType,
+		Capabilities: profile.capabilities,
+		Confidence:   profile.confidence,
+		Location:     address,
+	}
+}
+
+// expandRanges returns the host addresses of CIDR ranges, leaving out the
+// network and broadcast addresses of IPv4 ranges larger than a /31
+func expandRanges(ranges []string) ([]netip.Addr, error) {
+	var hosts []netip.Addr
+	for _, cidr := range ranges {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network range %q: %w", cidr, err)
+		}
+		prefix = prefix.Masked()
+
+		hostBits := prefix.Addr().BitLen() - prefix.Bits()
+		if hostBits > 16 || len(hosts)+(1<<hostBits) > maxScanHosts {
+			return nil, fmt.Errorf("network ranges exceed %d hosts", maxScanHosts)
+		}
+
+		skipEdges := prefix.Addr().Is4() && hostBits > 1
+		for addr := prefix.Addr(); prefix.Contains(addr); addr = addr.Next() {
+			if skipEdges && (addr == prefix.Addr() || !prefix.Contains(addr.Next())) {
+				continue
+			}
+			hosts = append(hosts, addr)
+		}
+	}
+	return hosts, nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/gousb"
@@ -18,8 +19,9 @@ import (
 
 // Scanner struct'ından ÖNCE, dosyanın başına ekle
 type deviceResult struct {
-	device *discovery.DiscoveredDevice
-	error  error
+	device   *discovery.DiscoveredDevice
+	error    error
+	timedOut bool
 }
 
 // Scanner implements USB device scanning
@@ -38,8 +40,12 @@ type Config struct {
 	FilterByClass  bool          `json:"filter_by_class"`
 	TestConnection bool          `json:"test_connection"`
 	MaxConcurrent  int           `json:"max_concurrent"`
+	DeviceTimeout  time.Duration `json:"device_timeout"` // per device processing limit
 }
 
+// defaultDeviceTimeout bounds how long a single device may be examined
+const defaultDeviceTimeout = 3 * time.Second
+
 const (
 	USBClassHID        = 3
 	USBClassPrinter    = 7
@@ -55,6 +61,7 @@ func NewScanner(logger *zap.Logger, config *Config) *Scanner {
 			FilterByClass:  true,
 			TestConnection: false, // USB connection test can be risky
 			MaxConcurrent:  5,
+			DeviceTimeout:  defaultDeviceTimeout,
 		}
 	}
 
@@ -100,19 +107,18 @@ func (s *Scanner) Scan(ctx context.Context) ([]*discovery.DiscoveredDevice, erro
 		return nil, fmt.Errorf("pre-scan checks failed: %w", err)
 	}
 
-	// Initialize USB context
+	// Initialize USB context. It and the opened devices are closed once no
+	// worker uses them any more, see closeWhenIdle.
 	usbCtx := gousb.NewContext()
-	defer func() {
-		if err := usbCtx.Close(); err != nil {
-			s.logger.Warn("Failed to close USB context", zap.Error(err))
-		}
-	}()
+	var devices []*gousb.Device
+	var inflight sync.WaitGroup
+	defer func() { s.closeWhenIdle(usbCtx, devices, &inflight) }()
 
 	// Configure USB context
 	s.configureUSBContext(usbCtx)
 
 	// Enumerate and process devices
-	discovered, err := s.enumerateAndProcessDevices(scanCtx, usbCtx)
+	devices, discovered, err := s.enumerateAndProcessDevices(scanCtx, usbCtx, &inflight)
 	if err != nil {
 		return nil, fmt.Errorf("device enumeration failed: %w", err)
 	}
@@ -162,19 +168,21 @@ func (s *Scanner) configureUSBContext(ctx *gousb.Context) {
 	)
 }
 
-// enumerateAndProcessDevices handles the main enumeration and processing loop
-func (s *Scanner) enumerateAndProcessDevices(ctx context.Context, usbCtx *gousb.Context) ([]*discovery.DiscoveredDevice, error) {
+// enumerateAndProcessDevices handles the main enumeration and processing
+// loop. It returns the opened devices, which the caller closes once inflight
+// is done, also when processing fails.
+func (s *Scanner) enumerateAndProcessDevices(ctx context.Context, usbCtx *gousb.Context, inflight *sync.WaitGroup) ([]*gousb.Device, []*discovery.DiscoveredDevice, error) {
 	// Enumerate devices with filter
 	devices, err := usbCtx.OpenDevices(s.createDeviceFilter())
 	if err != nil {
-		return nil, fmt.Errorf("failed to enumerate USB devices: %w", err)
+		return devices, nil, fmt.Errorf("failed to enumerate USB devices: %w", err)
 	}
-	defer s.closeAllDevices(devices)
 
 	s.logger.Info("Found USB devices to examine", zap.Int("device_count", len(devices)))
 
 	// Process devices with concurrency control
-	return s.processDevicesConcurrently(ctx, devices)
+	discovered, err := s.processDevicesConcurrently(ctx, devices, inflight)
+	return devices, discovered, err
 }
 
 // createDeviceFilter returns a device filter function
@@ -226,8 +234,10 @@ func (s *Scanner) isPotentialPOSDevice(desc *gousb.DeviceDesc) bool {
 	}
 }
 
-// processDevicesConcurrently processes devices with controlled concurrency
-func (s *Scanner) processDevicesConcurrently(ctx context.Context, devices []*gousb.Device) ([]*discovery.DiscoveredDevice, error) {
+// processDevicesConcurrently processes devices with controlled concurrency.
+// Workers and the device reads they start are tracked in inflight; they may
+// outlive the call when the scan times out.
+func (s *Scanner) processDevicesConcurrently(ctx context.Context, devices []*gousb.Device, inflight *sync.WaitGroup) ([]*discovery.DiscoveredDevice, error) {
 	if len(devices) == 0 {
 		return []*discovery.DiscoveredDevice{}, nil
 	}
@@ -242,7 +252,11 @@ func (s *Scanner) processDevicesConcurrently(ctx context.Context, devices []*gou
 
 	// Start workers
 	for i := 0; i < maxWorkers; i++ {
-		go s.deviceWorker(ctx, deviceChan, resultChan)
+		inflight.Add(1)
+		go func() {
+			defer inflight.Done()
+			s.deviceWorker(ctx, deviceChan, resultChan, inflight)
+		}()
 	}
 
 	// Send devices to workers
@@ -256,26 +270,45 @@ func (s *Scanner) processDevicesConcurrently(ctx context.Context, devices []*gou
 	}
 	close(deviceChan)
 
-	// Collect results
+	// Collect results. Every worker reports each device within the device
+	// timeout, so the loop ends even if a device never answers.
 	var discovered []*discovery.DiscoveredDevice
+	var failed, skipped int
 	for i := 0; i < len(devices); i++ {
 		select {
 		case result := <-resultChan:
-			if result.error != nil {
+			switch {
+			case result.timedOut:
+				skipped++
+			case result.error != nil:
+				failed++
 				s.logger.Warn("Device processing failed", zap.Error(result.error))
-			} else if result.device != nil {
+			case result.device != nil:
 				discovered = append(discovered, result.device)
 			}
 		case <-ctx.Done():
+			skipped += len(devices) - i
+			s.logScanSummary(len(devices), len(discovered), failed, skipped)
 			return discovered, ctx.Err()
 		}
 	}
 
+	s.logScanSummary(len(devices), len(discovered), failed, skipped)
 	return discovered, nil
 }
 
+// logScanSummary logs how the examined devices were handled
+func (s *Scanner) logScanSummary(total, discovered, failed, skipped int) {
+	s.logger.Info("USB device processing finished",
+		zap.Int("examined", total),
+		zap.Int("discovered", discovered),
+		zap.Int("failed", failed),
+		zap.Int("skipped", skipped),
+	)
+}
+
 // deviceWorker processes devices in worker pool
-func (s *Scanner) deviceWorker(ctx context.Context, deviceChan <-chan *gousb.Device, resultChan chan<- deviceResult) {
+func (s *Scanner) deviceWorker(ctx context.Context, deviceChan <-chan *gousb.Device, resultChan chan<- deviceResult, inflight *sync.WaitGroup) {
 	for {
 		select {
 		case device, ok := <-deviceChan:
@@ -283,11 +316,7 @@ func (s *Scanner) deviceWorker(ctx context.Context, deviceChan <-chan *gousb.Dev
 				return
 			}
 
-			discoveredDevice := s.processDevice(device)
-			resultChan <- deviceResult{
-				device: discoveredDevice,
-				error:  nil,
-			}
+			resultChan <- s.processDeviceWithTimeout(ctx, device, inflight)
 
 		case <-ctx.Done():
 			return
@@ -295,6 +324,60 @@ func (s *Scanner) deviceWorker(ctx context.Context, deviceChan <-chan *gousb.Dev
 	}
 }
 
+// processDeviceWithTimeout processes a device but gives up after the device
+// timeout, so one unresponsive device can't stall the scan. gousb reads can't
+// be interrupted; an abandoned read finishes in the background and stays
+// counted in inflight until it returns.
+func (s *Scanner) processDeviceWithTimeout(ctx context.Context, device *gousb.Device, inflight *sync.WaitGroup) deviceResult {
+	timeout := s.config.DeviceTimeout
+	if timeout <= 0 {
+		timeout = defaultDeviceTimeout
+	}
+
+	done := make(chan deviceResult, 1)
+	inflight.Add(1)
+	go func() {
+		defer inflight.Done()
+		done <- s.safeProcessDevice(device)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case result := <-done:
+		return result
+	case <-timer.C:
+		fields := []zap.Field{zap.Duration("timeout", timeout)}
+		if device.Desc != nil {
+			fields = append(fields,
+				zap.String("vendor_id", fmt.Sprintf("0x%04X", device.Desc.Vendor)),
+				zap.String("product_id", fmt.Sprintf("0x%04X", device.Desc.Product)),
+			)
+		}
+		s.logger.Warn("USB device processing timed out, skipping device", fields...)
+		return deviceResult{timedOut: true}
+	case <-ctx.Done():
+		return deviceResult{timedOut: true}
+	}
+}
+
+// safeProcessDevice processes a device and turns a panic into an error result,
+// so one faulty device can't kill the worker or stall result collection
+func (s *Scanner) safeProcessDevice(device *gousb.Device) (result deviceResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("Recovered from panic while processing USB device",
+				zap.Any("panic", r),
+				zap.Stack("stacktrace"),
+			)
+			result = deviceResult{error: fmt.Errorf("panic while processing device: %v", r)}
+		}
+	}()
+
+	return deviceResult{device: s.processDevice(device)}
+}
+
 // processDevice examines a single USB device and creates DiscoveredDevice if applicable
 func (s *Scanner) processDevice(device *gousb.Device) *discovery.DiscoveredDevice {
 	desc := device.Desc
@@ -490,6 +573,20 @@ func (s *Scanner) createLocationString(desc *gousb.DeviceDesc) string {
 	return fmt.Sprintf("USB-Bus%d-Port%d", desc.Bus, desc.Address)
 }
 
+// closeWhenIdle closes the opened devices and then the USB context once no
+// worker or device read uses them any more. Closing them under a read
+// abandoned after the device timeout would be a use after free in libusb, so
+// they are closed in the background and the scan doesn't wait for the read.
+func (s *Scanner) closeWhenIdle(usbCtx *gousb.Context, devices []*gousb.Device, inflight *sync.WaitGroup) {
+	go func() {
+		inflight.Wait()
+		s.closeAllDevices(devices)
+		if err := usbCtx.Close(); err != nil {
+			s.logger.Warn("Failed to close USB context", zap.Error(err))
+		}
+	}()
+}
+
 // closeAllDevices safely closes all opened USB devices
 func (s *Scanner) closeAllDevices(devices []*gousb.Device) {
 	for i, device := range devices {
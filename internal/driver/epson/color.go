@@ -0,0 +1,105 @@
+// internal/driver/epson/color.go
+package epson
+
+import (
+	"fmt"
+	"strings"
+
+	"device-service/pkg/driver"
+)
+
+// Print colors of two-color printing
+const (
+	ColorBlack = "BLACK"
+	ColorRed   = "RED"
+)
+
+// twoColorModels are printers with a red/black ribbon, which print red
+// without being configured for it. Thermal printers need two-color paper and
+// are switched on with the TWO_COLOR capability flag.
+var twoColorModels = []string{"TM-U220", "TM-U230", "TM-U295", "TM-U325", "TM-U675"}
+
+// isTwoColorModel reports whether a printer model prints red out of the box
+func isTwoColorModel(printerModel string) bool {
+	printerModel = strings.ToUpper(printerModel)
+	for _, twoColor := range twoColorModels {
+		if strings.Contains(printerModel, twoColor) {
+			return true
+		}
+	}
+	return false
+}
+
+// colorCommands returns the commands selecting a print color. An empty color
+// selects nothing, and printers without two-color printing get no commands
+// and print everything in black.
+func (d *EPSONDriver) colorCommands(color string) ([][]byte, error) {
+	switch strings.ToUpper(color) {
+	case "":
+		return nil, nil
+	case ColorBlack:
+		if !d.config.TwoColorEnabled {
+			return nil, nil
+		}
+		return [][]byte{ESC_POS_COMMANDS.COLOR_BLACK, ESC_POS_COMMANDS.CHAR_COLOR_BLACK}, nil
+	case ColorRed:
+		if !d.config.TwoColorEnabled {
+			return nil, nil
+		}
+		return [][]byte{ESC_POS_COMMANDS.COLOR_RED, ESC_POS_COMMANDS.CHAR_COLOR_RED}, nil
+	}
+	return nil, driver.NewOperationError(driver.ErrorCodeInvalidRequest,
+		fmt.Errorf("color must be %s or %s, got %q", ColorBlack, ColorRed, color))
+}
+
+// resetColorCommands returns the commands going back to black after text
+// printed in color
+func (d *EPSONDriver) resetColorCommands(color string) [][]byte {
+	if !d.config.TwoColorEnabled || !strings.EqualFold(color, ColorRed) {
+		return nil
+	}
+	return [][]byte{ESC_POS_COMMANDS.COLOR_BLACK, ESC_POS_COMMANDS.CHAR_COLOR_BLACK}
+}
+
+// receiptColors holds the color commands of the fields of a receipt
+type receiptColors struct {
+	header [][]byte
+	items  [][][]byte // by item index
+	total  [][]byte
+	footer [][]byte
+	reset  [][]byte // back to black after a field
+}
+
+// receiptColors resolves the color of each receipt field; fields without a
+// color use the default color
+func (d *EPSONDriver) receiptColors(receipt *ReceiptData, defaultColor string) (*receiptColors, error) {
+	colors := &receiptColors{items: make([][][]byte, len(receipt.Items))}
+
+	resolve := func(color string) ([][]byte, error) {
+		if color == "" {
+			color = defaultColor
+		}
+		commands, err := d.colorCommands(color)
+		if commands != nil {
+			colors.reset = [][]byte{ESC_POS_COMMANDS.COLOR_BLACK, ESC_POS_COMMANDS.CHAR_COLOR_BLACK}
+		}
+		return commands, err
+	}
+
+	var err error
+	if colors.header, err = resolve(receipt.HeaderColor); err != nil {
+		return nil, err
+	}
+	for i, item := range receipt.Items {
+		if colors.items[i], err = resolve(item.Color); err != nil {
+			return nil, err
+		}
+	}
+	if colors.total, err = resolve(receipt.TotalColor); err != nil {
+		return nil, err
+	}
+	if colors.footer, err = resolve(receipt.FooterColor); err != nil {
+		return nil, err
+	}
+	return colors, nil
+}
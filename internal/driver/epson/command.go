@@ -8,6 +8,14 @@ var ESC_POS_COMMANDS = struct {
 	STATUS_REQUEST  []byte
 	GET_DEVICE_INFO []byte
 
+	// Real-time status (DLE EOT n)
+	STATUS_OFFLINE_CAUSE []byte
+	STATUS_ERROR_CAUSE   []byte
+	STATUS_PAPER_SENSOR  []byte
+
+	// Transmit status (GS r n), processed in order with print data
+	TRANSMIT_PAPER_STATUS []byte
+
 	// Text formatting
 	TEXT_BOLD_ON       []byte
 	TEXT_BOLD_OFF      []byte
@@ -15,6 +23,10 @@ var ESC_POS_COMMANDS = struct {
 	TEXT_UNDERLINE_OFF []byte
 	TEXT_RESET         []byte
 
+	// Character font
+	FONT_A []byte
+	FONT_B []byte
+
 	// Text size
 	TEXT_SIZE_NORMAL        []byte
 	TEXT_SIZE_DOUBLE_WIDTH  []byte
@@ -27,17 +39,28 @@ var ESC_POS_COMMANDS = struct {
 	ALIGN_RIGHT  []byte
 
 	// Character sets
-	SELECT_CHARSET_PC437 []byte
-	SELECT_CHARSET_PC850 []byte
-	SELECT_CHARSET_PC852 []byte
-	SELECT_CHARSET_PC858 []byte
+	SELECT_CHARSET_PC437   []byte
+	SELECT_CHARSET_PC850   []byte
+	SELECT_CHARSET_PC852   []byte
+	SELECT_CHARSET_PC858   []byte
+	SELECT_CHARSET_PC866   []byte
+	SELECT_CHARSET_WPC1252 []byte
+	SELECT_CHARSET_WPC1254 []byte
+
+	// Two-color printing
+	COLOR_BLACK      []byte // ESC r 0
+	COLOR_RED        []byte // ESC r 1
+	CHAR_COLOR_BLACK []byte // GS ( N character color 1
+	CHAR_COLOR_RED   []byte // GS ( N character color 2
 
 	// Paper handling
-	LINE_FEED      []byte
-	FORM_FEED      []byte
-	FEED_LINES     []byte // + line count byte
-	SET_WIDTH_58MM []byte
-	SET_WIDTH_80MM []byte
+	LINE_FEED            []byte
+	FORM_FEED            []byte
+	FEED_LINES           []byte // + line count byte
+	LINE_SPACING         []byte // + spacing in motion units
+	LINE_SPACING_DEFAULT []byte
+	SET_WIDTH_58MM       []byte
+	SET_WIDTH_80MM       []byte
 
 	// Cutting
 	CUT_FULL    []byte
@@ -51,13 +74,32 @@ var ESC_POS_COMMANDS = struct {
 	PRINT_LOGO      []byte
 	BARCODE_CODE128 []byte
 	BARCODE_CODE39  []byte
+	BARCODE_HEIGHT  []byte // + height in dots
+	BARCODE_WIDTH   []byte // + module width (2-6)
+	BARCODE_HRI     []byte // + HRI position, 0 none, 2 below
 	QR_CODE_START   []byte
+	RASTER_IMAGE    []byte // + xL xH yL yH (width in bytes, height in dots) + bitmap
+
+	// Page mode
+	PAGE_MODE  []byte // enter page mode
+	PAGE_AREA  []byte // + xL xH yL yH dxL dxH dyL dyH
+	PAGE_PRINT []byte // print the page and return to standard mode
+	POSITION_X []byte // + nL nH absolute horizontal position
+	POSITION_Y []byte // + nL nH absolute vertical position, page mode only
 }{
 	// Basic commands
 	INITIALIZE:      []byte{0x1B, 0x40},       // ESC @
 	STATUS_REQUEST:  []byte{0x10, 0x04, 0x01}, // DLE EOT 1
 	GET_DEVICE_INFO: []byte{0x1D, 0x49, 0x01}, // GS I 1
 
+	// Real-time status (DLE EOT n)
+	STATUS_OFFLINE_CAUSE: []byte{0x10, 0x04, 0x02}, // DLE EOT 2
+	STATUS_ERROR_CAUSE:   []byte{0x10, 0x04, 0x03}, // DLE EOT 3
+	STATUS_PAPER_SENSOR:  []byte{0x10, 0x04, 0x04}, // DLE EOT 4
+
+	// Transmit status (GS r n)
+	TRANSMIT_PAPER_STATUS: []byte{0x1D, 0x72, 0x01}, // GS r 1
+
 	// Text formatting
 	TEXT_BOLD_ON:       []byte{0x1B, 0x45, 0x01}, // ESC E 1
 	TEXT_BOLD_OFF:      []byte{0x1B, 0x45, 0x00}, // ESC E 0
@@ -65,6 +107,10 @@ var ESC_POS_COMMANDS = struct {
 	TEXT_UNDERLINE_OFF: []byte{0x1B, 0x2D, 0x00}, // ESC - 0
 	TEXT_RESET:         []byte{0x1B, 0x21, 0x00}, // ESC ! 0
 
+	// Character font
+	FONT_A: []byte{0x1B, 0x4D, 0x00}, // ESC M 0 (12x24)
+	FONT_B: []byte{0x1B, 0x4D, 0x01}, // ESC M 1 (9x17)
+
 	// Text size
 	TEXT_SIZE_NORMAL:        []byte{0x1D, 0x21, 0x00}, // GS ! 0
 	TEXT_SIZE_DOUBLE_WIDTH:  []byte{0x1D, 0x21, 0x20}, // GS ! 32
@@ -77,17 +123,28 @@ var ESC_POS_COMMANDS = struct {
 	ALIGN_RIGHT:  []byte{0x1B, 0x61, 0x02}, // ESC a 2
 
 	// Character sets
-	SELECT_CHARSET_PC437: []byte{0x1B, 0x74, 0x00}, // ESC t 0
-	SELECT_CHARSET_PC850: []byte{0x1B, 0x74, 0x02}, // ESC t 2
-	SELECT_CHARSET_PC852: []byte{0x1B, 0x74, 0x12}, // ESC t 18
-	SELECT_CHARSET_PC858: []byte{0x1B, 0x74, 0x13}, // ESC t 19
+	SELECT_CHARSET_PC437:   []byte{0x1B, 0x74, 0x00}, // ESC t 0
+	SELECT_CHARSET_PC850:   []byte{0x1B, 0x74, 0x02}, // ESC t 2
+	SELECT_CHARSET_PC852:   []byte{0x1B, 0x74, 0x12}, // ESC t 18
+	SELECT_CHARSET_PC858:   []byte{0x1B, 0x74, 0x13}, // ESC t 19
+	SELECT_CHARSET_PC866:   []byte{0x1B, 0x74, 0x11}, // ESC t 17
+	SELECT_CHARSET_WPC1252: []byte{0x1B, 0x74, 0x10}, // ESC t 16
+	SELECT_CHARSET_WPC1254: []byte{0x1B, 0x74, 0x30}, // ESC t 48
+
+	// Two-color printing
+	COLOR_BLACK:      []byte{0x1B, 0x72, 0x00},                         // ESC r 0
+	COLOR_RED:        []byte{0x1B, 0x72, 0x01},                         // ESC r 1
+	CHAR_COLOR_BLACK: []byte{0x1D, 0x28, 0x4E, 0x02, 0x00, 0x30, 0x31}, // GS ( N 2 0 48 49
+	CHAR_COLOR_RED:   []byte{0x1D, 0x28, 0x4E, 0x02, 0x00, 0x30, 0x32}, // GS ( N 2 0 48 50
 
 	// Paper handling
-	LINE_FEED:      []byte{0x0A},                   // LF
-	FORM_FEED:      []byte{0x0C},                   // FF
-	FEED_LINES:     []byte{0x1B, 0x64},             // ESC d + n
-	SET_WIDTH_58MM: []byte{0x1D, 0x57, 0x40, 0x01}, // GS W 320
-	SET_WIDTH_80MM: []byte{0x1D, 0x57, 0x00, 0x02}, // GS W 512
+	LINE_FEED:            []byte{0x0A},                   // LF
+	FORM_FEED:            []byte{0x0C},                   // FF
+	FEED_LINES:           []byte{0x1B, 0x64},             // ESC d + n
+	LINE_SPACING:         []byte{0x1B, 0x33},             // ESC 3 + n
+	LINE_SPACING_DEFAULT: []byte{0x1B, 0x32},             // ESC 2
+	SET_WIDTH_58MM:       []byte{0x1D, 0x57, 0x40, 0x01}, // GS W 320
+	SET_WIDTH_80MM:       []byte{0x1D, 0x57, 0x00, 0x02}, // GS W 512
 
 	// Cutting
 	CUT_FULL:    []byte{0x1D, 0x56, 0x00}, // GS V 0
@@ -101,5 +158,16 @@ var ESC_POS_COMMANDS = struct {
 	PRINT_LOGO:      []byte{0x1D, 0x2F, 0x00},             // GS / 0
 	BARCODE_CODE128: []byte{0x1D, 0x6B, 0x49},             // GS k I
 	BARCODE_CODE39:  []byte{0x1D, 0x6B, 0x04},             // GS k 4
+	BARCODE_HEIGHT:  []byte{0x1D, 0x68},                   // GS h + n
+	BARCODE_WIDTH:   []byte{0x1D, 0x77},                   // GS w + n
+	BARCODE_HRI:     []byte{0x1D, 0x48},                   // GS H + n
 	QR_CODE_START:   []byte{0x1D, 0x28, 0x6B, 0x04, 0x00}, // GS ( k
+	RASTER_IMAGE:    []byte{0x1D, 0x76, 0x30, 0x00},       // GS v 0 0
+
+	// Page mode
+	PAGE_MODE:  []byte{0x1B, 0x4C}, // ESC L
+	PAGE_AREA:  []byte{0x1B, 0x57}, // ESC W + area
+	PAGE_PRINT: []byte{0x0C},       // FF
+	POSITION_X: []byte{0x1B, 0x24}, // ESC $ + nL nH
+	POSITION_Y: []byte{0x1D, 0x24}, // GS $ + nL nH
 }
@@ -0,0 +1,88 @@
+// internal/driver/epson/encoding.go
+package epson
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+
+	"device-service/pkg/driver"
+)
+
+// textEncoding is a printer code page: the command selecting it and the
+// charmap text is transcoded with
+type textEncoding struct {
+	name    string
+	command []byte           // ESC t n; nil leaves the code page as it is
+	charmap *charmap.Charmap // nil sends text bytes unchanged
+}
+
+// unmappableChar replaces characters missing from the selected code page
+const unmappableChar = '?'
+
+// textEncodings are the encodings a print request can select, by name
+var textEncodings = map[string]*textEncoding{
+	"PC437":   {name: "PC437", command: ESC_POS_COMMANDS.SELECT_CHARSET_PC437, charmap: charmap.CodePage437},
+	"PC850":   {name: "PC850", command: ESC_POS_COMMANDS.SELECT_CHARSET_PC850, charmap: charmap.CodePage850},
+	"PC852":   {name: "PC852", command: ESC_POS_COMMANDS.SELECT_CHARSET_PC852, charmap: charmap.CodePage852},
+	"PC858":   {name: "PC858", command: ESC_POS_COMMANDS.SELECT_CHARSET_PC858, charmap: charmap.CodePage858},
+	"PC866":   {name: "PC866", command: ESC_POS_COMMANDS.SELECT_CHARSET_PC866, charmap: charmap.CodePage866},
+	"WPC1252": {name: "WPC1252", command: ESC_POS_COMMANDS.SELECT_CHARSET_WPC1252, charmap: charmap.Windows1252},
+	"WPC1254": {name: "WPC1254", command: ESC_POS_COMMANDS.SELECT_CHARSET_WPC1254, charmap: charmap.Windows1254},
+
+	// Already encoded by the client; the code page selected by ESC @ stays
+	driver.PrintEncodingRaw: {name: driver.PrintEncodingRaw},
+}
+
+// defaultTextEncoding is used when a print request names no encoding: PC437
+// is selected and text is sent as received
+var defaultTextEncoding = &textEncoding{name: "", command: ESC_POS_COMMANDS.SELECT_CHARSET_PC437}
+
+// lookupTextEncoding returns the encoding named by a print request
+func lookupTextEncoding(name string) (*textEncoding, error) {
+	if name == "" {
+		return defaultTextEncoding, nil
+	}
+	encoding, ok := textEncodings[strings.ToUpper(name)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported encoding %q, expected one of %s",
+			name, strings.Join(driver.PrintEncodings, ", "))
+	}
+	return encoding, nil
+}
+
+// bytes transcodes text to the code page. Characters the code page lacks
+// are printed as '?'. Control codes are dropped, so text can't smuggle
+// ESC/POS commands (cut, drawer kick, initialize) to the printer; raw
+// commands only pass through the ESC_POS content type.
+func (e *textEncoding) bytes(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	if e == nil || e.charmap == nil {
+		for i := 0; i < len(text); i++ {
+			if !isControlByte(text[i]) {
+				encoded = append(encoded, text[i])
+			}
+		}
+		return encoded
+	}
+
+	for _, r := range text {
+		if r < utf8.RuneSelf && isControlByte(byte(r)) {
+			continue
+		}
+		b, ok := e.charmap.EncodeRune(r)
+		if !ok {
+			b = unmappableChar
+		}
+		encoded = append(encoded, b)
+	}
+	return encoded
+}
+
+// isControlByte reports whether b is a control code that would change the
+// printer state rather than print: C0 codes other than LF and HT, and DEL
+func isControlByte(b byte) bool {
+	return (b < 0x20 && b != '\n' && b != '\t') || b == 0x7F
+}
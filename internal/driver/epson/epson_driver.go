@@ -3,57 +3,94 @@ package epson
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 
 	"device-service/internal/model"
 	"device-service/internal/protocol"
+	"device-service/internal/tracing"
 	"device-service/internal/utils"
 	"device-service/pkg/driver"
 )
 
+// Print job size limits. Printer buffers are small, so oversized jobs are
+// rejected instead of stalling the device for minutes.
+const (
+	defaultMaxContentBytes = 64 * 1024  // content of a single copy
+	defaultMaxJobBytes     = 256 * 1024 // content of all copies together
+	maxCopies              = 10
+)
+
 // EPSONDriver implements driver.DeviceDriver and driver.PrinterDriver for EPSON printers
 type EPSONDriver struct {
 	config        *EPSONConfig
+	policy        driver.ConnectionPolicy
 	protocol      protocol.DeviceProtocol
 	logger        *utils.DeviceLogger
 	eventHandler  driver.EventHandler
 	isConnected   bool
+	hasConnected  bool
+	reconnects    int64 // reconnects after the first connection
 	lastPing      time.Time
 	healthMetrics *driver.HealthMetrics
 	mutex         sync.RWMutex
+	ioMutex       sync.Mutex  // serializes device I/O, so status requests don't interleave with print data; taken before mutex
+	staleReply    bool        // a status reply may still arrive after its read timed out; guarded by ioMutex
+	readback      atomic.Bool // the printer answered a status request at connect
 	deviceInfo    *driver.DeviceInfo
+	wireLogger    *protocol.WireLogger
+	buzzer        Buzzer // nil for printers without a buzzer
 }
 
+// Buzzer builds the commands sounding a printer's buzzer. It returns the beep
+// count and duration the printer supports closest to the requested ones.
+type Buzzer func(count, durationMs int) (commands [][]byte, beeps, beepDurationMs int)
+
 // EPSONConfig represents EPSON printer configuration
 type EPSONConfig struct {
-	DeviceID         string                 `json:"device_id"`
-	Model            string                 `json:"model"`
-	ConnectionType   model.ConnectionType   `json:"connection_type"`
-	ConnectionConfig map[string]interface{} `json:"connection_config"`
-	PaperWidth       int                    `json:"paper_width"`
-	CharacterSet     string                 `json:"character_set"`
-	CutType          string                 `json:"cut_type"`
-	DrawerPin        int                    `json:"drawer_pin"`
-	EnableDrawer     bool                   `json:"enable_drawer"`
-	EnableCutter     bool                   `json:"enable_cutter"`
-	LogoEnabled      bool                   `json:"logo_enabled"`
-	Options          map[string]interface{} `json:"options"`
+	DeviceID           string                 `json:"device_id"`
+	Model              string                 `json:"model"`
+	ConnectionType     model.ConnectionType   `json:"connection_type"`
+	ConnectionConfig   map[string]interface{} `json:"connection_config"`
+	PaperWidth         int                    `json:"paper_width"`
+	DefaultContentType string                 `json:"default_content_type"`
+	LineSpacing        int                    `json:"line_spacing"` // ESC 3 n motion units, 0 for the printer default
+	CompactMode        bool                   `json:"compact_mode"` // no extra line feeds for readability
+	MaxContentBytes    int                    `json:"max_content_bytes"`
+	MaxJobBytes        int                    `json:"max_job_bytes"`
+	CharacterSet       string                 `json:"character_set"`
+	CutType            string                 `json:"cut_type"`
+	CutFeedLines       int                    `json:"cut_feed_lines"` // line feeds clearing the print head to cutter gap before a cut
+	AutoCut            bool                   `json:"auto_cut"`       // cut after every print unless the request says otherwise
+	Font               string                 `json:"font"`           // A (12x24) or B (9x17), overridable per request
+	DrawerPin          int                    `json:"drawer_pin"`
+	EnableDrawer       bool                   `json:"enable_drawer"`
+	EnableCutter       bool                   `json:"enable_cutter"`
+	LogoEnabled        bool                   `json:"logo_enabled"`
+	TwoColorEnabled    bool                   `json:"two_color_enabled"` // red printing; mono printers print all text black
+	Options            map[string]interface{} `json:"options"`
 }
 
 // NewEPSONDriver creates a new EPSON printer driver
-func NewEPSONDriver(device *model.Device, connectionConfig interface{}, logger *zap.Logger) (driver.DeviceDriver, error) {
+func NewEPSONDriver(ctx context.Context, device *model.Device, connectionConfig interface{}, policy driver.ConnectionPolicy, logger *zap.Logger) (driver.DeviceDriver, error) {
 	// Parse connection configuration ONLY
 	connConfig, err := parseConnectionConfig(connectionConfig)
 	if err != nil {
 		return nil, fmt.Errorf("invalid connection configuration: %w", err)
 	}
+	// Virtual printers capture their output under the device ID
+	connConfig = protocol.WithCaptureID(device.ConnectionType, connConfig, device.DeviceID)
 
 	// Create EPSON configuration using device + connection info
 	epsonConfig := &EPSONConfig{
@@ -64,15 +101,24 @@ func NewEPSONDriver(device *model.Device, connectionConfig interface{}, logger *
 		ConnectionConfig: connConfig,
 
 		// Driver-specific defaults
-		PaperWidth:   80,
-		CharacterSet: "PC437",
-		CutType:      "FULL",
-		DrawerPin:    0,
-		EnableDrawer: true,
-		EnableCutter: true,
-		LogoEnabled:  false,
-		Options:      make(map[string]interface{}),
-	}
+		PaperWidth:         80,
+		DefaultContentType: "TEXT",
+		MaxContentBytes:    defaultMaxContentBytes,
+		MaxJobBytes:        defaultMaxJobBytes,
+		CharacterSet:       "PC437",
+		CutType:            "FULL",
+		CutFeedLines:       defaultCutFeedLines,
+		Font:               FontA,
+		DrawerPin:          0,
+		EnableDrawer:       true,
+		EnableCutter:       true,
+		LogoEnabled:        false,
+		TwoColorEnabled:    isTwoColorModel(device.Model),
+		Options:            make(map[string]interface{}),
+	}
+
+	// Per-device print settings stored with the device configuration
+	applyPrintSettings(epsonConfig, connConfig)
 
 	// Override defaults from device capabilities if available
 	if device.HasCapability(model.CapabilityDrawer) {
@@ -82,11 +128,15 @@ func NewEPSONDriver(device *model.Device, connectionConfig interface{}, logger *
 		epsonConfig.EnableCutter = true
 	}
 
-	deviceLogger := utils.NewDeviceLogger(logger, device.DeviceID, string(device.DeviceType), string(device.Brand))
+	// Capability toggles set by operators win over the defaults above
+	applyCapabilityFlags(epsonConfig, connConfig)
+
+	deviceLogger := utils.NewDeviceLogger(logger, device)
 
 	// ✅ CREATE DRIVER INSTANCE
 	epsonDriver := &EPSONDriver{
 		config: epsonConfig,
+		policy: policy,
 		logger: deviceLogger,
 		healthMetrics: &driver.HealthMetrics{
 			HealthScore: 0,
@@ -95,61 +145,50 @@ func NewEPSONDriver(device *model.Device, connectionConfig interface{}, logger *
 			Brand:          device.Brand,
 			Model:          device.Model,
 			ConnectionType: device.ConnectionType,
-			Capabilities:   getEPSONCapabilities(epsonConfig),
+			Capabilities:   getEPSONCapabilities(device.Brand, epsonConfig),
 			Manufacturer:   "Seiko Epson Corporation",
 		},
 		isConnected: false, // Başlangıçta bağlı değil
 	}
 
-	// ✅ EAGER CONNECTION: Hemen protocol oluştur ve bağlan
-	deviceLogger.Info("Creating protocol connection during driver initialization",
-		zap.String("connection_type", string(device.ConnectionType)),
-	)
-
-	// Protocol oluştur
-	protocolInstance, err := protocol.CreateProtocol(
-		device.ConnectionType,
-		connConfig,
-		deviceLogger.Logger,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create %s protocol: %w", device.ConnectionType, err)
+	// EPSON printers sound their built-in buzzer with ESC ( A; other ESC/POS
+	// brands set their own buzzer
+	if device.Brand == model.BrandEpson {
+		epsonDriver.buzzer = buzzerCommands
 	}
 
-	// Protocol connection'ı aç
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
+	// Wire logging is off unless enabled for this device
+	epsonDriver.wireLogger = protocol.NewWireLogger(deviceLogger.Logger)
+	if enabled, ok := connConfig[driver.WireLoggingKey].(bool); ok {
+		epsonDriver.wireLogger.SetEnabled(enabled)
+	}
 
-	if err := protocolInstance.Open(ctx); err != nil {
-		deviceLogger.Error("Failed to open protocol connection during driver creation", zap.Error(err))
-		// Connection fail olsa bile driver'ı oluştur, sonra lazy connection yapacak
-		epsonDriver.protocol = nil
-		epsonDriver.isConnected = false
-		deviceLogger.Warn("Driver created without active connection, will retry on first operation")
+	// Lazy drivers are created unconnected and connect on first use
+	if policy.Lazy() {
+		deviceLogger.Debug("EPSON driver created, connecting on first use")
 		return epsonDriver, nil
 	}
 
-	// Protocol'ü driver'a set et
-	epsonDriver.protocol = protocolInstance
-	epsonDriver.isConnected = true
-	epsonDriver.lastPing = time.Now()
-
-	// Printer'ı initialize et
-	if err := epsonDriver.initializePrinter(ctx); err != nil {
-		deviceLogger.Warn("Failed to initialize printer during driver creation", zap.Error(err))
-		// Initialization fail olsa bile devam et
+	// Connect eagerly through Connect, so the printer is initialized and
+	// status readback probed like on every reconnect; a failure leaves the
+	// driver usable so the connection can be retried later
+	if err := epsonDriver.Connect(ctx); err != nil {
+		deviceLogger.Warn("EPSON driver created without active connection", zap.Error(err))
 	}
 
-	deviceLogger.Info("EPSON driver created with active connection",
-		zap.String("connection_type", string(device.ConnectionType)),
-		zap.Bool("protocol_connected", protocolInstance.IsOpen()),
-	)
-
 	return epsonDriver, nil
 }
 
 // Connect establishes connection to EPSON printer
-func (d *EPSONDriver) Connect(ctx context.Context) error {
+func (d *EPSONDriver) Connect(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "driver.connect",
+		attribute.String("device.code", d.config.DeviceID),
+		attribute.String("driver", "EPSON"),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	d.ioMutex.Lock()
+	defer d.ioMutex.Unlock()
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
@@ -169,16 +208,22 @@ func (d *EPSONDriver) Connect(ctx context.Context) error {
 		d.updateHealthMetrics(false, time.Since(startTime), err)
 		return fmt.Errorf("failed to create %s protocol: %w", d.config.ConnectionType, err)
 	}
+	protocolInstance = protocol.WithTracing(protocolInstance)
 
-	// Open protocol connection
-	if err := protocolInstance.Open(ctx); err != nil {
+	// Open protocol connection according to the connection policy
+	if err := d.openWithRetry(ctx, protocolInstance); err != nil {
 		d.updateHealthMetrics(false, time.Since(startTime), err)
-		return fmt.Errorf("failed to open %s connection: %w", d.config.ConnectionType, err)
+		return err
 	}
 
-	d.protocol = protocolInstance
+	d.protocol = protocol.WithWireLog(protocolInstance, d.wireLogger)
 	d.isConnected = true
 	d.lastPing = time.Now()
+	if d.hasConnected {
+		d.reconnects++
+		protocol.Metrics().IncReconnects(d.config.ConnectionType)
+	}
+	d.hasConnected = true
 
 	// Initialize printer
 	if err := d.initializePrinter(ctx); err != nil {
@@ -188,29 +233,77 @@ func (d *EPSONDriver) Connect(ctx context.Context) error {
 		return fmt.Errorf("failed to initialize printer: %w", err)
 	}
 
+	// One-way printers would make every status request wait out the read
+	// timeout, so readback is detected once per connection
+	d.readback.Store(d.probeStatusReadback(ctx))
+
 	d.updateHealthMetrics(true, time.Since(startTime), nil)
 	d.notifyEvent("connected", nil)
 
 	d.logger.Info("EPSON printer connected successfully",
 		zap.String("connection_type", string(d.config.ConnectionType)),
 		zap.String("model", d.config.Model),
+		zap.Bool("status_readback", d.readback.Load()),
 	)
 
 	return nil
 }
 
+// openWithRetry opens the protocol connection, retrying with backoff as
+// defined by the driver's connection policy
+func (d *EPSONDriver) openWithRetry(ctx context.Context, protocolInstance protocol.DeviceProtocol) error {
+	var lastErr error
+
+	attempts := d.policy.Attempts()
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			delay := d.policy.Backoff(attempt - 1)
+			d.logger.Warn("Retrying protocol connection",
+				zap.Int("attempt", attempt),
+				zap.Int("max_attempts", attempts),
+				zap.Duration("backoff", delay),
+				zap.Error(lastErr),
+			)
+
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("connection aborted after %d attempts: %w", attempt-1, ctx.Err())
+			case <-time.After(delay):
+			}
+		}
+
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if d.policy.ConnectTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, d.policy.ConnectTimeout)
+		}
+		err := protocolInstance.Open(attemptCtx)
+		cancel()
+
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+	}
+
+	return fmt.Errorf("failed to open %s connection after %d attempts: %w",
+		d.config.ConnectionType, attempts, lastErr)
+}
+
 // Disconnect closes connection to EPSON printer
 func (d *EPSONDriver) Disconnect(ctx context.Context) error {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
-	if !d.isConnected {
+	// The protocol may still be open after a failed initialization, so close
+	// it even when the driver is not marked connected
+	if !d.isConnected && d.protocol == nil {
 		return nil
 	}
 
+	var closeErr error
 	if d.protocol != nil {
-		if err := d.protocol.Close(); err != nil {
-			d.logger.Error("Failed to close protocol", zap.Error(err))
+		if closeErr = d.protocol.Close(); closeErr != nil {
+			d.logger.Error("Failed to close protocol", zap.Error(closeErr))
 		}
 		d.protocol = nil
 	}
@@ -218,6 +311,10 @@ func (d *EPSONDriver) Disconnect(ctx context.Context) error {
 	d.isConnected = false
 	d.notifyEvent("disconnected", "manual disconnect")
 
+	if closeErr != nil {
+		return fmt.Errorf("failed to close protocol: %w", closeErr)
+	}
+
 	d.logger.Info("EPSON printer disconnected")
 	return nil
 }
@@ -236,54 +333,147 @@ func (d *EPSONDriver) GetDeviceInfo() (*driver.DeviceInfo, error) {
 
 // GetCapabilities returns device capabilities
 func (d *EPSONDriver) GetCapabilities() []model.Capability {
-	return getEPSONCapabilities(d.config)
+	capabilities := getEPSONCapabilities(d.deviceInfo.Brand, d.config)
+	if d.readback.Load() {
+		capabilities = append(capabilities, model.CapabilityStatusReadback)
+	}
+	return capabilities
 }
 
-// GetStatus returns current device status
+// GetStatus returns current device status. When connected it queries the
+// printer's DLE EOT real-time status so errors and paper state are reported.
 func (d *EPSONDriver) GetStatus() (*driver.DeviceStatus, error) {
+	d.ioMutex.Lock()
+	defer d.ioMutex.Unlock()
+	return d.statusLocked()
+}
+
+// statusLocked returns the device status; callers must hold the I/O mutex
+func (d *EPSONDriver) statusLocked() (*driver.DeviceStatus, error) {
 	d.mutex.RLock()
-	defer d.mutex.RUnlock()
+	connected := d.isConnected
+	lastPing := driver.OptionalTime(d.lastPing)
+	d.mutex.RUnlock()
 
-	if !d.isConnected {
+	if !connected {
 		return &driver.DeviceStatus{
 			Status:       model.DeviceStatusOffline,
 			IsReady:      false,
 			HasError:     false,
-			LastResponse: d.lastPing,
+			LastResponse: lastPing,
 		}, nil
 	}
 
-	return &driver.DeviceStatus{
+	status := &driver.DeviceStatus{
 		Status:       model.DeviceStatusOnline,
 		IsReady:      true,
 		HasError:     false,
-		LastResponse: d.lastPing,
-	}, nil
+		LastResponse: lastPing,
+		PaperStatus:  driver.PaperStatusUnknown,
+	}
+
+	if !d.readback.Load() {
+		status.Details = map[string]interface{}{"status_readback": false}
+		return status, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), realtimeStatusTimeout)
+	defer cancel()
+
+	realtime, err := d.queryRealtimeStatusLocked(ctx)
+	if err != nil {
+		// Not every connection can read back; keep the basic status
+		d.logger.Debug("Real-time status unavailable", zap.Error(err))
+		status.Details = map[string]interface{}{"realtime_status_error": err.Error()}
+		return status, nil
+	}
+
+	realtime.apply(status)
+	return status, nil
+}
+
+// GetPrinterStatus queries the printer's DLE EOT real-time status
+func (d *EPSONDriver) GetPrinterStatus(ctx context.Context) (*driver.PrinterStatus, error) {
+	if !d.IsConnected() {
+		return nil, fmt.Errorf("device not connected")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, realtimeStatusTimeout)
+	defer cancel()
+
+	realtime, err := d.queryRealtimeStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return realtime.printerStatus(), nil
+}
+
+// printDrainTimeout bounds how long WaitDrained waits for the printer to
+// work through its buffer, e.g. a long receipt with images
+const printDrainTimeout = 30 * time.Second
+
+// WaitDrained waits until the printer has printed everything sent before.
+// Unlike DLE EOT, GS r is executed in order with the print data, so its
+// reply only arrives once the data ahead of it was processed; a printer
+// stopped by paper out or an open cover never replies.
+func (d *EPSONDriver) WaitDrained(ctx context.Context) error {
+	if !d.IsConnected() {
+		return fmt.Errorf("device not connected")
+	}
+	if !d.readback.Load() {
+		return errStatusReadbackUnsupported
+	}
+
+	d.ioMutex.Lock()
+	defer d.ioMutex.Unlock()
+
+	d.discardStaleReply(ctx)
+	if err := d.sendCommands(ctx, [][]byte{ESC_POS_COMMANDS.TRANSMIT_PAPER_STATUS}); err != nil {
+		return err
+	}
+	response, err := d.readStatusReply(ctx, printDrainTimeout)
+	if err != nil {
+		return driver.NewOperationError(driver.ErrorCodeTimeout, fmt.Errorf("printer did not drain its buffer: %w", err))
+	}
+	if len(response) == 0 {
+		return driver.NewOperationError(driver.ErrorCodeTimeout, fmt.Errorf("empty paper status response"))
+	}
+	return nil
 }
 
 // ExecuteOperation executes a device operation
 func (d *EPSONDriver) ExecuteOperation(ctx context.Context, operation *model.DeviceOperation) (*driver.OperationResult, error) {
 	startTime := time.Now()
 
-	var result *driver.OperationResult
-	var err error
-
+	var handle func(context.Context, *model.DeviceOperation) (*driver.OperationResult, error)
 	switch operation.OperationType {
 	case model.OperationTypePrint:
-		result, err = d.handlePrintOperation(ctx, operation)
+		handle = d.handlePrintOperation
 	case model.OperationTypeCut:
-		result, err = d.handleCutOperation(ctx, operation)
+		handle = d.handleCutOperation
 	case model.OperationTypeOpenDrawer:
-		result, err = d.handleDrawerOperation(ctx, operation)
+		handle = d.handleDrawerOperation
 	case model.OperationTypeStatusCheck:
-		result, err = d.handleStatusOperation(ctx, operation)
+		handle = d.handleStatusOperation
+	case model.OperationTypeBeep:
+		handle = d.handleBeepOperation
 	default:
-		return nil, fmt.Errorf("unsupported operation: %s", operation.OperationType)
+		return nil, driver.NewOperationError(driver.ErrorCodeUnsupported,
+			fmt.Errorf("unsupported operation: %s", operation.OperationType))
 	}
 
+	// Handlers hold the I/O mutex; classifyError takes it again for its
+	// status query
+	result, err := func() (*driver.OperationResult, error) {
+		d.ioMutex.Lock()
+		defer d.ioMutex.Unlock()
+		return handle(ctx, operation)
+	}()
+
 	duration := time.Since(startTime)
 
 	if err != nil {
+		err = d.classifyError(err)
 		d.updateHealthMetrics(false, duration, err)
 		return nil, err
 	}
@@ -301,8 +491,14 @@ func (d *EPSONDriver) Ping(ctx context.Context) error {
 		return fmt.Errorf("device not connected")
 	}
 
+	pingCtx, cancel := d.policy.PingContext(ctx)
+	defer cancel()
+
+	d.ioMutex.Lock()
+	defer d.ioMutex.Unlock()
+
 	startTime := time.Now()
-	err := d.protocol.Ping(ctx)
+	err := d.protocol.Ping(pingCtx)
 
 	if err != nil {
 		d.updateHealthMetrics(false, time.Since(startTime), err)
@@ -319,6 +515,22 @@ func (d *EPSONDriver) GetHealthMetrics() (*driver.HealthMetrics, error) {
 	d.mutex.RLock()
 	defer d.mutex.RUnlock()
 	metrics := *d.healthMetrics
+
+	// Attach transport counters of the active connection
+	if provider, ok := d.protocol.(protocol.StatsProvider); ok {
+		stats := provider.Stats()
+		metrics.Transport = &driver.TransportStats{
+			ConnectionType: d.config.ConnectionType,
+			BytesWritten:   stats.BytesWritten,
+			BytesRead:      stats.BytesRead,
+			WriteErrors:    stats.WriteErrors,
+			ReadErrors:     stats.ReadErrors,
+			Reconnects:     d.reconnects,
+			AverageLatency: stats.AverageLatency,
+			LastActivity:   stats.LastActivity,
+		}
+	}
+
 	return &metrics, nil
 }
 
@@ -329,22 +541,41 @@ func (d *EPSONDriver) Configure(config interface{}) error {
 		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	// Operations read the config while holding the I/O mutex
+	d.ioMutex.Lock()
+	defer d.ioMutex.Unlock()
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
 	d.config = newConfig
-	d.deviceInfo.Capabilities = getEPSONCapabilities(newConfig)
+	d.deviceInfo.Capabilities = getEPSONCapabilities(d.deviceInfo.Brand, newConfig)
+	if enabled, ok := newConfig.ConnectionConfig[driver.WireLoggingKey].(bool); ok {
+		d.wireLogger.SetEnabled(enabled)
+	}
 
 	d.logger.Info("EPSON printer reconfigured")
 	return nil
 }
 
+// SetWireLogging turns hex dumps of protocol traffic on or off
+func (d *EPSONDriver) SetWireLogging(enabled bool) {
+	d.wireLogger.SetEnabled(enabled)
+}
+
+// WireLogging reports whether protocol traffic is being logged
+func (d *EPSONDriver) WireLogging() bool {
+	return d.wireLogger.Enabled()
+}
+
 // Reset resets the device
 func (d *EPSONDriver) Reset(ctx context.Context) error {
 	if !d.IsConnected() {
 		return fmt.Errorf("device not connected")
 	}
 
+	d.ioMutex.Lock()
+	defer d.ioMutex.Unlock()
+
 	if err := d.sendCommands(ctx, [][]byte{ESC_POS_COMMANDS.INITIALIZE}); err != nil {
 		return fmt.Errorf("failed to reset printer: %w", err)
 	}
@@ -353,6 +584,35 @@ func (d *EPSONDriver) Reset(ctx context.Context) error {
 	return nil
 }
 
+// SetBuzzer enables BEEP operations on printers of ESC/POS compatible brands
+// that sound their buzzer with their own commands
+func (d *EPSONDriver) SetBuzzer(buzzer Buzzer) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.buzzer = buzzer
+}
+
+// EPSON buzzer limits of ESC ( A fn=97: c cycles of t x 100 ms
+const (
+	maxBeepCycles   = 63
+	minBeepUnits    = 10
+	maxBeepUnits    = 255
+	beepUnitMs      = 100
+	beepPatternFunc = 97
+	beepPattern     = 1
+)
+
+// buzzerCommands sounds the EPSON buzzer with ESC ( A pL pH fn n c t. The
+// cycle time is rounded to 100 ms units; counts and durations beyond the
+// printer's range are capped.
+func buzzerCommands(count, durationMs int) ([][]byte, int, int) {
+	cycles := min(count, maxBeepCycles)
+	units := min(max((durationMs+beepUnitMs/2)/beepUnitMs, minBeepUnits), maxBeepUnits)
+
+	command := []byte{0x1B, 0x28, 0x41, 0x04, 0x00, beepPatternFunc, beepPattern, byte(cycles), byte(units)}
+	return [][]byte{command}, cycles, units * beepUnitMs
+}
+
 // SetEventHandler sets event handler
 func (d *EPSONDriver) SetEventHandler(handler driver.EventHandler) {
 	d.eventHandler = handler
@@ -368,19 +628,50 @@ func (d *EPSONDriver) Close() error {
 // sendCommands sends commands to printer using protocol
 func (d *EPSONDriver) sendCommands(ctx context.Context, commands [][]byte) error {
 	if d.protocol == nil {
-		return fmt.Errorf("no protocol connection")
+		return driver.NewOperationError(driver.ErrorCodeConnectionLost, fmt.Errorf("no protocol connection"))
 	}
 
 	for _, cmd := range commands {
 		if err := d.protocol.Write(ctx, cmd); err != nil {
-			return fmt.Errorf("failed to send command: %w", err)
+			code := driver.ErrorCodeConnectionLost
+			if ctx.Err() != nil {
+				code = driver.ErrorCodeTimeout
+			}
+			return driver.NewOperationError(code, fmt.Errorf("failed to send command: %w", err))
 		}
 	}
 
 	return nil
 }
 
-// readResponse reads response from printer using protocol
+// classifyError tags an operation failure with an error code. When the
+// printer can still answer, its real-time status explains a failure better
+// than the transport error (e.g. paper out instead of a write timeout).
+func (d *EPSONDriver) classifyError(err error) error {
+	code := driver.ErrorCodeOf(err)
+
+	switch code {
+	case driver.ErrorCodeUnsupported, driver.ErrorCodeInvalidRequest, driver.ErrorCodeCapabilityDisabled:
+		return err
+	case driver.ErrorCodeConnectionLost, driver.ErrorCodeTimeout, "":
+		if d.protocol != nil && d.protocol.IsOpen() && d.readback.Load() {
+			ctx, cancel := context.WithTimeout(context.Background(), realtimeStatusTimeout)
+			defer cancel()
+
+			if realtime, statusErr := d.queryRealtimeStatus(ctx); statusErr == nil {
+				if statusCode, message := realtime.errorCode(); statusCode != "" {
+					return driver.NewOperationError(statusCode, fmt.Errorf("%s: %w", message, err))
+				}
+			}
+		}
+	}
+
+	return err
+}
+
+// readResponse reads a response from the printer. The read waits at most
+// timeout, and less when the caller's context ends first; a cancelled context
+// unblocks the read in the protocol layer.
 func (d *EPSONDriver) readResponse(ctx context.Context, timeout time.Duration) ([]byte, error) {
 	if d.protocol == nil {
 		return nil, fmt.Errorf("no protocol connection")
@@ -472,6 +763,101 @@ func parseConnectionConfig(config interface{}) (map[string]interface{}, error) {
 	return configMap, nil
 }
 
+// applyPrintSettings applies the device's configured paper width, default
+// content type, line spacing, compact mode, cut behavior and print size
+// limits, ignoring unsupported values
+func applyPrintSettings(config *EPSONConfig, settings map[string]interface{}) {
+	switch width := settings["paper_width"].(type) {
+	case float64:
+		if int(width) == 58 || int(width) == 80 {
+			config.PaperWidth = int(width)
+		}
+	case int:
+		if width == 58 || width == 80 {
+			config.PaperWidth = width
+		}
+	}
+
+	if contentType, ok := settings["default_content_type"].(string); ok && contentType != "" {
+		config.DefaultContentType = strings.ToUpper(contentType)
+	}
+
+	switch spacing := settings["line_spacing"].(type) {
+	case float64:
+		if spacing >= 0 && spacing <= maxLineSpacing {
+			config.LineSpacing = int(spacing)
+		}
+	case int:
+		if spacing >= 0 && spacing <= maxLineSpacing {
+			config.LineSpacing = spacing
+		}
+	}
+
+	if compact, ok := settings["compact_mode"].(bool); ok {
+		config.CompactMode = compact
+	}
+
+	switch lines := settings["cut_feed_lines"].(type) {
+	case float64:
+		if lines >= 0 && lines <= maxCutFeedLines {
+			config.CutFeedLines = int(lines)
+		}
+	case int:
+		if lines >= 0 && lines <= maxCutFeedLines {
+			config.CutFeedLines = lines
+		}
+	}
+
+	if autoCut, ok := settings["auto_cut"].(bool); ok {
+		config.AutoCut = autoCut
+	}
+
+	if font, ok := settings["font"].(string); ok {
+		switch font = strings.ToUpper(font); font {
+		case FontA, FontB:
+			config.Font = font
+		}
+	}
+
+	if limit, ok := positiveSetting(settings["max_content_bytes"]); ok {
+		config.MaxContentBytes = limit
+	}
+	if limit, ok := positiveSetting(settings["max_job_bytes"]); ok {
+		config.MaxJobBytes = limit
+	}
+}
+
+// positiveSetting reads a positive integer setting
+func positiveSetting(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		if v > 0 {
+			return int(v), true
+		}
+	case int:
+		if v > 0 {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// applyCapabilityFlags applies the device's runtime capability toggles
+func applyCapabilityFlags(config *EPSONConfig, settings map[string]interface{}) {
+	if enabled, ok := driver.CapabilityFlag(settings, model.CapabilityCut); ok {
+		config.EnableCutter = enabled
+	}
+	if enabled, ok := driver.CapabilityFlag(settings, model.CapabilityDrawer); ok {
+		config.EnableDrawer = enabled
+	}
+	if enabled, ok := driver.CapabilityFlag(settings, model.CapabilityLogo); ok {
+		config.LogoEnabled = enabled
+	}
+	if enabled, ok := driver.CapabilityFlag(settings, model.CapabilityTwoColor); ok {
+		config.TwoColorEnabled = enabled
+	}
+}
+
 // parseEPSONConfig parses and validates EPSON configuration
 func parseEPSONConfig(config interface{}) (*EPSONConfig, error) {
 	var configMap map[string]interface{}
@@ -497,13 +883,18 @@ func parseEPSONConfig(config interface{}) (*EPSONConfig, error) {
 	}
 
 	epsonConfig := &EPSONConfig{
-		PaperWidth:   80,
-		CharacterSet: "PC437",
-		CutType:      "FULL",
-		DrawerPin:    0,
-		EnableDrawer: true,
-		EnableCutter: true,
-		LogoEnabled:  false,
+		PaperWidth:         80,
+		DefaultContentType: "TEXT",
+		MaxContentBytes:    defaultMaxContentBytes,
+		MaxJobBytes:        defaultMaxJobBytes,
+		CharacterSet:       "PC437",
+		CutType:            "FULL",
+		CutFeedLines:       defaultCutFeedLines,
+		Font:               FontA,
+		DrawerPin:          0,
+		EnableDrawer:       true,
+		EnableCutter:       true,
+		LogoEnabled:        false,
 	}
 
 	if deviceID, ok := configMap["device_id"].(string); ok {
@@ -511,33 +902,38 @@ func parseEPSONConfig(config interface{}) (*EPSONConfig, error) {
 	}
 	if deviceModel, ok := configMap["model"].(string); ok {
 		epsonConfig.Model = deviceModel
+		epsonConfig.TwoColorEnabled = isTwoColorModel(deviceModel)
 	}
 	if connType, ok := configMap["connection_type"].(string); ok {
 		epsonConfig.ConnectionType = model.ConnectionType(connType)
 	}
 	if connConfig, ok := configMap["connection_config"].(map[string]interface{}); ok {
 		epsonConfig.ConnectionConfig = connConfig
+		applyPrintSettings(epsonConfig, connConfig)
+		applyCapabilityFlags(epsonConfig, connConfig)
 	}
 
 	return epsonConfig, nil
 }
 
-// getEPSONCapabilities returns device capabilities based on configuration
-func getEPSONCapabilities(config *EPSONConfig) []model.Capability {
-	capabilities := []model.Capability{
-		model.CapabilityPrint,
-		model.CapabilityStatus,
-	}
-
-	if config.EnableCutter {
-		capabilities = append(capabilities, model.CapabilityCut)
-	}
-	if config.EnableDrawer {
-		capabilities = append(capabilities, model.CapabilityDrawer)
+// getEPSONCapabilities returns the brand's printer capabilities without the
+// ones switched off in the configuration
+func getEPSONCapabilities(brand model.DeviceBrand, config *EPSONConfig) []model.Capability {
+	capabilities := []model.Capability{}
+	for _, capability := range driver.DefaultCapabilities(model.DeviceTypePrinter, brand) {
+		switch {
+		case capability == model.CapabilityCut && !config.EnableCutter:
+		case capability == model.CapabilityDrawer && !config.EnableDrawer:
+		default:
+			capabilities = append(capabilities, capability)
+		}
 	}
 	if config.LogoEnabled {
 		capabilities = append(capabilities, model.CapabilityLogo)
 	}
+	if config.TwoColorEnabled {
+		capabilities = append(capabilities, model.CapabilityTwoColor)
+	}
 
 	return capabilities
 }
@@ -549,7 +945,8 @@ func (d *EPSONDriver) handlePrintOperation(ctx context.Context, operation *model
 	// Parse operation data
 	printData, err := d.parsePrintOperationData(operation.OperationData)
 	if err != nil {
-		return nil, fmt.Errorf("invalid print operation data: %w", err)
+		return nil, driver.NewOperationError(driver.ErrorCodeInvalidRequest,
+			fmt.Errorf("invalid print operation data: %w", err))
 	}
 
 	// Build command sequence
@@ -581,13 +978,13 @@ func (d *EPSONDriver) handlePrintOperation(ctx context.Context, operation *model
 	return &driver.OperationResult{
 		Success: true,
 		Data: map[string]interface{}{
-			"printed":        true,
-			"content_length": len(printData.Content),
-			"lines_printed":  strings.Count(printData.Content, "\n") + 1,
-			"copies":         printData.Copies,
-			"cut_performed":  printData.Cut,
-			"drawer_opened":  printData.OpenDrawer,
-			"print_duration": duration.Milliseconds(),
+			driver.ResultKeyPrinted:       true,
+			driver.ResultKeyContentLength: len(printData.Content),
+			driver.ResultKeyLinesPrinted:  strings.Count(printData.Content, "\n") + 1,
+			driver.ResultKeyCopies:        printData.Copies,
+			driver.ResultKeyCutPerformed:  printData.Cut && d.config.EnableCutter,
+			driver.ResultKeyDrawerOpened:  printData.OpenDrawer && d.config.EnableDrawer,
+			driver.ResultKeyDurationMs:    duration.Milliseconds(),
 		},
 		Duration:  duration.String(),
 		Timestamp: time.Now(),
@@ -599,7 +996,8 @@ func (d *EPSONDriver) handleCutOperation(ctx context.Context, operation *model.D
 	d.logger.Info("Processing cut operation", zap.String("operation_id", operation.ID.String()))
 
 	if !d.config.EnableCutter {
-		return nil, fmt.Errorf("cutter is disabled on this device")
+		return nil, driver.NewOperationError(driver.ErrorCodeCapabilityDisabled,
+			fmt.Errorf("%w: %s is disabled on this device", driver.ErrCapabilityDisabled, model.CapabilityCut))
 	}
 
 	// Parse cut type from operation data
@@ -618,7 +1016,8 @@ func (d *EPSONDriver) handleCutOperation(ctx context.Context, operation *model.D
 	case "PARTIAL":
 		cutCommand = ESC_POS_COMMANDS.CUT_PARTIAL
 	default:
-		return nil, fmt.Errorf("invalid cut type: %s", cutType)
+		return nil, driver.NewOperationError(driver.ErrorCodeInvalidRequest,
+			fmt.Errorf("invalid cut type: %s", cutType))
 	}
 
 	// Send cut command
@@ -641,9 +1040,9 @@ func (d *EPSONDriver) handleCutOperation(ctx context.Context, operation *model.D
 	return &driver.OperationResult{
 		Success: true,
 		Data: map[string]interface{}{
-			"cut":          true,
-			"cut_type":     cutType,
-			"cut_duration": duration.Milliseconds(),
+			driver.ResultKeyCutPerformed: true,
+			driver.ResultKeyCutType:      cutType,
+			driver.ResultKeyDurationMs:   duration.Milliseconds(),
 		},
 		Duration:  duration.String(),
 		Timestamp: time.Now(),
@@ -655,7 +1054,8 @@ func (d *EPSONDriver) handleDrawerOperation(ctx context.Context, operation *mode
 	d.logger.Info("Processing drawer operation", zap.String("operation_id", operation.ID.String()))
 
 	if !d.config.EnableDrawer {
-		return nil, fmt.Errorf("cash drawer is disabled on this device")
+		return nil, driver.NewOperationError(driver.ErrorCodeCapabilityDisabled,
+			fmt.Errorf("%w: %s is disabled on this device", driver.ErrCapabilityDisabled, model.CapabilityDrawer))
 	}
 
 	// Parse drawer pin from operation data
@@ -681,7 +1081,8 @@ func (d *EPSONDriver) handleDrawerOperation(ctx context.Context, operation *mode
 	case 1, 5:
 		drawerCommand = ESC_POS_COMMANDS.DRAWER_KICK_PIN5
 	default:
-		return nil, fmt.Errorf("invalid drawer pin: %d (supported: 0/2 or 1/5)", pin)
+		return nil, driver.NewOperationError(driver.ErrorCodeInvalidRequest,
+			fmt.Errorf("invalid drawer pin: %d (supported: 0/2 or 1/5)", pin))
 	}
 
 	// Send drawer command
@@ -704,9 +1105,9 @@ func (d *EPSONDriver) handleDrawerOperation(ctx context.Context, operation *mode
 	return &driver.OperationResult{
 		Success: true,
 		Data: map[string]interface{}{
-			"drawer_opened":   true,
-			"pin_used":        pin,
-			"drawer_duration": duration.Milliseconds(),
+			driver.ResultKeyDrawerOpened: true,
+			driver.ResultKeyDrawerPin:    pin,
+			driver.ResultKeyDurationMs:   duration.Milliseconds(),
 		},
 		Duration:  duration.String(),
 		Timestamp: time.Now(),
@@ -720,18 +1121,21 @@ func (d *EPSONDriver) handleStatusOperation(ctx context.Context, operation *mode
 	startTime := time.Now()
 
 	// Get basic status
-	status, err := d.GetStatus()
+	status, err := d.statusLocked()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get device status: %w", err)
 	}
 
 	// Request detailed status from printer
-	statusData, err := d.requestDetailedStatus(ctx)
+	statusData, rawResponse, err := d.requestDetailedStatus(ctx)
 	if err != nil {
-		d.logger.Warn("Failed to get detailed status", zap.Error(err))
+		if d.readback.Load() {
+			d.logger.Warn("Failed to get detailed status", zap.Error(err))
+		}
 		// Don't fail, just use basic status
 		statusData = map[string]interface{}{
 			"basic_status_only": true,
+			"status_readback":   d.readback.Load(),
 		}
 	}
 
@@ -744,13 +1148,13 @@ func (d *EPSONDriver) handleStatusOperation(ctx context.Context, operation *mode
 	duration := time.Since(startTime)
 
 	result := map[string]interface{}{
-		"status":          status,
-		"detailed_status": statusData,
-		"last_ping":       d.lastPing,
-		"connection_type": d.config.ConnectionType,
-		"model":           d.config.Model,
-		"capabilities":    d.GetCapabilities(),
-		"status_duration": duration.Milliseconds(),
+		driver.ResultKeyStatus:         status,
+		driver.ResultKeyDetailedStatus: statusData,
+		driver.ResultKeyDurationMs:     duration.Milliseconds(),
+		"last_ping":                    driver.OptionalTime(d.lastPing),
+		"connection_type":              d.config.ConnectionType,
+		"model":                        d.config.Model,
+		"capabilities":                 d.GetCapabilities(),
 	}
 
 	if deviceInfo != nil {
@@ -763,10 +1167,11 @@ func (d *EPSONDriver) handleStatusOperation(ctx context.Context, operation *mode
 	)
 
 	return &driver.OperationResult{
-		Success:   true,
-		Data:      result,
-		Duration:  duration.String(),
-		Timestamp: time.Now(),
+		Success:     true,
+		Data:        result,
+		Duration:    duration.String(),
+		Timestamp:   time.Now(),
+		RawResponse: rawResponse,
 	}, nil
 }
 
@@ -774,6 +1179,14 @@ func (d *EPSONDriver) handleStatusOperation(ctx context.Context, operation *mode
 func (d *EPSONDriver) handleBeepOperation(ctx context.Context, operation *model.DeviceOperation) (*driver.OperationResult, error) {
 	d.logger.Info("Processing beep operation", zap.String("operation_id", operation.ID.String()))
 
+	d.mutex.RLock()
+	buzzer := d.buzzer
+	d.mutex.RUnlock()
+	if buzzer == nil {
+		return nil, driver.NewOperationError(driver.ErrorCodeUnsupported,
+			fmt.Errorf("printer has no buzzer"))
+	}
+
 	// Parse beep parameters
 	beepCount := 1
 	beepDuration := 100 // milliseconds
@@ -797,20 +1210,14 @@ func (d *EPSONDriver) handleBeepOperation(ctx context.Context, operation *model.
 		beepDuration = 2000
 	}
 
+	if beepCount < 1 || beepDuration < 1 {
+		return nil, driver.NewOperationError(driver.ErrorCodeInvalidRequest,
+			fmt.Errorf("beep count and duration must be positive"))
+	}
+
 	startTime := time.Now()
 
-	// Create beep sequence (using buzzer command if available)
-	// EPSON printers typically use ESC ( A for buzzer
-	beepCommand := []byte{0x1B, 0x28, 0x41, 0x04, 0x00, 0x01, 0x01, 0x01}
-
-	commands := [][]byte{}
-	for i := 0; i < beepCount; i++ {
-		commands = append(commands, beepCommand)
-		if i < beepCount-1 {
-			// Add delay between beeps (implemented by sending line feeds)
-			commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
-		}
-	}
+	commands, beepCount, beepDuration := buzzer(beepCount, beepDuration)
 
 	if err := d.sendCommands(ctx, commands); err != nil {
 		return nil, fmt.Errorf("failed to send beep commands: %w", err)
@@ -827,10 +1234,10 @@ func (d *EPSONDriver) handleBeepOperation(ctx context.Context, operation *model.
 	return &driver.OperationResult{
 		Success: true,
 		Data: map[string]interface{}{
-			"beep_executed":  true,
-			"beep_count":     beepCount,
-			"beep_duration":  beepDuration,
-			"total_duration": duration.Milliseconds(),
+			driver.ResultKeyBeepPerformed:  true,
+			driver.ResultKeyBeepCount:      beepCount,
+			driver.ResultKeyBeepDurationMs: beepDuration,
+			driver.ResultKeyDurationMs:     duration.Milliseconds(),
 		},
 		Duration:  duration.String(),
 		Timestamp: time.Now(),
@@ -842,9 +1249,9 @@ func (d *EPSONDriver) handleBeepOperation(ctx context.Context, operation *model.
 // parsePrintOperationData parses print operation data
 func (d *EPSONDriver) parsePrintOperationData(data model.JSONObject) (*PrintOperationData, error) {
 	printData := &PrintOperationData{
-		ContentType: "TEXT",
+		ContentType: d.config.DefaultContentType,
 		Copies:      1,
-		Cut:         false,
+		Cut:         d.config.AutoCut,
 		OpenDrawer:  false,
 		Logo:        false,
 		Options:     make(map[string]string),
@@ -895,6 +1302,16 @@ func (d *EPSONDriver) parsePrintOperationData(data model.JSONObject) (*PrintOper
 		}
 	}
 
+	if autoFallback, ok := data["auto_fallback"].(bool); ok {
+		printData.AutoFallback = autoFallback
+	}
+
+	if encoding, ok := data["encoding"]; ok {
+		if e, ok := encoding.(string); ok {
+			printData.Encoding = strings.ToUpper(e)
+		}
+	}
+
 	if options, ok := data["options"]; ok {
 		if opts, ok := options.(map[string]interface{}); ok {
 			for k, v := range opts {
@@ -909,15 +1326,72 @@ func (d *EPSONDriver) parsePrintOperationData(data model.JSONObject) (*PrintOper
 	if printData.Content == "" {
 		return nil, fmt.Errorf("content cannot be empty")
 	}
-	if printData.Copies < 1 || printData.Copies > 10 {
-		return nil, fmt.Errorf("copies must be between 1 and 10")
+	if _, err := lookupTextEncoding(printData.Encoding); err != nil {
+		return nil, err
+	}
+	if len(printData.Content) > d.config.MaxContentBytes {
+		return nil, fmt.Errorf("content is %d bytes, the limit is %d bytes",
+			len(printData.Content), d.config.MaxContentBytes)
+	}
+
+	// Large content allows fewer copies, so the whole job stays within limits
+	allowedCopies := d.config.MaxJobBytes / len(printData.Content)
+	if allowedCopies > maxCopies {
+		allowedCopies = maxCopies
+	}
+	if allowedCopies < 1 {
+		allowedCopies = 1
+	}
+	if printData.Copies < 1 || printData.Copies > allowedCopies {
+		return nil, fmt.Errorf("copies must be between 1 and %d for %d bytes of content",
+			allowedCopies, len(printData.Content))
+	}
+
+	// Content must be what its type declares; only a receipt can fall back
+	if err := validatePrintContent(printData.ContentType, printData.Content); err != nil {
+		if !printData.AutoFallback || printData.ContentType != "RECEIPT" {
+			return nil, err
+		}
+		d.logger.Warn("Printing RECEIPT content as formatted text", zap.Error(err))
 	}
 
 	return printData, nil
 }
 
+// validatePrintContent checks that content matches its content type: a JSON
+// receipt for RECEIPT and hex or base64 bytes for ESC_POS. Text needs no
+// particular shape; PDF content is checked while it is decoded.
+func validatePrintContent(contentType, content string) error {
+	switch contentType {
+	case "RECEIPT":
+		var receipt ReceiptData
+		if err := json.Unmarshal([]byte(content), &receipt); err != nil {
+			return fmt.Errorf("RECEIPT content must be a JSON receipt (set auto_fallback to print it as text): %w", err)
+		}
+	case "ESC_POS":
+		if _, err := decodeRawContent(content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeRawContent decodes ESC_POS content, given as hex ("1B 40 0A") or
+// base64. Whitespace is ignored; content that is valid hex is read as hex.
+func decodeRawContent(content string) ([]byte, error) {
+	compact := strings.Join(strings.Fields(content), "")
+	if data, err := hex.DecodeString(compact); err == nil {
+		return data, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(compact)
+	if err != nil {
+		return nil, fmt.Errorf("ESC_POS content must be hex or base64 encoded: %w", err)
+	}
+	return data, nil
+}
+
 // buildHTMLCommands builds commands for HTML content (simplified)
-func (d *EPSONDriver) buildHTMLCommands(content string) ([][]byte, error) {
+func (d *EPSONDriver) buildHTMLCommands(content string, enc *textEncoding) ([][]byte, error) {
 	// This is a simplified HTML to ESC/POS converter
 	// In a real implementation, you'd use a proper HTML parser
 
@@ -943,7 +1417,7 @@ func (d *EPSONDriver) buildHTMLCommands(content string) ([][]byte, error) {
 	}
 
 	// Convert to text commands
-	return d.buildTextCommands(text, make(map[string]string))
+	return d.buildTextCommands(text, make(map[string]string), enc)
 }
 
 // waitForPrintCompletion waits for print operation to complete
@@ -970,31 +1444,38 @@ func (d *EPSONDriver) waitForPrintCompletion(ctx context.Context, printData *Pri
 	}
 }
 
-// requestDetailedStatus requests detailed status from printer
-func (d *EPSONDriver) requestDetailedStatus(ctx context.Context) (map[string]interface{}, error) {
+// requestDetailedStatus requests detailed status from printer, returning the
+// parsed status along with the raw response bytes. Callers must hold the I/O
+// mutex.
+func (d *EPSONDriver) requestDetailedStatus(ctx context.Context) (map[string]interface{}, []byte, error) {
+	if !d.readback.Load() {
+		return nil, nil, errStatusReadbackUnsupported
+	}
+
+	d.discardStaleReply(ctx)
+
 	// Send status request command
 	if err := d.sendCommands(ctx, [][]byte{ESC_POS_COMMANDS.STATUS_REQUEST}); err != nil {
-		return nil, fmt.Errorf("failed to send status request: %w", err)
+		return nil, nil, fmt.Errorf("failed to send status request: %w", err)
 	}
 
 	// Read response with timeout
 	responseCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
-	response, err := d.readResponse(responseCtx, 2*time.Second)
+	response, err := d.readStatusReply(responseCtx, 2*time.Second)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read status response: %w", err)
+		return nil, nil, fmt.Errorf("failed to read status response: %w", err)
 	}
 
 	// Parse status response
 	statusData := d.parseStatusResponse(response)
-	return statusData, nil
+	return statusData, response, nil
 }
 
 // parseStatusResponse parses printer status response
 func (d *EPSONDriver) parseStatusResponse(response []byte) map[string]interface{} {
 	status := map[string]interface{}{
-		"raw_response":    fmt.Sprintf("%x", response),
 		"response_length": len(response),
 	}
 
@@ -1015,17 +1496,227 @@ func (d *EPSONDriver) parseStatusResponse(response []byte) map[string]interface{
 	return status
 }
 
+// realtimeStatusTimeout bounds a full DLE EOT status exchange
+const realtimeStatusTimeout = 2 * time.Second
+
+// realtimeStatus is the printer state reported by DLE EOT 1-4
+type realtimeStatus struct {
+	Offline              bool `json:"offline"`
+	DrawerOpen           bool `json:"drawer_open"`
+	WaitingForRecovery   bool `json:"waiting_for_recovery"`
+	CoverOpen            bool `json:"cover_open"`
+	PaperFeeding         bool `json:"paper_feeding"`
+	PaperEndStop         bool `json:"paper_end_stop"`
+	ErrorOccurred        bool `json:"error_occurred"`
+	MechanicalError      bool `json:"mechanical_error"`
+	AutocutterError      bool `json:"autocutter_error"`
+	UnrecoverableError   bool `json:"unrecoverable_error"`
+	AutoRecoverableError bool `json:"auto_recoverable_error"`
+	PaperNearEnd         bool `json:"paper_near_end"`
+	PaperEnd             bool `json:"paper_end"`
+}
+
+// statusReadbackProbeTimeout bounds the connect-time status probe
+const statusReadbackProbeTimeout = 500 * time.Millisecond
+
+// errStatusReadbackUnsupported is returned for status requests to printers
+// that don't answer them
+var errStatusReadbackUnsupported = driver.NewOperationError(driver.ErrorCodeUnsupported,
+	fmt.Errorf("printer does not support status readback"))
+
+// probeStatusReadback reports whether the printer answers status requests:
+// the connection must be readable and DLE EOT 1 must get a reply. Callers
+// must hold the I/O mutex.
+func (d *EPSONDriver) probeStatusReadback(ctx context.Context) bool {
+	if !protocol.CanRead(d.protocol) {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, statusReadbackProbeTimeout)
+	defer cancel()
+
+	// Input left from before the connect isn't a reply to the probe
+	d.staleReply = true
+	d.discardStaleReply(ctx)
+
+	if err := d.sendCommands(ctx, [][]byte{ESC_POS_COMMANDS.STATUS_REQUEST}); err != nil {
+		return false
+	}
+	response, err := d.readStatusReply(ctx, statusReadbackProbeTimeout)
+	return err == nil && len(response) > 0
+}
+
+// staleReplyPoll is how long discardStaleReply waits for more stale input
+const staleReplyPoll = 20 * time.Millisecond
+
+// maxStaleReplyReads bounds the reads discardStaleReply makes, in case the
+// printer keeps sending, e.g. automatic status back
+const maxStaleReplyReads = 16
+
+// readStatusReply reads the reply to a status request. A reply that doesn't
+// arrive in time may still arrive later, so it is discarded before the next
+// request instead of being taken as that request's reply. Callers must hold
+// the I/O mutex.
+func (d *EPSONDriver) readStatusReply(ctx context.Context, timeout time.Duration) ([]byte, error) {
+	response, err := d.readResponse(ctx, timeout)
+	if err != nil || len(response) == 0 {
+		d.staleReply = true
+	}
+	return response, err
+}
+
+// discardStaleReply reads and drops input left by a status request whose
+// reply timed out. Callers must hold the I/O mutex.
+func (d *EPSONDriver) discardStaleReply(ctx context.Context) {
+	if !d.staleReply {
+		return
+	}
+	for i := 0; i < maxStaleReplyReads; i++ {
+		data, err := d.readResponse(ctx, staleReplyPoll)
+		if err != nil || len(data) == 0 {
+			break
+		}
+		d.logger.Debug("Discarded late status reply", zap.Int("bytes", len(data)))
+	}
+	d.staleReply = false
+}
+
+// queryRealtimeStatus sends DLE EOT 1-4 and decodes the status bytes
+func (d *EPSONDriver) queryRealtimeStatus(ctx context.Context) (*realtimeStatus, error) {
+	d.ioMutex.Lock()
+	defer d.ioMutex.Unlock()
+	return d.queryRealtimeStatusLocked(ctx)
+}
+
+// queryRealtimeStatusLocked is queryRealtimeStatus for callers holding the
+// I/O mutex
+func (d *EPSONDriver) queryRealtimeStatusLocked(ctx context.Context) (*realtimeStatus, error) {
+	if !d.readback.Load() {
+		return nil, errStatusReadbackUnsupported
+	}
+
+	requests := [][]byte{
+		ESC_POS_COMMANDS.STATUS_REQUEST,
+		ESC_POS_COMMANDS.STATUS_OFFLINE_CAUSE,
+		ESC_POS_COMMANDS.STATUS_ERROR_CAUSE,
+		ESC_POS_COMMANDS.STATUS_PAPER_SENSOR,
+	}
+
+	d.discardStaleReply(ctx)
+
+	responses := make([]byte, len(requests))
+	for i, request := range requests {
+		if err := d.sendCommands(ctx, [][]byte{request}); err != nil {
+			return nil, fmt.Errorf("failed to send status request %d: %w", request[2], err)
+		}
+
+		response, err := d.readStatusReply(ctx, realtimeStatusTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read status response %d: %w", request[2], err)
+		}
+		if len(response) == 0 {
+			return nil, fmt.Errorf("empty status response %d", request[2])
+		}
+		responses[i] = response[0]
+	}
+
+	printer, offline, errorCause, paper := responses[0], responses[1], responses[2], responses[3]
+	return &realtimeStatus{
+		DrawerOpen:           printer&0x04 != 0,
+		Offline:              printer&0x08 != 0,
+		WaitingForRecovery:   printer&0x20 != 0,
+		CoverOpen:            offline&0x04 != 0,
+		PaperFeeding:         offline&0x08 != 0,
+		PaperEndStop:         offline&0x20 != 0,
+		ErrorOccurred:        offline&0x40 != 0,
+		MechanicalError:      errorCause&0x04 != 0,
+		AutocutterError:      errorCause&0x08 != 0,
+		UnrecoverableError:   errorCause&0x20 != 0,
+		AutoRecoverableError: errorCause&0x40 != 0,
+		PaperNearEnd:         paper&0x0C != 0,
+		PaperEnd:             paper&0x60 != 0,
+	}, nil
+}
+
+// apply maps the real-time status onto the generic device status
+func (rs *realtimeStatus) apply(status *driver.DeviceStatus) {
+	drawerOpen := rs.DrawerOpen
+	status.DrawerOpen = &drawerOpen
+
+	switch {
+	case rs.PaperEnd || rs.PaperEndStop:
+		status.PaperStatus = driver.PaperStatusOut
+	case rs.PaperNearEnd:
+		status.PaperStatus = driver.PaperStatusLow
+	default:
+		status.PaperStatus = driver.PaperStatusOK
+	}
+
+	status.Details = map[string]interface{}{"realtime": rs}
+
+	code, message := rs.errorCode()
+	if code == "" {
+		return
+	}
+
+	status.HasError = true
+	status.IsReady = false
+	status.ErrorCode = string(code)
+	status.ErrorMessage = message
+	if rs.UnrecoverableError {
+		status.Status = model.DeviceStatusError
+	}
+}
+
+// printerStatus maps the real-time status onto the documented printer status
+func (rs *realtimeStatus) printerStatus() *driver.PrinterStatus {
+	return &driver.PrinterStatus{
+		Online:       !rs.Offline && !rs.ErrorOccurred,
+		PaperPresent: !rs.PaperEnd && !rs.PaperEndStop,
+		PaperNearEnd: rs.PaperNearEnd,
+		CoverOpen:    rs.CoverOpen,
+		CutterError:  rs.AutocutterError,
+		DrawerOpen:   rs.DrawerOpen,
+		CheckedAt:    time.Now(),
+	}
+}
+
+// errorCode returns the most significant error, or empty when none
+func (rs *realtimeStatus) errorCode() (driver.ErrorCode, string) {
+	switch {
+	case rs.UnrecoverableError:
+		return driver.ErrorCodeHardwareError, "printer reported an unrecoverable error"
+	case rs.CoverOpen:
+		return driver.ErrorCodeCoverOpen, "printer cover is open"
+	case rs.PaperEnd || rs.PaperEndStop:
+		return driver.ErrorCodePaperOut, "printer is out of paper"
+	case rs.AutocutterError:
+		return driver.ErrorCodeCutterError, "autocutter error"
+	case rs.MechanicalError:
+		return driver.ErrorCodeHardwareError, "printer mechanical error"
+	case rs.AutoRecoverableError:
+		return driver.ErrorCodeHardwareError, "printer reported an auto-recoverable error"
+	case rs.ErrorOccurred || rs.Offline:
+		return driver.ErrorCodeDeviceOffline, "printer is offline"
+	}
+	return "", ""
+}
+
 // Data structures for operations
 
 // PrintOperationData represents print operation parameters
 type PrintOperationData struct {
 	Content     string            `json:"content"`
-	ContentType string            `json:"content_type"` // TEXT, HTML, ESC_POS, RECEIPT
+	ContentType string            `json:"content_type"` // TEXT, HTML, ESC_POS (hex or base64), RECEIPT, LABEL, PDF
 	Copies      int               `json:"copies"`
 	Cut         bool              `json:"cut"`
 	OpenDrawer  bool              `json:"open_drawer"`
 	Logo        bool              `json:"logo"`
 	Options     map[string]string `json:"options,omitempty"`
+	Encoding    string            `json:"encoding,omitempty"` // code page text is transcoded to, or RAW
+	// AutoFallback prints RECEIPT content that isn't a JSON receipt as
+	// formatted text instead of rejecting it
+	AutoFallback bool `json:"auto_fallback,omitempty"`
 }
 
 // ReceiptData represents structured receipt data
@@ -1034,6 +1725,11 @@ type ReceiptData struct {
 	Items  []ReceiptItem `json:"items"`
 	Total  float64       `json:"total"`
 	Footer string        `json:"footer"`
+
+	// Field colors, BLACK or RED; unset fields use the color option
+	HeaderColor string `json:"header_color,omitempty"`
+	TotalColor  string `json:"total_color,omitempty"`
+	FooterColor string `json:"footer_color,omitempty"`
 }
 
 // ReceiptItem represents a single receipt item
@@ -1041,17 +1737,24 @@ type ReceiptItem struct {
 	Name  string  `json:"name"`
 	Price float64 `json:"price"`
 	Qty   int     `json:"qty,omitempty"`
+	Color string  `json:"color,omitempty"` // BLACK or RED
 }
 
 // buildTextCommands - IMPROVED with better formatting
-func (d *EPSONDriver) buildTextCommands(content string, options map[string]string) ([][]byte, error) {
+func (d *EPSONDriver) buildTextCommands(content string, options map[string]string, enc *textEncoding) ([][]byte, error) {
 	commands := [][]byte{}
 
+	compact := compactMode(options, d.config.CompactMode)
+	font := textFont(options, d.config.Font)
+	commands = append(commands, fontCommand(font))
+
 	// ✅ ALWAYS start with center alignment for better layout
 	commands = append(commands, ESC_POS_COMMANDS.ALIGN_CENTER)
 
 	// ✅ Add header spacing
-	commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+	if !compact {
+		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+	}
 
 	// Parse and apply formatting options
 	if bold, ok := options["bold"]; ok && bold == "true" {
@@ -1062,6 +1765,12 @@ func (d *EPSONDriver) buildTextCommands(content string, options map[string]strin
 		commands = append(commands, ESC_POS_COMMANDS.TEXT_UNDERLINE_ON)
 	}
 
+	color, err := d.colorCommands(options["color"])
+	if err != nil {
+		return nil, err
+	}
+	commands = append(commands, color...)
+
 	// ✅ DEFAULT: Make text larger for better readability
 	textSize := "DOUBLE"
 	if size, ok := options["size"]; ok {
@@ -1094,6 +1803,10 @@ func (d *EPSONDriver) buildTextCommands(content string, options map[string]strin
 		}
 	}
 
+	// Long lines are wrapped or truncated only when requested
+	width := charsPerLine(d.config.PaperWidth, font, textSize)
+	mode := overflowMode(options, "")
+
 	// ✅ Process content line by line with proper spacing
 	lines := strings.Split(content, "\n")
 	for i, line := range lines {
@@ -1101,40 +1814,56 @@ func (d *EPSONDriver) buildTextCommands(content string, options map[string]strin
 		line = strings.TrimSpace(line)
 
 		if line != "" {
-			commands = append(commands, []byte(line))
+			commands = appendFittedLine(commands, line, width, mode, enc)
 		}
 
 		// Add line feed after each line (including empty lines for spacing)
 		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
 
 		// ✅ Add extra spacing between non-empty lines for better readability
-		if line != "" && i < len(lines)-1 {
+		if !compact && line != "" && i < len(lines)-1 {
 			commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
 		}
 	}
 
 	// ✅ Add footer spacing
-	commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
-	commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+	if !compact {
+		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+	}
 
 	// Reset formatting
 	commands = append(commands, ESC_POS_COMMANDS.TEXT_RESET)
 	commands = append(commands, ESC_POS_COMMANDS.ALIGN_LEFT)
+	commands = append(commands, d.resetColorCommands(options["color"])...)
 
 	return commands, nil
 }
 
 // buildReceiptCommands - IMPROVED with better formatting
-func (d *EPSONDriver) buildReceiptCommands(content string, options map[string]string) ([][]byte, error) {
+func (d *EPSONDriver) buildReceiptCommands(content string, options map[string]string, enc *textEncoding) ([][]byte, error) {
 	commands := [][]byte{}
 
 	// Parse receipt data
 	var receipt ReceiptData
 	if err := json.Unmarshal([]byte(content), &receipt); err != nil {
-		// If not JSON, treat as formatted text with better defaults
-		return d.buildFormattedTextCommands(content, options)
+		// Content that isn't JSON only gets here with auto_fallback set;
+		// print it as formatted text
+		return d.buildFormattedTextCommands(content, options, enc)
 	}
 
+	// Compact receipts keep one line feed where the layout needs a line break
+	compact := compactMode(options, d.config.CompactMode)
+
+	// Each field is printed in its own color, defaulting to the color option
+	colors, err := d.receiptColors(&receipt, options["color"])
+	if err != nil {
+		return nil, err
+	}
+
+	font := textFont(options, d.config.Font)
+	commands = append(commands, fontCommand(font))
+
 	// ✅ RECEIPT HEADER with nice formatting
 	if receipt.Header != "" {
 		// Center alignment for header
@@ -1143,43 +1872,62 @@ func (d *EPSONDriver) buildReceiptCommands(content string, options map[string]st
 		commands = append(commands, ESC_POS_COMMANDS.TEXT_BOLD_ON)
 
 		// Add top spacing
-		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
-		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		if !compact {
+			commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+			commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		}
 
-		commands = append(commands, []byte(receipt.Header))
+		commands = append(commands, colors.header...)
+		commands = append(commands, enc.bytes(receipt.Header))
+		commands = append(commands, colors.reset...)
 		commands = append(commands, ESC_POS_COMMANDS.TEXT_BOLD_OFF)
 		commands = append(commands, ESC_POS_COMMANDS.TEXT_SIZE_NORMAL)
 
 		// Header separator
 		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
-		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		if !compact {
+			commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		}
 		commands = append(commands, []byte("================================"))
 		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
-		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		if !compact {
+			commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		}
 	}
 
 	// ✅ ITEMS with better spacing and formatting
 	commands = append(commands, ESC_POS_COMMANDS.ALIGN_LEFT)
 	commands = append(commands, ESC_POS_COMMANDS.TEXT_SIZE_NORMAL)
 
+	// Item names are truncated unless wrapping was requested
+	lineWidth := charsPerLine(d.config.PaperWidth, font, "NORMAL")
+	mode := overflowMode(options, OverflowTruncate)
+
 	for i, item := range receipt.Items {
 		// Item name and price formatting
-		itemLine := formatReceiptLine(item.Name, item.Price, d.config.PaperWidth)
-		commands = append(commands, []byte(itemLine))
-		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		commands = append(commands, colors.items[i]...)
+		for _, itemLine := range formatReceiptLine(item.Name, item.Price, lineWidth, mode) {
+			commands = append(commands, enc.bytes(itemLine))
+			commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		}
+		commands = append(commands, colors.reset...)
 
 		// Add spacing between items
-		if i < len(receipt.Items)-1 {
+		if !compact && i < len(receipt.Items)-1 {
 			commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
 		}
 	}
 
 	// ✅ TOTAL with emphasis
 	if receipt.Total > 0 {
-		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		if !compact {
+			commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		}
 		commands = append(commands, []byte("================================"))
 		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
-		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		if !compact {
+			commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		}
 
 		// Center and emphasize total
 		commands = append(commands, ESC_POS_COMMANDS.ALIGN_CENTER)
@@ -1187,27 +1935,37 @@ func (d *EPSONDriver) buildReceiptCommands(content string, options map[string]st
 		commands = append(commands, ESC_POS_COMMANDS.TEXT_BOLD_ON)
 
 		totalLine := fmt.Sprintf("TOPLAM: %.2f TL", receipt.Total)
-		commands = append(commands, []byte(totalLine))
+		commands = append(commands, colors.total...)
+		commands = append(commands, enc.bytes(totalLine))
+		commands = append(commands, colors.reset...)
 
 		commands = append(commands, ESC_POS_COMMANDS.TEXT_BOLD_OFF)
 		commands = append(commands, ESC_POS_COMMANDS.TEXT_SIZE_NORMAL)
 		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
-		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		if !compact {
+			commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		}
 	}
 
 	// ✅ FOOTER with center alignment
 	if receipt.Footer != "" {
 		commands = append(commands, ESC_POS_COMMANDS.ALIGN_CENTER)
 		commands = append(commands, ESC_POS_COMMANDS.TEXT_SIZE_NORMAL)
-		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
-		commands = append(commands, []byte(receipt.Footer))
+		if !compact {
+			commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		}
+		commands = append(commands, colors.footer...)
+		commands = append(commands, enc.bytes(receipt.Footer))
+		commands = append(commands, colors.reset...)
 		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
 	}
 
 	// ✅ Final spacing
-	commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
-	commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
-	commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+	if !compact {
+		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+	}
 
 	// Reset alignment
 	commands = append(commands, ESC_POS_COMMANDS.ALIGN_LEFT)
@@ -1216,31 +1974,47 @@ func (d *EPSONDriver) buildReceiptCommands(content string, options map[string]st
 }
 
 // ✅ NEW: Better formatted text commands for non-JSON content
-func (d *EPSONDriver) buildFormattedTextCommands(content string, options map[string]string) ([][]byte, error) {
+func (d *EPSONDriver) buildFormattedTextCommands(content string, options map[string]string, enc *textEncoding) ([][]byte, error) {
 	commands := [][]byte{}
 
+	compact := compactMode(options, d.config.CompactMode)
+	font := textFont(options, d.config.Font)
+	commands = append(commands, fontCommand(font))
+
 	// ✅ Start with nice header
 	commands = append(commands, ESC_POS_COMMANDS.ALIGN_CENTER)
-	commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
-	commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+	if !compact {
+		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+	}
 
 	// ✅ Make text bigger and bold for better visibility
 	commands = append(commands, ESC_POS_COMMANDS.TEXT_SIZE_DOUBLE_BOTH)
 	commands = append(commands, ESC_POS_COMMANDS.TEXT_BOLD_ON)
 
+	color, err := d.colorCommands(options["color"])
+	if err != nil {
+		return nil, err
+	}
+	commands = append(commands, color...)
+
+	// Long lines are wrapped or truncated only when requested
+	width := charsPerLine(d.config.PaperWidth, font, "DOUBLE")
+	mode := overflowMode(options, "")
+
 	// Process each line
 	lines := strings.Split(content, "\n")
 	for i, line := range lines {
 		line = strings.TrimSpace(line)
 
 		if line != "" {
-			commands = append(commands, []byte(line))
+			commands = appendFittedLine(commands, line, width, mode, enc)
 		}
 
 		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
 
 		// Extra spacing between non-empty lines
-		if line != "" && i < len(lines)-1 {
+		if !compact && line != "" && i < len(lines)-1 {
 			commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
 		}
 	}
@@ -1248,15 +2022,20 @@ func (d *EPSONDriver) buildFormattedTextCommands(content string, options map[str
 	// ✅ Nice footer with current time
 	commands = append(commands, ESC_POS_COMMANDS.TEXT_BOLD_OFF)
 	commands = append(commands, ESC_POS_COMMANDS.TEXT_SIZE_NORMAL)
-	commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+	commands = append(commands, d.resetColorCommands(options["color"])...)
+	if !compact {
+		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+	}
 	commands = append(commands, []byte("--------------------------------"))
 	commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
 
 	currentTime := time.Now().Format("02.01.2006 15:04:05")
-	commands = append(commands, []byte(currentTime))
-	commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
-	commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+	commands = append(commands, enc.bytes(currentTime))
 	commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+	if !compact {
+		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+	}
 
 	// Reset formatting
 	commands = append(commands, ESC_POS_COMMANDS.TEXT_RESET)
@@ -1265,40 +2044,92 @@ func (d *EPSONDriver) buildFormattedTextCommands(content string, options map[str
 	return commands, nil
 }
 
-// ✅ Helper function to format receipt lines properly
-func formatReceiptLine(name string, price float64, paperWidth int) string {
-	maxNameWidth := paperWidth - 12 // Reserve space for price
-	if maxNameWidth < 10 {
-		maxNameWidth = 10
+// buildPlainTextCommands prints content verbatim in the printer's default
+// font and alignment. Only explicitly requested options apply.
+func (d *EPSONDriver) buildPlainTextCommands(content string, options map[string]string, enc *textEncoding) ([][]byte, error) {
+	font := textFont(options, d.config.Font)
+	commands := [][]byte{fontCommand(font)}
+
+	color, err := d.colorCommands(options["color"])
+	if err != nil {
+		return nil, err
 	}
+	commands = append(commands, color...)
+
+	width := charsPerLine(d.config.PaperWidth, font, "NORMAL")
+	mode := overflowMode(options, "")
 
-	// Truncate name if too long
-	if len(name) > maxNameWidth {
-		name = name[:maxNameWidth-3] + "..."
+	for _, line := range strings.Split(strings.TrimSuffix(content, "\n"), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line != "" {
+			commands = appendFittedLine(commands, line, width, mode, enc)
+		}
+		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
 	}
 
+	commands = append(commands, d.resetColorCommands(options["color"])...)
+
+	return commands, nil
+}
+
+// formatReceiptLine formats an item as name and right-aligned price within
+// lineWidth characters. Long names are truncated, or wrapped onto extra lines
+// with the price on the last one.
+func formatReceiptLine(name string, price float64, lineWidth int, mode string) []string {
 	// Format price
 	priceStr := fmt.Sprintf("%.2f", price)
 
+	maxNameWidth := lineWidth - len(priceStr) - 1 // Reserve space for price
+	if maxNameWidth < 10 {
+		maxNameWidth = 10
+	}
+
+	var nameLines []string
+	if mode == OverflowWrap {
+		nameLines = wrapText(name, maxNameWidth)
+	} else {
+		nameLines = []string{truncateText(name, maxNameWidth)}
+	}
+
 	// Calculate spacing
-	totalUsed := len(name) + len(priceStr)
-	spacesNeeded := paperWidth - totalUsed
+	last := nameLines[len(nameLines)-1]
+	spacesNeeded := lineWidth - utf8.RuneCountInString(last) - len(priceStr)
 	if spacesNeeded < 1 {
 		spacesNeeded = 1
 	}
+	nameLines[len(nameLines)-1] = last + strings.Repeat(" ", spacesNeeded) + priceStr
 
-	return name + strings.Repeat(" ", spacesNeeded) + priceStr
+	return nameLines
+}
+
+// appendFittedLine appends a text line, fitted to width, followed by line feeds
+// between wrapped parts
+func appendFittedLine(commands [][]byte, line string, width int, mode string, enc *textEncoding) [][]byte {
+	for i, part := range fitLine(line, width, mode) {
+		if i > 0 {
+			commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+		}
+		commands = append(commands, enc.bytes(part))
+	}
+	return commands
 }
 
 // ✅ IMPROVED: buildPrintCommands with better defaults
 func (d *EPSONDriver) buildPrintCommands(printData *PrintOperationData) ([][]byte, error) {
 	commands := [][]byte{}
 
+	enc, err := lookupTextEncoding(printData.Encoding)
+	if err != nil {
+		return nil, driver.NewOperationError(driver.ErrorCodeInvalidRequest, err)
+	}
+
 	// Initialize printer
 	commands = append(commands, ESC_POS_COMMANDS.INITIALIZE)
 
-	// Set character set
-	commands = append(commands, ESC_POS_COMMANDS.SELECT_CHARSET_PC437)
+	// Select the code page text is transcoded to
+	if enc.command != nil {
+		commands = append(commands, enc.command)
+	}
 
 	// Set paper width
 	if d.config.PaperWidth == 58 {
@@ -1307,6 +2138,9 @@ func (d *EPSONDriver) buildPrintCommands(printData *PrintOperationData) ([][]byt
 		commands = append(commands, ESC_POS_COMMANDS.SET_WIDTH_80MM)
 	}
 
+	// Line spacing from the request, falling back to the device setting
+	commands = append(commands, lineSpacingCommand(lineSpacing(printData.Options, d.config.LineSpacing)))
+
 	// ✅ Print logo if requested and enabled
 	if printData.Logo && d.config.LogoEnabled {
 		commands = append(commands, ESC_POS_COMMANDS.ALIGN_CENTER)
@@ -1320,30 +2154,41 @@ func (d *EPSONDriver) buildPrintCommands(printData *PrintOperationData) ([][]byt
 		printData.Options = make(map[string]string)
 	}
 
-	// Set better defaults if not specified
-	if _, exists := printData.Options["size"]; !exists {
-		printData.Options["size"] = "DOUBLE" // Make text bigger by default
-	}
-	if _, exists := printData.Options["align"]; !exists {
-		printData.Options["align"] = "CENTER" // Center by default
+	// Set better defaults if not specified, except for plain text
+	plain := printData.ContentType == "TEXT" && plainTextMode(printData.Options)
+	if !plain {
+		if _, exists := printData.Options["size"]; !exists {
+			printData.Options["size"] = "DOUBLE" // Make text bigger by default
+		}
+		if _, exists := printData.Options["align"]; !exists {
+			printData.Options["align"] = "CENTER" // Center by default
+		}
 	}
 
 	// Process content based on type
 	switch printData.ContentType {
 	case "TEXT":
-		textCommands, err := d.buildFormattedTextCommands(printData.Content, printData.Options)
+		build := d.buildFormattedTextCommands
+		if plain {
+			build = d.buildPlainTextCommands
+		}
+		textCommands, err := build(printData.Content, printData.Options, enc)
 		if err != nil {
 			return nil, fmt.Errorf("failed to build text commands: %w", err)
 		}
 		commands = append(commands, textCommands...)
 
 	case "ESC_POS":
-		// Raw ESC/POS data
-		commands = append(commands, []byte(printData.Content))
+		// Raw ESC/POS data, hex or base64 encoded
+		raw, err := decodeRawContent(printData.Content)
+		if err != nil {
+			return nil, driver.NewOperationError(driver.ErrorCodeInvalidRequest, err)
+		}
+		commands = append(commands, raw)
 
 	case "HTML":
 		// Convert HTML to ESC/POS
-		textCommands, err := d.buildHTMLCommands(printData.Content)
+		textCommands, err := d.buildHTMLCommands(printData.Content, enc)
 		if err != nil {
 			return nil, fmt.Errorf("failed to build HTML commands: %w", err)
 		}
@@ -1351,12 +2196,29 @@ func (d *EPSONDriver) buildPrintCommands(printData *PrintOperationData) ([][]byt
 
 	case "RECEIPT":
 		// Structured receipt format
-		receiptCommands, err := d.buildReceiptCommands(printData.Content, printData.Options)
+		receiptCommands, err := d.buildReceiptCommands(printData.Content, printData.Options, enc)
 		if err != nil {
 			return nil, fmt.Errorf("failed to build receipt commands: %w", err)
 		}
 		commands = append(commands, receiptCommands...)
 
+	case "LABEL":
+		// Positioned elements printed in page mode
+		labelCommands, err := d.buildLabelCommands(printData.Content, enc)
+		if err != nil {
+			return nil, driver.NewOperationError(driver.ErrorCodeInvalidRequest,
+				fmt.Errorf("invalid label: %w", err))
+		}
+		commands = append(commands, labelCommands...)
+
+	case "PDF":
+		// Base64 PDF, printed from the raster image of its pages
+		pdfCommands, err := d.buildPDFCommands(printData.Content, printData.Options)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, pdfCommands...)
+
 	default:
 		return nil, fmt.Errorf("unsupported content type: %s", printData.ContentType)
 	}
@@ -1371,7 +2233,7 @@ func (d *EPSONDriver) buildPrintCommands(printData *PrintOperationData) ([][]byt
 			commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
 			commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
 			commands = append(commands, ESC_POS_COMMANDS.ALIGN_CENTER)
-			commands = append(commands, []byte(fmt.Sprintf("--- KOPYA %d ---", i+1)))
+			commands = append(commands, enc.bytes(fmt.Sprintf("--- KOPYA %d ---", i+1)))
 			commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
 			commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
 
@@ -1380,19 +2242,14 @@ func (d *EPSONDriver) buildPrintCommands(printData *PrintOperationData) ([][]byt
 		}
 	}
 
-	// ✅ Final spacing before cut/drawer
-	commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
-	commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
-	commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+	// Feed the last line past the cutter before cut/drawer
+	for i := 0; i < d.config.CutFeedLines; i++ {
+		commands = append(commands, ESC_POS_COMMANDS.LINE_FEED)
+	}
 
 	// Cut paper if requested
 	if printData.Cut && d.config.EnableCutter {
-		switch d.config.CutType {
-		case "PARTIAL":
-			commands = append(commands, ESC_POS_COMMANDS.CUT_PARTIAL)
-		default:
-			commands = append(commands, ESC_POS_COMMANDS.CUT_FULL)
-		}
+		commands = append(commands, d.cutCommand())
 	}
 
 	// Open drawer if requested
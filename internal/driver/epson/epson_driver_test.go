@@ -0,0 +1,268 @@
+// internal/driver/epson/epson_driver_test.go
+package epson
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"device-service/internal/model"
+	"device-service/internal/utils"
+	"device-service/pkg/driver"
+)
+
+func TestDecodeRawContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []byte
+		wantErr bool
+	}{
+		{name: "hex", content: "1B400A", want: []byte{0x1B, 0x40, 0x0A}},
+		{name: "spaced hex", content: "1b 40\n0a", want: []byte{0x1B, 0x40, 0x0A}},
+		{name: "base64", content: "G0AK", want: []byte{0x1B, 0x40, 0x0A}},
+		{name: "plain text", content: "hello world", wantErr: true},
+		{name: "odd hex that is not base64", content: "1B4", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeRawContent(tt.content)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeRawContent(%q) = %x, want an error", tt.content, got)
+				}
+				if err := validatePrintContent("ESC_POS", tt.content); err == nil {
+					t.Fatalf("validatePrintContent accepted %q", tt.content)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(got, tt.want) {
+				t.Fatalf("decodeRawContent(%q) = %x, %v; want %x", tt.content, got, err, tt.want)
+			}
+		})
+	}
+}
+
+// statusPrinter answers every status request with reply once answering is
+// set, and otherwise only hands out the input queued by the test. It keeps
+// every write, each taking writeDelay.
+type statusPrinter struct {
+	mu         sync.Mutex
+	input      [][]byte
+	answering  bool
+	reply      byte
+	writes     [][]byte
+	writeDelay time.Duration
+}
+
+func (p *statusPrinter) Open(ctx context.Context) error { return nil }
+func (p *statusPrinter) Close() error                   { return nil }
+func (p *statusPrinter) IsOpen() bool                   { return true }
+func (p *statusPrinter) Ping(ctx context.Context) error { return nil }
+func (p *statusPrinter) GetProtocolType() model.ConnectionType {
+	return model.ConnectionTypeVirtual
+}
+
+func (p *statusPrinter) Write(ctx context.Context, data []byte) error {
+	time.Sleep(p.writeDelay)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.writes = append(p.writes, append([]byte(nil), data...))
+	if p.answering && isStatusRequest(data) {
+		p.input = append(p.input, []byte{p.reply})
+	}
+	return nil
+}
+
+// isStatusRequest reports whether data is a DLE EOT real-time status request
+func isStatusRequest(data []byte) bool {
+	return bytes.HasPrefix(data, []byte{0x10, 0x04})
+}
+
+func (p *statusPrinter) Read(ctx context.Context, maxBytes int) ([]byte, error) {
+	p.mu.Lock()
+	if len(p.input) > 0 {
+		data := p.input[0]
+		p.input = p.input[1:]
+		p.mu.Unlock()
+		return data, nil
+	}
+	p.mu.Unlock()
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (p *statusPrinter) queue(data ...byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.input = append(p.input, data)
+}
+
+func TestRealtimeStatusDiscardsLateReply(t *testing.T) {
+	printer := &statusPrinter{reply: 0x12}
+	d := newStatusTestDriver(t, printer)
+
+	// The printer is too slow to answer in time
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := d.queryRealtimeStatus(ctx); err == nil {
+		t.Fatal("status query without a reply succeeded")
+	}
+
+	// Its reply arrives late, reporting the drawer open, and the printer
+	// answers the next queries in time
+	printer.queue(0x16)
+	printer.mu.Lock()
+	printer.answering = true
+	printer.mu.Unlock()
+
+	status, err := d.queryRealtimeStatus(context.Background())
+	if err != nil {
+		t.Fatalf("status query failed: %v", err)
+	}
+	if status.DrawerOpen {
+		t.Fatal("late reply to the earlier query was read as the current status")
+	}
+}
+
+func newStatusTestDriver(t *testing.T, printer *statusPrinter) *EPSONDriver {
+	t.Helper()
+	config, err := parseEPSONConfig(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("parseEPSONConfig() failed: %v", err)
+	}
+	device := &model.Device{DeviceID: "PRN-1", Brand: model.BrandEpson, DeviceType: model.DeviceTypePrinter}
+	d := &EPSONDriver{
+		config:        config,
+		protocol:      printer,
+		isConnected:   true,
+		logger:        utils.NewDeviceLogger(zap.NewNop(), device),
+		healthMetrics: &driver.HealthMetrics{},
+	}
+	d.readback.Store(true)
+	return d
+}
+
+func TestStatusPollWaitsForPrintData(t *testing.T) {
+	printer := &statusPrinter{reply: 0x12, answering: true, writeDelay: time.Millisecond}
+	d := newStatusTestDriver(t, printer)
+
+	printed := make(chan error, 1)
+	go func() {
+		_, err := d.ExecuteOperation(context.Background(), &model.DeviceOperation{
+			ID:            uuid.New(),
+			OperationType: model.OperationTypePrint,
+			OperationData: model.JSONObject{"content": "hello", "content_type": "TEXT"},
+		})
+		printed <- err
+	}()
+
+	// Poll while the print is being sent
+	for {
+		printer.mu.Lock()
+		started := len(printer.writes) > 0
+		printer.mu.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(100 * time.Microsecond)
+	}
+	if _, err := d.GetPrinterStatus(context.Background()); err != nil {
+		t.Fatalf("GetPrinterStatus() failed: %v", err)
+	}
+	if err := <-printed; err != nil {
+		t.Fatalf("print failed: %v", err)
+	}
+
+	printer.mu.Lock()
+	defer printer.mu.Unlock()
+	polled := false
+	for _, write := range printer.writes {
+		switch {
+		case isStatusRequest(write):
+			polled = true
+		case polled:
+			t.Fatalf("print data %x interleaved with the status poll", write)
+		}
+	}
+}
+
+// serveTCPPrinter accepts a printer connection on a local port and, when
+// answering, replies to DLE EOT status requests with reply
+func serveTCPPrinter(t *testing.T, answering bool, reply byte) (string, int) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 1024)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return
+			}
+			if answering && bytes.Contains(buf[:n], []byte{0x10, 0x04}) {
+				conn.Write([]byte{reply})
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+func TestEagerDriverProbesStatusReadback(t *testing.T) {
+	tests := []struct {
+		name      string
+		answering bool
+	}{
+		{name: "two-way printer", answering: true},
+		{name: "one-way printer", answering: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port := serveTCPPrinter(t, tt.answering, 0x12)
+			device := &model.Device{
+				DeviceID:       "PRN-1",
+				Brand:          model.BrandEpson,
+				DeviceType:     model.DeviceTypePrinter,
+				Model:          "TM-T88VI",
+				ConnectionType: model.ConnectionTypeTCP,
+			}
+			policy := driver.DefaultConnectionPolicy()
+			policy.ConnectStrategy = driver.ConnectEager
+
+			instance, err := NewEPSONDriver(context.Background(), device,
+				map[string]interface{}{"host": host, "port": port}, policy, zap.NewNop())
+			if err != nil {
+				t.Fatalf("NewEPSONDriver() failed: %v", err)
+			}
+			d := instance.(*EPSONDriver)
+			defer d.Close()
+
+			if !d.IsConnected() {
+				t.Fatal("eager driver not connected")
+			}
+			if got := d.readback.Load(); got != tt.answering {
+				t.Fatalf("readback = %v, want %v", got, tt.answering)
+			}
+		})
+	}
+}
@@ -1,2 +1,195 @@
 // internal/driver/epson/helpers.go
 package epson
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Text overflow modes, selected per request with the "overflow" print option
+const (
+	OverflowWrap     = "WRAP"
+	OverflowTruncate = "TRUNCATE"
+)
+
+// Character fonts, selected per request with the "font" print option
+const (
+	FontA = "A"
+	FontB = "B"
+)
+
+// textFont returns the requested font, or fallback when unset or unknown
+func textFont(options map[string]string, fallback string) string {
+	switch font := strings.ToUpper(options["font"]); font {
+	case FontA, FontB:
+		return font
+	}
+	if strings.EqualFold(fallback, FontB) {
+		return FontB
+	}
+	return FontA
+}
+
+// fontCommand selects font with ESC M n
+func fontCommand(font string) []byte {
+	if font == FontB {
+		return ESC_POS_COMMANDS.FONT_B
+	}
+	return ESC_POS_COMMANDS.FONT_A
+}
+
+// charsPerLine returns how many characters fit on a line for the paper width
+// (in mm), font and text size. Double width sizes halve the line.
+func charsPerLine(paperWidth int, font, textSize string) int {
+	chars := 48 // 80mm
+	if paperWidth == 58 {
+		chars = 32
+	}
+	if font == FontB {
+		chars = 64
+		if paperWidth == 58 {
+			chars = 42
+		}
+	}
+
+	switch strings.ToUpper(textSize) {
+	case "DOUBLE_WIDTH", "DOUBLE", "BIG":
+		chars /= 2
+	}
+	return chars
+}
+
+// maxLineSpacing is the largest ESC 3 n line spacing
+const maxLineSpacing = 255
+
+// lineSpacing returns the requested line spacing in motion units, or
+// fallback when unset. 0 selects the printer's default spacing.
+func lineSpacing(options map[string]string, fallback int) int {
+	value, ok := options["line_spacing"]
+	if !ok {
+		return fallback
+	}
+	if strings.EqualFold(value, "default") {
+		return 0
+	}
+	if spacing, err := strconv.Atoi(value); err == nil && spacing >= 0 && spacing <= maxLineSpacing {
+		return spacing
+	}
+	return fallback
+}
+
+// lineSpacingCommand selects spacing motion units per line, or the printer
+// default (about 1/6 inch) for 0
+func lineSpacingCommand(spacing int) []byte {
+	if spacing <= 0 {
+		return ESC_POS_COMMANDS.LINE_SPACING_DEFAULT
+	}
+	return append(append([]byte{}, ESC_POS_COMMANDS.LINE_SPACING...), byte(spacing))
+}
+
+// Line feeds sent before a cut: three by default, enough for the print head to
+// cutter gap of most printers
+const (
+	defaultCutFeedLines = 3
+	maxCutFeedLines     = 20
+)
+
+// compactMode reports whether the "compact" option, or fallback when unset,
+// suppresses the extra line feeds added for readability
+func compactMode(options map[string]string, fallback bool) bool {
+	if value, ok := options["compact"]; ok {
+		if compact, err := strconv.ParseBool(value); err == nil {
+			return compact
+		}
+	}
+	return fallback
+}
+
+// Text formats, selected per request with the "format" print option
+const (
+	TextFormatFormatted = "FORMATTED"
+	TextFormatPlain     = "PLAIN"
+)
+
+// plainTextMode reports whether TEXT content should be printed verbatim,
+// without the default alignment, sizing and footer
+func plainTextMode(options map[string]string) bool {
+	return strings.EqualFold(options["format"], TextFormatPlain)
+}
+
+// overflowMode returns the requested overflow mode or fallback when unset
+func overflowMode(options map[string]string, fallback string) string {
+	if mode, ok := options["overflow"]; ok {
+		switch strings.ToUpper(mode) {
+		case OverflowWrap:
+			return OverflowWrap
+		case OverflowTruncate:
+			return OverflowTruncate
+		}
+	}
+	return fallback
+}
+
+// fitLine fits a single line into width according to mode. An empty mode
+// leaves the line as is and lets the printer break it.
+func fitLine(line string, width int, mode string) []string {
+	switch mode {
+	case OverflowWrap:
+		return wrapText(line, width)
+	case OverflowTruncate:
+		return []string{truncateText(line, width)}
+	default:
+		return []string{line}
+	}
+}
+
+// wrapText word-wraps text to width characters, splitting words that are
+// longer than a whole line
+func wrapText(text string, width int) []string {
+	if width <= 0 || utf8.RuneCountInString(text) <= width {
+		return []string{text}
+	}
+
+	var lines []string
+	var current []rune
+	for _, word := range strings.Fields(text) {
+		runes := []rune(word)
+
+		if len(current) > 0 && len(current)+1+len(runes) > width {
+			lines = append(lines, string(current))
+			current = current[:0]
+		}
+
+		for len(runes) > width {
+			if len(current) > 0 {
+				lines = append(lines, string(current))
+				current = current[:0]
+			}
+			lines = append(lines, string(runes[:width]))
+			runes = runes[width:]
+		}
+
+		if len(current) > 0 {
+			current = append(current, ' ')
+		}
+		current = append(current, runes...)
+	}
+
+	if len(current) > 0 || len(lines) == 0 {
+		lines = append(lines, string(current))
+	}
+	return lines
+}
+
+// truncateText cuts text to width characters, marking the cut with "..."
+func truncateText(text string, width int) string {
+	runes := []rune(text)
+	if width <= 0 || len(runes) <= width {
+		return text
+	}
+	if width <= 3 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-3]) + "..."
+}
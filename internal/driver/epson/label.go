@@ -0,0 +1,301 @@
+// internal/driver/epson/label.go
+package epson
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Label element types
+const (
+	LabelElementText    = "TEXT"
+	LabelElementBarcode = "BARCODE"
+	LabelElementBox     = "BOX"
+)
+
+// Label layout limits, in dots (8 dots per mm on 203 dpi printers)
+const (
+	maxLabelHeight     = 1662 // largest page mode print area height of TM printers
+	maxLabelElements   = 100
+	fontACharWidth     = 12
+	fontACharHeight    = 24
+	defaultBarHeight   = 80
+	defaultModuleWidth = 2
+	defaultBoxBorder   = 2
+)
+
+// LabelData represents a LABEL print job: elements at absolute positions in
+// a print area, printed in ESC/POS page mode. Coordinates are in dots from
+// the top left corner of the area.
+type LabelData struct {
+	Width    int            `json:"width,omitempty"` // defaults to the printable paper width
+	Height   int            `json:"height"`
+	Elements []LabelElement `json:"elements"`
+}
+
+// LabelElement represents a positioned text, barcode or box. X and Y are the
+// element's top left corner.
+type LabelElement struct {
+	Type string `json:"type"` // TEXT, BARCODE or BOX
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+
+	// TEXT
+	Text string `json:"text,omitempty"`
+	Size string `json:"size,omitempty"` // NORMAL, DOUBLE_WIDTH, DOUBLE_HEIGHT or DOUBLE
+	Bold bool   `json:"bold,omitempty"`
+
+	// BARCODE
+	Symbology   string `json:"symbology,omitempty"` // CODE128 or CODE39
+	Data        string `json:"data,omitempty"`
+	BarHeight   int    `json:"bar_height,omitempty"`   // dots, 1-255
+	ModuleWidth int    `json:"module_width,omitempty"` // dots, 2-6
+	HRI         bool   `json:"hri,omitempty"`          // print the data below the bars
+
+	// BOX
+	Width     int `json:"width,omitempty"`
+	Height    int `json:"height,omitempty"`
+	Thickness int `json:"thickness,omitempty"` // border in dots
+}
+
+// labelWidth returns the printable width in dots for the paper width in mm,
+// matching the width selected with GS W
+func labelWidth(paperWidth int) int {
+	if paperWidth == 58 {
+		return 320
+	}
+	return 512
+}
+
+// buildLabelCommands builds a page mode job from a JSON LabelData payload.
+// Page mode prints elements from their bottom edge, so each element is
+// positioned at its top Y plus its height.
+func (d *EPSONDriver) buildLabelCommands(content string, enc *textEncoding) ([][]byte, error) {
+	var label LabelData
+	if err := json.Unmarshal([]byte(content), &label); err != nil {
+		return nil, fmt.Errorf("label content must be a JSON label: %w", err)
+	}
+
+	paperWidth := labelWidth(d.config.PaperWidth)
+	if label.Width == 0 {
+		label.Width = paperWidth
+	}
+	if label.Width < 1 || label.Width > paperWidth {
+		return nil, fmt.Errorf("label width must be between 1 and %d dots", paperWidth)
+	}
+	if label.Height < 1 || label.Height > maxLabelHeight {
+		return nil, fmt.Errorf("label height must be between 1 and %d dots", maxLabelHeight)
+	}
+	if len(label.Elements) == 0 {
+		return nil, fmt.Errorf("label has no elements")
+	}
+	if len(label.Elements) > maxLabelElements {
+		return nil, fmt.Errorf("label has %d elements, at most %d allowed", len(label.Elements), maxLabelElements)
+	}
+
+	commands := [][]byte{
+		ESC_POS_COMMANDS.PAGE_MODE,
+		withUint16s(ESC_POS_COMMANDS.PAGE_AREA, 0, 0, label.Width, label.Height),
+	}
+
+	for i, element := range label.Elements {
+		elementCommands, err := buildLabelElement(element, label.Width, label.Height, enc)
+		if err != nil {
+			return nil, fmt.Errorf("label element %d: %w", i, err)
+		}
+		commands = append(commands, elementCommands...)
+	}
+
+	// Print the page and return to standard mode
+	commands = append(commands, ESC_POS_COMMANDS.PAGE_PRINT)
+	commands = append(commands, ESC_POS_COMMANDS.TEXT_RESET)
+
+	return commands, nil
+}
+
+// buildLabelElement builds the commands of a single label element within an
+// area of the given size
+func buildLabelElement(element LabelElement, areaWidth, areaHeight int, enc *textEncoding) ([][]byte, error) {
+	if element.X < 0 || element.Y < 0 {
+		return nil, fmt.Errorf("position must not be negative")
+	}
+
+	switch strings.ToUpper(element.Type) {
+	case LabelElementText:
+		return buildLabelText(element, areaWidth, areaHeight, enc)
+	case LabelElementBarcode:
+		return buildLabelBarcode(element, areaWidth, areaHeight)
+	case LabelElementBox:
+		return buildLabelBox(element, areaWidth, areaHeight)
+	default:
+		return nil, fmt.Errorf("unsupported element type %q", element.Type)
+	}
+}
+
+// buildLabelText builds a single line of text in the label's encoding
+func buildLabelText(element LabelElement, areaWidth, areaHeight int, enc *textEncoding) ([][]byte, error) {
+	if element.Text == "" {
+		return nil, fmt.Errorf("text is required")
+	}
+	if strings.ContainsAny(element.Text, "\r\n") {
+		return nil, fmt.Errorf("text must be a single line")
+	}
+
+	sizeCommand, widthScale, heightScale := ESC_POS_COMMANDS.TEXT_SIZE_NORMAL, 1, 1
+	switch strings.ToUpper(element.Size) {
+	case "", "NORMAL":
+	case "DOUBLE_WIDTH":
+		sizeCommand, widthScale = ESC_POS_COMMANDS.TEXT_SIZE_DOUBLE_WIDTH, 2
+	case "DOUBLE_HEIGHT":
+		sizeCommand, heightScale = ESC_POS_COMMANDS.TEXT_SIZE_DOUBLE_HEIGHT, 2
+	case "DOUBLE", "BIG":
+		sizeCommand, widthScale, heightScale = ESC_POS_COMMANDS.TEXT_SIZE_DOUBLE_BOTH, 2, 2
+	default:
+		return nil, fmt.Errorf("unsupported text size %q", element.Size)
+	}
+
+	width := utf8.RuneCountInString(element.Text) * fontACharWidth * widthScale
+	height := fontACharHeight * heightScale
+	if err := checkLabelBounds(element.X, element.Y, width, height, areaWidth, areaHeight); err != nil {
+		return nil, err
+	}
+
+	commands := labelPosition(element.X, element.Y+height)
+	commands = append(commands, sizeCommand)
+	if element.Bold {
+		commands = append(commands, ESC_POS_COMMANDS.TEXT_BOLD_ON)
+	}
+	commands = append(commands, enc.bytes(element.Text))
+	if element.Bold {
+		commands = append(commands, ESC_POS_COMMANDS.TEXT_BOLD_OFF)
+	}
+	commands = append(commands, ESC_POS_COMMANDS.TEXT_SIZE_NORMAL)
+
+	return commands, nil
+}
+
+// buildLabelBarcode builds a CODE128 or CODE39 barcode
+func buildLabelBarcode(element LabelElement, areaWidth, areaHeight int) ([][]byte, error) {
+	if element.Data == "" {
+		return nil, fmt.Errorf("barcode data is required")
+	}
+	if len(element.Data) > 253 {
+		return nil, fmt.Errorf("barcode data must be at most 253 bytes")
+	}
+	for i := 0; i < len(element.Data); i++ {
+		if element.Data[i] < 0x20 || element.Data[i] > 0x7E {
+			return nil, fmt.Errorf("barcode data must be printable ASCII")
+		}
+	}
+
+	barHeight := element.BarHeight
+	if barHeight == 0 {
+		barHeight = defaultBarHeight
+	}
+	if barHeight < 1 || barHeight > 255 {
+		return nil, fmt.Errorf("bar height must be between 1 and 255 dots")
+	}
+	moduleWidth := element.ModuleWidth
+	if moduleWidth == 0 {
+		moduleWidth = defaultModuleWidth
+	}
+	if moduleWidth < 2 || moduleWidth > 6 {
+		return nil, fmt.Errorf("module width must be between 2 and 6 dots")
+	}
+
+	var barcode []byte
+	switch strings.ToUpper(element.Symbology) {
+	case "", "CODE128":
+		// Code set B, length covers the "{B" prefix
+		barcode = append(append([]byte{}, ESC_POS_COMMANDS.BARCODE_CODE128...), byte(len(element.Data)+2), '{', 'B')
+		barcode = append(barcode, element.Data...)
+	case "CODE39":
+		barcode = append(append([]byte{}, ESC_POS_COMMANDS.BARCODE_CODE39...), element.Data...)
+		barcode = append(barcode, 0x00)
+	default:
+		return nil, fmt.Errorf("unsupported barcode symbology %q", element.Symbology)
+	}
+
+	height := barHeight
+	hri := byte(0)
+	if element.HRI {
+		height += fontACharHeight
+		hri = 2
+	}
+	if err := checkLabelBounds(element.X, element.Y, 0, height, areaWidth, areaHeight); err != nil {
+		return nil, err
+	}
+
+	commands := labelPosition(element.X, element.Y+height)
+	commands = append(commands,
+		append(append([]byte{}, ESC_POS_COMMANDS.BARCODE_HEIGHT...), byte(barHeight)),
+		append(append([]byte{}, ESC_POS_COMMANDS.BARCODE_WIDTH...), byte(moduleWidth)),
+		append(append([]byte{}, ESC_POS_COMMANDS.BARCODE_HRI...), hri),
+		barcode,
+	)
+
+	return commands, nil
+}
+
+// buildLabelBox builds a rectangle outline as a raster bit image
+func buildLabelBox(element LabelElement, areaWidth, areaHeight int) ([][]byte, error) {
+	if element.Width < 1 || element.Height < 1 {
+		return nil, fmt.Errorf("box width and height are required")
+	}
+	if err := checkLabelBounds(element.X, element.Y, element.Width, element.Height, areaWidth, areaHeight); err != nil {
+		return nil, err
+	}
+
+	thickness := element.Thickness
+	if thickness == 0 {
+		thickness = defaultBoxBorder
+	}
+	if thickness < 1 || 2*thickness > element.Width || 2*thickness > element.Height {
+		return nil, fmt.Errorf("box border must be between 1 dot and half the box size")
+	}
+
+	rowBytes := (element.Width + 7) / 8
+	image := withUint16s(ESC_POS_COMMANDS.RASTER_IMAGE, rowBytes, element.Height)
+	bitmap := make([]byte, rowBytes*element.Height)
+	for y := 0; y < element.Height; y++ {
+		edgeRow := y < thickness || y >= element.Height-thickness
+		for x := 0; x < element.Width; x++ {
+			if edgeRow || x < thickness || x >= element.Width-thickness {
+				bitmap[y*rowBytes+x/8] |= 0x80 >> (x % 8)
+			}
+		}
+	}
+
+	commands := labelPosition(element.X, element.Y+element.Height)
+	commands = append(commands, append(image, bitmap...))
+
+	return commands, nil
+}
+
+// checkLabelBounds rejects elements reaching outside the print area
+func checkLabelBounds(x, y, width, height, areaWidth, areaHeight int) error {
+	if x+width > areaWidth || x >= areaWidth || y+height > areaHeight {
+		return fmt.Errorf("element at (%d,%d) sized %dx%d does not fit the %dx%d label",
+			x, y, width, height, areaWidth, areaHeight)
+	}
+	return nil
+}
+
+// labelPosition moves the page mode print position to x and the baseline y
+func labelPosition(x, y int) [][]byte {
+	return [][]byte{
+		withUint16s(ESC_POS_COMMANDS.POSITION_X, x),
+		withUint16s(ESC_POS_COMMANDS.POSITION_Y, y),
+	}
+}
+
+// withUint16s appends values to a command as little endian nL nH pairs
+func withUint16s(command []byte, values ...int) []byte {
+	result := append([]byte{}, command...)
+	for _, value := range values {
+		result = append(result, byte(value&0xFF), byte(value>>8&0xFF))
+	}
+	return result
+}
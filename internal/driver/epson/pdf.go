@@ -0,0 +1,1035 @@
+// internal/driver/epson/pdf.go
+package epson
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"device-service/pkg/driver"
+)
+
+// PDF print limits
+const (
+	maxPDFPages       = 20
+	maxPDFImagePixels = 12_000_000 // decoded pixels of one page image, A4 at 300 dpi fits
+	maxPDFTreeDepth   = 32
+)
+
+// PDF page selections of the pdf_pages print option
+const (
+	PDFPagesFirst = "FIRST"
+	PDFPagesAll   = "ALL"
+)
+
+var (
+	errPDFEncrypted   = errors.New("encrypted PDFs are not supported")
+	errPDFUnsupported = errors.New("unsupported PDF")
+)
+
+// buildPDFCommands rasterizes the pages of a base64 encoded PDF to the paper
+// width. Only the first page is printed unless the pdf_pages option is ALL;
+// pages are then separated by a cut, or by blank lines without a cutter.
+//
+// PDFs are not rasterized: each page is printed from the one raster image it
+// draws, so pages must be a single image, e.g. rendered or scanned by the
+// back-office system. Pages that draw text or more than one image, such as
+// an invoice with a logo, are rejected as unsupported rather than printed
+// in part. Vector graphics other than text are not printed.
+func (d *EPSONDriver) buildPDFCommands(content string, options map[string]string) ([][]byte, error) {
+	pages := strings.ToUpper(options["pdf_pages"])
+	if pages == "" {
+		pages = PDFPagesFirst
+	}
+	if pages != PDFPagesFirst && pages != PDFPagesAll {
+		return nil, driver.NewOperationError(driver.ErrorCodeInvalidRequest,
+			fmt.Errorf("pdf_pages must be %s or %s", PDFPagesFirst, PDFPagesAll))
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(content), ""))
+	if err != nil {
+		return nil, driver.NewOperationError(driver.ErrorCodeInvalidRequest,
+			fmt.Errorf("PDF content must be base64 encoded: %w", err))
+	}
+
+	limit := 1
+	if pages == PDFPagesAll {
+		limit = maxPDFPages
+	}
+	images, err := readPDFPageImages(data, limit)
+	if err != nil {
+		if errors.Is(err, errPDFEncrypted) || errors.Is(err, errPDFUnsupported) {
+			return nil, driver.NewOperationError(driver.ErrorCodeUnsupported, err)
+		}
+		return nil, driver.NewOperationError(driver.ErrorCodeInvalidRequest, fmt.Errorf("invalid PDF: %w", err))
+	}
+
+	var commands [][]byte
+	width := labelWidth(d.config.PaperWidth)
+	for i, img := range images {
+		if i > 0 {
+			commands = append(commands, append(append([]byte{}, ESC_POS_COMMANDS.FEED_LINES...), 4))
+			if d.config.EnableCutter {
+				commands = append(commands, d.cutCommand())
+			}
+		}
+		commands = append(commands, buildRasterImage(img, width)...)
+	}
+	return commands, nil
+}
+
+// cutCommand returns the cut command of the configured cut type
+func (d *EPSONDriver) cutCommand() []byte {
+	if d.config.CutType == "PARTIAL" {
+		return ESC_POS_COMMANDS.CUT_PARTIAL
+	}
+	return ESC_POS_COMMANDS.CUT_FULL
+}
+
+// readPDFPageImages returns the raster image of up to limit pages of a PDF,
+// in page order. Each page must draw exactly one image and no text.
+func readPDFPageImages(data []byte, limit int) (images []image.Image, err error) {
+	// The parser follows offsets and sizes read from the file; a malformed
+	// file it doesn't anticipate fails the print, not the service
+	defer func() {
+		if r := recover(); r != nil {
+			images, err = nil, fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+
+	doc, err := parsePDF(data)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := doc.pages()
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) > limit {
+		pages = pages[:limit]
+	}
+
+	images = make([]image.Image, 0, len(pages))
+	for i, page := range pages {
+		content, err := doc.pageContent(page)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+		switch drawn := len(content.images) + content.inlineImages; {
+		case content.text:
+			return nil, fmt.Errorf("%w: page %d draws text, only pages that are a single raster image can be printed", errPDFUnsupported, i+1)
+		case drawn == 0:
+			return nil, fmt.Errorf("%w: page %d has no raster image, only pages that are a single raster image can be printed", errPDFUnsupported, i+1)
+		case drawn > 1:
+			return nil, fmt.Errorf("%w: page %d draws %d images, only pages that are a single raster image can be printed", errPDFUnsupported, i+1, drawn)
+		case content.inlineImages > 0:
+			return nil, fmt.Errorf("%w: page %d draws an inline image", errPDFUnsupported, i+1)
+		}
+		img, err := doc.decodeImage(content.images[0])
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+// PDF object model: dictionaries, arrays ([]interface{}), names, numbers
+// (float64), strings, booleans, null (nil), references and streams
+type (
+	pdfDict map[string]interface{}
+	pdfName string
+	pdfRef  int // object number; generations are ignored
+
+	pdfStream struct {
+		dict pdfDict
+		data []byte
+	}
+)
+
+// pdfDocument holds the objects of a PDF by object number
+type pdfDocument struct {
+	objects map[int]interface{}
+	trailer pdfDict
+}
+
+// pdfPage is a leaf of the page tree with its inherited resources
+type pdfPage struct {
+	resources pdfDict
+	contents  interface{}
+}
+
+// pdfPageContent is what the content stream of a page draws
+type pdfPageContent struct {
+	images       []*pdfStream // distinct image XObjects
+	inlineImages int
+	text         bool
+}
+
+var pdfObjectHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// parsePDF reads the objects of a PDF by scanning for object headers, which
+// also recovers files with a broken cross-reference table. Objects packed in
+// object streams are unpacked.
+func parsePDF(data []byte) (*pdfDocument, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\r\n "), []byte("%PDF-")) {
+		return nil, errors.New("missing %PDF header")
+	}
+
+	doc := &pdfDocument{objects: make(map[int]interface{})}
+	var objectStreams []*pdfStream
+	for pos := 0; pos < len(data); {
+		match := pdfObjectHeader.FindSubmatchIndex(data[pos:])
+		if match == nil {
+			break
+		}
+		number, _ := strconv.Atoi(string(data[pos+match[2] : pos+match[3]]))
+		parser := &pdfParser{data: data, pos: pos + match[1]}
+		value, err := parser.parseObjectBody()
+		if err != nil {
+			pos += match[1]
+			continue
+		}
+		pos = parser.pos
+
+		// Later definitions of an object are incremental updates
+		doc.objects[number] = value
+		if stream, ok := value.(*pdfStream); ok {
+			switch stream.dict["Type"] {
+			case pdfName("ObjStm"):
+				objectStreams = append(objectStreams, stream)
+			case pdfName("XRef"):
+				doc.trailer = stream.dict
+			}
+		}
+	}
+
+	if index := bytes.LastIndex(data, []byte("trailer")); index >= 0 {
+		parser := &pdfParser{data: data, pos: index + len("trailer")}
+		if trailer, err := parser.parseValue(); err == nil {
+			if dict, ok := trailer.(pdfDict); ok && (doc.trailer == nil || dict["Root"] != nil) {
+				doc.trailer = dict
+			}
+		}
+	}
+	if doc.trailer == nil {
+		return nil, errors.New("missing trailer")
+	}
+	if doc.trailer["Encrypt"] != nil {
+		return nil, errPDFEncrypted
+	}
+
+	for _, stream := range objectStreams {
+		doc.unpackObjectStream(stream)
+	}
+	return doc, nil
+}
+
+// unpackObjectStream adds the objects of an object stream that aren't defined
+// directly in the file
+func (doc *pdfDocument) unpackObjectStream(stream *pdfStream) {
+	data, err := doc.streamData(stream)
+	if err != nil {
+		return
+	}
+	count, _ := doc.resolve(stream.dict["N"]).(float64)
+	first, _ := doc.resolve(stream.dict["First"]).(float64)
+	if !(first >= 0 && first <= float64(len(data))) {
+		return
+	}
+
+	header := &pdfParser{data: data[:int(first)]}
+	for i := 0; i < int(count); i++ {
+		number, err1 := header.parseValue()
+		offset, err2 := header.parseValue()
+		if err1 != nil || err2 != nil {
+			return
+		}
+		n, _ := number.(float64)
+		o, _ := offset.(float64)
+		if !(o >= 0 && first+o < float64(len(data))) {
+			return
+		}
+		if _, exists := doc.objects[int(n)]; exists {
+			continue
+		}
+		parser := &pdfParser{data: data, pos: int(first + o)}
+		if value, err := parser.parseValue(); err == nil {
+			doc.objects[int(n)] = value
+		}
+	}
+}
+
+// resolve follows a reference to the object it names
+func (doc *pdfDocument) resolve(value interface{}) interface{} {
+	for i := 0; i < maxPDFTreeDepth; i++ {
+		ref, ok := value.(pdfRef)
+		if !ok {
+			return value
+		}
+		value = doc.objects[int(ref)]
+	}
+	return nil
+}
+
+// dict resolves a value to a dictionary, the dictionary of a stream included
+func (doc *pdfDocument) dict(value interface{}) pdfDict {
+	switch v := doc.resolve(value).(type) {
+	case pdfDict:
+		return v
+	case *pdfStream:
+		return v.dict
+	}
+	return nil
+}
+
+// pages returns the pages of the document in order
+func (doc *pdfDocument) pages() ([]pdfPage, error) {
+	catalog := doc.dict(doc.trailer["Root"])
+	if catalog == nil {
+		return nil, errors.New("missing document catalog")
+	}
+
+	var pages []pdfPage
+	visited := make(map[interface{}]bool)
+	var walk func(node interface{}, resources pdfDict, depth int)
+	walk = func(node interface{}, resources pdfDict, depth int) {
+		if ref, ok := node.(pdfRef); ok {
+			if visited[ref] {
+				return
+			}
+			visited[ref] = true
+		}
+		dict := doc.dict(node)
+		if dict == nil || depth > maxPDFTreeDepth || len(pages) >= maxPDFPages {
+			return
+		}
+		if own := doc.dict(dict["Resources"]); own != nil {
+			resources = own
+		}
+		if kids, ok := doc.resolve(dict["Kids"]).([]interface{}); ok {
+			for _, kid := range kids {
+				walk(kid, resources, depth+1)
+			}
+			return
+		}
+		if dict["Type"] == pdfName("Page") {
+			pages = append(pages, pdfPage{resources: resources, contents: dict["Contents"]})
+		}
+	}
+	walk(catalog["Pages"], nil, 0)
+
+	if len(pages) == 0 {
+		return nil, errors.New("document has no pages")
+	}
+	return pages, nil
+}
+
+// pageContent scans the content streams of a page for what they draw
+func (doc *pdfDocument) pageContent(page pdfPage) (*pdfPageContent, error) {
+	streams, ok := doc.resolve(page.contents).([]interface{})
+	if !ok {
+		streams = []interface{}{page.contents}
+	}
+
+	// The streams of a page are one content stream split at token boundaries
+	var data []byte
+	for _, value := range streams {
+		stream, ok := doc.resolve(value).(*pdfStream)
+		if !ok {
+			continue
+		}
+		part, err := doc.streamData(stream)
+		if err != nil {
+			return nil, err
+		}
+		data = append(append(data, part...), '\n')
+	}
+
+	content := &pdfPageContent{}
+	if err := doc.scanContent(data, page.resources, 0, content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// scanContent records the text and images drawn by a content stream,
+// following the form XObjects it draws
+func (doc *pdfDocument) scanContent(data []byte, resources pdfDict, depth int, content *pdfPageContent) error {
+	if depth > 4 {
+		return nil
+	}
+
+	p := &pdfParser{data: data}
+	var operand interface{}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return nil
+		}
+
+		c := p.data[p.pos]
+		if isPDFDelimiter(c) || c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9') {
+			value, err := p.parseValue()
+			if err != nil {
+				// Skip what isn't an operand, e.g. a stray delimiter
+				p.pos++
+			}
+			operand = value
+			continue
+		}
+
+		switch p.readRegular() {
+		case "BT":
+			content.text = true
+		case "BI":
+			// Inline image data is binary; skip it to its EI operator
+			content.inlineImages++
+			end := bytes.Index(p.data[p.pos:], []byte("EI"))
+			for end >= 0 && !isPDFSpace(p.data[p.pos+end-1]) {
+				next := bytes.Index(p.data[p.pos+end+2:], []byte("EI"))
+				if next < 0 {
+					end = -1
+					break
+				}
+				end += next + 2
+			}
+			if end < 0 {
+				return nil
+			}
+			p.pos += end + 2
+		case "Do":
+			name, _ := operand.(pdfName)
+			stream, ok := doc.resolve(doc.dict(resources["XObject"])[string(name)]).(*pdfStream)
+			if !ok {
+				break
+			}
+			switch stream.dict["Subtype"] {
+			case pdfName("Image"):
+				if !containsPDFStream(content.images, stream) {
+					content.images = append(content.images, stream)
+				}
+			case pdfName("Form"):
+				formResources := doc.dict(stream.dict["Resources"])
+				if formResources == nil {
+					formResources = resources
+				}
+				formData, err := doc.streamData(stream)
+				if err != nil {
+					return err
+				}
+				if err := doc.scanContent(formData, formResources, depth+1, content); err != nil {
+					return err
+				}
+			}
+		}
+		operand = nil
+	}
+}
+
+func containsPDFStream(streams []*pdfStream, stream *pdfStream) bool {
+	for _, s := range streams {
+		if s == stream {
+			return true
+		}
+	}
+	return false
+}
+
+// streamFilters returns the filter names of a stream
+func (doc *pdfDocument) streamFilters(stream *pdfStream) []string {
+	switch filter := doc.resolve(stream.dict["Filter"]).(type) {
+	case pdfName:
+		return []string{string(filter)}
+	case []interface{}:
+		names := make([]string, 0, len(filter))
+		for _, f := range filter {
+			if name, ok := doc.resolve(f).(pdfName); ok {
+				names = append(names, string(name))
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// streamData decodes the data of a stream. Decoding stops before a DCTDecode
+// filter, whose data is a JPEG file.
+func (doc *pdfDocument) streamData(stream *pdfStream) ([]byte, error) {
+	data := stream.data
+	params := doc.dict(stream.dict["DecodeParms"])
+	if list, ok := doc.resolve(stream.dict["DecodeParms"]).([]interface{}); ok && len(list) > 0 {
+		params = doc.dict(list[0])
+	}
+
+	for _, filter := range doc.streamFilters(stream) {
+		switch filter {
+		case "FlateDecode", "Fl":
+			reader, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, fmt.Errorf("invalid FlateDecode data: %w", err)
+			}
+			decoded, err := io.ReadAll(io.LimitReader(reader, 4*maxPDFImagePixels+1))
+			if err != nil && len(decoded) == 0 {
+				return nil, fmt.Errorf("invalid FlateDecode data: %w", err)
+			}
+			if data, err = doc.unpredict(decoded, params); err != nil {
+				return nil, err
+			}
+		case "DCTDecode", "DCT":
+			return data, nil
+		default:
+			return nil, fmt.Errorf("%w: %s stream filter", errPDFUnsupported, filter)
+		}
+	}
+	return data, nil
+}
+
+// unpredict reverses the PNG predictors of FlateDecode data
+func (doc *pdfDocument) unpredict(data []byte, params pdfDict) ([]byte, error) {
+	predictor, _ := doc.resolve(params["Predictor"]).(float64)
+	if predictor <= 1 {
+		return data, nil
+	}
+	if predictor < 10 {
+		return nil, fmt.Errorf("%w: TIFF predictor", errPDFUnsupported)
+	}
+
+	colors, bpc, columns := 1.0, 8.0, 1.0
+	if v, ok := doc.resolve(params["Colors"]).(float64); ok {
+		colors = v
+	}
+	if v, ok := doc.resolve(params["BitsPerComponent"]).(float64); ok {
+		bpc = v
+	}
+	if v, ok := doc.resolve(params["Columns"]).(float64); ok {
+		columns = v
+	}
+	pixelBytes := int(colors*bpc+7) / 8
+	rowBytes := int(colors*bpc*columns+7) / 8
+	if rowBytes <= 0 {
+		return nil, errors.New("invalid predictor parameters")
+	}
+
+	out := make([]byte, 0, len(data))
+	previous := make([]byte, rowBytes)
+	for pos := 0; pos+1+rowBytes <= len(data); pos += 1 + rowBytes {
+		filter := data[pos]
+		row := append([]byte{}, data[pos+1:pos+1+rowBytes]...)
+		for i := range row {
+			var left, upLeft byte
+			if i >= pixelBytes {
+				left, upLeft = row[i-pixelBytes], previous[i-pixelBytes]
+			}
+			up := previous[i]
+			switch filter {
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			}
+		}
+		out = append(out, row...)
+		previous = row
+	}
+	return out, nil
+}
+
+// paeth is the PNG Paeth predictor
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// decodeImage decodes an image XObject to a grayscale image
+func (doc *pdfDocument) decodeImage(stream *pdfStream) (image.Image, error) {
+	width, _ := doc.resolve(stream.dict["Width"]).(float64)
+	height, _ := doc.resolve(stream.dict["Height"]).(float64)
+	if width < 1 || height < 1 || width*height > maxPDFImagePixels {
+		return nil, fmt.Errorf("%w: image of %gx%g pixels", errPDFUnsupported, width, height)
+	}
+
+	data, err := doc.streamData(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := doc.streamFilters(stream)
+	if len(filters) > 0 && (filters[len(filters)-1] == "DCTDecode" || filters[len(filters)-1] == "DCT") {
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid JPEG image: %w", err)
+		}
+		return img, nil
+	}
+
+	return doc.decodeSamples(stream, data, int(width), int(height))
+}
+
+// decodeSamples converts raw image samples to a grayscale image
+func (doc *pdfDocument) decodeSamples(stream *pdfStream, data []byte, width, height int) (image.Image, error) {
+	bpc := 8
+	if v, ok := doc.resolve(stream.dict["BitsPerComponent"]).(float64); ok {
+		bpc = int(v)
+	}
+	imageMask, _ := doc.resolve(stream.dict["ImageMask"]).(bool)
+	if imageMask {
+		bpc = 1
+	}
+	if bpc != 1 && bpc != 2 && bpc != 4 && bpc != 8 {
+		return nil, fmt.Errorf("%w: %d bits per component", errPDFUnsupported, bpc)
+	}
+
+	toGray, components, err := doc.colorSpace(stream.dict["ColorSpace"], bpc, imageMask)
+	if err != nil {
+		return nil, err
+	}
+
+	rowBytes := (width*components*bpc + 7) / 8
+	if len(data) < rowBytes*height {
+		return nil, errors.New("image data is shorter than its size")
+	}
+
+	// Decode [1 0] inverts one component images
+	invert := false
+	if decode, ok := doc.resolve(stream.dict["Decode"]).([]interface{}); ok && len(decode) == 2 {
+		first, _ := doc.resolve(decode[0]).(float64)
+		invert = first == 1
+	}
+
+	maxSample := 1<<bpc - 1
+	gray := image.NewGray(image.Rect(0, 0, width, height))
+	samples := make([]int, components)
+	for y := 0; y < height; y++ {
+		row := data[y*rowBytes : (y+1)*rowBytes]
+		for x := 0; x < width; x++ {
+			for c := 0; c < components; c++ {
+				bit := (x*components + c) * bpc
+				sample := int(row[bit/8]>>(8-bpc-bit%8)) & maxSample
+				if invert {
+					sample = maxSample - sample
+				}
+				samples[c] = sample * 255 / maxSample
+			}
+			gray.Pix[y*gray.Stride+x] = toGray(samples)
+		}
+	}
+	return gray, nil
+}
+
+// colorSpace returns the conversion of a color space's samples, scaled to
+// 0-255, to gray, and its number of components
+func (doc *pdfDocument) colorSpace(value interface{}, bpc int, imageMask bool) (func([]int) uint8, int, error) {
+	if imageMask {
+		// Mask samples of 0 are painted black
+		return func(s []int) uint8 { return uint8(s[0]) }, 1, nil
+	}
+
+	switch space := doc.resolve(value).(type) {
+	case pdfName:
+		switch space {
+		case "DeviceGray", "G", "CalGray":
+			return func(s []int) uint8 { return uint8(s[0]) }, 1, nil
+		case "DeviceRGB", "RGB", "CalRGB":
+			return rgbToGray, 3, nil
+		case "DeviceCMYK", "CMYK":
+			return cmykToGray, 4, nil
+		}
+		return nil, 0, fmt.Errorf("%w: %s color space", errPDFUnsupported, space)
+
+	case []interface{}:
+		if len(space) == 0 {
+			break
+		}
+		family, _ := doc.resolve(space[0]).(pdfName)
+		switch family {
+		case "ICCBased":
+			if len(space) < 2 {
+				break
+			}
+			n, _ := doc.resolve(doc.dict(space[1])["N"]).(float64)
+			switch n {
+			case 1:
+				return func(s []int) uint8 { return uint8(s[0]) }, 1, nil
+			case 3:
+				return rgbToGray, 3, nil
+			case 4:
+				return cmykToGray, 4, nil
+			}
+		case "CalGray", "CalRGB":
+			return doc.colorSpace(family, bpc, false)
+		case "Indexed", "I":
+			return doc.indexedColorSpace(space, bpc)
+		}
+		return nil, 0, fmt.Errorf("%w: %s color space", errPDFUnsupported, family)
+	}
+	return nil, 0, fmt.Errorf("%w: missing or invalid color space", errPDFUnsupported)
+}
+
+// indexedColorSpace returns the conversion of palette indices to gray
+func (doc *pdfDocument) indexedColorSpace(space []interface{}, bpc int) (func([]int) uint8, int, error) {
+	if len(space) < 4 {
+		return nil, 0, errors.New("invalid Indexed color space")
+	}
+	baseToGray, baseComponents, err := doc.colorSpace(space[1], 8, false)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var lookup []byte
+	switch table := doc.resolve(space[3]).(type) {
+	case string:
+		lookup = []byte(table)
+	case *pdfStream:
+		if lookup, err = doc.streamData(table); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	maxSample := 1<<bpc - 1
+	palette := make([]uint8, maxSample+1)
+	base := make([]int, baseComponents)
+	for index := range palette {
+		palette[index] = 255
+		if (index+1)*baseComponents > len(lookup) {
+			continue
+		}
+		for c := range base {
+			base[c] = int(lookup[index*baseComponents+c])
+		}
+		palette[index] = baseToGray(base)
+	}
+
+	// Samples arrive scaled to 0-255; scale them back to indices
+	return func(s []int) uint8 { return palette[s[0]*maxSample/255] }, 1, nil
+}
+
+func rgbToGray(s []int) uint8 {
+	return uint8((299*s[0] + 587*s[1] + 114*s[2]) / 1000)
+}
+
+func cmykToGray(s []int) uint8 {
+	k := 255 - s[3]
+	r, g, b := (255-s[0])*k/255, (255-s[1])*k/255, (255-s[2])*k/255
+	return rgbToGray([]int{r, g, b})
+}
+
+// pdfParser parses PDF objects from data
+type pdfParser struct {
+	data []byte
+	pos  int
+}
+
+// parseObjectBody parses the value of an indirect object after its "N G obj"
+// header, with its stream data when it is a stream
+func (p *pdfParser) parseObjectBody() (interface{}, error) {
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if !p.consumeKeyword("stream") {
+		p.skipSpace()
+		p.consumeKeyword("endobj")
+		return value, nil
+	}
+
+	dict, ok := value.(pdfDict)
+	if !ok {
+		return nil, errors.New("stream without dictionary")
+	}
+	if p.pos < len(p.data) && p.data[p.pos] == '\r' {
+		p.pos++
+	}
+	if p.pos < len(p.data) && p.data[p.pos] == '\n' {
+		p.pos++
+	}
+
+	// Trust a direct Length only when endstream follows it
+	start := p.pos
+	end := -1
+	if length, ok := dict["Length"].(float64); ok && length >= 0 && start+int(length) <= len(p.data) {
+		rest := bytes.TrimLeft(p.data[start+int(length):], "\r\n \t")
+		if bytes.HasPrefix(rest, []byte("endstream")) {
+			end = start + int(length)
+		}
+	}
+	if end < 0 {
+		index := bytes.Index(p.data[start:], []byte("endstream"))
+		if index < 0 {
+			return nil, errors.New("unterminated stream")
+		}
+		end = start + index
+		for end > start && (p.data[end-1] == '\n' || p.data[end-1] == '\r') {
+			end--
+		}
+	}
+
+	p.pos = end
+	p.skipSpace()
+	p.consumeKeyword("endstream")
+	p.skipSpace()
+	p.consumeKeyword("endobj")
+	return &pdfStream{dict: dict, data: p.data[start:end]}, nil
+}
+
+// parseValue parses one PDF value
+func (p *pdfParser) parseValue() (interface{}, error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	switch c := p.data[p.pos]; {
+	case c == '<' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '<':
+		return p.parseDict()
+	case c == '<':
+		return p.parseHexString()
+	case c == '[':
+		return p.parseArray()
+	case c == '(':
+		return p.parseLiteralString()
+	case c == '/':
+		return p.parseName(), nil
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return p.parseNumberOrRef()
+	}
+
+	keyword := p.readRegular()
+	switch keyword {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", keyword, p.pos)
+}
+
+func (p *pdfParser) parseDict() (interface{}, error) {
+	p.pos += 2
+	dict := make(pdfDict)
+	for {
+		p.skipSpace()
+		if p.pos+1 < len(p.data) && p.data[p.pos] == '>' && p.data[p.pos+1] == '>' {
+			p.pos += 2
+			return dict, nil
+		}
+		if p.pos >= len(p.data) || p.data[p.pos] != '/' {
+			return nil, errors.New("invalid dictionary key")
+		}
+		key := p.parseName()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		dict[string(key)] = value
+	}
+}
+
+func (p *pdfParser) parseArray() (interface{}, error) {
+	p.pos++
+	var array []interface{}
+	for {
+		p.skipSpace()
+		if p.pos < len(p.data) && p.data[p.pos] == ']' {
+			p.pos++
+			return array, nil
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+	}
+}
+
+func (p *pdfParser) parseName() pdfName {
+	p.pos++
+	raw := p.readRegular()
+	if !strings.Contains(raw, "#") {
+		return pdfName(raw)
+	}
+
+	var name strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '#' && i+2 < len(raw) {
+			if b, err := strconv.ParseUint(raw[i+1:i+3], 16, 8); err == nil {
+				name.WriteByte(byte(b))
+				i += 2
+				continue
+			}
+		}
+		name.WriteByte(raw[i])
+	}
+	return pdfName(name.String())
+}
+
+func (p *pdfParser) parseNumberOrRef() (interface{}, error) {
+	token := p.readRegular()
+	number, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %q", token)
+	}
+
+	// An integer followed by a generation and R is a reference
+	if !strings.ContainsAny(token, ".+-") {
+		saved := p.pos
+		p.skipSpace()
+		generation := p.readRegular()
+		p.skipSpace()
+		if _, err := strconv.Atoi(generation); err == nil && p.readRegular() == "R" {
+			return pdfRef(int(number)), nil
+		}
+		p.pos = saved
+	}
+	return number, nil
+}
+
+func (p *pdfParser) parseHexString() (interface{}, error) {
+	end := bytes.IndexByte(p.data[p.pos:], '>')
+	if end < 0 {
+		return nil, errors.New("unterminated hex string")
+	}
+	digits := strings.Join(strings.Fields(string(p.data[p.pos+1:p.pos+end])), "")
+	p.pos += end + 1
+	if len(digits)%2 == 1 {
+		digits += "0"
+	}
+
+	decoded := make([]byte, len(digits)/2)
+	for i := range decoded {
+		b, err := strconv.ParseUint(digits[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return nil, errors.New("invalid hex string")
+		}
+		decoded[i] = byte(b)
+	}
+	return string(decoded), nil
+}
+
+func (p *pdfParser) parseLiteralString() (interface{}, error) {
+	p.pos++
+	var value []byte
+	depth := 1
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case '\\':
+			if p.pos >= len(p.data) {
+				break
+			}
+			escaped := p.data[p.pos]
+			p.pos++
+			switch escaped {
+			case 'n':
+				value = append(value, '\n')
+			case 'r':
+				value = append(value, '\r')
+			case 't':
+				value = append(value, '\t')
+			case 'b':
+				value = append(value, '\b')
+			case 'f':
+				value = append(value, '\f')
+			case '\r', '\n':
+				// line continuation
+			default:
+				if escaped >= '0' && escaped <= '7' {
+					octal := int(escaped - '0')
+					for i := 0; i < 2 && p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '7'; i++ {
+						octal = octal*8 + int(p.data[p.pos]-'0')
+						p.pos++
+					}
+					value = append(value, byte(octal))
+				} else {
+					value = append(value, escaped)
+				}
+			}
+		case '(':
+			depth++
+			value = append(value, c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return string(value), nil
+			}
+			value = append(value, c)
+		default:
+			value = append(value, c)
+		}
+	}
+	return nil, errors.New("unterminated string")
+}
+
+// readRegular reads a run of regular characters: a keyword, number or name
+func (p *pdfParser) readRegular() string {
+	start := p.pos
+	for p.pos < len(p.data) && !isPDFSpace(p.data[p.pos]) && !isPDFDelimiter(p.data[p.pos]) {
+		p.pos++
+	}
+	return string(p.data[start:p.pos])
+}
+
+// consumeKeyword skips keyword when it comes next
+func (p *pdfParser) consumeKeyword(keyword string) bool {
+	if !bytes.HasPrefix(p.data[p.pos:], []byte(keyword)) {
+		return false
+	}
+	p.pos += len(keyword)
+	return true
+}
+
+// skipSpace skips white space and comments
+func (p *pdfParser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; {
+		case isPDFSpace(c):
+			p.pos++
+		case c == '%':
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
@@ -0,0 +1,114 @@
+// internal/driver/epson/raster.go
+package epson
+
+import (
+	"image"
+	"image/color"
+)
+
+// rasterBandHeight is the height in dots of one GS v 0 image. Tall images are
+// sent as bands so each fits the receive buffer of small printers.
+const rasterBandHeight = 256
+
+// buildRasterImage scales an image to width dots, keeping its aspect ratio,
+// dithers it to black and white and returns it as GS v 0 raster bands. White
+// rows at the bottom of the image are not printed.
+func buildRasterImage(img image.Image, width int) [][]byte {
+	gray := scaleToGray(img, width)
+	bitmap, rowBytes, height := ditherBitmap(gray)
+
+	// Drop the blank bottom of e.g. an A4 page holding a short receipt
+	for height > 0 && isBlankRow(bitmap[(height-1)*rowBytes:height*rowBytes]) {
+		height--
+	}
+
+	var commands [][]byte
+	for top := 0; top < height; top += rasterBandHeight {
+		band := rasterBandHeight
+		if top+band > height {
+			band = height - top
+		}
+		command := withUint16s(ESC_POS_COMMANDS.RASTER_IMAGE, rowBytes, band)
+		commands = append(commands, append(command, bitmap[top*rowBytes:(top+band)*rowBytes]...))
+	}
+	return commands
+}
+
+// scaleToGray scales an image to width pixels with an area average and
+// converts it to grayscale
+func scaleToGray(img image.Image, width int) *image.Gray {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	height := srcHeight * width / srcWidth
+	if height < 1 {
+		height = 1
+	}
+
+	gray := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*srcHeight/height
+		y1 := bounds.Min.Y + (y+1)*srcHeight/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*srcWidth/width
+			x1 := bounds.Min.X + (x+1)*srcWidth/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			sum, count := 0, 0
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					sum += int(color.GrayModel.Convert(img.At(sx, sy)).(color.Gray).Y)
+					count++
+				}
+			}
+			gray.Pix[y*gray.Stride+x] = uint8(sum / count)
+		}
+	}
+	return gray
+}
+
+// ditherBitmap turns a grayscale image into a 1 bit per dot bitmap with
+// Floyd-Steinberg error diffusion. Set bits print black.
+func ditherBitmap(gray *image.Gray) ([]byte, int, int) {
+	width, height := gray.Rect.Dx(), gray.Rect.Dy()
+	rowBytes := (width + 7) / 8
+	bitmap := make([]byte, rowBytes*height)
+
+	current := make([]int, width+2)
+	next := make([]int, width+2)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			value := int(gray.Pix[y*gray.Stride+x]) + current[x+1]
+			output := 255
+			if value < 128 {
+				output = 0
+				bitmap[y*rowBytes+x/8] |= 0x80 >> (x % 8)
+			}
+
+			diff := value - output
+			current[x+2] += diff * 7 / 16
+			next[x] += diff * 3 / 16
+			next[x+1] += diff * 5 / 16
+			next[x+2] += diff / 16
+		}
+		current, next = next, current
+		for i := range next {
+			next[i] = 0
+		}
+	}
+	return bitmap, rowBytes, height
+}
+
+// isBlankRow reports whether a bitmap row prints nothing
+func isBlankRow(row []byte) bool {
+	for _, b := range row {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+// internal/driver/kodpos/kodpos_driver.go
+package kodpos
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"device-service/internal/driver/epson"
+	"device-service/internal/model"
+	"device-service/pkg/driver"
+)
+
+// Kodpos buzzer limits of ESC B n t: n beeps of t x 50 ms
+const (
+	maxBeeps     = 9
+	maxBeepUnits = 9
+	beepUnitMs   = 50
+)
+
+const kodposManufacturer = "Kodpos"
+
+// KodposDriver implements driver.DeviceDriver and driver.PrinterDriver for
+// Kodpos printers. They are ESC/POS compatible, so printing, cutting and the
+// cash drawer go through the EPSON driver; the buzzer uses Kodpos commands.
+type KodposDriver struct {
+	*epson.EPSONDriver
+}
+
+// NewKodposDriver creates a new Kodpos printer driver
+func NewKodposDriver(ctx context.Context, device *model.Device, connectionConfig interface{}, policy driver.ConnectionPolicy, logger *zap.Logger) (driver.DeviceDriver, error) {
+	base, err := epson.NewEPSONDriver(ctx, device, connectionConfig, policy, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	escposDriver, ok := base.(*epson.EPSONDriver)
+	if !ok {
+		return nil, fmt.Errorf("unexpected ESC/POS driver type %T", base)
+	}
+	escposDriver.SetBuzzer(buzzerCommands)
+
+	deviceInfo, _ := escposDriver.GetDeviceInfo()
+	deviceInfo.Manufacturer = kodposManufacturer
+
+	return &KodposDriver{EPSONDriver: escposDriver}, nil
+}
+
+// buzzerCommands sounds the buzzer with ESC B n t. The duration is rounded to
+// 50 ms units; counts and durations beyond the printer's range are capped.
+func buzzerCommands(count, durationMs int) ([][]byte, int, int) {
+	beeps := min(count, maxBeeps)
+	units := min(max((durationMs+beepUnitMs/2)/beepUnitMs, 1), maxBeepUnits)
+
+	return [][]byte{{0x1B, 0x42, byte(beeps), byte(units)}}, beeps, units * beepUnitMs
+}
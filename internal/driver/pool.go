@@ -0,0 +1,608 @@
+// internal/driver/pool.go
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"device-service/internal/model"
+	"device-service/internal/protocol"
+	"device-service/pkg/driver"
+)
+
+var (
+	// ErrCircuitOpen is returned when a device failed too often and is cooling down
+	ErrCircuitOpen = errors.New("device circuit breaker is open")
+
+	// ErrPoolExhausted is returned when the maximum number of open
+	// connections is reached and none of them is idle
+	ErrPoolExhausted = errors.New("driver pool exhausted")
+)
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// Pool keeps live drivers of connected devices so that operations reuse one
+// connection per device instead of creating a driver on every call. It also
+// tracks in-flight operations and a per-device circuit breaker.
+//
+// Idle drivers can be evicted after a TTL and the total number of open
+// connections can be capped. A driver is only evicted while no operation
+// holds it; the check and the removal happen under the pool lock, so an
+// operation either acquires the driver first or creates a new one. A driver
+// removed or replaced while operations hold it is closed by the last release.
+type Pool struct {
+	registry *Registry
+	entries  map[string]*poolEntry
+	breakers map[string]*circuitBreaker
+
+	failureThreshold int
+	cooldown         time.Duration
+
+	maxIdlePerDevice int
+	idleTTL          time.Duration
+	maxOpen          int
+	pending          int // drivers being created outside the lock
+
+	created   int64
+	evicted   int64
+	exhausted int64
+
+	mu     sync.Mutex
+	logger *zap.Logger
+}
+
+// poolEntry holds a cached driver with its usage state
+type poolEntry struct {
+	driver    driver.DeviceDriver
+	createdAt time.Time
+	lastUsed  time.Time
+	inFlight  int
+	detached  bool // dropped from the pool while in use; closed on its last release
+}
+
+// circuitBreaker tracks consecutive operation failures of a device
+type circuitBreaker struct {
+	consecutiveFailures int
+	openedAt            *time.Time
+	lastFailure         *time.Time
+	trialInFlight       bool // the one operation let through while half-open
+}
+
+// ConnectionState describes the live connection of a device
+type ConnectionState struct {
+	DeviceID          string     `json:"device_id"`
+	DriverCached      bool       `json:"driver_cached"`
+	Connected         bool       `json:"connected"`
+	CachedAt          *time.Time `json:"cached_at,omitempty"`
+	LastUsed          *time.Time `json:"last_used,omitempty"`
+	InFlight          int        `json:"in_flight_operations"`
+	CircuitState      string     `json:"circuit_state"`
+	ConsecutiveErrors int        `json:"consecutive_failures"`
+	CircuitOpenedAt   *time.Time `json:"circuit_opened_at,omitempty"`
+	LastFailure       *time.Time `json:"last_failure,omitempty"`
+}
+
+// PoolStats describes the utilization of the driver pool
+type PoolStats struct {
+	Open             int           `json:"open"`
+	InUse            int           `json:"in_use"`
+	Idle             int           `json:"idle"`
+	InFlight         int           `json:"in_flight_operations"`
+	MaxOpen          int           `json:"max_open"` // 0 means unlimited
+	MaxIdlePerDevice int           `json:"max_idle_per_device"`
+	IdleTTL          time.Duration `json:"idle_ttl"`    // 0 means never evicted
+	Utilization      float64       `json:"utilization"` // Open/MaxOpen, 0 without a cap
+	Created          int64         `json:"created_total"`
+	Evicted          int64         `json:"evicted_total"`
+	Exhausted        int64         `json:"exhausted_total"`
+}
+
+// NewPool creates a new driver pool backed by the registry
+func NewPool(registry *Registry, logger *zap.Logger) *Pool {
+	return &Pool{
+		registry:         registry,
+		entries:          make(map[string]*poolEntry),
+		breakers:         make(map[string]*circuitBreaker),
+		failureThreshold: 5,
+		cooldown:         30 * time.Second,
+		maxIdlePerDevice: 1,
+		logger:           logger,
+	}
+}
+
+// SetLimits configures idle eviction and the connection cap. A device holds
+// a single connection, so maxIdlePerDevice is either zero (close it as soon
+// as the last operation releases it) or one (keep it). An idleTTL of zero
+// disables eviction and a maxOpen of zero disables the cap.
+func (p *Pool) SetLimits(maxIdlePerDevice int, idleTTL time.Duration, maxOpen int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if maxIdlePerDevice > 1 {
+		maxIdlePerDevice = 1
+	}
+	if maxIdlePerDevice < 0 {
+		maxIdlePerDevice = 0
+	}
+	p.maxIdlePerDevice = maxIdlePerDevice
+	p.idleTTL = idleTTL
+	p.maxOpen = maxOpen
+}
+
+// SetCircuitBreaker configures when a device circuit opens and how long it
+// stays open. A threshold of zero or less disables the breaker.
+func (p *Pool) SetCircuitBreaker(failureThreshold int, cooldown time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failureThreshold = failureThreshold
+	p.cooldown = cooldown
+}
+
+// Put caches the live driver of a device. A previously cached, different
+// driver is returned so the caller can close it. When the pool is full the
+// least recently used idle driver of another device is evicted.
+func (p *Pool) Put(deviceID string, driverInstance driver.DeviceDriver) driver.DeviceDriver {
+	p.mu.Lock()
+
+	var previous driver.DeviceDriver
+	entry, exists := p.entries[deviceID]
+	if exists && entry.driver == driverInstance {
+		entry.lastUsed = time.Now()
+		p.mu.Unlock()
+		return nil
+	}
+	if exists {
+		previous = p.detachLocked(entry)
+	}
+
+	var evicted []evictedDriver
+	if !exists && p.maxOpen > 0 && len(p.entries)+p.pending >= p.maxOpen {
+		if victim, ok := p.evictLRULocked(deviceID); ok {
+			evicted = append(evicted, victim)
+		}
+	}
+
+	now := time.Now()
+	p.entries[deviceID] = &poolEntry{
+		driver:    driverInstance,
+		createdAt: now,
+		lastUsed:  now,
+	}
+	p.mu.Unlock()
+
+	p.closeEvicted(evicted, "pool full")
+	return previous
+}
+
+// Get returns the cached driver of a device
+func (p *Pool) Get(deviceID string) (driver.DeviceDriver, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, exists := p.entries[deviceID]
+	if !exists {
+		return nil, false
+	}
+	return entry.driver, true
+}
+
+// Remove drops the cached driver of a device, and the output captured when
+// it is a virtual device. An idle driver is returned for the caller to
+// close; a driver still held by operations isn't returned and is closed when
+// the last of them releases it.
+func (p *Pool) Remove(deviceID string) (driver.DeviceDriver, bool) {
+	protocol.ClearCapturedOutput(deviceID)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, exists := p.entries[deviceID]
+	if !exists {
+		return nil, false
+	}
+	delete(p.entries, deviceID)
+	if previous := p.detachLocked(entry); previous != nil {
+		return previous, true
+	}
+	return nil, false
+}
+
+// detachLocked marks an entry dropped from the pool and returns its driver
+// when idle, for the caller to close. A driver held by operations is closed
+// by its last release instead. Callers must hold the pool lock.
+func (p *Pool) detachLocked(entry *poolEntry) driver.DeviceDriver {
+	entry.detached = true
+	if entry.inFlight > 0 {
+		return nil
+	}
+	return entry.driver
+}
+
+// Acquire returns a connected driver for the device, reusing the cached one
+// when possible and creating (and caching) a new one otherwise. A driver
+// created with the lazy connect strategy is connected here, under ctx. The
+// returned release func must be called with the operation outcome.
+func (p *Pool) Acquire(ctx context.Context, device *model.Device) (driver.DeviceDriver, func(err error), error) {
+	trial, err := p.checkCircuit(device.DeviceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.mu.Lock()
+	entry, exists := p.entries[device.DeviceID]
+	if exists && entry.driver.IsConnected() {
+		entry.inFlight++
+		entry.lastUsed = time.Now()
+		p.mu.Unlock()
+		return entry.driver, p.releaseFunc(device.DeviceID, entry), nil
+	}
+
+	// Reserve a connection slot before creating the driver, making room by
+	// evicting the least recently used idle driver when the pool is full
+	var evicted []evictedDriver
+	if !exists && p.maxOpen > 0 && len(p.entries)+p.pending >= p.maxOpen {
+		victim, ok := p.evictLRULocked(device.DeviceID)
+		if !ok {
+			p.exhausted++
+			p.mu.Unlock()
+			if trial {
+				p.abandonTrial(device.DeviceID)
+			}
+			return nil, nil, fmt.Errorf("%w: %d connections open", ErrPoolExhausted, p.maxOpen)
+		}
+		evicted = append(evicted, victim)
+	}
+	p.pending++
+	p.mu.Unlock()
+
+	p.closeEvicted(evicted, "pool full")
+
+	// Creating a driver may connect to the device, so do it outside the lock
+	driverInstance, err := p.registry.CreateDriver(ctx, device, device.ConnectionConfig)
+	if err != nil {
+		p.mu.Lock()
+		p.pending--
+		p.mu.Unlock()
+		// A missing driver is a deployment gap, not a device fault
+		if !errors.Is(err, ErrDriverNotFound) {
+			p.recordResult(device.DeviceID, err)
+		} else if trial {
+			p.abandonTrial(device.DeviceID)
+		}
+		return nil, nil, err
+	}
+
+	// Lazy drivers connect on first use, which is now
+	if !driverInstance.IsConnected() && p.registry.ConnectionPolicy(device.Brand).Lazy() {
+		if err := driverInstance.Connect(ctx); err != nil {
+			p.mu.Lock()
+			p.pending--
+			p.mu.Unlock()
+			p.recordResult(device.DeviceID, err)
+			driverInstance.Close()
+			return nil, nil, err
+		}
+	}
+
+	p.mu.Lock()
+	p.pending--
+	p.created++
+	now := time.Now()
+	var stale driver.DeviceDriver
+	if current, exists := p.entries[device.DeviceID]; exists {
+		if current.driver.IsConnected() {
+			// Another caller won the race; use its driver and drop ours
+			current.inFlight++
+			current.lastUsed = now
+			p.mu.Unlock()
+			driverInstance.Close()
+			return current.driver, p.releaseFunc(device.DeviceID, current), nil
+		}
+		stale = p.detachLocked(current)
+	}
+	entry = &poolEntry{
+		driver:    driverInstance,
+		createdAt: now,
+		lastUsed:  now,
+		inFlight:  1,
+	}
+	p.entries[device.DeviceID] = entry
+	p.mu.Unlock()
+
+	if stale != nil {
+		stale.Close()
+	}
+
+	return driverInstance, p.releaseFunc(device.DeviceID, entry), nil
+}
+
+// AcquireConnected returns the cached driver of a device if it is connected,
+// without creating one. The returned release func must be called with the
+// operation outcome.
+func (p *Pool) AcquireConnected(deviceID string) (driver.DeviceDriver, func(err error), bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, exists := p.entries[deviceID]
+	if !exists || !entry.driver.IsConnected() {
+		return nil, nil, false
+	}
+	entry.inFlight++
+	entry.lastUsed = time.Now()
+	return entry.driver, p.releaseFunc(deviceID, entry), true
+}
+
+// releaseFunc builds the release callback of an acquired driver
+func (p *Pool) releaseFunc(deviceID string, entry *poolEntry) func(err error) {
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() {
+			var evicted []evictedDriver
+			reason := "no idle connections kept"
+
+			p.mu.Lock()
+			entry.inFlight--
+			entry.lastUsed = time.Now()
+			switch {
+			case entry.inFlight > 0:
+				// Still held by other operations
+			case entry.detached:
+				// Removed or replaced while this operation held it
+				reason = "removed while in use"
+				evicted = append(evicted, evictedDriver{deviceID: deviceID, driver: entry.driver})
+			case p.maxIdlePerDevice == 0 && p.entries[deviceID] == entry:
+				delete(p.entries, deviceID)
+				p.evicted++
+				evicted = append(evicted, evictedDriver{deviceID: deviceID, driver: entry.driver})
+			}
+			p.mu.Unlock()
+
+			p.closeEvicted(evicted, reason)
+			p.recordResult(deviceID, err)
+		})
+	}
+}
+
+// evictedDriver is a driver removed from the pool that still has to be closed
+type evictedDriver struct {
+	deviceID string
+	driver   driver.DeviceDriver
+}
+
+// EvictIdle closes drivers that have not been used for longer than the idle
+// TTL and returns how many were evicted. Drivers held by an operation are
+// never evicted.
+func (p *Pool) EvictIdle() int {
+	p.mu.Lock()
+	if p.idleTTL <= 0 {
+		p.mu.Unlock()
+		return 0
+	}
+
+	now := time.Now()
+	var evicted []evictedDriver
+	for deviceID, entry := range p.entries {
+		if entry.inFlight == 0 && now.Sub(entry.lastUsed) >= p.idleTTL {
+			delete(p.entries, deviceID)
+			evicted = append(evicted, evictedDriver{deviceID: deviceID, driver: entry.driver})
+		}
+	}
+	p.evicted += int64(len(evicted))
+	p.mu.Unlock()
+
+	p.closeEvicted(evicted, "idle timeout")
+	return len(evicted)
+}
+
+// EvictInactive closes the cached drivers of devices missing from active,
+// e.g. devices deleted or taken offline behind the pool's back, and returns
+// how many were evicted. Only drivers idle since before asOf, when active was
+// read, are evicted, so a driver created for a device that just came online
+// survives. Drivers held by an operation are never evicted.
+func (p *Pool) EvictInactive(active map[string]bool, asOf time.Time) int {
+	p.mu.Lock()
+	var evicted []evictedDriver
+	for deviceID, entry := range p.entries {
+		if active[deviceID] || entry.inFlight > 0 || !entry.lastUsed.Before(asOf) {
+			continue
+		}
+		delete(p.entries, deviceID)
+		evicted = append(evicted, evictedDriver{deviceID: deviceID, driver: entry.driver})
+	}
+	p.evicted += int64(len(evicted))
+	p.mu.Unlock()
+
+	p.closeEvicted(evicted, "device not active")
+	return len(evicted)
+}
+
+// evictLRULocked removes the least recently used idle driver of a device
+// other than exceptDeviceID; callers must hold the pool lock
+func (p *Pool) evictLRULocked(exceptDeviceID string) (evictedDriver, bool) {
+	var victimID string
+	var victim *poolEntry
+	for deviceID, entry := range p.entries {
+		if deviceID == exceptDeviceID || entry.inFlight > 0 {
+			continue
+		}
+		if victim == nil || entry.lastUsed.Before(victim.lastUsed) {
+			victimID, victim = deviceID, entry
+		}
+	}
+	if victim == nil {
+		return evictedDriver{}, false
+	}
+
+	delete(p.entries, victimID)
+	p.evicted++
+	return evictedDriver{deviceID: victimID, driver: victim.driver}, true
+}
+
+// closeEvicted closes evicted drivers, which disconnects their protocol
+func (p *Pool) closeEvicted(evicted []evictedDriver, reason string) {
+	for _, e := range evicted {
+		if err := e.driver.Close(); err != nil {
+			p.logger.Warn("Failed to close evicted driver",
+				zap.String("device_id", e.deviceID),
+				zap.String("reason", reason),
+				zap.Error(err),
+			)
+			continue
+		}
+		p.logger.Info("Evicted driver from pool",
+			zap.String("device_id", e.deviceID),
+			zap.String("reason", reason),
+		)
+	}
+}
+
+// Stats returns the current pool utilization
+func (p *Pool) Stats() *PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := &PoolStats{
+		Open:             len(p.entries),
+		MaxOpen:          p.maxOpen,
+		MaxIdlePerDevice: p.maxIdlePerDevice,
+		IdleTTL:          p.idleTTL,
+		Created:          p.created,
+		Evicted:          p.evicted,
+		Exhausted:        p.exhausted,
+	}
+	for _, entry := range p.entries {
+		if entry.inFlight > 0 {
+			stats.InUse++
+			stats.InFlight += entry.inFlight
+		} else {
+			stats.Idle++
+		}
+	}
+	if p.maxOpen > 0 {
+		stats.Utilization = float64(stats.Open) / float64(p.maxOpen)
+	}
+	return stats
+}
+
+// checkCircuit returns ErrCircuitOpen while the device is cooling down. Once
+// the cooldown is over a single trial operation is let through, reported as
+// trial; others are rejected until its result closes or re-opens the circuit.
+func (p *Pool) checkCircuit(deviceID string) (trial bool, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	breaker, exists := p.breakers[deviceID]
+	if !exists {
+		return false, nil
+	}
+
+	switch p.circuitState(breaker, time.Now()) {
+	case CircuitOpen:
+		retryIn := breaker.openedAt.Add(p.cooldown).Sub(time.Now()).Round(time.Second)
+		return false, fmt.Errorf("%w: retry in %s", ErrCircuitOpen, retryIn)
+	case CircuitHalfOpen:
+		if breaker.trialInFlight {
+			return false, fmt.Errorf("%w: trial operation in progress", ErrCircuitOpen)
+		}
+		breaker.trialInFlight = true
+		return true, nil
+	}
+	return false, nil
+}
+
+// abandonTrial lets another operation try a half-open circuit after the
+// trial ended without reaching the device
+func (p *Pool) abandonTrial(deviceID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if breaker, exists := p.breakers[deviceID]; exists {
+		breaker.trialInFlight = false
+	}
+}
+
+// recordResult updates the circuit breaker of a device
+func (p *Pool) recordResult(deviceID string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	breaker, exists := p.breakers[deviceID]
+	if err == nil {
+		if exists {
+			delete(p.breakers, deviceID)
+		}
+		return
+	}
+
+	if !exists {
+		breaker = &circuitBreaker{}
+		p.breakers[deviceID] = breaker
+	}
+
+	now := time.Now()
+	breaker.consecutiveFailures++
+	breaker.lastFailure = &now
+	breaker.trialInFlight = false
+
+	// A failed trial in half-open state re-opens the circuit right away
+	if p.failureThreshold > 0 && breaker.consecutiveFailures >= p.failureThreshold {
+		if breaker.openedAt == nil || p.circuitState(breaker, now) == CircuitHalfOpen {
+			breaker.openedAt = &now
+			p.logger.Warn("Device circuit breaker opened",
+				zap.String("device_id", deviceID),
+				zap.Int("consecutive_failures", breaker.consecutiveFailures),
+				zap.Duration("cooldown", p.cooldown),
+			)
+		}
+	}
+}
+
+// circuitState derives the breaker state; callers must hold the pool lock
+func (p *Pool) circuitState(breaker *circuitBreaker, now time.Time) string {
+	if p.failureThreshold <= 0 || breaker.openedAt == nil {
+		return CircuitClosed
+	}
+	if now.Sub(*breaker.openedAt) < p.cooldown {
+		return CircuitOpen
+	}
+	return CircuitHalfOpen
+}
+
+// State returns the connection state of a device
+func (p *Pool) State(deviceID string) *ConnectionState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state := &ConnectionState{
+		DeviceID:     deviceID,
+		CircuitState: CircuitClosed,
+	}
+
+	if entry, exists := p.entries[deviceID]; exists {
+		createdAt, lastUsed := entry.createdAt, entry.lastUsed
+		state.DriverCached = true
+		state.Connected = entry.driver.IsConnected()
+		state.CachedAt = &createdAt
+		state.LastUsed = &lastUsed
+		state.InFlight = entry.inFlight
+	}
+
+	if breaker, exists := p.breakers[deviceID]; exists {
+		state.CircuitState = p.circuitState(breaker, time.Now())
+		state.ConsecutiveErrors = breaker.consecutiveFailures
+		state.CircuitOpenedAt = breaker.openedAt
+		state.LastFailure = breaker.lastFailure
+	}
+
+	return state
+}
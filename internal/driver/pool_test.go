@@ -0,0 +1,279 @@
+// internal/driver/pool_test.go
+package driver
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"device-service/internal/model"
+	"device-service/pkg/driver"
+)
+
+// fakeDriver is a connected driver that records how it is used and closed
+type fakeDriver struct {
+	name      string
+	inUse     atomic.Int32 // acquired by the test and not yet released
+	closes    atomic.Int32
+	misclosed atomic.Bool // closed while acquired
+}
+
+func (d *fakeDriver) Connect(ctx context.Context) error    { return nil }
+func (d *fakeDriver) Disconnect(ctx context.Context) error { return nil }
+func (d *fakeDriver) IsConnected() bool                    { return d.closes.Load() == 0 }
+func (d *fakeDriver) GetDeviceInfo() (*driver.DeviceInfo, error) {
+	return &driver.DeviceInfo{}, nil
+}
+func (d *fakeDriver) GetCapabilities() []model.Capability { return nil }
+func (d *fakeDriver) GetStatus() (*driver.DeviceStatus, error) {
+	return &driver.DeviceStatus{}, nil
+}
+func (d *fakeDriver) ExecuteOperation(ctx context.Context, operation *model.DeviceOperation) (*driver.OperationResult, error) {
+	return &driver.OperationResult{Success: true}, nil
+}
+func (d *fakeDriver) Ping(ctx context.Context) error { return nil }
+func (d *fakeDriver) GetHealthMetrics() (*driver.HealthMetrics, error) {
+	return &driver.HealthMetrics{}, nil
+}
+func (d *fakeDriver) Configure(config interface{}) error  { return nil }
+func (d *fakeDriver) Reset(ctx context.Context) error     { return nil }
+func (d *fakeDriver) SetEventHandler(driver.EventHandler) {}
+
+func (d *fakeDriver) Close() error {
+	if d.inUse.Load() > 0 {
+		d.misclosed.Store(true)
+	}
+	d.closes.Add(1)
+	return nil
+}
+
+// fakeFactory registers drivers created by the registry so tests can
+// inspect them
+type fakeFactory struct {
+	name    string
+	mu      sync.Mutex
+	created []*fakeDriver
+}
+
+func (f *fakeFactory) create(ctx context.Context, device *model.Device, connectionConfig interface{}, policy driver.ConnectionPolicy, logger *zap.Logger) (driver.DeviceDriver, error) {
+	d := &fakeDriver{name: f.name}
+	f.mu.Lock()
+	f.created = append(f.created, d)
+	f.mu.Unlock()
+	return d, nil
+}
+
+func (f *fakeFactory) drivers() []*fakeDriver {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*fakeDriver(nil), f.created...)
+}
+
+func newTestPool(t *testing.T) (*Pool, *fakeFactory) {
+	t.Helper()
+	factory := &fakeFactory{name: "fake"}
+	registry := NewRegistry(zap.NewNop())
+	registry.Register(model.BrandEpson, model.DeviceTypePrinter, "*", factory.create)
+	return NewPool(registry, zap.NewNop()), factory
+}
+
+func testDevice(deviceID string) *model.Device {
+	return &model.Device{
+		DeviceID:         deviceID,
+		Brand:            model.BrandEpson,
+		DeviceType:       model.DeviceTypePrinter,
+		Model:            "TM-T88VI",
+		ConnectionConfig: model.JSONObject{},
+	}
+}
+
+// acquire takes a driver from the pool and marks it in use until released
+func acquire(t *testing.T, pool *Pool, device *model.Device) (*fakeDriver, func()) {
+	t.Helper()
+	instance, release, err := pool.Acquire(context.Background(), device)
+	if err != nil {
+		t.Errorf("Acquire(%s) failed: %v", device.DeviceID, err)
+		return nil, func() {}
+	}
+	d := instance.(*fakeDriver)
+	d.inUse.Add(1)
+	return d, func() {
+		d.inUse.Add(-1)
+		release(nil)
+	}
+}
+
+// TestPoolConcurrentAcquireAndEvict runs acquires against idle eviction and
+// checks that no driver is closed while held. Run it with -race.
+func TestPoolConcurrentAcquireAndEvict(t *testing.T) {
+	pool, factory := newTestPool(t)
+	pool.SetLimits(1, time.Nanosecond, 2)
+
+	devices := []*model.Device{testDevice("DEV-1"), testDevice("DEV-2"), testDevice("DEV-3")}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var evictors sync.WaitGroup
+	evictors.Add(1)
+	go func() {
+		defer evictors.Done()
+		for ctx.Err() == nil {
+			pool.EvictIdle()
+		}
+	}()
+
+	var workers sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		workers.Add(1)
+		go func(w int) {
+			defer workers.Done()
+			for i := 0; i < 200; i++ {
+				device := devices[(w+i)%len(devices)]
+				instance, release, err := pool.Acquire(context.Background(), device)
+				if errors.Is(err, ErrPoolExhausted) {
+					continue
+				}
+				if err != nil {
+					t.Errorf("Acquire(%s) failed: %v", device.DeviceID, err)
+					return
+				}
+				d := instance.(*fakeDriver)
+				d.inUse.Add(1)
+				if d.closes.Load() > 0 {
+					t.Errorf("Acquire(%s) returned a closed driver", device.DeviceID)
+				}
+				time.Sleep(20 * time.Microsecond) // the operation
+				d.inUse.Add(-1)
+				release(nil)
+			}
+		}(w)
+	}
+	workers.Wait()
+	cancel()
+	evictors.Wait()
+
+	stats := pool.Stats()
+	if stats.Open > 2 {
+		t.Errorf("%d connections open, want at most 2", stats.Open)
+	}
+
+	cached := 0
+	for _, d := range factory.drivers() {
+		if d.misclosed.Load() {
+			t.Fatal("driver closed while held by an operation")
+		}
+		switch closes := d.closes.Load(); {
+		case closes > 1:
+			t.Fatalf("driver closed %d times", closes)
+		case closes == 0:
+			cached++
+		}
+	}
+	if cached != stats.Open {
+		t.Errorf("%d drivers left open, want the %d cached ones", cached, stats.Open)
+	}
+}
+
+func TestPoolRemoveClosesHeldDriverOnLastRelease(t *testing.T) {
+	pool, _ := newTestPool(t)
+	device := testDevice("DEV-1")
+
+	first, releaseFirst := acquire(t, pool, device)
+	second, releaseSecond := acquire(t, pool, device)
+	if first != second {
+		t.Fatal("concurrent acquires of a device got different drivers")
+	}
+
+	if idle, _ := pool.Remove(device.DeviceID); idle != nil {
+		t.Fatal("Remove returned a driver still held by operations")
+	}
+
+	releaseFirst()
+	if first.closes.Load() != 0 {
+		t.Fatal("driver closed while still held")
+	}
+	releaseSecond()
+	if closes := first.closes.Load(); closes != 1 {
+		t.Fatalf("driver closed %d times after its last release, want 1", closes)
+	}
+
+	replacement, release := acquire(t, pool, device)
+	defer release()
+	if replacement == first {
+		t.Fatal("Acquire after Remove returned the removed driver")
+	}
+}
+
+func TestPoolMaxOpenEvictsIdleDriver(t *testing.T) {
+	pool, _ := newTestPool(t)
+	pool.SetLimits(1, 0, 1)
+
+	held, releaseHeld := acquire(t, pool, testDevice("DEV-1"))
+
+	if _, _, err := pool.Acquire(context.Background(), testDevice("DEV-2")); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("Acquire with every connection held: got %v, want %v", err, ErrPoolExhausted)
+	}
+
+	releaseHeld()
+	other, release := acquire(t, pool, testDevice("DEV-2"))
+	defer release()
+
+	if held.closes.Load() != 1 {
+		t.Fatal("idle driver not closed to make room")
+	}
+	if other.closes.Load() != 0 {
+		t.Fatal("new driver closed")
+	}
+	if stats := pool.Stats(); stats.Open != 1 || stats.Exhausted != 1 || stats.Evicted != 1 {
+		t.Fatalf("stats = %+v, want 1 open, 1 exhausted, 1 evicted", stats)
+	}
+}
+
+func TestPoolHalfOpenCircuitAdmitsOneTrial(t *testing.T) {
+	pool, _ := newTestPool(t)
+	pool.SetCircuitBreaker(1, 20*time.Millisecond)
+	device := testDevice("DEV-1")
+	deviceErr := errors.New("paper jam")
+
+	_, release := acquireWithResult(t, pool, device)
+	release(deviceErr)
+	if _, _, err := pool.Acquire(context.Background(), device); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Acquire during cooldown: got %v, want %v", err, ErrCircuitOpen)
+	}
+
+	// A failed trial re-opens the circuit
+	time.Sleep(30 * time.Millisecond)
+	_, releaseTrial := acquireWithResult(t, pool, device)
+	if _, _, err := pool.Acquire(context.Background(), device); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Acquire during a half-open trial: got %v, want %v", err, ErrCircuitOpen)
+	}
+	releaseTrial(deviceErr)
+	if state := pool.State(device.DeviceID).CircuitState; state != CircuitOpen {
+		t.Fatalf("circuit %s after a failed trial, want %s", state, CircuitOpen)
+	}
+
+	// A successful trial closes it
+	time.Sleep(30 * time.Millisecond)
+	_, releaseTrial = acquireWithResult(t, pool, device)
+	releaseTrial(nil)
+	if state := pool.State(device.DeviceID).CircuitState; state != CircuitClosed {
+		t.Fatalf("circuit %s after a successful trial, want %s", state, CircuitClosed)
+	}
+	_, release = acquireWithResult(t, pool, device)
+	release(nil)
+}
+
+// acquireWithResult takes a driver from the pool, leaving the operation
+// outcome to the caller
+func acquireWithResult(t *testing.T, pool *Pool, device *model.Device) (driver.DeviceDriver, func(error)) {
+	t.Helper()
+	instance, release, err := pool.Acquire(context.Background(), device)
+	if err != nil {
+		t.Fatalf("Acquire(%s) failed: %v", device.DeviceID, err)
+	}
+	return instance, release
+}
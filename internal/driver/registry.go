@@ -2,41 +2,147 @@
 package driver
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 
 	"device-service/internal/model"
+	"device-service/internal/protocol"
+	"device-service/internal/utils"
 	"device-service/pkg/driver" // ✅ Artık pkg'den import
 )
 
-// DriverFactory creates device drivers - ✅ Return type düzeltildi
-type DriverFactory func(device *model.Device, connectionConfig interface{}, logger *zap.Logger) (driver.DeviceDriver, error)
+// DriverFactory creates device drivers - ✅ Return type düzeltildi. Drivers
+// that connect eagerly do so under ctx.
+type DriverFactory func(ctx context.Context, device *model.Device, connectionConfig interface{}, policy driver.ConnectionPolicy, logger *zap.Logger) (driver.DeviceDriver, error)
 
-// Registry manages device driver registration and creation
+// Registry manages device driver registration and creation.
+// All methods are safe for concurrent use, so drivers can be registered,
+// unregistered or toggled at runtime while operations are in flight.
 type Registry struct {
-	drivers map[DriverKey]DriverFactory
-	mu      sync.RWMutex
-	logger  *zap.Logger
+	drivers       map[DriverKey]*registryEntry
+	defaultPolicy driver.ConnectionPolicy
+	brandPolicies map[model.DeviceBrand]driver.ConnectionPolicy
+	configCipher  *utils.ConfigCipher
+	mu            sync.RWMutex
+	logger        *zap.Logger
 }
 
 // DriverKey uniquely identifies a driver
 type DriverKey struct {
-	Brand      model.DeviceBrand
-	DeviceType model.DeviceType
-	Model      string
+	Brand      model.DeviceBrand `json:"brand"`
+	DeviceType model.DeviceType  `json:"device_type"`
+	Model      string            `json:"model"`
+}
+
+// DriverInfo describes a registered driver and its runtime state
+type DriverInfo struct {
+	DriverKey
+	Enabled      bool      `json:"enabled"`
+	RegisteredAt time.Time `json:"registered_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// registryEntry holds a factory together with its runtime state
+type registryEntry struct {
+	factory      DriverFactory
+	enabled      bool
+	registeredAt time.Time
+	updatedAt    time.Time
+}
+
+// ErrDriverNotFound is returned when no enabled driver is registered for a
+// device's brand, type and model
+var ErrDriverNotFound = errors.New("driver not found")
+
+// DriverNotFoundError names the device a driver is missing for. It matches
+// ErrDriverNotFound with errors.Is.
+type DriverNotFoundError struct {
+	DriverKey
+}
+
+func (e *DriverNotFoundError) Error() string {
+	return fmt.Sprintf("no driver found for brand=%s, type=%s, model=%s", e.Brand, e.DeviceType, e.Model)
+}
+
+func (e *DriverNotFoundError) Is(target error) bool {
+	return target == ErrDriverNotFound
+}
+
+var (
+	defaultRegistry     *Registry
+	defaultRegistryOnce sync.Once
+)
+
+// DefaultRegistry returns the process-wide driver registry, creating it on
+// first use. The logger is only used when the registry is created.
+func DefaultRegistry(logger *zap.Logger) *Registry {
+	defaultRegistryOnce.Do(func() {
+		defaultRegistry = NewRegistry(logger)
+	})
+	return defaultRegistry
 }
 
 // NewRegistry creates a new driver registry
 func NewRegistry(logger *zap.Logger) *Registry {
 	return &Registry{
-		drivers: make(map[DriverKey]DriverFactory),
-		logger:  logger,
+		drivers:       make(map[DriverKey]*registryEntry),
+		defaultPolicy: driver.DefaultConnectionPolicy(),
+		brandPolicies: make(map[model.DeviceBrand]driver.ConnectionPolicy),
+		logger:        logger,
+	}
+}
+
+// SetConnectionPolicy sets the connection policy used by all drivers
+// that do not have a brand-specific override
+func (r *Registry) SetConnectionPolicy(policy driver.ConnectionPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.defaultPolicy = policy
+	r.logger.Info("Default connection policy set",
+		zap.Duration("connect_timeout", policy.ConnectTimeout),
+		zap.Int("max_retries", policy.MaxRetries),
+		zap.Duration("initial_backoff", policy.InitialBackoff),
+		zap.Duration("max_backoff", policy.MaxBackoff),
+		zap.Float64("backoff_jitter", policy.BackoffJitter),
+		zap.Duration("ping_timeout", policy.PingTimeout),
+		zap.String("connect_strategy", string(policy.ConnectStrategy)),
+	)
+}
+
+// SetBrandConnectionPolicy overrides the connection policy for a brand
+func (r *Registry) SetBrandConnectionPolicy(brand model.DeviceBrand, policy driver.ConnectionPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.brandPolicies[brand] = policy
+	r.logger.Info("Brand connection policy set",
+		zap.String("brand", string(brand)),
+		zap.Duration("connect_timeout", policy.ConnectTimeout),
+		zap.Int("max_retries", policy.MaxRetries),
+		zap.String("connect_strategy", string(policy.ConnectStrategy)),
+	)
+}
+
+// ConnectionPolicy returns the effective connection policy for a brand
+func (r *Registry) ConnectionPolicy(brand model.DeviceBrand) driver.ConnectionPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if policy, exists := r.brandPolicies[brand]; exists {
+		return policy
 	}
+	return r.defaultPolicy
 }
 
-// Register registers a driver factory
+// Register registers a driver factory. Registering an existing key replaces
+// its factory and re-enables it.
 func (r *Registry) Register(brand model.DeviceBrand, deviceType model.DeviceType, model string, factory DriverFactory) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -47,83 +153,368 @@ func (r *Registry) Register(brand model.DeviceBrand, deviceType model.DeviceType
 		Model:      model,
 	}
 
-	r.drivers[key] = factory
+	now := time.Now()
+	entry, exists := r.drivers[key]
+	if !exists {
+		entry = &registryEntry{registeredAt: now}
+		r.drivers[key] = entry
+	}
+	entry.factory = factory
+	entry.enabled = true
+	entry.updatedAt = now
+
 	r.logger.Info("Driver registered",
 		zap.String("brand", string(brand)),
 		zap.String("device_type", string(deviceType)),
 		zap.String("model", model),
+		zap.Bool("replaced", exists),
+	)
+}
+
+// Unregister removes a driver factory. It returns false if no driver was
+// registered under the given key.
+func (r *Registry) Unregister(brand model.DeviceBrand, deviceType model.DeviceType, model string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := DriverKey{Brand: brand, DeviceType: deviceType, Model: model}
+	if _, exists := r.drivers[key]; !exists {
+		return false
+	}
+
+	delete(r.drivers, key)
+	r.logger.Info("Driver unregistered",
+		zap.String("brand", string(brand)),
+		zap.String("device_type", string(deviceType)),
+		zap.String("model", model),
+	)
+	return true
+}
+
+// SetEnabled enables or disables a registered driver without removing it.
+// Disabled drivers are skipped by CreateDriver and IsSupported.
+func (r *Registry) SetEnabled(key DriverKey, enabled bool) (*DriverInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, exists := r.drivers[key]
+	if !exists {
+		return nil, fmt.Errorf("driver not registered: brand=%s, type=%s, model=%s",
+			key.Brand, key.DeviceType, key.Model)
+	}
+
+	if entry.enabled != enabled {
+		entry.enabled = enabled
+		entry.updatedAt = time.Now()
+		r.logger.Info("Driver state changed",
+			zap.String("brand", string(key.Brand)),
+			zap.String("device_type", string(key.DeviceType)),
+			zap.String("model", key.Model),
+			zap.Bool("enabled", enabled),
+		)
+	}
+
+	info := entry.info(key)
+	return &info, nil
+}
+
+// CreateDriver creates a driver instance. A device without an enabled
+// driver fails with a DriverNotFoundError tagged DRIVER_NOT_FOUND, so it
+// isn't mistaken for a connection failure. An eager connect is bounded by
+// ctx.
+func (r *Registry) CreateDriver(ctx context.Context, device *model.Device, connectionConfig interface{}) (driver.DeviceDriver, error) {
+	return r.createDriver(ctx, device, connectionConfig, r.ConnectionPolicy(device.Brand))
+}
+
+// CreateLazyDriver creates a driver instance that doesn't connect until its
+// Connect is called, whatever the configured connect strategy. It is meant
+// for callers that connect the driver themselves under their own deadline.
+func (r *Registry) CreateLazyDriver(ctx context.Context, device *model.Device, connectionConfig interface{}) (driver.DeviceDriver, error) {
+	policy := r.ConnectionPolicy(device.Brand)
+	policy.ConnectStrategy = driver.ConnectLazy
+	return r.createDriver(ctx, device, connectionConfig, policy)
+}
+
+// createDriver creates a driver instance with the given connection policy
+func (r *Registry) createDriver(ctx context.Context, device *model.Device, connectionConfig interface{}, policy driver.ConnectionPolicy) (driver.DeviceDriver, error) {
+	// Resolve the factory under the lock but call it outside, since driver
+	// construction may open a connection and block for a while.
+	match, factory, found := r.resolve(device.Brand, device.DeviceType, device.Model)
+	if !found {
+		return nil, driver.NewOperationError(driver.ErrorCodeDriverNotFound, &DriverNotFoundError{
+			DriverKey: DriverKey{Brand: device.Brand, DeviceType: device.DeviceType, Model: device.Model},
+		})
+	}
+
+	logMatch := r.logger.Info
+	if match.Match == MatchExact {
+		logMatch = r.logger.Debug
+	}
+	logMatch("Driver entry resolved",
+		zap.String("device_id", device.DeviceID),
+		zap.String("model", device.Model),
+		zap.String("match", match.Match),
+		zap.String("entry_brand", string(match.Brand)),
+		zap.String("entry_model", match.Model),
 	)
+
+	// Secrets are decrypted only here, right before the driver connects
+	connectionConfig, err := r.decryptConnectionConfig(connectionConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// ✅ FIXED: Pass both device and connectionConfig
+	return factory(ctx, device, connectionConfig, policy, r.logger)
 }
 
-// CreateDriver creates a driver instance
-func (r *Registry) CreateDriver(device *model.Device, connectionConfig interface{}) (driver.DeviceDriver, error) {
+// ProbeTransport checks that a device is reachable over its connection
+// without creating a driver, so the printer isn't initialized. The probe is
+// bounded by the ping timeout of the device's connection policy.
+func (r *Registry) ProbeTransport(ctx context.Context, device *model.Device) error {
+	if _, _, found := r.resolve(device.Brand, device.DeviceType, device.Model); !found {
+		return driver.NewOperationError(driver.ErrorCodeDriverNotFound, &DriverNotFoundError{
+			DriverKey: DriverKey{Brand: device.Brand, DeviceType: device.DeviceType, Model: device.Model},
+		})
+	}
+
+	decrypted, err := r.decryptConnectionConfig(device.ConnectionConfig)
+	if err != nil {
+		return err
+	}
+
+	var connectionConfig map[string]interface{}
+	switch v := decrypted.(type) {
+	case model.JSONObject:
+		connectionConfig = v
+	case map[string]interface{}:
+		connectionConfig = v
+	}
+
+	probeCtx, cancel := r.ConnectionPolicy(device.Brand).PingContext(ctx)
+	defer cancel()
+
+	return protocol.ProbeTransport(probeCtx, device.ConnectionType, connectionConfig, r.logger)
+}
+
+// ConfigureDriver applies the current configuration of a device, including
+// its capability toggles, to a live driver
+func (r *Registry) ConfigureDriver(driverInstance driver.DeviceDriver, device *model.Device) error {
+	decrypted, err := r.decryptConnectionConfig(device.ConnectionConfig)
+	if err != nil {
+		return err
+	}
+
+	var connectionConfig map[string]interface{}
+	switch v := decrypted.(type) {
+	case model.JSONObject:
+		connectionConfig = v
+	case map[string]interface{}:
+		connectionConfig = v
+	}
+
+	return driverInstance.Configure(map[string]interface{}{
+		"device_id":         device.DeviceID,
+		"model":             device.Model,
+		"connection_type":   string(device.ConnectionType),
+		"connection_config": connectionConfig,
+	})
+}
+
+// SetConfigCipher sets the cipher used to decrypt secret connection config
+// values before they are handed to drivers
+func (r *Registry) SetConfigCipher(configCipher *utils.ConfigCipher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.configCipher = configCipher
+}
+
+// decryptConnectionConfig decrypts encrypted values of a connection config
+func (r *Registry) decryptConnectionConfig(connectionConfig interface{}) (interface{}, error) {
 	r.mu.RLock()
-	defer r.mu.RUnlock()
+	configCipher := r.configCipher
+	r.mu.RUnlock()
 
-	// Try exact match first
-	key := DriverKey{
-		Brand:      device.Brand,
-		DeviceType: device.DeviceType,
-		Model:      device.Model,
+	var configMap map[string]interface{}
+	switch v := connectionConfig.(type) {
+	case model.JSONObject:
+		configMap = v
+	case map[string]interface{}:
+		configMap = v
+	default:
+		return connectionConfig, nil
 	}
 
-	if factory, exists := r.drivers[key]; exists {
-		// ✅ FIXED: Pass both device and connectionConfig
-		return factory(device, connectionConfig, r.logger)
+	for _, value := range configMap {
+		if !utils.IsEncryptedValue(value) {
+			continue
+		}
+		if configCipher == nil {
+			return nil, fmt.Errorf("connection config contains encrypted values but no encryption key is configured")
+		}
+
+		decrypted, err := configCipher.DecryptConfig(configMap)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt connection config: %w", err)
+		}
+		return decrypted, nil
 	}
 
-	// Try brand + device type match (any model)
-	key.Model = "*"
-	if factory, exists := r.drivers[key]; exists {
-		return factory(device, connectionConfig, r.logger)
+	return connectionConfig, nil
+}
+
+// How a device resolved to a driver entry
+const (
+	MatchExact    = "EXACT"    // brand, device type and model
+	MatchWildcard = "WILDCARD" // brand and device type, "*" model
+	MatchGeneric  = "GENERIC"  // the generic driver of the device type
+)
+
+// DriverMatch is the driver entry a device resolves to
+type DriverMatch struct {
+	DriverKey
+	Match string `json:"match"` // EXACT, WILDCARD or GENERIC
+}
+
+// Resolve returns the enabled driver entry a device would be created with,
+// the same one CreateDriver and IsSupported use
+func (r *Registry) Resolve(brand model.DeviceBrand, deviceType model.DeviceType, deviceModel string) (DriverMatch, bool) {
+	match, _, found := r.resolve(brand, deviceType, deviceModel)
+	return match, found
+}
+
+// resolve finds the enabled factory for a device: exact match first, then
+// brand + device type (any model), then the generic driver.
+func (r *Registry) resolve(brand model.DeviceBrand, deviceType model.DeviceType, deviceModel string) (DriverMatch, DriverFactory, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	candidates := []DriverMatch{
+		{DriverKey: DriverKey{Brand: brand, DeviceType: deviceType, Model: deviceModel}, Match: MatchExact},
+		{DriverKey: DriverKey{Brand: brand, DeviceType: deviceType, Model: "*"}, Match: MatchWildcard},
+		{DriverKey: DriverKey{Brand: model.BrandGeneric, DeviceType: deviceType, Model: "*"}, Match: MatchGeneric},
 	}
 
-	// Try generic driver
-	key.Brand = model.BrandGeneric
-	if factory, exists := r.drivers[key]; exists {
-		return factory(device, connectionConfig, r.logger)
+	for _, candidate := range candidates {
+		if entry, exists := r.drivers[candidate.DriverKey]; exists && entry.enabled {
+			return candidate, entry.factory, true
+		}
 	}
 
-	return nil, fmt.Errorf("no driver found for brand=%s, type=%s, model=%s",
-		device.Brand, device.DeviceType, device.Model)
+	return DriverMatch{}, nil, false
 }
 
-// ListDrivers returns all registered drivers
+// ListDrivers returns all enabled drivers
 func (r *Registry) ListDrivers() []DriverKey {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	keys := make([]DriverKey, 0, len(r.drivers))
-	for key := range r.drivers {
-		keys = append(keys, key)
+	for key, entry := range r.drivers {
+		if entry.enabled {
+			keys = append(keys, key)
+		}
 	}
 	return keys
 }
 
-// IsSupported checks if a device is supported
+// ListDriverInfo returns all registered drivers, including disabled ones,
+// sorted by brand, device type and model
+func (r *Registry) ListDriverInfo() []DriverInfo {
+	r.mu.RLock()
+	infos := make([]DriverInfo, 0, len(r.drivers))
+	for key, entry := range r.drivers {
+		infos = append(infos, entry.info(key))
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		a, b := infos[i].DriverKey, infos[j].DriverKey
+		if a.Brand != b.Brand {
+			return a.Brand < b.Brand
+		}
+		if a.DeviceType != b.DeviceType {
+			return a.DeviceType < b.DeviceType
+		}
+		return a.Model < b.Model
+	})
+	return infos
+}
+
+// IsSupported checks if a device is supported by an exact, wildcard model
+// or generic driver entry, see Resolve
 func (r *Registry) IsSupported(brand model.DeviceBrand, deviceType model.DeviceType, deviceModel string) bool {
+	_, found := r.Resolve(brand, deviceType, deviceModel)
+	return found
+}
+
+// Suggestion reasons, from the closest match down
+const (
+	SuggestionSameBrandAndType = "SAME_BRAND_AND_TYPE"
+	SuggestionSameType         = "SAME_DEVICE_TYPE"
+	SuggestionSameBrand        = "SAME_BRAND"
+)
+
+// maxSuggestions bounds the alternatives offered for an unsupported device
+const maxSuggestions = 5
+
+// DriverSuggestion is an enabled driver close to an unsupported device. A
+// "*" model accepts any model of its brand and device type.
+type DriverSuggestion struct {
+	DriverKey
+	Reason string `json:"reason"`
+}
+
+// SuggestAlternatives returns the enabled drivers closest to a device no
+// driver supports: other models of the same brand and device type first,
+// then other brands of the device type, then other device types of the
+// brand. Wildcard model entries come before explicit models of the same
+// rank.
+func (r *Registry) SuggestAlternatives(brand model.DeviceBrand, deviceType model.DeviceType, deviceModel string) []DriverSuggestion {
 	r.mu.RLock()
-	defer r.mu.RUnlock()
+	suggestions := []DriverSuggestion{}
+	for key, entry := range r.drivers {
+		if !entry.enabled || key == (DriverKey{Brand: brand, DeviceType: deviceType, Model: deviceModel}) {
+			continue
+		}
 
-	// Check exact match
-	key := DriverKey{Brand: brand, DeviceType: deviceType, Model: deviceModel}
-	if _, exists := r.drivers[key]; exists {
-		return true
+		switch {
+		case key.Brand == brand && key.DeviceType == deviceType:
+			suggestions = append(suggestions, DriverSuggestion{DriverKey: key, Reason: SuggestionSameBrandAndType})
+		case key.DeviceType == deviceType:
+			suggestions = append(suggestions, DriverSuggestion{DriverKey: key, Reason: SuggestionSameType})
+		case key.Brand == brand:
+			suggestions = append(suggestions, DriverSuggestion{DriverKey: key, Reason: SuggestionSameBrand})
+		}
 	}
+	r.mu.RUnlock()
 
-	// Check wildcard match
-	key.Model = "*"
-	if _, exists := r.drivers[key]; exists {
-		return true
+	rank := map[string]int{
+		SuggestionSameBrandAndType: 0,
+		SuggestionSameType:         1,
+		SuggestionSameBrand:        2,
 	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Reason != b.Reason {
+			return rank[a.Reason] < rank[b.Reason]
+		}
+		if (a.Model == "*") != (b.Model == "*") {
+			return a.Model == "*"
+		}
+		if a.Brand != b.Brand {
+			return a.Brand < b.Brand
+		}
+		if a.DeviceType != b.DeviceType {
+			return a.DeviceType < b.DeviceType
+		}
+		return a.Model < b.Model
+	})
 
-	// Check generic driver
-	key.Brand = model.BrandGeneric
-	if _, exists := r.drivers[key]; exists {
-		return true
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
 	}
-
-	return false
+	return suggestions
 }
 
 // GetSupportedBrands returns all supported brands for a device type
@@ -132,8 +523,8 @@ func (r *Registry) GetSupportedBrands(deviceType model.DeviceType) []model.Devic
 	defer r.mu.RUnlock()
 
 	brandSet := make(map[model.DeviceBrand]bool)
-	for key := range r.drivers {
-		if key.DeviceType == deviceType {
+	for key, entry := range r.drivers {
+		if key.DeviceType == deviceType && entry.enabled {
 			brandSet[key.Brand] = true
 		}
 	}
@@ -144,3 +535,13 @@ func (r *Registry) GetSupportedBrands(deviceType model.DeviceType) []model.Devic
 	}
 	return brands
 }
+
+// info converts an entry into its public representation
+func (e *registryEntry) info(key DriverKey) DriverInfo {
+	return DriverInfo{
+		DriverKey:    key,
+		Enabled:      e.enabled,
+		RegisteredAt: e.registeredAt,
+		UpdatedAt:    e.updatedAt,
+	}
+}
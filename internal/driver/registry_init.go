@@ -5,6 +5,9 @@ import (
 	"go.uber.org/zap"
 
 	"device-service/internal/driver/epson"
+	"device-service/internal/driver/kodpos"
+	"device-service/internal/driver/scale"
+	"device-service/internal/driver/scanner"
 	"device-service/internal/model"
 	// ✅ pkg'den import
 )
@@ -16,6 +19,12 @@ func RegisterDefaultDrivers(registry *Registry, logger *zap.Logger) {
 
 	registerKODPOSDrivers(registry, logger) // ← Bu satırı ekle
 
+	// Register weighing scale drivers
+	registerScaleDrivers(registry, logger)
+
+	// Register barcode scanner drivers
+	registerScannerDrivers(registry, logger)
+
 	// Register other brand drivers here
 	// registerSTARDrivers(registry, logger)
 	// registerINGENICODrivers(registry, logger)
@@ -83,7 +92,7 @@ func registerKODPOSDrivers(registry *Registry, logger *zap.Logger) {
 		model.BrandKodpos,
 		model.DeviceTypePrinter,
 		"POS80 Series",
-		epson.NewEPSONDriver, // EPSON driver'ını kullanıyoruz (ESC/POS uyumlu)
+		kodpos.NewKodposDriver,
 	)
 
 	// Generic KODPOS printer (wildcard)
@@ -91,10 +100,40 @@ func registerKODPOSDrivers(registry *Registry, logger *zap.Logger) {
 		model.BrandKodpos,
 		model.DeviceTypePrinter,
 		"*",
-		epson.NewEPSONDriver,
+		kodpos.NewKodposDriver,
 	)
 
 	logger.Info("KODPOS printer drivers registered",
 		zap.Int("models", 2),
 	)
 }
+
+// registerScaleDrivers registers weighing scale drivers
+func registerScaleDrivers(registry *Registry, logger *zap.Logger) {
+	// Generic Toledo 8217 compatible scale (wildcard)
+	registry.Register(
+		model.BrandGeneric,
+		model.DeviceTypeScale,
+		"*",
+		scale.NewScaleDriver,
+	)
+
+	logger.Info("Scale drivers registered",
+		zap.Int("models", 1),
+	)
+}
+
+// registerScannerDrivers registers barcode scanner drivers
+func registerScannerDrivers(registry *Registry, logger *zap.Logger) {
+	// Generic serial mode scanner sending one barcode per line (wildcard)
+	registry.Register(
+		model.BrandGeneric,
+		model.DeviceTypeScanner,
+		"*",
+		scanner.NewScannerDriver,
+	)
+
+	logger.Info("Scanner drivers registered",
+		zap.Int("models", 1),
+	)
+}
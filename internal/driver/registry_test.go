@@ -0,0 +1,155 @@
+// internal/driver/registry_test.go
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"device-service/internal/model"
+	"device-service/pkg/driver"
+)
+
+func TestRegistryPassesConnectionPolicy(t *testing.T) {
+	defaultPolicy := driver.ConnectionPolicy{
+		ConnectTimeout:    5 * time.Second,
+		MaxRetries:        3,
+		InitialBackoff:    200 * time.Millisecond,
+		MaxBackoff:        2 * time.Second,
+		BackoffMultiplier: 1.5,
+	}
+	brandPolicy := defaultPolicy
+	brandPolicy.ConnectTimeout = 30 * time.Second
+	brandPolicy.MaxRetries = 0
+
+	errCreated := errors.New("driver created")
+	var got driver.ConnectionPolicy
+	factory := func(ctx context.Context, device *model.Device, connectionConfig interface{}, policy driver.ConnectionPolicy, logger *zap.Logger) (driver.DeviceDriver, error) {
+		got = policy
+		return nil, errCreated
+	}
+
+	registry := NewRegistry(zap.NewNop())
+	registry.SetConnectionPolicy(defaultPolicy)
+	registry.SetBrandConnectionPolicy(model.BrandStar, brandPolicy)
+	registry.Register(model.BrandEpson, model.DeviceTypePrinter, "*", factory)
+	registry.Register(model.BrandStar, model.DeviceTypePrinter, "*", factory)
+
+	tests := []struct {
+		brand model.DeviceBrand
+		want  driver.ConnectionPolicy
+	}{
+		{brand: model.BrandEpson, want: defaultPolicy},
+		{brand: model.BrandStar, want: brandPolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.brand), func(t *testing.T) {
+			device := &model.Device{
+				DeviceID:         "DEV-1",
+				Brand:            tt.brand,
+				DeviceType:       model.DeviceTypePrinter,
+				Model:            "TM-T88VI",
+				ConnectionConfig: model.JSONObject{},
+			}
+			if _, err := registry.CreateDriver(context.Background(), device, device.ConnectionConfig); !errors.Is(err, errCreated) {
+				t.Fatalf("CreateDriver() error = %v, want the factory's error", err)
+			}
+			if got != tt.want {
+				t.Fatalf("factory got policy %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryResolvesExactThenWildcardThenGeneric(t *testing.T) {
+	exact := &fakeFactory{name: "exact"}
+	wildcard := &fakeFactory{name: "wildcard"}
+	generic := &fakeFactory{name: "generic"}
+
+	registry := NewRegistry(zap.NewNop())
+	registry.Register(model.BrandEpson, model.DeviceTypePrinter, "TM-T88VI", exact.create)
+	registry.Register(model.BrandEpson, model.DeviceTypePrinter, "*", wildcard.create)
+	registry.Register(model.BrandGeneric, model.DeviceTypeScale, "*", generic.create)
+
+	tests := []struct {
+		name       string
+		brand      model.DeviceBrand
+		deviceType model.DeviceType
+		model      string
+		match      string // empty when unsupported
+		driver     string
+	}{
+		{name: "exact model", brand: model.BrandEpson, deviceType: model.DeviceTypePrinter, model: "TM-T88VI", match: MatchExact, driver: "exact"},
+		{name: "unregistered model uses the brand wildcard", brand: model.BrandEpson, deviceType: model.DeviceTypePrinter, model: "TM-T88VII", match: MatchWildcard, driver: "wildcard"},
+		{name: "other brand uses the generic driver", brand: model.BrandStar, deviceType: model.DeviceTypeScale, model: "S-100", match: MatchGeneric, driver: "generic"},
+		{name: "no entry", brand: model.BrandStar, deviceType: model.DeviceTypePrinter, model: "TSP100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := registry.IsSupported(tt.brand, tt.deviceType, tt.model); got != (tt.match != "") {
+				t.Fatalf("IsSupported() = %v, want %v", got, tt.match != "")
+			}
+
+			match, found := registry.Resolve(tt.brand, tt.deviceType, tt.model)
+			if found != (tt.match != "") || match.Match != tt.match {
+				t.Fatalf("Resolve() = %+v, %v; want match %q", match, found, tt.match)
+			}
+
+			device := &model.Device{
+				DeviceID:         "DEV-1",
+				Brand:            tt.brand,
+				DeviceType:       tt.deviceType,
+				Model:            tt.model,
+				ConnectionConfig: model.JSONObject{},
+			}
+			instance, err := registry.CreateDriver(context.Background(), device, device.ConnectionConfig)
+			if tt.match == "" {
+				if !errors.Is(err, ErrDriverNotFound) {
+					t.Fatalf("CreateDriver() error = %v, want %v", err, ErrDriverNotFound)
+				}
+				if code := driver.ErrorCodeOf(err); code != driver.ErrorCodeDriverNotFound {
+					t.Fatalf("CreateDriver() error code = %q, want %q", code, driver.ErrorCodeDriverNotFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateDriver() failed: %v", err)
+			}
+			if got := instance.(*fakeDriver).name; got != tt.driver {
+				t.Fatalf("CreateDriver() used the %s driver, want %s", got, tt.driver)
+			}
+		})
+	}
+}
+
+func TestRegistryDisabledExactEntryFallsBackToWildcard(t *testing.T) {
+	exact := &fakeFactory{name: "exact"}
+	wildcard := &fakeFactory{name: "wildcard"}
+
+	registry := NewRegistry(zap.NewNop())
+	registry.Register(model.BrandEpson, model.DeviceTypePrinter, "TM-T88VI", exact.create)
+	registry.Register(model.BrandEpson, model.DeviceTypePrinter, "*", wildcard.create)
+
+	key := DriverKey{Brand: model.BrandEpson, DeviceType: model.DeviceTypePrinter, Model: "TM-T88VI"}
+	if _, err := registry.SetEnabled(key, false); err != nil {
+		t.Fatalf("SetEnabled() failed: %v", err)
+	}
+
+	match, found := registry.Resolve(key.Brand, key.DeviceType, key.Model)
+	if !found || match.Match != MatchWildcard || match.Model != "*" {
+		t.Fatalf("Resolve() = %+v, %v; want the wildcard entry", match, found)
+	}
+
+	wildcardKey := DriverKey{Brand: model.BrandEpson, DeviceType: model.DeviceTypePrinter, Model: "*"}
+	if _, err := registry.SetEnabled(wildcardKey, false); err != nil {
+		t.Fatalf("SetEnabled() failed: %v", err)
+	}
+	if registry.IsSupported(key.Brand, key.DeviceType, key.Model) {
+		t.Fatal("IsSupported() = true with every matching entry disabled")
+	}
+}
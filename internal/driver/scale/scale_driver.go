@@ -0,0 +1,683 @@
+// internal/driver/scale/scale_driver.go
+package scale
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+
+	"device-service/internal/model"
+	"device-service/internal/protocol"
+	"device-service/internal/tracing"
+	"device-service/internal/utils"
+	"device-service/pkg/driver"
+)
+
+// Scale timing defaults
+const (
+	responseTimeout    = 2 * time.Second        // one request/answer exchange
+	defaultStableWait  = 3 * time.Second        // how long a weigh waits for the scale to settle
+	stablePollInterval = 200 * time.Millisecond // delay between weight requests while in motion
+	defaultDecimals    = 3                      // implied decimals when the scale sends none
+)
+
+// ScaleDriver implements driver.DeviceDriver and driver.ScaleDriver for
+// scales speaking the Toledo 8217 protocol over a serial line
+type ScaleDriver struct {
+	config        *ScaleConfig
+	policy        driver.ConnectionPolicy
+	protocol      protocol.DeviceProtocol
+	logger        *utils.DeviceLogger
+	eventHandler  driver.EventHandler
+	isConnected   bool
+	hasConnected  bool
+	reconnects    int64 // reconnects after the first connection
+	lastPing      time.Time
+	healthMetrics *driver.HealthMetrics
+	mutex         sync.RWMutex
+	exchangeMutex sync.Mutex // serializes request/answer exchanges
+	deviceInfo    *driver.DeviceInfo
+	wireLogger    *protocol.WireLogger
+}
+
+// ScaleConfig represents scale configuration
+type ScaleConfig struct {
+	DeviceID         string                 `json:"device_id"`
+	Model            string                 `json:"model"`
+	ConnectionType   model.ConnectionType   `json:"connection_type"`
+	ConnectionConfig map[string]interface{} `json:"connection_config"`
+	Unit             driver.WeightUnit      `json:"weight_unit"`
+	Decimals         int                    `json:"weight_decimals"`
+	StableWait       time.Duration          `json:"stable_wait"`
+}
+
+// NewScaleDriver creates a new scale driver
+func NewScaleDriver(ctx context.Context, device *model.Device, connectionConfig interface{}, policy driver.ConnectionPolicy, logger *zap.Logger) (driver.DeviceDriver, error) {
+	connConfig, err := parseConnectionConfig(connectionConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid connection configuration: %w", err)
+	}
+
+	scaleConfig := &ScaleConfig{
+		DeviceID:         device.DeviceID,
+		Model:            device.Model,
+		ConnectionType:   device.ConnectionType,
+		ConnectionConfig: withSerialDefaults(device.ConnectionType, protocol.WithCaptureID(device.ConnectionType, connConfig, device.DeviceID)),
+	}
+	applyScaleSettings(scaleConfig, connConfig)
+
+	deviceLogger := utils.NewDeviceLogger(logger, device)
+
+	scaleDriver := &ScaleDriver{
+		config: scaleConfig,
+		policy: policy,
+		logger: deviceLogger,
+		healthMetrics: &driver.HealthMetrics{
+			HealthScore: 0,
+		},
+		deviceInfo: &driver.DeviceInfo{
+			Brand:          device.Brand,
+			Model:          device.Model,
+			ConnectionType: device.ConnectionType,
+			Capabilities:   getScaleCapabilities(),
+			Manufacturer:   "Toledo 8217 compatible",
+		},
+		wireLogger: protocol.NewWireLogger(deviceLogger.Logger),
+	}
+
+	if enabled, ok := connConfig[driver.WireLoggingKey].(bool); ok {
+		scaleDriver.wireLogger.SetEnabled(enabled)
+	}
+
+	// Lazy drivers are created unconnected and connect on first use
+	if policy.Lazy() {
+		return scaleDriver, nil
+	}
+
+	// Connect eagerly like the other drivers; a failure leaves the driver
+	// usable so the connection can be retried later
+	if err := scaleDriver.Connect(ctx); err != nil {
+		deviceLogger.Warn("Scale driver created without active connection", zap.Error(err))
+	}
+
+	return scaleDriver, nil
+}
+
+// Connect establishes connection to the scale
+func (d *ScaleDriver) Connect(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "driver.connect",
+		attribute.String("device.code", d.config.DeviceID),
+		attribute.String("driver", "SCALE"),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.isConnected {
+		return nil
+	}
+
+	startTime := time.Now()
+
+	protocolInstance, err := protocol.CreateProtocol(
+		d.config.ConnectionType,
+		d.config.ConnectionConfig,
+		d.logger.Logger,
+	)
+	if err != nil {
+		d.updateHealthMetrics(false, time.Since(startTime), err)
+		return fmt.Errorf("failed to create %s protocol: %w", d.config.ConnectionType, err)
+	}
+	protocolInstance = protocol.WithTracing(protocolInstance)
+
+	if err := d.openWithRetry(ctx, protocolInstance); err != nil {
+		d.updateHealthMetrics(false, time.Since(startTime), err)
+		return err
+	}
+
+	d.protocol = protocol.WithWireLog(protocolInstance, d.wireLogger)
+	d.isConnected = true
+	d.lastPing = time.Now()
+	if d.hasConnected {
+		d.reconnects++
+		protocol.Metrics().IncReconnects(d.config.ConnectionType)
+	}
+	d.hasConnected = true
+
+	d.updateHealthMetrics(true, time.Since(startTime), nil)
+	d.notifyEvent("connected", nil)
+
+	d.logger.Info("Scale connected successfully",
+		zap.String("connection_type", string(d.config.ConnectionType)),
+		zap.String("model", d.config.Model),
+	)
+
+	return nil
+}
+
+// openWithRetry opens the protocol connection, retrying with backoff as
+// defined by the driver's connection policy
+func (d *ScaleDriver) openWithRetry(ctx context.Context, protocolInstance protocol.DeviceProtocol) error {
+	var lastErr error
+
+	attempts := d.policy.Attempts()
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			delay := d.policy.Backoff(attempt - 1)
+			d.logger.Warn("Retrying protocol connection",
+				zap.Int("attempt", attempt),
+				zap.Int("max_attempts", attempts),
+				zap.Duration("backoff", delay),
+				zap.Error(lastErr),
+			)
+
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("connection aborted after %d attempts: %w", attempt-1, ctx.Err())
+			case <-time.After(delay):
+			}
+		}
+
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if d.policy.ConnectTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, d.policy.ConnectTimeout)
+		}
+		err := protocolInstance.Open(attemptCtx)
+		cancel()
+
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+	}
+
+	return fmt.Errorf("failed to open %s connection after %d attempts: %w",
+		d.config.ConnectionType, attempts, lastErr)
+}
+
+// Disconnect closes connection to the scale
+func (d *ScaleDriver) Disconnect(ctx context.Context) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if !d.isConnected && d.protocol == nil {
+		return nil
+	}
+
+	var closeErr error
+	if d.protocol != nil {
+		if closeErr = d.protocol.Close(); closeErr != nil {
+			d.logger.Error("Failed to close protocol", zap.Error(closeErr))
+		}
+		d.protocol = nil
+	}
+
+	d.isConnected = false
+	d.notifyEvent("disconnected", "manual disconnect")
+
+	if closeErr != nil {
+		return fmt.Errorf("failed to close protocol: %w", closeErr)
+	}
+
+	d.logger.Info("Scale disconnected")
+	return nil
+}
+
+// IsConnected returns connection status
+func (d *ScaleDriver) IsConnected() bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.isConnected && d.protocol != nil && d.protocol.IsOpen()
+}
+
+// GetDeviceInfo returns device information
+func (d *ScaleDriver) GetDeviceInfo() (*driver.DeviceInfo, error) {
+	return d.deviceInfo, nil
+}
+
+// GetCapabilities returns device capabilities
+func (d *ScaleDriver) GetCapabilities() []model.Capability {
+	return getScaleCapabilities()
+}
+
+// GetStatus returns current device status. When connected it asks the scale
+// for its status byte so memory, calibration and capacity errors are reported.
+func (d *ScaleDriver) GetStatus() (*driver.DeviceStatus, error) {
+	d.mutex.RLock()
+	connected := d.isConnected
+	lastPing := driver.OptionalTime(d.lastPing)
+	d.mutex.RUnlock()
+
+	if !connected {
+		return &driver.DeviceStatus{
+			Status:       model.DeviceStatusOffline,
+			LastResponse: lastPing,
+		}, nil
+	}
+
+	status := &driver.DeviceStatus{
+		Status:       model.DeviceStatusOnline,
+		IsReady:      true,
+		LastResponse: lastPing,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), responseTimeout)
+	defer cancel()
+
+	scaleState, err := d.queryStatus(ctx)
+	if err != nil {
+		d.logger.Debug("Scale status unavailable", zap.Error(err))
+		status.Details = map[string]interface{}{"status_error": err.Error()}
+		return status, nil
+	}
+
+	status.Details = map[string]interface{}{"scale": scaleState}
+	if code, message := scaleState.errorCode(); code != "" {
+		status.HasError = true
+		status.IsReady = false
+		status.ErrorCode = string(code)
+		status.ErrorMessage = message
+	}
+
+	return status, nil
+}
+
+// ExecuteOperation executes a device operation
+func (d *ScaleDriver) ExecuteOperation(ctx context.Context, operation *model.DeviceOperation) (*driver.OperationResult, error) {
+	startTime := time.Now()
+
+	var result *driver.OperationResult
+	var err error
+
+	switch operation.OperationType {
+	case model.OperationTypeWeigh:
+		result, err = d.handleWeighOperation(ctx, operation)
+	case model.OperationTypeStatusCheck:
+		result, err = d.handleStatusOperation(ctx, operation)
+	default:
+		return nil, driver.NewOperationError(driver.ErrorCodeUnsupported,
+			fmt.Errorf("unsupported operation: %s", operation.OperationType))
+	}
+
+	duration := time.Since(startTime)
+
+	if err != nil {
+		d.updateHealthMetrics(false, duration, err)
+		return nil, err
+	}
+
+	d.updateHealthMetrics(true, duration, nil)
+	result.Data[driver.ResultKeyDurationMs] = duration.Milliseconds()
+	result.Duration = duration.String()
+	result.Timestamp = time.Now()
+
+	return result, nil
+}
+
+// ReadWeight reads the current weight, waiting up to the configured stable
+// wait for the scale to settle. A scale still in motion afterwards returns
+// an unstable reading.
+func (d *ScaleDriver) ReadWeight(ctx context.Context) (*driver.WeightReading, error) {
+	return d.readWeight(ctx, true)
+}
+
+// Ping tests device connectivity with a status request, since the generic
+// serial ping sends printer commands
+func (d *ScaleDriver) Ping(ctx context.Context) error {
+	if !d.IsConnected() {
+		return fmt.Errorf("device not connected")
+	}
+
+	pingCtx, cancel := d.policy.PingContext(ctx)
+	defer cancel()
+
+	startTime := time.Now()
+	if _, err := d.queryStatus(pingCtx); err != nil {
+		d.updateHealthMetrics(false, time.Since(startTime), err)
+		return fmt.Errorf("ping failed: %w", err)
+	}
+
+	d.mutex.Lock()
+	d.lastPing = time.Now()
+	d.mutex.Unlock()
+	d.updateHealthMetrics(true, time.Since(startTime), nil)
+	return nil
+}
+
+// GetHealthMetrics returns health metrics
+func (d *ScaleDriver) GetHealthMetrics() (*driver.HealthMetrics, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	metrics := *d.healthMetrics
+
+	if provider, ok := d.protocol.(protocol.StatsProvider); ok {
+		stats := provider.Stats()
+		metrics.Transport = &driver.TransportStats{
+			ConnectionType: d.config.ConnectionType,
+			BytesWritten:   stats.BytesWritten,
+			BytesRead:      stats.BytesRead,
+			WriteErrors:    stats.WriteErrors,
+			ReadErrors:     stats.ReadErrors,
+			Reconnects:     d.reconnects,
+			AverageLatency: stats.AverageLatency,
+			LastActivity:   stats.LastActivity,
+		}
+	}
+
+	return &metrics, nil
+}
+
+// Configure updates device configuration
+func (d *ScaleDriver) Configure(config interface{}) error {
+	configMap, err := parseConnectionConfig(config)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if connConfig, ok := configMap["connection_config"].(map[string]interface{}); ok {
+		d.config.ConnectionConfig = withSerialDefaults(d.config.ConnectionType, connConfig)
+		applyScaleSettings(d.config, connConfig)
+		if enabled, ok := connConfig[driver.WireLoggingKey].(bool); ok {
+			d.wireLogger.SetEnabled(enabled)
+		}
+	}
+
+	d.logger.Info("Scale reconfigured",
+		zap.String("weight_unit", string(d.config.Unit)),
+	)
+	return nil
+}
+
+// SetWireLogging turns hex dumps of protocol traffic on or off
+func (d *ScaleDriver) SetWireLogging(enabled bool) {
+	d.wireLogger.SetEnabled(enabled)
+}
+
+// WireLogging reports whether protocol traffic is being logged
+func (d *ScaleDriver) WireLogging() bool {
+	return d.wireLogger.Enabled()
+}
+
+// Reset reopens the connection; the Toledo protocol has no reset command
+func (d *ScaleDriver) Reset(ctx context.Context) error {
+	if err := d.Disconnect(ctx); err != nil {
+		return fmt.Errorf("failed to reset scale: %w", err)
+	}
+	if err := d.Connect(ctx); err != nil {
+		return fmt.Errorf("failed to reset scale: %w", err)
+	}
+
+	d.logger.Info("Scale reset")
+	return nil
+}
+
+// SetEventHandler sets event handler
+func (d *ScaleDriver) SetEventHandler(handler driver.EventHandler) {
+	d.eventHandler = handler
+}
+
+// Close cleans up resources
+func (d *ScaleDriver) Close() error {
+	return d.Disconnect(context.Background())
+}
+
+// handleWeighOperation handles weigh operations
+func (d *ScaleDriver) handleWeighOperation(ctx context.Context, operation *model.DeviceOperation) (*driver.OperationResult, error) {
+	waitStable := true
+	if value, ok := operation.OperationData["wait_stable"].(bool); ok {
+		waitStable = value
+	}
+
+	reading, err := d.readWeight(ctx, waitStable)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		driver.ResultKeyUnit:   reading.Unit,
+		driver.ResultKeyStable: reading.Stable,
+	}
+	if reading.Stable {
+		data[driver.ResultKeyWeight] = reading.Weight
+	}
+
+	d.logger.Info("Weight read",
+		zap.String("operation_id", operation.ID.String()),
+		zap.Float64("weight", reading.Weight),
+		zap.String("unit", string(reading.Unit)),
+		zap.Bool("stable", reading.Stable),
+	)
+
+	return &driver.OperationResult{
+		Success: true,
+		Data:    data,
+	}, nil
+}
+
+// handleStatusOperation handles status check operations
+func (d *ScaleDriver) handleStatusOperation(ctx context.Context, operation *model.DeviceOperation) (*driver.OperationResult, error) {
+	status, err := d.GetStatus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+
+	return &driver.OperationResult{
+		Success: true,
+		Data: map[string]interface{}{
+			driver.ResultKeyStatus: status,
+		},
+	}, nil
+}
+
+// readWeight requests the weight, polling while the scale is in motion when
+// waitStable is set
+func (d *ScaleDriver) readWeight(ctx context.Context, waitStable bool) (*driver.WeightReading, error) {
+	d.mutex.RLock()
+	unit, decimals, stableWait := d.config.Unit, d.config.Decimals, d.config.StableWait
+	d.mutex.RUnlock()
+
+	deadline := time.Now().Add(stableWait)
+	for {
+		response, err := d.exchange(ctx, TOLEDO_COMMANDS.REQUEST_WEIGHT)
+		if err != nil {
+			return nil, err
+		}
+
+		reading, err := parseToledoWeight(response, unit, decimals)
+		if err != nil {
+			return nil, err
+		}
+		reading.Timestamp = time.Now()
+
+		if reading.Stable || !waitStable || time.Now().After(deadline) {
+			return reading, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return reading, nil
+		case <-time.After(stablePollInterval):
+		}
+	}
+}
+
+// queryStatus requests and decodes the scale status byte
+func (d *ScaleDriver) queryStatus(ctx context.Context) (*scaleStatus, error) {
+	response, err := d.exchange(ctx, TOLEDO_COMMANDS.REQUEST_STATUS)
+	if err != nil {
+		return nil, err
+	}
+	if len(response) < 2 || response[0] != toledoStatusMarker {
+		return nil, fmt.Errorf("unexpected status response %q", response)
+	}
+	return parseScaleStatus(response[1]), nil
+}
+
+// exchange sends a command and reads the scale's framed answer
+func (d *ScaleDriver) exchange(ctx context.Context, command []byte) ([]byte, error) {
+	d.exchangeMutex.Lock()
+	defer d.exchangeMutex.Unlock()
+
+	d.mutex.RLock()
+	protocolInstance := d.protocol
+	d.mutex.RUnlock()
+
+	if protocolInstance == nil {
+		return nil, driver.NewOperationError(driver.ErrorCodeConnectionLost, fmt.Errorf("no protocol connection"))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, responseTimeout)
+	defer cancel()
+
+	if err := protocolInstance.Write(ctx, command); err != nil {
+		return nil, driver.NewOperationError(exchangeErrorCode(ctx), fmt.Errorf("failed to send command: %w", err))
+	}
+
+	var response []byte
+	for {
+		chunk, err := protocolInstance.Read(ctx, 64)
+		if err != nil {
+			return nil, driver.NewOperationError(exchangeErrorCode(ctx), fmt.Errorf("failed to read response: %w", err))
+		}
+		response = append(response, chunk...)
+
+		if payload, complete := toledoFrame(response); complete {
+			return payload, nil
+		}
+		if ctx.Err() != nil {
+			return nil, driver.NewOperationError(driver.ErrorCodeTimeout, fmt.Errorf("incomplete response %q", response))
+		}
+	}
+}
+
+// exchangeErrorCode tells a timed out exchange from a broken connection
+func exchangeErrorCode(ctx context.Context) driver.ErrorCode {
+	if ctx.Err() != nil {
+		return driver.ErrorCodeTimeout
+	}
+	return driver.ErrorCodeConnectionLost
+}
+
+// updateHealthMetrics updates device health metrics
+func (d *ScaleDriver) updateHealthMetrics(success bool, responseTime time.Duration, err error) {
+	d.healthMetrics.TotalOperations++
+	d.healthMetrics.ResponseTime = responseTime
+
+	now := time.Now()
+	if success {
+		d.healthMetrics.LastSuccessTime = &now
+	} else {
+		d.healthMetrics.ErrorCount++
+		d.healthMetrics.LastErrorTime = &now
+	}
+	d.healthMetrics.SuccessRate = float64(d.healthMetrics.TotalOperations-d.healthMetrics.ErrorCount) / float64(d.healthMetrics.TotalOperations)
+	d.healthMetrics.HealthScore = int(d.healthMetrics.SuccessRate * 100)
+}
+
+// notifyEvent notifies event handler
+func (d *ScaleDriver) notifyEvent(eventType string, data interface{}) {
+	if d.eventHandler != nil {
+		switch eventType {
+		case "connected":
+			d.eventHandler.OnDeviceConnected(d.config.DeviceID)
+		case "disconnected":
+			d.eventHandler.OnDeviceDisconnected(d.config.DeviceID, data.(string))
+		}
+	}
+}
+
+func parseConnectionConfig(config interface{}) (map[string]interface{}, error) {
+	var configMap map[string]interface{}
+
+	switch v := config.(type) {
+	case map[string]interface{}:
+		configMap = v
+	case model.JSONObject:
+		configMap = map[string]interface{}(v)
+	case *model.JSONObject:
+		if v != nil {
+			configMap = map[string]interface{}(*v)
+		} else {
+			return nil, fmt.Errorf("config is nil")
+		}
+	default:
+		return nil, fmt.Errorf("invalid config type: %T, expected map[string]interface{} or model.JSONObject", config)
+	}
+
+	if configMap == nil {
+		return nil, fmt.Errorf("config map is nil")
+	}
+
+	return configMap, nil
+}
+
+// withSerialDefaults returns a copy of a serial connection config using the
+// Toledo line settings (7 data bits, even parity) unless configured otherwise
+func withSerialDefaults(connectionType model.ConnectionType, config map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(config)+2)
+	for key, value := range config {
+		merged[key] = value
+	}
+	if connectionType != model.ConnectionTypeSerial {
+		return merged
+	}
+
+	if _, ok := merged["data_bits"]; !ok {
+		merged["data_bits"] = 7
+	}
+	if _, ok := merged["parity"]; !ok {
+		merged["parity"] = "even"
+	}
+	return merged
+}
+
+// applyScaleSettings applies the weight unit, implied decimals and stable
+// wait stored with the device configuration, ignoring unsupported values
+func applyScaleSettings(config *ScaleConfig, settings map[string]interface{}) {
+	config.Unit = driver.WeightUnitKilogram
+	config.Decimals = defaultDecimals
+	config.StableWait = defaultStableWait
+
+	if unit, ok := settings["weight_unit"].(string); ok {
+		switch driver.WeightUnit(strings.ToLower(unit)) {
+		case driver.WeightUnitKilogram, driver.WeightUnitPound:
+			config.Unit = driver.WeightUnit(strings.ToLower(unit))
+		}
+	}
+
+	switch decimals := settings["weight_decimals"].(type) {
+	case float64:
+		if decimals >= 0 && decimals <= 4 {
+			config.Decimals = int(decimals)
+		}
+	case int:
+		if decimals >= 0 && decimals <= 4 {
+			config.Decimals = decimals
+		}
+	}
+
+	switch waitMs := settings["stable_wait_ms"].(type) {
+	case float64:
+		if waitMs >= 0 {
+			config.StableWait = time.Duration(waitMs) * time.Millisecond
+		}
+	case int:
+		if waitMs >= 0 {
+			config.StableWait = time.Duration(waitMs) * time.Millisecond
+		}
+	}
+}
+
+// getScaleCapabilities returns the capabilities of a scale
+func getScaleCapabilities() []model.Capability {
+	return driver.DefaultCapabilities(model.DeviceTypeScale, model.BrandGeneric)
+}
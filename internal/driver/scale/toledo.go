@@ -0,0 +1,125 @@
+// internal/driver/scale/toledo.go
+package scale
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"device-service/pkg/driver"
+)
+
+// Toledo 8217 protocol bytes. The host sends a single command byte and the
+// scale answers with an STX ... CR framed message.
+const (
+	toledoSTX = 0x02
+	toledoCR  = 0x0D
+
+	toledoRequestWeight = 'W'
+	toledoRequestStatus = 'S'
+
+	// toledoStatusMarker starts a status message instead of weight digits
+	toledoStatusMarker = '?'
+)
+
+// TOLEDO_COMMANDS contains the Toledo 8217 host commands
+var TOLEDO_COMMANDS = struct {
+	REQUEST_WEIGHT []byte
+	REQUEST_STATUS []byte
+}{
+	REQUEST_WEIGHT: []byte{toledoRequestWeight},
+	REQUEST_STATUS: []byte{toledoRequestStatus},
+}
+
+// scaleStatus is the state reported by a Toledo 8217 status byte
+type scaleStatus struct {
+	InMotion           bool `json:"in_motion"`
+	AtZero             bool `json:"at_zero"`
+	RAMError           bool `json:"ram_error"`
+	EEPROMError        bool `json:"eeprom_error"`
+	UnderCapacity      bool `json:"under_capacity"`
+	OverCapacity       bool `json:"over_capacity"`
+	ROMError           bool `json:"rom_error"`
+	CalibrationFaulted bool `json:"calibration_faulted"`
+}
+
+// parseScaleStatus decodes a Toledo 8217 status byte
+func parseScaleStatus(status byte) *scaleStatus {
+	return &scaleStatus{
+		InMotion:           status&0x01 != 0,
+		AtZero:             status&0x02 != 0,
+		RAMError:           status&0x04 != 0,
+		EEPROMError:        status&0x08 != 0,
+		UnderCapacity:      status&0x10 != 0,
+		OverCapacity:       status&0x20 != 0,
+		ROMError:           status&0x40 != 0,
+		CalibrationFaulted: status&0x80 != 0,
+	}
+}
+
+// errorCode returns the most significant error, or empty when none
+func (ss *scaleStatus) errorCode() (driver.ErrorCode, string) {
+	switch {
+	case ss.RAMError || ss.EEPROMError || ss.ROMError:
+		return driver.ErrorCodeHardwareError, "scale reported a memory error"
+	case ss.CalibrationFaulted:
+		return driver.ErrorCodeHardwareError, "scale calibration is faulty"
+	case ss.OverCapacity:
+		return driver.ErrorCodeHardwareError, "scale is over capacity"
+	case ss.UnderCapacity:
+		return driver.ErrorCodeHardwareError, "scale is under capacity"
+	}
+	return "", ""
+}
+
+// toledoFrame extracts the payload of the first complete STX ... CR message
+// in data; complete is false until the closing CR has been received
+func toledoFrame(data []byte) (payload []byte, complete bool) {
+	// Some scales omit STX; start is then -1 and the message starts at the
+	// first byte
+	start := bytes.IndexByte(data, toledoSTX)
+
+	end := bytes.IndexByte(data[start+1:], toledoCR)
+	if end < 0 {
+		return nil, false
+	}
+	return data[start+1 : start+1+end], true
+}
+
+// parseToledoWeight decodes a weight request answer. A status payload means
+// the scale could not report a weight: it is either in motion, which is
+// returned as an unstable reading, or in an error state.
+func parseToledoWeight(payload []byte, unit driver.WeightUnit, decimals int) (*driver.WeightReading, error) {
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("empty weight response")
+	}
+
+	if payload[0] == toledoStatusMarker {
+		if len(payload) < 2 {
+			return nil, fmt.Errorf("truncated status response")
+		}
+		status := parseScaleStatus(payload[1])
+		if code, message := status.errorCode(); code != "" {
+			return nil, driver.NewOperationError(code, fmt.Errorf("%s", message))
+		}
+		if status.InMotion {
+			return &driver.WeightReading{Unit: unit, Stable: false}, nil
+		}
+		return nil, fmt.Errorf("scale sent status 0x%02X instead of a weight", payload[1])
+	}
+
+	digits := strings.TrimSpace(string(payload))
+	weight, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid weight %q: %w", digits, err)
+	}
+
+	// Without a decimal point the scale sends a fixed number of decimals
+	if !strings.Contains(digits, ".") && decimals > 0 {
+		weight /= math.Pow10(decimals)
+	}
+
+	return &driver.WeightReading{Weight: weight, Unit: unit, Stable: true}, nil
+}
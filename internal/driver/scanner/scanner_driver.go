@@ -0,0 +1,647 @@
+// internal/driver/scanner/scanner_driver.go
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+
+	"device-service/internal/model"
+	"device-service/internal/protocol"
+	"device-service/internal/tracing"
+	"device-service/internal/utils"
+	"device-service/pkg/driver"
+)
+
+// Scanner timing and framing defaults
+const (
+	defaultScanTimeout = 10 * time.Second // how long a SCAN operation waits for a barcode
+	maxScanTimeout     = 5 * time.Minute  // upper bound of a requested scan timeout
+	readPoll           = 500 * time.Millisecond
+	readChunk          = 256
+	maxBarcodeLength   = 4096 // unterminated input beyond this is dropped
+)
+
+// ScannerDriver implements driver.DeviceDriver and driver.ScanStreamer for
+// barcode scanners in serial (or keyboard-less TCP) mode, which send every
+// decoded barcode as a line terminated by CR, LF or CR LF
+type ScannerDriver struct {
+	config        *ScannerConfig
+	policy        driver.ConnectionPolicy
+	protocol      protocol.DeviceProtocol
+	logger        *utils.DeviceLogger
+	eventHandler  driver.EventHandler
+	isConnected   bool
+	hasConnected  bool
+	reconnects    int64 // reconnects after the first connection
+	lastPing      time.Time
+	lastScan      time.Time
+	healthMetrics *driver.HealthMetrics
+	mutex         sync.RWMutex
+	readMutex     sync.Mutex // serializes reads; guards pending
+	pending       []byte     // bytes of a barcode not yet terminated
+	deviceInfo    *driver.DeviceInfo
+	wireLogger    *protocol.WireLogger
+}
+
+// ScannerConfig represents scanner configuration
+type ScannerConfig struct {
+	DeviceID         string                 `json:"device_id"`
+	Model            string                 `json:"model"`
+	ConnectionType   model.ConnectionType   `json:"connection_type"`
+	ConnectionConfig map[string]interface{} `json:"connection_config"`
+}
+
+// NewScannerDriver creates a new scanner driver
+func NewScannerDriver(ctx context.Context, device *model.Device, connectionConfig interface{}, policy driver.ConnectionPolicy, logger *zap.Logger) (driver.DeviceDriver, error) {
+	connConfig, err := parseConnectionConfig(connectionConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid connection configuration: %w", err)
+	}
+
+	deviceLogger := utils.NewDeviceLogger(logger, device)
+
+	scannerDriver := &ScannerDriver{
+		config: &ScannerConfig{
+			DeviceID:         device.DeviceID,
+			Model:            device.Model,
+			ConnectionType:   device.ConnectionType,
+			ConnectionConfig: protocol.WithCaptureID(device.ConnectionType, connConfig, device.DeviceID),
+		},
+		policy: policy,
+		logger: deviceLogger,
+		healthMetrics: &driver.HealthMetrics{
+			HealthScore: 0,
+		},
+		deviceInfo: &driver.DeviceInfo{
+			Brand:          device.Brand,
+			Model:          device.Model,
+			ConnectionType: device.ConnectionType,
+			Capabilities:   getScannerCapabilities(),
+			Manufacturer:   "Serial barcode scanner",
+		},
+		wireLogger: protocol.NewWireLogger(deviceLogger.Logger),
+	}
+
+	if enabled, ok := connConfig[driver.WireLoggingKey].(bool); ok {
+		scannerDriver.wireLogger.SetEnabled(enabled)
+	}
+
+	// Lazy drivers are created unconnected and connect on first use
+	if policy.Lazy() {
+		return scannerDriver, nil
+	}
+
+	// Connect eagerly like the other drivers; a failure leaves the driver
+	// usable so the connection can be retried later
+	if err := scannerDriver.Connect(ctx); err != nil {
+		deviceLogger.Warn("Scanner driver created without active connection", zap.Error(err))
+	}
+
+	return scannerDriver, nil
+}
+
+// Connect establishes connection to the scanner
+func (d *ScannerDriver) Connect(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "driver.connect",
+		attribute.String("device.code", d.config.DeviceID),
+		attribute.String("driver", "SCANNER"),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.isConnected {
+		return nil
+	}
+
+	startTime := time.Now()
+
+	protocolInstance, err := protocol.CreateProtocol(
+		d.config.ConnectionType,
+		d.config.ConnectionConfig,
+		d.logger.Logger,
+	)
+	if err != nil {
+		d.updateHealthMetrics(false, time.Since(startTime), err)
+		return fmt.Errorf("failed to create %s protocol: %w", d.config.ConnectionType, err)
+	}
+	protocolInstance = protocol.WithTracing(protocolInstance)
+
+	if err := d.openWithRetry(ctx, protocolInstance); err != nil {
+		d.updateHealthMetrics(false, time.Since(startTime), err)
+		return err
+	}
+
+	d.protocol = protocol.WithWireLog(protocolInstance, d.wireLogger)
+	d.isConnected = true
+	d.lastPing = time.Now()
+	if d.hasConnected {
+		d.reconnects++
+		protocol.Metrics().IncReconnects(d.config.ConnectionType)
+	}
+	d.hasConnected = true
+
+	d.updateHealthMetrics(true, time.Since(startTime), nil)
+	d.notifyEvent("connected", nil)
+
+	d.logger.Info("Scanner connected successfully",
+		zap.String("connection_type", string(d.config.ConnectionType)),
+		zap.String("model", d.config.Model),
+	)
+
+	return nil
+}
+
+// openWithRetry opens the protocol connection, retrying with backoff as
+// defined by the driver's connection policy
+func (d *ScannerDriver) openWithRetry(ctx context.Context, protocolInstance protocol.DeviceProtocol) error {
+	var lastErr error
+
+	attempts := d.policy.Attempts()
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			delay := d.policy.Backoff(attempt - 1)
+			d.logger.Warn("Retrying protocol connection",
+				zap.Int("attempt", attempt),
+				zap.Int("max_attempts", attempts),
+				zap.Duration("backoff", delay),
+				zap.Error(lastErr),
+			)
+
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("connection aborted after %d attempts: %w", attempt-1, ctx.Err())
+			case <-time.After(delay):
+			}
+		}
+
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if d.policy.ConnectTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, d.policy.ConnectTimeout)
+		}
+		err := protocolInstance.Open(attemptCtx)
+		cancel()
+
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+	}
+
+	return fmt.Errorf("failed to open %s connection after %d attempts: %w",
+		d.config.ConnectionType, attempts, lastErr)
+}
+
+// Disconnect closes connection to the scanner
+func (d *ScannerDriver) Disconnect(ctx context.Context) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if !d.isConnected && d.protocol == nil {
+		return nil
+	}
+
+	var closeErr error
+	if d.protocol != nil {
+		if closeErr = d.protocol.Close(); closeErr != nil {
+			d.logger.Error("Failed to close protocol", zap.Error(closeErr))
+		}
+		d.protocol = nil
+	}
+
+	d.isConnected = false
+	d.notifyEvent("disconnected", "manual disconnect")
+
+	if closeErr != nil {
+		return fmt.Errorf("failed to close protocol: %w", closeErr)
+	}
+
+	d.logger.Info("Scanner disconnected")
+	return nil
+}
+
+// IsConnected returns connection status
+func (d *ScannerDriver) IsConnected() bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.isConnected && d.protocol != nil && d.protocol.IsOpen()
+}
+
+// GetDeviceInfo returns device information
+func (d *ScannerDriver) GetDeviceInfo() (*driver.DeviceInfo, error) {
+	return d.deviceInfo, nil
+}
+
+// GetCapabilities returns device capabilities
+func (d *ScannerDriver) GetCapabilities() []model.Capability {
+	return getScannerCapabilities()
+}
+
+// GetStatus returns current device status. A scanner in serial mode has no
+// status command, so an open connection is all that can be reported.
+func (d *ScannerDriver) GetStatus() (*driver.DeviceStatus, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	lastPing := driver.OptionalTime(d.lastPing)
+	if !d.isConnected {
+		return &driver.DeviceStatus{
+			Status:       model.DeviceStatusOffline,
+			LastResponse: lastPing,
+		}, nil
+	}
+
+	status := &driver.DeviceStatus{
+		Status:       model.DeviceStatusOnline,
+		IsReady:      true,
+		LastResponse: lastPing,
+	}
+	if !d.lastScan.IsZero() {
+		status.Details = map[string]interface{}{"last_scan": d.lastScan}
+	}
+	return status, nil
+}
+
+// ExecuteOperation executes a device operation
+func (d *ScannerDriver) ExecuteOperation(ctx context.Context, operation *model.DeviceOperation) (*driver.OperationResult, error) {
+	startTime := time.Now()
+
+	var result *driver.OperationResult
+	var err error
+
+	switch operation.OperationType {
+	case model.OperationTypeScan:
+		result, err = d.handleScanOperation(ctx, operation)
+	case model.OperationTypeStatusCheck:
+		result, err = d.handleStatusOperation(ctx, operation)
+	default:
+		return nil, driver.NewOperationError(driver.ErrorCodeUnsupported,
+			fmt.Errorf("unsupported operation: %s", operation.OperationType))
+	}
+
+	duration := time.Since(startTime)
+
+	if err != nil {
+		d.updateHealthMetrics(false, duration, err)
+		return nil, err
+	}
+
+	d.updateHealthMetrics(true, duration, nil)
+	result.Data[driver.ResultKeyDurationMs] = duration.Milliseconds()
+	result.Duration = duration.String()
+	result.Timestamp = time.Now()
+
+	return result, nil
+}
+
+// StreamScans reports every barcode the scanner reads until ctx is done
+func (d *ScannerDriver) StreamScans(ctx context.Context, onScan func(*driver.ScanResult)) error {
+	d.readMutex.Lock()
+	defer d.readMutex.Unlock()
+
+	// Barcodes read before the session started belong to nobody
+	d.pending = nil
+
+	for {
+		err := d.readLines(ctx, func(barcode string) bool {
+			onScan(d.scanResult(barcode))
+			return true
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Ping checks that the connection is still open. Scanners in serial mode
+// don't answer commands, and the generic serial ping sends printer commands.
+func (d *ScannerDriver) Ping(ctx context.Context) error {
+	if !d.IsConnected() {
+		return fmt.Errorf("device not connected")
+	}
+
+	d.mutex.Lock()
+	d.lastPing = time.Now()
+	d.mutex.Unlock()
+	return nil
+}
+
+// GetHealthMetrics returns health metrics
+func (d *ScannerDriver) GetHealthMetrics() (*driver.HealthMetrics, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	metrics := *d.healthMetrics
+
+	if provider, ok := d.protocol.(protocol.StatsProvider); ok {
+		stats := provider.Stats()
+		metrics.Transport = &driver.TransportStats{
+			ConnectionType: d.config.ConnectionType,
+			BytesWritten:   stats.BytesWritten,
+			BytesRead:      stats.BytesRead,
+			WriteErrors:    stats.WriteErrors,
+			ReadErrors:     stats.ReadErrors,
+			Reconnects:     d.reconnects,
+			AverageLatency: stats.AverageLatency,
+			LastActivity:   stats.LastActivity,
+		}
+	}
+
+	return &metrics, nil
+}
+
+// Configure updates device configuration
+func (d *ScannerDriver) Configure(config interface{}) error {
+	configMap, err := parseConnectionConfig(config)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if connConfig, ok := configMap["connection_config"].(map[string]interface{}); ok {
+		d.config.ConnectionConfig = connConfig
+		if enabled, ok := connConfig[driver.WireLoggingKey].(bool); ok {
+			d.wireLogger.SetEnabled(enabled)
+		}
+	}
+
+	d.logger.Info("Scanner reconfigured")
+	return nil
+}
+
+// SetWireLogging turns hex dumps of protocol traffic on or off
+func (d *ScannerDriver) SetWireLogging(enabled bool) {
+	d.wireLogger.SetEnabled(enabled)
+}
+
+// WireLogging reports whether protocol traffic is being logged
+func (d *ScannerDriver) WireLogging() bool {
+	return d.wireLogger.Enabled()
+}
+
+// Reset reopens the connection; serial mode scanners have no reset command
+func (d *ScannerDriver) Reset(ctx context.Context) error {
+	if err := d.Disconnect(ctx); err != nil {
+		return fmt.Errorf("failed to reset scanner: %w", err)
+	}
+	if err := d.Connect(ctx); err != nil {
+		return fmt.Errorf("failed to reset scanner: %w", err)
+	}
+
+	d.logger.Info("Scanner reset")
+	return nil
+}
+
+// SetEventHandler sets event handler
+func (d *ScannerDriver) SetEventHandler(handler driver.EventHandler) {
+	d.eventHandler = handler
+}
+
+// Close cleans up resources
+func (d *ScannerDriver) Close() error {
+	return d.Disconnect(context.Background())
+}
+
+// handleScanOperation waits for the next barcode, up to the requested
+// timeout in seconds
+func (d *ScannerDriver) handleScanOperation(ctx context.Context, operation *model.DeviceOperation) (*driver.OperationResult, error) {
+	timeout := scanTimeout(operation.OperationData["timeout"])
+	scanCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	d.readMutex.Lock()
+	defer d.readMutex.Unlock()
+
+	var result *driver.ScanResult
+	for result == nil {
+		err := d.readLines(scanCtx, func(barcode string) bool {
+			result = d.scanResult(barcode)
+			return false
+		})
+		if result != nil {
+			break
+		}
+		if scanCtx.Err() != nil {
+			return nil, driver.NewOperationError(driver.ErrorCodeTimeout,
+				fmt.Errorf("no barcode scanned within %s", timeout))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	d.logger.Info("Barcode scanned",
+		zap.String("operation_id", operation.ID.String()),
+		zap.Int("length", len(result.Data)),
+	)
+
+	return &driver.OperationResult{
+		Success: true,
+		Data: map[string]interface{}{
+			driver.ResultKeyScanData: result.Data,
+			driver.ResultKeyScanType: result.Type,
+		},
+	}, nil
+}
+
+// handleStatusOperation handles status check operations
+func (d *ScannerDriver) handleStatusOperation(ctx context.Context, operation *model.DeviceOperation) (*driver.OperationResult, error) {
+	status, err := d.GetStatus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+
+	return &driver.OperationResult{
+		Success: true,
+		Data: map[string]interface{}{
+			driver.ResultKeyStatus: status,
+		},
+	}, nil
+}
+
+// readLines reads one chunk from the scanner and hands every complete
+// barcode to onBarcode, which returns false to keep the rest buffered for
+// the next read. Callers must hold readMutex. A read that times out with
+// nothing received is not an error.
+func (d *ScannerDriver) readLines(ctx context.Context, onBarcode func(string) bool) error {
+	d.mutex.RLock()
+	protocolInstance := d.protocol
+	d.mutex.RUnlock()
+
+	if protocolInstance == nil {
+		return driver.NewOperationError(driver.ErrorCodeConnectionLost, fmt.Errorf("no protocol connection"))
+	}
+
+	// Buffered barcodes are handed out before reading more
+	if d.takeLines(onBarcode) {
+		return nil
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, readPoll)
+	defer cancel()
+
+	chunk, err := protocolInstance.Read(readCtx, readChunk)
+	if err != nil {
+		if isReadTimeout(readCtx, err) {
+			return nil
+		}
+		return driver.NewOperationError(driver.ErrorCodeConnectionLost, fmt.Errorf("failed to read from scanner: %w", err))
+	}
+
+	d.pending = append(d.pending, chunk...)
+	if !d.takeLines(onBarcode) && len(d.pending) > maxBarcodeLength {
+		d.logger.Warn("Dropping unterminated scanner input", zap.Int("bytes", len(d.pending)))
+		d.pending = nil
+	}
+	return nil
+}
+
+// takeLines hands the terminated barcodes in pending to onBarcode until it
+// returns false, and reports whether it took one
+func (d *ScannerDriver) takeLines(onBarcode func(string) bool) bool {
+	took := false
+	for {
+		line, rest, ok := nextLine(d.pending)
+		if !ok {
+			return took
+		}
+		d.pending = rest
+		took = true
+		d.recordScan()
+		if !onBarcode(line) {
+			return took
+		}
+	}
+}
+
+// nextLine returns the first complete, non-empty line of data and the bytes
+// after it. Empty lines, e.g. the LF of a CR LF terminator, are skipped.
+func nextLine(data []byte) (line string, rest []byte, ok bool) {
+	for {
+		end := bytes.IndexAny(data, "\r\n")
+		if end < 0 {
+			return "", data, false
+		}
+		barcode := bytes.TrimSpace(data[:end])
+		data = data[end+1:]
+		if len(barcode) > 0 {
+			return string(barcode), data, true
+		}
+	}
+}
+
+// scanResult wraps a barcode read by the scanner
+func (d *ScannerDriver) scanResult(barcode string) *driver.ScanResult {
+	return &driver.ScanResult{
+		Success:   true,
+		Data:      barcode,
+		Type:      driver.ScanTypeBarcode,
+		Quality:   100,
+		Timestamp: time.Now(),
+	}
+}
+
+// recordScan notes when the scanner last read a barcode
+func (d *ScannerDriver) recordScan() {
+	d.mutex.Lock()
+	d.lastScan = time.Now()
+	d.lastPing = d.lastScan
+	d.mutex.Unlock()
+}
+
+// isReadTimeout tells a poll that received nothing from a failed read
+func isReadTimeout(readCtx context.Context, err error) bool {
+	if readCtx.Err() != nil {
+		return true
+	}
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
+
+// scanTimeout reads the timeout in seconds of a SCAN operation, falling back
+// to the default when it is missing or out of range
+func scanTimeout(value interface{}) time.Duration {
+	var seconds float64
+	switch v := value.(type) {
+	case float64:
+		seconds = v
+	case int:
+		seconds = float64(v)
+	}
+
+	timeout := time.Duration(seconds * float64(time.Second))
+	if timeout <= 0 || timeout > maxScanTimeout {
+		return defaultScanTimeout
+	}
+	return timeout
+}
+
+// updateHealthMetrics updates device health metrics
+func (d *ScannerDriver) updateHealthMetrics(success bool, responseTime time.Duration, err error) {
+	d.healthMetrics.TotalOperations++
+	d.healthMetrics.ResponseTime = responseTime
+
+	now := time.Now()
+	if success {
+		d.healthMetrics.LastSuccessTime = &now
+	} else {
+		d.healthMetrics.ErrorCount++
+		d.healthMetrics.LastErrorTime = &now
+	}
+	d.healthMetrics.SuccessRate = float64(d.healthMetrics.TotalOperations-d.healthMetrics.ErrorCount) / float64(d.healthMetrics.TotalOperations)
+	d.healthMetrics.HealthScore = int(d.healthMetrics.SuccessRate * 100)
+}
+
+// notifyEvent notifies event handler
+func (d *ScannerDriver) notifyEvent(eventType string, data interface{}) {
+	if d.eventHandler != nil {
+		switch eventType {
+		case "connected":
+			d.eventHandler.OnDeviceConnected(d.config.DeviceID)
+		case "disconnected":
+			d.eventHandler.OnDeviceDisconnected(d.config.DeviceID, data.(string))
+		}
+	}
+}
+
+func parseConnectionConfig(config interface{}) (map[string]interface{}, error) {
+	var configMap map[string]interface{}
+
+	switch v := config.(type) {
+	case map[string]interface{}:
+		configMap = v
+	case model.JSONObject:
+		configMap = map[string]interface{}(v)
+	case *model.JSONObject:
+		if v != nil {
+			configMap = map[string]interface{}(*v)
+		} else {
+			return nil, fmt.Errorf("config is nil")
+		}
+	default:
+		return nil, fmt.Errorf("invalid config type: %T, expected map[string]interface{} or model.JSONObject", config)
+	}
+
+	if configMap == nil {
+		return nil, fmt.Errorf("config map is nil")
+	}
+
+	return configMap, nil
+}
+
+// getScannerCapabilities returns the capabilities of a scanner
+func getScannerCapabilities() []model.Capability {
+	return driver.DefaultCapabilities(model.DeviceTypeScanner, model.BrandGeneric)
+}
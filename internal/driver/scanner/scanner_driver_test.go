@@ -0,0 +1,194 @@
+// internal/driver/scanner/scanner_driver_test.go
+package scanner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"device-service/internal/model"
+	"device-service/internal/protocol"
+	"device-service/internal/utils"
+	"device-service/pkg/driver"
+)
+
+// fakeProtocol hands out queued chunks, then fails with err or waits for
+// the read context like an idle serial line
+type fakeProtocol struct {
+	mu     sync.Mutex
+	chunks [][]byte
+	err    error
+}
+
+func (p *fakeProtocol) Open(ctx context.Context) error      { return nil }
+func (p *fakeProtocol) Close() error                        { return nil }
+func (p *fakeProtocol) IsOpen() bool                        { return true }
+func (p *fakeProtocol) Ping(ctx context.Context) error      { return nil }
+func (p *fakeProtocol) Write(context.Context, []byte) error { return nil }
+func (p *fakeProtocol) GetProtocolType() model.ConnectionType {
+	return model.ConnectionTypeSerial
+}
+
+func (p *fakeProtocol) Read(ctx context.Context, maxBytes int) ([]byte, error) {
+	p.mu.Lock()
+	if len(p.chunks) > 0 {
+		chunk := p.chunks[0]
+		p.chunks = p.chunks[1:]
+		p.mu.Unlock()
+		return chunk, nil
+	}
+	err := p.err
+	p.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+var _ protocol.DeviceProtocol = (*fakeProtocol)(nil)
+
+func newTestDriver(p *fakeProtocol) *ScannerDriver {
+	device := &model.Device{DeviceID: "SCN-1", DeviceType: model.DeviceTypeScanner, Brand: model.BrandGeneric}
+	return &ScannerDriver{
+		config:        &ScannerConfig{DeviceID: device.DeviceID, ConnectionType: model.ConnectionTypeSerial},
+		protocol:      p,
+		isConnected:   true,
+		logger:        utils.NewDeviceLogger(zap.NewNop(), device),
+		healthMetrics: &driver.HealthMetrics{},
+	}
+}
+
+func chunks(parts ...string) [][]byte {
+	out := make([][]byte, len(parts))
+	for i, part := range parts {
+		out[i] = []byte(part)
+	}
+	return out
+}
+
+func TestNextLine(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		line string
+		rest string
+		ok   bool
+	}{
+		{name: "CR LF", data: "4006381333931\r\n", line: "4006381333931", rest: "\n", ok: true},
+		{name: "LF", data: "ABC\nDEF", line: "ABC", rest: "DEF", ok: true},
+		{name: "leading terminators skipped", data: "\n\r\nABC\r", line: "ABC", rest: "", ok: true},
+		{name: "unterminated", data: "ABC", rest: "ABC"},
+		{name: "only terminators", data: "\r\n", rest: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, rest, ok := nextLine([]byte(tt.data))
+			if line != tt.line || string(rest) != tt.rest || ok != tt.ok {
+				t.Fatalf("nextLine(%q) = %q, %q, %v; want %q, %q, %v",
+					tt.data, line, rest, ok, tt.line, tt.rest, tt.ok)
+			}
+		})
+	}
+}
+
+func TestStreamScansReportsBarcodesUntilCancelled(t *testing.T) {
+	d := newTestDriver(&fakeProtocol{chunks: chunks("12", "34\r\n56\r", "\n", "789\n")})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var scanned []string
+	err := d.StreamScans(ctx, func(result *driver.ScanResult) {
+		scanned = append(scanned, result.Data)
+		if len(scanned) == 3 {
+			cancel()
+		}
+	})
+
+	if err != nil {
+		t.Fatalf("StreamScans returned %v after cancel, want nil", err)
+	}
+	if want := []string{"1234", "56", "789"}; !reflect.DeepEqual(scanned, want) {
+		t.Fatalf("scanned %v, want %v", scanned, want)
+	}
+}
+
+func TestStreamScansReturnsReadFailure(t *testing.T) {
+	readErr := errors.New("device unplugged")
+	d := newTestDriver(&fakeProtocol{chunks: chunks("1234\r\n"), err: readErr})
+
+	var scans int
+	err := d.StreamScans(context.Background(), func(*driver.ScanResult) { scans++ })
+
+	if !errors.Is(err, readErr) {
+		t.Fatalf("StreamScans returned %v, want %v", err, readErr)
+	}
+	if code := driver.ErrorCodeOf(err); code != driver.ErrorCodeConnectionLost {
+		t.Fatalf("error code %q, want %q", code, driver.ErrorCodeConnectionLost)
+	}
+	if scans != 1 {
+		t.Fatalf("got %d scans before the failure, want 1", scans)
+	}
+}
+
+func TestScanOperationKeepsLaterBarcodes(t *testing.T) {
+	d := newTestDriver(&fakeProtocol{chunks: chunks("111\r\n222\r\n")})
+
+	for _, want := range []string{"111", "222"} {
+		result, err := d.ExecuteOperation(context.Background(), &model.DeviceOperation{
+			ID:            uuid.New(),
+			OperationType: model.OperationTypeScan,
+			OperationData: model.JSONObject{"timeout": 1},
+		})
+		if err != nil {
+			t.Fatalf("scan failed: %v", err)
+		}
+		if got := result.Data[driver.ResultKeyScanData]; got != want {
+			t.Fatalf("scanned %v, want %s", got, want)
+		}
+	}
+}
+
+func TestScanOperationTimesOut(t *testing.T) {
+	d := newTestDriver(&fakeProtocol{chunks: chunks("no terminator")})
+
+	start := time.Now()
+	_, err := d.ExecuteOperation(context.Background(), &model.DeviceOperation{
+		ID:            uuid.New(),
+		OperationType: model.OperationTypeScan,
+		OperationData: model.JSONObject{"timeout": 0.2},
+	})
+
+	if code := driver.ErrorCodeOf(err); code != driver.ErrorCodeTimeout {
+		t.Fatalf("error %v with code %q, want %q", err, code, driver.ErrorCodeTimeout)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("scan waited %s, want about 200ms", elapsed)
+	}
+}
+
+func TestScanTimeout(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  time.Duration
+	}{
+		{value: nil, want: defaultScanTimeout},
+		{value: 3, want: 3 * time.Second},
+		{value: 1.5, want: 1500 * time.Millisecond},
+		{value: -1, want: defaultScanTimeout},
+		{value: 3600.0, want: defaultScanTimeout},
+	}
+
+	for _, tt := range tests {
+		if got := scanTimeout(tt.value); got != tt.want {
+			t.Errorf("scanTimeout(%v) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
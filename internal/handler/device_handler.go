@@ -2,13 +2,16 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"device-service/internal/model"
 	"device-service/internal/service"
 	"device-service/internal/utils"
-	_ "time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -31,13 +34,18 @@ func NewDeviceHandler(deviceService *service.DeviceService, logger *zap.Logger)
 
 // RegisterDevice registers a new device
 // @Summary Register a new device
-// @Description Register a new device in the system with configuration
+// @Description Register a new device in the system with configuration. With upsert=true an already registered device ID is updated instead, and the response tells whether the device was created.
 // @Tags Devices
 // @Accept json
 // @Produce json
 // @Param request body service.RegisterDeviceRequest true "Device registration request"
+// @Param upsert query bool false "Update the device if its device ID is already registered"
 // @Success 201 {object} utils.APIResponse{data=model.Device} "Device registered successfully"
+// @Success 200 {object} utils.APIResponse{data=service.DeviceUpsertResult} "Existing device updated (upsert)"
 // @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 403 {object} utils.APIResponse "Tenant does not match the caller's tenant"
+// @Failure 409 {object} utils.APIResponse "Upsert would change the device type or brand"
+// @Failure 422 {object} utils.APIResponse{data=service.UnsupportedDeviceError} "Unsupported device, with the closest supported drivers"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /devices [post]
 func (h *DeviceHandler) RegisterDevice(c *gin.Context) {
@@ -53,10 +61,23 @@ func (h *DeviceHandler) RegisterDevice(c *gin.Context) {
 		req.UserID = userID.(string)
 	}
 
+	if c.Query("upsert") == "true" {
+		result, err := h.deviceService.UpsertDevice(c.Request.Context(), &req)
+		if err != nil {
+			h.registrationError(c, err)
+			return
+		}
+		if result.Created {
+			utils.SuccessResponse(c, http.StatusCreated, "Device registered successfully", result)
+			return
+		}
+		utils.SuccessResponse(c, http.StatusOK, "Device updated successfully", result)
+		return
+	}
+
 	device, err := h.deviceService.RegisterDevice(c.Request.Context(), &req)
 	if err != nil {
-		h.logger.Error("Failed to register device", zap.Error(err))
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to register device", err)
+		h.registrationError(c, err)
 		return
 	}
 
@@ -64,6 +85,175 @@ func (h *DeviceHandler) RegisterDevice(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusCreated, "Device registered successfully", device)
 }
 
+// registrationError responds to a failed device registration or upsert
+func (h *DeviceHandler) registrationError(c *gin.Context, err error) {
+	var unsupported *service.UnsupportedDeviceError
+	switch {
+	case errors.As(err, &unsupported):
+		utils.ErrorResponseWithData(c, http.StatusUnprocessableEntity, "UNSUPPORTED_DEVICE", "Unsupported device", err, unsupported)
+	case errors.Is(err, service.ErrTenantMismatch):
+		utils.ErrorResponse(c, http.StatusForbidden, "Tenant does not match the caller's tenant", err)
+	case errors.Is(err, service.ErrDeviceIdentityChanged):
+		utils.ErrorResponse(c, http.StatusConflict, "Device type or brand cannot change", err)
+	default:
+		h.logger.Error("Failed to register device", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to register device", err)
+	}
+}
+
+// CloneDevice registers a copy of a device
+// @Summary Clone a device
+// @Description Register a new device with the type, model, connection config, capabilities and settings of an existing one, e.g. for a row of identical POS stations. The given connection config is merged over the source's. Secret settings are not copied and are listed in omitted_secrets. The clone is validated like a new registration and starts OFFLINE.
+// @Tags Devices
+// @Accept json
+// @Produce json
+// @Param device_id path string true "Source device ID"
+// @Param request body service.CloneDeviceRequest true "New device ID and the settings that differ"
+// @Success 201 {object} utils.APIResponse{data=service.DeviceCloneResult} "Device cloned successfully"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 404 {object} utils.APIResponse "Source device not found"
+// @Failure 409 {object} utils.APIResponse "Device ID already registered"
+// @Failure 422 {object} utils.APIResponse{data=service.UnsupportedDeviceError} "Unsupported device, with the closest supported drivers"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /devices/{device_id}/clone [post]
+func (h *DeviceHandler) CloneDevice(c *gin.Context) {
+	deviceID := c.Param("device_id")
+
+	var req service.CloneDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.DeviceID == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "device_id of the new device is required", nil)
+		return
+	}
+	req.UserID = getUserID(c)
+
+	result, err := h.deviceService.CloneDevice(c.Request.Context(), deviceID, &req)
+	if err != nil {
+		var unsupported *service.UnsupportedDeviceError
+		switch {
+		case errors.Is(err, service.ErrCloneSourceNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "Device not found", err)
+		case errors.Is(err, service.ErrDeviceExists):
+			utils.ErrorResponse(c, http.StatusConflict, "Device ID already registered", err)
+		case errors.As(err, &unsupported):
+			utils.ErrorResponseWithData(c, http.StatusUnprocessableEntity, "UNSUPPORTED_DEVICE", "Unsupported device", err, unsupported)
+		case errors.Is(err, service.ErrInvalidClone):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid device configuration", err)
+		default:
+			h.logger.Error("Failed to clone device", zap.Error(err), zap.String("device_id", deviceID))
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to clone device", err)
+		}
+		return
+	}
+
+	h.logger.Info("Device cloned successfully",
+		zap.String("device_id", result.Device.DeviceID),
+		zap.String("source_device_id", deviceID),
+	)
+	utils.SuccessResponse(c, http.StatusCreated, "Device cloned successfully", result)
+}
+
+// ExportDevices exports device definitions
+// @Summary Export devices
+// @Description Export portable device definitions (without secrets), optionally for a single branch
+// @Tags Devices
+// @Produce json
+// @Param branch_id query string false "Branch ID"
+// @Success 200 {object} utils.APIResponse{data=service.DeviceExport} "Devices exported successfully"
+// @Failure 400 {object} utils.APIResponse "Invalid branch ID"
+// @Failure 500 {object} utils.APIResponse "Failed to export devices"
+// @Router /devices/export [get]
+func (h *DeviceHandler) ExportDevices(c *gin.Context) {
+	var branchID *uuid.UUID
+	if branchIDStr := c.Query("branch_id"); branchIDStr != "" {
+		id, err := uuid.Parse(branchIDStr)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid branch ID", err)
+			return
+		}
+		branchID = &id
+	}
+
+	export, err := h.deviceService.ExportDevices(c.Request.Context(), branchID)
+	if err != nil {
+		h.logger.Error("Failed to export devices", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to export devices", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Devices exported successfully", export)
+}
+
+// RefreshDeviceStatuses checks the status of online devices right away
+// @Summary Refresh device statuses
+// @Description Run an immediate health check on all online devices, optionally for a single branch, and return each device's current status. Checks run concurrently with a per-device timeout.
+// @Tags Devices
+// @Produce json
+// @Param branch_id query string false "Branch ID"
+// @Success 200 {object} utils.APIResponse{data=service.StatusRefreshResult} "Device statuses refreshed"
+// @Failure 400 {object} utils.APIResponse "Invalid branch ID"
+// @Failure 500 {object} utils.APIResponse "Failed to refresh device statuses"
+// @Router /devices/status/refresh [post]
+func (h *DeviceHandler) RefreshDeviceStatuses(c *gin.Context) {
+	var branchID *uuid.UUID
+	if branchIDStr := c.Query("branch_id"); branchIDStr != "" {
+		id, err := uuid.Parse(branchIDStr)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid branch ID", err)
+			return
+		}
+		branchID = &id
+	}
+
+	result, err := h.deviceService.RefreshStatuses(c.Request.Context(), branchID)
+	if err != nil {
+		h.logger.Error("Failed to refresh device statuses", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to refresh device statuses", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Device statuses refreshed", result)
+}
+
+// ImportDevices imports device definitions
+// @Summary Import devices
+// @Description Register exported device definitions, remapping branch IDs and reporting conflicts
+// @Tags Devices
+// @Accept json
+// @Produce json
+// @Param request body service.DeviceImportRequest true "Device import request"
+// @Success 200 {object} utils.APIResponse{data=service.DeviceImportResult} "Devices imported"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 500 {object} utils.APIResponse "Failed to import devices"
+// @Router /devices/import [post]
+func (h *DeviceHandler) ImportDevices(c *gin.Context) {
+	var req service.DeviceImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if len(req.Devices) == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "No devices to import", nil)
+		return
+	}
+
+	if userID, exists := c.Get("user_id"); exists {
+		req.UserID = userID.(string)
+	}
+
+	result, err := h.deviceService.ImportDevices(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Failed to import devices", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to import devices", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Devices imported", result)
+}
+
 // ListDevices lists devices with filtering and pagination
 // @Summary List devices
 // @Description Get list of devices with filtering and pagination support
@@ -73,10 +263,12 @@ func (h *DeviceHandler) RegisterDevice(c *gin.Context) {
 // @Param page query int false "Page number" default(1)
 // @Param per_page query int false "Items per page" default(20)
 // @Param branch_id query string false "Filter by branch ID"
-// @Param device_type query string false "Filter by device type" Enums(POS, PRINTER, SCANNER, CASH_REGISTER, CASH_DRAWER, DISPLAY)
+// @Param tenant_id query string false "Filter by tenant ID (platform-wide callers)"
+// @Param device_type query string false "Filter by device type" Enums(POS, PRINTER, SCANNER, CASH_REGISTER, CASH_DRAWER, DISPLAY, SCALE)
 // @Param brand query string false "Filter by brand" Enums(EPSON, STAR, INGENICO, PAX, CITIZEN, BIXOLON, VERIFONE, GENERIC)
 // @Param status query string false "Filter by status" Enums(ONLINE, OFFLINE, ERROR, MAINTENANCE, CONNECTING)
 // @Param location query string false "Filter by location"
+// @Param search query string false "Search device ID, name or model"
 // @Param sort_by query string false "Sort by field" default(created_at)
 // @Param sort_order query string false "Sort order" Enums(asc, desc) default(desc)
 // @Success 200 {object} utils.APIResponse{data=object{devices=[]model.Device,pagination=service.PaginationResult}} "Devices retrieved successfully"
@@ -109,6 +301,11 @@ func (h *DeviceHandler) ListDevices(c *gin.Context) {
 			filter.BranchID = &device_id
 		}
 	}
+	if tenantIDStr := c.Query("tenant_id"); tenantIDStr != "" {
+		if tenantID, err := uuid.Parse(tenantIDStr); err == nil {
+			filter.TenantID = &tenantID
+		}
+	}
 	if deviceType := c.Query("device_type"); deviceType != "" {
 		dt := model.DeviceType(deviceType)
 		filter.DeviceType = &dt
@@ -124,6 +321,9 @@ func (h *DeviceHandler) ListDevices(c *gin.Context) {
 	if location := c.Query("location"); location != "" {
 		filter.Location = &location
 	}
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		filter.SearchTerm = &search
+	}
 
 	// Parse sorting
 	if sortBy := c.Query("sort_by"); sortBy != "" {
@@ -177,6 +377,17 @@ func (h *DeviceHandler) GetDevice(c *gin.Context) {
 }
 
 // UpdateDevice handles device updates
+// @Summary Update device
+// @Description Update the name, location or firmware version of a device. An empty name or location clears it.
+// @Tags Devices
+// @Accept json
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Param request body UpdateDeviceRequest true "Device update request"
+// @Success 200 {object} utils.APIResponse{data=model.Device} "Device updated successfully"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 500 {object} utils.APIResponse "Update failed"
+// @Router /devices/{device_id} [put]
 func (h *DeviceHandler) UpdateDevice(c *gin.Context) {
 	deviceID := c.Param("device_id")
 	if deviceID == "" {
@@ -190,9 +401,18 @@ func (h *DeviceHandler) UpdateDevice(c *gin.Context) {
 		return
 	}
 
-	// Implementation would update device fields
-	// For now, just return success
-	utils.SuccessResponse(c, http.StatusOK, "Device updated successfully", gin.H{"device_id": deviceID})
+	device, err := h.deviceService.UpdateDevice(c.Request.Context(), deviceID, &service.UpdateDeviceRequest{
+		Name:            req.Name,
+		Location:        req.Location,
+		FirmwareVersion: req.FirmwareVersion,
+	}, getUserID(c))
+	if err != nil {
+		h.logger.Error("Failed to update device", zap.Error(err), zap.String("device_id", deviceID))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update device", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Device updated successfully", device)
 }
 
 // DeleteDevice handles device deletion
@@ -223,6 +443,7 @@ func (h *DeviceHandler) DeleteDevice(c *gin.Context) {
 // @Success 200 {object} utils.APIResponse "Device connected successfully"
 // @Failure 400 {object} utils.APIResponse "Invalid device ID"
 // @Failure 500 {object} utils.APIResponse "Connection failed"
+// @Failure 501 {object} utils.APIResponse "No driver registered for the device"
 // @Router /devices/{device_id}/connect [post]
 func (h *DeviceHandler) ConnectDevice(c *gin.Context) {
 	deviceID := c.Param("device_id")
@@ -232,6 +453,10 @@ func (h *DeviceHandler) ConnectDevice(c *gin.Context) {
 	}
 
 	if err := h.deviceService.ConnectDevice(c.Request.Context(), deviceID); err != nil {
+		if errors.Is(err, service.ErrDriverNotFound) {
+			utils.ErrorResponseWithCode(c, http.StatusNotImplemented, service.OperationErrorCode(err), "No driver registered for the device", err)
+			return
+		}
 		h.logger.Error("Failed to connect device", zap.Error(err), zap.String("device_id", deviceID))
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to connect device", err)
 		return
@@ -267,6 +492,34 @@ func (h *DeviceHandler) DisconnectDevice(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Device disconnected successfully", gin.H{"device_id": deviceID})
 }
 
+// ReconnectDevice forces a fresh connection to a device
+// @Summary Reconnect device
+// @Description Evict the cached driver, close the connection and establish a fresh, tested connection
+// @Tags Devices
+// @Accept json
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Success 200 {object} utils.APIResponse{data=service.ReconnectResult} "Device reconnected successfully"
+// @Failure 400 {object} utils.APIResponse "Invalid device ID"
+// @Failure 500 {object} utils.APIResponse "Reconnection failed"
+// @Router /devices/{device_id}/reconnect [post]
+func (h *DeviceHandler) ReconnectDevice(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	if deviceID == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Device ID is required", nil)
+		return
+	}
+
+	result, err := h.deviceService.ReconnectDevice(c.Request.Context(), deviceID)
+	if err != nil {
+		h.logger.Error("Failed to reconnect device", zap.Error(err), zap.String("device_id", deviceID))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to reconnect device", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Device reconnected successfully", result)
+}
+
 // TestDevice tests device connectivity
 // @Summary Test device connectivity
 // @Description Test connection and basic functionality of a device
@@ -323,6 +576,293 @@ func (h *DeviceHandler) GetDeviceHealth(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Device health retrieved successfully", health)
 }
 
+// GetPrinterStatus queries a printer's real-time status
+// @Summary Get printer status
+// @Description Query a connected printer's real-time status (DLE EOT) and return it as typed fields
+// @Tags Devices
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Success 200 {object} utils.APIResponse{data=driver.PrinterStatus} "Printer status retrieved successfully"
+// @Failure 400 {object} utils.APIResponse "Invalid device ID"
+// @Failure 404 {object} utils.APIResponse "Device not found"
+// @Failure 409 {object} utils.APIResponse "Device not connected"
+// @Failure 422 {object} utils.APIResponse "Device can't report printer status"
+// @Failure 502 {object} utils.APIResponse "Printer did not answer the status query"
+// @Router /devices/{device_id}/printer-status [get]
+func (h *DeviceHandler) GetPrinterStatus(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	if deviceID == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Device ID is required", nil)
+		return
+	}
+
+	status, err := h.deviceService.GetPrinterStatus(c.Request.Context(), deviceID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDeviceNotConnected):
+			utils.ErrorResponse(c, http.StatusConflict, "Device not connected", err)
+		case errors.Is(err, service.ErrPrinterStatusUnsupported):
+			utils.ErrorResponse(c, http.StatusUnprocessableEntity, "Device can't report printer status", err)
+		case errors.Is(err, service.ErrPrinterStatusFailed):
+			h.logger.Error("Failed to get printer status", zap.Error(err), zap.String("device_id", deviceID))
+			utils.ErrorResponse(c, http.StatusBadGateway, "Failed to get printer status", err)
+		default:
+			utils.ErrorResponse(c, http.StatusNotFound, "Device not found", err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Printer status retrieved successfully", status)
+}
+
+// GetVirtualOutput downloads the output captured by a virtual device
+// @Summary Download virtual device output
+// @Description Download the raw bytes (e.g. the ESC/POS stream of a receipt) sent to a VIRTUAL device since it was first connected or its output was last cleared, to check receipt layouts without a printer. Output is kept in memory up to max_bytes of the connection config, oldest bytes dropped first.
+// @Tags Devices
+// @Produce octet-stream
+// @Param device_id path string true "Device ID"
+// @Success 200 {file} binary "Captured output"
+// @Failure 400 {object} utils.APIResponse "Invalid device ID"
+// @Failure 404 {object} utils.APIResponse "Device or captured output not found"
+// @Failure 422 {object} utils.APIResponse "Device is not a virtual device"
+// @Router /devices/{device_id}/virtual-output [get]
+func (h *DeviceHandler) GetVirtualOutput(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	if deviceID == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Device ID is required", nil)
+		return
+	}
+
+	output, err := h.deviceService.GetVirtualOutput(c.Request.Context(), deviceID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotVirtualDevice):
+			utils.ErrorResponse(c, http.StatusUnprocessableEntity, "Device is not a virtual device", err)
+		case errors.Is(err, service.ErrNoCapturedOutput):
+			utils.ErrorResponse(c, http.StatusNotFound, "No captured output", err)
+		default:
+			utils.ErrorResponse(c, http.StatusNotFound, "Device not found", err)
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", deviceID+".bin"))
+	c.Data(http.StatusOK, "application/octet-stream", output)
+}
+
+// ClearVirtualOutput discards the output captured by a virtual device
+// @Summary Clear virtual device output
+// @Description Discard the output captured by a VIRTUAL device, e.g. before the print a test checks.
+// @Tags Devices
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Success 200 {object} utils.APIResponse "Virtual output cleared"
+// @Failure 400 {object} utils.APIResponse "Invalid device ID"
+// @Failure 404 {object} utils.APIResponse "Device not found"
+// @Failure 422 {object} utils.APIResponse "Device is not a virtual device"
+// @Router /devices/{device_id}/virtual-output [delete]
+func (h *DeviceHandler) ClearVirtualOutput(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	if deviceID == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Device ID is required", nil)
+		return
+	}
+
+	if err := h.deviceService.ClearVirtualOutput(c.Request.Context(), deviceID); err != nil {
+		if errors.Is(err, service.ErrNotVirtualDevice) {
+			utils.ErrorResponse(c, http.StatusUnprocessableEntity, "Device is not a virtual device", err)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusNotFound, "Device not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Virtual output cleared", nil)
+}
+
+// GetDeviceStatusHistory retrieves device status transitions
+// @Summary Get device status history
+// @Description Get the status transitions of a device (old and new status, reason, time), newest first
+// @Tags Devices
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Param since query string false "Start time (RFC3339)"
+// @Param until query string false "End time (RFC3339)"
+// @Param limit query int false "Maximum events (1-1000)" default(100)
+// @Success 200 {object} utils.APIResponse{data=service.DeviceStatusHistory} "Device status history retrieved successfully"
+// @Failure 400 {object} utils.APIResponse "Invalid parameters"
+// @Failure 500 {object} utils.APIResponse "Failed to get device status history"
+// @Router /devices/{device_id}/status-history [get]
+func (h *DeviceHandler) GetDeviceStatusHistory(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	if deviceID == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Device ID is required", nil)
+		return
+	}
+
+	req := &service.StatusHistoryRequest{Limit: 100}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 1000 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid limit, expected 1-1000", err)
+			return
+		}
+		req.Limit = limit
+	}
+	if since := c.Query("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid since time, expected RFC3339", err)
+			return
+		}
+		req.Since = &t
+	}
+	if until := c.Query("until"); until != "" {
+		t, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid until time, expected RFC3339", err)
+			return
+		}
+		req.Until = &t
+	}
+
+	history, err := h.deviceService.GetStatusHistory(c.Request.Context(), deviceID, req)
+	if err != nil {
+		h.logger.Error("Failed to get device status history", zap.Error(err), zap.String("device_id", deviceID))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get device status history", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Device status history retrieved successfully", history)
+}
+
+// CreateInventorySnapshot records a fleet-wide inventory snapshot
+// @Summary Create inventory snapshot
+// @Description Record the identity, firmware, status and capabilities of every device as a stored audit snapshot. Online devices are read live, others as registered; live tells which.
+// @Tags Inventory
+// @Produce json
+// @Success 201 {object} utils.APIResponse{data=model.InventorySnapshot} "Inventory snapshot created"
+// @Failure 500 {object} utils.APIResponse "Failed to create inventory snapshot"
+// @Router /inventory/snapshot [post]
+func (h *DeviceHandler) CreateInventorySnapshot(c *gin.Context) {
+	snapshot, err := h.deviceService.CreateInventorySnapshot(c.Request.Context(), getUserID(c))
+	if err != nil {
+		h.logger.Error("Failed to create inventory snapshot", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create inventory snapshot", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Inventory snapshot created", snapshot)
+}
+
+// GetInventorySnapshot retrieves an inventory snapshot
+// @Summary Get inventory snapshot
+// @Description Get a stored inventory snapshot by ID
+// @Tags Inventory
+// @Produce json
+// @Param snapshot_id path string true "Snapshot ID"
+// @Success 200 {object} utils.APIResponse{data=model.InventorySnapshot} "Inventory snapshot retrieved successfully"
+// @Failure 400 {object} utils.APIResponse "Invalid snapshot ID"
+// @Failure 404 {object} utils.APIResponse "Inventory snapshot not found"
+// @Router /inventory/snapshots/{snapshot_id} [get]
+func (h *DeviceHandler) GetInventorySnapshot(c *gin.Context) {
+	snapshotID, err := uuid.Parse(c.Param("snapshot_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid snapshot ID", err)
+		return
+	}
+
+	snapshot, err := h.deviceService.GetInventorySnapshot(c.Request.Context(), snapshotID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Inventory snapshot not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Inventory snapshot retrieved successfully", snapshot)
+}
+
+// GetUptimeReport reports device connectivity SLA
+// @Summary Device uptime report
+// @Description Uptime percentage, outages, mean time between failures and mean time to recovery per device and per branch, computed from status history. Maintenance time is not counted against uptime.
+// @Tags Reports
+// @Produce json
+// @Param branch_id query string false "Filter by branch ID"
+// @Param from query string false "Period start (RFC3339), defaults to 30 days before to"
+// @Param to query string false "Period end (RFC3339), defaults to now"
+// @Success 200 {object} utils.APIResponse{data=service.UptimeReport} "Uptime report retrieved successfully"
+// @Failure 400 {object} utils.APIResponse "Invalid parameters"
+// @Failure 500 {object} utils.APIResponse "Failed to get uptime report"
+// @Router /reports/uptime [get]
+func (h *DeviceHandler) GetUptimeReport(c *gin.Context) {
+	req := &service.UptimeReportRequest{}
+
+	if branchIDStr := c.Query("branch_id"); branchIDStr != "" {
+		branchID, err := uuid.Parse(branchIDStr)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid branch ID", err)
+			return
+		}
+		req.BranchID = &branchID
+	}
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid from time, expected RFC3339", err)
+			return
+		}
+		req.From = &t
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid to time, expected RFC3339", err)
+			return
+		}
+		req.To = &t
+	}
+
+	report, err := h.deviceService.GetUptimeReport(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidReportPeriod) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid report period", err)
+			return
+		}
+		h.logger.Error("Failed to get uptime report", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get uptime report", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Uptime report retrieved successfully", report)
+}
+
+// GetDeviceConnection retrieves live connection state
+// @Summary Get device connection state
+// @Description Get live driver, in-flight operation and circuit breaker state of a device
+// @Tags Devices
+// @Accept json
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Success 200 {object} utils.APIResponse{data=service.DeviceConnection} "Device connection state retrieved successfully"
+// @Failure 400 {object} utils.APIResponse "Invalid device ID"
+// @Failure 500 {object} utils.APIResponse "Failed to get device connection state"
+// @Router /devices/{device_id}/connection [get]
+func (h *DeviceHandler) GetDeviceConnection(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	if deviceID == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Device ID is required", nil)
+		return
+	}
+
+	connection, err := h.deviceService.GetConnectionState(c.Request.Context(), deviceID)
+	if err != nil {
+		h.logger.Error("Failed to get device connection state", zap.Error(err), zap.String("device_id", deviceID))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get device connection state", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Device connection state retrieved successfully", connection)
+}
+
 // UpdateDeviceConfig updates device configuration
 // @Summary Update device configuration
 // @Description Update device configuration settings
@@ -358,8 +898,201 @@ func (h *DeviceHandler) UpdateDeviceConfig(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Device configuration updated successfully", gin.H{"device_id": deviceID})
 }
 
+// GetDeviceCapabilities returns the capability toggles of a device
+// @Summary Get device capability flags
+// @Description Get which toggleable capabilities (CUT, DRAWER, LOGO, TWO_COLOR) are enabled on a device
+// @Tags Devices
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Success 200 {object} utils.APIResponse{data=service.CapabilityFlagsResult} "Capability flags retrieved"
+// @Failure 404 {object} utils.APIResponse "Device not found"
+// @Router /devices/{device_id}/capabilities [get]
+func (h *DeviceHandler) GetDeviceCapabilities(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	if deviceID == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Device ID is required", nil)
+		return
+	}
+
+	result, err := h.deviceService.GetCapabilityFlags(c.Request.Context(), deviceID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Device not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Capability flags retrieved successfully", result)
+}
+
+// UpdateDeviceCapabilities enables or disables device capabilities at runtime
+// @Summary Update device capability flags
+// @Description Enable or disable toggleable capabilities; changes are persisted and applied to the live driver
+// @Tags Devices
+// @Accept json
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Param request body UpdateCapabilitiesRequest true "Capability flags"
+// @Success 200 {object} utils.APIResponse{data=service.CapabilityFlagsResult} "Capability flags updated"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 500 {object} utils.APIResponse "Update failed"
+// @Router /devices/{device_id}/capabilities [put]
+func (h *DeviceHandler) UpdateDeviceCapabilities(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	if deviceID == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Device ID is required", nil)
+		return
+	}
+
+	var req UpdateCapabilitiesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if len(req.Flags) == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "At least one capability flag is required", nil)
+		return
+	}
+
+	flags := make(map[model.Capability]bool, len(req.Flags))
+	for capability, enabled := range req.Flags {
+		flags[model.Capability(strings.ToUpper(capability))] = enabled
+	}
+
+	result, err := h.deviceService.SetCapabilityFlags(c.Request.Context(), deviceID, flags, getUserID(c))
+	if err != nil {
+		if errors.Is(err, service.ErrCapabilityNotToggleable) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Capability cannot be toggled", err)
+			return
+		}
+		h.logger.Error("Failed to update device capabilities", zap.Error(err), zap.String("device_id", deviceID))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update device capabilities", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Capability flags updated successfully", result)
+}
+
+// UpdateWireLogging toggles protocol wire logging for a device
+// @Summary Toggle protocol wire logging
+// @Description Enable or disable truncated, rate-limited hex dumps of the bytes written to and read from a device
+// @Tags Devices
+// @Accept json
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Param request body WireLoggingRequest true "Wire logging flag"
+// @Success 200 {object} utils.APIResponse{data=service.WireLoggingResult} "Wire logging updated"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 500 {object} utils.APIResponse "Update failed"
+// @Router /devices/{device_id}/wire-logging [put]
+func (h *DeviceHandler) UpdateWireLogging(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	if deviceID == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Device ID is required", nil)
+		return
+	}
+
+	var req WireLoggingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	result, err := h.deviceService.SetWireLogging(c.Request.Context(), deviceID, *req.Enabled, getUserID(c))
+	if err != nil {
+		h.logger.Error("Failed to update wire logging", zap.Error(err), zap.String("device_id", deviceID))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update wire logging", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Wire logging updated successfully", result)
+}
+
+// GetOperationPolicy returns the operation allow and deny lists of a device
+// @Summary Get device operation policy
+// @Description Get the operations a device is administratively allowed or denied, regardless of its capabilities
+// @Tags Devices
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Success 200 {object} utils.APIResponse{data=service.OperationPolicy} "Operation policy retrieved"
+// @Failure 404 {object} utils.APIResponse "Device not found"
+// @Router /devices/{device_id}/operation-policy [get]
+func (h *DeviceHandler) GetOperationPolicy(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	if deviceID == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Device ID is required", nil)
+		return
+	}
+
+	result, err := h.deviceService.GetOperationPolicy(c.Request.Context(), deviceID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Device not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Operation policy retrieved successfully", result)
+}
+
+// UpdateOperationPolicy replaces the operation allow and deny lists of a device
+// @Summary Update device operation policy
+// @Description Replace the operations a device is allowed or denied. Denied operations are rejected with OPERATION_NOT_PERMITTED even when the device supports them; a non-empty allow list permits only the operations it names. Empty lists clear the policy. Print jobs with raw ESC_POS content are rejected unless allow_raw_print is set and the caller's token has the print:raw scope; enabling allow_raw_print takes the admin scope.
+// @Tags Devices
+// @Accept json
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Param request body OperationPolicyRequest true "Operation policy"
+// @Success 200 {object} utils.APIResponse{data=service.OperationPolicy} "Operation policy updated"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 403 {object} utils.APIResponse "Admin scope required"
+// @Failure 500 {object} utils.APIResponse "Update failed"
+// @Router /devices/{device_id}/operation-policy [put]
+func (h *DeviceHandler) UpdateOperationPolicy(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	if deviceID == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Device ID is required", nil)
+		return
+	}
+
+	var req OperationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	policy := &service.OperationPolicy{
+		Allowed: toOperationTypes(req.Allowed),
+		Denied:  toOperationTypes(req.Denied),
+
+		AllowRawPrint: req.AllowRawPrint,
+	}
+
+	result, err := h.deviceService.SetOperationPolicy(c.Request.Context(), deviceID, policy, getUserID(c))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidOperationPolicy) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid operation policy", err)
+			return
+		}
+		if errors.Is(err, service.ErrAdminScopeRequired) {
+			utils.ErrorResponse(c, http.StatusForbidden, "Admin scope required", err)
+			return
+		}
+		h.logger.Error("Failed to update operation policy", zap.Error(err), zap.String("device_id", deviceID))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update operation policy", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Operation policy updated successfully", result)
+}
+
 // Helper functions and DTOs
 
+// toOperationTypes converts operation names to operation types
+func toOperationTypes(names []string) []model.OperationType {
+	operations := make([]model.OperationType, 0, len(names))
+	for _, name := range names {
+		operations = append(operations, model.OperationType(strings.ToUpper(strings.TrimSpace(name))))
+	}
+	return operations
+}
+
 // getUserID extracts user ID from context
 func getUserID(c *gin.Context) string {
 	if userID, exists := c.Get("user_id"); exists {
@@ -370,6 +1103,7 @@ func getUserID(c *gin.Context) string {
 
 // UpdateDeviceRequest represents device update request
 type UpdateDeviceRequest struct {
+	Name            *string `json:"name,omitempty"`
 	Location        *string `json:"location,omitempty"`
 	FirmwareVersion *string `json:"firmware_version,omitempty"`
 }
@@ -378,3 +1112,22 @@ type UpdateDeviceRequest struct {
 type UpdateConfigRequest struct {
 	Config map[string]interface{} `json:"config"`
 }
+
+// WireLoggingRequest represents wire logging toggle request
+type WireLoggingRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// UpdateCapabilitiesRequest represents capability toggle request, e.g.
+// {"flags": {"CUT": false}}
+type UpdateCapabilitiesRequest struct {
+	Flags map[string]bool `json:"flags" binding:"required"`
+}
+
+// OperationPolicyRequest represents a device operation policy update
+type OperationPolicyRequest struct {
+	Allowed []string `json:"allowed"` // e.g. ["PRINT", "CUT"]; empty permits all
+	Denied  []string `json:"denied"`  // e.g. ["OPEN_DRAWER"]
+
+	AllowRawPrint bool `json:"allow_raw_print"` // permit ESC_POS print content, which is sent unsanitized
+}
@@ -33,7 +33,7 @@ func NewDiscoveryHandler(discoveryService *service.DiscoveryService, logger *zap
 // @Produce json
 // @Param type query string false "Scan type" Enums(all, serial, usb, tcp, bluetooth) default(all)
 // @Param timeout query string false "Scan timeout" default(30s)
-// @Success 200 {object} utils.APIResponse{data=object{devices_found=int,devices=[]service.DiscoveredDevice}} "Device scan completed"
+// @Success 200 {object} utils.APIResponse{data=service.ScanResult} "Device scan completed"
 // @Failure 500 {object} utils.APIResponse "Scan failed"
 // @Router /discovery/scan [get]
 func (h *DiscoveryHandler) ScanDevices(c *gin.Context) {
@@ -46,22 +46,19 @@ func (h *DiscoveryHandler) ScanDevices(c *gin.Context) {
 		Timeout:  timeout,
 	}
 
-	devices, err := h.discoveryService.ScanDevices(c.Request.Context(), req)
+	result, err := h.discoveryService.ScanDevices(c.Request.Context(), req)
 	if err != nil {
 		h.logger.Error("Failed to scan devices", zap.Error(err))
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to scan devices", err)
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Device scan completed", gin.H{
-		"devices_found": len(devices),
-		"devices":       devices,
-	})
+	utils.SuccessResponse(c, http.StatusOK, "Device scan completed", result)
 }
 
 // AutoSetupDevices automatically sets up discovered devices
 // @Summary Auto-setup devices
-// @Description Automatically register and setup discovered devices
+// @Description Automatically register and setup discovered devices. Devices below the configured minimum confidence are skipped unless device_filter.min_confidence lowers it
 // @Tags Discovery
 // @Accept json
 // @Produce json
@@ -111,7 +108,7 @@ func (h *DiscoveryHandler) GetSupportedDevices(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param brand path string true "Device brand" Enums(EPSON, STAR, INGENICO, PAX, CITIZEN, BIXOLON, VERIFONE, GENERIC)
-// @Param type path string true "Device type" Enums(POS, PRINTER, SCANNER, CASH_REGISTER, CASH_DRAWER, DISPLAY)
+// @Param type path string true "Device type" Enums(POS, PRINTER, SCANNER, CASH_REGISTER, CASH_DRAWER, DISPLAY, SCALE)
 // @Success 200 {object} utils.APIResponse{data=object{brand=string,device_type=string,capabilities=[]string}} "Capabilities retrieved"
 // @Failure 404 {object} utils.APIResponse "Device not supported"
 // @Router /discovery/capabilities/{brand}/{type} [get]
@@ -132,6 +129,26 @@ func (h *DiscoveryHandler) GetCapabilities(c *gin.Context) {
 	})
 }
 
+// GetConfigTemplate returns a connection config template
+// @Summary Get connection config template
+// @Description Get default connection settings for a brand and model, to pre-fill device registration. Unlisted models get the brand default.
+// @Tags Discovery
+// @Produce json
+// @Param brand path string true "Device brand" Enums(EPSON, STAR, INGENICO, PAX, CITIZEN, BIXOLON, VERIFONE, GENERIC)
+// @Param model path string true "Device model"
+// @Success 200 {object} utils.APIResponse{data=service.ConfigTemplateResponse} "Config template retrieved"
+// @Failure 404 {object} utils.APIResponse "No template for brand"
+// @Router /discovery/config-template/{brand}/{model} [get]
+func (h *DiscoveryHandler) GetConfigTemplate(c *gin.Context) {
+	template, err := h.discoveryService.GetConfigTemplate(c.Param("brand"), c.Param("model"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Config template not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Config template retrieved", template)
+}
+
 // AutoSetupRequest represents auto-setup request
 type AutoSetupRequest struct {
 	BranchID     string            `json:"branch_id" binding:"required"`
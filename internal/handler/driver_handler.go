@@ -0,0 +1,93 @@
+// internal/handler/driver_handler.go
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"device-service/internal/driver"
+	"device-service/internal/model"
+	"device-service/internal/utils"
+)
+
+// DriverHandler handles driver registry administration requests
+type DriverHandler struct {
+	driverRegistry *driver.Registry
+	logger         *utils.ServiceLogger
+}
+
+// NewDriverHandler creates a new driver handler
+func NewDriverHandler(driverRegistry *driver.Registry, logger *zap.Logger) *DriverHandler {
+	return &DriverHandler{
+		driverRegistry: driverRegistry,
+		logger:         utils.NewServiceLogger(logger, "driver-handler"),
+	}
+}
+
+// ListDrivers lists all registered drivers
+// @Summary List registered drivers
+// @Description Get all registered device drivers including disabled ones
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=object{total=int,drivers=[]driver.DriverInfo}} "Drivers retrieved successfully"
+// @Failure 403 {object} utils.APIResponse "Missing admin scope"
+// @Router /admin/drivers [get]
+func (h *DriverHandler) ListDrivers(c *gin.Context) {
+	drivers := h.driverRegistry.ListDriverInfo()
+
+	utils.SuccessResponse(c, http.StatusOK, "Drivers retrieved successfully", gin.H{
+		"total":   len(drivers),
+		"drivers": drivers,
+	})
+}
+
+// ToggleDriver enables or disables a registered driver
+// @Summary Toggle driver
+// @Description Enable or disable a registered device driver without restarting the service
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body ToggleDriverRequest true "Driver toggle request"
+// @Success 200 {object} utils.APIResponse{data=driver.DriverInfo} "Driver updated successfully"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 403 {object} utils.APIResponse "Missing admin scope"
+// @Failure 404 {object} utils.APIResponse "Driver not registered"
+// @Router /admin/drivers [put]
+func (h *DriverHandler) ToggleDriver(c *gin.Context) {
+	var req ToggleDriverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	key := driver.DriverKey{
+		Brand:      req.Brand,
+		DeviceType: req.DeviceType,
+		Model:      req.Model,
+	}
+
+	info, err := h.driverRegistry.SetEnabled(key, *req.Enabled)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Driver not registered", err)
+		return
+	}
+
+	h.logger.Info("Driver toggled",
+		zap.String("brand", string(req.Brand)),
+		zap.String("device_type", string(req.DeviceType)),
+		zap.String("model", req.Model),
+		zap.Bool("enabled", info.Enabled),
+	)
+	utils.SuccessResponse(c, http.StatusOK, "Driver updated successfully", info)
+}
+
+// ToggleDriverRequest represents driver toggle request
+type ToggleDriverRequest struct {
+	Brand      model.DeviceBrand `json:"brand" binding:"required"`
+	DeviceType model.DeviceType  `json:"device_type" binding:"required"`
+	Model      string            `json:"model" binding:"required"`
+	Enabled    *bool             `json:"enabled" binding:"required"`
+}
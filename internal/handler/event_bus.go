@@ -6,6 +6,9 @@ import (
 	"time"
 
 	"go.uber.org/zap"
+
+	"device-service/internal/model"
+	"device-service/internal/service"
 )
 
 // EventBus manages event distribution
@@ -139,6 +142,14 @@ func (deh *DeviceEventHandler) OnOperationCompleted(deviceID string, operationID
 	)
 }
 
+// OnOperationProgress handles operation progress events. The event type is the
+// progress state: queued, processing or the final status. Progress is
+// reported while the operation runs, so it is routed by the device it comes
+// with instead of a device lookup.
+func (deh *DeviceEventHandler) OnOperationProgress(device *model.Device, progress *model.OperationProgress) {
+	deh.websocketHandler.BroadcastDeviceOperationEvent(progress.OperationID, device, progress.State, progress)
+}
+
 // OnStatusChanged handles device status change events
 func (deh *DeviceEventHandler) OnStatusChanged(deviceID string, oldStatus, newStatus string) {
 	deh.websocketHandler.BroadcastDeviceEvent(deviceID, "status_changed", map[string]interface{}{
@@ -152,3 +163,36 @@ func (deh *DeviceEventHandler) OnStatusChanged(deviceID string, oldStatus, newSt
 		zap.String("new_status", newStatus),
 	)
 }
+
+// OnErrorRateAnomaly handles error rate early warnings
+func (deh *DeviceEventHandler) OnErrorRateAnomaly(anomaly *service.ErrorRateAnomaly) {
+	deh.websocketHandler.BroadcastDeviceEvent(anomaly.DeviceID, "error_rate_anomaly", anomaly)
+
+	deh.logger.Info("Device error rate anomaly event broadcasted",
+		zap.String("device_id", anomaly.DeviceID),
+		zap.Float64("error_rate", anomaly.ErrorRate),
+	)
+}
+
+// OnOperationRecovered handles operations settled after being interrupted by
+// a service restart
+func (deh *DeviceEventHandler) OnOperationRecovered(recovered *service.RecoveredOperation) {
+	deh.websocketHandler.BroadcastDeviceEvent(recovered.DeviceID, "operation_recovered", recovered)
+
+	deh.logger.Info("Operation recovered event broadcasted",
+		zap.String("device_id", recovered.DeviceID),
+		zap.String("operation_id", recovered.OperationID),
+		zap.String("action", recovered.Action),
+	)
+}
+
+// OnOfflineOperationExpired handles offline operations dead-lettered before
+// they could be synced
+func (deh *DeviceEventHandler) OnOfflineOperationExpired(expired *service.OfflineOperationExpired) {
+	deh.websocketHandler.BroadcastDeviceEvent(expired.DeviceID, "offline_operation_expired", expired)
+
+	deh.logger.Info("Offline operation expired event broadcasted",
+		zap.String("device_id", expired.DeviceID),
+		zap.String("operation_id", expired.OperationID.String()),
+	)
+}
@@ -10,22 +10,25 @@ import (
 
 	"device-service/internal/config"
 	"device-service/internal/database"
+	"device-service/internal/service"
 	"device-service/internal/utils"
 )
 
 // HealthHandler handles health check requests
 type HealthHandler struct {
-	db     *database.DB
-	config *config.Config
-	logger *utils.ServiceLogger
+	db         *database.DB
+	config     *config.Config
+	background *service.BackgroundManager
+	logger     *utils.ServiceLogger
 }
 
 // NewHealthHandler creates a new health handler
-func NewHealthHandler(db *database.DB, config *config.Config, logger *zap.Logger) *HealthHandler {
+func NewHealthHandler(db *database.DB, config *config.Config, background *service.BackgroundManager, logger *zap.Logger) *HealthHandler {
 	return &HealthHandler{
-		db:     db,
-		config: config,
-		logger: utils.NewServiceLogger(logger, "health-handler"),
+		db:         db,
+		config:     config,
+		background: background,
+		logger:     utils.NewServiceLogger(logger, "health-handler"),
 	}
 }
 
@@ -84,6 +87,32 @@ func (h *HealthHandler) HealthCheck(c *gin.Context) {
 		},
 	}
 
+	// Background services check
+	for _, task := range h.background.Status() {
+		check := CheckResult{
+			Status: "healthy",
+			Data: map[string]interface{}{
+				"running":      task.Running,
+				"interval":     task.Interval,
+				"last_run":     task.LastRun,
+				"last_success": task.LastSuccess,
+				"runs":         task.Runs,
+				"failures":     task.Failures,
+				"panics":       task.Panics,
+			},
+		}
+		if task.LastError != "" {
+			check.Data["last_error"] = task.LastError
+			check.Data["last_error_at"] = task.LastErrorAt
+		}
+		if task.Status != service.BackgroundStatusHealthy {
+			health.Status = "unhealthy"
+			check.Status = "unhealthy"
+			check.Message = "Background service " + task.Status
+		}
+		health.Checks["background_"+task.Name] = check
+	}
+
 	statusCode := http.StatusOK
 	if health.Status == "unhealthy" {
 		statusCode = http.StatusServiceUnavailable
@@ -148,9 +177,20 @@ func (h *HealthHandler) ReadinessCheck(c *gin.Context) {
 		return
 	}
 
+	// Background services must be making progress
+	if !h.background.Healthy() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":              "not ready",
+			"reason":              "background service not making progress",
+			"background_services": h.background.Status(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"status":    "ready",
-		"timestamp": time.Now(),
+		"status":              "ready",
+		"timestamp":           time.Now(),
+		"background_services": h.background.Status(),
 	})
 }
 
@@ -0,0 +1,135 @@
+// internal/handler/metrics_handler.go
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"device-service/internal/driver"
+	"device-service/internal/model"
+	"device-service/internal/protocol"
+	"device-service/internal/service"
+	"device-service/internal/utils"
+)
+
+// MetricsHandler exposes service metrics in Prometheus text format. It reads
+// the built-in protocol recorder; a custom recorder plugged in through
+// protocol.SetMetricsRecorder is expected to export its own metrics.
+type MetricsHandler struct {
+	recorder         *protocol.CounterRecorder
+	driverPool       *driver.Pool
+	operationService *service.OperationService
+	logger           *utils.ServiceLogger
+}
+
+// NewMetricsHandler creates a new metrics handler
+func NewMetricsHandler(driverPool *driver.Pool, operationService *service.OperationService, logger *zap.Logger) *MetricsHandler {
+	return &MetricsHandler{
+		recorder:         protocol.DefaultMetrics(),
+		driverPool:       driverPool,
+		operationService: operationService,
+		logger:           utils.NewServiceLogger(logger, "metrics-handler"),
+	}
+}
+
+// transportMetric describes a single exported transport counter
+type transportMetric struct {
+	name  string
+	help  string
+	value func(protocol.TransportCounters) int64
+}
+
+var transportMetrics = []transportMetric{
+	{"device_transport_bytes_written_total", "Total bytes written to devices.", func(c protocol.TransportCounters) int64 { return c.BytesWritten }},
+	{"device_transport_bytes_read_total", "Total bytes read from devices.", func(c protocol.TransportCounters) int64 { return c.BytesRead }},
+	{"device_transport_write_errors_total", "Total failed writes to devices.", func(c protocol.TransportCounters) int64 { return c.WriteErrors }},
+	{"device_transport_read_errors_total", "Total failed reads from devices.", func(c protocol.TransportCounters) int64 { return c.ReadErrors }},
+	{"device_transport_reconnects_total", "Total device reconnects.", func(c protocol.TransportCounters) int64 { return c.Reconnects }},
+}
+
+// poolMetric describes a single exported driver pool metric
+type poolMetric struct {
+	name       string
+	help       string
+	metricType string
+	value      func(*driver.PoolStats) float64
+}
+
+var poolMetrics = []poolMetric{
+	{"device_driver_pool_open", "Driver connections currently open.", "gauge", func(s *driver.PoolStats) float64 { return float64(s.Open) }},
+	{"device_driver_pool_in_use", "Driver connections held by an operation.", "gauge", func(s *driver.PoolStats) float64 { return float64(s.InUse) }},
+	{"device_driver_pool_idle", "Driver connections not held by an operation.", "gauge", func(s *driver.PoolStats) float64 { return float64(s.Idle) }},
+	{"device_driver_pool_max_open", "Maximum open driver connections, 0 when unlimited.", "gauge", func(s *driver.PoolStats) float64 { return float64(s.MaxOpen) }},
+	{"device_driver_pool_utilization", "Open driver connections as a fraction of the maximum.", "gauge", func(s *driver.PoolStats) float64 { return s.Utilization }},
+	{"device_driver_pool_created_total", "Total drivers created by the pool.", "counter", func(s *driver.PoolStats) float64 { return float64(s.Created) }},
+	{"device_driver_pool_evicted_total", "Total drivers evicted from the pool.", "counter", func(s *driver.PoolStats) float64 { return float64(s.Evicted) }},
+	{"device_driver_pool_exhausted_total", "Total acquires rejected because the pool was full.", "counter", func(s *driver.PoolStats) float64 { return float64(s.Exhausted) }},
+}
+
+// queueMetric describes a single exported per-device queue gauge
+type queueMetric struct {
+	name  string
+	help  string
+	value func(*service.DeviceQueueStats) float64
+}
+
+var queueMetrics = []queueMetric{
+	{"device_operation_queue_depth", "Operations waiting for the device.", func(s *service.DeviceQueueStats) float64 { return float64(s.Depth) }},
+	{"device_operation_queue_oldest_age_seconds", "Age of the oldest operation waiting for the device.", func(s *service.DeviceQueueStats) float64 { return s.OldestQueuedAge }},
+	{"device_operation_queue_wait_seconds_avg", "Average wait time of recent operations on the device.", func(s *service.DeviceQueueStats) float64 { return s.AverageWait }},
+}
+
+// Metrics returns transport metrics labeled by connection type, driver pool
+// utilization and per-device operation queues
+// @Summary Prometheus metrics
+// @Description Transport-level counters, driver pool utilization and device operation queues in Prometheus text exposition format
+// @Tags Health
+// @Produce plain
+// @Success 200 {string} string "Prometheus metrics"
+// @Router /metrics [get]
+func (h *MetricsHandler) Metrics(c *gin.Context) {
+	snapshot := h.recorder.Snapshot()
+
+	connectionTypes := make([]model.ConnectionType, 0, len(snapshot))
+	for connectionType := range snapshot {
+		connectionTypes = append(connectionTypes, connectionType)
+	}
+	sort.Slice(connectionTypes, func(i, j int) bool { return connectionTypes[i] < connectionTypes[j] })
+
+	var b strings.Builder
+	for _, metric := range transportMetrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n", metric.name, metric.help)
+		fmt.Fprintf(&b, "# TYPE %s counter\n", metric.name)
+		for _, connectionType := range connectionTypes {
+			fmt.Fprintf(&b, "%s{connection_type=%q} %d\n",
+				metric.name, string(connectionType), metric.value(snapshot[connectionType]))
+		}
+	}
+
+	if h.driverPool != nil {
+		poolStats := h.driverPool.Stats()
+		for _, metric := range poolMetrics {
+			fmt.Fprintf(&b, "# HELP %s %s\n", metric.name, metric.help)
+			fmt.Fprintf(&b, "# TYPE %s %s\n", metric.name, metric.metricType)
+			fmt.Fprintf(&b, "%s %g\n", metric.name, metric.value(poolStats))
+		}
+	}
+
+	if h.operationService != nil {
+		queues := h.operationService.ListDeviceQueues()
+		for _, metric := range queueMetrics {
+			fmt.Fprintf(&b, "# HELP %s %s\n", metric.name, metric.help)
+			fmt.Fprintf(&b, "# TYPE %s gauge\n", metric.name)
+			for _, queue := range queues {
+				fmt.Fprintf(&b, "%s{device_id=%q} %g\n", metric.name, queue.DeviceID.String(), metric.value(queue))
+			}
+		}
+	}
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
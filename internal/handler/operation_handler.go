@@ -2,8 +2,11 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,6 +16,7 @@ import (
 	"device-service/internal/model"
 	"device-service/internal/service"
 	"device-service/internal/utils"
+	pkgdriver "device-service/pkg/driver"
 )
 
 // OperationHandler handles operation-related HTTP requests
@@ -37,10 +41,13 @@ func (h *OperationHandler) ExecuteOperation(c *gin.Context) {
 		return
 	}
 
+	req.IncludeRaw = includeRaw(c)
+	req.LockToken = lockToken(c)
+
 	response, err := h.operationService.ExecuteOperation(c.Request.Context(), &req)
 	if err != nil {
 		h.logger.Error("Failed to execute operation", zap.Error(err))
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to execute operation", err)
+		respondExecuteError(c, "Failed to execute operation", err)
 		return
 	}
 
@@ -67,19 +74,25 @@ func (h *OperationHandler) ExecuteDeviceOperation(c *gin.Context) {
 		OperationType: req.OperationType,
 		Data:          req.Data,
 		Priority:      req.Priority,
+		Metadata:      req.Metadata,
 	}
 
 	if req.CorrelationID != nil {
 		correlationID, err := uuid.Parse(*req.CorrelationID)
-		if err == nil {
-			operationReq.CorrelationID = &correlationID
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid correlation ID", err)
+			return
 		}
+		operationReq.CorrelationID = &correlationID
 	}
 
+	operationReq.IncludeRaw = includeRaw(c)
+	operationReq.LockToken = lockToken(c)
+
 	response, err := h.operationService.ExecuteOperation(c.Request.Context(), operationReq)
 	if err != nil {
 		h.logger.Error("Failed to execute device operation", zap.Error(err))
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to execute operation", err)
+		respondExecuteError(c, "Failed to execute operation", err)
 		return
 	}
 
@@ -88,12 +101,14 @@ func (h *OperationHandler) ExecuteDeviceOperation(c *gin.Context) {
 
 // PrintOperation executes print operation
 // @Summary Print operation
-// @Description Execute a print operation on a device
+// @Description Execute a print operation on a device. With verify set, the printer status is read back after printing and the operation fails (e.g. PAPER_OUT) unless the content printed. With device.print_dedup_window set, repeating an identical print within it returns the first print's response with deduplicated set, unless force is set. TEXT content is printed centered, enlarged and with a timestamp footer unless format is PLAIN (or raw_text is set), which prints it verbatim. Font B fits more characters per line (64 on 80mm, 42 on 58mm paper instead of 48 and 32). RECEIPT content must be a JSON receipt unless auto_fallback is set, which prints other content as formatted text.
 // @Tags Operations
 // @Accept json
 // @Produce json
 // @Param device_id path string true "Device ID"
 // @Param request body PrintRequest true "Print request"
+// @Param include query string false "Set to raw to include the base64 raw device response"
+// @Param X-Device-Lock-Token header string false "Lock token, required while the device is locked"
 // @Success 200 {object} utils.APIResponse{data=service.OperationResponse} "Print operation completed"
 // @Failure 400 {object} utils.APIResponse "Invalid request"
 // @Failure 500 {object} utils.APIResponse "Print operation failed"
@@ -111,27 +126,79 @@ func (h *OperationHandler) PrintOperation(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
+	if req.Encoding != "" && !pkgdriver.IsPrintEncoding(req.Encoding) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid encoding",
+			fmt.Errorf("encoding must be one of %s", strings.Join(pkgdriver.PrintEncodings, ", ")))
+		return
+	}
 
 	// Convert print request to operation data
 	operationData := map[string]interface{}{
 		"content":      req.Content,
 		"content_type": req.ContentType,
 		"copies":       req.Copies,
-		"cut":          req.Cut,
 		"open_drawer":  req.OpenDrawer,
 	}
+	if req.Cut != nil {
+		operationData["cut"] = *req.Cut
+	}
+	if req.AutoFallback {
+		operationData["auto_fallback"] = true
+	}
+	options := map[string]interface{}{}
+	if req.Overflow != "" {
+		options["overflow"] = req.Overflow
+	}
+	if req.LineSpacing != nil {
+		options["line_spacing"] = strconv.Itoa(*req.LineSpacing)
+	}
+	if req.Compact != nil {
+		options["compact"] = strconv.FormatBool(*req.Compact)
+	}
+	if req.Color != "" {
+		options["color"] = req.Color
+	}
+	if req.PDFPages != "" {
+		options["pdf_pages"] = req.PDFPages
+	}
+	if req.Format != "" {
+		options["format"] = req.Format
+	}
+	if req.RawText {
+		options["format"] = "PLAIN"
+	}
+	if req.Font != "" {
+		options["font"] = req.Font
+	}
+	if len(options) > 0 {
+		operationData["options"] = options
+	}
+	if req.Encoding != "" {
+		operationData["encoding"] = strings.ToUpper(req.Encoding)
+	}
+	if req.Verify {
+		operationData[service.PrintVerifyKey] = true
+	}
+	if req.Force {
+		operationData[service.PrintForceKey] = true
+	}
 
 	operationReq := &service.OperationRequest{
 		DeviceID:      deviceID,
 		OperationType: model.OperationTypePrint,
 		Data:          operationData,
-		Priority:      model.PriorityHigh,
+		Priority:      req.Priority,
+		Metadata:      req.Metadata,
+		Deduplicate:   true,
 	}
 
+	operationReq.IncludeRaw = includeRaw(c)
+	operationReq.LockToken = lockToken(c)
+
 	response, err := h.operationService.ExecuteOperation(c.Request.Context(), operationReq)
 	if err != nil {
 		h.logger.Error("Failed to execute print operation", zap.Error(err))
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to print", err)
+		respondExecuteError(c, "Failed to print", err)
 		return
 	}
 
@@ -146,6 +213,8 @@ func (h *OperationHandler) PrintOperation(c *gin.Context) {
 // @Produce json
 // @Param device_id path string true "Device ID"
 // @Param request body PaymentRequest true "Payment request"
+// @Param include query string false "Set to raw to include the base64 raw device response"
+// @Param X-Device-Lock-Token header string false "Lock token, required while the device is locked"
 // @Success 200 {object} utils.APIResponse{data=service.OperationResponse} "Payment operation completed"
 // @Failure 400 {object} utils.APIResponse "Invalid request"
 // @Failure 500 {object} utils.APIResponse "Payment operation failed"
@@ -177,14 +246,18 @@ func (h *OperationHandler) PaymentOperation(c *gin.Context) {
 		DeviceID:      deviceID,
 		OperationType: model.OperationTypePayment,
 		Data:          operationData,
-		Priority:      model.PriorityUltraCritical,
+		Priority:      req.Priority,
 		CorrelationID: &correlationID,
+		Metadata:      req.Metadata,
 	}
 
+	operationReq.IncludeRaw = includeRaw(c)
+	operationReq.LockToken = lockToken(c)
+
 	response, err := h.operationService.ExecuteOperation(c.Request.Context(), operationReq)
 	if err != nil {
 		h.logger.Error("Failed to execute payment operation", zap.Error(err))
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to process payment", err)
+		respondExecuteError(c, "Failed to process payment", err)
 		return
 	}
 
@@ -199,6 +272,8 @@ func (h *OperationHandler) PaymentOperation(c *gin.Context) {
 // @Produce json
 // @Param device_id path string true "Device ID"
 // @Param request body ScanRequest true "Scan request"
+// @Param include query string false "Set to raw to include the base64 raw device response"
+// @Param X-Device-Lock-Token header string false "Lock token, required while the device is locked"
 // @Success 200 {object} utils.APIResponse{data=service.OperationResponse} "Scan operation completed"
 // @Failure 400 {object} utils.APIResponse "Invalid request"
 // @Failure 500 {object} utils.APIResponse "Scan operation failed"
@@ -226,13 +301,17 @@ func (h *OperationHandler) ScanOperation(c *gin.Context) {
 		DeviceID:      deviceID,
 		OperationType: model.OperationTypeScan,
 		Data:          operationData,
-		Priority:      model.PriorityNormal,
+		Priority:      req.Priority,
+		Metadata:      req.Metadata,
 	}
 
+	operationReq.IncludeRaw = includeRaw(c)
+	operationReq.LockToken = lockToken(c)
+
 	response, err := h.operationService.ExecuteOperation(c.Request.Context(), operationReq)
 	if err != nil {
 		h.logger.Error("Failed to execute scan operation", zap.Error(err))
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to scan", err)
+		respondExecuteError(c, "Failed to scan", err)
 		return
 	}
 
@@ -246,6 +325,8 @@ func (h *OperationHandler) ScanOperation(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param device_id path string true "Device ID"
+// @Param include query string false "Set to raw to include the base64 raw device response"
+// @Param X-Device-Lock-Token header string false "Lock token, required while the device is locked"
 // @Success 200 {object} utils.APIResponse{data=service.OperationResponse} "Drawer opened successfully"
 // @Failure 400 {object} utils.APIResponse "Invalid device ID"
 // @Failure 500 {object} utils.APIResponse "Drawer operation failed"
@@ -266,19 +347,183 @@ func (h *OperationHandler) OpenDrawerOperation(c *gin.Context) {
 		DeviceID:      deviceID,
 		OperationType: model.OperationTypeOpenDrawer,
 		Data:          operationData,
-		Priority:      model.PriorityHigh,
 	}
 
+	operationReq.IncludeRaw = includeRaw(c)
+	operationReq.LockToken = lockToken(c)
+
 	response, err := h.operationService.ExecuteOperation(c.Request.Context(), operationReq)
 	if err != nil {
 		h.logger.Error("Failed to execute drawer operation", zap.Error(err))
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to open drawer", err)
+		respondExecuteError(c, "Failed to open drawer", err)
 		return
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, "Drawer opened successfully", response)
 }
 
+// WeighOperation executes weigh operation
+// @Summary Read weight
+// @Description Read the current weight from a scale
+// @Tags Operations
+// @Accept json
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Param request body WeighRequest false "Weigh request"
+// @Param include query string false "Set to raw to include the base64 raw device response"
+// @Param X-Device-Lock-Token header string false "Lock token, required while the device is locked"
+// @Success 200 {object} utils.APIResponse{data=service.OperationResponse} "Weight read"
+// @Failure 400 {object} utils.APIResponse "Invalid request or device is not a scale"
+// @Failure 500 {object} utils.APIResponse "Weigh operation failed"
+// @Router /devices/{device_id}/weigh [post]
+func (h *OperationHandler) WeighOperation(c *gin.Context) {
+	deviceIDStr := c.Param("device_id")
+	deviceID, err := uuid.Parse(deviceIDStr)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid device ID", err)
+		return
+	}
+
+	// The body is optional; an empty one waits for a stable weight
+	var req WeighRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	operationData := map[string]interface{}{
+		"wait_stable": req.WaitStable == nil || *req.WaitStable,
+	}
+
+	operationReq := &service.OperationRequest{
+		DeviceID:      deviceID,
+		OperationType: model.OperationTypeWeigh,
+		Data:          operationData,
+		Priority:      req.Priority,
+		Metadata:      req.Metadata,
+	}
+
+	operationReq.IncludeRaw = includeRaw(c)
+	operationReq.LockToken = lockToken(c)
+
+	response, err := h.operationService.ExecuteOperation(c.Request.Context(), operationReq)
+	if err != nil {
+		h.logger.Error("Failed to execute weigh operation", zap.Error(err))
+		respondExecuteError(c, "Failed to read weight", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Weight read", response)
+}
+
+// BeepOperation executes beep operation
+// @Summary Sound buzzer
+// @Description Sound the buzzer of a device with the BEEP capability
+// @Tags Operations
+// @Accept json
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Param request body BeepRequest false "Beep request"
+// @Param include query string false "Set to raw to include the base64 raw device response"
+// @Param X-Device-Lock-Token header string false "Lock token, required while the device is locked"
+// @Success 200 {object} utils.APIResponse{data=service.OperationResponse} "Beep operation completed"
+// @Failure 400 {object} utils.APIResponse "Invalid request or device has no buzzer"
+// @Failure 500 {object} utils.APIResponse "Beep operation failed"
+// @Router /devices/{device_id}/beep [post]
+func (h *OperationHandler) BeepOperation(c *gin.Context) {
+	deviceIDStr := c.Param("device_id")
+	deviceID, err := uuid.Parse(deviceIDStr)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid device ID", err)
+		return
+	}
+
+	// The body is optional; an empty one beeps once for 100 ms
+	var req BeepRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	operationData := map[string]interface{}{}
+	if req.Count != nil {
+		if *req.Count < 1 || *req.Count > maxBeepCount {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid beep count", fmt.Errorf("count must be between 1 and %d", maxBeepCount))
+			return
+		}
+		operationData["count"] = *req.Count
+	}
+	if req.Duration != nil {
+		if *req.Duration < 1 || *req.Duration > maxBeepDurationMs {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid beep duration", fmt.Errorf("duration must be between 1 and %d ms", maxBeepDurationMs))
+			return
+		}
+		operationData["duration"] = *req.Duration
+	}
+
+	operationReq := &service.OperationRequest{
+		DeviceID:      deviceID,
+		OperationType: model.OperationTypeBeep,
+		Data:          operationData,
+		Priority:      req.Priority,
+		Metadata:      req.Metadata,
+	}
+
+	operationReq.IncludeRaw = includeRaw(c)
+	operationReq.LockToken = lockToken(c)
+
+	response, err := h.operationService.ExecuteOperation(c.Request.Context(), operationReq)
+	if err != nil {
+		h.logger.Error("Failed to execute beep operation", zap.Error(err))
+		respondExecuteError(c, "Failed to beep", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Beep operation completed", response)
+}
+
+// SelfTestOperation runs a device self-test
+// @Summary Run device self-test
+// @Description Run the sub-tests that apply to the device's capabilities (status read, test print, cutter, drawer pulse, buzzer) and report each as passed, failed or skipped
+// @Tags Operations
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Success 200 {object} utils.APIResponse{data=service.SelfTestReport} "Self-test completed"
+// @Failure 400 {object} utils.APIResponse "Invalid device ID"
+// @Failure 404 {object} utils.APIResponse "Device not found"
+// @Failure 409 {object} utils.APIResponse "Device is not online"
+// @Param X-Device-Lock-Token header string false "Lock token, required while the device is locked"
+// @Router /devices/{device_id}/self-test [post]
+func (h *OperationHandler) SelfTestOperation(c *gin.Context) {
+	deviceIDStr := c.Param("device_id")
+	deviceID, err := uuid.Parse(deviceIDStr)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid device ID", err)
+		return
+	}
+
+	report, err := h.operationService.SelfTest(c.Request.Context(), deviceID, lockToken(c))
+	if err != nil {
+		if errors.Is(err, service.ErrSelfTestNotAllowed) {
+			utils.ErrorResponse(c, http.StatusConflict, "Self-test not allowed", err)
+			return
+		}
+		h.logger.Error("Failed to run self-test", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusNotFound, "Device not found", err)
+		return
+	}
+
+	message := "Self-test passed"
+	if !report.Passed {
+		message = "Self-test failed"
+	}
+	utils.SuccessResponse(c, http.StatusOK, message, report)
+}
+
 // DisplayOperation executes display operation
 // @Summary Display text
 // @Description Display text on customer display
@@ -287,6 +532,8 @@ func (h *OperationHandler) OpenDrawerOperation(c *gin.Context) {
 // @Produce json
 // @Param device_id path string true "Device ID"
 // @Param request body DisplayRequest true "Display request"
+// @Param include query string false "Set to raw to include the base64 raw device response"
+// @Param X-Device-Lock-Token header string false "Lock token, required while the device is locked"
 // @Success 200 {object} utils.APIResponse{data=service.OperationResponse} "Display operation completed"
 // @Failure 400 {object} utils.APIResponse "Invalid request"
 // @Failure 500 {object} utils.APIResponse "Display operation failed"
@@ -316,13 +563,17 @@ func (h *OperationHandler) DisplayOperation(c *gin.Context) {
 		DeviceID:      deviceID,
 		OperationType: model.OperationTypeDisplayText,
 		Data:          operationData,
-		Priority:      model.PriorityNormal,
+		Priority:      req.Priority,
+		Metadata:      req.Metadata,
 	}
 
+	operationReq.IncludeRaw = includeRaw(c)
+	operationReq.LockToken = lockToken(c)
+
 	response, err := h.operationService.ExecuteOperation(c.Request.Context(), operationReq)
 	if err != nil {
 		h.logger.Error("Failed to execute display operation", zap.Error(err))
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to display text", err)
+		respondExecuteError(c, "Failed to display text", err)
 		return
 	}
 
@@ -331,17 +582,17 @@ func (h *OperationHandler) DisplayOperation(c *gin.Context) {
 
 // GetOperation retrieves operation by ID
 // @Summary Get operation details
-// @Description Get operation details and status by operation ID
+// @Description Get operation details and status by operation ID. progress reports the live state: queued (with queue_position and estimated_wait_seconds), processing, or the final status; the same progress is pushed as operation events over WebSocket.
 // @Tags Operations
 // @Accept json
 // @Produce json
-// @Param id path string true "Operation ID"
+// @Param operation_id path string true "Operation ID"
 // @Success 200 {object} utils.APIResponse{data=model.DeviceOperation} "Operation retrieved successfully"
 // @Failure 400 {object} utils.APIResponse "Invalid operation ID"
 // @Failure 404 {object} utils.APIResponse "Operation not found"
-// @Router /operations/{id} [get]
+// @Router /operations/{operation_id} [get]
 func (h *OperationHandler) GetOperation(c *gin.Context) {
-	idStr := c.Param("id")
+	idStr := c.Param("operation_id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid operation ID", err)
@@ -367,14 +618,337 @@ func (h *OperationHandler) GetOperation(c *gin.Context) {
 // @Param page query int false "Page number" default(1)
 // @Param per_page query int false "Items per page" default(20)
 // @Param device_id query string false "Filter by device ID"
-// @Param operation_type query string false "Filter by operation type" Enums(PRINT, PAYMENT, SCAN, STATUS_CHECK, OPEN_DRAWER, DISPLAY_TEXT, BEEP, REFUND, CUT)
+// @Param operation_type query string false "Filter by operation type" Enums(PRINT, PAYMENT, SCAN, STATUS_CHECK, OPEN_DRAWER, DISPLAY_TEXT, BEEP, REFUND, CUT, WEIGH)
 // @Param status query string false "Filter by status" Enums(PENDING, PROCESSING, SUCCESS, FAILED, TIMEOUT, CANCELLED)
+// @Param correlation_id query string false "Filter by correlation ID"
+// @Param error_contains query string false "Case-insensitive substring of the error message"
+// @Param source query string false "Filter by the channel the operation was started through" Enums(REST, WEBSOCKET, OFFLINE_SYNC, REPLAY, RECOVERY)
+// @Param metadata.order_id query string false "Filter by a metadata tag; any metadata.<key> is accepted and all must match"
 // @Param start_date query string false "Start date filter (RFC3339)"
 // @Param end_date query string false "End date filter (RFC3339)"
 // @Success 200 {object} utils.APIResponse{data=object{operations=[]model.DeviceOperation,pagination=service.PaginationResult}} "Operations retrieved successfully"
+// @Failure 400 {object} utils.APIResponse "Invalid filter"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /operations [get]
 func (h *OperationHandler) ListOperations(c *gin.Context) {
+	filter, err := parseOperationFilter(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid filter", err)
+		return
+	}
+
+	operations, pagination, err := h.operationService.ListOperations(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Failed to list operations", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to list operations", err)
+		return
+	}
+
+	response := gin.H{
+		"operations": operations,
+		"pagination": pagination,
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Operations retrieved successfully", response)
+}
+
+// GetDeviceOperationSummary returns operation statistics of a device
+// @Summary Get device operation summary
+// @Description Total operations, success rate, error count, average response time and last operation time of a device over a period
+// @Tags Operations
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Param period query string false "Period as a Go duration, e.g. 1h or 168h" default(24h)
+// @Success 200 {object} utils.APIResponse{data=service.DeviceOperationSummary} "Operation summary retrieved successfully"
+// @Failure 400 {object} utils.APIResponse "Invalid parameters"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /devices/{device_id}/operations/summary [get]
+func (h *OperationHandler) GetDeviceOperationSummary(c *gin.Context) {
+	deviceID, err := uuid.Parse(c.Param("device_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid device ID", err)
+		return
+	}
+
+	period, err := time.ParseDuration(c.DefaultQuery("period", "24h"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid period, expected a duration such as 24h", err)
+		return
+	}
+
+	summary, err := h.operationService.GetDeviceOperationSummary(c.Request.Context(), deviceID, period)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSummaryPeriod) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid period", err)
+			return
+		}
+		h.logger.Error("Failed to get operation summary", zap.Error(err), zap.String("device_id", deviceID.String()))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get operation summary", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Operation summary retrieved successfully", summary)
+}
+
+// GetLatestDeviceOperations returns the newest operations of a device
+// @Summary Get latest device operations
+// @Description The newest operations of a device with their status, newest first, without paging. Cheaper than listing operations, e.g. for a "last print" display.
+// @Tags Operations
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Param count query int false "Number of operations (1-50)" default(1)
+// @Success 200 {object} utils.APIResponse{data=[]model.DeviceOperation} "Latest operations retrieved successfully"
+// @Failure 400 {object} utils.APIResponse "Invalid parameters"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /devices/{device_id}/operations/latest [get]
+func (h *OperationHandler) GetLatestDeviceOperations(c *gin.Context) {
+	deviceID, err := uuid.Parse(c.Param("device_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid device ID", err)
+		return
+	}
+
+	count, err := strconv.Atoi(c.DefaultQuery("count", "1"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid count", err)
+		return
+	}
+
+	operations, err := h.operationService.GetLatestOperations(c.Request.Context(), deviceID, count)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidLatestCount) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid count", err)
+			return
+		}
+		h.logger.Error("Failed to get latest operations", zap.Error(err), zap.String("device_id", deviceID.String()))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get latest operations", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Latest operations retrieved successfully", operations)
+}
+
+// GetDeviceQueue returns the operation queue of a device
+// @Summary Get device operation queue
+// @Description Number of operations waiting for a device, age of the oldest one and recent average wait time
+// @Tags Operations
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Success 200 {object} utils.APIResponse{data=service.DeviceQueueStats} "Device queue retrieved successfully"
+// @Failure 400 {object} utils.APIResponse "Invalid device ID"
+// @Router /devices/{device_id}/queue [get]
+func (h *OperationHandler) GetDeviceQueue(c *gin.Context) {
+	deviceID, err := uuid.Parse(c.Param("device_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid device ID", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Device queue retrieved successfully", h.operationService.GetDeviceQueue(deviceID))
+}
+
+// LockDevice reserves a device for exclusive use
+// @Summary Lock device
+// @Description Reserve a device so only requests presenting the returned token (X-Device-Lock-Token header) run operations on it. The lease expires after its TTL unless renewed by locking again with the token.
+// @Tags Operations
+// @Accept json
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Param request body LockDeviceRequest false "Lock options"
+// @Success 200 {object} utils.APIResponse{data=service.DeviceLock} "Device locked"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 404 {object} utils.APIResponse "Device not found"
+// @Failure 409 {object} utils.APIResponse "Lease expired, lock again without a token"
+// @Failure 423 {object} utils.APIResponse "Device locked by another client"
+// @Failure 500 {object} utils.APIResponse "Lock failed"
+// @Router /devices/{device_id}/lock [post]
+func (h *OperationHandler) LockDevice(c *gin.Context) {
+	deviceID, err := uuid.Parse(c.Param("device_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid device ID", err)
+		return
+	}
+
+	var req LockDeviceRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	lock, err := h.operationService.LockDevice(c.Request.Context(), deviceID, &service.DeviceLockRequest{
+		TTL:    time.Duration(req.TTLSeconds) * time.Second,
+		Holder: req.Holder,
+		Token:  req.Token,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidLockTTL):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid lock TTL", err)
+		case errors.Is(err, service.ErrDeviceLocked):
+			utils.ErrorResponse(c, http.StatusLocked, "Device locked", err)
+		case errors.Is(err, service.ErrLockNotHeld):
+			utils.ErrorResponse(c, http.StatusConflict, "Device lock not held", err)
+		case errors.Is(err, service.ErrDeviceNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "Device not found", err)
+		default:
+			h.logger.Error("Failed to lock device", zap.Error(err), zap.String("device_id", deviceID.String()))
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to lock device", err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Device locked", lock)
+}
+
+// UnlockDevice releases a device lock
+// @Summary Unlock device
+// @Description Release the lock of a device. Succeeds when the device is no longer locked.
+// @Tags Operations
+// @Produce json
+// @Param device_id path string true "Device ID"
+// @Param X-Device-Lock-Token header string true "Lock token"
+// @Success 200 {object} utils.APIResponse "Device unlocked"
+// @Failure 400 {object} utils.APIResponse "Invalid device ID"
+// @Failure 409 {object} utils.APIResponse "Lock held with another token"
+// @Router /devices/{device_id}/lock [delete]
+func (h *OperationHandler) UnlockDevice(c *gin.Context) {
+	deviceID, err := uuid.Parse(c.Param("device_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid device ID", err)
+		return
+	}
+
+	if err := h.operationService.UnlockDevice(c.Request.Context(), deviceID, lockToken(c)); err != nil {
+		utils.ErrorResponse(c, http.StatusConflict, "Device lock not held", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Device unlocked", gin.H{"device_id": deviceID})
+}
+
+// ListDeviceQueues returns the operation queues of all devices
+// @Summary List device operation queues
+// @Description Operation queue depth and wait times of every device that ran operations, deepest queue first
+// @Tags Operations
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=[]service.DeviceQueueStats} "Device queues retrieved successfully"
+// @Router /operations/queues [get]
+func (h *OperationHandler) ListDeviceQueues(c *gin.Context) {
+	utils.SuccessResponse(c, http.StatusOK, "Device queues retrieved successfully", h.operationService.ListDeviceQueues())
+}
+
+// GetOperationTimeSeries returns time-bucketed operation statistics
+// @Summary Operation time series
+// @Description Operation counts by status and average duration per hour or day, for dashboards
+// @Tags Operations
+// @Produce json
+// @Param interval query string false "Bucket size: hour or day" default(hour)
+// @Param start query string false "Range start (RFC3339), defaults to 24 buckets before end"
+// @Param end query string false "Range end (RFC3339), defaults to now"
+// @Param device_id query string false "Filter by device ID"
+// @Param source query string false "Filter by the channel operations were started through" Enums(REST, WEBSOCKET, OFFLINE_SYNC, REPLAY, RECOVERY)
+// @Success 200 {object} utils.APIResponse{data=service.OperationTimeSeries} "Operation time series retrieved successfully"
+// @Failure 400 {object} utils.APIResponse "Invalid parameters"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /operations/stats/timeseries [get]
+func (h *OperationHandler) GetOperationTimeSeries(c *gin.Context) {
+	req := &service.TimeSeriesRequest{Interval: strings.ToLower(c.DefaultQuery("interval", service.IntervalHour))}
+
+	if start := c.Query("start"); start != "" {
+		t, err := time.Parse(time.RFC3339, start)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid start time, expected RFC3339", err)
+			return
+		}
+		req.Start = &t
+	}
+	if end := c.Query("end"); end != "" {
+		t, err := time.Parse(time.RFC3339, end)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid end time, expected RFC3339", err)
+			return
+		}
+		req.End = &t
+	}
+
+	if deviceIDStr := c.Query("device_id"); deviceIDStr != "" {
+		deviceID, err := uuid.Parse(deviceIDStr)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid device ID", err)
+			return
+		}
+		req.DeviceID = &deviceID
+	}
+
+	if sourceStr := c.Query("source"); sourceStr != "" {
+		source := model.OperationSource(strings.ToUpper(sourceStr))
+		if !source.IsValid() {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid source", fmt.Errorf("unknown operation source: %s", sourceStr))
+			return
+		}
+		req.Source = &source
+	}
+
+	series, err := h.operationService.GetOperationTimeSeries(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTimeSeries) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid time series request", err)
+			return
+		}
+		h.logger.Error("Failed to get operation time series", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get operation time series", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Operation time series retrieved successfully", series)
+}
+
+// GroupOperationErrors groups failed operations by error message
+// @Summary Group operation errors
+// @Description Group operations matching the filters by error message, most frequent first
+// @Tags Operations
+// @Produce json
+// @Param device_id query string false "Filter by device ID"
+// @Param operation_type query string false "Filter by operation type"
+// @Param correlation_id query string false "Filter by correlation ID"
+// @Param error_contains query string false "Case-insensitive substring of the error message"
+// @Param metadata.order_id query string false "Filter by a metadata tag; any metadata.<key> is accepted and all must match"
+// @Param start_date query string false "Start date filter (RFC3339)"
+// @Param end_date query string false "End date filter (RFC3339)"
+// @Param limit query int false "Maximum number of groups" default(20)
+// @Success 200 {object} utils.APIResponse{data=[]repository.OperationErrorGroup} "Operation errors grouped successfully"
+// @Failure 400 {object} utils.APIResponse "Invalid filter"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /operations/errors [get]
+func (h *OperationHandler) GroupOperationErrors(c *gin.Context) {
+	filter, err := parseOperationFilter(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid filter", err)
+		return
+	}
+
+	limit := 20
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	groups, err := h.operationService.GroupOperationErrors(c.Request.Context(), filter, limit)
+	if err != nil {
+		h.logger.Error("Failed to group operation errors", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to group operation errors", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Operation errors grouped successfully", groups)
+}
+
+// parseOperationFilter builds an operation filter from query parameters. An
+// unknown source is an error, since ignoring it would widen the result.
+func parseOperationFilter(c *gin.Context) (*service.OperationFilter, error) {
 	filter := &service.OperationFilter{
 		Page:      1,
 		PerPage:   20,
@@ -408,6 +982,32 @@ func (h *OperationHandler) ListOperations(c *gin.Context) {
 		s := model.OperationStatus(status)
 		filter.Status = &s
 	}
+	if correlationID := c.Query("correlation_id"); correlationID != "" {
+		if id, err := uuid.Parse(correlationID); err == nil {
+			filter.CorrelationID = &id
+		}
+	}
+	if errorContains := c.Query("error_contains"); errorContains != "" {
+		filter.ErrorContains = &errorContains
+	}
+	if source := c.Query("source"); source != "" {
+		s := model.OperationSource(strings.ToUpper(source))
+		if !s.IsValid() {
+			return nil, fmt.Errorf("unknown operation source: %s", source)
+		}
+		filter.Source = &s
+	}
+	// metadata.<key>=<value> matches operations tagged with that value
+	for param, values := range c.Request.URL.Query() {
+		key := strings.TrimPrefix(param, "metadata.")
+		if key == param || key == "" || len(values) == 0 {
+			continue
+		}
+		if filter.Metadata == nil {
+			filter.Metadata = make(map[string]string)
+		}
+		filter.Metadata[key] = values[0]
+	}
 	if startDate := c.Query("start_date"); startDate != "" {
 		if date, err := time.Parse(time.RFC3339, startDate); err == nil {
 			filter.StartDate = &date
@@ -419,19 +1019,55 @@ func (h *OperationHandler) ListOperations(c *gin.Context) {
 		}
 	}
 
-	operations, pagination, err := h.operationService.ListOperations(c.Request.Context(), filter)
-	if err != nil {
-		h.logger.Error("Failed to list operations", zap.Error(err))
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to list operations", err)
+	return filter, nil
+}
+
+// respondExecuteError sends an operation execution failure, reporting the
+// device error code (e.g. PAPER_OUT) when the driver classified it
+func respondExecuteError(c *gin.Context, message string, err error) {
+	code := service.OperationErrorCode(err)
+	if code == "" {
+		utils.ErrorResponse(c, executeErrorStatus(err), message, err)
 		return
 	}
+	utils.ErrorResponseWithCode(c, executeErrorStatus(err), code, message, err)
+}
 
-	response := gin.H{
-		"operations": operations,
-		"pagination": pagination,
+// executeErrorStatus maps an operation execution error to an HTTP status
+func executeErrorStatus(err error) int {
+	switch pkgdriver.ErrorCode(service.OperationErrorCode(err)) {
+	case pkgdriver.ErrorCodeCapabilityDisabled:
+		return http.StatusUnprocessableEntity
+	case pkgdriver.ErrorCodeInvalidRequest, pkgdriver.ErrorCodeUnsupported:
+		return http.StatusBadRequest
+	case pkgdriver.ErrorCodeTimeout:
+		return http.StatusGatewayTimeout
+	case pkgdriver.ErrorCodeQueueFull:
+		return http.StatusTooManyRequests
+	case pkgdriver.ErrorCodeDeviceLocked:
+		return http.StatusLocked
+	case pkgdriver.ErrorCodeNotPermitted:
+		return http.StatusForbidden
+	case pkgdriver.ErrorCodeDriverNotFound:
+		return http.StatusNotImplemented
 	}
+	return http.StatusInternalServerError
+}
 
-	utils.SuccessResponse(c, http.StatusOK, "Operations retrieved successfully", response)
+// lockToken returns the device lock token presented with a request
+func lockToken(c *gin.Context) string {
+	return c.GetHeader("X-Device-Lock-Token")
+}
+
+// includeRaw reports whether ?include= asks for the raw device response,
+// e.g. include=raw or include=raw,timing
+func includeRaw(c *gin.Context) bool {
+	for _, part := range strings.Split(c.Query("include"), ",") {
+		if strings.EqualFold(strings.TrimSpace(part), "raw") {
+			return true
+		}
+	}
+	return false
 }
 
 // ListDeviceOperations handles device-specific operation listing
@@ -479,14 +1115,15 @@ func (h *OperationHandler) ListDeviceOperations(c *gin.Context) {
 // @Tags Operations
 // @Accept json
 // @Produce json
-// @Param id path string true "Operation ID"
+// @Param operation_id path string true "Operation ID"
 // @Param request body CancelOperationRequest true "Cancel operation request"
 // @Success 200 {object} utils.APIResponse "Operation cancelled successfully"
 // @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 409 {object} utils.APIResponse "Operation already completed"
 // @Failure 500 {object} utils.APIResponse "Cancel failed"
-// @Router /operations/{id}/cancel [put]
+// @Router /operations/{operation_id}/cancel [put]
 func (h *OperationHandler) CancelOperation(c *gin.Context) {
-	idStr := c.Param("id")
+	idStr := c.Param("operation_id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid operation ID", err)
@@ -500,6 +1137,10 @@ func (h *OperationHandler) CancelOperation(c *gin.Context) {
 	}
 
 	if err := h.operationService.CancelOperation(c.Request.Context(), id, req.Reason); err != nil {
+		if errors.Is(err, service.ErrStatusConflict) {
+			utils.ErrorResponse(c, http.StatusConflict, "Operation already completed", err)
+			return
+		}
 		h.logger.Error("Failed to cancel operation", zap.Error(err))
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to cancel operation", err)
 		return
@@ -508,23 +1149,240 @@ func (h *OperationHandler) CancelOperation(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Operation cancelled successfully", gin.H{"operation_id": id})
 }
 
-// Request DTOs for operations
+// ReplayOperation re-executes a stored operation
+// @Summary Replay operation
+// @Description Re-execute a stored operation on the same or another device. Payment and refund operations require allow_payment.
+// @Tags Operations
+// @Accept json
+// @Produce json
+// @Param operation_id path string true "Operation ID"
+// @Param request body ReplayOperationRequest false "Replay options"
+// @Success 200 {object} utils.APIResponse{data=service.OperationResponse} "Operation replayed successfully"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 403 {object} utils.APIResponse "Replay not allowed"
+// @Failure 423 {object} utils.APIResponse "Device locked by another client"
+// @Failure 500 {object} utils.APIResponse "Replay failed"
+// @Param X-Device-Lock-Token header string false "Lock token, required while the device is locked"
+// @Router /operations/{operation_id}/replay [post]
+func (h *OperationHandler) ReplayOperation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("operation_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid operation ID", err)
+		return
+	}
+
+	var req ReplayOperationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	replayReq := &service.ReplayRequest{
+		AllowPayment: req.AllowPayment,
+		LockToken:    lockToken(c),
+	}
+	if req.DeviceID != "" {
+		deviceID, err := uuid.Parse(req.DeviceID)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid device ID", err)
+			return
+		}
+		replayReq.DeviceID = &deviceID
+	}
+	if userID, exists := c.Get("user_id"); exists {
+		replayReq.UserID = userID.(string)
+	}
+
+	response, err := h.operationService.ReplayOperation(c.Request.Context(), id, replayReq)
+	if err != nil {
+		if errors.Is(err, service.ErrReplayNotAllowed) {
+			utils.ErrorResponse(c, http.StatusForbidden, "Replay not allowed", err)
+			return
+		}
+		if errors.Is(err, service.ErrDeviceLocked) {
+			utils.ErrorResponse(c, http.StatusLocked, "Device locked", err)
+			return
+		}
+		h.logger.Error("Failed to replay operation", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to replay operation", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Operation replayed successfully", response)
+}
+
+// BroadcastOperation executes an operation on all matching devices of a branch
+// @Summary Broadcast operation
+// @Description Execute the same operation on every device of a branch matching the device type/capability filter
+// @Tags Operations
+// @Accept json
+// @Produce json
+// @Param branch_id path string true "Branch ID"
+// @Param X-Device-Lock-Token header string false "Lock token, required for devices locked by the caller"
+// @Param request body BroadcastOperationRequest true "Broadcast request"
+// @Success 200 {object} utils.APIResponse{data=service.BroadcastResponse} "Broadcast completed"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 403 {object} utils.APIResponse "Broadcast not allowed"
+// @Failure 500 {object} utils.APIResponse "Broadcast failed"
+// @Router /branches/{branch_id}/broadcast [post]
+func (h *OperationHandler) BroadcastOperation(c *gin.Context) {
+	branchID, err := uuid.Parse(c.Param("branch_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid branch ID", err)
+		return
+	}
+
+	var req BroadcastOperationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	broadcastReq := &service.BroadcastRequest{
+		OperationType: req.OperationType,
+		Data:          req.Data,
+		Priority:      req.Priority,
+		Metadata:      req.Metadata,
+		LockToken:     lockToken(c),
+	}
+	if req.DeviceType != "" {
+		deviceType := model.DeviceType(req.DeviceType)
+		broadcastReq.DeviceType = &deviceType
+	}
+	if req.Capability != "" {
+		capability := model.Capability(req.Capability)
+		broadcastReq.Capability = &capability
+	}
+	if req.CorrelationID != nil {
+		correlationID, err := uuid.Parse(*req.CorrelationID)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid correlation ID", err)
+			return
+		}
+		broadcastReq.CorrelationID = &correlationID
+	}
+
+	response, err := h.operationService.BroadcastOperation(c.Request.Context(), branchID, broadcastReq)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPriority) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid priority", err)
+			return
+		}
+		if errors.Is(err, service.ErrInvalidMetadata) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid metadata", err)
+			return
+		}
+		if errors.Is(err, service.ErrBroadcastNotAllowed) {
+			utils.ErrorResponse(c, http.StatusForbidden, "Broadcast not allowed", err)
+			return
+		}
+		h.logger.Error("Failed to broadcast operation", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to broadcast operation", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Broadcast completed", response)
+}
+
+// ChainOperations executes a chain of dependent operations
+// @Summary Chain operations
+// @Description Execute operations in order under one correlation ID. Each step runs only if the previous ones succeeded; step inputs copy earlier results into its data, e.g. {"auth_code": "0.auth_code"}
+// @Tags Operations
+// @Accept json
+// @Produce json
+// @Param X-Device-Lock-Token header string false "Lock token, required for steps on devices locked by the caller"
+// @Param request body ChainOperationRequest true "Chain request"
+// @Success 200 {object} utils.APIResponse{data=service.ChainResponse} "Chain completed"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 500 {object} utils.APIResponse "Chain failed"
+// @Router /operations/chain [post]
+func (h *OperationHandler) ChainOperations(c *gin.Context) {
+	var req ChainOperationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	chainReq := &service.ChainRequest{
+		Steps:     make([]service.ChainStep, len(req.Steps)),
+		Metadata:  req.Metadata,
+		LockToken: lockToken(c),
+	}
+	for i, step := range req.Steps {
+		deviceID, err := uuid.Parse(step.DeviceID)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid device ID", err)
+			return
+		}
+		chainReq.Steps[i] = service.ChainStep{
+			DeviceID:      deviceID,
+			OperationType: step.OperationType,
+			Data:          step.Data,
+			Priority:      step.Priority,
+			Inputs:        step.Inputs,
+		}
+	}
+	if req.CorrelationID != nil {
+		correlationID, err := uuid.Parse(*req.CorrelationID)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid correlation ID", err)
+			return
+		}
+		chainReq.CorrelationID = &correlationID
+	}
+
+	response, err := h.operationService.ChainOperations(c.Request.Context(), chainReq)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidChain) || errors.Is(err, service.ErrInvalidPriority) ||
+			errors.Is(err, service.ErrInvalidMetadata) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid operation chain", err)
+			return
+		}
+		h.logger.Error("Failed to chain operations", zap.Error(err))
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to chain operations", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Chain completed", response)
+}
+
+// Request DTOs for operations. Their Metadata tags the operation with
+// client values, e.g. an order_id, that are stored and echoed back in the
+// response but never sent to the device; see service.OperationRequest.
 
 // DeviceOperationRequest represents a device operation request
 type DeviceOperationRequest struct {
 	OperationType model.OperationType     `json:"operation_type" binding:"required"`
 	Data          map[string]interface{}  `json:"data" binding:"required"`
-	Priority      model.OperationPriority `json:"priority"`
+	Priority      model.OperationPriority `json:"priority"` // 0 uses the operation type's default
 	CorrelationID *string                 `json:"correlation_id,omitempty"`
+	Metadata      map[string]string       `json:"metadata,omitempty"`
 }
 
 // PrintRequest represents a print operation request
 type PrintRequest struct {
-	Content     string `json:"content" binding:"required"`
-	ContentType string `json:"content_type"`
-	Copies      int    `json:"copies"`
-	Cut         bool   `json:"cut"`
-	OpenDrawer  bool   `json:"open_drawer"`
+	Content      string `json:"content" binding:"required"`
+	ContentType  string `json:"content_type"` // TEXT, HTML, ESC_POS (hex or base64 bytes, needs allow_raw_print), RECEIPT, LABEL (JSON label layout in content) or PDF (base64 in content)
+	Copies       int    `json:"copies"`
+	Cut          *bool  `json:"cut,omitempty"` // defaults to the device's auto_cut setting
+	OpenDrawer   bool   `json:"open_drawer"`
+	Overflow     string `json:"overflow,omitempty"`                                       // WRAP or TRUNCATE long lines
+	LineSpacing  *int   `json:"line_spacing,omitempty" binding:"omitempty,min=0,max=255"` // motion units (0-255), 0 for the printer default
+	Compact      *bool  `json:"compact,omitempty"`                                        // no extra line feeds; defaults to the device setting
+	Encoding     string `json:"encoding,omitempty"`                                       // PC437, PC850, PC852, PC858, PC866, WPC1252, WPC1254 or RAW to send text untranscoded
+	Color        string `json:"color,omitempty"`                                          // BLACK or RED on two-color printers; others print black
+	PDFPages     string `json:"pdf_pages,omitempty"`                                      // PDF pages to print: FIRST (default) or ALL, cut apart
+	Format       string `json:"format,omitempty"`                                         // TEXT layout: FORMATTED (default) or PLAIN to print the content verbatim
+	RawText      bool   `json:"raw_text,omitempty"`                                       // shorthand for format PLAIN
+	Font         string `json:"font,omitempty" binding:"omitempty,oneof=A B a b"`         // A (default, 12x24) or B (9x17, more characters per line); defaults to the device setting
+	AutoFallback bool   `json:"auto_fallback,omitempty"`                                  // print RECEIPT content that isn't a JSON receipt as formatted text instead of failing
+	Verify       bool   `json:"verify,omitempty"`                                         // read back the printer status after printing and fail unless it printed; needs status readback
+	Force        bool   `json:"force,omitempty"`                                          // print even when an identical print was just sent to the device
+
+	Priority model.OperationPriority `json:"priority,omitempty"` // defaults per operation type
+	Metadata map[string]string       `json:"metadata,omitempty"`
 }
 
 // PaymentRequest represents a payment operation request
@@ -534,12 +1392,18 @@ type PaymentRequest struct {
 	PaymentMethod string  `json:"payment_method" binding:"required"`
 	Reference     string  `json:"reference"`
 	Timeout       int     `json:"timeout"`
+
+	Priority model.OperationPriority `json:"priority,omitempty"` // defaults per operation type
+	Metadata map[string]string       `json:"metadata,omitempty"`
 }
 
 // ScanRequest represents a scan operation request
 type ScanRequest struct {
 	ScanType string `json:"scan_type" binding:"required"`
 	Timeout  int    `json:"timeout"`
+
+	Priority model.OperationPriority `json:"priority,omitempty"` // defaults per operation type
+	Metadata map[string]string       `json:"metadata,omitempty"`
 }
 
 // DisplayRequest represents a display operation request
@@ -548,9 +1412,75 @@ type DisplayRequest struct {
 	Line2    string `json:"line2"`
 	Duration int    `json:"duration"`
 	Clear    bool   `json:"clear"`
+
+	Priority model.OperationPriority `json:"priority,omitempty"` // defaults per operation type
+	Metadata map[string]string       `json:"metadata,omitempty"`
+}
+
+// WeighRequest represents a weigh operation request
+type WeighRequest struct {
+	WaitStable *bool `json:"wait_stable,omitempty"` // defaults to true
+
+	Priority model.OperationPriority `json:"priority,omitempty"` // defaults per operation type
+	Metadata map[string]string       `json:"metadata,omitempty"`
+}
+
+// Beep limits, matching what drivers accept
+const (
+	maxBeepCount      = 10
+	maxBeepDurationMs = 2000
+)
+
+// BeepRequest represents a beep operation request
+type BeepRequest struct {
+	Count    *int `json:"count,omitempty"`    // 1-10, defaults to 1
+	Duration *int `json:"duration,omitempty"` // ms per beep, 1-2000, defaults to 100
+
+	Priority model.OperationPriority `json:"priority,omitempty"` // defaults per operation type
+	Metadata map[string]string       `json:"metadata,omitempty"`
+}
+
+// LockDeviceRequest represents a device lock request
+type LockDeviceRequest struct {
+	TTLSeconds int    `json:"ttl_seconds,omitempty"` // lease length, defaults to the configured TTL
+	Holder     string `json:"holder,omitempty"`      // e.g. the cashier station, for operators
+	Token      string `json:"token,omitempty"`       // renews the lease of this lock
 }
 
 // CancelOperationRequest represents an operation cancellation request
 type CancelOperationRequest struct {
 	Reason string `json:"reason" binding:"required"`
 }
+
+// ReplayOperationRequest represents operation replay request
+type ReplayOperationRequest struct {
+	DeviceID     string `json:"device_id,omitempty"`
+	AllowPayment bool   `json:"allow_payment"`
+}
+
+// BroadcastOperationRequest represents a branch broadcast request
+type BroadcastOperationRequest struct {
+	OperationType model.OperationType     `json:"operation_type" binding:"required"`
+	Data          map[string]interface{}  `json:"data" binding:"required"`
+	Priority      model.OperationPriority `json:"priority"`
+	DeviceType    string                  `json:"device_type,omitempty"`
+	Capability    string                  `json:"capability,omitempty"`
+	CorrelationID *string                 `json:"correlation_id,omitempty"`
+	Metadata      map[string]string       `json:"metadata,omitempty"`
+}
+
+// ChainOperationRequest represents an operation chain request
+type ChainOperationRequest struct {
+	Steps         []ChainStepRequest `json:"steps" binding:"required,min=1,dive"`
+	CorrelationID *string            `json:"correlation_id,omitempty"`
+	Metadata      map[string]string  `json:"metadata,omitempty"`
+}
+
+// ChainStepRequest represents one step of an operation chain request
+type ChainStepRequest struct {
+	DeviceID      string                  `json:"device_id" binding:"required"`
+	OperationType model.OperationType     `json:"operation_type" binding:"required"`
+	Data          map[string]interface{}  `json:"data"`
+	Priority      model.OperationPriority `json:"priority"`         // 0 uses the operation type's default
+	Inputs        map[string]string       `json:"inputs,omitempty"` // data key -> "<step>.<result key>"
+}
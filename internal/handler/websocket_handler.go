@@ -4,8 +4,12 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,8 +17,11 @@ import (
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
 
+	"device-service/internal/config"
+	"device-service/internal/model"
 	"device-service/internal/service"
 	"device-service/internal/utils"
+	pkgdriver "device-service/pkg/driver"
 )
 
 // WebSocketHandler manages WebSocket connections for real-time communication
@@ -25,21 +32,37 @@ type WebSocketHandler struct {
 	operationService *service.OperationService
 	logger           *utils.ServiceLogger
 	eventBus         *EventBus
+	pingInterval     time.Duration
+	pongTimeout      time.Duration
+
+	// Tenant, branch and type of devices, cached to route their events
+	scopesMu sync.RWMutex
+	scopes   map[string]deviceScope
+}
+
+// deviceScopeTTL bounds how long a device's cached scope is trusted, so a
+// device moved to another branch is routed correctly again soon
+const deviceScopeTTL = time.Minute
+
+// deviceScope is what routes the events of a device to clients
+type deviceScope struct {
+	tenantID   *uuid.UUID
+	branchID   string
+	deviceType model.DeviceType
+	expires    time.Time
 }
 
 // NewWebSocketHandler creates a new WebSocket handler
 func NewWebSocketHandler(
 	deviceService *service.DeviceService,
 	operationService *service.OperationService,
+	wsConfig config.WebSocketConfig,
 	logger *zap.Logger,
 ) *WebSocketHandler {
 	upgrader := websocket.Upgrader{
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
-		CheckOrigin: func(r *http.Request) bool {
-			// In production, implement proper origin checking
-			return true
-		},
+		CheckOrigin:     checkOrigin(wsConfig.AllowedOrigins),
 	}
 
 	handler := &WebSocketHandler{
@@ -49,6 +72,9 @@ func NewWebSocketHandler(
 		operationService: operationService,
 		logger:           utils.NewServiceLogger(logger, "websocket-handler"),
 		eventBus:         NewEventBus(),
+		pingInterval:     wsConfig.PingInterval,
+		pongTimeout:      wsConfig.PongTimeout,
+		scopes:           make(map[string]deviceScope),
 	}
 
 	// Start event bus
@@ -57,6 +83,36 @@ func NewWebSocketHandler(
 	return handler
 }
 
+// checkOrigin returns the upgrader's origin check. Clients sending no Origin
+// header are not browsers and are accepted; browsers only from the allowed
+// origins ("*" allows any), or from the service's own host when none are set.
+func checkOrigin(allowedOrigins []string) func(r *http.Request) bool {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" || allowed["*"] || allowed[strings.ToLower(origin)] {
+			return true
+		}
+		if len(allowed) > 0 {
+			return false
+		}
+		originURL, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(originURL.Host, r.Host)
+	}
+}
+
+// clientTenant returns the tenant the request's token is scoped to, if any
+func clientTenant(c *gin.Context) *uuid.UUID {
+	if tenantID, ok := model.TenantFromContext(c.Request.Context()); ok {
+		return &tenantID
+	}
+	return nil
+}
+
 // HandleDeviceConnection handles device-specific WebSocket connections
 func (h *WebSocketHandler) HandleDeviceConnection(c *gin.Context) {
 	deviceID := c.Param("device_id")
@@ -65,6 +121,12 @@ func (h *WebSocketHandler) HandleDeviceConnection(c *gin.Context) {
 		return
 	}
 
+	// Devices of other tenants are not found for tenant scoped callers
+	if _, err := h.deviceService.GetDevice(c.Request.Context(), deviceID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "device not found"})
+		return
+	}
+
 	// Upgrade connection
 	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -79,6 +141,7 @@ func (h *WebSocketHandler) HandleDeviceConnection(c *gin.Context) {
 		Send:        make(chan []byte, 256),
 		Type:        "device",
 		DeviceID:    &deviceID,
+		TenantID:    clientTenant(c),
 		UserAgent:   c.Request.UserAgent(),
 		RemoteAddr:  c.Request.RemoteAddr,
 		ConnectedAt: time.Now(),
@@ -92,6 +155,8 @@ func (h *WebSocketHandler) HandleDeviceConnection(c *gin.Context) {
 		zap.String("remote_addr", client.RemoteAddr),
 	)
 
+	h.sendHello(client)
+
 	// Send initial device status
 	go h.sendInitialDeviceStatus(client, deviceID)
 
@@ -113,6 +178,7 @@ func (h *WebSocketHandler) HandleEventConnection(c *gin.Context) {
 		Connection:  conn,
 		Send:        make(chan []byte, 256),
 		Type:        "events",
+		TenantID:    clientTenant(c),
 		UserAgent:   c.Request.UserAgent(),
 		RemoteAddr:  c.Request.RemoteAddr,
 		ConnectedAt: time.Now(),
@@ -123,6 +189,8 @@ func (h *WebSocketHandler) HandleEventConnection(c *gin.Context) {
 		zap.String("client_id", client.ID),
 	)
 
+	h.sendHello(client)
+
 	go h.handleClientRead(client)
 	go h.handleClientWrite(client)
 }
@@ -140,6 +208,7 @@ func (h *WebSocketHandler) HandleOperationConnection(c *gin.Context) {
 		Connection:  conn,
 		Send:        make(chan []byte, 256),
 		Type:        "operations",
+		TenantID:    clientTenant(c),
 		UserAgent:   c.Request.UserAgent(),
 		RemoteAddr:  c.Request.RemoteAddr,
 		ConnectedAt: time.Now(),
@@ -150,6 +219,8 @@ func (h *WebSocketHandler) HandleOperationConnection(c *gin.Context) {
 		zap.String("client_id", client.ID),
 	)
 
+	h.sendHello(client)
+
 	go h.handleClientRead(client)
 	go h.handleClientWrite(client)
 }
@@ -162,6 +233,14 @@ func (h *WebSocketHandler) HandleBranchConnection(c *gin.Context) {
 		return
 	}
 
+	// Events are routed by the device's branch UUID in canonical form
+	parsedBranchID, err := uuid.Parse(branchID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid branch_id"})
+		return
+	}
+	branchID = parsedBranchID.String()
+
 	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		h.logger.Error("Failed to upgrade WebSocket connection", zap.Error(err))
@@ -174,6 +253,7 @@ func (h *WebSocketHandler) HandleBranchConnection(c *gin.Context) {
 		Send:        make(chan []byte, 256),
 		Type:        "branch",
 		BranchID:    &branchID,
+		TenantID:    clientTenant(c),
 		UserAgent:   c.Request.UserAgent(),
 		RemoteAddr:  c.Request.RemoteAddr,
 		ConnectedAt: time.Now(),
@@ -185,21 +265,159 @@ func (h *WebSocketHandler) HandleBranchConnection(c *gin.Context) {
 		zap.String("branch_id", branchID),
 	)
 
+	h.sendHello(client)
+
 	go h.handleClientRead(client)
 	go h.handleClientWrite(client)
 }
 
+// HandleAgentConnection handles heartbeat streams of device agents. The agent
+// authenticates with the device's agent_token as a Bearer token; it isn't
+// accepted in the URL, which proxies and access logs record. The agent then
+// periodically sends heartbeat frames:
+//
+//	{"type": "heartbeat", "data": {"status": "ONLINE"}}
+//
+// While heartbeats arrive the device is not actively pinged.
+func (h *WebSocketHandler) HandleAgentConnection(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	if deviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "device_id is required"})
+		return
+	}
+
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+	device, err := h.deviceService.AuthenticateAgent(c.Request.Context(), deviceID, token)
+	if err != nil {
+		if errors.Is(err, service.ErrAgentUnauthorized) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid agent token"})
+			return
+		}
+		h.logger.Error("Failed to authenticate device agent", zap.Error(err), zap.String("device_id", deviceID))
+		c.JSON(http.StatusNotFound, gin.H{"error": "device not found"})
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		h.logger.Error("Failed to upgrade WebSocket connection", zap.Error(err))
+		return
+	}
+
+	// DeviceID is left unset so device broadcasts are not sent to the agent
+	client := &Client{
+		ID:          uuid.New().String(),
+		Connection:  conn,
+		Send:        make(chan []byte, 256),
+		Type:        "agent",
+		UserAgent:   c.Request.UserAgent(),
+		RemoteAddr:  c.Request.RemoteAddr,
+		ConnectedAt: time.Now(),
+	}
+
+	h.connections.Register(client)
+	h.logger.Info("Device agent connected",
+		zap.String("client_id", client.ID),
+		zap.String("device_id", deviceID),
+		zap.String("remote_addr", client.RemoteAddr),
+	)
+
+	h.sendHello(client)
+
+	go h.handleAgentRead(client, device)
+	go h.handleClientWrite(client)
+}
+
+// handleAgentRead reads heartbeat frames of a device agent. A stream silent
+// for longer than the heartbeat timeout is closed.
+func (h *WebSocketHandler) handleAgentRead(client *Client, device *model.Device) {
+	timeout := h.deviceService.HeartbeatTimeout()
+
+	defer func() {
+		h.deviceService.EndHeartbeatStream(device.DeviceID)
+		h.connections.Unregister(client)
+		client.Connection.Close()
+		h.logger.Info("Device agent disconnected",
+			zap.String("client_id", client.ID),
+			zap.String("device_id", device.DeviceID),
+		)
+	}()
+	defer utils.RecoverPanic(h.logger.Logger, zap.String("goroutine", "websocket_agent_read"), zap.String("client_id", client.ID))
+
+	client.Connection.SetReadDeadline(time.Now().Add(timeout))
+	client.Connection.SetPongHandler(func(string) error {
+		client.Connection.SetReadDeadline(time.Now().Add(timeout))
+		return nil
+	})
+
+	for {
+		_, messageBytes, err := client.Connection.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				h.logger.Warn("Device agent stream ended",
+					zap.Error(err),
+					zap.String("device_id", device.DeviceID),
+				)
+			}
+			return
+		}
+		client.Connection.SetReadDeadline(time.Now().Add(timeout))
+
+		var message struct {
+			Version   int                    `json:"version"`
+			Type      string                 `json:"type"`
+			Data      service.HeartbeatFrame `json:"data"`
+			RequestID string                 `json:"request_id,omitempty"`
+		}
+		if err := json.Unmarshal(messageBytes, &message); err != nil {
+			h.sendErrorCode(client, ErrorCodeInvalidMessage, "Invalid heartbeat frame", "")
+			continue
+		}
+		if !isSupportedProtocolVersion(message.Version) {
+			h.sendUnsupportedVersion(client, message.Version, message.RequestID)
+			continue
+		}
+		if message.Type != MessageTypeHeartbeat {
+			h.sendErrorCode(client, ErrorCodeUnknownType, fmt.Sprintf("Unsupported message type: %s", message.Type), message.RequestID)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err = h.deviceService.RecordHeartbeat(ctx, device, &message.Data)
+		cancel()
+		if err != nil {
+			h.logger.Error("Failed to record heartbeat", zap.Error(err), zap.String("device_id", device.DeviceID))
+			h.sendError(client, err.Error())
+			continue
+		}
+
+		h.sendMessage(client, &WebSocketMessage{
+			Type: MessageTypeHeartbeatAck,
+			Data: map[string]interface{}{
+				"status":          device.Status,
+				"timeout_seconds": int(timeout.Seconds()),
+			},
+			Timestamp: time.Now(),
+			RequestID: message.RequestID,
+		})
+	}
+}
+
 // handleClientRead handles reading messages from WebSocket client
 func (h *WebSocketHandler) handleClientRead(client *Client) {
 	defer func() {
+		// Stop the scan session before Unregister closes the send channel
+		h.closeScanSession(client)
 		h.connections.Unregister(client)
 		client.Connection.Close()
 	}()
+	defer utils.RecoverPanic(h.logger.Logger, zap.String("goroutine", "websocket_read"), zap.String("client_id", client.ID))
 
 	// Set read deadline and pong handler
-	client.Connection.SetReadDeadline(time.Now().Add(60 * time.Second))
+	client.Connection.SetReadDeadline(time.Now().Add(h.pongTimeout))
 	client.Connection.SetPongHandler(func(string) error {
-		client.Connection.SetReadDeadline(time.Now().Add(60 * time.Second))
+		client.Connection.SetReadDeadline(time.Now().Add(h.pongTimeout))
 		return nil
 	})
 
@@ -222,6 +440,13 @@ func (h *WebSocketHandler) handleClientRead(client *Client) {
 				zap.Error(err),
 				zap.String("client_id", client.ID),
 			)
+			h.sendErrorCode(client, ErrorCodeInvalidMessage, "Invalid message envelope", "")
+			continue
+		}
+
+		// Reject unknown versions before interpreting Data
+		if !isSupportedProtocolVersion(message.Version) {
+			h.sendUnsupportedVersion(client, message.Version, message.RequestID)
 			continue
 		}
 
@@ -232,11 +457,12 @@ func (h *WebSocketHandler) handleClientRead(client *Client) {
 
 // handleClientWrite handles writing messages to WebSocket client
 func (h *WebSocketHandler) handleClientWrite(client *Client) {
-	ticker := time.NewTicker(54 * time.Second)
+	ticker := time.NewTicker(h.pingInterval)
 	defer func() {
 		ticker.Stop()
 		client.Connection.Close()
 	}()
+	defer utils.RecoverPanic(h.logger.Logger, zap.String("goroutine", "websocket_write"), zap.String("client_id", client.ID))
 
 	for {
 		select {
@@ -267,22 +493,28 @@ func (h *WebSocketHandler) handleClientWrite(client *Client) {
 // handleClientMessage handles incoming client messages
 func (h *WebSocketHandler) handleClientMessage(client *Client, message *WebSocketMessage) {
 	switch message.Type {
-	case "subscribe":
+	case MessageTypeSubscribe:
 		h.handleSubscription(client, message)
-	case "unsubscribe":
+	case MessageTypeUnsubscribe:
 		h.handleUnsubscription(client, message)
-	case "device_command":
+	case MessageTypeDeviceCommand:
 		h.handleDeviceCommand(client, message)
-	case "ping":
+	case MessageTypeScanSessionStart:
+		h.handleScanSessionStart(client, message)
+	case MessageTypeScanSessionEnd:
+		h.handleScanSessionEnd(client, message)
+	case MessageTypePing:
 		h.sendMessage(client, &WebSocketMessage{
-			Type:      "pong",
+			Type:      MessageTypePong,
 			Timestamp: time.Now(),
+			RequestID: message.RequestID,
 		})
 	default:
 		h.logger.Warn("Unknown message type",
 			zap.String("type", message.Type),
 			zap.String("client_id", client.ID),
 		)
+		h.sendErrorCode(client, ErrorCodeUnknownType, fmt.Sprintf("Unknown message type: %s", message.Type), message.RequestID)
 	}
 }
 
@@ -294,6 +526,20 @@ func (h *WebSocketHandler) handleSubscription(client *Client, message *WebSocket
 
 	if data, ok := message.Data.(map[string]interface{}); ok {
 		if topic, ok := data["topic"].(string); ok {
+			confirmation := map[string]interface{}{
+				"topic": topic,
+			}
+
+			if topic == TopicDeviceTypes {
+				deviceTypes, err := h.parseDeviceTypeFilter(client, data["device_types"])
+				if err != nil {
+					h.sendErrorCode(client, ErrorCodeInvalidMessage, err.Error(), message.RequestID)
+					return
+				}
+				client.SetDeviceTypes(deviceTypes)
+				confirmation["device_types"] = deviceTypes
+			}
+
 			client.Subscriptions[topic] = true
 			h.logger.Info("Client subscribed to topic",
 				zap.String("client_id", client.ID),
@@ -302,16 +548,38 @@ func (h *WebSocketHandler) handleSubscription(client *Client, message *WebSocket
 
 			// Send subscription confirmation
 			h.sendMessage(client, &WebSocketMessage{
-				Type: "subscription_confirmed",
-				Data: map[string]interface{}{
-					"topic": topic,
-				},
+				Type:      MessageTypeSubscriptionConfirmed,
+				Data:      confirmation,
 				Timestamp: time.Now(),
 			})
 		}
 	}
 }
 
+// parseDeviceTypeFilter reads the device types of a device_types
+// subscription, which only branch connections accept
+func (h *WebSocketHandler) parseDeviceTypeFilter(client *Client, value interface{}) ([]model.DeviceType, error) {
+	if client.BranchID == nil {
+		return nil, fmt.Errorf("%s subscriptions are only available on branch connections", TopicDeviceTypes)
+	}
+
+	values, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("device_types must be a list of device types")
+	}
+
+	deviceTypes := make([]model.DeviceType, 0, len(values))
+	for _, v := range values {
+		name, ok := v.(string)
+		deviceType := model.DeviceType(strings.ToUpper(name))
+		if !ok || !deviceType.IsValid() {
+			return nil, fmt.Errorf("unknown device type: %v", v)
+		}
+		deviceTypes = append(deviceTypes, deviceType)
+	}
+	return deviceTypes, nil
+}
+
 // handleUnsubscription handles client unsubscription requests
 func (h *WebSocketHandler) handleUnsubscription(client *Client, message *WebSocketMessage) {
 	if client.Subscriptions == nil {
@@ -321,6 +589,9 @@ func (h *WebSocketHandler) handleUnsubscription(client *Client, message *WebSock
 	if data, ok := message.Data.(map[string]interface{}); ok {
 		if topic, ok := data["topic"].(string); ok {
 			delete(client.Subscriptions, topic)
+			if topic == TopicDeviceTypes {
+				client.SetDeviceTypes(nil)
+			}
 			h.logger.Info("Client unsubscribed from topic",
 				zap.String("client_id", client.ID),
 				zap.String("topic", topic),
@@ -354,8 +625,11 @@ func (h *WebSocketHandler) handleDeviceCommand(client *Client, message *WebSocke
 
 // executeDeviceCommand executes a device command
 func (h *WebSocketHandler) executeDeviceCommand(client *Client, deviceID, command string, data map[string]interface{}) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer utils.RecoverPanic(h.logger.Logger, zap.String("goroutine", "websocket_command"), zap.String("device_id", deviceID))
+
+	ctx, cancel := context.WithTimeout(client.scopedContext(context.Background()), 30*time.Second)
 	defer cancel()
+	ctx = model.WithOperationSource(ctx, model.OperationSourceWebSocket)
 
 	var err error
 	var result interface{}
@@ -386,7 +660,7 @@ func (h *WebSocketHandler) executeDeviceCommand(client *Client, deviceID, comman
 
 	// Send response
 	response := &WebSocketMessage{
-		Type: "command_response",
+		Type: MessageTypeCommandResponse,
 		Data: map[string]interface{}{
 			"command": command,
 			"success": err == nil,
@@ -402,9 +676,144 @@ func (h *WebSocketHandler) executeDeviceCommand(client *Client, deviceID, comman
 	h.sendMessage(client, response)
 }
 
-// sendInitialDeviceStatus sends initial device status to client
+// handleScanSessionStart starts a continuous scan session streaming every
+// barcode the device reads to the client, one session per client
+func (h *WebSocketHandler) handleScanSessionStart(client *Client, message *WebSocketMessage) {
+	if client.DeviceID == nil {
+		h.sendErrorCode(client, ErrorCodeInvalidMessage, "scan_session_start only available on device connections", message.RequestID)
+		return
+	}
+
+	req := &service.ScanSessionRequest{}
+	if data, ok := message.Data.(map[string]interface{}); ok {
+		if seconds, ok := data["idle_timeout_seconds"].(float64); ok && seconds > 0 {
+			req.IdleTimeout = time.Duration(seconds * float64(time.Second))
+		}
+		if token, ok := data["lock_token"].(string); ok {
+			req.LockToken = token
+		}
+	}
+
+	client.scanMu.Lock()
+	if client.scanCancel != nil {
+		client.scanMu.Unlock()
+		h.sendErrorCode(client, ErrorCodeScanSession, "scan session already running", message.RequestID)
+		return
+	}
+	ctx, cancel := context.WithCancel(client.scopedContext(context.Background()))
+	client.scanCancel = cancel
+	client.scanMu.Unlock()
+
+	go h.runScanSession(ctx, client, *client.DeviceID, req, message.RequestID)
+}
+
+// handleScanSessionEnd ends the client's scan session; the session reports
+// its summary in a scan_session_ended frame
+func (h *WebSocketHandler) handleScanSessionEnd(client *Client, message *WebSocketMessage) {
+	client.scanMu.Lock()
+	cancel := client.scanCancel
+	client.scanMu.Unlock()
+
+	if cancel == nil {
+		h.sendErrorCode(client, ErrorCodeScanSession, "no scan session running", message.RequestID)
+		return
+	}
+	cancel()
+}
+
+// runScanSession runs a scan session and relays its frames to the client
+func (h *WebSocketHandler) runScanSession(ctx context.Context, client *Client, deviceID string, req *service.ScanSessionRequest, requestID string) {
+	defer utils.RecoverPanic(h.logger.Logger, zap.String("goroutine", "websocket_scan_session"), zap.String("device_id", deviceID))
+	defer func() {
+		client.scanMu.Lock()
+		client.scanCancel()
+		client.scanCancel = nil
+		client.scanMu.Unlock()
+	}()
+
+	sequence := 0
+	summary, err := h.operationService.RunScanSession(ctx, deviceID, req, service.ScanSessionHandlers{
+		OnStart: func(idleTimeout, maxDuration time.Duration) {
+			h.sendScanSessionMessage(client, &WebSocketMessage{
+				Type: MessageTypeScanSessionStarted,
+				Data: map[string]interface{}{
+					"device_id":            deviceID,
+					"idle_timeout_seconds": idleTimeout.Seconds(),
+					"max_duration_seconds": maxDuration.Seconds(),
+				},
+				Timestamp: time.Now(),
+				RequestID: requestID,
+			})
+		},
+		OnScan: func(result *pkgdriver.ScanResult) {
+			sequence++
+			h.sendScanSessionMessage(client, &WebSocketMessage{
+				Type: MessageTypeScanResult,
+				Data: &ScanResultData{
+					DeviceID:  deviceID,
+					Sequence:  sequence,
+					Data:      result.Data,
+					Type:      string(result.Type),
+					Quality:   result.Quality,
+					Timestamp: result.Timestamp,
+				},
+				Timestamp: time.Now(),
+				RequestID: requestID,
+			})
+		},
+	})
+	if err != nil {
+		h.logger.Warn("Failed to start scan session", zap.String("device_id", deviceID), zap.Error(err))
+		h.sendScanSessionMessage(client, &WebSocketMessage{
+			Type: MessageTypeError,
+			Data: &ErrorData{
+				Error: fmt.Sprintf("failed to start scan session: %v", err),
+				Code:  ErrorCodeScanSession,
+			},
+			Timestamp: time.Now(),
+			RequestID: requestID,
+		})
+		return
+	}
+
+	h.sendScanSessionMessage(client, &WebSocketMessage{
+		Type:      MessageTypeScanSessionEnded,
+		Data:      summary,
+		Timestamp: time.Now(),
+		RequestID: requestID,
+	})
+}
+
+// sendScanSessionMessage sends a scan session frame unless the client has
+// gone away
+func (h *WebSocketHandler) sendScanSessionMessage(client *Client, message *WebSocketMessage) {
+	client.scanMu.Lock()
+	defer client.scanMu.Unlock()
+
+	if client.scanClosed {
+		return
+	}
+	h.sendMessage(client, message)
+}
+
+// closeScanSession ends a client's scan session and stops it sending frames
+func (h *WebSocketHandler) closeScanSession(client *Client) {
+	client.scanMu.Lock()
+	defer client.scanMu.Unlock()
+
+	client.scanClosed = true
+	if client.scanCancel != nil {
+		client.scanCancel()
+	}
+}
+
+// sendInitialDeviceStatus sends initial device status to client: the stored
+// device, its health, the live connection state of its driver and, for a
+// connected printer, its real-time paper and cover status
 func (h *WebSocketHandler) sendInitialDeviceStatus(client *Client, deviceID string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer utils.RecoverPanic(h.logger.Logger, zap.String("goroutine", "websocket_initial_status"), zap.String("device_id", deviceID))
+
+	ctx, cancel := context.WithTimeout(client.scopedContext(context.Background()), 10*time.Second)
 	defer cancel()
 
 	device, err := h.deviceService.GetDevice(ctx, deviceID)
@@ -418,12 +827,33 @@ func (h *WebSocketHandler) sendInitialDeviceStatus(client *Client, deviceID stri
 		h.logger.Error("Failed to get device health", zap.Error(err))
 	}
 
+	data := map[string]interface{}{
+		"device": device,
+		"health": health,
+	}
+
+	connection, err := h.deviceService.GetConnectionState(ctx, deviceID)
+	if err != nil {
+		h.logger.Error("Failed to get device connection state", zap.Error(err))
+	} else {
+		data["connection"] = connection
+	}
+
+	// Printers without a live connection or status readback simply have no
+	// printer_status in the frame
+	if device.DeviceType == model.DeviceTypePrinter && connection != nil && connection.Connected {
+		printerStatus, err := h.deviceService.GetPrinterStatus(ctx, deviceID)
+		switch {
+		case err == nil:
+			data["printer_status"] = printerStatus
+		case !errors.Is(err, service.ErrDeviceNotConnected) && !errors.Is(err, service.ErrPrinterStatusUnsupported):
+			h.logger.Warn("Failed to get printer status", zap.String("device_id", deviceID), zap.Error(err))
+		}
+	}
+
 	message := &WebSocketMessage{
-		Type: "initial_status",
-		Data: map[string]interface{}{
-			"device": device,
-			"health": health,
-		},
+		Type:      MessageTypeInitialStatus,
+		Data:      data,
 		Timestamp: time.Now(),
 	}
 
@@ -432,6 +862,7 @@ func (h *WebSocketHandler) sendInitialDeviceStatus(client *Client, deviceID stri
 
 // sendMessage sends a message to a client
 func (h *WebSocketHandler) sendMessage(client *Client, message *WebSocketMessage) {
+	message.Version = ProtocolVersion
 	messageBytes, err := json.Marshal(message)
 	if err != nil {
 		h.logger.Error("Failed to marshal WebSocket message", zap.Error(err))
@@ -449,20 +880,72 @@ func (h *WebSocketHandler) sendMessage(client *Client, message *WebSocketMessage
 
 // sendError sends an error message to a client
 func (h *WebSocketHandler) sendError(client *Client, errorMsg string) {
-	message := &WebSocketMessage{
-		Type: "error",
-		Data: map[string]interface{}{
-			"error": errorMsg,
+	h.sendErrorCode(client, "", errorMsg, "")
+}
+
+// sendErrorCode sends an error message with a machine readable code
+func (h *WebSocketHandler) sendErrorCode(client *Client, code, errorMsg, requestID string) {
+	h.sendMessage(client, &WebSocketMessage{
+		Type: MessageTypeError,
+		Data: &ErrorData{
+			Error: errorMsg,
+			Code:  code,
+		},
+		Timestamp: time.Now(),
+		RequestID: requestID,
+	})
+}
+
+// sendUnsupportedVersion tells a client its envelope version is not accepted
+func (h *WebSocketHandler) sendUnsupportedVersion(client *Client, version int, requestID string) {
+	h.logger.Warn("Unsupported WebSocket protocol version",
+		zap.Int("version", version),
+		zap.String("client_id", client.ID),
+	)
+	h.sendMessage(client, &WebSocketMessage{
+		Type: MessageTypeError,
+		Data: &ErrorData{
+			Error:             fmt.Sprintf("unsupported protocol version %d", version),
+			Code:              ErrorCodeUnsupportedVersion,
+			SupportedVersions: SupportedProtocolVersions,
+		},
+		Timestamp: time.Now(),
+		RequestID: requestID,
+	})
+}
+
+// sendHello sends the connection handshake advertising the protocol version
+func (h *WebSocketHandler) sendHello(client *Client) {
+	h.sendMessage(client, &WebSocketMessage{
+		Type: MessageTypeHello,
+		Data: &HelloData{
+			ClientID:          client.ID,
+			ConnectionType:    client.Type,
+			ServerVersion:     ProtocolVersion,
+			SupportedVersions: SupportedProtocolVersions,
+			MessageTypes:      clientMessageTypes(client.Type),
 		},
 		Timestamp: time.Now(),
+	})
+}
+
+// clientMessageTypes returns the message types a client may send on a
+// connection type
+func clientMessageTypes(connectionType string) []string {
+	if connectionType == "agent" {
+		return []string{MessageTypeHeartbeat}
 	}
-	h.sendMessage(client, message)
+	types := []string{MessageTypePing, MessageTypeSubscribe, MessageTypeUnsubscribe}
+	if connectionType == "device" {
+		types = append(types, MessageTypeDeviceCommand, MessageTypeScanSessionStart, MessageTypeScanSessionEnd)
+	}
+	return types
 }
 
 // BroadcastDeviceEvent broadcasts device events to relevant clients
 func (h *WebSocketHandler) BroadcastDeviceEvent(deviceID string, eventType string, data interface{}) {
 	message := &WebSocketMessage{
-		Type: "device_event",
+		Type: MessageTypeDeviceEvent,
 		Data: map[string]interface{}{
 			"device_id":  deviceID,
 			"event_type": eventType,
@@ -471,14 +954,48 @@ func (h *WebSocketHandler) BroadcastDeviceEvent(deviceID string, eventType strin
 		Timestamp: time.Now(),
 	}
 
-	h.broadcastToDeviceClients(deviceID, message)
-	h.broadcastToEventClients(message)
+	scope, ok := h.deviceScope(deviceID)
+	if !ok {
+		h.broadcastToClients(visibleClients(h.connections.GetEventClients(), nil), message)
+		return
+	}
+
+	h.broadcastToDeviceClients(deviceID, scope, message)
+	h.broadcastToEventClients(scope, message)
+	h.broadcastToBranchClients(scope, message)
 }
 
 // BroadcastOperationEvent broadcasts operation events to relevant clients
 func (h *WebSocketHandler) BroadcastOperationEvent(operationID uuid.UUID, deviceID string, eventType string, data interface{}) {
-	message := &WebSocketMessage{
-		Type: "operation_event",
+	scope, ok := h.deviceScope(deviceID)
+	if !ok {
+		message := operationEventMessage(operationID, deviceID, eventType, data)
+		h.broadcastToClients(visibleClients(h.connections.GetOperationClients(), nil), message)
+		return
+	}
+	h.broadcastOperationEvent(operationID, deviceID, scope, eventType, data)
+}
+
+// BroadcastDeviceOperationEvent broadcasts an operation event of a known
+// device to relevant clients, without looking the device up
+func (h *WebSocketHandler) BroadcastDeviceOperationEvent(operationID uuid.UUID, device *model.Device, eventType string, data interface{}) {
+	h.broadcastOperationEvent(operationID, device.DeviceID, h.rememberDevice(device), eventType, data)
+}
+
+// broadcastOperationEvent broadcasts an operation event to the clients the
+// device's scope allows
+func (h *WebSocketHandler) broadcastOperationEvent(operationID uuid.UUID, deviceID string, scope deviceScope, eventType string, data interface{}) {
+	message := operationEventMessage(operationID, deviceID, eventType, data)
+
+	h.broadcastToOperationClients(scope, message)
+	h.broadcastToDeviceClients(deviceID, scope, message)
+	h.broadcastToBranchClients(scope, message)
+}
+
+// operationEventMessage builds an operation_event frame
+func operationEventMessage(operationID uuid.UUID, deviceID string, eventType string, data interface{}) *WebSocketMessage {
+	return &WebSocketMessage{
+		Type: MessageTypeOperationEvent,
 		Data: map[string]interface{}{
 			"operation_id": operationID.String(),
 			"device_id":    deviceID,
@@ -487,31 +1004,97 @@ func (h *WebSocketHandler) BroadcastOperationEvent(operationID uuid.UUID, device
 		},
 		Timestamp: time.Now(),
 	}
-
-	h.broadcastToOperationClients(message)
-	h.broadcastToDeviceClients(deviceID, message)
 }
 
 // broadcastToDeviceClients broadcasts to clients connected to a specific device
-func (h *WebSocketHandler) broadcastToDeviceClients(deviceID string, message *WebSocketMessage) {
+func (h *WebSocketHandler) broadcastToDeviceClients(deviceID string, scope deviceScope, message *WebSocketMessage) {
 	clients := h.connections.GetDeviceClients(deviceID)
-	h.broadcastToClients(clients, message)
+	h.broadcastToClients(visibleClients(clients, scope.tenantID), message)
 }
 
-// broadcastToEventClients broadcasts to all event clients
-func (h *WebSocketHandler) broadcastToEventClients(message *WebSocketMessage) {
+// broadcastToEventClients broadcasts to the event clients of the device's tenant
+func (h *WebSocketHandler) broadcastToEventClients(scope deviceScope, message *WebSocketMessage) {
 	clients := h.connections.GetEventClients()
-	h.broadcastToClients(clients, message)
+	h.broadcastToClients(visibleClients(clients, scope.tenantID), message)
 }
 
-// broadcastToOperationClients broadcasts to all operation clients
-func (h *WebSocketHandler) broadcastToOperationClients(message *WebSocketMessage) {
+// broadcastToOperationClients broadcasts to the operation clients of the
+// device's tenant
+func (h *WebSocketHandler) broadcastToOperationClients(scope deviceScope, message *WebSocketMessage) {
 	clients := h.connections.GetOperationClients()
+	h.broadcastToClients(visibleClients(clients, scope.tenantID), message)
+}
+
+// broadcastToBranchClients broadcasts to clients of the device's branch whose
+// tenant and device type filter accept the device
+func (h *WebSocketHandler) broadcastToBranchClients(scope deviceScope, message *WebSocketMessage) {
+	if scope.branchID == "" || !h.connections.HasBranchClients() {
+		return
+	}
+
+	var clients []*Client
+	for _, client := range h.connections.GetBranchClients(scope.branchID) {
+		if client.SeesTenant(scope.tenantID) && client.AcceptsDeviceType(scope.deviceType) {
+			clients = append(clients, client)
+		}
+	}
 	h.broadcastToClients(clients, message)
 }
 
+// visibleClients returns the clients allowed to see events of a tenant's
+// devices
+func visibleClients(clients []*Client, tenantID *uuid.UUID) []*Client {
+	visible := clients[:0:0]
+	for _, client := range clients {
+		if client.SeesTenant(tenantID) {
+			visible = append(visible, client)
+		}
+	}
+	return visible
+}
+
+// deviceScope returns the tenant, branch and type of a device, looked up at
+// most once per deviceScopeTTL. ok is false for unknown devices.
+func (h *WebSocketHandler) deviceScope(deviceID string) (deviceScope, bool) {
+	h.scopesMu.RLock()
+	scope, ok := h.scopes[deviceID]
+	h.scopesMu.RUnlock()
+	if ok && time.Now().Before(scope.expires) {
+		return scope, true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	device, err := h.deviceService.GetDevice(ctx, deviceID)
+	if err != nil {
+		h.logger.Warn("Failed to resolve device for broadcast",
+			zap.String("device_id", deviceID),
+			zap.Error(err),
+		)
+		return deviceScope{}, false
+	}
+	return h.rememberDevice(device), true
+}
+
+// rememberDevice caches the scope of a device
+func (h *WebSocketHandler) rememberDevice(device *model.Device) deviceScope {
+	scope := deviceScope{
+		tenantID:   device.TenantID,
+		branchID:   device.BranchID.String(),
+		deviceType: device.DeviceType,
+		expires:    time.Now().Add(deviceScopeTTL),
+	}
+
+	h.scopesMu.Lock()
+	h.scopes[device.DeviceID] = scope
+	h.scopesMu.Unlock()
+	return scope
+}
+
 // broadcastToClients broadcasts message to specified clients
 func (h *WebSocketHandler) broadcastToClients(clients []*Client, message *WebSocketMessage) {
+	message.Version = ProtocolVersion
 	messageBytes, err := json.Marshal(message)
 	if err != nil {
 		h.logger.Error("Failed to marshal broadcast message", zap.Error(err))
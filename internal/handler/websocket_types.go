@@ -2,10 +2,14 @@
 package handler
 
 import (
+	"context"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+
+	"device-service/internal/model"
 )
 
 // Client represents a WebSocket client
@@ -13,23 +17,190 @@ type Client struct {
 	ID            string          `json:"id"`
 	Connection    *websocket.Conn `json:"-"`
 	Send          chan []byte     `json:"-"`
-	Type          string          `json:"type"` // device, events, operations, branch
+	Type          string          `json:"type"` // device, events, operations, branch, agent
 	DeviceID      *string         `json:"device_id,omitempty"`
 	BranchID      *string         `json:"branch_id,omitempty"`
+	TenantID      *uuid.UUID      `json:"tenant_id,omitempty"` // from the caller's token; nil receives events of all tenants
 	UserAgent     string          `json:"user_agent"`
 	RemoteAddr    string          `json:"remote_addr"`
 	ConnectedAt   time.Time       `json:"connected_at"`
 	Subscriptions map[string]bool `json:"subscriptions,omitempty"`
+
+	// Running scan session of a device client. Session frames are sent
+	// under scanMu and only while the client is open, since the send
+	// channel is closed when the client goes away.
+	scanMu     sync.Mutex
+	scanCancel context.CancelFunc
+	scanClosed bool
+
+	// Device types a branch client receives events for; nil receives all
+	filterMu    sync.RWMutex
+	deviceTypes map[model.DeviceType]bool
+}
+
+// SetDeviceTypes limits the device events a client receives to the given
+// types. No types removes the filter.
+func (c *Client) SetDeviceTypes(types []model.DeviceType) {
+	c.filterMu.Lock()
+	defer c.filterMu.Unlock()
+
+	if len(types) == 0 {
+		c.deviceTypes = nil
+		return
+	}
+	c.deviceTypes = make(map[model.DeviceType]bool, len(types))
+	for _, deviceType := range types {
+		c.deviceTypes[deviceType] = true
+	}
+}
+
+// SeesTenant reports whether a client may receive events of devices of the
+// given tenant. Tenant scoped clients never see events of devices they
+// can't resolve to their tenant.
+func (c *Client) SeesTenant(tenantID *uuid.UUID) bool {
+	if c.TenantID == nil {
+		return true
+	}
+	return tenantID != nil && *tenantID == *c.TenantID
+}
+
+// scopedContext returns ctx scoped to the client's tenant, so device lookups
+// and commands made for the client only reach its own devices
+func (c *Client) scopedContext(ctx context.Context) context.Context {
+	if c.TenantID != nil {
+		return model.WithTenant(ctx, *c.TenantID)
+	}
+	return ctx
+}
+
+// AcceptsDeviceType reports whether a client receives events of devices of
+// the given type
+func (c *Client) AcceptsDeviceType(deviceType model.DeviceType) bool {
+	c.filterMu.RLock()
+	defer c.filterMu.RUnlock()
+	return c.deviceTypes == nil || c.deviceTypes[deviceType]
+}
+
+// ProtocolVersion is the WebSocket envelope version sent by the server.
+// Bump it when a message type is removed or its Data schema changes in an
+// incompatible way; adding message types or optional fields does not
+// require a new version.
+const ProtocolVersion = 1
+
+// SupportedProtocolVersions lists the envelope versions accepted from clients
+var SupportedProtocolVersions = []int{1}
+
+// isSupportedProtocolVersion reports whether a client envelope version is
+// accepted. Messages without a version predate versioning and are read as
+// version 1.
+func isSupportedProtocolVersion(version int) bool {
+	if version == 0 {
+		return true
+	}
+	for _, supported := range SupportedProtocolVersions {
+		if version == supported {
+			return true
+		}
+	}
+	return false
 }
 
+// WebSocket message types. Each type has a stable Data schema within a
+// protocol version.
+//
+// Server to client:
+//
+//	hello                  HelloData, sent once on connect
+//	error                  ErrorData
+//	pong                   no data
+//	subscription_confirmed {"topic": string, "device_types": [string]}
+//	command_response       {"command": string, "success": bool, "result": any, "error": string}
+//	initial_status         {"device": Device, "health": DeviceHealth}
+//	scan_session_started   {"device_id": string, "idle_timeout_seconds": number, "max_duration_seconds": number}
+//	scan_result            ScanResultData, one per barcode read
+//	scan_session_ended     ScanSessionSummary
+//	device_event           {"device_id": string, "event_type": string, "data": any}
+//	operation_event        {"operation_id": string, "device_id": string, "event_type": string, "data": any}
+//	heartbeat_ack          {"status": string, "timeout_seconds": int}
+//
+// Client to server:
+//
+//	ping                   no data
+//	subscribe              {"topic": string}, or {"topic": "device_types", "device_types": [string]} on branch connections
+//	unsubscribe            {"topic": string}
+//	device_command         {"command": "connect" | "disconnect" | "test" | "status"}
+//	scan_session_start     {"idle_timeout_seconds": number, "lock_token": string}, device connections only
+//	scan_session_end       no data
+//	heartbeat              HeartbeatFrame, agent connections only
+const (
+	MessageTypeHello                 = "hello"
+	MessageTypeError                 = "error"
+	MessageTypePing                  = "ping"
+	MessageTypePong                  = "pong"
+	MessageTypeSubscribe             = "subscribe"
+	MessageTypeUnsubscribe           = "unsubscribe"
+	MessageTypeSubscriptionConfirmed = "subscription_confirmed"
+	MessageTypeDeviceCommand         = "device_command"
+	MessageTypeCommandResponse       = "command_response"
+	MessageTypeInitialStatus         = "initial_status"
+	MessageTypeDeviceEvent           = "device_event"
+	MessageTypeOperationEvent        = "operation_event"
+	MessageTypeHeartbeat             = "heartbeat"
+	MessageTypeHeartbeatAck          = "heartbeat_ack"
+	MessageTypeScanSessionStart      = "scan_session_start"
+	MessageTypeScanSessionEnd        = "scan_session_end"
+	MessageTypeScanSessionStarted    = "scan_session_started"
+	MessageTypeScanResult            = "scan_result"
+	MessageTypeScanSessionEnded      = "scan_session_ended"
+)
+
+// TopicDeviceTypes is the subscription topic limiting a branch client to
+// events of devices of the listed types. Unsubscribing removes the filter.
+const TopicDeviceTypes = "device_types"
+
+// Error frame codes
+const (
+	ErrorCodeUnsupportedVersion = "UNSUPPORTED_VERSION"
+	ErrorCodeInvalidMessage     = "INVALID_MESSAGE"
+	ErrorCodeUnknownType        = "UNKNOWN_MESSAGE_TYPE"
+	ErrorCodeScanSession        = "SCAN_SESSION_FAILED"
+)
+
 // WebSocketMessage represents a WebSocket message
 type WebSocketMessage struct {
+	Version   int         `json:"version"`
 	Type      string      `json:"type"`
 	Data      interface{} `json:"data,omitempty"`
 	Timestamp time.Time   `json:"timestamp"`
 	RequestID string      `json:"request_id,omitempty"`
 }
 
+// HelloData is the handshake sent to a client after it connects
+type HelloData struct {
+	ClientID          string   `json:"client_id"`
+	ConnectionType    string   `json:"connection_type"`
+	ServerVersion     int      `json:"server_version"`
+	SupportedVersions []int    `json:"supported_versions"`
+	MessageTypes      []string `json:"message_types"`
+}
+
+// ScanResultData is the payload of a scan_result frame
+type ScanResultData struct {
+	DeviceID  string    `json:"device_id"`
+	Sequence  int       `json:"sequence"` // 1 for the first scan of the session
+	Data      string    `json:"data"`
+	Type      string    `json:"type,omitempty"`
+	Quality   int       `json:"quality,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ErrorData is the payload of an error frame
+type ErrorData struct {
+	Error             string `json:"error"`
+	Code              string `json:"code,omitempty"`
+	SupportedVersions []int  `json:"supported_versions,omitempty"`
+}
+
 // ConnectionManager manages WebSocket connections
 type ConnectionManager struct {
 	clients    map[string]*Client
@@ -136,6 +307,19 @@ func (cm *ConnectionManager) GetBranchClients(branchID string) []*Client {
 	return clients
 }
 
+// HasBranchClients reports whether any branch client is connected
+func (cm *ConnectionManager) HasBranchClients() bool {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	for _, client := range cm.clients {
+		if client.BranchID != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // GetStats returns connection statistics
 func (cm *ConnectionManager) GetStats() *ConnectionStats {
 	cm.mutex.RLock()
@@ -1 +1,124 @@
+// internal/middleware/auth_middleware.go
 package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"device-service/internal/config"
+	"device-service/internal/model"
+	"device-service/internal/utils"
+)
+
+// tokenClaims are the JWT claims the service reads
+type tokenClaims struct {
+	Subject   string `json:"sub"`
+	ExpiresAt int64  `json:"exp"`
+	TenantID  string `json:"tenant_id"`
+	Scope     string `json:"scope"` // space separated, e.g. "admin print:raw"
+}
+
+// AuthMiddleware verifies HS256 bearer tokens signed with the JWT secret.
+// The token subject becomes the request's user_id; a tenant_id claim scopes
+// the request context to that tenant, so repositories only return its rows.
+// Only admin tokens may leave out tenant_id to act platform-wide; any other
+// token without it is rejected. The scopes of the scope claim are carried in
+// the request context for model.HasScope.
+func AuthMiddleware(cfg *config.SecurityConfig) gin.HandlerFunc {
+	secret := []byte(cfg.JWTSecret)
+
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Missing bearer token", nil)
+			c.Abort()
+			return
+		}
+
+		claims, err := verifyToken(token, secret, time.Now())
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid token", err)
+			c.Abort()
+			return
+		}
+
+		if claims.Subject != "" {
+			c.Set("user_id", claims.Subject)
+		}
+		if scopes := strings.Fields(claims.Scope); len(scopes) > 0 {
+			c.Request = c.Request.WithContext(model.WithScopes(c.Request.Context(), scopes))
+		}
+
+		if claims.TenantID == "" {
+			// A token without a tenant would see every tenant's rows
+			if !model.HasScope(c.Request.Context(), model.ScopeAdmin) {
+				utils.ErrorResponse(c, http.StatusUnauthorized, "Missing tenant claim", nil)
+				c.Abort()
+				return
+			}
+		} else {
+			tenantID, err := uuid.Parse(claims.TenantID)
+			if err != nil {
+				utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid tenant claim", err)
+				c.Abort()
+				return
+			}
+			c.Set("tenant_id", tenantID)
+			c.Request = c.Request.WithContext(model.WithTenant(c.Request.Context(), tenantID))
+		}
+
+		c.Next()
+	}
+}
+
+// verifyToken checks an HS256 JWT's signature and expiry and returns its claims
+func verifyToken(token string, secret []byte, now time.Time) (*tokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.New("malformed token header")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Alg != "HS256" {
+		return nil, errors.New("unsupported token algorithm")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed token signature")
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, errors.New("invalid token signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("malformed token payload")
+	}
+	claims := &tokenClaims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, errors.New("malformed token claims")
+	}
+	if claims.ExpiresAt != 0 && now.Unix() >= claims.ExpiresAt {
+		return nil, errors.New("token expired")
+	}
+
+	return claims, nil
+}
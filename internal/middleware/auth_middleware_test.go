@@ -0,0 +1,74 @@
+// internal/middleware/auth_middleware_test.go
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"device-service/internal/config"
+	"device-service/internal/model"
+)
+
+// signToken signs claims as an HS256 JWT
+func signToken(t *testing.T, secret string, claims tokenClaims) string {
+	t.Helper()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payloadJSON, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("failed to encode claims: %v", err)
+	}
+	payload := base64.RawURLEncoding.EncodeToString(payloadJSON)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(header + "." + payload))
+	return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuthMiddlewareTenantClaim(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const secret = "test-secret"
+	tenantID := uuid.New()
+
+	tests := []struct {
+		name       string
+		claims     tokenClaims
+		wantStatus int
+		wantTenant bool
+	}{
+		{name: "tenant token", claims: tokenClaims{Subject: "user", TenantID: tenantID.String()}, wantStatus: http.StatusOK, wantTenant: true},
+		{name: "admin token without tenant", claims: tokenClaims{Subject: "ops", Scope: "admin"}, wantStatus: http.StatusOK},
+		{name: "token without tenant", claims: tokenClaims{Subject: "user", Scope: "print:raw"}, wantStatus: http.StatusUnauthorized},
+		{name: "invalid tenant", claims: tokenClaims{Subject: "user", TenantID: "not-a-uuid"}, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(AuthMiddleware(&config.SecurityConfig{JWTSecret: secret}))
+			router.GET("/", func(c *gin.Context) {
+				got, ok := model.TenantFromContext(c.Request.Context())
+				if ok != tt.wantTenant || (ok && got != tenantID) {
+					t.Errorf("request tenant = %v, %v; want %v, %v", got, ok, tenantID, tt.wantTenant)
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+signToken(t, secret, tt.claims))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
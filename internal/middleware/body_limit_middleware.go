@@ -0,0 +1,69 @@
+// internal/middleware/body_limit_middleware.go
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"device-service/internal/config"
+	"device-service/internal/utils"
+)
+
+// BodyLimitMiddleware bounds request bodies. Routes listed as large routes
+// get the large limit, every other route the default one. A declared
+// Content-Length over the limit is rejected with 413 right away; a body that
+// turns out longer while it is read fails the read, and handlers report that
+// as 413 too. It must run before anything that reads the body.
+func BodyLimitMiddleware(cfg *config.BodyLimitConfig, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		limit := cfg.DefaultBytes
+		if containsString(cfg.LargeRoutes, c.FullPath()) {
+			limit = cfg.LargeBytes
+		}
+
+		if c.Request.ContentLength > limit {
+			logger.Warn("Request body too large",
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.Int64("content_length", c.Request.ContentLength),
+				zap.Int64("limit", limit),
+			)
+			utils.ErrorResponse(c, http.StatusRequestEntityTooLarge, "Request body too large",
+				fmt.Errorf("body of %d bytes exceeds the %d byte limit", c.Request.ContentLength, limit))
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// replayBody returns a body yielding what was already read from a request
+// body and then the error that stopped the read, so a handler reading it
+// after a middleware sees the same failure, e.g. an oversize body
+func replayBody(read []byte, err error) io.ReadCloser {
+	if err == nil {
+		return io.NopCloser(bytes.NewReader(read))
+	}
+	return io.NopCloser(io.MultiReader(bytes.NewReader(read), &failingReader{err: err}))
+}
+
+// failingReader fails every read with err
+type failingReader struct {
+	err error
+}
+
+func (r *failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,134 @@
+// internal/middleware/field_mapping_middleware.go
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"device-service/internal/config"
+)
+
+// FieldMappingMiddleware renames the JSON body fields of legacy clients to
+// their canonical names, so handlers validate the canonical payload. Renames
+// apply to the top-level object and to its "data" object, which carries the
+// operation data of generic operation requests. A canonical field the client
+// also sent wins over the legacy one.
+func FieldMappingMiddleware(cfg *config.FieldMappingConfig, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(cfg.Rules) == 0 || c.Request.Body == nil || !hasJSONBody(c.Request) {
+			c.Next()
+			return
+		}
+
+		renames := matchingRenames(cfg, c.GetHeader(cfg.ClientHeader), c.FullPath())
+		if len(renames) == 0 {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		c.Request.Body.Close()
+		if err != nil {
+			c.Request.Body = replayBody(body, err)
+			c.Next()
+			return
+		}
+
+		mapped, renamed := renameFields(body, renames)
+		if renamed > 0 {
+			logger.Debug("Mapped legacy request fields",
+				zap.String("client", c.GetHeader(cfg.ClientHeader)),
+				zap.String("route", c.FullPath()),
+				zap.Int("fields", renamed),
+			)
+			body = mapped
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Request.ContentLength = int64(len(body))
+		c.Next()
+	}
+}
+
+// hasJSONBody reports whether a request carries a JSON body
+func hasJSONBody(r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+		return false
+	}
+	return !isBinaryContentType(r.Header.Get("Content-Type"))
+}
+
+// matchingRenames collects the renames of every rule matching a client and
+// route, in rule order
+func matchingRenames(cfg *config.FieldMappingConfig, client, route string) []config.FieldRename {
+	var renames []config.FieldRename
+	for _, rule := range cfg.Rules {
+		if rule.Client != "" && rule.Client != client {
+			continue
+		}
+		if len(rule.Routes) > 0 && !containsString(rule.Routes, route) {
+			continue
+		}
+		renames = append(renames, rule.Fields...)
+	}
+	return renames
+}
+
+// renameFields applies renames to a JSON object body and its "data" object.
+// Bodies that aren't JSON objects are returned unchanged.
+func renameFields(body []byte, renames []config.FieldRename) ([]byte, int) {
+	// Numbers stay json.Number so amounts and IDs survive re-encoding as sent
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var payload map[string]interface{}
+	if err := decoder.Decode(&payload); err != nil || payload == nil {
+		return body, 0
+	}
+
+	renamed := renameKeys(payload, renames)
+	if data, ok := payload["data"].(map[string]interface{}); ok {
+		renamed += renameKeys(data, renames)
+	}
+	if renamed == 0 {
+		return body, 0
+	}
+
+	mapped, err := json.Marshal(payload)
+	if err != nil {
+		return body, 0
+	}
+	return mapped, renamed
+}
+
+// renameKeys moves legacy keys of an object to their canonical names and
+// returns how many legacy keys it replaced
+func renameKeys(object map[string]interface{}, renames []config.FieldRename) int {
+	renamed := 0
+	for _, rename := range renames {
+		value, ok := object[rename.From]
+		if !ok {
+			continue
+		}
+		delete(object, rename.From)
+		if _, exists := object[rename.To]; !exists {
+			object[rename.To] = value
+		}
+		renamed++
+	}
+	return renamed
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
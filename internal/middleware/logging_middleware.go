@@ -2,15 +2,43 @@
 package middleware
 
 import (
-	"device-service/internal/utils"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"device-service/internal/config"
+	"device-service/internal/model"
+	"device-service/internal/utils"
 )
 
-func LoggingMiddleware(logger *utils.ServiceLogger) gin.HandlerFunc {
+// LoggingMiddleware logs every API request. When body capture is enabled it
+// additionally logs truncated, redacted request and response bodies of
+// non-GET API calls.
+func LoggingMiddleware(logger *utils.ServiceLogger, cfg *config.LoggingConfig) gin.HandlerFunc {
+	capture := cfg != nil && cfg.BodyCapture.Enabled
+	var redactor *bodyRedactor
+	if capture {
+		redactor = newBodyRedactor(cfg.BodyCapture.RedactFields, cfg.BodyCapture.MaxBodyBytes)
+	}
+
 	return func(c *gin.Context) {
 		startTime := time.Now()
+
+		var requestBody []byte
+		var responseWriter *bodyCaptureWriter
+		captured := capture && shouldCaptureBody(c.Request)
+		if captured {
+			requestBody = readRequestBody(c.Request)
+			responseWriter = &bodyCaptureWriter{ResponseWriter: c.Writer, limit: redactor.maxBytes}
+			c.Writer = responseWriter
+		}
+
 		c.Next()
 		duration := time.Since(startTime)
 
@@ -22,5 +50,176 @@ func LoggingMiddleware(logger *utils.ServiceLogger) gin.HandlerFunc {
 			c.Writer.Status(),
 			duration,
 		)
+
+		if captured {
+			logger.Info("API request body",
+				zap.String("request_id", c.GetString("request_id")),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.Int("status_code", c.Writer.Status()),
+				zap.String("request_body", redactor.redact(requestBody, c.ContentType())),
+				zap.String("response_body", redactor.redact(responseWriter.body.Bytes(), responseWriter.Header().Get("Content-Type"))),
+				zap.Bool("response_truncated", responseWriter.truncated),
+			)
+		}
+	}
+}
+
+// shouldCaptureBody reports whether the request bodies are worth logging:
+// only non-GET API calls with textual payloads, never WebSocket upgrades
+func shouldCaptureBody(r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+		return false
+	}
+	if !strings.HasPrefix(r.URL.Path, "/api/") {
+		return false
+	}
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return false
+	}
+	return !isBinaryContentType(r.Header.Get("Content-Type"))
+}
+
+// isBinaryContentType reports whether a content type carries file/binary data
+func isBinaryContentType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, prefix := range []string{"multipart/", "application/octet-stream", "image/", "audio/", "video/", "application/pdf"} {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// readRequestBody reads the request body and puts it back for the handlers
+func readRequestBody(r *http.Request) []byte {
+	if r.Body == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = replayBody(body, err)
+	if err != nil {
+		return nil
+	}
+	return body
+}
+
+// bodyCaptureWriter copies up to limit bytes of the response body
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyCaptureWriter) capture(b []byte) {
+	if isBinaryContentType(w.Header().Get("Content-Type")) {
+		w.truncated = true
+		return
+	}
+
+	remaining := w.limit - w.body.Len()
+	if remaining <= 0 {
+		w.truncated = w.truncated || len(b) > 0
+		return
+	}
+	if len(b) > remaining {
+		b = b[:remaining]
+		w.truncated = true
+	}
+	w.body.Write(b)
+}
+
+// bodyRedactor masks sensitive fields in JSON bodies and truncates output
+type bodyRedactor struct {
+	fields   []string
+	maxBytes int
+}
+
+// newBodyRedactor creates a redactor for the given field names
+func newBodyRedactor(fields []string, maxBytes int) *bodyRedactor {
+	if maxBytes <= 0 {
+		maxBytes = 4096
+	}
+
+	normalized := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			normalized = append(normalized, field)
+		}
+	}
+
+	return &bodyRedactor{fields: normalized, maxBytes: maxBytes}
+}
+
+// redact returns a loggable representation of body
+func (br *bodyRedactor) redact(body []byte, contentType string) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if isBinaryContentType(contentType) {
+		return "[binary body omitted]"
+	}
+
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err == nil {
+		if redacted, err := json.Marshal(br.redactValue(payload)); err == nil {
+			body = redacted
+		}
+	} else if !strings.Contains(strings.ToLower(contentType), "json") {
+		// Unparsable non-JSON payloads cannot be redacted, so they are not logged
+		return "[non-JSON body omitted]"
+	}
+
+	if len(body) > br.maxBytes {
+		return string(body[:br.maxBytes]) + "...[truncated]"
+	}
+	return string(body)
+}
+
+// redactValue walks a decoded JSON value and masks sensitive keys
+func (br *bodyRedactor) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if br.isSensitive(key) {
+				v[key] = model.RedactedValue
+				continue
+			}
+			v[key] = br.redactValue(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = br.redactValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// isSensitive reports whether a key is, or is prefixed/suffixed by, one of
+// the redacted field names (e.g. "pin" matches "pin" and "pin_block" but not
+// "last_ping")
+func (br *bodyRedactor) isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, field := range br.fields {
+		if key == field || strings.HasPrefix(key, field+"_") || strings.HasSuffix(key, "_"+field) {
+			return true
+		}
 	}
+	return false
 }
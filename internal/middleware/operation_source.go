@@ -0,0 +1,17 @@
+// internal/middleware/operation_source.go
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"device-service/internal/model"
+)
+
+// OperationSourceMiddleware records operations started by the request as
+// coming from source
+func OperationSourceMiddleware(source model.OperationSource) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(model.WithOperationSource(c.Request.Context(), source))
+		c.Next()
+	}
+}
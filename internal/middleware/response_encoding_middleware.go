@@ -0,0 +1,24 @@
+// internal/middleware/response_encoding_middleware.go
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"device-service/internal/utils"
+)
+
+// ResponseEncodingMiddleware negotiates the response encoding from the Accept
+// header. Clients asking for application/msgpack (or application/x-msgpack)
+// get msgpack encoded responses; everyone else keeps JSON.
+func ResponseEncodingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Vary", "Accept")
+
+		switch c.NegotiateFormat(gin.MIMEJSON, utils.MIMEMsgPack, "application/x-msgpack") {
+		case utils.MIMEMsgPack, "application/x-msgpack":
+			c.Set(utils.ResponseEncodingKey, utils.MIMEMsgPack)
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,23 @@
+// internal/middleware/scope_middleware.go
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"device-service/internal/model"
+	"device-service/internal/utils"
+)
+
+// ScopeMiddleware rejects requests whose token wasn't granted scope
+func ScopeMiddleware(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !model.HasScope(c.Request.Context(), scope) {
+			utils.ErrorResponse(c, http.StatusForbidden, "Missing scope: "+scope, nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,192 @@
+// internal/middleware/timeout_middleware.go
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"device-service/internal/utils"
+)
+
+// TimeoutMiddleware bounds how long a request may take. The handler runs
+// with a deadline on its context and a buffered response; when the deadline
+// passes first the client gets a 504 right away and whatever the handler
+// writes afterwards is discarded. The middleware still waits for the handler
+// to return before the request context is released, so handlers should
+// honor c.Request.Context(). WebSocket upgrades are not limited.
+func TimeoutMiddleware(timeout time.Duration, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 || isWebSocketRequest(c.Request) {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		// Read before the handler runs; c is the handler's from here on
+		requestID := c.GetString("request_id")
+		method, path := c.Request.Method, c.Request.URL.Path
+
+		original := c.Writer
+		buffered := &timeoutWriter{ResponseWriter: original, header: original.Header().Clone(), status: http.StatusOK}
+		c.Writer = buffered
+
+		done := make(chan interface{}, 1)
+		go func() {
+			defer func() { done <- recover() }()
+			c.Next()
+		}()
+
+		var recovered interface{}
+		select {
+		case recovered = <-done:
+			buffered.flush()
+		case <-ctx.Done():
+			buffered.timeout()
+			logger.Warn("Request timed out",
+				zap.String("request_id", requestID),
+				zap.String("method", method),
+				zap.String("path", path),
+				zap.Duration("timeout", timeout),
+			)
+			writeTimeoutResponse(original, requestID)
+
+			// The context must outlive the handler
+			recovered = <-done
+		}
+
+		c.Writer = original
+		if recovered != nil {
+			panic(recovered)
+		}
+	}
+}
+
+// isWebSocketRequest reports whether r upgrades to a WebSocket connection
+func isWebSocketRequest(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || strings.HasPrefix(r.URL.Path, "/ws/")
+}
+
+// writeTimeoutResponse writes the 504 response straight to the client
+func writeTimeoutResponse(w gin.ResponseWriter, requestID string) {
+	body, err := json.Marshal(utils.APIResponse{
+		Success: false,
+		Message: "Request timed out",
+		Error: &utils.APIError{
+			Code:    "REQUEST_TIMEOUT",
+			Message: "Request timed out",
+		},
+		Timestamp: time.Now(),
+		RequestID: requestID,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusGatewayTimeout)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusGatewayTimeout)
+	w.Write(body)
+}
+
+// timeoutWriter buffers the handler's response, including headers, until
+// the handler finishes in time. After a timeout every write is discarded.
+// Like gin's writer, the status can change until the response is written.
+type timeoutWriter struct {
+	gin.ResponseWriter
+	header   http.Header
+	body     bytes.Buffer
+	status   int
+	written  bool
+	timedOut bool
+	mu       sync.Mutex
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written = true
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written = true
+	if w.timedOut {
+		return len(b), nil
+	}
+	return w.body.Write(b)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.written
+}
+
+// Flush is a no-op; the response is sent once the handler returns
+func (w *timeoutWriter) Flush() {}
+
+// timeout makes the writer discard the rest of the handler's response
+func (w *timeoutWriter) timeout() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timedOut = true
+}
+
+// flush sends the buffered response to the client
+func (w *timeoutWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	header := w.ResponseWriter.Header()
+	for key, values := range w.header {
+		header[key] = values
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.written {
+		w.ResponseWriter.WriteHeaderNow()
+		w.ResponseWriter.Write(w.body.Bytes())
+	}
+}
@@ -2,8 +2,12 @@
 package model
 
 import (
+	"crypto/sha256"
 	"database/sql/driver"
+	"encoding/hex"
 	"encoding/json"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,8 +23,19 @@ const (
 	DeviceTypeCashRegister DeviceType = "CASH_REGISTER"
 	DeviceTypeCashDrawer   DeviceType = "CASH_DRAWER"
 	DeviceTypeDisplay      DeviceType = "DISPLAY"
+	DeviceTypeScale        DeviceType = "SCALE"
 )
 
+// IsValid reports whether t is a known device type
+func (t DeviceType) IsValid() bool {
+	switch t {
+	case DeviceTypePOS, DeviceTypePrinter, DeviceTypeScanner, DeviceTypeCashRegister,
+		DeviceTypeCashDrawer, DeviceTypeDisplay, DeviceTypeScale:
+		return true
+	}
+	return false
+}
+
 // DeviceStatus represents the current status of a device
 type DeviceStatus string
 
@@ -40,6 +55,7 @@ const (
 	ConnectionTypeUSB       ConnectionType = "USB"
 	ConnectionTypeTCP       ConnectionType = "TCP"
 	ConnectionTypeBluetooth ConnectionType = "BLUETOOTH"
+	ConnectionTypeVirtual   ConnectionType = "VIRTUAL" // no hardware, output is captured for download
 )
 
 // DeviceBrand represents supported device brands
@@ -72,6 +88,15 @@ const (
 	CapabilityLogo    Capability = "LOGO"
 	CapabilityBarcode Capability = "BARCODE"
 	CapabilityQR      Capability = "QR"
+	CapabilityWeigh   Capability = "WEIGH"
+
+	// CapabilityTwoColor is reported by printers printing red besides black,
+	// on two-color thermal paper or a red/black ribbon
+	CapabilityTwoColor Capability = "TWO_COLOR"
+
+	// CapabilityStatusReadback is reported by drivers whose device answers
+	// status requests; one-way connections only get basic status
+	CapabilityStatusReadback Capability = "STATUS_READBACK"
 )
 
 // JSONArray type for PostgreSQL JSONB arrays
@@ -122,6 +147,7 @@ func (j JSONObject) Value() (driver.Value, error) {
 type Device struct {
 	ID                 uuid.UUID      `json:"id" db:"id"`
 	DeviceID           string         `json:"device_id" db:"device_id"`
+	Name               *string        `json:"name" db:"name"` // friendly name, e.g. "Register 3 Printer"
 	DeviceType         DeviceType     `json:"device_type" db:"device_type"`
 	Brand              DeviceBrand    `json:"brand" db:"brand"`
 	Model              string         `json:"model" db:"model"`
@@ -130,6 +156,7 @@ type Device struct {
 	ConnectionConfig   JSONObject     `json:"connection_config" db:"connection_config"`
 	Capabilities       JSONArray      `json:"capabilities" db:"capabilities"`
 	BranchID           uuid.UUID      `json:"branch_id" db:"branch_id"`
+	TenantID           *uuid.UUID     `json:"tenant_id,omitempty" db:"tenant_id"` // organization owning the branch
 	Location           *string        `json:"location" db:"location"`
 	Status             DeviceStatus   `json:"status" db:"status"`
 	LastPing           *time.Time     `json:"last_ping" db:"last_ping"`
@@ -139,6 +166,89 @@ type Device struct {
 	UpdatedAt          time.Time      `json:"updated_at" db:"updated_at"`
 }
 
+// MarshalJSON encodes the device with secret connection config values redacted,
+// so credentials never leave the service through API responses or logs
+func (d Device) MarshalJSON() ([]byte, error) {
+	type deviceJSON Device
+	redacted := deviceJSON(d)
+	redacted.ConnectionConfig = d.ConnectionConfig.RedactSecrets()
+	return json.Marshal(redacted)
+}
+
+// RedactedValue replaces secret values in API responses and logs
+const RedactedValue = "[REDACTED]"
+
+var (
+	secretConfigKeysMu sync.RWMutex
+	secretConfigKeys   = []string{"password", "secret", "token", "api_key", "private_key", "pin", "credentials", "passphrase"}
+)
+
+// SetSecretConfigKeys replaces the connection config keys treated as secrets
+func SetSecretConfigKeys(keys []string) {
+	normalized := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			normalized = append(normalized, key)
+		}
+	}
+
+	secretConfigKeysMu.Lock()
+	defer secretConfigKeysMu.Unlock()
+	secretConfigKeys = normalized
+}
+
+// IsSecretConfigKey reports whether a connection config key holds a secret.
+// A key matches a secret name exactly or as a "_" separated prefix/suffix
+// (e.g. "wifi_password", "pin_code" but not "shipping").
+func IsSecretConfigKey(key string) bool {
+	key = strings.ToLower(key)
+
+	secretConfigKeysMu.RLock()
+	defer secretConfigKeysMu.RUnlock()
+
+	for _, secret := range secretConfigKeys {
+		if key == secret || strings.HasPrefix(key, secret+"_") || strings.HasSuffix(key, "_"+secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactSecrets returns a copy with secret values replaced, recursing into
+// nested objects
+func (j JSONObject) RedactSecrets() JSONObject {
+	if j == nil {
+		return nil
+	}
+
+	redacted := make(JSONObject, len(j))
+	for key, value := range j {
+		if IsSecretConfigKey(key) {
+			redacted[key] = RedactedValue
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			redacted[key] = map[string]interface{}(JSONObject(nested).RedactSecrets())
+			continue
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// RedactedHash returns a short, stable hash of the redacted config. It changes
+// when a non-secret value changes, so logs can mark reconfigurations without
+// revealing the config or its secrets.
+func (j JSONObject) RedactedHash() string {
+	// encoding/json sorts map keys, so equal configs marshal identically
+	data, err := json.Marshal(j.RedactSecrets())
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
 // HasCapability checks if device has a specific capability
 func (d *Device) HasCapability(capability Capability) bool {
 	for _, cap := range d.Capabilities {
@@ -191,6 +301,16 @@ type DeviceHealth struct {
 	RecordedAt    time.Time  `json:"recorded_at" db:"recorded_at"`
 }
 
+// DeviceStatusEvent represents a single device status transition
+type DeviceStatusEvent struct {
+	ID        uuid.UUID    `json:"id" db:"id"`
+	DeviceID  uuid.UUID    `json:"device_id" db:"device_id"`
+	OldStatus DeviceStatus `json:"old_status" db:"old_status"`
+	NewStatus DeviceStatus `json:"new_status" db:"new_status"`
+	Reason    string       `json:"reason,omitempty" db:"reason"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+}
+
 // PerformanceMetrics structure
 type PerformanceMetrics struct {
 	AverageResponseTime int     `json:"average_response_time_ms"`
@@ -0,0 +1,72 @@
+// internal/model/inventory.go
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InventorySnapshot is a point-in-time record of every device of the fleet,
+// kept for audits
+type InventorySnapshot struct {
+	ID          uuid.UUID        `json:"id" db:"id"`
+	TenantID    *uuid.UUID       `json:"tenant_id,omitempty" db:"tenant_id"`
+	RequestedBy string           `json:"requested_by,omitempty" db:"requested_by"`
+	DeviceCount int              `json:"device_count" db:"device_count"`
+	LiveCount   int              `json:"live_count" db:"live_count"` // devices read live
+	Devices     InventoryDevices `json:"devices" db:"devices"`
+	StartedAt   time.Time        `json:"started_at" db:"started_at"`
+	CompletedAt time.Time        `json:"completed_at" db:"completed_at"`
+	CreatedAt   time.Time        `json:"created_at" db:"created_at"`
+}
+
+// InventoryDevice is a device as recorded in an inventory snapshot. The
+// registered fields are always set; Live tells whether identity, firmware,
+// status and capabilities were read from the device itself.
+type InventoryDevice struct {
+	ID             uuid.UUID      `json:"id"`
+	DeviceID       string         `json:"device_id"`
+	Name           *string        `json:"name,omitempty"`
+	BranchID       uuid.UUID      `json:"branch_id"`
+	DeviceType     DeviceType     `json:"device_type"`
+	Brand          DeviceBrand    `json:"brand"`
+	Model          string         `json:"model"`
+	ConnectionType ConnectionType `json:"connection_type"`
+	Status         DeviceStatus   `json:"status"`
+	LastPing       *time.Time     `json:"last_ping,omitempty"`
+
+	FirmwareVersion string       `json:"firmware_version,omitempty"`
+	SerialNumber    string       `json:"serial_number,omitempty"`
+	HardwareVersion string       `json:"hardware_version,omitempty"`
+	Capabilities    []Capability `json:"capabilities"`
+
+	Live      bool   `json:"live"`
+	Ready     *bool  `json:"ready,omitempty"`      // live devices only
+	ErrorCode string `json:"error_code,omitempty"` // error the device reported, e.g. PAPER_OUT
+	Error     string `json:"error,omitempty"`      // why the device couldn't be read live
+}
+
+// InventoryDevices type for PostgreSQL JSONB device lists
+type InventoryDevices []InventoryDevice
+
+func (d *InventoryDevices) Scan(value interface{}) error {
+	if value == nil {
+		*d = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, d)
+}
+
+func (d InventoryDevices) Value() (driver.Value, error) {
+	if d == nil {
+		return json.Marshal([]InventoryDevice{})
+	}
+	return json.Marshal(d)
+}
@@ -2,6 +2,8 @@
 package model
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,8 +23,20 @@ const (
 	OperationTypeBeep        OperationType = "BEEP"
 	OperationTypeRefund      OperationType = "REFUND"
 	OperationTypeCut         OperationType = "CUT"
+	OperationTypeWeigh       OperationType = "WEIGH"
 )
 
+// IsValid reports whether t is a known operation type
+func (t OperationType) IsValid() bool {
+	switch t {
+	case OperationTypePrint, OperationTypePayment, OperationTypeScan, OperationTypeStatusCheck,
+		OperationTypeOpenDrawer, OperationTypeDisplayText, OperationTypeBeep, OperationTypeRefund,
+		OperationTypeCut, OperationTypeWeigh:
+		return true
+	}
+	return false
+}
+
 // OperationStatus represents the status of an operation
 type OperationStatus string
 
@@ -46,6 +60,11 @@ const (
 	PriorityBackground    OperationPriority = 5 // Bulk operations
 )
 
+// IsValid reports whether p is a known priority
+func (p OperationPriority) IsValid() bool {
+	return p >= PriorityUltraCritical && p <= PriorityBackground
+}
+
 // DeviceOperation represents an operation performed on a device
 type DeviceOperation struct {
 	ID            uuid.UUID         `json:"id" db:"id"`
@@ -62,6 +81,54 @@ type DeviceOperation struct {
 	CorrelationID *uuid.UUID        `json:"correlation_id" db:"correlation_id"`
 	Result        JSONObject        `json:"result" db:"result"`
 	CreatedAt     time.Time         `json:"created_at" db:"created_at"`
+
+	ParentOperationID *uuid.UUID `json:"parent_operation_id,omitempty" db:"parent_operation_id"`
+	TenantID          *uuid.UUID `json:"tenant_id,omitempty" db:"tenant_id"` // copied from the device
+
+	Metadata OperationMetadata `json:"metadata,omitempty" db:"metadata"` // client tags, e.g. order_id
+	Source   OperationSource   `json:"source,omitempty" db:"source"`     // channel it was started through, e.g. REST
+
+	Progress *OperationProgress `json:"progress,omitempty" db:"-"` // live state, set when polled
+}
+
+// OperationMetadata holds client supplied string tags of an operation, stored
+// as a PostgreSQL JSONB object
+type OperationMetadata map[string]string
+
+func (m *OperationMetadata) Scan(value interface{}) error {
+	if value == nil {
+		*m = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, m)
+}
+
+func (m OperationMetadata) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return json.Marshal(m)
+}
+
+// Operation progress states of an active operation. Finished operations
+// report their status in lower case (success, failed, timeout, cancelled).
+const (
+	OperationStateQueued     = "queued"
+	OperationStateProcessing = "processing"
+)
+
+// OperationProgress reports where an operation stands: queued behind other
+// operations of its device, with its place and estimated wait, processing on
+// the device, or finished
+type OperationProgress struct {
+	OperationID   uuid.UUID `json:"operation_id"`
+	State         string    `json:"state"`                            // queued, processing, success, failed, timeout or cancelled
+	QueuePosition *int      `json:"queue_position,omitempty"`         // 1 runs next
+	EstimatedWait *float64  `json:"estimated_wait_seconds,omitempty"` // until it runs, from recent run times
 }
 
 // IsCompleted checks if operation is completed (success or failed)
@@ -127,4 +194,5 @@ type OfflineOperation struct {
 	LastSyncAttempt *time.Time        `json:"last_sync_attempt" db:"last_sync_attempt"`
 	Priority        OperationPriority `json:"priority" db:"priority"`
 	ExpiresAt       *time.Time        `json:"expires_at" db:"expires_at"`
+	ClaimedUntil    *time.Time        `json:"claimed_until,omitempty" db:"claimed_until"` // set while a sync runs the operation
 }
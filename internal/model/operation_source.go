@@ -0,0 +1,41 @@
+// internal/model/operation_source.go
+package model
+
+import "context"
+
+// OperationSource is the channel an operation was started through
+type OperationSource string
+
+const (
+	OperationSourceREST        OperationSource = "REST"
+	OperationSourceWebSocket   OperationSource = "WEBSOCKET"
+	OperationSourceOfflineSync OperationSource = "OFFLINE_SYNC"
+	OperationSourceReplay      OperationSource = "REPLAY"
+	OperationSourceRecovery    OperationSource = "RECOVERY" // requeued after a restart
+)
+
+// IsValid reports whether s is a known operation source
+func (s OperationSource) IsValid() bool {
+	switch s {
+	case OperationSourceREST, OperationSourceWebSocket, OperationSourceOfflineSync,
+		OperationSourceReplay, OperationSourceRecovery:
+		return true
+	}
+	return false
+}
+
+// operationSourceKey is the context key of the channel operations run from
+type operationSourceKey struct{}
+
+// WithOperationSource returns a context whose operations are recorded as
+// started through source
+func WithOperationSource(ctx context.Context, source OperationSource) context.Context {
+	return context.WithValue(ctx, operationSourceKey{}, source)
+}
+
+// OperationSourceFromContext returns the channel operations started with ctx
+// come from, or an empty source when no entry point set one
+func OperationSourceFromContext(ctx context.Context) OperationSource {
+	source, _ := ctx.Value(operationSourceKey{}).(OperationSource)
+	return source
+}
@@ -0,0 +1,35 @@
+// internal/model/scope.go
+package model
+
+import "context"
+
+// Token scopes granting more than access to a tenant's devices
+const (
+	// ScopeAdmin grants administrative access, e.g. to service settings and
+	// to device policies that weaken print sanitization. It implies every
+	// other scope.
+	ScopeAdmin = "admin"
+
+	// ScopeRawPrint permits raw ESC/POS print jobs on devices that allow them
+	ScopeRawPrint = "print:raw"
+)
+
+// scopesKey is the context key of the scopes a request's token grants
+type scopesKey struct{}
+
+// WithScopes returns a context carrying the scopes granted to its caller
+func WithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, scopesKey{}, scopes)
+}
+
+// HasScope reports whether a context's caller was granted a scope. Contexts
+// without scopes, e.g. of background jobs, have none.
+func HasScope(ctx context.Context, scope string) bool {
+	scopes, _ := ctx.Value(scopesKey{}).([]string)
+	for _, granted := range scopes {
+		if granted == scope || granted == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+// internal/model/tenant.go
+package model
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// tenantKey is the context key of the tenant a request is scoped to
+type tenantKey struct{}
+
+// WithTenant returns a context scoped to a tenant. Repositories only return
+// rows of that tenant.
+func WithTenant(ctx context.Context, tenantID uuid.UUID) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant a context is scoped to. ok is false
+// for unscoped contexts: background jobs and platform-wide callers.
+func TenantFromContext(ctx context.Context) (tenantID uuid.UUID, ok bool) {
+	tenantID, ok = ctx.Value(tenantKey{}).(uuid.UUID)
+	return tenantID, ok
+}
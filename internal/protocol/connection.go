@@ -27,10 +27,28 @@ type USBConfig struct {
 type TCPConfig struct {
 	Host         string        `json:"host"`
 	Port         int           `json:"port"`
-	SSL          bool          `json:"ssl"`
+	SSL          bool          `json:"ssl"` // connect over TLS
+	TLS          TCPTLSConfig  `json:"tls"`
 	KeepAlive    bool          `json:"keep_alive"`
 	BufferSize   int           `json:"buffer_size"`
 	Timeout      time.Duration `json:"timeout"`
 	ReadTimeout  time.Duration `json:"read_timeout"`
 	WriteTimeout time.Duration `json:"write_timeout"`
 }
+
+// TCPTLSConfig represents the TLS settings of a TCP connection, used when
+// SSL is set. Files are PEM encoded, named relative to the TLS certificate
+// directory (device.tls_cert_dir) and read when the connection opens.
+type TCPTLSConfig struct {
+	CAFile             string `json:"ca_file"`              // CAs verifying the device, instead of the system roots
+	CertFile           string `json:"cert_file"`            // client certificate, for devices requiring mutual TLS
+	KeyFile            string `json:"key_file"`             // client certificate key
+	ServerName         string `json:"server_name"`          // name verified in the device certificate, defaults to the host
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"` // accept any device certificate, for self-signed dev devices only
+}
+
+// VirtualConfig represents virtual connection configuration
+type VirtualConfig struct {
+	CaptureID string `json:"capture_id"` // set by the driver to the device ID
+	MaxBytes  int    `json:"max_bytes"`  // output kept in memory, oldest bytes are dropped first
+}
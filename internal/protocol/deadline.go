@@ -0,0 +1,50 @@
+// internal/protocol/deadline.go
+package protocol
+
+import (
+	"context"
+	"time"
+)
+
+// ioDeadline returns the deadline of a single read or write: timeout from
+// now, or the context's deadline when that comes first. The zero time means
+// no deadline.
+func ioDeadline(ctx context.Context, timeout time.Duration) time.Time {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if ctxDeadline, ok := ctx.Deadline(); ok && (deadline.IsZero() || ctxDeadline.Before(deadline)) {
+		deadline = ctxDeadline
+	}
+	return deadline
+}
+
+// interruptOnDone unblocks a pending read or write when ctx ends by moving
+// its deadline to now. The returned func must be called once the call has
+// returned; it waits for an interrupt in progress, so the moved deadline
+// can't leak into the next call.
+func interruptOnDone(ctx context.Context, setDeadline func(time.Time) error) func() {
+	interrupted := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		defer close(interrupted)
+		setDeadline(time.Now())
+	})
+	return func() {
+		if !stop() {
+			<-interrupted
+		}
+	}
+}
+
+// contextExpired returns the context's error, or DeadlineExceeded once its
+// deadline has passed even if the context hasn't noticed yet
+func contextExpired(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
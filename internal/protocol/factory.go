@@ -2,6 +2,7 @@
 package protocol
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -21,11 +22,26 @@ func CreateProtocol(connectionType model.ConnectionType, config map[string]inter
 		return createTCPProtocol(config, logger)
 	case model.ConnectionTypeBluetooth:
 		return createBluetoothProtocol(config, logger)
+	case model.ConnectionTypeVirtual:
+		return createVirtualProtocol(config, logger)
 	default:
 		return nil, fmt.Errorf("unsupported protocol type: %s", connectionType)
 	}
 }
 
+// ProbeTransport checks that a device is reachable by opening and closing a
+// connection to it, without sending anything over it
+func ProbeTransport(ctx context.Context, connectionType model.ConnectionType, config map[string]interface{}, logger *zap.Logger) error {
+	protocolInstance, err := CreateProtocol(connectionType, config, logger)
+	if err != nil {
+		return err
+	}
+	if err := protocolInstance.Open(ctx); err != nil {
+		return err
+	}
+	return protocolInstance.Close()
+}
+
 // createSerialProtocol creates a serial protocol
 func createSerialProtocol(config map[string]interface{}, logger *zap.Logger) (DeviceProtocol, error) {
 	serialConfig := &SerialConfig{
@@ -190,6 +206,21 @@ func createTCPProtocol(config map[string]interface{}, logger *zap.Logger) (Devic
 		tcpConfig.SSL = ssl
 	}
 
+	// Parse TLS: true, or the TLS settings with an enabled flag
+	switch tlsValue := config["tls"].(type) {
+	case bool:
+		tcpConfig.SSL = tcpConfig.SSL || tlsValue
+	case map[string]interface{}:
+		if enabled, ok := tlsValue["enabled"].(bool); ok {
+			tcpConfig.SSL = tcpConfig.SSL || enabled
+		}
+		tcpConfig.TLS.CAFile, _ = tlsValue["ca_file"].(string)
+		tcpConfig.TLS.CertFile, _ = tlsValue["cert_file"].(string)
+		tcpConfig.TLS.KeyFile, _ = tlsValue["key_file"].(string)
+		tcpConfig.TLS.ServerName, _ = tlsValue["server_name"].(string)
+		tcpConfig.TLS.InsecureSkipVerify, _ = tlsValue["insecure_skip_verify"].(bool)
+	}
+
 	// Parse keep alive
 	if keepAlive, ok := config["keep_alive"].(bool); ok {
 		tcpConfig.KeepAlive = keepAlive
@@ -241,6 +272,36 @@ func createBluetoothProtocol(config map[string]interface{}, logger *zap.Logger)
 	return nil, fmt.Errorf("Bluetooth protocol not implemented yet")
 }
 
+// createVirtualProtocol creates a virtual protocol
+func createVirtualProtocol(config map[string]interface{}, logger *zap.Logger) (DeviceProtocol, error) {
+	virtualConfig := &VirtualConfig{
+		MaxBytes: defaultVirtualMaxBytes,
+	}
+
+	// Parse capture ID
+	if captureID, ok := config[CaptureIDKey].(string); ok && captureID != "" {
+		virtualConfig.CaptureID = captureID
+	} else {
+		return nil, fmt.Errorf("virtual capture_id is required")
+	}
+
+	// Parse max bytes
+	if maxBytes, ok := config["max_bytes"]; ok {
+		switch v := maxBytes.(type) {
+		case float64:
+			virtualConfig.MaxBytes = int(v)
+		case int:
+			virtualConfig.MaxBytes = v
+		}
+	}
+
+	logger.Info("Creating virtual protocol",
+		zap.String("capture_id", virtualConfig.CaptureID),
+	)
+
+	return NewVirtualConnection(virtualConfig, logger), nil
+}
+
 // ValidateConfig validates configuration for a specific protocol type
 func ValidateConfig(connectionType model.ConnectionType, config map[string]interface{}) error {
 	switch connectionType {
@@ -252,6 +313,8 @@ func ValidateConfig(connectionType model.ConnectionType, config map[string]inter
 		return validateTCPConfig(config)
 	case model.ConnectionTypeBluetooth:
 		return validateBluetoothConfig(config)
+	case model.ConnectionTypeVirtual:
+		return validateVirtualConfig(config)
 	default:
 		return fmt.Errorf("unsupported connection type: %s", connectionType)
 	}
@@ -325,6 +388,32 @@ func validateTCPConfig(config map[string]interface{}) error {
 		}
 	}
 
+	switch tlsValue := config["tls"].(type) {
+	case nil, bool:
+	case map[string]interface{}:
+		for _, key := range []string{"ca_file", "cert_file", "key_file", "server_name"} {
+			if value, ok := tlsValue[key]; ok {
+				if _, isString := value.(string); !isString {
+					return fmt.Errorf("invalid tls.%s type", key)
+				}
+			}
+		}
+		certFile, _ := tlsValue["cert_file"].(string)
+		keyFile, _ := tlsValue["key_file"].(string)
+		if (certFile == "") != (keyFile == "") {
+			return fmt.Errorf("tls cert_file and key_file must be set together")
+		}
+		for _, key := range []string{"ca_file", "cert_file", "key_file"} {
+			if name, _ := tlsValue[key].(string); name != "" {
+				if _, err := resolveTLSFile(name); err != nil {
+					return fmt.Errorf("invalid tls.%s: %w", key, err)
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("invalid tls type")
+	}
+
 	return nil
 }
 
@@ -333,3 +422,31 @@ func validateBluetoothConfig(config map[string]interface{}) error {
 	// TODO: Implement Bluetooth validation
 	return fmt.Errorf("Bluetooth configuration validation not implemented yet")
 }
+
+// validateVirtualConfig validates virtual configuration. The capture ID is
+// set by the driver, so it isn't required here. Output is only kept in
+// memory; a file path in a device config would let callers write anywhere
+// on the server.
+func validateVirtualConfig(config map[string]interface{}) error {
+	if _, ok := config["output_file"]; ok {
+		return fmt.Errorf("output_file is not supported, download the output from the virtual-output endpoint")
+	}
+
+	if maxBytes, ok := config["max_bytes"]; ok {
+		var limit int
+		switch v := maxBytes.(type) {
+		case float64:
+			limit = int(v)
+		case int:
+			limit = v
+		default:
+			return fmt.Errorf("invalid max_bytes type")
+		}
+
+		if limit < 1 {
+			return fmt.Errorf("invalid max_bytes: %d", limit)
+		}
+	}
+
+	return nil
+}
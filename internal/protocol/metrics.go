@@ -0,0 +1,209 @@
+// internal/protocol/metrics.go
+package protocol
+
+import (
+	"sync"
+	"time"
+
+	"device-service/internal/model"
+)
+
+// MetricsRecorder receives transport-level counters from all connections.
+// Implementations must be safe for concurrent use.
+type MetricsRecorder interface {
+	AddBytesWritten(connectionType model.ConnectionType, n int)
+	AddBytesRead(connectionType model.ConnectionType, n int)
+	IncWriteErrors(connectionType model.ConnectionType)
+	IncReadErrors(connectionType model.ConnectionType)
+	IncReconnects(connectionType model.ConnectionType)
+}
+
+// TransportCounters holds aggregated transport counters for a connection type
+type TransportCounters struct {
+	BytesWritten int64 `json:"bytes_written"`
+	BytesRead    int64 `json:"bytes_read"`
+	WriteErrors  int64 `json:"write_errors"`
+	ReadErrors   int64 `json:"read_errors"`
+	Reconnects   int64 `json:"reconnects"`
+}
+
+// CounterRecorder is the default in-memory MetricsRecorder
+type CounterRecorder struct {
+	mu       sync.RWMutex
+	counters map[model.ConnectionType]*TransportCounters
+}
+
+// NewCounterRecorder creates a new in-memory metrics recorder
+func NewCounterRecorder() *CounterRecorder {
+	return &CounterRecorder{
+		counters: make(map[model.ConnectionType]*TransportCounters),
+	}
+}
+
+// AddBytesWritten adds to the bytes written counter
+func (cr *CounterRecorder) AddBytesWritten(connectionType model.ConnectionType, n int) {
+	cr.update(connectionType, func(c *TransportCounters) { c.BytesWritten += int64(n) })
+}
+
+// AddBytesRead adds to the bytes read counter
+func (cr *CounterRecorder) AddBytesRead(connectionType model.ConnectionType, n int) {
+	cr.update(connectionType, func(c *TransportCounters) { c.BytesRead += int64(n) })
+}
+
+// IncWriteErrors increments the write error counter
+func (cr *CounterRecorder) IncWriteErrors(connectionType model.ConnectionType) {
+	cr.update(connectionType, func(c *TransportCounters) { c.WriteErrors++ })
+}
+
+// IncReadErrors increments the read error counter
+func (cr *CounterRecorder) IncReadErrors(connectionType model.ConnectionType) {
+	cr.update(connectionType, func(c *TransportCounters) { c.ReadErrors++ })
+}
+
+// IncReconnects increments the reconnect counter
+func (cr *CounterRecorder) IncReconnects(connectionType model.ConnectionType) {
+	cr.update(connectionType, func(c *TransportCounters) { c.Reconnects++ })
+}
+
+// Snapshot returns a copy of all counters keyed by connection type
+func (cr *CounterRecorder) Snapshot() map[model.ConnectionType]TransportCounters {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	snapshot := make(map[model.ConnectionType]TransportCounters, len(cr.counters))
+	for connectionType, counters := range cr.counters {
+		snapshot[connectionType] = *counters
+	}
+	return snapshot
+}
+
+// update applies fn to the counters of a connection type
+func (cr *CounterRecorder) update(connectionType model.ConnectionType, fn func(*TransportCounters)) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	counters, exists := cr.counters[connectionType]
+	if !exists {
+		counters = &TransportCounters{}
+		cr.counters[connectionType] = counters
+	}
+	fn(counters)
+}
+
+var (
+	defaultRecorder = NewCounterRecorder()
+	recorderMu      sync.RWMutex
+	recorder        MetricsRecorder = defaultRecorder
+)
+
+// SetMetricsRecorder replaces the recorder used by all connections.
+// Passing nil restores the default in-memory recorder.
+func SetMetricsRecorder(r MetricsRecorder) {
+	recorderMu.Lock()
+	defer recorderMu.Unlock()
+
+	if r == nil {
+		r = defaultRecorder
+	}
+	recorder = r
+}
+
+// Metrics returns the recorder currently used by all connections
+func Metrics() MetricsRecorder {
+	recorderMu.RLock()
+	defer recorderMu.RUnlock()
+	return recorder
+}
+
+// DefaultMetrics returns the built-in in-memory recorder
+func DefaultMetrics() *CounterRecorder {
+	return defaultRecorder
+}
+
+// StatsProvider is implemented by connections that expose their statistics
+type StatsProvider interface {
+	Stats() ProtocolStats
+}
+
+// statsTracker keeps per-connection statistics and forwards transport
+// counters to the global metrics recorder
+type statsTracker struct {
+	mu             sync.RWMutex
+	stats          ProtocolStats
+	connectionType model.ConnectionType
+}
+
+// newStatsTracker creates a stats tracker for a connection type
+func newStatsTracker(connectionType model.ConnectionType) *statsTracker {
+	return &statsTracker{connectionType: connectionType}
+}
+
+// recordOpen marks the connection as open. Drivers open a new connection to
+// reconnect, so they count reconnects themselves.
+func (st *statsTracker) recordOpen() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.stats.IsConnected = true
+	st.stats.LastActivity = time.Now()
+}
+
+// recordClose marks the connection as closed
+func (st *statsTracker) recordClose() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.stats.IsConnected = false
+}
+
+// recordWrite records a successful write
+func (st *statsTracker) recordWrite(n int, latency time.Duration) {
+	st.mu.Lock()
+	st.stats.BytesWritten += int64(n)
+	st.stats.OperationCount++
+	st.stats.LastActivity = time.Now()
+	if st.stats.AverageLatency == 0 {
+		st.stats.AverageLatency = latency
+	} else {
+		st.stats.AverageLatency = (st.stats.AverageLatency + latency) / 2
+	}
+	st.mu.Unlock()
+
+	Metrics().AddBytesWritten(st.connectionType, n)
+}
+
+// recordRead records a successful read
+func (st *statsTracker) recordRead(n int) {
+	st.mu.Lock()
+	st.stats.BytesRead += int64(n)
+	st.stats.OperationCount++
+	st.stats.LastActivity = time.Now()
+	st.mu.Unlock()
+
+	Metrics().AddBytesRead(st.connectionType, n)
+}
+
+// recordWriteError records a failed write
+func (st *statsTracker) recordWriteError() {
+	st.mu.Lock()
+	st.stats.ErrorCount++
+	st.stats.WriteErrors++
+	st.mu.Unlock()
+
+	Metrics().IncWriteErrors(st.connectionType)
+}
+
+// recordReadError records a failed read
+func (st *statsTracker) recordReadError() {
+	st.mu.Lock()
+	st.stats.ErrorCount++
+	st.stats.ReadErrors++
+	st.mu.Unlock()
+
+	Metrics().IncReadErrors(st.connectionType)
+}
+
+// snapshot returns a copy of the current statistics
+func (st *statsTracker) snapshot() ProtocolStats {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.stats
+}
@@ -26,12 +26,29 @@ type DeviceProtocol interface {
 	Ping(ctx context.Context) error
 }
 
+// ReadbackReporter is implemented by connections that may be write-only,
+// e.g. a USB printer class device without a bulk IN endpoint
+type ReadbackReporter interface {
+	CanRead() bool
+}
+
+// CanRead reports whether data can be read back over a connection.
+// Connections that don't implement ReadbackReporter are bidirectional.
+func CanRead(p DeviceProtocol) bool {
+	if reporter, ok := p.(ReadbackReporter); ok {
+		return reporter.CanRead()
+	}
+	return p != nil
+}
+
 // ProtocolStats provides protocol-level statistics
 type ProtocolStats struct {
 	BytesWritten   int64         `json:"bytes_written"`
 	BytesRead      int64         `json:"bytes_read"`
 	OperationCount int64         `json:"operation_count"`
 	ErrorCount     int64         `json:"error_count"`
+	WriteErrors    int64         `json:"write_errors"`
+	ReadErrors     int64         `json:"read_errors"`
 	LastActivity   time.Time     `json:"last_activity"`
 	AverageLatency time.Duration `json:"average_latency"`
 	IsConnected    bool          `json:"is_connected"`
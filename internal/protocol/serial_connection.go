@@ -21,7 +21,7 @@ type SerialConnection struct {
 	logger *zap.Logger
 	mutex  sync.RWMutex
 	isOpen bool
-	stats  *ProtocolStats
+	stats  *statsTracker
 }
 
 // NewSerialConnection creates a new serial connection
@@ -32,9 +32,7 @@ func NewSerialConnection(config *SerialConfig, logger *zap.Logger) DeviceProtoco
 			zap.String("protocol", "serial"),
 			zap.String("port", config.Port),
 		),
-		stats: &ProtocolStats{
-			IsConnected: false,
-		},
+		stats: newStatsTracker(model.ConnectionTypeSerial),
 	}
 }
 
@@ -86,8 +84,7 @@ func (sc *SerialConnection) Open(ctx context.Context) error {
 
 	sc.port = port
 	sc.isOpen = true
-	sc.stats.IsConnected = true
-	sc.stats.LastActivity = time.Now()
+	sc.stats.recordOpen()
 
 	sc.logger.Info("Serial port opened successfully")
 	return nil
@@ -109,7 +106,7 @@ func (sc *SerialConnection) Close() error {
 
 	sc.port = nil
 	sc.isOpen = false
-	sc.stats.IsConnected = false
+	sc.stats.recordClose()
 
 	sc.logger.Info("Serial port closed successfully")
 	return nil
@@ -122,7 +119,8 @@ func (sc *SerialConnection) IsOpen() bool {
 	return sc.isOpen && sc.port != nil
 }
 
-// Write writes data to the serial port
+// Write writes data to the serial port. Writes go to the driver's buffer and
+// return promptly, so only a context that already ended is checked.
 func (sc *SerialConnection) Write(ctx context.Context, data []byte) error {
 	sc.mutex.RLock()
 	defer sc.mutex.RUnlock()
@@ -140,7 +138,7 @@ func (sc *SerialConnection) Write(ctx context.Context, data []byte) error {
 	startTime := time.Now()
 	n, err := sc.port.Write(data)
 	if err != nil {
-		sc.stats.ErrorCount++
+		sc.stats.recordWriteError()
 		sc.logger.Error("Serial write failed", zap.Error(err))
 		return fmt.Errorf("failed to write to serial port: %w", err)
 	}
@@ -150,17 +148,21 @@ func (sc *SerialConnection) Write(ctx context.Context, data []byte) error {
 	}
 
 	// Update statistics
-	duration := time.Since(startTime)
-	sc.stats.BytesWritten += int64(len(data))
-	sc.stats.OperationCount++
-	sc.stats.LastActivity = time.Now()
-	sc.updateAverageLatency(duration)
+	sc.stats.recordWrite(len(data), time.Since(startTime))
 
 	sc.logger.Debug("Serial write completed", zap.Int("bytes", len(data)))
 	return nil
 }
 
-// Read reads data from the serial port
+// serialReadPoll bounds each blocking serial read. A serial port's read
+// timeout can't be moved while a read blocks, so reads are sliced to notice
+// a cancelled context.
+const serialReadPoll = 100 * time.Millisecond
+
+// Read reads data from the serial port. It waits up to the port timeout or
+// the context's deadline, whichever comes first, and returns early when the
+// context is cancelled. Nothing arriving within the port timeout yields an
+// empty read.
 func (sc *SerialConnection) Read(ctx context.Context, maxBytes int) ([]byte, error) {
 	sc.mutex.RLock()
 	defer sc.mutex.RUnlock()
@@ -170,47 +172,37 @@ func (sc *SerialConnection) Read(ctx context.Context, maxBytes int) ([]byte, err
 	}
 
 	buffer := make([]byte, maxBytes)
+	deadline := ioDeadline(ctx, sc.config.Timeout)
 
-	done := make(chan struct {
-		data []byte
-		err  error
-	}, 1)
+	for {
+		if err := contextExpired(ctx); err != nil {
+			return nil, err
+		}
 
-	go func() {
-		n, err := sc.port.Read(buffer)
-		result := struct {
-			data []byte
-			err  error
-		}{}
-
-		if err != nil {
-			if err == io.EOF {
-				result.data = buffer[:n]
-			} else {
-				result.err = fmt.Errorf("failed to read from serial port: %w", err)
+		wait := serialReadPoll
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return []byte{}, nil
+			}
+			if remaining < wait {
+				wait = remaining
 			}
-		} else {
-			result.data = make([]byte, n)
-			copy(result.data, buffer[:n])
 		}
-		done <- result
-	}()
-
-	select {
-	case result := <-done:
-		if result.err != nil {
-			sc.stats.ErrorCount++
-			return nil, result.err
+		if err := sc.port.SetReadTimeout(wait); err != nil {
+			sc.stats.recordReadError()
+			return nil, fmt.Errorf("failed to set serial read timeout: %w", err)
 		}
 
-		sc.stats.BytesRead += int64(len(result.data))
-		sc.stats.OperationCount++
-		sc.stats.LastActivity = time.Now()
-
-		return result.data, nil
-
-	case <-ctx.Done():
-		return nil, ctx.Err()
+		n, err := sc.port.Read(buffer)
+		if err != nil && err != io.EOF {
+			sc.stats.recordReadError()
+			return nil, fmt.Errorf("failed to read from serial port: %w", err)
+		}
+		if n > 0 || err == io.EOF {
+			sc.stats.recordRead(n)
+			return buffer[:n], nil
+		}
 	}
 }
 
@@ -230,11 +222,7 @@ func (sc *SerialConnection) Ping(ctx context.Context) error {
 	return sc.Write(ctx, pingData)
 }
 
-// updateAverageLatency updates the running average latency
-func (sc *SerialConnection) updateAverageLatency(newLatency time.Duration) {
-	if sc.stats.AverageLatency == 0 {
-		sc.stats.AverageLatency = newLatency
-	} else {
-		sc.stats.AverageLatency = (sc.stats.AverageLatency + newLatency) / 2
-	}
+// Stats returns a snapshot of the connection statistics
+func (sc *SerialConnection) Stats() ProtocolStats {
+	return sc.stats.snapshot()
 }
@@ -4,8 +4,11 @@ package protocol
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -14,6 +17,38 @@ import (
 	"device-service/internal/model"
 )
 
+// tlsCertDir is the directory the TLS files named in device connection
+// configs are read from. Empty rejects TLS files.
+var tlsCertDir struct {
+	mu  sync.RWMutex
+	dir string
+}
+
+// SetTLSCertDir sets the directory TLS ca_file, cert_file and key_file names
+// of device connection configs resolve under. Device configs are written
+// through the API, so they can't name arbitrary files on the server.
+func SetTLSCertDir(dir string) {
+	tlsCertDir.mu.Lock()
+	defer tlsCertDir.mu.Unlock()
+	tlsCertDir.dir = dir
+}
+
+// resolveTLSFile returns the path of a TLS file name under the certificate
+// directory, rejecting absolute paths and names leaving the directory
+func resolveTLSFile(name string) (string, error) {
+	tlsCertDir.mu.RLock()
+	dir := tlsCertDir.dir
+	tlsCertDir.mu.RUnlock()
+
+	if dir == "" {
+		return "", fmt.Errorf("TLS file %q: no device TLS certificate directory is configured", name)
+	}
+	if filepath.IsAbs(name) || !filepath.IsLocal(name) {
+		return "", fmt.Errorf("TLS file %q must be a relative path inside the certificate directory", name)
+	}
+	return filepath.Join(dir, name), nil
+}
+
 // TCPConnection implements DeviceProtocol for TCP connections
 type TCPConnection struct {
 	config *TCPConfig
@@ -21,7 +56,7 @@ type TCPConnection struct {
 	logger *zap.Logger
 	mutex  sync.RWMutex
 	isOpen bool
-	stats  *ProtocolStats
+	stats  *statsTracker
 }
 
 // NewTCPConnection creates a new TCP connection
@@ -33,9 +68,7 @@ func NewTCPConnection(config *TCPConfig, logger *zap.Logger) DeviceProtocol {
 			zap.String("host", config.Host),
 			zap.Int("port", config.Port),
 		),
-		stats: &ProtocolStats{
-			IsConnected: false,
-		},
+		stats: newStatsTracker(model.ConnectionTypeTCP),
 	}
 }
 
@@ -52,6 +85,7 @@ func (tc *TCPConnection) Open(ctx context.Context) error {
 		zap.String("host", tc.config.Host),
 		zap.Int("port", tc.config.Port),
 		zap.Bool("ssl", tc.config.SSL),
+		zap.Bool("insecure_skip_verify", tc.config.SSL && tc.config.TLS.InsecureSkipVerify),
 	)
 
 	// Create dialer with timeout
@@ -67,11 +101,13 @@ func (tc *TCPConnection) Open(ctx context.Context) error {
 
 	if tc.config.SSL {
 		// SSL/TLS connection
-		tlsConfig := &tls.Config{
-			ServerName:         tc.config.Host,
-			InsecureSkipVerify: false, // Set to true for testing only
+		tlsConfig, tlsErr := buildTLSConfig(tc.config)
+		if tlsErr != nil {
+			tc.logger.Error("Invalid TLS configuration", zap.Error(tlsErr))
+			return tlsErr
 		}
-		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: tlsConfig}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", address)
 	} else {
 		// Plain TCP connection
 		conn, err = dialer.DialContext(ctx, "tcp", address)
@@ -98,13 +134,62 @@ func (tc *TCPConnection) Open(ctx context.Context) error {
 
 	tc.conn = conn
 	tc.isOpen = true
-	tc.stats.IsConnected = true
-	tc.stats.LastActivity = time.Now()
+	tc.stats.recordOpen()
 
 	tc.logger.Info("TCP connection opened successfully")
 	return nil
 }
 
+// buildTLSConfig builds the client TLS config of a connection from its CA,
+// client certificate and verification settings. Files are read from the
+// TLS certificate directory.
+func buildTLSConfig(config *TCPConfig) (*tls.Config, error) {
+	settings := config.TLS
+
+	tlsConfig := &tls.Config{
+		ServerName:         config.Host,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: settings.InsecureSkipVerify,
+	}
+	if settings.ServerName != "" {
+		tlsConfig.ServerName = settings.ServerName
+	}
+
+	if settings.CAFile != "" {
+		caFile, err := resolveTLSFile(settings.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS CA file: %w", err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in TLS CA file %s", settings.CAFile)
+		}
+		tlsConfig.RootCAs = roots
+	}
+
+	if settings.CertFile != "" || settings.KeyFile != "" {
+		certFile, err := resolveTLSFile(settings.CertFile)
+		if err != nil {
+			return nil, err
+		}
+		keyFile, err := resolveTLSFile(settings.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
 // Close closes the TCP connection
 func (tc *TCPConnection) Close() error {
 	tc.mutex.Lock()
@@ -121,7 +206,7 @@ func (tc *TCPConnection) Close() error {
 
 	tc.conn = nil
 	tc.isOpen = false
-	tc.stats.IsConnected = false
+	tc.stats.recordClose()
 
 	tc.logger.Info("TCP connection closed successfully")
 	return nil
@@ -134,7 +219,9 @@ func (tc *TCPConnection) IsOpen() bool {
 	return tc.isOpen && tc.conn != nil
 }
 
-// Write writes data to the TCP connection
+// Write writes data to the TCP connection. The write deadline is the write
+// timeout or the context's deadline, whichever comes first, and a cancelled
+// context aborts a blocked write.
 func (tc *TCPConnection) Write(ctx context.Context, data []byte) error {
 	tc.mutex.RLock()
 	defer tc.mutex.RUnlock()
@@ -149,15 +236,17 @@ func (tc *TCPConnection) Write(ctx context.Context, data []byte) error {
 	default:
 	}
 
-	// Set write deadline
-	if tc.config.WriteTimeout > 0 {
-		tc.conn.SetWriteDeadline(time.Now().Add(tc.config.WriteTimeout))
-	}
+	tc.conn.SetWriteDeadline(ioDeadline(ctx, tc.config.WriteTimeout))
+	stop := interruptOnDone(ctx, tc.conn.SetWriteDeadline)
 
 	startTime := time.Now()
 	n, err := tc.conn.Write(data)
+	stop()
 	if err != nil {
-		tc.stats.ErrorCount++
+		tc.stats.recordWriteError()
+		if ctxErr := contextExpired(ctx); ctxErr != nil {
+			return fmt.Errorf("TCP write aborted: %w", ctxErr)
+		}
 		tc.logger.Error("TCP write failed", zap.Error(err))
 		return fmt.Errorf("failed to write to TCP connection: %w", err)
 	}
@@ -167,17 +256,16 @@ func (tc *TCPConnection) Write(ctx context.Context, data []byte) error {
 	}
 
 	// Update statistics
-	duration := time.Since(startTime)
-	tc.stats.BytesWritten += int64(len(data))
-	tc.stats.OperationCount++
-	tc.stats.LastActivity = time.Now()
-	tc.updateAverageLatency(duration)
+	tc.stats.recordWrite(len(data), time.Since(startTime))
 
 	tc.logger.Debug("TCP write completed", zap.Int("bytes", len(data)))
 	return nil
 }
 
-// Read reads data from the TCP connection
+// Read reads data from the TCP connection. The read deadline is the read
+// timeout or the context's deadline, whichever comes first, and a cancelled
+// context unblocks a pending read, so no read outlives its caller and
+// consumes a later response.
 func (tc *TCPConnection) Read(ctx context.Context, maxBytes int) ([]byte, error) {
 	tc.mutex.RLock()
 	defer tc.mutex.RUnlock()
@@ -186,50 +274,26 @@ func (tc *TCPConnection) Read(ctx context.Context, maxBytes int) ([]byte, error)
 		return nil, fmt.Errorf("TCP connection not open")
 	}
 
-	// Set read deadline
-	if tc.config.ReadTimeout > 0 {
-		tc.conn.SetReadDeadline(time.Now().Add(tc.config.ReadTimeout))
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	buffer := make([]byte, maxBytes)
-
-	done := make(chan struct {
-		data []byte
-		err  error
-	}, 1)
-
-	go func() {
-		n, err := tc.conn.Read(buffer)
-		result := struct {
-			data []byte
-			err  error
-		}{}
+	tc.conn.SetReadDeadline(ioDeadline(ctx, tc.config.ReadTimeout))
+	stop := interruptOnDone(ctx, tc.conn.SetReadDeadline)
 
-		if err != nil {
-			result.err = fmt.Errorf("failed to read from TCP connection: %w", err)
-		} else {
-			result.data = make([]byte, n)
-			copy(result.data, buffer[:n])
-		}
-		done <- result
-	}()
-
-	select {
-	case result := <-done:
-		if result.err != nil {
-			tc.stats.ErrorCount++
-			return nil, result.err
+	buffer := make([]byte, maxBytes)
+	n, err := tc.conn.Read(buffer)
+	stop()
+	if err != nil {
+		tc.stats.recordReadError()
+		if ctxErr := contextExpired(ctx); ctxErr != nil {
+			return nil, ctxErr
 		}
-
-		tc.stats.BytesRead += int64(len(result.data))
-		tc.stats.OperationCount++
-		tc.stats.LastActivity = time.Now()
-
-		return result.data, nil
-
-	case <-ctx.Done():
-		return nil, ctx.Err()
+		return nil, fmt.Errorf("failed to read from TCP connection: %w", err)
 	}
+
+	tc.stats.recordRead(n)
+	return buffer[:n], nil
 }
 
 // GetProtocolType returns the protocol type
@@ -248,11 +312,7 @@ func (tc *TCPConnection) Ping(ctx context.Context) error {
 	return tc.Write(ctx, pingData)
 }
 
-// updateAverageLatency updates the running average latency
-func (tc *TCPConnection) updateAverageLatency(newLatency time.Duration) {
-	if tc.stats.AverageLatency == 0 {
-		tc.stats.AverageLatency = newLatency
-	} else {
-		tc.stats.AverageLatency = (tc.stats.AverageLatency + newLatency) / 2
-	}
+// Stats returns a snapshot of the connection statistics
+func (tc *TCPConnection) Stats() ProtocolStats {
+	return tc.stats.snapshot()
 }
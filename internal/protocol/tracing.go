@@ -0,0 +1,84 @@
+// internal/protocol/tracing.go
+package protocol
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"device-service/internal/tracing"
+)
+
+// tracingProtocol wraps a protocol and records a span for each Open, Write
+// and Read made on behalf of a traced caller
+type tracingProtocol struct {
+	DeviceProtocol
+}
+
+// WithTracing wraps protocol so its I/O shows up in the trace of the
+// operation using it. Calls without a span in their context, like
+// background health pings, are not traced.
+func WithTracing(protocol DeviceProtocol) DeviceProtocol {
+	if protocol == nil {
+		return nil
+	}
+	return &tracingProtocol{DeviceProtocol: protocol}
+}
+
+// Open opens the connection inside a protocol.open span
+func (tp *tracingProtocol) Open(ctx context.Context) error {
+	if !traced(ctx) {
+		return tp.DeviceProtocol.Open(ctx)
+	}
+	ctx, span := tracing.Start(ctx, "protocol.open", tp.connectionType())
+	err := tp.DeviceProtocol.Open(ctx)
+	tracing.End(span, err)
+	return err
+}
+
+// Write writes data inside a protocol.write span
+func (tp *tracingProtocol) Write(ctx context.Context, data []byte) error {
+	if !traced(ctx) {
+		return tp.DeviceProtocol.Write(ctx, data)
+	}
+	ctx, span := tracing.Start(ctx, "protocol.write", tp.connectionType(), attribute.Int("protocol.bytes", len(data)))
+	err := tp.DeviceProtocol.Write(ctx, data)
+	tracing.End(span, err)
+	return err
+}
+
+// Read reads data inside a protocol.read span
+func (tp *tracingProtocol) Read(ctx context.Context, maxBytes int) ([]byte, error) {
+	if !traced(ctx) {
+		return tp.DeviceProtocol.Read(ctx, maxBytes)
+	}
+	ctx, span := tracing.Start(ctx, "protocol.read", tp.connectionType(), attribute.Int("protocol.max_bytes", maxBytes))
+	data, err := tp.DeviceProtocol.Read(ctx, maxBytes)
+	span.SetAttributes(attribute.Int("protocol.bytes", len(data)))
+	tracing.End(span, err)
+	return data, err
+}
+
+// CanRead forwards whether the wrapped connection can be read
+func (tp *tracingProtocol) CanRead() bool {
+	return CanRead(tp.DeviceProtocol)
+}
+
+// Stats forwards the statistics of the wrapped connection
+func (tp *tracingProtocol) Stats() ProtocolStats {
+	if provider, ok := tp.DeviceProtocol.(StatsProvider); ok {
+		return provider.Stats()
+	}
+	return ProtocolStats{}
+}
+
+// connectionType returns the span attribute naming the connection type
+func (tp *tracingProtocol) connectionType() attribute.KeyValue {
+	return attribute.String("protocol.connection_type", string(tp.GetProtocolType()))
+}
+
+// traced reports whether ctx belongs to a trace that is being recorded
+func traced(ctx context.Context) bool {
+	return trace.SpanFromContext(ctx).IsRecording()
+}
@@ -26,7 +26,7 @@ type USBConnection struct {
 	logger   *zap.Logger
 	mutex    sync.RWMutex
 	isOpen   bool
-	stats    *ProtocolStats
+	stats    *statsTracker
 }
 
 // NewUSBConnection creates a new USB connection
@@ -38,9 +38,7 @@ func NewUSBConnection(config *USBConfig, logger *zap.Logger) DeviceProtocol {
 			zap.String("vendor_id", config.VendorID),
 			zap.String("product_id", config.ProductID),
 		),
-		stats: &ProtocolStats{
-			IsConnected: false,
-		},
+		stats: newStatsTracker(model.ConnectionTypeUSB),
 	}
 }
 
@@ -108,8 +106,7 @@ func (uc *USBConnection) Open(ctx context.Context) error {
 	uc.outEndpt = outEndpt
 	uc.inEndpt = inEndpt
 	uc.isOpen = true
-	uc.stats.IsConnected = true
-	uc.stats.LastActivity = time.Now()
+	uc.stats.recordOpen()
 
 	uc.logger.Info("USB connection opened successfully")
 	return nil
@@ -142,7 +139,7 @@ func (uc *USBConnection) Close() error {
 	uc.outEndpt = nil
 	uc.inEndpt = nil
 	uc.isOpen = false
-	uc.stats.IsConnected = false
+	uc.stats.recordClose()
 
 	uc.logger.Info("USB connection closed successfully")
 	return nil
@@ -155,7 +152,8 @@ func (uc *USBConnection) IsOpen() bool {
 	return uc.isOpen && uc.device != nil && uc.outEndpt != nil
 }
 
-// Write writes data to the USB connection
+// Write writes data to the USB connection. The transfer is cancelled when the
+// connection timeout passes or the context ends.
 func (uc *USBConnection) Write(ctx context.Context, data []byte) error {
 	uc.mutex.RLock()
 	defer uc.mutex.RUnlock()
@@ -170,10 +168,16 @@ func (uc *USBConnection) Write(ctx context.Context, data []byte) error {
 	default:
 	}
 
+	ctx, cancel := uc.transferContext(ctx)
+	defer cancel()
+
 	startTime := time.Now()
-	n, err := uc.outEndpt.Write(data)
+	n, err := uc.outEndpt.WriteContext(ctx, data)
 	if err != nil {
-		uc.stats.ErrorCount++
+		uc.stats.recordWriteError()
+		if ctxErr := contextExpired(ctx); ctxErr != nil {
+			return fmt.Errorf("USB write aborted: %w", ctxErr)
+		}
 		uc.logger.Error("USB write failed", zap.Error(err))
 		return fmt.Errorf("failed to write to USB device: %w", err)
 	}
@@ -183,17 +187,14 @@ func (uc *USBConnection) Write(ctx context.Context, data []byte) error {
 	}
 
 	// Update statistics
-	duration := time.Since(startTime)
-	uc.stats.BytesWritten += int64(len(data))
-	uc.stats.OperationCount++
-	uc.stats.LastActivity = time.Now()
-	uc.updateAverageLatency(duration)
+	uc.stats.recordWrite(len(data), time.Since(startTime))
 
 	uc.logger.Debug("USB write completed", zap.Int("bytes", len(data)))
 	return nil
 }
 
-// Read reads data from the USB connection
+// Read reads data from the USB connection. The transfer is cancelled when the
+// connection timeout passes or the context ends.
 func (uc *USBConnection) Read(ctx context.Context, maxBytes int) ([]byte, error) {
 	uc.mutex.RLock()
 	defer uc.mutex.RUnlock()
@@ -202,45 +203,39 @@ func (uc *USBConnection) Read(ctx context.Context, maxBytes int) ([]byte, error)
 		return nil, fmt.Errorf("USB connection not open or no in endpoint")
 	}
 
-	buffer := make([]byte, maxBytes)
-
-	done := make(chan struct {
-		data []byte
-		err  error
-	}, 1)
-
-	go func() {
-		n, err := uc.inEndpt.Read(buffer)
-		result := struct {
-			data []byte
-			err  error
-		}{}
-
-		if err != nil {
-			result.err = fmt.Errorf("failed to read from USB device: %w", err)
-		} else {
-			result.data = make([]byte, n)
-			copy(result.data, buffer[:n])
-		}
-		done <- result
-	}()
+	ctx, cancel := uc.transferContext(ctx)
+	defer cancel()
 
-	select {
-	case result := <-done:
-		if result.err != nil {
-			uc.stats.ErrorCount++
-			return nil, result.err
+	buffer := make([]byte, maxBytes)
+	n, err := uc.inEndpt.ReadContext(ctx, buffer)
+	if err != nil {
+		uc.stats.recordReadError()
+		if ctxErr := contextExpired(ctx); ctxErr != nil {
+			return nil, ctxErr
 		}
+		return nil, fmt.Errorf("failed to read from USB device: %w", err)
+	}
 
-		uc.stats.BytesRead += int64(len(result.data))
-		uc.stats.OperationCount++
-		uc.stats.LastActivity = time.Now()
-
-		return result.data, nil
+	uc.stats.recordRead(n)
+	return buffer[:n], nil
+}
 
-	case <-ctx.Done():
-		return nil, ctx.Err()
+// transferContext bounds a USB transfer by the connection timeout or the
+// context's deadline, whichever comes first. Cancelling it cancels the
+// transfer, so no transfer outlives its caller.
+func (uc *USBConnection) transferContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline := ioDeadline(ctx, uc.config.Timeout)
+	if deadline.IsZero() {
+		return context.WithCancel(ctx)
 	}
+	return context.WithDeadline(ctx, deadline)
+}
+
+// CanRead reports whether the device has a bulk IN endpoint to read from
+func (uc *USBConnection) CanRead() bool {
+	uc.mutex.RLock()
+	defer uc.mutex.RUnlock()
+	return uc.inEndpt != nil
 }
 
 // GetProtocolType returns the protocol type
@@ -327,11 +322,7 @@ func (uc *USBConnection) findAndOpenDevice(vendorID, productID gousb.ID) (*gousb
 	return devices[0], nil
 }
 
-// updateAverageLatency updates the running average latency
-func (uc *USBConnection) updateAverageLatency(newLatency time.Duration) {
-	if uc.stats.AverageLatency == 0 {
-		uc.stats.AverageLatency = newLatency
-	} else {
-		uc.stats.AverageLatency = (uc.stats.AverageLatency + newLatency) / 2
-	}
+// Stats returns a snapshot of the connection statistics
+func (uc *USBConnection) Stats() ProtocolStats {
+	return uc.stats.snapshot()
 }
@@ -0,0 +1,191 @@
+// internal/protocol/virtual_connection.go
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"device-service/internal/model"
+)
+
+// CaptureIDKey names the connection config value identifying the capture a
+// virtual connection writes to. Drivers set it to the device ID.
+const CaptureIDKey = "capture_id"
+
+// defaultVirtualMaxBytes bounds the output kept in memory per capture
+const defaultVirtualMaxBytes = 1 << 20
+
+// captures holds the output written to virtual connections by capture ID.
+// It outlives the connections, so output is kept across reconnects and
+// idle evictions from the driver pool. It is cleared when the device's
+// driver is removed from the pool, on disconnect or reconnect, and when the
+// device is deleted.
+var captures = struct {
+	mu      sync.Mutex
+	buffers map[string][]byte
+}{buffers: make(map[string][]byte)}
+
+// CapturedOutput returns a copy of the bytes written to a capture, and false
+// when nothing has connected to it since startup or the last clear
+func CapturedOutput(captureID string) ([]byte, bool) {
+	captures.mu.Lock()
+	defer captures.mu.Unlock()
+
+	buffer, ok := captures.buffers[captureID]
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), buffer...), true
+}
+
+// ClearCapturedOutput discards the bytes written to a capture
+func ClearCapturedOutput(captureID string) {
+	captures.mu.Lock()
+	defer captures.mu.Unlock()
+	delete(captures.buffers, captureID)
+}
+
+// WithCaptureID returns config with the capture ID of a virtual connection
+// set to deviceID. Configs of other connection types are returned as is.
+func WithCaptureID(connectionType model.ConnectionType, config map[string]interface{}, deviceID string) map[string]interface{} {
+	if connectionType != model.ConnectionTypeVirtual {
+		return config
+	}
+
+	withID := make(map[string]interface{}, len(config)+1)
+	for key, value := range config {
+		withID[key] = value
+	}
+	withID[CaptureIDKey] = deviceID
+	return withID
+}
+
+// VirtualConnection implements DeviceProtocol without hardware: the bytes
+// written are captured in memory so print output can be checked without a
+// printer. Nothing can be read back, so status queries report the device as
+// write-only.
+type VirtualConnection struct {
+	config *VirtualConfig
+	logger *zap.Logger
+	mutex  sync.RWMutex
+	isOpen bool
+	stats  *statsTracker
+}
+
+// NewVirtualConnection creates a new virtual connection
+func NewVirtualConnection(config *VirtualConfig, logger *zap.Logger) DeviceProtocol {
+	return &VirtualConnection{
+		config: config,
+		logger: logger.With(
+			zap.String("protocol", "virtual"),
+			zap.String("capture_id", config.CaptureID),
+		),
+		stats: newStatsTracker(model.ConnectionTypeVirtual),
+	}
+}
+
+// Open opens the capture
+func (vc *VirtualConnection) Open(ctx context.Context) error {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	if vc.isOpen {
+		return nil
+	}
+
+	captures.mu.Lock()
+	if _, ok := captures.buffers[vc.config.CaptureID]; !ok {
+		captures.buffers[vc.config.CaptureID] = []byte{}
+	}
+	captures.mu.Unlock()
+
+	vc.isOpen = true
+	vc.stats.recordOpen()
+
+	vc.logger.Info("Virtual connection opened")
+	return nil
+}
+
+// Close closes the connection. The captured output is kept.
+func (vc *VirtualConnection) Close() error {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+
+	if !vc.isOpen {
+		return nil
+	}
+
+	vc.isOpen = false
+	vc.stats.recordClose()
+
+	vc.logger.Info("Virtual connection closed")
+	return nil
+}
+
+// IsOpen returns whether the connection is open
+func (vc *VirtualConnection) IsOpen() bool {
+	vc.mutex.RLock()
+	defer vc.mutex.RUnlock()
+	return vc.isOpen
+}
+
+// Write appends data to the capture, dropping the oldest bytes beyond the
+// size limit
+func (vc *VirtualConnection) Write(ctx context.Context, data []byte) error {
+	vc.mutex.RLock()
+	defer vc.mutex.RUnlock()
+
+	if !vc.isOpen {
+		return fmt.Errorf("virtual connection not open")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	startTime := time.Now()
+	captures.mu.Lock()
+	buffer := append(captures.buffers[vc.config.CaptureID], data...)
+	if excess := len(buffer) - vc.config.MaxBytes; excess > 0 {
+		buffer = append([]byte(nil), buffer[excess:]...)
+	}
+	captures.buffers[vc.config.CaptureID] = buffer
+	captures.mu.Unlock()
+
+	vc.stats.recordWrite(len(data), time.Since(startTime))
+
+	vc.logger.Debug("Virtual write captured", zap.Int("bytes", len(data)))
+	return nil
+}
+
+// Read always fails: a virtual device never answers
+func (vc *VirtualConnection) Read(ctx context.Context, maxBytes int) ([]byte, error) {
+	return nil, fmt.Errorf("virtual connection is write-only")
+}
+
+// CanRead reports that nothing can be read back from a virtual device
+func (vc *VirtualConnection) CanRead() bool {
+	return false
+}
+
+// GetProtocolType returns the protocol type
+func (vc *VirtualConnection) GetProtocolType() model.ConnectionType {
+	return model.ConnectionTypeVirtual
+}
+
+// Ping tests the connection without writing to the capture
+func (vc *VirtualConnection) Ping(ctx context.Context) error {
+	if !vc.IsOpen() {
+		return fmt.Errorf("virtual connection not open")
+	}
+	return nil
+}
+
+// Stats returns a snapshot of the connection statistics
+func (vc *VirtualConnection) Stats() ProtocolStats {
+	return vc.stats.snapshot()
+}
@@ -0,0 +1,127 @@
+// internal/protocol/wire_log.go
+package protocol
+
+import (
+	"context"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Wire log limits: each dump is cut to wireLogMaxBytes and at most
+// wireLogRatePerSecond dumps are logged per device and second
+const (
+	wireLogMaxBytes      = 256
+	wireLogRatePerSecond = 20
+)
+
+// WireLogger logs hex dumps of the bytes a connection writes and reads. It is
+// off by default and can be toggled at runtime for a single device.
+type WireLogger struct {
+	enabled atomic.Bool
+	logger  *zap.Logger
+
+	mu          sync.Mutex
+	windowStart time.Time
+	logged      int
+	suppressed  int
+}
+
+// NewWireLogger creates a disabled wire logger writing to the device logger
+func NewWireLogger(logger *zap.Logger) *WireLogger {
+	return &WireLogger{logger: logger}
+}
+
+// SetEnabled turns wire logging on or off
+func (wl *WireLogger) SetEnabled(enabled bool) {
+	if wl.enabled.Swap(enabled) != enabled {
+		wl.logger.Info("Protocol wire logging toggled", zap.Bool("enabled", enabled))
+	}
+}
+
+// Enabled reports whether wire logging is on
+func (wl *WireLogger) Enabled() bool {
+	return wl.enabled.Load()
+}
+
+// log writes one truncated hex dump, subject to the rate limit
+func (wl *WireLogger) log(direction string, data []byte) {
+	if !wl.enabled.Load() || len(data) == 0 {
+		return
+	}
+
+	wl.mu.Lock()
+	now := time.Now()
+	if now.Sub(wl.windowStart) >= time.Second {
+		if wl.suppressed > 0 {
+			wl.logger.Debug("Protocol wire log entries suppressed", zap.Int("suppressed", wl.suppressed))
+		}
+		wl.windowStart = now
+		wl.logged = 0
+		wl.suppressed = 0
+	}
+	if wl.logged >= wireLogRatePerSecond {
+		wl.suppressed++
+		wl.mu.Unlock()
+		return
+	}
+	wl.logged++
+	wl.mu.Unlock()
+
+	dump := data
+	if len(dump) > wireLogMaxBytes {
+		dump = dump[:wireLogMaxBytes]
+	}
+
+	// Debug level would hide the dumps on production loggers; the toggle is
+	// what keeps the volume down
+	wl.logger.Info("Protocol wire data",
+		zap.String("direction", direction),
+		zap.Int("length", len(data)),
+		zap.Bool("truncated", len(data) > wireLogMaxBytes),
+		zap.String("hex", hex.EncodeToString(dump)),
+	)
+}
+
+// wireLogProtocol wraps a protocol and reports its traffic to a WireLogger
+type wireLogProtocol struct {
+	DeviceProtocol
+	wireLogger *WireLogger
+}
+
+// WithWireLog wraps protocol so every Write/Read is passed to wireLogger
+func WithWireLog(protocol DeviceProtocol, wireLogger *WireLogger) DeviceProtocol {
+	if protocol == nil || wireLogger == nil {
+		return protocol
+	}
+	return &wireLogProtocol{DeviceProtocol: protocol, wireLogger: wireLogger}
+}
+
+// Write logs and writes data
+func (wp *wireLogProtocol) Write(ctx context.Context, data []byte) error {
+	wp.wireLogger.log("write", data)
+	return wp.DeviceProtocol.Write(ctx, data)
+}
+
+// Read reads data and logs what was received
+func (wp *wireLogProtocol) Read(ctx context.Context, maxBytes int) ([]byte, error) {
+	data, err := wp.DeviceProtocol.Read(ctx, maxBytes)
+	wp.wireLogger.log("read", data)
+	return data, err
+}
+
+// CanRead forwards whether the wrapped connection can be read
+func (wp *wireLogProtocol) CanRead() bool {
+	return CanRead(wp.DeviceProtocol)
+}
+
+// Stats forwards the statistics of the wrapped connection
+func (wp *wireLogProtocol) Stats() ProtocolStats {
+	if provider, ok := wp.DeviceProtocol.(StatsProvider); ok {
+		return provider.Stats()
+	}
+	return ProtocolStats{}
+}
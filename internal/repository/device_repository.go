@@ -4,6 +4,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -15,6 +16,10 @@ import (
 	"device-service/internal/model"
 )
 
+// ErrDeviceNotFound is returned when no device of the caller's tenant has
+// the requested ID
+var ErrDeviceNotFound = errors.New("device not found")
+
 // deviceRepository implements DeviceRepository interface
 type deviceRepository struct {
 	db     *database.DB
@@ -33,17 +38,18 @@ func NewDeviceRepository(db *database.DB, logger *zap.Logger) DeviceRepository {
 func (r *deviceRepository) Create(ctx context.Context, device *model.Device) error {
 	query := `
 		INSERT INTO devices (
-			id, device_id, device_type, brand, model, firmware_version,
+			id, device_id, name, device_type, brand, model, firmware_version,
 			connection_type, connection_config, capabilities, branch_id,
-			location, status, error_info, performance_metrics
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+			location, status, error_info, performance_metrics, tenant_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
-		device.ID, device.DeviceID, device.DeviceType, device.Brand,
+		device.ID, device.DeviceID, device.Name, device.DeviceType, device.Brand,
 		device.Model, device.FirmwareVersion, device.ConnectionType,
 		device.ConnectionConfig, device.Capabilities, device.BranchID,
 		device.Location, device.Status, device.ErrorInfo, device.PerformanceMetrics,
+		device.TenantID,
 	)
 
 	if err != nil {
@@ -58,25 +64,24 @@ func (r *deviceRepository) Create(ctx context.Context, device *model.Device) err
 // GetByID retrieves a device by its UUID
 func (r *deviceRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Device, error) {
 	query := `
-		SELECT id, device_id, device_type, brand, model, firmware_version,
+		SELECT id, device_id, name, device_type, brand, model, firmware_version,
 			   connection_type, connection_config, capabilities, branch_id,
 			   location, status, last_ping, error_info, performance_metrics,
-			   created_at, updated_at
-		FROM devices WHERE id = $1
-	`
+			   created_at, updated_at, tenant_id
+		FROM devices WHERE id = $1 AND ` + tenantCondition("tenant_id", 2)
 
 	device := &model.Device{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&device.ID, &device.DeviceID, &device.DeviceType, &device.Brand,
+	err := r.db.QueryRowContext(ctx, query, id, tenantArg(ctx)).Scan(
+		&device.ID, &device.DeviceID, &device.Name, &device.DeviceType, &device.Brand,
 		&device.Model, &device.FirmwareVersion, &device.ConnectionType,
 		&device.ConnectionConfig, &device.Capabilities, &device.BranchID,
 		&device.Location, &device.Status, &device.LastPing, &device.ErrorInfo,
-		&device.PerformanceMetrics, &device.CreatedAt, &device.UpdatedAt,
+		&device.PerformanceMetrics, &device.CreatedAt, &device.UpdatedAt, &device.TenantID,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("device not found with id: %s", id)
+			return nil, fmt.Errorf("%w with id: %s", ErrDeviceNotFound, id)
 		}
 		r.logger.Error("Failed to get device by ID", zap.Error(err), zap.String("id", id.String()))
 		return nil, fmt.Errorf("failed to get device: %w", err)
@@ -88,25 +93,24 @@ func (r *deviceRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.De
 // GetByDeviceID retrieves a device by its device ID
 func (r *deviceRepository) GetByDeviceID(ctx context.Context, deviceID string) (*model.Device, error) {
 	query := `
-		SELECT id, device_id, device_type, brand, model, firmware_version,
+		SELECT id, device_id, name, device_type, brand, model, firmware_version,
 			   connection_type, connection_config, capabilities, branch_id,
 			   location, status, last_ping, error_info, performance_metrics,
-			   created_at, updated_at
-		FROM devices WHERE device_id = $1
-	`
+			   created_at, updated_at, tenant_id
+		FROM devices WHERE device_id = $1 AND ` + tenantCondition("tenant_id", 2)
 
 	device := &model.Device{}
-	err := r.db.QueryRowContext(ctx, query, deviceID).Scan(
-		&device.ID, &device.DeviceID, &device.DeviceType, &device.Brand,
+	err := r.db.QueryRowContext(ctx, query, deviceID, tenantArg(ctx)).Scan(
+		&device.ID, &device.DeviceID, &device.Name, &device.DeviceType, &device.Brand,
 		&device.Model, &device.FirmwareVersion, &device.ConnectionType,
 		&device.ConnectionConfig, &device.Capabilities, &device.BranchID,
 		&device.Location, &device.Status, &device.LastPing, &device.ErrorInfo,
-		&device.PerformanceMetrics, &device.CreatedAt, &device.UpdatedAt,
+		&device.PerformanceMetrics, &device.CreatedAt, &device.UpdatedAt, &device.TenantID,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("device not found with device_id: %s", deviceID)
+			return nil, fmt.Errorf("%w with device_id: %s", ErrDeviceNotFound, deviceID)
 		}
 		r.logger.Error("Failed to get device by device_id", zap.Error(err), zap.String("device_id", deviceID))
 		return nil, fmt.Errorf("failed to get device: %w", err)
@@ -115,22 +119,35 @@ func (r *deviceRepository) GetByDeviceID(ctx context.Context, deviceID string) (
 	return device, nil
 }
 
-// Update updates an existing device
+// Update updates an existing device, recording a status event when its
+// status changes. The event reason is the device's last error, if any.
 func (r *deviceRepository) Update(ctx context.Context, device *model.Device) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := recordStatusChange(ctx, tx, device.ID, device.Status, statusReason(device)); err != nil {
+		r.logger.Error("Failed to record device status change", zap.Error(err), zap.String("device_id", device.DeviceID))
+		return err
+	}
+
 	query := `
 		UPDATE devices SET
 			device_type = $2, brand = $3, model = $4, firmware_version = $5,
 			connection_type = $6, connection_config = $7, capabilities = $8,
 			branch_id = $9, location = $10, status = $11, last_ping = $12,
-			error_info = $13, performance_metrics = $14, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1
-	`
+			error_info = $13, performance_metrics = $14, name = $15,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND ` + tenantCondition("tenant_id", 16)
 
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := tx.ExecContext(ctx, query,
 		device.ID, device.DeviceType, device.Brand, device.Model,
 		device.FirmwareVersion, device.ConnectionType, device.ConnectionConfig,
 		device.Capabilities, device.BranchID, device.Location, device.Status,
-		device.LastPing, device.ErrorInfo, device.PerformanceMetrics,
+		device.LastPing, device.ErrorInfo, device.PerformanceMetrics, device.Name,
+		tenantArg(ctx),
 	)
 
 	if err != nil {
@@ -147,18 +164,32 @@ func (r *deviceRepository) Update(ctx context.Context, device *model.Device) err
 		return fmt.Errorf("device not found with id: %s", device.ID)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit device update: %w", err)
+	}
+
 	r.logger.Debug("Device updated successfully", zap.String("device_id", device.DeviceID))
 	return nil
 }
 
-// UpdateStatus updates device status
-func (r *deviceRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status model.DeviceStatus) error {
+// UpdateStatus updates device status and records the transition with its reason
+func (r *deviceRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status model.DeviceStatus, reason string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := recordStatusChange(ctx, tx, id, status, reason); err != nil {
+		r.logger.Error("Failed to record device status change", zap.Error(err), zap.String("id", id.String()))
+		return err
+	}
+
 	query := `
 		UPDATE devices SET status = $2, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $1
-	`
+		WHERE id = $1 AND ` + tenantCondition("tenant_id", 3)
 
-	result, err := r.db.ExecContext(ctx, query, id, status)
+	result, err := tx.ExecContext(ctx, query, id, status, tenantArg(ctx))
 	if err != nil {
 		r.logger.Error("Failed to update device status", zap.Error(err), zap.String("id", id.String()))
 		return fmt.Errorf("failed to update device status: %w", err)
@@ -173,14 +204,68 @@ func (r *deviceRepository) UpdateStatus(ctx context.Context, id uuid.UUID, statu
 		return fmt.Errorf("device not found with id: %s", id)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit device status: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateError puts a device in ERROR status with its error info, recording the
+// transition with the info's last error as reason
+func (r *deviceRepository) UpdateError(ctx context.Context, id uuid.UUID, errorInfo model.JSONObject) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	reason, _ := errorInfo["last_error"].(string)
+	if err := recordStatusChange(ctx, tx, id, model.DeviceStatusError, reason); err != nil {
+		r.logger.Error("Failed to record device status change", zap.Error(err), zap.String("id", id.String()))
+		return err
+	}
+
+	query := `
+		UPDATE devices SET status = $2, error_info = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND ` + tenantCondition("tenant_id", 4)
+
+	result, err := tx.ExecContext(ctx, query, id, model.DeviceStatusError, errorInfo, tenantArg(ctx))
+	if err != nil {
+		r.logger.Error("Failed to update device error", zap.Error(err), zap.String("id", id.String()))
+		return fmt.Errorf("failed to update device error: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("device not found with id: %s", id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit device error: %w", err)
+	}
+
 	return nil
 }
 
+// statusReason returns why a device is in its status as far as the device
+// itself records it
+func statusReason(device *model.Device) string {
+	if lastError, ok := device.ErrorInfo["last_error"].(string); ok {
+		return lastError
+	}
+	return ""
+}
+
 // Delete removes a device
 func (r *deviceRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM devices WHERE id = $1`
+	query := `DELETE FROM devices WHERE id = $1 AND ` + tenantCondition("tenant_id", 2)
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.db.ExecContext(ctx, query, id, tenantArg(ctx))
 	if err != nil {
 		r.logger.Error("Failed to delete device", zap.Error(err), zap.String("id", id.String()))
 		return fmt.Errorf("failed to delete device: %w", err)
@@ -201,10 +286,16 @@ func (r *deviceRepository) Delete(ctx context.Context, id uuid.UUID) error {
 
 // List retrieves devices with filtering and pagination
 func (r *deviceRepository) List(ctx context.Context, filter *DeviceFilter) ([]*model.Device, int, error) {
-	// Build WHERE clause
-	whereConditions := []string{}
-	args := []interface{}{}
-	argIndex := 1
+	// Build WHERE clause, always scoped to the caller's tenant
+	whereConditions := []string{tenantCondition("tenant_id", 1)}
+	args := []interface{}{tenantArg(ctx)}
+	argIndex := 2
+
+	if filter.TenantID != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("tenant_id = $%d", argIndex))
+		args = append(args, *filter.TenantID)
+		argIndex++
+	}
 
 	if filter.BranchID != nil {
 		whereConditions = append(whereConditions, fmt.Sprintf("branch_id = $%d", argIndex))
@@ -237,7 +328,7 @@ func (r *deviceRepository) List(ctx context.Context, filter *DeviceFilter) ([]*m
 	}
 
 	if filter.SearchTerm != nil {
-		whereConditions = append(whereConditions, fmt.Sprintf("(device_id ILIKE $%d OR model ILIKE $%d)", argIndex, argIndex))
+		whereConditions = append(whereConditions, fmt.Sprintf("(device_id ILIKE $%d OR name ILIKE $%d OR model ILIKE $%d)", argIndex, argIndex, argIndex))
 		args = append(args, "%"+*filter.SearchTerm+"%")
 		argIndex++
 	}
@@ -269,10 +360,10 @@ func (r *deviceRepository) List(ctx context.Context, filter *DeviceFilter) ([]*m
 	// Build main query with pagination
 	offset := (filter.Page - 1) * filter.PerPage
 	query := fmt.Sprintf(`
-		SELECT id, device_id, device_type, brand, model, firmware_version,
+		SELECT id, device_id, name, device_type, brand, model, firmware_version,
 			   connection_type, connection_config, capabilities, branch_id,
 			   location, status, last_ping, error_info, performance_metrics,
-			   created_at, updated_at
+			   created_at, updated_at, tenant_id
 		FROM devices %s
 		ORDER BY %s
 		LIMIT $%d OFFSET $%d
@@ -291,11 +382,11 @@ func (r *deviceRepository) List(ctx context.Context, filter *DeviceFilter) ([]*m
 	for rows.Next() {
 		device := &model.Device{}
 		err := rows.Scan(
-			&device.ID, &device.DeviceID, &device.DeviceType, &device.Brand,
+			&device.ID, &device.DeviceID, &device.Name, &device.DeviceType, &device.Brand,
 			&device.Model, &device.FirmwareVersion, &device.ConnectionType,
 			&device.ConnectionConfig, &device.Capabilities, &device.BranchID,
 			&device.Location, &device.Status, &device.LastPing, &device.ErrorInfo,
-			&device.PerformanceMetrics, &device.CreatedAt, &device.UpdatedAt,
+			&device.PerformanceMetrics, &device.CreatedAt, &device.UpdatedAt, &device.TenantID,
 		)
 		if err != nil {
 			r.logger.Error("Failed to scan device row", zap.Error(err))
@@ -314,16 +405,16 @@ func (r *deviceRepository) List(ctx context.Context, filter *DeviceFilter) ([]*m
 // ListByBranch retrieves devices by branch
 func (r *deviceRepository) ListByBranch(ctx context.Context, branchID uuid.UUID) ([]*model.Device, error) {
 	query := `
-		SELECT id, device_id, device_type, brand, model, firmware_version,
+		SELECT id, device_id, name, device_type, brand, model, firmware_version,
 			   connection_type, connection_config, capabilities, branch_id,
 			   location, status, last_ping, error_info, performance_metrics,
-			   created_at, updated_at
+			   created_at, updated_at, tenant_id
 		FROM devices 
-		WHERE branch_id = $1
+		WHERE branch_id = $1 AND ` + tenantCondition("tenant_id", 2) + `
 		ORDER BY device_type, device_id
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, branchID)
+	rows, err := r.db.QueryContext(ctx, query, branchID, tenantArg(ctx))
 	if err != nil {
 		r.logger.Error("Failed to list devices by branch", zap.Error(err))
 		return nil, fmt.Errorf("failed to list devices by branch: %w", err)
@@ -334,11 +425,11 @@ func (r *deviceRepository) ListByBranch(ctx context.Context, branchID uuid.UUID)
 	for rows.Next() {
 		device := &model.Device{}
 		err := rows.Scan(
-			&device.ID, &device.DeviceID, &device.DeviceType, &device.Brand,
+			&device.ID, &device.DeviceID, &device.Name, &device.DeviceType, &device.Brand,
 			&device.Model, &device.FirmwareVersion, &device.ConnectionType,
 			&device.ConnectionConfig, &device.Capabilities, &device.BranchID,
 			&device.Location, &device.Status, &device.LastPing, &device.ErrorInfo,
-			&device.PerformanceMetrics, &device.CreatedAt, &device.UpdatedAt,
+			&device.PerformanceMetrics, &device.CreatedAt, &device.UpdatedAt, &device.TenantID,
 		)
 		if err != nil {
 			r.logger.Error("Failed to scan device row", zap.Error(err))
@@ -353,16 +444,16 @@ func (r *deviceRepository) ListByBranch(ctx context.Context, branchID uuid.UUID)
 // ListByStatus retrieves devices by status
 func (r *deviceRepository) ListByStatus(ctx context.Context, status model.DeviceStatus) ([]*model.Device, error) {
 	query := `
-		SELECT id, device_id, device_type, brand, model, firmware_version,
+		SELECT id, device_id, name, device_type, brand, model, firmware_version,
 			   connection_type, connection_config, capabilities, branch_id,
 			   location, status, last_ping, error_info, performance_metrics,
-			   created_at, updated_at
+			   created_at, updated_at, tenant_id
 		FROM devices 
-		WHERE status = $1
+		WHERE status = $1 AND ` + tenantCondition("tenant_id", 2) + `
 		ORDER BY last_ping DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, status)
+	rows, err := r.db.QueryContext(ctx, query, status, tenantArg(ctx))
 	if err != nil {
 		r.logger.Error("Failed to list devices by status", zap.Error(err))
 		return nil, fmt.Errorf("failed to list devices by status: %w", err)
@@ -373,11 +464,11 @@ func (r *deviceRepository) ListByStatus(ctx context.Context, status model.Device
 	for rows.Next() {
 		device := &model.Device{}
 		err := rows.Scan(
-			&device.ID, &device.DeviceID, &device.DeviceType, &device.Brand,
+			&device.ID, &device.DeviceID, &device.Name, &device.DeviceType, &device.Brand,
 			&device.Model, &device.FirmwareVersion, &device.ConnectionType,
 			&device.ConnectionConfig, &device.Capabilities, &device.BranchID,
 			&device.Location, &device.Status, &device.LastPing, &device.ErrorInfo,
-			&device.PerformanceMetrics, &device.CreatedAt, &device.UpdatedAt,
+			&device.PerformanceMetrics, &device.CreatedAt, &device.UpdatedAt, &device.TenantID,
 		)
 		if err != nil {
 			r.logger.Error("Failed to scan device row", zap.Error(err))
@@ -490,25 +581,52 @@ func (r *deviceRepository) UpdateMultipleStatus(ctx context.Context, deviceIDs [
 
 	// Build placeholders for IN clause
 	placeholders := make([]string, len(deviceIDs))
-	args := make([]interface{}, len(deviceIDs)+1)
+	args := make([]interface{}, len(deviceIDs)+2)
 
 	for i, id := range deviceIDs {
 		placeholders[i] = fmt.Sprintf("$%d", i+1)
 		args[i] = id
 	}
 	args[len(deviceIDs)] = status
+	args[len(deviceIDs)+1] = tenantArg(ctx)
+	tenantScope := tenantCondition("tenant_id", len(deviceIDs)+2)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Record the transitions of devices whose status changes, locking them
+	// until the update below replaces the recorded old status
+	eventQuery := fmt.Sprintf(`
+		INSERT INTO device_status_events (device_id, old_status, new_status)
+		SELECT id, status, $%d FROM (
+			SELECT id, status FROM devices WHERE id IN (%s) AND %s FOR UPDATE
+		) locked
+		WHERE status <> $%d
+	`, len(deviceIDs)+1, strings.Join(placeholders, ","), tenantScope, len(deviceIDs)+1)
+
+	if _, err := tx.ExecContext(ctx, eventQuery, args...); err != nil {
+		r.logger.Error("Failed to record multiple device status changes", zap.Error(err))
+		return fmt.Errorf("failed to record device status events: %w", err)
+	}
 
 	query := fmt.Sprintf(`
 		UPDATE devices SET status = $%d, updated_at = CURRENT_TIMESTAMP
-		WHERE id IN (%s)
-	`, len(deviceIDs)+1, strings.Join(placeholders, ","))
+		WHERE id IN (%s) AND %s
+	`, len(deviceIDs)+1, strings.Join(placeholders, ","), tenantScope)
 
-	result, err := r.db.ExecContext(ctx, query, args...)
+	result, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
 		r.logger.Error("Failed to update multiple device status", zap.Error(err))
 		return fmt.Errorf("failed to update multiple device status: %w", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit multiple device status: %w", err)
+	}
+
 	rowsAffected, _ := result.RowsAffected()
 	r.logger.Info("Updated multiple device status",
 		zap.Int64("rows_affected", rowsAffected),
@@ -520,10 +638,10 @@ func (r *deviceRepository) UpdateMultipleStatus(ctx context.Context, deviceIDs [
 
 // GetDeviceStats retrieves device statistics
 func (r *deviceRepository) GetDeviceStats(ctx context.Context, branchID *uuid.UUID) (*DeviceStats, error) {
-	whereClause := ""
-	args := []interface{}{}
+	whereClause := "WHERE " + tenantCondition("tenant_id", 1)
+	args := []interface{}{tenantArg(ctx)}
 	if branchID != nil {
-		whereClause = "WHERE branch_id = $1"
+		whereClause += " AND branch_id = $2"
 		args = append(args, *branchID)
 	}
 
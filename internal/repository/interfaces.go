@@ -17,7 +17,8 @@ type DeviceRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*model.Device, error)
 	GetByDeviceID(ctx context.Context, deviceID string) (*model.Device, error)
 	Update(ctx context.Context, device *model.Device) error
-	UpdateStatus(ctx context.Context, id uuid.UUID, status model.DeviceStatus) error
+	UpdateStatus(ctx context.Context, id uuid.UUID, status model.DeviceStatus, reason string) error
+	UpdateError(ctx context.Context, id uuid.UUID, errorInfo model.JSONObject) error
 	Delete(ctx context.Context, id uuid.UUID) error
 
 	// Listing and filtering
@@ -44,20 +45,40 @@ type OperationRepository interface {
 	UpdateStatus(ctx context.Context, id uuid.UUID, status model.OperationStatus) error
 	Delete(ctx context.Context, id uuid.UUID) error
 
+	// TransitionStatus atomically persists the operation only if its stored
+	// status is one of from; otherwise it returns ErrStatusConflict
+	TransitionStatus(ctx context.Context, operation *model.DeviceOperation, from ...model.OperationStatus) error
+
 	// Listing and filtering
 	List(ctx context.Context, filter *OperationFilter) ([]*model.DeviceOperation, int, error)
 	ListByDevice(ctx context.Context, deviceID uuid.UUID, limit int) ([]*model.DeviceOperation, error)
 	ListByCorrelation(ctx context.Context, correlationID uuid.UUID) ([]*model.DeviceOperation, error)
 	ListPending(ctx context.Context, priority *model.OperationPriority) ([]*model.DeviceOperation, error)
+	ListActive(ctx context.Context, createdBefore time.Time) ([]*model.DeviceOperation, error)
 
 	// Analytics and reporting
 	GetOperationStats(ctx context.Context, filter *OperationStatsFilter) (*OperationStats, error)
 	GetDeviceOperationSummary(ctx context.Context, deviceID uuid.UUID, period time.Duration) (*OperationSummary, error)
+	GroupErrors(ctx context.Context, filter *OperationFilter, limit int) ([]*OperationErrorGroup, error)
+	GetOperationTimeSeries(ctx context.Context, filter *OperationTimeSeriesFilter) ([]*OperationTimeBucket, error)
 
 	// Cleanup
 	DeleteOldOperations(ctx context.Context, olderThan time.Time) (int64, error)
 }
 
+// StatusEventRepository defines device status history access. Events are
+// written by DeviceRepository as part of each status change.
+type StatusEventRepository interface {
+	ListByDevice(ctx context.Context, deviceID uuid.UUID, filter *StatusEventFilter) ([]*model.DeviceStatusEvent, error)
+	GetUptimeStats(ctx context.Context, filter *UptimeFilter) ([]*DeviceUptimeStats, error)
+}
+
+// InventoryRepository defines inventory snapshot data access operations
+type InventoryRepository interface {
+	Create(ctx context.Context, snapshot *model.InventorySnapshot) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.InventorySnapshot, error)
+}
+
 // OfflineRepository defines offline operation data access operations
 type OfflineRepository interface {
 	// Queue operations
@@ -69,7 +90,10 @@ type OfflineRepository interface {
 	// Queue management
 	GetQueueSize(ctx context.Context, deviceID uuid.UUID) (int, error)
 	GetPendingOperations(ctx context.Context, maxAttempts int) ([]*model.OfflineOperation, error)
-	DeleteExpired(ctx context.Context) (int64, error)
+	ClaimPendingOperations(ctx context.Context, maxAttempts int, claimTTL time.Duration) ([]*model.OfflineOperation, error)
+	ReleaseClaim(ctx context.Context, id uuid.UUID) error
+	ExpireStale(ctx context.Context, maxAge time.Duration) ([]*model.OfflineOperation, error)
+	DeleteExpired(ctx context.Context, retention time.Duration) (int64, error)
 	ClearQueue(ctx context.Context, deviceID uuid.UUID) error
 }
 
@@ -77,6 +101,7 @@ type OfflineRepository interface {
 
 // DeviceFilter represents device listing filters
 type DeviceFilter struct {
+	TenantID   *uuid.UUID          `json:"tenant_id,omitempty"` // narrows platform-wide listings; tenant-scoped callers only see their own
 	BranchID   *uuid.UUID          `json:"branch_id,omitempty"`
 	DeviceType *model.DeviceType   `json:"device_type,omitempty"`
 	Brand      *model.DeviceBrand  `json:"brand,omitempty"`
@@ -96,6 +121,9 @@ type OperationFilter struct {
 	Status        *model.OperationStatus   `json:"status,omitempty"`
 	Priority      *model.OperationPriority `json:"priority,omitempty"`
 	CorrelationID *uuid.UUID               `json:"correlation_id,omitempty"`
+	ErrorContains *string                  `json:"error_contains,omitempty"` // case-insensitive match on error_message
+	Source        *model.OperationSource   `json:"source,omitempty"`
+	Metadata      map[string]string        `json:"metadata,omitempty"` // operations carrying all of these tags
 	StartDate     *time.Time               `json:"start_date,omitempty"`
 	EndDate       *time.Time               `json:"end_date,omitempty"`
 	Page          int                      `json:"page"`
@@ -104,6 +132,20 @@ type OperationFilter struct {
 	SortOrder     string                   `json:"sort_order"`
 }
 
+// StatusEventFilter represents device status history filters
+type StatusEventFilter struct {
+	StartDate *time.Time `json:"start_date,omitempty"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+	Limit     int        `json:"limit"`
+}
+
+// UptimeFilter represents device uptime statistics filters
+type UptimeFilter struct {
+	BranchID  *uuid.UUID `json:"branch_id,omitempty"`
+	StartDate time.Time  `json:"start_date"`
+	EndDate   time.Time  `json:"end_date"`
+}
+
 // OperationStatsFilter represents operation statistics filters
 type OperationStatsFilter struct {
 	DeviceID  *uuid.UUID `json:"device_id,omitempty"`
@@ -112,6 +154,16 @@ type OperationStatsFilter struct {
 	EndDate   *time.Time `json:"end_date,omitempty"`
 }
 
+// OperationTimeSeriesFilter represents time-bucketed operation stats filters.
+// Interval is a date_trunc field ("hour" or "day"); buckets are in UTC.
+type OperationTimeSeriesFilter struct {
+	Interval  string                 `json:"interval"`
+	DeviceID  *uuid.UUID             `json:"device_id,omitempty"`
+	Source    *model.OperationSource `json:"source,omitempty"`
+	StartDate time.Time              `json:"start_date"`
+	EndDate   time.Time              `json:"end_date"`
+}
+
 // Statistics structures
 
 // DeviceStats represents device statistics
@@ -137,6 +189,38 @@ type OperationStats struct {
 	ByPriority      map[model.OperationPriority]int `json:"by_priority"`
 }
 
+// OperationErrorGroup represents operations sharing the same error message
+type OperationErrorGroup struct {
+	ErrorMessage string    `json:"error_message"`
+	Count        int       `json:"count"`
+	DeviceCount  int       `json:"device_count"`
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+}
+
+// OperationTimeBucket represents operation counts of one time bucket
+type OperationTimeBucket struct {
+	BucketStart   time.Time                     `json:"bucket_start"`
+	Total         int                           `json:"total"`
+	ByStatus      map[model.OperationStatus]int `json:"by_status"`
+	AvgDurationMs *float64                      `json:"average_duration_ms,omitempty"`
+}
+
+// DeviceUptimeStats represents the time a device spent online and in
+// maintenance within a period. Monitoring starts when the device was registered, if that
+// is later than the period start. An outage is a change into OFFLINE or
+// ERROR from any other status; one already in progress at the start counts.
+type DeviceUptimeStats struct {
+	ID                 uuid.UUID `json:"id"`
+	DeviceID           string    `json:"device_id"`
+	Name               *string   `json:"name,omitempty"`
+	BranchID           uuid.UUID `json:"branch_id"`
+	MonitoredSeconds   float64   `json:"monitored_seconds"`
+	UpSeconds          float64   `json:"up_seconds"`          // ONLINE
+	MaintenanceSeconds float64   `json:"maintenance_seconds"` // planned, not counted against uptime
+	Outages            int       `json:"outages"`
+}
+
 // OperationSummary represents operation summary for a device
 type OperationSummary struct {
 	DeviceID        uuid.UUID     `json:"device_id"`
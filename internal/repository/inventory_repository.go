@@ -0,0 +1,75 @@
+// internal/repository/inventory_repository.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"device-service/internal/database"
+	"device-service/internal/model"
+)
+
+// inventoryRepository implements InventoryRepository interface
+type inventoryRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+// NewInventoryRepository creates a new inventory snapshot repository
+func NewInventoryRepository(db *database.DB, logger *zap.Logger) InventoryRepository {
+	return &inventoryRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create stores an inventory snapshot
+func (r *inventoryRepository) Create(ctx context.Context, snapshot *model.InventorySnapshot) error {
+	query := `
+		INSERT INTO inventory_snapshots (
+			id, tenant_id, requested_by, device_count, live_count, devices,
+			started_at, completed_at
+		) VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8)
+		RETURNING created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		snapshot.ID, snapshot.TenantID, snapshot.RequestedBy, snapshot.DeviceCount,
+		snapshot.LiveCount, snapshot.Devices, snapshot.StartedAt, snapshot.CompletedAt,
+	).Scan(&snapshot.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to create inventory snapshot", zap.Error(err))
+		return fmt.Errorf("failed to create inventory snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an inventory snapshot by ID
+func (r *inventoryRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.InventorySnapshot, error) {
+	query := `
+		SELECT id, tenant_id, COALESCE(requested_by, ''), device_count, live_count,
+			   devices, started_at, completed_at, created_at
+		FROM inventory_snapshots WHERE id = $1 AND ` + tenantCondition("tenant_id", 2)
+
+	snapshot := &model.InventorySnapshot{}
+	err := r.db.QueryRowContext(ctx, query, id, tenantArg(ctx)).Scan(
+		&snapshot.ID, &snapshot.TenantID, &snapshot.RequestedBy, &snapshot.DeviceCount,
+		&snapshot.LiveCount, &snapshot.Devices, &snapshot.StartedAt, &snapshot.CompletedAt,
+		&snapshot.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("inventory snapshot not found with id: %s", id)
+		}
+		r.logger.Error("Failed to get inventory snapshot", zap.Error(err), zap.String("id", id.String()))
+		return nil, fmt.Errorf("failed to get inventory snapshot: %w", err)
+	}
+
+	return snapshot, nil
+}
@@ -4,6 +4,8 @@ package repository
 import (
 	"context"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -53,7 +55,8 @@ func (r *offlineRepository) Enqueue(ctx context.Context, operation *model.Offlin
 func (r *offlineRepository) Dequeue(ctx context.Context, deviceID uuid.UUID, limit int) ([]*model.OfflineOperation, error) {
 	query := `
 		SELECT id, device_id, operation_type, operation_data, priority,
-			   created_at, sync_status, sync_attempts, last_sync_attempt, expires_at
+			   created_at, sync_status, sync_attempts, last_sync_attempt, expires_at,
+			   claimed_until
 		FROM offline_operations
 		WHERE device_id = $1 AND sync_status = 'PENDING'
 		ORDER BY priority ASC, created_at ASC
@@ -73,7 +76,7 @@ func (r *offlineRepository) Dequeue(ctx context.Context, deviceID uuid.UUID, lim
 			&operation.ID, &operation.DeviceID, &operation.OperationType,
 			&operation.OperationData, &operation.Priority, &operation.CreatedAt,
 			&operation.SyncStatus, &operation.SyncAttempts, &operation.LastSyncAttempt,
-			&operation.ExpiresAt,
+			&operation.ExpiresAt, &operation.ClaimedUntil,
 		)
 		if err != nil {
 			r.logger.Error("Failed to scan offline operation", zap.Error(err))
@@ -89,7 +92,7 @@ func (r *offlineRepository) Dequeue(ctx context.Context, deviceID uuid.UUID, lim
 func (r *offlineRepository) MarkSynced(ctx context.Context, id uuid.UUID) error {
 	query := `
 		UPDATE offline_operations 
-		SET sync_status = 'SYNCED', last_sync_attempt = CURRENT_TIMESTAMP
+		SET sync_status = 'SYNCED', last_sync_attempt = CURRENT_TIMESTAMP, claimed_until = NULL
 		WHERE id = $1
 	`
 
@@ -114,7 +117,7 @@ func (r *offlineRepository) MarkSynced(ctx context.Context, id uuid.UUID) error
 func (r *offlineRepository) MarkFailed(ctx context.Context, id uuid.UUID, attempts int) error {
 	query := `
 		UPDATE offline_operations 
-		SET sync_attempts = $2, last_sync_attempt = CURRENT_TIMESTAMP
+		SET sync_attempts = $2, last_sync_attempt = CURRENT_TIMESTAMP, claimed_until = NULL
 		WHERE id = $1
 	`
 
@@ -155,7 +158,8 @@ func (r *offlineRepository) GetQueueSize(ctx context.Context, deviceID uuid.UUID
 func (r *offlineRepository) GetPendingOperations(ctx context.Context, maxAttempts int) ([]*model.OfflineOperation, error) {
 	query := `
 		SELECT id, device_id, operation_type, operation_data, priority,
-			   created_at, sync_status, sync_attempts, last_sync_attempt, expires_at
+			   created_at, sync_status, sync_attempts, last_sync_attempt, expires_at,
+			   claimed_until
 		FROM offline_operations
 		WHERE sync_status = 'PENDING' AND sync_attempts < $1
 		ORDER BY priority ASC, created_at ASC
@@ -174,7 +178,7 @@ func (r *offlineRepository) GetPendingOperations(ctx context.Context, maxAttempt
 			&operation.ID, &operation.DeviceID, &operation.OperationType,
 			&operation.OperationData, &operation.Priority, &operation.CreatedAt,
 			&operation.SyncStatus, &operation.SyncAttempts, &operation.LastSyncAttempt,
-			&operation.ExpiresAt,
+			&operation.ExpiresAt, &operation.ClaimedUntil,
 		)
 		if err != nil {
 			r.logger.Error("Failed to scan offline operation", zap.Error(err))
@@ -186,14 +190,132 @@ func (r *offlineRepository) GetPendingOperations(ctx context.Context, maxAttempt
 	return operations, nil
 }
 
-// DeleteExpired removes expired operations
-func (r *offlineRepository) DeleteExpired(ctx context.Context) (int64, error) {
+// ClaimPendingOperations claims the pending operations that may be retried
+// and aren't claimed by another sync, and returns them. A claim lasts for
+// claimTTL, so the operations of a sync that crashed become claimable again
+// once it expires. Rows locked by a concurrent claim are skipped.
+func (r *offlineRepository) ClaimPendingOperations(ctx context.Context, maxAttempts int, claimTTL time.Duration) ([]*model.OfflineOperation, error) {
+	query := `
+		UPDATE offline_operations
+		SET claimed_until = CURRENT_TIMESTAMP + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM offline_operations
+			WHERE sync_status = 'PENDING' AND sync_attempts < $1
+			  AND (claimed_until IS NULL OR claimed_until < CURRENT_TIMESTAMP)
+			  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+			ORDER BY priority ASC, created_at ASC
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, device_id, operation_type, operation_data, priority,
+			created_at, sync_status, sync_attempts, last_sync_attempt, expires_at,
+			claimed_until
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, maxAttempts, claimTTL.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim pending operations: %w", err)
+	}
+	defer rows.Close()
+
+	operations := []*model.OfflineOperation{}
+	for rows.Next() {
+		operation := &model.OfflineOperation{}
+		err := rows.Scan(
+			&operation.ID, &operation.DeviceID, &operation.OperationType,
+			&operation.OperationData, &operation.Priority, &operation.CreatedAt,
+			&operation.SyncStatus, &operation.SyncAttempts, &operation.LastSyncAttempt,
+			&operation.ExpiresAt, &operation.ClaimedUntil,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan offline operation", zap.Error(err))
+			continue
+		}
+		operations = append(operations, operation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate claimed operations: %w", err)
+	}
+
+	// RETURNING doesn't keep the subquery order
+	sort.SliceStable(operations, func(i, j int) bool {
+		if operations[i].Priority != operations[j].Priority {
+			return operations[i].Priority < operations[j].Priority
+		}
+		return operations[i].CreatedAt.Before(operations[j].CreatedAt)
+	})
+
+	return operations, nil
+}
+
+// ReleaseClaim releases the claim on an operation without counting a sync
+// attempt, e.g. when its device went offline
+func (r *offlineRepository) ReleaseClaim(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE offline_operations SET claimed_until = NULL WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to release offline operation claim: %w", err)
+	}
+
+	return nil
+}
+
+// ExpireStale dead-letters pending operations that passed their expiry or
+// were queued for longer than maxAge (0 disables the age limit): they are
+// marked EXPIRED so they are never synced, and returned. expires_at then
+// records when an operation was dead-lettered. Operations claimed by a
+// running sync are left to it.
+func (r *offlineRepository) ExpireStale(ctx context.Context, maxAge time.Duration) ([]*model.OfflineOperation, error) {
+	query := `
+		UPDATE offline_operations
+		SET sync_status = 'EXPIRED', expires_at = CURRENT_TIMESTAMP
+		WHERE sync_status = 'PENDING'
+		  AND (claimed_until IS NULL OR claimed_until < CURRENT_TIMESTAMP)
+		  AND ((expires_at IS NOT NULL AND expires_at < CURRENT_TIMESTAMP)
+		       OR ($1 > 0 AND created_at < CURRENT_TIMESTAMP - make_interval(secs => $1)))
+		RETURNING id, device_id, operation_type, operation_data, priority,
+			created_at, sync_status, sync_attempts, last_sync_attempt, expires_at,
+			claimed_until
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, maxAge.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire stale offline operations: %w", err)
+	}
+	defer rows.Close()
+
+	operations := []*model.OfflineOperation{}
+	for rows.Next() {
+		operation := &model.OfflineOperation{}
+		err := rows.Scan(
+			&operation.ID, &operation.DeviceID, &operation.OperationType,
+			&operation.OperationData, &operation.Priority, &operation.CreatedAt,
+			&operation.SyncStatus, &operation.SyncAttempts, &operation.LastSyncAttempt,
+			&operation.ExpiresAt, &operation.ClaimedUntil,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan offline operation", zap.Error(err))
+			continue
+		}
+		operations = append(operations, operation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate expired operations: %w", err)
+	}
+
+	return operations, nil
+}
+
+// DeleteExpired removes operations whose expiry passed more than retention
+// ago, so dead-lettered operations stay inspectable for retention
+func (r *offlineRepository) DeleteExpired(ctx context.Context, retention time.Duration) (int64, error) {
 	query := `
 		DELETE FROM offline_operations 
-		WHERE expires_at IS NOT NULL AND expires_at < CURRENT_TIMESTAMP
+		WHERE expires_at IS NOT NULL AND expires_at < CURRENT_TIMESTAMP - make_interval(secs => $1)
 	`
 
-	result, err := r.db.ExecContext(ctx, query)
+	result, err := r.db.ExecContext(ctx, query, retention.Seconds())
 	if err != nil {
 		return 0, fmt.Errorf("failed to delete expired operations: %w", err)
 	}
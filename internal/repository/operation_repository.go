@@ -4,6 +4,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -15,6 +16,10 @@ import (
 	"device-service/internal/model"
 )
 
+// ErrStatusConflict is returned when a status transition is rejected because
+// the operation was moved to another status concurrently
+var ErrStatusConflict = errors.New("operation status conflict")
+
 // operationRepository implements OperationRepository interface
 type operationRepository struct {
 	db     *database.DB
@@ -34,14 +39,20 @@ func (r *operationRepository) Create(ctx context.Context, operation *model.Devic
 	query := `
 		INSERT INTO device_operations (
 			id, device_id, operation_type, operation_data, priority,
-			status, started_at, correlation_id, result
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			status, started_at, correlation_id, result, parent_operation_id,
+			tenant_id, metadata, source
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+			COALESCE($11, (SELECT tenant_id FROM devices WHERE id = $2)), $12, NULLIF($13, '')
+		)
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
 		operation.ID, operation.DeviceID, operation.OperationType,
 		operation.OperationData, operation.Priority, operation.Status,
 		operation.StartedAt, operation.CorrelationID, operation.Result,
+		operation.ParentOperationID, operation.TenantID, operation.Metadata,
+		operation.Source,
 	)
 
 	if err != nil {
@@ -57,17 +68,18 @@ func (r *operationRepository) GetByID(ctx context.Context, id uuid.UUID) (*model
 	query := `
 		SELECT id, device_id, operation_type, operation_data, priority,
 			   status, started_at, completed_at, duration_ms, error_message,
-			   retry_count, correlation_id, result, created_at
-		FROM device_operations WHERE id = $1
-	`
+			   retry_count, correlation_id, result, created_at,
+			   parent_operation_id, tenant_id, metadata, COALESCE(source, '')
+		FROM device_operations WHERE id = $1 AND ` + tenantCondition("tenant_id", 2)
 
 	operation := &model.DeviceOperation{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, id, tenantArg(ctx)).Scan(
 		&operation.ID, &operation.DeviceID, &operation.OperationType,
 		&operation.OperationData, &operation.Priority, &operation.Status,
 		&operation.StartedAt, &operation.CompletedAt, &operation.DurationMs,
 		&operation.ErrorMessage, &operation.RetryCount, &operation.CorrelationID,
-		&operation.Result, &operation.CreatedAt,
+		&operation.Result, &operation.CreatedAt, &operation.ParentOperationID, &operation.TenantID, &operation.Metadata,
+		&operation.Source,
 	)
 
 	if err != nil {
@@ -132,11 +144,64 @@ func (r *operationRepository) UpdateStatus(ctx context.Context, id uuid.UUID, st
 	return nil
 }
 
+// TransitionStatus updates an operation only if its current status is one of from
+func (r *operationRepository) TransitionStatus(ctx context.Context, operation *model.DeviceOperation, from ...model.OperationStatus) error {
+	if len(from) == 0 {
+		return fmt.Errorf("no source status given for transition to %s", operation.Status)
+	}
+
+	args := []interface{}{
+		operation.ID, operation.Status, operation.CompletedAt,
+		operation.DurationMs, operation.ErrorMessage, operation.RetryCount,
+		operation.Result,
+	}
+
+	placeholders := make([]string, len(from))
+	for i, status := range from {
+		args = append(args, status)
+		placeholders[i] = fmt.Sprintf("$%d", len(args))
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE device_operations SET
+			status = $2, completed_at = $3, duration_ms = $4,
+			error_message = $5, retry_count = $6, result = $7
+		WHERE id = $1 AND status IN (%s)
+	`, strings.Join(placeholders, ", "))
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to transition operation status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		var current model.OperationStatus
+		err := r.db.QueryRowContext(ctx,
+			`SELECT status FROM device_operations WHERE id = $1`, operation.ID,
+		).Scan(&current)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("operation not found with id: %s", operation.ID)
+			}
+			return fmt.Errorf("failed to get operation status: %w", err)
+		}
+		return fmt.Errorf("%w: cannot move operation %s from %s to %s",
+			ErrStatusConflict, operation.ID, current, operation.Status)
+	}
+
+	return nil
+}
+
 // Delete removes an operation
 func (r *operationRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM device_operations WHERE id = $1`
+	query := `DELETE FROM device_operations WHERE id = $1 AND ` + tenantCondition("tenant_id", 2)
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.db.ExecContext(ctx, query, id, tenantArg(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to delete operation: %w", err)
 	}
@@ -153,12 +218,13 @@ func (r *operationRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-// List retrieves operations with filtering and pagination
-func (r *operationRepository) List(ctx context.Context, filter *OperationFilter) ([]*model.DeviceOperation, int, error) {
-	// Build WHERE clause
-	whereConditions := []string{}
-	args := []interface{}{}
-	argIndex := 1
+// buildOperationWhere builds the WHERE clause of an operation filter, scoped
+// to the caller's tenant, and returns it with its args and the next
+// placeholder index
+func buildOperationWhere(ctx context.Context, filter *OperationFilter) (string, []interface{}, int) {
+	whereConditions := []string{tenantCondition("tenant_id", 1)}
+	args := []interface{}{tenantArg(ctx)}
+	argIndex := 2
 
 	if filter.DeviceID != nil {
 		whereConditions = append(whereConditions, fmt.Sprintf("device_id = $%d", argIndex))
@@ -190,6 +256,25 @@ func (r *operationRepository) List(ctx context.Context, filter *OperationFilter)
 		argIndex++
 	}
 
+	if filter.ErrorContains != nil && *filter.ErrorContains != "" {
+		whereConditions = append(whereConditions, fmt.Sprintf(`error_message ILIKE $%d ESCAPE '\'`, argIndex))
+		args = append(args, "%"+escapeLikePattern(*filter.ErrorContains)+"%")
+		argIndex++
+	}
+
+	if filter.Source != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("source = $%d", argIndex))
+		args = append(args, *filter.Source)
+		argIndex++
+	}
+
+	if len(filter.Metadata) > 0 {
+		// Containment matches every requested tag through the GIN index
+		whereConditions = append(whereConditions, fmt.Sprintf("metadata @> $%d", argIndex))
+		args = append(args, model.OperationMetadata(filter.Metadata))
+		argIndex++
+	}
+
 	if filter.StartDate != nil {
 		whereConditions = append(whereConditions, fmt.Sprintf("created_at >= $%d", argIndex))
 		args = append(args, *filter.StartDate)
@@ -202,10 +287,19 @@ func (r *operationRepository) List(ctx context.Context, filter *OperationFilter)
 		argIndex++
 	}
 
-	whereClause := ""
-	if len(whereConditions) > 0 {
-		whereClause = "WHERE " + strings.Join(whereConditions, " AND ")
-	}
+	whereClause := "WHERE " + strings.Join(whereConditions, " AND ")
+
+	return whereClause, args, argIndex
+}
+
+// escapeLikePattern escapes LIKE wildcards so user input matches literally
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// List retrieves operations with filtering and pagination
+func (r *operationRepository) List(ctx context.Context, filter *OperationFilter) ([]*model.DeviceOperation, int, error) {
+	whereClause, args, argIndex := buildOperationWhere(ctx, filter)
 
 	// Count total records
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM device_operations %s", whereClause)
@@ -230,7 +324,8 @@ func (r *operationRepository) List(ctx context.Context, filter *OperationFilter)
 	query := fmt.Sprintf(`
 		SELECT id, device_id, operation_type, operation_data, priority,
 			   status, started_at, completed_at, duration_ms, error_message,
-			   retry_count, correlation_id, result, created_at
+			   retry_count, correlation_id, result, created_at,
+			   parent_operation_id, tenant_id, metadata, COALESCE(source, '')
 		FROM device_operations %s
 		ORDER BY %s
 		LIMIT $%d OFFSET $%d
@@ -252,7 +347,8 @@ func (r *operationRepository) List(ctx context.Context, filter *OperationFilter)
 			&operation.OperationData, &operation.Priority, &operation.Status,
 			&operation.StartedAt, &operation.CompletedAt, &operation.DurationMs,
 			&operation.ErrorMessage, &operation.RetryCount, &operation.CorrelationID,
-			&operation.Result, &operation.CreatedAt,
+			&operation.Result, &operation.CreatedAt, &operation.ParentOperationID, &operation.TenantID, &operation.Metadata,
+			&operation.Source,
 		)
 		if err != nil {
 			r.logger.Error("Failed to scan operation row", zap.Error(err))
@@ -269,14 +365,15 @@ func (r *operationRepository) ListByDevice(ctx context.Context, deviceID uuid.UU
 	query := `
 		SELECT id, device_id, operation_type, operation_data, priority,
 			   status, started_at, completed_at, duration_ms, error_message,
-			   retry_count, correlation_id, result, created_at
+			   retry_count, correlation_id, result, created_at,
+			   parent_operation_id, tenant_id, metadata, COALESCE(source, '')
 		FROM device_operations 
-		WHERE device_id = $1
+		WHERE device_id = $1 AND ` + tenantCondition("tenant_id", 3) + `
 		ORDER BY created_at DESC
 		LIMIT $2
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, deviceID, limit)
+	rows, err := r.db.QueryContext(ctx, query, deviceID, limit, tenantArg(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list operations by device: %w", err)
 	}
@@ -290,7 +387,8 @@ func (r *operationRepository) ListByDevice(ctx context.Context, deviceID uuid.UU
 			&operation.OperationData, &operation.Priority, &operation.Status,
 			&operation.StartedAt, &operation.CompletedAt, &operation.DurationMs,
 			&operation.ErrorMessage, &operation.RetryCount, &operation.CorrelationID,
-			&operation.Result, &operation.CreatedAt,
+			&operation.Result, &operation.CreatedAt, &operation.ParentOperationID, &operation.TenantID, &operation.Metadata,
+			&operation.Source,
 		)
 		if err != nil {
 			r.logger.Error("Failed to scan operation row", zap.Error(err))
@@ -307,13 +405,14 @@ func (r *operationRepository) ListByCorrelation(ctx context.Context, correlation
 	query := `
 		SELECT id, device_id, operation_type, operation_data, priority,
 			   status, started_at, completed_at, duration_ms, error_message,
-			   retry_count, correlation_id, result, created_at
+			   retry_count, correlation_id, result, created_at,
+			   parent_operation_id, tenant_id, metadata, COALESCE(source, '')
 		FROM device_operations 
-		WHERE correlation_id = $1
+		WHERE correlation_id = $1 AND ` + tenantCondition("tenant_id", 2) + `
 		ORDER BY created_at ASC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, correlationID)
+	rows, err := r.db.QueryContext(ctx, query, correlationID, tenantArg(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list operations by correlation: %w", err)
 	}
@@ -327,7 +426,8 @@ func (r *operationRepository) ListByCorrelation(ctx context.Context, correlation
 			&operation.OperationData, &operation.Priority, &operation.Status,
 			&operation.StartedAt, &operation.CompletedAt, &operation.DurationMs,
 			&operation.ErrorMessage, &operation.RetryCount, &operation.CorrelationID,
-			&operation.Result, &operation.CreatedAt,
+			&operation.Result, &operation.CreatedAt, &operation.ParentOperationID, &operation.TenantID, &operation.Metadata,
+			&operation.Source,
 		)
 		if err != nil {
 			r.logger.Error("Failed to scan operation row", zap.Error(err))
@@ -352,7 +452,8 @@ func (r *operationRepository) ListPending(ctx context.Context, priority *model.O
 	query := fmt.Sprintf(`
 		SELECT id, device_id, operation_type, operation_data, priority,
 			   status, started_at, completed_at, duration_ms, error_message,
-			   retry_count, correlation_id, result, created_at
+			   retry_count, correlation_id, result, created_at,
+			   parent_operation_id, tenant_id, metadata, COALESCE(source, '')
 		FROM device_operations %s
 		ORDER BY priority ASC, created_at ASC
 	`, whereClause)
@@ -371,7 +472,48 @@ func (r *operationRepository) ListPending(ctx context.Context, priority *model.O
 			&operation.OperationData, &operation.Priority, &operation.Status,
 			&operation.StartedAt, &operation.CompletedAt, &operation.DurationMs,
 			&operation.ErrorMessage, &operation.RetryCount, &operation.CorrelationID,
-			&operation.Result, &operation.CreatedAt,
+			&operation.Result, &operation.CreatedAt, &operation.ParentOperationID, &operation.TenantID, &operation.Metadata,
+			&operation.Source,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan operation row", zap.Error(err))
+			continue
+		}
+		operations = append(operations, operation)
+	}
+
+	return operations, nil
+}
+
+// ListActive retrieves operations created before the given time that are
+// still PENDING or PROCESSING, oldest first
+func (r *operationRepository) ListActive(ctx context.Context, createdBefore time.Time) ([]*model.DeviceOperation, error) {
+	query := `
+		SELECT id, device_id, operation_type, operation_data, priority,
+			   status, started_at, completed_at, duration_ms, error_message,
+			   retry_count, correlation_id, result, created_at,
+			   parent_operation_id, tenant_id, metadata, COALESCE(source, '')
+		FROM device_operations
+		WHERE status IN ('PENDING', 'PROCESSING') AND created_at < $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, createdBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active operations: %w", err)
+	}
+	defer rows.Close()
+
+	operations := []*model.DeviceOperation{}
+	for rows.Next() {
+		operation := &model.DeviceOperation{}
+		err := rows.Scan(
+			&operation.ID, &operation.DeviceID, &operation.OperationType,
+			&operation.OperationData, &operation.Priority, &operation.Status,
+			&operation.StartedAt, &operation.CompletedAt, &operation.DurationMs,
+			&operation.ErrorMessage, &operation.RetryCount, &operation.CorrelationID,
+			&operation.Result, &operation.CreatedAt, &operation.ParentOperationID, &operation.TenantID, &operation.Metadata,
+			&operation.Source,
 		)
 		if err != nil {
 			r.logger.Error("Failed to scan operation row", zap.Error(err))
@@ -380,14 +522,58 @@ func (r *operationRepository) ListPending(ctx context.Context, priority *model.O
 		operations = append(operations, operation)
 	}
 
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate active operations: %w", err)
+	}
+
 	return operations, nil
 }
 
+// GroupErrors groups failed operations matching the filter by error message
+func (r *operationRepository) GroupErrors(ctx context.Context, filter *OperationFilter, limit int) ([]*OperationErrorGroup, error) {
+	whereClause, args, argIndex := buildOperationWhere(ctx, filter)
+	whereClause += " AND error_message IS NOT NULL"
+
+	query := fmt.Sprintf(`
+		SELECT error_message, COUNT(*), COUNT(DISTINCT device_id),
+			   MIN(created_at), MAX(created_at)
+		FROM device_operations %s
+		GROUP BY error_message
+		ORDER BY COUNT(*) DESC, MAX(created_at) DESC
+		LIMIT $%d
+	`, whereClause, argIndex)
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to group operation errors: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []*OperationErrorGroup
+	for rows.Next() {
+		group := &OperationErrorGroup{}
+		if err := rows.Scan(
+			&group.ErrorMessage, &group.Count, &group.DeviceCount,
+			&group.FirstSeen, &group.LastSeen,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan operation error group: %w", err)
+		}
+		groups = append(groups, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate operation error groups: %w", err)
+	}
+
+	return groups, nil
+}
+
 // GetOperationStats retrieves operation statistics
 func (r *operationRepository) GetOperationStats(ctx context.Context, filter *OperationStatsFilter) (*OperationStats, error) {
-	whereConditions := []string{}
-	args := []interface{}{}
-	argIndex := 1
+	whereConditions := []string{tenantCondition("tenant_id", 1)}
+	args := []interface{}{tenantArg(ctx)}
+	argIndex := 2
 
 	if filter.DeviceID != nil {
 		whereConditions = append(whereConditions, fmt.Sprintf("device_id = $%d", argIndex))
@@ -407,10 +593,7 @@ func (r *operationRepository) GetOperationStats(ctx context.Context, filter *Ope
 		argIndex++
 	}
 
-	whereClause := ""
-	if len(whereConditions) > 0 {
-		whereClause = "WHERE " + strings.Join(whereConditions, " AND ")
-	}
+	whereClause := "WHERE " + strings.Join(whereConditions, " AND ")
 
 	query := fmt.Sprintf(`
 		SELECT 
@@ -448,6 +631,86 @@ func (r *operationRepository) GetOperationStats(ctx context.Context, filter *Ope
 	return stats, nil
 }
 
+// GetOperationTimeSeries retrieves operation counts by status and average
+// duration per time bucket, ordered by bucket. Empty buckets are omitted.
+func (r *operationRepository) GetOperationTimeSeries(ctx context.Context, filter *OperationTimeSeriesFilter) ([]*OperationTimeBucket, error) {
+	whereConditions := []string{"created_at >= $2", "created_at < $3", tenantCondition("tenant_id", 4)}
+	args := []interface{}{filter.Interval, filter.StartDate, filter.EndDate, tenantArg(ctx)}
+
+	if filter.DeviceID != nil {
+		args = append(args, *filter.DeviceID)
+		whereConditions = append(whereConditions, fmt.Sprintf("device_id = $%d", len(args)))
+	}
+	if filter.Source != nil {
+		args = append(args, *filter.Source)
+		whereConditions = append(whereConditions, fmt.Sprintf("source = $%d", len(args)))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT date_trunc($1, created_at AT TIME ZONE 'UTC') AS bucket, status,
+			   COUNT(*), COUNT(duration_ms), AVG(duration_ms)
+		FROM device_operations
+		WHERE %s
+		GROUP BY bucket, status
+		ORDER BY bucket
+	`, strings.Join(whereConditions, " AND "))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operation time series: %w", err)
+	}
+	defer rows.Close()
+
+	var buckets []*OperationTimeBucket
+	var current *OperationTimeBucket
+	var timedOps int
+	var durationSum float64
+
+	// Rows come per (bucket, status); fold them into one entry per bucket
+	flush := func() {
+		if current != nil && timedOps > 0 {
+			avg := durationSum / float64(timedOps)
+			current.AvgDurationMs = &avg
+		}
+	}
+
+	for rows.Next() {
+		var bucket time.Time
+		var status model.OperationStatus
+		var count, timedCount int
+		var avgDurationMs sql.NullFloat64
+
+		if err := rows.Scan(&bucket, &status, &count, &timedCount, &avgDurationMs); err != nil {
+			return nil, fmt.Errorf("failed to scan operation time bucket: %w", err)
+		}
+		bucket = time.Date(bucket.Year(), bucket.Month(), bucket.Day(), bucket.Hour(), 0, 0, 0, time.UTC)
+
+		if current == nil || !current.BucketStart.Equal(bucket) {
+			flush()
+			current = &OperationTimeBucket{
+				BucketStart: bucket,
+				ByStatus:    make(map[model.OperationStatus]int),
+			}
+			timedOps, durationSum = 0, 0
+			buckets = append(buckets, current)
+		}
+
+		current.Total += count
+		current.ByStatus[status] += count
+		if avgDurationMs.Valid {
+			timedOps += timedCount
+			durationSum += avgDurationMs.Float64 * float64(timedCount)
+		}
+	}
+	flush()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate operation time buckets: %w", err)
+	}
+
+	return buckets, nil
+}
+
 // GetDeviceOperationSummary retrieves operation summary for a device
 func (r *operationRepository) GetDeviceOperationSummary(ctx context.Context, deviceID uuid.UUID, period time.Duration) (*OperationSummary, error) {
 	since := time.Now().Add(-period)
@@ -456,11 +719,11 @@ func (r *operationRepository) GetDeviceOperationSummary(ctx context.Context, dev
 		SELECT 
 			COUNT(*) as total_ops,
 			COUNT(CASE WHEN status = 'SUCCESS' THEN 1 END) as successful_ops,
-			COUNT(CASE WHEN status = 'FAILED' THEN 1 END) as error_count,
+			COUNT(CASE WHEN status IN ('FAILED', 'TIMEOUT') THEN 1 END) as error_count,
 			AVG(duration_ms) as avg_response_time_ms,
 			MAX(created_at) as last_operation
 		FROM device_operations
-		WHERE device_id = $1 AND created_at >= $2
+		WHERE device_id = $1 AND created_at >= $2 AND ` + tenantCondition("tenant_id", 3) + `
 	`
 
 	summary := &OperationSummary{
@@ -471,7 +734,7 @@ func (r *operationRepository) GetDeviceOperationSummary(ctx context.Context, dev
 	var successfulOps, avgResponseTimeMs sql.NullFloat64
 	var lastOp sql.NullTime
 
-	err := r.db.QueryRowContext(ctx, query, deviceID, since).Scan(
+	err := r.db.QueryRowContext(ctx, query, deviceID, since, tenantArg(ctx)).Scan(
 		&summary.TotalOps,
 		&successfulOps,
 		&summary.ErrorCount,
@@ -0,0 +1,181 @@
+// internal/repository/status_event_repository.go
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"device-service/internal/database"
+	"device-service/internal/model"
+)
+
+// statusEventRepository implements StatusEventRepository interface
+type statusEventRepository struct {
+	db     *database.DB
+	logger *zap.Logger
+}
+
+// NewStatusEventRepository creates a new device status event repository
+func NewStatusEventRepository(db *database.DB, logger *zap.Logger) StatusEventRepository {
+	return &statusEventRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ListByDevice retrieves status transitions of a device, newest first
+func (r *statusEventRepository) ListByDevice(ctx context.Context, deviceID uuid.UUID, filter *StatusEventFilter) ([]*model.DeviceStatusEvent, error) {
+	whereConditions := []string{"device_id = $1", "device_id IN (SELECT id FROM devices WHERE " + tenantCondition("tenant_id", 2) + ")"}
+	args := []interface{}{deviceID, tenantArg(ctx)}
+
+	if filter.StartDate != nil {
+		args = append(args, *filter.StartDate)
+		whereConditions = append(whereConditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.EndDate != nil {
+		args = append(args, *filter.EndDate)
+		whereConditions = append(whereConditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+
+	args = append(args, filter.Limit)
+	query := fmt.Sprintf(`
+		SELECT id, device_id, old_status, new_status, COALESCE(reason, ''), created_at
+		FROM device_status_events
+		WHERE %s
+		ORDER BY created_at DESC
+		LIMIT $%d
+	`, strings.Join(whereConditions, " AND "), len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to list device status events", zap.Error(err))
+		return nil, fmt.Errorf("failed to list device status events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*model.DeviceStatusEvent{}
+	for rows.Next() {
+		event := &model.DeviceStatusEvent{}
+		err := rows.Scan(&event.ID, &event.DeviceID, &event.OldStatus, &event.NewStatus, &event.Reason, &event.CreatedAt)
+		if err != nil {
+			r.logger.Error("Failed to scan device status event", zap.Error(err))
+			continue
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate device status events: %w", err)
+	}
+
+	return events, nil
+}
+
+// GetUptimeStats computes per device uptime over a period in the database.
+// The status at the period start is the last one recorded before it (or the
+// old status of the first change after it, or the current status for devices
+// that never changed); each change then opens a period lasting until the
+// next one.
+func (r *statusEventRepository) GetUptimeStats(ctx context.Context, filter *UptimeFilter) ([]*DeviceUptimeStats, error) {
+	query := `
+		WITH scoped AS (
+			SELECT id, device_id, name, branch_id, status,
+				   GREATEST($1::timestamptz, created_at) AS window_start
+			FROM devices
+			WHERE created_at < $2 AND ($3::uuid IS NULL OR branch_id = $3)
+				  AND ` + tenantCondition("tenant_id", 4) + `
+		),
+		transitions AS (
+			SELECT s.id AS device_id, s.window_start AS started_at,
+				   COALESCE(
+					   (SELECT e.new_status FROM device_status_events e
+						WHERE e.device_id = s.id AND e.created_at <= s.window_start
+						ORDER BY e.created_at DESC LIMIT 1),
+					   (SELECT e.old_status FROM device_status_events e
+						WHERE e.device_id = s.id AND e.created_at > s.window_start
+						ORDER BY e.created_at ASC LIMIT 1),
+					   s.status
+				   ) AS status
+			FROM scoped s
+			UNION ALL
+			SELECT e.device_id, e.created_at, e.new_status
+			FROM device_status_events e
+			JOIN scoped s ON s.id = e.device_id
+			WHERE e.created_at > s.window_start AND e.created_at < $2
+		),
+		periods AS (
+			SELECT device_id, status,
+				   EXTRACT(EPOCH FROM LEAD(started_at, 1, $2::timestamptz)
+					   OVER (PARTITION BY device_id ORDER BY started_at) - started_at) AS seconds,
+				   LAG(status) OVER (PARTITION BY device_id ORDER BY started_at) AS previous_status
+			FROM transitions
+		)
+		SELECT s.id, s.device_id, s.name, s.branch_id,
+			   EXTRACT(EPOCH FROM ($2::timestamptz - s.window_start)),
+			   COALESCE(SUM(p.seconds) FILTER (WHERE p.status = 'ONLINE'), 0),
+			   COALESCE(SUM(p.seconds) FILTER (WHERE p.status = 'MAINTENANCE'), 0),
+			   COUNT(*) FILTER (WHERE p.status IN ('OFFLINE', 'ERROR')
+				   AND (p.previous_status IS NULL OR p.previous_status NOT IN ('OFFLINE', 'ERROR')))
+		FROM scoped s
+		JOIN periods p ON p.device_id = s.id
+		GROUP BY s.id, s.device_id, s.name, s.branch_id, s.window_start
+		ORDER BY s.branch_id, s.device_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.StartDate, filter.EndDate, filter.BranchID, tenantArg(ctx))
+	if err != nil {
+		r.logger.Error("Failed to get device uptime stats", zap.Error(err))
+		return nil, fmt.Errorf("failed to get device uptime stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []*DeviceUptimeStats{}
+	for rows.Next() {
+		stat := &DeviceUptimeStats{}
+		err := rows.Scan(&stat.ID, &stat.DeviceID, &stat.Name, &stat.BranchID,
+			&stat.MonitoredSeconds, &stat.UpSeconds, &stat.MaintenanceSeconds, &stat.Outages)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan device uptime stats: %w", err)
+		}
+		stats = append(stats, stat)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate device uptime stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// recordStatusChange locks the device row inside tx and records a status
+// event when the stored status differs from the new one. The lock keeps the
+// recorded old status the one the following update replaces.
+func recordStatusChange(ctx context.Context, tx *sql.Tx, id uuid.UUID, status model.DeviceStatus, reason string) error {
+	var current model.DeviceStatus
+	err := tx.QueryRowContext(ctx, `SELECT status FROM devices WHERE id = $1 FOR UPDATE`, id).Scan(&current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("device not found with id: %s", id)
+		}
+		return fmt.Errorf("failed to get device status: %w", err)
+	}
+
+	if current == status {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO device_status_events (device_id, old_status, new_status, reason)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+	`, id, current, status, reason)
+	if err != nil {
+		return fmt.Errorf("failed to record device status event: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+// internal/repository/tenant.go
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"device-service/internal/model"
+)
+
+// tenantArg returns the tenant a query is scoped to as a query argument:
+// nil for unscoped contexts, which then match every tenant
+func tenantArg(ctx context.Context) interface{} {
+	if tenantID, ok := model.TenantFromContext(ctx); ok {
+		return tenantID
+	}
+	return nil
+}
+
+// tenantCondition returns the condition matching rows of the tenant bound
+// to placeholder argIndex by tenantArg
+func tenantCondition(column string, argIndex int) string {
+	return fmt.Sprintf("($%d::uuid IS NULL OR %s = $%d)", argIndex, column, argIndex)
+}
@@ -11,8 +11,10 @@ import (
 
 	"device-service/internal/config"
 	"device-service/internal/database"
+	"device-service/internal/driver"
 	"device-service/internal/handler"
 	"device-service/internal/middleware"
+	"device-service/internal/model"
 	"device-service/internal/service"
 	"device-service/internal/utils"
 )
@@ -25,6 +27,9 @@ type Router struct {
 	deviceService    *service.DeviceService
 	operationService *service.OperationService
 	discoveryService *service.DiscoveryService
+	driverRegistry   *driver.Registry
+	driverPool       *driver.Pool
+	background       *service.BackgroundManager
 }
 
 // NewRouter creates a new router instance
@@ -35,6 +40,9 @@ func NewRouter(
 	deviceService *service.DeviceService,
 	operationService *service.OperationService,
 	discoveryService *service.DiscoveryService,
+	driverRegistry *driver.Registry,
+	driverPool *driver.Pool,
+	background *service.BackgroundManager,
 ) *Router {
 	return &Router{
 		config:           config,
@@ -43,6 +51,9 @@ func NewRouter(
 		deviceService:    deviceService,
 		operationService: operationService,
 		discoveryService: discoveryService,
+		driverRegistry:   driverRegistry,
+		driverPool:       driverPool,
+		background:       background,
 	}
 }
 
@@ -55,6 +66,9 @@ func (r *Router) SetupRouter() *gin.Engine {
 		gin.SetMode(gin.DebugMode)
 	}
 
+	// Name fields in binding errors by their JSON names
+	utils.RegisterJSONFieldNames()
+
 	// Create Gin engine
 	router := gin.New()
 
@@ -75,15 +89,18 @@ func (r *Router) addMiddleware(router *gin.Engine) {
 	// Request ID middleware
 	router.Use(middleware.RequestIDMiddleware())
 
+	// Request body size limit, before anything reads the body
+	router.Use(middleware.BodyLimitMiddleware(&r.config.Server.BodyLimit, r.logger))
+
 	// Logging middleware
 	serviceLogger := utils.NewServiceLogger(r.logger, "http-server")
-	router.Use(middleware.LoggingMiddleware(serviceLogger))
+	router.Use(middleware.LoggingMiddleware(serviceLogger, &r.config.Logging))
 
 	// CORS middleware
 	router.Use(middleware.CORSMiddleware(&r.config.Security))
 
-	// Authentication middleware would go here
-	// router.Use(middleware.AuthMiddleware(&r.config.Security))
+	// Request deadline middleware
+	router.Use(middleware.TimeoutMiddleware(r.config.Server.RequestTimeout, r.logger))
 
 	r.logger.Info("Middleware configured")
 }
@@ -91,24 +108,59 @@ func (r *Router) addMiddleware(router *gin.Engine) {
 // addRoutes sets up all application routes
 func (r *Router) addRoutes(router *gin.Engine) {
 	// Create handlers
-	healthHandler := handler.NewHealthHandler(r.db, r.config, r.logger)
+	healthHandler := handler.NewHealthHandler(r.db, r.config, r.background, r.logger)
 	deviceHandler := handler.NewDeviceHandler(r.deviceService, r.logger)
 	operationHandler := handler.NewOperationHandler(r.operationService, r.logger)
 	discoveryHandler := handler.NewDiscoveryHandler(r.discoveryService, r.logger)
-	wsHandler := handler.NewWebSocketHandler(r.deviceService, r.operationService, r.logger)
+	driverHandler := handler.NewDriverHandler(r.driverRegistry, r.logger)
+	metricsHandler := handler.NewMetricsHandler(r.driverPool, r.operationService, r.logger)
+	wsHandler := handler.NewWebSocketHandler(r.deviceService, r.operationService, r.config.Server.WebSocket, r.logger)
+
+	// Error rate early warnings, operation progress, expired offline
+	// operations and operations recovered after a restart go out as
+	// WebSocket events
+	deviceEvents := handler.NewDeviceEventHandler(wsHandler, r.logger)
+	r.deviceService.SetErrorRateAnomalyHandler(deviceEvents.OnErrorRateAnomaly)
+	r.operationService.SetOperationProgressHandler(deviceEvents.OnOperationProgress)
+	r.operationService.SetOfflineExpiredHandler(deviceEvents.OnOfflineOperationExpired)
+	r.operationService.SetOperationRecoveryHandler(deviceEvents.OnOperationRecovered)
 
 	// Health check routes (no auth required)
 	r.addHealthRoutes(router, healthHandler)
 
+	// Metrics routes (no auth required)
+	r.addMetricsRoutes(router, metricsHandler)
+
 	// API v1 routes
 	apiV1 := router.Group("/api/v1")
+	// API requests continue the caller's trace
+	apiV1.Use(middleware.TracingMiddleware())
+	if r.config.Security.AuthEnabled {
+		// Tokens carrying a tenant_id scope every API call to that tenant
+		apiV1.Use(middleware.AuthMiddleware(&r.config.Security))
+	}
+	// Operations started through the API are recorded as REST operations
+	apiV1.Use(middleware.OperationSourceMiddleware(model.OperationSourceREST))
+	// Legacy clients' field names are mapped before handlers bind the body
+	apiV1.Use(middleware.FieldMappingMiddleware(&r.config.Server.FieldMapping, r.logger))
+	if r.config.Server.MsgPackEnabled {
+		apiV1.Use(middleware.ResponseEncodingMiddleware())
+	}
 	r.addDeviceRoutes(apiV1, deviceHandler, operationHandler)
 	r.addOperationRoutes(apiV1, operationHandler)
+	r.addBranchRoutes(apiV1, operationHandler)
+	r.addReportRoutes(apiV1, deviceHandler)
+	r.addInventoryRoutes(apiV1, deviceHandler)
 	r.addDiscoveryRoutes(apiV1, discoveryHandler)
+	r.addAdminRoutes(apiV1, driverHandler)
 
-	// WebSocket routes
+	// WebSocket routes: clients authenticate like API calls and only
+	// receive events of their tenant's devices
 	r.addWebSocketRoutes(router, wsHandler)
 
+	// Device agents authenticate with their device's agent token instead
+	r.addAgentRoutes(router, wsHandler)
+
 	// Documentation routes
 	r.addDocumentationRoutes(router)
 
@@ -126,6 +178,11 @@ func (r *Router) addHealthRoutes(router *gin.Engine, handler *handler.HealthHand
 	}
 }
 
+// addMetricsRoutes sets up Prometheus metrics routes
+func (r *Router) addMetricsRoutes(router *gin.Engine, handler *handler.MetricsHandler) {
+	router.GET("/metrics", handler.Metrics)
+}
+
 // addDeviceRoutes sets up device management routes
 func (r *Router) addDeviceRoutes(api *gin.RouterGroup, deviceHandler *handler.DeviceHandler, operationHandler *handler.OperationHandler) {
 	devices := api.Group("/devices")
@@ -133,6 +190,9 @@ func (r *Router) addDeviceRoutes(api *gin.RouterGroup, deviceHandler *handler.De
 		// Device CRUD operations
 		devices.POST("", deviceHandler.RegisterDevice)
 		devices.GET("", deviceHandler.ListDevices)
+		devices.GET("/export", deviceHandler.ExportDevices)
+		devices.POST("/import", deviceHandler.ImportDevices)
+		devices.POST("/status/refresh", deviceHandler.RefreshDeviceStatuses)
 
 		// Individual device operations
 		device := devices.Group("/:device_id")
@@ -141,11 +201,23 @@ func (r *Router) addDeviceRoutes(api *gin.RouterGroup, deviceHandler *handler.De
 			device.GET("", deviceHandler.GetDevice)
 			device.PUT("", deviceHandler.UpdateDevice)
 			device.DELETE("", deviceHandler.DeleteDevice)
+			device.POST("/clone", deviceHandler.CloneDevice)
 			device.POST("/connect", deviceHandler.ConnectDevice)
 			device.POST("/disconnect", deviceHandler.DisconnectDevice)
+			device.POST("/reconnect", deviceHandler.ReconnectDevice)
 			device.POST("/test", deviceHandler.TestDevice)
 			device.GET("/health", deviceHandler.GetDeviceHealth)
+			device.GET("/printer-status", deviceHandler.GetPrinterStatus)
+			device.GET("/virtual-output", deviceHandler.GetVirtualOutput)
+			device.DELETE("/virtual-output", deviceHandler.ClearVirtualOutput)
+			device.GET("/status-history", deviceHandler.GetDeviceStatusHistory)
+			device.GET("/connection", deviceHandler.GetDeviceConnection)
 			device.PUT("/config", deviceHandler.UpdateDeviceConfig)
+			device.GET("/capabilities", deviceHandler.GetDeviceCapabilities)
+			device.PUT("/capabilities", deviceHandler.UpdateDeviceCapabilities)
+			device.PUT("/wire-logging", deviceHandler.UpdateWireLogging)
+			device.GET("/operation-policy", deviceHandler.GetOperationPolicy)
+			device.PUT("/operation-policy", deviceHandler.UpdateOperationPolicy)
 
 			// Device operations - DİREKT DEVICE ALTINDA
 			device.POST("/print", operationHandler.PrintOperation)
@@ -153,7 +225,15 @@ func (r *Router) addDeviceRoutes(api *gin.RouterGroup, deviceHandler *handler.De
 			device.POST("/scan", operationHandler.ScanOperation)
 			device.POST("/open-drawer", operationHandler.OpenDrawerOperation)
 			device.POST("/display", operationHandler.DisplayOperation)
+			device.POST("/weigh", operationHandler.WeighOperation)
+			device.POST("/beep", operationHandler.BeepOperation)
+			device.POST("/self-test", operationHandler.SelfTestOperation)
 			device.GET("/operations", operationHandler.ListDeviceOperations)
+			device.GET("/operations/summary", operationHandler.GetDeviceOperationSummary)
+			device.GET("/operations/latest", operationHandler.GetLatestDeviceOperations)
+			device.GET("/queue", operationHandler.GetDeviceQueue)
+			device.POST("/lock", operationHandler.LockDevice)
+			device.DELETE("/lock", operationHandler.UnlockDevice)
 		}
 	}
 }
@@ -164,8 +244,38 @@ func (r *Router) addOperationRoutes(api *gin.RouterGroup, handler *handler.Opera
 	{
 		operations.POST("", handler.ExecuteOperation)
 		operations.GET("", handler.ListOperations)
+		operations.POST("/chain", handler.ChainOperations)
+		operations.GET("/errors", handler.GroupOperationErrors)
+		operations.GET("/stats/timeseries", handler.GetOperationTimeSeries)
+		operations.GET("/queues", handler.ListDeviceQueues)
 		operations.GET("/:operation_id", handler.GetOperation)
 		operations.PUT("/:operation_id/cancel", handler.CancelOperation)
+		operations.POST("/:operation_id/replay", handler.ReplayOperation)
+	}
+}
+
+// addBranchRoutes sets up branch-wide operation routes
+func (r *Router) addBranchRoutes(api *gin.RouterGroup, handler *handler.OperationHandler) {
+	branches := api.Group("/branches")
+	{
+		branches.POST("/:branch_id/broadcast", handler.BroadcastOperation)
+	}
+}
+
+// addReportRoutes sets up reporting routes
+func (r *Router) addReportRoutes(api *gin.RouterGroup, handler *handler.DeviceHandler) {
+	reports := api.Group("/reports")
+	{
+		reports.GET("/uptime", handler.GetUptimeReport)
+	}
+}
+
+// addInventoryRoutes sets up fleet inventory routes
+func (r *Router) addInventoryRoutes(api *gin.RouterGroup, handler *handler.DeviceHandler) {
+	inventory := api.Group("/inventory")
+	{
+		inventory.POST("/snapshot", handler.CreateInventorySnapshot)
+		inventory.GET("/snapshots/:snapshot_id", handler.GetInventorySnapshot)
 	}
 }
 
@@ -177,12 +287,29 @@ func (r *Router) addDiscoveryRoutes(api *gin.RouterGroup, handler *handler.Disco
 		discovery.POST("/auto-setup", handler.AutoSetupDevices)
 		discovery.GET("/supported", handler.GetSupportedDevices)
 		discovery.GET("/capabilities/:brand/:type", handler.GetCapabilities)
+		discovery.GET("/config-template/:brand/:model", handler.GetConfigTemplate)
+	}
+}
+
+// addAdminRoutes sets up administrative routes
+func (r *Router) addAdminRoutes(api *gin.RouterGroup, driverHandler *handler.DriverHandler) {
+	admin := api.Group("/admin")
+	if r.config.Security.AuthEnabled {
+		// Only admin tokens may change the service's drivers
+		admin.Use(middleware.ScopeMiddleware(model.ScopeAdmin))
+	}
+	{
+		admin.GET("/drivers", driverHandler.ListDrivers)
+		admin.PUT("/drivers", driverHandler.ToggleDriver)
 	}
 }
 
 // addWebSocketRoutes sets up WebSocket routes
 func (r *Router) addWebSocketRoutes(router *gin.Engine, handler *handler.WebSocketHandler) {
 	ws := router.Group("/ws")
+	if r.config.Security.AuthEnabled {
+		ws.Use(middleware.AuthMiddleware(&r.config.Security))
+	}
 	{
 		ws.GET("/devices/:device_id", handler.HandleDeviceConnection)
 		ws.GET("/events", handler.HandleEventConnection)
@@ -191,6 +318,11 @@ func (r *Router) addWebSocketRoutes(router *gin.Engine, handler *handler.WebSock
 	}
 }
 
+// addAgentRoutes sets up the heartbeat streams of device agents
+func (r *Router) addAgentRoutes(router *gin.Engine, handler *handler.WebSocketHandler) {
+	router.GET("/ws/agents/:device_id", handler.HandleAgentConnection)
+}
+
 // addDocumentationRoutes sets up documentation routes
 func (r *Router) addDocumentationRoutes(router *gin.Engine) {
 	// Swagger documentation
@@ -0,0 +1,237 @@
+// internal/service/background_service.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"device-service/internal/utils"
+)
+
+// staleIntervals is how many missed intervals mark a background task as stalled
+const staleIntervals = 3
+
+// BackgroundTask is a single iteration of a periodic background job
+type BackgroundTask func(ctx context.Context) error
+
+// BackgroundManager runs periodic background tasks, recovers them from
+// panics and tracks their progress for health reporting
+type BackgroundManager struct {
+	tasks  map[string]*backgroundTaskState
+	mu     sync.RWMutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	logger *utils.ServiceLogger
+}
+
+// backgroundTaskState holds runtime state of a background task
+type backgroundTaskState struct {
+	name        string
+	interval    time.Duration
+	timeout     time.Duration
+	startedAt   time.Time
+	running     bool
+	lastRun     *time.Time
+	lastSuccess *time.Time
+	lastError   string
+	lastErrorAt *time.Time
+	runs        int64
+	failures    int64
+	panics      int64
+}
+
+// NewBackgroundManager creates a new background manager
+func NewBackgroundManager(logger *zap.Logger) *BackgroundManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &BackgroundManager{
+		tasks:  make(map[string]*backgroundTaskState),
+		ctx:    ctx,
+		cancel: cancel,
+		logger: utils.NewServiceLogger(logger, "background-manager"),
+	}
+}
+
+// Start runs task every interval until the manager is stopped. Each run gets
+// its own timeout; a panicking run is recovered and the loop keeps going.
+func (bm *BackgroundManager) Start(name string, interval, timeout time.Duration, task BackgroundTask) {
+	state := &backgroundTaskState{
+		name:      name,
+		interval:  interval,
+		timeout:   timeout,
+		startedAt: time.Now(),
+		running:   true,
+	}
+
+	bm.mu.Lock()
+	bm.tasks[name] = state
+	bm.mu.Unlock()
+
+	bm.wg.Add(1)
+	go func() {
+		defer bm.wg.Done()
+		defer bm.setRunning(state, false)
+		defer utils.RecoverPanic(bm.logger.Logger, zap.String("goroutine", "background_task"), zap.String("task", name))
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		bm.logger.Info("Background task started",
+			zap.String("task", name),
+			zap.Duration("interval", interval),
+		)
+
+		for {
+			select {
+			case <-bm.ctx.Done():
+				bm.logger.Info("Background task stopped", zap.String("task", name))
+				return
+			case <-ticker.C:
+				bm.runOnce(state, task)
+			}
+		}
+	}()
+}
+
+// runOnce executes a single iteration with panic recovery
+func (bm *BackgroundManager) runOnce(state *backgroundTaskState, task BackgroundTask) {
+	ctx, cancel := context.WithTimeout(bm.ctx, state.timeout)
+	defer cancel()
+
+	startTime := time.Now()
+	var err error
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+				bm.mu.Lock()
+				state.panics++
+				bm.mu.Unlock()
+				bm.logger.Error("Background task panicked",
+					zap.String("task", state.name),
+					zap.Any("panic", r),
+					zap.Stack("stacktrace"),
+				)
+			}
+		}()
+		err = task(ctx)
+	}()
+
+	finishedAt := time.Now()
+
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	state.runs++
+	state.lastRun = &finishedAt
+	if err != nil {
+		state.failures++
+		state.lastError = err.Error()
+		state.lastErrorAt = &finishedAt
+		bm.logger.Warn("Background task run failed",
+			zap.String("task", state.name),
+			zap.Duration("duration", finishedAt.Sub(startTime)),
+			zap.Error(err),
+		)
+		return
+	}
+	state.lastSuccess = &finishedAt
+}
+
+// setRunning updates running flag of a task
+func (bm *BackgroundManager) setRunning(state *backgroundTaskState, running bool) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	state.running = running
+}
+
+// Status returns the status of all background tasks sorted by name
+func (bm *BackgroundManager) Status() []BackgroundTaskStatus {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+
+	now := time.Now()
+	statuses := make([]BackgroundTaskStatus, 0, len(bm.tasks))
+	for _, state := range bm.tasks {
+		statuses = append(statuses, state.status(now))
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Healthy reports whether all background tasks are running and making progress
+func (bm *BackgroundManager) Healthy() bool {
+	for _, status := range bm.Status() {
+		if status.Status != BackgroundStatusHealthy {
+			return false
+		}
+	}
+	return true
+}
+
+// Stop stops all background tasks and waits for them to exit
+func (bm *BackgroundManager) Stop() {
+	bm.cancel()
+	bm.wg.Wait()
+}
+
+// status builds the public status; callers must hold the manager lock
+func (s *backgroundTaskState) status(now time.Time) BackgroundTaskStatus {
+	status := BackgroundTaskStatus{
+		Name:        s.name,
+		Status:      BackgroundStatusHealthy,
+		Running:     s.running,
+		Interval:    s.interval.String(),
+		LastRun:     s.lastRun,
+		LastSuccess: s.lastSuccess,
+		LastError:   s.lastError,
+		LastErrorAt: s.lastErrorAt,
+		Runs:        s.runs,
+		Failures:    s.failures,
+		Panics:      s.panics,
+	}
+
+	// A task is stalled when it has not succeeded for several intervals
+	progress := s.startedAt
+	if s.lastSuccess != nil {
+		progress = *s.lastSuccess
+	}
+
+	switch {
+	case !s.running:
+		status.Status = BackgroundStatusStopped
+	case now.Sub(progress) > staleIntervals*s.interval:
+		status.Status = BackgroundStatusStalled
+	}
+
+	return status
+}
+
+// Background task status values
+const (
+	BackgroundStatusHealthy = "healthy"
+	BackgroundStatusStalled = "stalled"
+	BackgroundStatusStopped = "stopped"
+)
+
+// BackgroundTaskStatus represents the health of a background task
+type BackgroundTaskStatus struct {
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Running     bool       `json:"running"`
+	Interval    string     `json:"interval"`
+	LastRun     *time.Time `json:"last_run,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	Runs        int64      `json:"runs"`
+	Failures    int64      `json:"failures"`
+	Panics      int64      `json:"panics"`
+}
@@ -0,0 +1,151 @@
+// internal/service/device_clone.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"device-service/internal/model"
+)
+
+var (
+	// ErrCloneSourceNotFound is returned when the device to clone doesn't exist
+	ErrCloneSourceNotFound = errors.New("clone source device not found")
+
+	// ErrDeviceExists is returned when a new device would reuse a registered
+	// device ID
+	ErrDeviceExists = errors.New("device already exists")
+
+	// ErrInvalidClone is returned when the clone fails the validation of a
+	// new registration
+	ErrInvalidClone = errors.New("invalid device clone")
+)
+
+// CloneDeviceRequest names the device created from a source device. The
+// connection config given is merged over the source's, for the settings that
+// differ between otherwise identical devices such as the host address.
+type CloneDeviceRequest struct {
+	DeviceID         string                 `json:"device_id"`
+	Name             *string                `json:"name,omitempty"`
+	Location         *string                `json:"location,omitempty"`
+	BranchID         *uuid.UUID             `json:"branch_id,omitempty"` // defaults to the source's branch
+	ConnectionConfig map[string]interface{} `json:"connection_config,omitempty"`
+	UserID           string                 `json:"-"`
+}
+
+// DeviceCloneResult represents a cloned device. Secret connection settings
+// aren't copied; OmittedSecrets lists those that must be set on the clone.
+type DeviceCloneResult struct {
+	Device         *model.Device `json:"device"`
+	SourceDeviceID string        `json:"source_device_id"`
+	OmittedSecrets []string      `json:"omitted_secrets,omitempty"`
+}
+
+// CloneDevice registers a new device with the type, model, connection config,
+// capabilities and settings of an existing one, so identical devices can be
+// provisioned quickly. The clone is validated like a new registration and
+// starts OFFLINE.
+func (ds *DeviceService) CloneDevice(ctx context.Context, sourceDeviceID string, req *CloneDeviceRequest) (*DeviceCloneResult, error) {
+	source, err := ds.deviceRepo.GetByDeviceID(ctx, sourceDeviceID)
+	if err != nil || source == nil {
+		return nil, fmt.Errorf("%w: %s", ErrCloneSourceNotFound, sourceDeviceID)
+	}
+
+	// Secrets such as an agent token identify the source device itself
+	connectionConfig, omitted := stripSecretConfig(source.ConnectionConfig)
+	for key, value := range req.ConnectionConfig {
+		if value == model.RedactedValue && model.IsSecretConfigKey(key) {
+			continue
+		}
+		connectionConfig[key] = value
+	}
+	ds.keepRawPrintOptIn(ctx, connectionConfig, nil)
+	var omittedSecrets []string
+	for _, key := range omitted {
+		if _, set := connectionConfig[key]; !set {
+			omittedSecrets = append(omittedSecrets, key)
+		}
+	}
+
+	branchID := source.BranchID
+	if req.BranchID != nil {
+		branchID = *req.BranchID
+	}
+
+	registerReq := &RegisterDeviceRequest{
+		DeviceID:         req.DeviceID,
+		Name:             req.Name,
+		DeviceType:       source.DeviceType,
+		Brand:            source.Brand,
+		Model:            source.Model,
+		ConnectionType:   source.ConnectionType,
+		ConnectionConfig: connectionConfig,
+		BranchID:         branchID,
+		TenantID:         source.TenantID,
+		Location:         req.Location,
+		UserID:           req.UserID,
+	}
+	if err := ds.validateRegisterRequest(registerReq); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidClone, err)
+	}
+	if ds.deviceExists(ctx, req.DeviceID) {
+		return nil, fmt.Errorf("%w: %s", ErrDeviceExists, req.DeviceID)
+	}
+	if !ds.driverRegistry.IsSupported(source.Brand, source.DeviceType, source.Model) {
+		return nil, newUnsupportedDeviceError(ds.driverRegistry, source.Brand, source.DeviceType, source.Model)
+	}
+
+	sealedConfig, err := ds.sealConnectionConfig(connectionConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	device := &model.Device{
+		ID:               uuid.New(),
+		DeviceID:         req.DeviceID,
+		Name:             normalizeDeviceName(req.Name),
+		DeviceType:       source.DeviceType,
+		Brand:            source.Brand,
+		Model:            source.Model,
+		ConnectionType:   source.ConnectionType,
+		ConnectionConfig: sealedConfig,
+		Capabilities:     append(source.Capabilities[:0:0], source.Capabilities...),
+		BranchID:         branchID,
+		TenantID:         source.TenantID,
+		Location:         req.Location,
+		Status:           model.DeviceStatusOffline,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	if err := ds.deviceRepo.Create(ctx, device); err != nil {
+		ds.logger.Error("Failed to create cloned device", zap.Error(err))
+		return nil, fmt.Errorf("failed to create device: %w", err)
+	}
+
+	ds.auditLogger.LogDeviceRegistration(
+		device.DeviceID,
+		string(device.DeviceType),
+		string(device.Brand),
+		req.UserID,
+		true,
+	)
+
+	ds.logger.Info("Device cloned successfully",
+		zap.String("device_id", device.DeviceID),
+		zap.String("source_device_id", source.DeviceID),
+		zap.Strings("omitted_secrets", omittedSecrets),
+	)
+
+	return &DeviceCloneResult{
+		Device:         device,
+		SourceDeviceID: source.DeviceID,
+		OmittedSecrets: omittedSecrets,
+	}, nil
+}
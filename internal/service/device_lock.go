@@ -0,0 +1,178 @@
+// internal/service/device_lock.go
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"device-service/internal/repository"
+)
+
+var (
+	// ErrDeviceLocked is returned when a device is reserved by another client
+	// and the request doesn't present the lock token
+	ErrDeviceLocked = errors.New("device locked")
+
+	// ErrLockNotHeld is returned when releasing or renewing a lock with a
+	// token that doesn't hold it
+	ErrLockNotHeld = errors.New("device lock not held")
+
+	// ErrInvalidLockTTL is returned for lease durations outside the allowed range
+	ErrInvalidLockTTL = errors.New("invalid lock ttl")
+)
+
+// DeviceLock is an exclusive reservation of a device. While it is active
+// only operations presenting its token run on the device.
+type DeviceLock struct {
+	DeviceID  uuid.UUID `json:"device_id"`
+	Token     string    `json:"token"`
+	Holder    string    `json:"holder,omitempty"` // e.g. the cashier station
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DeviceLockRequest represents a device lock request. Presenting the token of
+// the active lock renews its lease.
+type DeviceLockRequest struct {
+	TTL    time.Duration `json:"ttl"` // 0 uses the configured default
+	Holder string        `json:"holder,omitempty"`
+	Token  string        `json:"token,omitempty"`
+}
+
+// LockDevice reserves a device for the caller, or renews the caller's lease.
+// The lease expires unless renewed, so a crashed client never keeps a device
+// locked for longer than its TTL.
+func (os *OperationService) LockDevice(ctx context.Context, deviceID uuid.UUID, req *DeviceLockRequest) (*DeviceLock, error) {
+	lockConfig := os.config.Device.Lock
+	ttl := req.TTL
+	if ttl == 0 {
+		ttl = lockConfig.DefaultTTL
+	}
+	if ttl < time.Second || ttl > lockConfig.MaxTTL {
+		return nil, fmt.Errorf("%w: %s, expected 1s-%s", ErrInvalidLockTTL, ttl, lockConfig.MaxTTL)
+	}
+
+	if _, err := os.deviceRepo.GetByID(ctx, deviceID); err != nil {
+		if errors.Is(err, repository.ErrDeviceNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrDeviceNotFound, deviceID)
+		}
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+
+	lock, renewed, err := os.queues.lock(deviceID, req.Token, req.Holder, time.Now().Add(ttl))
+	if err != nil {
+		return nil, err
+	}
+
+	if !renewed {
+		os.logger.Info("Device locked",
+			zap.String("device_id", deviceID.String()),
+			zap.String("holder", lock.Holder),
+			zap.Time("expires_at", lock.ExpiresAt),
+		)
+	}
+	return lock, nil
+}
+
+// UnlockDevice releases a device lock. Releasing a device that is no longer
+// locked, e.g. because the lease expired, succeeds.
+func (os *OperationService) UnlockDevice(ctx context.Context, deviceID uuid.UUID, token string) error {
+	if err := os.queues.unlock(deviceID, token); err != nil {
+		return err
+	}
+
+	os.logger.Info("Device unlocked", zap.String("device_id", deviceID.String()))
+	return nil
+}
+
+// lock grants or renews the lock of a device. renewed reports whether the
+// token already held it.
+func (dq *deviceQueues) lock(deviceID uuid.UUID, token, holder string, expiresAt time.Time) (*DeviceLock, bool, error) {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	queue := dq.queueFor(deviceID)
+	current := queue.activeLock(time.Now())
+	if current != nil {
+		if token != current.Token {
+			return nil, false, fmt.Errorf("%w until %s", ErrDeviceLocked, current.ExpiresAt.Format(time.RFC3339))
+		}
+		current.ExpiresAt = expiresAt
+		if holder != "" {
+			current.Holder = holder
+		}
+		renewed := *current
+		return &renewed, true, nil
+	}
+	if token != "" {
+		// The lease the token held expired; the caller has to lock anew
+		return nil, false, fmt.Errorf("%w: lease expired", ErrLockNotHeld)
+	}
+
+	newToken, err := newLockToken()
+	if err != nil {
+		return nil, false, err
+	}
+	queue.lock = &DeviceLock{
+		DeviceID:  deviceID,
+		Token:     newToken,
+		Holder:    holder,
+		ExpiresAt: expiresAt,
+	}
+	granted := *queue.lock
+	return &granted, false, nil
+}
+
+// unlock releases the lock of a device held by token
+func (dq *deviceQueues) unlock(deviceID uuid.UUID, token string) error {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	queue, exists := dq.queues[deviceID]
+	if !exists {
+		return nil
+	}
+	current := queue.activeLock(time.Now())
+	if current == nil {
+		return nil
+	}
+	if token != current.Token {
+		return ErrLockNotHeld
+	}
+	queue.lock = nil
+	return nil
+}
+
+// activeLock returns the unexpired lock of a device, dropping an expired
+// one; callers must hold the queues lock
+func (q *deviceQueue) activeLock(now time.Time) *DeviceLock {
+	if q.lock != nil && !now.Before(q.lock.ExpiresAt) {
+		q.lock = nil
+	}
+	return q.lock
+}
+
+// checkLock rejects operations without the token of an active lock; callers
+// must hold the queues lock
+func (q *deviceQueue) checkLock(token string, now time.Time) error {
+	current := q.activeLock(now)
+	if current == nil || token == current.Token {
+		return nil
+	}
+	return fmt.Errorf("%w until %s", ErrDeviceLocked, current.ExpiresAt.Format(time.RFC3339))
+}
+
+// newLockToken returns a random lock token
+func newLockToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	return hex.EncodeToString(token), nil
+}
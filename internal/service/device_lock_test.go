@@ -0,0 +1,76 @@
+// internal/service/device_lock_test.go
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestDeviceLockRenewal(t *testing.T) {
+	dq := newDeviceQueues(0)
+	deviceID := uuid.New()
+
+	lock, renewed, err := dq.lock(deviceID, "", "till-1", time.Now().Add(time.Minute))
+	if err != nil || renewed {
+		t.Fatalf("lock() = %v, %v; want a new lock", renewed, err)
+	}
+
+	if _, _, err := dq.lock(deviceID, "wrong-token", "till-2", time.Now().Add(time.Minute)); !errors.Is(err, ErrDeviceLocked) {
+		t.Errorf("renewing with the wrong token: error = %v, want %v", err, ErrDeviceLocked)
+	}
+	if _, _, err := dq.lock(deviceID, "", "till-2", time.Now().Add(time.Minute)); !errors.Is(err, ErrDeviceLocked) {
+		t.Errorf("locking a locked device: error = %v, want %v", err, ErrDeviceLocked)
+	}
+	if err := dq.unlock(deviceID, "wrong-token"); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("unlocking with the wrong token: error = %v, want %v", err, ErrLockNotHeld)
+	}
+
+	expiresAt := time.Now().Add(2 * time.Minute)
+	renewal, renewed, err := dq.lock(deviceID, lock.Token, "", expiresAt)
+	if err != nil || !renewed {
+		t.Fatalf("renewing with the lock's token: %v, %v; want a renewal", renewed, err)
+	}
+	if !renewal.ExpiresAt.Equal(expiresAt) || renewal.Holder != "till-1" {
+		t.Errorf("renewed lock = %+v, want the lease extended for till-1", renewal)
+	}
+}
+
+func TestDeviceLockLeaseExpiry(t *testing.T) {
+	dq := newDeviceQueues(0)
+	deviceID := uuid.New()
+
+	lock, _, err := dq.lock(deviceID, "", "till-1", time.Now().Add(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("lock() error = %v", err)
+	}
+	if _, err := dq.enter(context.Background(), deviceID, uuid.New(), "", nil); !errors.Is(err, ErrDeviceLocked) {
+		t.Fatalf("operation without the token: error = %v, want %v", err, ErrDeviceLocked)
+	}
+	leave, err := dq.enter(context.Background(), deviceID, uuid.New(), lock.Token, nil)
+	if err != nil {
+		t.Fatalf("operation with the token: error = %v", err)
+	}
+	leave()
+
+	time.Sleep(100 * time.Millisecond)
+
+	// The expired lease no longer holds the device
+	leave, err = dq.enter(context.Background(), deviceID, uuid.New(), "", nil)
+	if err != nil {
+		t.Fatalf("operation after the lease expired: error = %v", err)
+	}
+	leave()
+	if _, _, err := dq.lock(deviceID, lock.Token, "", time.Now().Add(time.Minute)); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("renewing an expired lease: error = %v, want %v", err, ErrLockNotHeld)
+	}
+	if err := dq.unlock(deviceID, lock.Token); err != nil {
+		t.Errorf("unlocking an expired lease: error = %v", err)
+	}
+	if _, renewed, err := dq.lock(deviceID, "", "till-2", time.Now().Add(time.Minute)); err != nil || renewed {
+		t.Errorf("locking after the lease expired: %v, %v; want a new lock", renewed, err)
+	}
+}
@@ -3,55 +3,183 @@ package service
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"device-service/internal/config"
 	internalDriver "device-service/internal/driver" // Registry için
 	"device-service/internal/model"
+	"device-service/internal/protocol"
 	"device-service/internal/repository"
 	"device-service/internal/utils"
+	"device-service/pkg/devicetypes"
 	"device-service/pkg/driver" // DeviceDriver interface için
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+var (
+	// ErrCapabilityNotToggleable is returned for capabilities that can't be
+	// switched on or off for a device
+	ErrCapabilityNotToggleable = errors.New("capability cannot be toggled")
+
+	// ErrAgentUnauthorized is returned when a device agent presents a missing
+	// or wrong token
+	ErrAgentUnauthorized = errors.New("device agent unauthorized")
+
+	// ErrInvalidHeartbeat is returned for heartbeat frames with an unknown status
+	ErrInvalidHeartbeat = errors.New("invalid heartbeat")
+
+	// ErrInvalidOperationPolicy is returned for operation allow/deny lists
+	// naming unknown operations or listing one operation in both
+	ErrInvalidOperationPolicy = errors.New("invalid operation policy")
+
+	// ErrDeviceNotFound is returned when a device doesn't exist or belongs
+	// to another tenant
+	ErrDeviceNotFound = errors.New("device not found")
+
+	// ErrAdminScopeRequired is returned when a caller without the admin
+	// scope enables raw ESC/POS printing on a device
+	ErrAdminScopeRequired = errors.New("admin scope required")
+
+	// ErrUnsupportedDevice is returned when no enabled driver supports a
+	// device's brand, type and model
+	ErrUnsupportedDevice = errors.New("unsupported device")
+
+	// ErrTenantMismatch is returned when a tenant-scoped caller names another
+	// tenant in a request
+	ErrTenantMismatch = errors.New("tenant mismatch")
+
+	// ErrDeviceIdentityChanged is returned when an upsert names an existing
+	// device with another device type or brand
+	ErrDeviceIdentityChanged = errors.New("device type or brand cannot change")
+
+	// ErrDeviceNotConnected is returned for live queries on a device without
+	// a connected driver
+	ErrDeviceNotConnected = errors.New("device not connected")
+
+	// ErrPrinterStatusUnsupported is returned when a device's driver can't
+	// report real-time printer status
+	ErrPrinterStatusUnsupported = errors.New("printer status not supported")
+
+	// ErrPrinterStatusFailed is returned when a printer doesn't answer a
+	// real-time status query
+	ErrPrinterStatusFailed = errors.New("printer status query failed")
+)
+
+// UnsupportedDeviceError is an ErrUnsupportedDevice carrying the closest
+// supported driver entries, so callers can tell whether a wildcard model or
+// another driver would do
+type UnsupportedDeviceError struct {
+	Brand       model.DeviceBrand                 `json:"brand"`
+	DeviceType  model.DeviceType                  `json:"device_type"`
+	Model       string                            `json:"model"`
+	Suggestions []internalDriver.DriverSuggestion `json:"suggestions"`
+}
+
+func (e *UnsupportedDeviceError) Error() string {
+	return fmt.Sprintf("%s: %s %s %s", ErrUnsupportedDevice, e.Brand, e.DeviceType, e.Model)
+}
+
+func (e *UnsupportedDeviceError) Unwrap() error {
+	return ErrUnsupportedDevice
+}
+
+// newUnsupportedDeviceError builds the error for a device no driver supports
+func newUnsupportedDeviceError(registry *internalDriver.Registry, brand model.DeviceBrand, deviceType model.DeviceType, deviceModel string) *UnsupportedDeviceError {
+	return &UnsupportedDeviceError{
+		Brand:       brand,
+		DeviceType:  deviceType,
+		Model:       deviceModel,
+		Suggestions: registry.SuggestAlternatives(brand, deviceType, deviceModel),
+	}
+}
+
+// AgentTokenKey is the connection config key holding the token a device
+// agent authenticates its heartbeat stream with
+const AgentTokenKey = "agent_token"
+
+// defaultHeartbeatTimeout applies when no heartbeat timeout is configured
+const defaultHeartbeatTimeout = 30 * time.Second
+
 // DeviceService handles device management business logic
 type DeviceService struct {
 	deviceRepo     repository.DeviceRepository
 	operationRepo  repository.OperationRepository
+	statusEvents   repository.StatusEventRepository
+	inventoryRepo  repository.InventoryRepository
 	driverRegistry *internalDriver.Registry
 	config         *config.Config
 	logger         *utils.ServiceLogger
 	auditLogger    *utils.AuditLogger
+	driverPool     *internalDriver.Pool
+	configCipher   *utils.ConfigCipher
+
+	// Last agent heartbeat per device; devices with a fresh heartbeat are
+	// not actively pinged
+	heartbeats  map[string]time.Time
+	heartbeatMu sync.RWMutex
+
+	anomalyHandler func(*ErrorRateAnomaly)
 }
 
 // NewDeviceService creates a new device service instance
 func NewDeviceService(
 	deviceRepo repository.DeviceRepository,
 	operationRepo repository.OperationRepository,
+	statusEvents repository.StatusEventRepository,
+	inventoryRepo repository.InventoryRepository,
 	driverRegistry *internalDriver.Registry,
+	driverPool *internalDriver.Pool,
 	config *config.Config,
 	logger *zap.Logger,
 ) *DeviceService {
 	return &DeviceService{
 		deviceRepo:     deviceRepo,
 		operationRepo:  operationRepo,
+		statusEvents:   statusEvents,
+		inventoryRepo:  inventoryRepo,
 		driverRegistry: driverRegistry,
 		config:         config,
 		logger:         utils.NewServiceLogger(logger, "device-service"),
 		auditLogger:    utils.NewAuditLogger(logger),
+		driverPool:     driverPool,
+		heartbeats:     make(map[string]time.Time),
 	}
 }
 
+// SetConfigCipher enables encryption at rest of secret connection config values
+func (ds *DeviceService) SetConfigCipher(configCipher *utils.ConfigCipher) {
+	ds.configCipher = configCipher
+}
+
 // RegisterDevice registers a new device in the system
 func (ds *DeviceService) RegisterDevice(ctx context.Context, req *RegisterDeviceRequest) (*model.Device, error) {
+	// Settings left out are taken from the brand and model template
+	if req.ConnectionConfig != nil {
+		req.ConnectionConfig = withConfigTemplate(req.ConnectionConfig, req.Brand, req.Model, req.ConnectionType)
+	}
+
 	// Validate request
 	if err := ds.validateRegisterRequest(req); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
+	// Scoped callers register into their own tenant only
+	tenantID := req.TenantID
+	if scoped, ok := model.TenantFromContext(ctx); ok {
+		if tenantID != nil && *tenantID != scoped {
+			return nil, ErrTenantMismatch
+		}
+		tenantID = &scoped
+	}
+
 	// Check if device already exists
 	existing, err := ds.deviceRepo.GetByDeviceID(ctx, req.DeviceID)
 	if err == nil && existing != nil {
@@ -60,21 +188,29 @@ func (ds *DeviceService) RegisterDevice(ctx context.Context, req *RegisterDevice
 
 	// Verify driver support
 	if !ds.driverRegistry.IsSupported(req.Brand, req.DeviceType, req.Model) {
-		return nil, fmt.Errorf("unsupported device: %s %s %s", req.Brand, req.DeviceType, req.Model)
+		return nil, newUnsupportedDeviceError(ds.driverRegistry, req.Brand, req.DeviceType, req.Model)
+	}
+
+	requested := withPrintSettings(req.ConnectionConfig, req.PaperWidth, req.DefaultContentType)
+	connectionConfig, err := ds.sealConnectionConfig(ds.keepRawPrintOptIn(ctx, requested, nil))
+	if err != nil {
+		return nil, err
 	}
 
 	// Create device model
 	device := &model.Device{
 		ID:               uuid.New(),
 		DeviceID:         req.DeviceID,
+		Name:             normalizeDeviceName(req.Name),
 		DeviceType:       req.DeviceType,
 		Brand:            req.Brand,
 		Model:            req.Model,
 		FirmwareVersion:  req.FirmwareVersion,
 		ConnectionType:   req.ConnectionType,
-		ConnectionConfig: model.JSONObject(req.ConnectionConfig),
-		Capabilities:     ds.getDeviceCapabilities(req.DeviceType, req.Brand),
+		ConnectionConfig: connectionConfig,
+		Capabilities:     driver.CapabilityArray(driver.DefaultCapabilities(req.DeviceType, req.Brand)),
 		BranchID:         req.BranchID,
+		TenantID:         tenantID,
 		Location:         req.Location,
 		Status:           model.DeviceStatusOffline,
 		CreatedAt:        time.Now(),
@@ -105,6 +241,81 @@ func (ds *DeviceService) RegisterDevice(ctx context.Context, req *RegisterDevice
 	return device, nil
 }
 
+// UpsertDevice registers a device, or updates it when the device ID is
+// already registered, so provisioning can run repeatedly. An update takes the
+// request's model, connection, branch and descriptive fields; the request's
+// connection config is merged over the stored one, so settings made since
+// registration (capability flags, operation policy) are kept. The device
+// type and brand of an existing device can't change.
+func (ds *DeviceService) UpsertDevice(ctx context.Context, req *RegisterDeviceRequest) (*DeviceUpsertResult, error) {
+	existing, err := ds.deviceRepo.GetByDeviceID(ctx, req.DeviceID)
+	if err != nil && !errors.Is(err, repository.ErrDeviceNotFound) {
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+	if existing == nil {
+		device, err := ds.RegisterDevice(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return &DeviceUpsertResult{Device: device, Created: true}, nil
+	}
+
+	if req.ConnectionConfig != nil {
+		req.ConnectionConfig = withConfigTemplate(req.ConnectionConfig, req.Brand, req.Model, req.ConnectionType)
+	}
+	if err := ds.validateRegisterRequest(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if req.TenantID != nil && (existing.TenantID == nil || *existing.TenantID != *req.TenantID) {
+		return nil, ErrTenantMismatch
+	}
+	if req.DeviceType != existing.DeviceType || req.Brand != existing.Brand {
+		return nil, fmt.Errorf("%w: registered as %s %s", ErrDeviceIdentityChanged, existing.Brand, existing.DeviceType)
+	}
+	if !ds.driverRegistry.IsSupported(req.Brand, req.DeviceType, req.Model) {
+		return nil, newUnsupportedDeviceError(ds.driverRegistry, req.Brand, req.DeviceType, req.Model)
+	}
+
+	merged := make(map[string]interface{}, len(existing.ConnectionConfig)+len(req.ConnectionConfig))
+	for key, value := range existing.ConnectionConfig {
+		merged[key] = value
+	}
+	for key, value := range keepStoredSecrets(req.ConnectionConfig, existing.ConnectionConfig) {
+		merged[key] = value
+	}
+	ds.keepRawPrintOptIn(ctx, merged, existing.ConnectionConfig)
+	connectionConfig, err := ds.sealConnectionConfig(
+		withPrintSettings(merged, req.PaperWidth, req.DefaultContentType),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	oldConfig := existing.ConnectionConfig
+	existing.Name = normalizeDeviceName(req.Name)
+	existing.Model = req.Model
+	existing.FirmwareVersion = req.FirmwareVersion
+	existing.ConnectionType = req.ConnectionType
+	existing.ConnectionConfig = connectionConfig
+	existing.BranchID = req.BranchID
+	existing.Location = req.Location
+	existing.UpdatedAt = time.Now()
+
+	if err := ds.deviceRepo.Update(ctx, existing); err != nil {
+		return nil, fmt.Errorf("failed to update device: %w", err)
+	}
+
+	ds.auditLogger.LogDeviceConfiguration(existing.DeviceID, req.UserID, oldConfig, connectionConfig)
+
+	ds.logger.Info("Device updated by upsert",
+		zap.String("device_id", existing.DeviceID),
+		zap.String("user_id", req.UserID),
+	)
+
+	return &DeviceUpsertResult{Device: existing, Created: false}, nil
+}
+
 // ConnectDevice attempts to connect to a device
 func (ds *DeviceService) ConnectDevice(ctx context.Context, deviceID string) error {
 	// Get device from database
@@ -114,16 +325,16 @@ func (ds *DeviceService) ConnectDevice(ctx context.Context, deviceID string) err
 	}
 
 	// Create device logger
-	deviceLogger := utils.NewDeviceLogger(ds.logger.Logger, device.DeviceID, string(device.DeviceType), string(device.Brand))
+	deviceLogger := utils.NewDeviceLogger(ds.logger.Logger, device)
 
 	// Update status to connecting
 	device.Status = model.DeviceStatusConnecting
-	if err := ds.deviceRepo.UpdateStatus(ctx, device.ID, device.Status); err != nil {
+	if err := ds.deviceRepo.UpdateStatus(ctx, device.ID, device.Status, "connect requested"); err != nil {
 		deviceLogger.Error("Failed to update device status", zap.Error(err))
 	}
 
 	// Create driver
-	driverInstance, err := ds.driverRegistry.CreateDriver(device, device.ConnectionConfig)
+	driverInstance, err := ds.driverRegistry.CreateLazyDriver(ctx, device, device.ConnectionConfig)
 	if err != nil {
 		deviceLogger.LogConnection("create_driver", false, err)
 		ds.updateDeviceError(ctx, device, err)
@@ -151,6 +362,10 @@ func (ds *DeviceService) ConnectDevice(ctx context.Context, deviceID string) err
 
 	deviceLogger.LogConnection("connect", true, nil)
 
+	if previous := ds.driverPool.Put(device.DeviceID, driverInstance); previous != nil {
+		previous.Close()
+	}
+
 	// Start health monitoring
 	go ds.startHealthMonitoring(device, driverInstance)
 
@@ -164,18 +379,95 @@ func (ds *DeviceService) DisconnectDevice(ctx context.Context, deviceID string)
 		return fmt.Errorf("device not found: %w", err)
 	}
 
-	deviceLogger := utils.NewDeviceLogger(ds.logger.Logger, device.DeviceID, string(device.DeviceType), string(device.Brand))
+	deviceLogger := utils.NewDeviceLogger(ds.logger.Logger, device)
+
+	// Release the live connection first; the driver leaves the cache even if
+	// the disconnect fails, so it is never reused
+	disconnectErr := ds.closeDriver(ctx, device.DeviceID, deviceLogger)
 
 	// Update status
 	device.Status = model.DeviceStatusOffline
-	if err := ds.deviceRepo.UpdateStatus(ctx, device.ID, device.Status); err != nil {
+	if err := ds.deviceRepo.UpdateStatus(ctx, device.ID, device.Status, "disconnect requested"); err != nil {
 		deviceLogger.Error("Failed to update device status", zap.Error(err))
 	}
 
-	deviceLogger.LogConnection("disconnect", true, nil)
+	deviceLogger.LogConnection("disconnect", disconnectErr == nil, disconnectErr)
 	return nil
 }
 
+// ReconnectDevice evicts the cached driver of a device, closes its protocol and
+// establishes a fresh, ping-verified connection
+func (ds *DeviceService) ReconnectDevice(ctx context.Context, deviceID string) (*ReconnectResult, error) {
+	device, err := ds.deviceRepo.GetByDeviceID(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("device not found: %w", err)
+	}
+
+	deviceLogger := utils.NewDeviceLogger(ds.logger.Logger, device)
+	ds.closeDriver(ctx, device.DeviceID, deviceLogger)
+
+	startTime := time.Now()
+	if err := ds.ConnectDevice(ctx, deviceID); err != nil {
+		return nil, err
+	}
+
+	driverInstance, ok := ds.driverPool.Get(deviceID)
+	if !ok {
+		return nil, fmt.Errorf("device %s has no live driver after reconnect", deviceID)
+	}
+
+	// Connectivity test on the new connection
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	pingStart := time.Now()
+	if err := driverInstance.Ping(pingCtx); err != nil {
+		deviceLogger.LogConnection("reconnect", false, err)
+		ds.closeDriver(ctx, deviceID, deviceLogger)
+		ds.updateDeviceError(ctx, device, err)
+		return nil, fmt.Errorf("connectivity test failed after reconnect: %w", err)
+	}
+	pingDuration := time.Since(pingStart)
+
+	device, err = ds.deviceRepo.GetByDeviceID(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("device not found: %w", err)
+	}
+
+	deviceLogger.LogConnection("reconnect", true, nil)
+
+	return &ReconnectResult{
+		DeviceID:     device.DeviceID,
+		Status:       device.Status,
+		Connected:    driverInstance.IsConnected(),
+		LastPing:     device.LastPing,
+		PingDuration: pingDuration.String(),
+		Duration:     time.Since(startTime).String(),
+	}, nil
+}
+
+// closeDriver removes the cached driver of a device from the pool and closes
+// its connection. A driver still running operations is closed by the pool
+// when they finish. The health monitor of the driver stops on its next tick.
+func (ds *DeviceService) closeDriver(ctx context.Context, deviceID string, deviceLogger *utils.DeviceLogger) error {
+	driverInstance, ok := ds.driverPool.Remove(deviceID)
+	if !ok {
+		return nil
+	}
+
+	disconnectCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	disconnectErr := driverInstance.Disconnect(disconnectCtx)
+	if disconnectErr != nil {
+		deviceLogger.Warn("Failed to disconnect driver", zap.Error(disconnectErr))
+	}
+	if err := driverInstance.Close(); err != nil {
+		deviceLogger.Warn("Failed to close driver", zap.Error(err))
+	}
+	return disconnectErr
+}
+
 // GetDevice retrieves device information
 func (ds *DeviceService) GetDevice(ctx context.Context, deviceID string) (*model.Device, error) {
 	device, err := ds.deviceRepo.GetByDeviceID(ctx, deviceID)
@@ -202,6 +494,44 @@ func (ds *DeviceService) ListDevices(ctx context.Context, filter *DeviceFilter)
 	return devices, pagination, nil
 }
 
+// UpdateDevice updates the descriptive fields of a device. Fields left out
+// of the request keep their value; an empty name or location clears it.
+func (ds *DeviceService) UpdateDevice(ctx context.Context, deviceID string, req *UpdateDeviceRequest, userID string) (*model.Device, error) {
+	device, err := ds.deviceRepo.GetByDeviceID(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("device not found: %w", err)
+	}
+
+	if err := validateDeviceName(req.Name); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if req.Name != nil {
+		device.Name = normalizeDeviceName(req.Name)
+	}
+	if req.Location != nil {
+		device.Location = nil
+		if location := strings.TrimSpace(*req.Location); location != "" {
+			device.Location = &location
+		}
+	}
+	if req.FirmwareVersion != nil {
+		device.FirmwareVersion = req.FirmwareVersion
+	}
+	device.UpdatedAt = time.Now()
+
+	if err := ds.deviceRepo.Update(ctx, device); err != nil {
+		return nil, fmt.Errorf("failed to update device: %w", err)
+	}
+
+	ds.logger.Info("Device updated",
+		zap.String("device_id", deviceID),
+		zap.String("user_id", userID),
+	)
+
+	return device, nil
+}
+
 // UpdateDeviceConfiguration updates device configuration
 func (ds *DeviceService) UpdateDeviceConfiguration(ctx context.Context, deviceID string, config map[string]interface{}, userID string) error {
 	device, err := ds.deviceRepo.GetByDeviceID(ctx, deviceID)
@@ -209,8 +539,18 @@ func (ds *DeviceService) UpdateDeviceConfiguration(ctx context.Context, deviceID
 		return fmt.Errorf("device not found: %w", err)
 	}
 
+	if err := validatePrintSettings(config); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	config = ds.keepRawPrintOptIn(ctx, keepStoredSecrets(config, device.ConnectionConfig), device.ConnectionConfig)
+	newConfig, err := ds.sealConnectionConfig(config)
+	if err != nil {
+		return err
+	}
+
 	oldConfig := device.ConnectionConfig
-	device.ConnectionConfig = model.JSONObject(config)
+	device.ConnectionConfig = newConfig
 	device.UpdatedAt = time.Now()
 
 	if err := ds.deviceRepo.Update(ctx, device); err != nil {
@@ -218,7 +558,7 @@ func (ds *DeviceService) UpdateDeviceConfiguration(ctx context.Context, deviceID
 	}
 
 	// Audit log
-	ds.auditLogger.LogDeviceConfiguration(deviceID, userID, oldConfig, config)
+	ds.auditLogger.LogDeviceConfiguration(deviceID, userID, oldConfig, newConfig)
 
 	ds.logger.Info("Device configuration updated",
 		zap.String("device_id", deviceID),
@@ -228,6 +568,39 @@ func (ds *DeviceService) UpdateDeviceConfiguration(ctx context.Context, deviceID
 	return nil
 }
 
+// keepRawPrintOptIn keeps the raw ESC/POS opt-in of a connection config as
+// stored unless the caller has the admin scope, so it can't be enabled
+// around SetOperationPolicy by writing the config directly
+func (ds *DeviceService) keepRawPrintOptIn(ctx context.Context, config map[string]interface{}, stored model.JSONObject) map[string]interface{} {
+	if scopeGranted(ctx, &ds.config.Security, model.ScopeAdmin) {
+		return config
+	}
+	if value, ok := stored[driver.AllowRawPrintKey]; ok {
+		if config == nil {
+			config = make(map[string]interface{})
+		}
+		config[driver.AllowRawPrintKey] = value
+	} else {
+		delete(config, driver.AllowRawPrintKey)
+	}
+	return config
+}
+
+// keepStoredSecrets replaces secrets echoed back as redacted placeholders
+// with their stored value, or drops them when nothing is stored
+func keepStoredSecrets(config map[string]interface{}, stored model.JSONObject) map[string]interface{} {
+	for key, value := range config {
+		if value == model.RedactedValue && model.IsSecretConfigKey(key) {
+			if storedValue, ok := stored[key]; ok {
+				config[key] = storedValue
+			} else {
+				delete(config, key)
+			}
+		}
+	}
+	return config
+}
+
 // DeleteDevice removes a device from the system
 func (ds *DeviceService) DeleteDevice(ctx context.Context, deviceID string, userID string) error {
 	device, err := ds.deviceRepo.GetByDeviceID(ctx, deviceID)
@@ -243,6 +616,7 @@ func (ds *DeviceService) DeleteDevice(ctx context.Context, deviceID string, user
 	if err := ds.deviceRepo.Delete(ctx, device.ID); err != nil {
 		return fmt.Errorf("failed to delete device: %w", err)
 	}
+	protocol.ClearCapturedOutput(device.DeviceID)
 
 	ds.logger.Info("Device deleted",
 		zap.String("device_id", deviceID),
@@ -252,113 +626,843 @@ func (ds *DeviceService) DeleteDevice(ctx context.Context, deviceID string, user
 	return nil
 }
 
-// GetDeviceHealth retrieves device health metrics
-func (ds *DeviceService) GetDeviceHealth(ctx context.Context, deviceID string) (*DeviceHealth, error) {
+// SetCapabilityFlags enables or disables toggleable capabilities of a device.
+// The flags are persisted with the connection config and applied to the live
+// driver, if any, so e.g. a broken cutter can be switched off without a
+// reconnect.
+func (ds *DeviceService) SetCapabilityFlags(ctx context.Context, deviceID string, flags map[model.Capability]bool, userID string) (*CapabilityFlagsResult, error) {
 	device, err := ds.deviceRepo.GetByDeviceID(ctx, deviceID)
 	if err != nil {
 		return nil, fmt.Errorf("device not found: %w", err)
 	}
 
-	// Get latest health metrics
-	healthLogs, err := ds.deviceRepo.GetHealthLogs(ctx, device.ID, 1)
-	if err != nil || len(healthLogs) == 0 {
-		return &DeviceHealth{
-			DeviceID:    deviceID,
-			HealthScore: 0,
-			Status:      string(device.Status),
-			LastCheck:   device.LastPing,
-		}, nil
+	supported := ds.toggleableCapabilities(device)
+	for capability := range flags {
+		if !supported[capability] {
+			return nil, fmt.Errorf("%w: %s on %s %s", ErrCapabilityNotToggleable, capability, device.Brand, device.DeviceType)
+		}
 	}
 
-	latestHealth := healthLogs[0]
-	return &DeviceHealth{
+	oldConfig := device.ConnectionConfig
+	newConfig := make(model.JSONObject, len(oldConfig)+len(flags))
+	for key, value := range oldConfig {
+		newConfig[key] = value
+	}
+
+	capabilities := make(model.JSONArray, 0, len(device.Capabilities))
+	for _, capability := range device.Capabilities {
+		if enabled, toggled := flags[model.Capability(fmt.Sprint(capability))]; toggled && !enabled {
+			continue
+		}
+		capabilities = append(capabilities, capability)
+	}
+	for capability, enabled := range flags {
+		newConfig[driver.CapabilityFlags[capability]] = enabled
+		if enabled && !device.HasCapability(capability) {
+			capabilities = append(capabilities, string(capability))
+		}
+	}
+
+	device.ConnectionConfig = newConfig
+	device.Capabilities = capabilities
+	device.UpdatedAt = time.Now()
+
+	if err := ds.deviceRepo.Update(ctx, device); err != nil {
+		return nil, fmt.Errorf("failed to update device capabilities: %w", err)
+	}
+
+	ds.auditLogger.LogDeviceConfiguration(deviceID, userID, oldConfig, newConfig)
+
+	result := &CapabilityFlagsResult{
 		DeviceID:     deviceID,
-		HealthScore:  latestHealth.HealthScore,
-		Status:       string(device.Status),
-		LastCheck:    device.LastPing,
-		ResponseTime: latestHealth.ResponseTime,
-		ErrorRate:    latestHealth.ErrorRate,
-		Uptime:       latestHealth.Uptime,
-		//TODO: Metrics:      latestHealth.Metrics,
-	}, nil
+		Flags:        ds.capabilityFlags(device),
+		Capabilities: device.Capabilities,
+	}
+
+	// Apply to the live driver right away
+	if driverInstance, ok := ds.driverPool.Get(deviceID); ok {
+		if err := ds.driverRegistry.ConfigureDriver(driverInstance, device); err != nil {
+			ds.logger.Warn("Failed to apply capability flags to live driver",
+				zap.String("device_id", deviceID),
+				zap.Error(err),
+			)
+		} else {
+			result.AppliedLive = true
+		}
+	}
+
+	ds.logger.Info("Device capability flags updated",
+		zap.String("device_id", deviceID),
+		zap.Any("flags", flags),
+		zap.Bool("applied_live", result.AppliedLive),
+		zap.String("user_id", userID),
+	)
+
+	return result, nil
 }
 
-// TestDevice performs a device connectivity test
-func (ds *DeviceService) TestDevice(ctx context.Context, deviceID string) (*TestResult, error) {
+// GetCapabilityFlags returns the capability toggles of a device
+func (ds *DeviceService) GetCapabilityFlags(ctx context.Context, deviceID string) (*CapabilityFlagsResult, error) {
 	device, err := ds.deviceRepo.GetByDeviceID(ctx, deviceID)
 	if err != nil {
 		return nil, fmt.Errorf("device not found: %w", err)
 	}
 
-	deviceLogger := utils.NewDeviceLogger(ds.logger.Logger, device.DeviceID, string(device.DeviceType), string(device.Brand))
-
-	startTime := time.Now()
+	return &CapabilityFlagsResult{
+		DeviceID:     deviceID,
+		Flags:        ds.capabilityFlags(device),
+		Capabilities: device.Capabilities,
+	}, nil
+}
 
-	// Create driver
-	driverInstance, err := ds.driverRegistry.CreateDriver(device, device.ConnectionConfig)
+// SetWireLogging turns hex dumps of a device's protocol traffic on or off. The
+// flag is persisted so it survives reconnects and applied to the live driver.
+func (ds *DeviceService) SetWireLogging(ctx context.Context, deviceID string, enabled bool, userID string) (*WireLoggingResult, error) {
+	device, err := ds.deviceRepo.GetByDeviceID(ctx, deviceID)
 	if err != nil {
-		return &TestResult{
-			Success:      false,
-			ErrorMessage: err.Error(),
-			Duration:     time.Since(startTime).String(),
-		}, nil
+		return nil, fmt.Errorf("device not found: %w", err)
 	}
 
-	// Test connection
-	testCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-
-	if err := driverInstance.Connect(testCtx); err != nil {
-		deviceLogger.LogConnection("test", false, err)
-		return &TestResult{
-			Success:      false,
-			ErrorMessage: err.Error(),
-			Duration:     time.Since(startTime).String(),
-		}, nil
+	oldConfig := device.ConnectionConfig
+	newConfig := make(model.JSONObject, len(oldConfig)+1)
+	for key, value := range oldConfig {
+		newConfig[key] = value
 	}
-	defer driverInstance.Disconnect(testCtx)
+	newConfig[driver.WireLoggingKey] = enabled
 
-	// Test ping
-	if err := driverInstance.Ping(testCtx); err != nil {
-		return &TestResult{
-			Success:      false,
-			ErrorMessage: err.Error(),
-			Duration:     time.Since(startTime).String(),
-		}, nil
+	device.ConnectionConfig = newConfig
+	device.UpdatedAt = time.Now()
+
+	if err := ds.deviceRepo.Update(ctx, device); err != nil {
+		return nil, fmt.Errorf("failed to update wire logging: %w", err)
 	}
 
-	// Get device info
-	deviceInfo, err := driverInstance.GetDeviceInfo()
-	if err != nil {
-		deviceLogger.Warn("Failed to get device info during test", zap.Error(err))
+	ds.auditLogger.LogDeviceConfiguration(deviceID, userID, oldConfig, newConfig)
+
+	result := &WireLoggingResult{DeviceID: deviceID, Enabled: enabled}
+	if driverInstance, ok := ds.driverPool.Get(deviceID); ok {
+		if toggler, ok := driverInstance.(driver.WireLogToggler); ok {
+			toggler.SetWireLogging(enabled)
+			result.AppliedLive = true
+		}
 	}
 
-	deviceLogger.LogConnection("test", true, nil)
+	ds.logger.Info("Device wire logging updated",
+		zap.String("device_id", deviceID),
+		zap.Bool("enabled", enabled),
+		zap.Bool("applied_live", result.AppliedLive),
+		zap.String("user_id", userID),
+	)
 
-	return &TestResult{
-		Success:    true,
-		Duration:   time.Since(startTime).String(),
-		DeviceInfo: deviceInfo,
-	}, nil
+	return result, nil
 }
 
-// Helper methods
+// GetPrinterStatus queries a connected printer's real-time status (paper,
+// cover, cutter and drawer state) through its driver
+func (ds *DeviceService) GetPrinterStatus(ctx context.Context, deviceID string) (*driver.PrinterStatus, error) {
+	device, err := ds.deviceRepo.GetByDeviceID(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("device not found: %w", err)
+	}
 
-// validateRegisterRequest validates device registration request
-func (ds *DeviceService) validateRegisterRequest(req *RegisterDeviceRequest) error {
-	if req.DeviceID == "" {
-		return fmt.Errorf("device_id is required")
+	driverInstance, ok := ds.driverPool.Get(device.DeviceID)
+	if !ok || !driverInstance.IsConnected() {
+		return nil, ErrDeviceNotConnected
 	}
-	if req.DeviceType == "" {
-		return fmt.Errorf("device_type is required")
+
+	reporter, ok := driverInstance.(driver.PrinterStatusReporter)
+	if !ok {
+		return nil, ErrPrinterStatusUnsupported
 	}
-	if req.Brand == "" {
-		return fmt.Errorf("brand is required")
+
+	status, err := reporter.GetPrinterStatus(ctx)
+	if driver.ErrorCodeOf(err) == driver.ErrorCodeUnsupported {
+		return nil, fmt.Errorf("%w: %v", ErrPrinterStatusUnsupported, err)
 	}
-	if req.Model == "" {
-		return fmt.Errorf("model is required")
+	if err != nil {
+		ds.logger.Warn("Failed to query printer status",
+			zap.String("device_id", deviceID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("%w: %v", ErrPrinterStatusFailed, err)
 	}
-	if req.ConnectionType == "" {
+
+	return status, nil
+}
+
+// SetOperationPolicy replaces the operation allow and deny lists of a device.
+// The lists are administrative policy on top of capabilities: an operation
+// the device can perform is still rejected when it is denied, or when an
+// allow list is set and doesn't name it. Empty lists clear the policy.
+// Enabling raw ESC/POS printing takes the admin scope when auth is enabled.
+func (ds *DeviceService) SetOperationPolicy(ctx context.Context, deviceID string, policy *OperationPolicy, userID string) (*OperationPolicy, error) {
+	if err := validateOperationPolicy(policy); err != nil {
+		return nil, err
+	}
+
+	device, err := ds.deviceRepo.GetByDeviceID(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("device not found: %w", err)
+	}
+	if policy.AllowRawPrint && !driver.RawPrintAllowed(device.ConnectionConfig) && !scopeGranted(ctx, &ds.config.Security, model.ScopeAdmin) {
+		return nil, fmt.Errorf("%w: to allow raw ESC/POS printing", ErrAdminScopeRequired)
+	}
+
+	oldConfig := device.ConnectionConfig
+	newConfig := make(model.JSONObject, len(oldConfig)+2)
+	for key, value := range oldConfig {
+		newConfig[key] = value
+	}
+	setOperationList(newConfig, driver.AllowedOperationsKey, policy.Allowed)
+	setOperationList(newConfig, driver.DeniedOperationsKey, policy.Denied)
+	if policy.AllowRawPrint {
+		newConfig[driver.AllowRawPrintKey] = true
+	} else {
+		delete(newConfig, driver.AllowRawPrintKey)
+	}
+
+	device.ConnectionConfig = newConfig
+	device.UpdatedAt = time.Now()
+
+	if err := ds.deviceRepo.Update(ctx, device); err != nil {
+		return nil, fmt.Errorf("failed to update operation policy: %w", err)
+	}
+
+	ds.auditLogger.LogDeviceConfiguration(deviceID, userID, oldConfig, newConfig)
+
+	result := operationPolicy(device)
+
+	ds.logger.Info("Device operation policy updated",
+		zap.String("device_id", deviceID),
+		zap.Any("allowed", result.Allowed),
+		zap.Any("denied", result.Denied),
+		zap.Bool("allow_raw_print", result.AllowRawPrint),
+		zap.String("user_id", userID),
+	)
+
+	return result, nil
+}
+
+// GetOperationPolicy returns the operation allow and deny lists of a device
+func (ds *DeviceService) GetOperationPolicy(ctx context.Context, deviceID string) (*OperationPolicy, error) {
+	device, err := ds.deviceRepo.GetByDeviceID(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("device not found: %w", err)
+	}
+
+	return operationPolicy(device), nil
+}
+
+// validateOperationPolicy rejects unknown operations and operations that are
+// both allowed and denied
+func validateOperationPolicy(policy *OperationPolicy) error {
+	allowed := make(map[model.OperationType]bool, len(policy.Allowed))
+	for _, operation := range policy.Allowed {
+		if !operation.IsValid() {
+			return fmt.Errorf("%w: unknown operation %q", ErrInvalidOperationPolicy, operation)
+		}
+		allowed[operation] = true
+	}
+	for _, operation := range policy.Denied {
+		if !operation.IsValid() {
+			return fmt.Errorf("%w: unknown operation %q", ErrInvalidOperationPolicy, operation)
+		}
+		if allowed[operation] {
+			return fmt.Errorf("%w: %s is both allowed and denied", ErrInvalidOperationPolicy, operation)
+		}
+	}
+	return nil
+}
+
+// setOperationList stores an operation list in a connection config, removing
+// the key for an empty list
+func setOperationList(config model.JSONObject, key string, operations []model.OperationType) {
+	if len(operations) == 0 {
+		delete(config, key)
+		return
+	}
+
+	names := make([]string, 0, len(operations))
+	seen := make(map[model.OperationType]bool, len(operations))
+	for _, operation := range operations {
+		if !seen[operation] {
+			seen[operation] = true
+			names = append(names, string(operation))
+		}
+	}
+	sort.Strings(names)
+	config[key] = names
+}
+
+// operationPolicy reads the operation policy of a device
+func operationPolicy(device *model.Device) *OperationPolicy {
+	return &OperationPolicy{
+		DeviceID: device.DeviceID,
+		Allowed:  driver.OperationList(device.ConnectionConfig, driver.AllowedOperationsKey),
+		Denied:   driver.OperationList(device.ConnectionConfig, driver.DeniedOperationsKey),
+
+		AllowRawPrint: driver.RawPrintAllowed(device.ConnectionConfig),
+	}
+}
+
+// toggleableCapabilities returns the capabilities of a device that can be
+// switched on or off
+func (ds *DeviceService) toggleableCapabilities(device *model.Device) map[model.Capability]bool {
+	supported := make(map[model.Capability]bool)
+	for _, capability := range driver.DefaultCapabilities(device.DeviceType, device.Brand) {
+		supported[capability] = true
+	}
+	if device.DeviceType == model.DeviceTypePrinter {
+		supported[model.CapabilityLogo] = true
+		supported[model.CapabilityTwoColor] = true
+	}
+
+	toggleable := make(map[model.Capability]bool)
+	for capability := range driver.CapabilityFlags {
+		if supported[capability] {
+			toggleable[capability] = true
+		}
+	}
+	return toggleable
+}
+
+// capabilityFlags reports the current toggle of each toggleable capability.
+// Without an explicit flag a capability is enabled when the device has it.
+func (ds *DeviceService) capabilityFlags(device *model.Device) map[model.Capability]bool {
+	flags := make(map[model.Capability]bool)
+	for capability := range ds.toggleableCapabilities(device) {
+		if enabled, ok := driver.CapabilityFlag(device.ConnectionConfig, capability); ok {
+			flags[capability] = enabled
+			continue
+		}
+		flags[capability] = device.HasCapability(capability)
+	}
+	return flags
+}
+
+// GetDeviceHealth retrieves device health metrics
+func (ds *DeviceService) GetDeviceHealth(ctx context.Context, deviceID string) (*DeviceHealth, error) {
+	device, err := ds.deviceRepo.GetByDeviceID(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("device not found: %w", err)
+	}
+
+	// Prefer real-time metrics of a live driver over stored health logs
+	if health := ds.liveDeviceHealth(device); health != nil {
+		return health, nil
+	}
+
+	// Get latest health metrics
+	healthLogs, err := ds.deviceRepo.GetHealthLogs(ctx, device.ID, 1)
+	if err != nil || len(healthLogs) == 0 {
+		return &DeviceHealth{
+			DeviceID:    deviceID,
+			HealthScore: 0,
+			Status:      string(device.Status),
+			LastCheck:   device.LastPing,
+			Source:      HealthSourceStored,
+		}, nil
+	}
+
+	latestHealth := healthLogs[0]
+	return &DeviceHealth{
+		DeviceID:     deviceID,
+		HealthScore:  latestHealth.HealthScore,
+		Status:       string(device.Status),
+		LastCheck:    device.LastPing,
+		ResponseTime: latestHealth.ResponseTime,
+		ErrorRate:    latestHealth.ErrorRate,
+		Uptime:       latestHealth.Uptime,
+		Source:       HealthSourceStored,
+		//TODO: Metrics:      latestHealth.Metrics,
+	}, nil
+}
+
+// GetStatusHistory returns the status transitions of a device, newest first
+func (ds *DeviceService) GetStatusHistory(ctx context.Context, deviceID string, req *StatusHistoryRequest) (*DeviceStatusHistory, error) {
+	device, err := ds.deviceRepo.GetByDeviceID(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("device not found: %w", err)
+	}
+
+	events, err := ds.statusEvents.ListByDevice(ctx, device.ID, &repository.StatusEventFilter{
+		StartDate: req.Since,
+		EndDate:   req.Until,
+		Limit:     req.Limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status history: %w", err)
+	}
+
+	return &DeviceStatusHistory{
+		DeviceID:      device.DeviceID,
+		CurrentStatus: device.Status,
+		Events:        events,
+	}, nil
+}
+
+// liveDeviceHealth builds device health from the connected driver's metrics.
+// It returns nil when no live driver exists or metrics are unavailable.
+func (ds *DeviceService) liveDeviceHealth(device *model.Device) *DeviceHealth {
+	driverInstance, exists := ds.driverPool.Get(device.DeviceID)
+	if !exists || !driverInstance.IsConnected() {
+		return nil
+	}
+
+	metrics, err := driverInstance.GetHealthMetrics()
+	if err != nil || metrics == nil {
+		ds.logger.Warn("Failed to get live health metrics, falling back to stored logs",
+			zap.String("device_id", device.DeviceID),
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	responseTime := int(metrics.ResponseTime.Milliseconds())
+	errorRate := 1 - metrics.SuccessRate
+	uptime := metrics.UptimePercent
+	now := time.Now()
+
+	health := &DeviceHealth{
+		DeviceID:     device.DeviceID,
+		HealthScore:  metrics.HealthScore,
+		Status:       string(device.Status),
+		LastCheck:    &now,
+		ResponseTime: &responseTime,
+		ErrorRate:    &errorRate,
+		Uptime:       &uptime,
+		Source:       HealthSourceLive,
+		Metrics: map[string]interface{}{
+			"success_rate":     metrics.SuccessRate,
+			"error_count":      metrics.ErrorCount,
+			"total_operations": metrics.TotalOperations,
+		},
+	}
+
+	if metrics.LastErrorTime != nil {
+		health.Metrics["last_error_time"] = metrics.LastErrorTime
+	}
+	if metrics.LastSuccessTime != nil {
+		health.Metrics["last_success_time"] = metrics.LastSuccessTime
+	}
+	if metrics.Transport != nil {
+		health.Metrics["transport"] = metrics.Transport
+	}
+
+	return health
+}
+
+// GetConnectionState returns the live driver/connection state of a device
+func (ds *DeviceService) GetConnectionState(ctx context.Context, deviceID string) (*DeviceConnection, error) {
+	device, err := ds.deviceRepo.GetByDeviceID(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("device not found: %w", err)
+	}
+
+	return &DeviceConnection{
+		ConnectionState: ds.driverPool.State(device.DeviceID),
+		Status:          device.Status,
+		ConnectionType:  device.ConnectionType,
+		LastPing:        device.LastPing,
+	}, nil
+}
+
+// Bulk status refresh limits
+const (
+	statusRefreshConcurrency = 8
+	statusRefreshTimeout     = 5 * time.Second
+)
+
+// CheckDeviceHealth pings a device and stores the outcome: a failed ping puts
+// the device in ERROR, a successful one updates the last ping and writes a
+// health log. Devices whose agent pushes heartbeats are not pinged.
+//
+// A pooled connection is pinged when there is one; otherwise only the
+// transport is probed, so health checks never create and initialize a driver.
+func (ds *DeviceService) CheckDeviceHealth(ctx context.Context, device *model.Device) *StatusCheckResult {
+	result := &StatusCheckResult{
+		DeviceID:       device.DeviceID,
+		PreviousStatus: device.Status,
+		Status:         device.Status,
+		CheckedAt:      time.Now(),
+	}
+
+	// Devices whose agent pushes heartbeats are not polled
+	if ds.HeartbeatActive(device.DeviceID) {
+		result.Source = StatusCheckSourceHeartbeat
+		return result
+	}
+	result.Source = StatusCheckSourcePing
+
+	// Ping device
+	startTime := time.Now()
+	var err error
+	if driverInstance, release, ok := ds.driverPool.AcquireConnected(device.DeviceID); ok {
+		err = driverInstance.Ping(ctx)
+		release(err)
+	} else {
+		err = ds.driverRegistry.ProbeTransport(ctx, device)
+	}
+	responseTime := int(time.Since(startTime).Milliseconds())
+	result.ResponseTime = &responseTime
+
+	if errors.Is(err, ErrDriverNotFound) {
+		ds.logger.Error("No driver for health check",
+			zap.Error(err),
+			zap.String("device_id", device.DeviceID),
+		)
+		result.Error = err.Error()
+		result.ErrorCode = OperationErrorCode(err)
+		return result
+	}
+
+	if err != nil {
+		// Device is not responding
+		result.Error = err.Error()
+		if updateErr := ds.deviceRepo.UpdateStatus(ctx, device.ID, model.DeviceStatusError, fmt.Sprintf("health check failed: %v", err)); updateErr != nil {
+			ds.logger.Error("Failed to update device status", zap.Error(updateErr), zap.String("device_id", device.DeviceID))
+			return result
+		}
+		result.Status = model.DeviceStatusError
+		ds.logger.Warn("Device health check failed",
+			zap.String("device_id", device.DeviceID),
+			zap.Error(err),
+		)
+		return result
+	}
+
+	// Device is healthy
+	ds.deviceRepo.UpdateLastPing(ctx, device.ID, time.Now())
+	ds.deviceRepo.CreateHealthLog(ctx, &model.DeviceHealth{
+		DeviceID:     device.ID,
+		HealthScore:  100,
+		ResponseTime: &responseTime,
+		RecordedAt:   time.Now(),
+	})
+
+	return result
+}
+
+// RefreshStatuses runs a health check on every online device, optionally
+// limited to a branch, and returns each device's status once all checks
+// finished. Checks run concurrently and each is bounded by a timeout.
+func (ds *DeviceService) RefreshStatuses(ctx context.Context, branchID *uuid.UUID) (*StatusRefreshResult, error) {
+	var devices []*model.Device
+	if branchID != nil {
+		branchDevices, err := ds.deviceRepo.ListByBranch(ctx, *branchID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list branch devices: %w", err)
+		}
+		for _, device := range branchDevices {
+			if device.Status == model.DeviceStatusOnline {
+				devices = append(devices, device)
+			}
+		}
+	} else {
+		onlineDevices, err := ds.deviceRepo.ListByStatus(ctx, model.DeviceStatusOnline)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list online devices: %w", err)
+		}
+		devices = onlineDevices
+	}
+
+	startTime := time.Now()
+	results := make([]*StatusCheckResult, len(devices))
+	semaphore := make(chan struct{}, statusRefreshConcurrency)
+
+	var wg sync.WaitGroup
+	for i, device := range devices {
+		wg.Add(1)
+		go func(i int, device *model.Device) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			// A panicking check still reports its device
+			results[i] = &StatusCheckResult{
+				DeviceID:       device.DeviceID,
+				PreviousStatus: device.Status,
+				Status:         device.Status,
+				Source:         StatusCheckSourcePing,
+				Error:          "health check failed",
+				CheckedAt:      time.Now(),
+			}
+			defer utils.RecoverPanic(ds.logger.Logger, zap.String("goroutine", "refresh_device_status"), zap.String("device_id", device.DeviceID))
+
+			checkCtx, cancel := context.WithTimeout(ctx, statusRefreshTimeout)
+			defer cancel()
+
+			results[i] = ds.CheckDeviceHealth(checkCtx, device)
+		}(i, device)
+	}
+	wg.Wait()
+
+	refresh := &StatusRefreshResult{
+		BranchID:   branchID,
+		Total:      len(results),
+		DurationMs: time.Since(startTime).Milliseconds(),
+		Results:    results,
+	}
+	for _, result := range results {
+		if result.Error != "" {
+			refresh.Failed++
+		} else {
+			refresh.Healthy++
+		}
+	}
+
+	return refresh, nil
+}
+
+// listAllDevices returns every device visible to the caller, oldest first
+func (ds *DeviceService) listAllDevices(ctx context.Context) ([]*model.Device, error) {
+	var devices []*model.Device
+	filter := &repository.DeviceFilter{Page: 1, PerPage: 100, SortBy: "created_at", SortOrder: "asc"}
+	for {
+		page, total, err := ds.deviceRepo.List(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list devices: %w", err)
+		}
+		devices = append(devices, page...)
+		if len(page) == 0 || len(devices) >= total {
+			break
+		}
+		filter.Page++
+	}
+	return devices, nil
+}
+
+// ExportDevices returns portable device definitions, optionally limited to
+// a branch. Secret connection settings are left out of the export.
+func (ds *DeviceService) ExportDevices(ctx context.Context, branchID *uuid.UUID) (*DeviceExport, error) {
+	var devices []*model.Device
+	if branchID != nil {
+		branchDevices, err := ds.deviceRepo.ListByBranch(ctx, *branchID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list branch devices: %w", err)
+		}
+		devices = branchDevices
+	} else {
+		allDevices, err := ds.listAllDevices(ctx)
+		if err != nil {
+			return nil, err
+		}
+		devices = allDevices
+	}
+
+	export := &DeviceExport{
+		Version:    DeviceExportVersion,
+		ExportedAt: time.Now(),
+		BranchID:   branchID,
+		Devices:    make([]DeviceDefinition, 0, len(devices)),
+	}
+
+	for _, device := range devices {
+		connectionConfig, omitted := stripSecretConfig(device.ConnectionConfig)
+		export.Devices = append(export.Devices, DeviceDefinition{
+			DeviceID:         device.DeviceID,
+			Name:             device.Name,
+			DeviceType:       device.DeviceType,
+			Brand:            device.Brand,
+			Model:            device.Model,
+			FirmwareVersion:  device.FirmwareVersion,
+			ConnectionType:   device.ConnectionType,
+			ConnectionConfig: connectionConfig,
+			BranchID:         device.BranchID,
+			Location:         device.Location,
+			OmittedSecrets:   omitted,
+		})
+	}
+
+	return export, nil
+}
+
+// ImportDevices registers exported device definitions. Branch IDs are
+// remapped through the request mapping; existing device IDs are reported as
+// conflicts and left untouched.
+func (ds *DeviceService) ImportDevices(ctx context.Context, req *DeviceImportRequest) (*DeviceImportResult, error) {
+	if req.Version != 0 && req.Version != DeviceExportVersion {
+		return nil, fmt.Errorf("unsupported export version: %d", req.Version)
+	}
+
+	result := &DeviceImportResult{
+		DryRun:  req.DryRun,
+		Total:   len(req.Devices),
+		Results: make([]DeviceImportItem, 0, len(req.Devices)),
+	}
+
+	// seen catches duplicate device IDs within the same import
+	seen := make(map[string]bool, len(req.Devices))
+
+	for _, definition := range req.Devices {
+		item := DeviceImportItem{DeviceID: definition.DeviceID}
+
+		branchID := definition.BranchID
+		if mapped, ok := req.BranchMapping[branchID.String()]; ok {
+			branchID = mapped
+		} else if req.DefaultBranchID != nil {
+			branchID = *req.DefaultBranchID
+		}
+		item.BranchID = branchID
+
+		registerReq := &RegisterDeviceRequest{
+			DeviceID:         definition.DeviceID,
+			Name:             definition.Name,
+			DeviceType:       definition.DeviceType,
+			Brand:            definition.Brand,
+			Model:            definition.Model,
+			FirmwareVersion:  definition.FirmwareVersion,
+			ConnectionType:   definition.ConnectionType,
+			ConnectionConfig: definition.ConnectionConfig,
+			BranchID:         branchID,
+			Location:         definition.Location,
+			UserID:           req.UserID,
+		}
+
+		validationErr := ds.validateRegisterRequest(registerReq)
+
+		switch {
+		case validationErr != nil:
+			item.Status = ImportStatusInvalid
+			item.Message = validationErr.Error()
+		case !ds.driverRegistry.IsSupported(definition.Brand, definition.DeviceType, definition.Model):
+			unsupported := newUnsupportedDeviceError(ds.driverRegistry, definition.Brand, definition.DeviceType, definition.Model)
+			item.Status = ImportStatusUnsupported
+			item.Message = unsupported.Error()
+			item.Suggestions = unsupported.Suggestions
+		case seen[definition.DeviceID] || ds.deviceExists(ctx, definition.DeviceID):
+			item.Status = ImportStatusConflict
+			item.Message = fmt.Sprintf("device with ID %s already exists", definition.DeviceID)
+		case req.DryRun:
+			item.Status = ImportStatusCreated
+		default:
+			device, err := ds.RegisterDevice(ctx, registerReq)
+			if err != nil {
+				item.Status = ImportStatusFailed
+				item.Message = err.Error()
+				break
+			}
+			item.Status = ImportStatusCreated
+			item.ID = &device.ID
+		}
+
+		if item.Status == ImportStatusCreated {
+			seen[definition.DeviceID] = true
+		}
+		if item.Status == ImportStatusCreated && len(definition.OmittedSecrets) > 0 {
+			item.Message = fmt.Sprintf("secrets must be set manually: %s", strings.Join(definition.OmittedSecrets, ", "))
+		}
+
+		switch item.Status {
+		case ImportStatusCreated:
+			result.Created++
+		case ImportStatusConflict:
+			result.Conflicts++
+		default:
+			result.Failed++
+		}
+		result.Results = append(result.Results, item)
+	}
+
+	ds.logger.Info("Device import completed",
+		zap.Bool("dry_run", req.DryRun),
+		zap.Int("total", result.Total),
+		zap.Int("created", result.Created),
+		zap.Int("conflicts", result.Conflicts),
+		zap.Int("failed", result.Failed),
+		zap.String("user_id", req.UserID),
+	)
+
+	return result, nil
+}
+
+// deviceExists reports whether a device with the given device ID is registered
+func (ds *DeviceService) deviceExists(ctx context.Context, deviceID string) bool {
+	existing, err := ds.deviceRepo.GetByDeviceID(ctx, deviceID)
+	return err == nil && existing != nil
+}
+
+// TestDevice performs a device connectivity test
+func (ds *DeviceService) TestDevice(ctx context.Context, deviceID string) (*TestResult, error) {
+	device, err := ds.deviceRepo.GetByDeviceID(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("device not found: %w", err)
+	}
+
+	deviceLogger := utils.NewDeviceLogger(ds.logger.Logger, device)
+
+	startTime := time.Now()
+
+	// Create driver
+	driverInstance, err := ds.driverRegistry.CreateLazyDriver(ctx, device, device.ConnectionConfig)
+	if err != nil {
+		return &TestResult{
+			Success:      false,
+			ErrorMessage: err.Error(),
+			ErrorCode:    OperationErrorCode(err),
+			Duration:     time.Since(startTime).String(),
+		}, nil
+	}
+
+	// Test connection
+	testCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if err := driverInstance.Connect(testCtx); err != nil {
+		deviceLogger.LogConnection("test", false, err)
+		return &TestResult{
+			Success:      false,
+			ErrorMessage: err.Error(),
+			Duration:     time.Since(startTime).String(),
+		}, nil
+	}
+	defer driverInstance.Disconnect(testCtx)
+
+	// Test ping
+	if err := driverInstance.Ping(testCtx); err != nil {
+		return &TestResult{
+			Success:      false,
+			ErrorMessage: err.Error(),
+			Duration:     time.Since(startTime).String(),
+		}, nil
+	}
+
+	// Get device info
+	deviceInfo, err := driverInstance.GetDeviceInfo()
+	if err != nil {
+		deviceLogger.Warn("Failed to get device info during test", zap.Error(err))
+	}
+
+	deviceLogger.LogConnection("test", true, nil)
+
+	return &TestResult{
+		Success:    true,
+		Duration:   time.Since(startTime).String(),
+		DeviceInfo: deviceInfo,
+	}, nil
+}
+
+// Helper methods
+
+// validateRegisterRequest validates device registration request
+func (ds *DeviceService) validateRegisterRequest(req *RegisterDeviceRequest) error {
+	if req.DeviceID == "" {
+		return fmt.Errorf("device_id is required")
+	}
+	if req.DeviceType == "" {
+		return fmt.Errorf("device_type is required")
+	}
+	if !req.DeviceType.IsValid() {
+		return fmt.Errorf("invalid device_type: %s", req.DeviceType)
+	}
+	if req.Brand == "" {
+		return fmt.Errorf("brand is required")
+	}
+	if req.Model == "" {
+		return fmt.Errorf("model is required")
+	}
+	if req.ConnectionType == "" {
 		return fmt.Errorf("connection_type is required")
 	}
 	if req.BranchID == uuid.Nil {
@@ -367,31 +1471,193 @@ func (ds *DeviceService) validateRegisterRequest(req *RegisterDeviceRequest) err
 	if req.ConnectionConfig == nil {
 		return fmt.Errorf("connection_config is required")
 	}
+	if err := validateDeviceName(req.Name); err != nil {
+		return err
+	}
+	if req.PaperWidth != nil && !isSupportedPaperWidth(float64(*req.PaperWidth)) {
+		return fmt.Errorf("paper_width must be 58 or 80")
+	}
+	if req.DefaultContentType != nil && !isSupportedContentType(*req.DefaultContentType) {
+		return fmt.Errorf("unsupported default_content_type: %s", *req.DefaultContentType)
+	}
+	return validatePrintSettings(req.ConnectionConfig)
+}
+
+// maxDeviceNameLength matches the devices.name column size
+const maxDeviceNameLength = 255
+
+// validateDeviceName checks the length of an optional device name
+func validateDeviceName(name *string) error {
+	if name != nil && len(strings.TrimSpace(*name)) > maxDeviceNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxDeviceNameLength)
+	}
 	return nil
 }
 
-// getDeviceCapabilities returns capabilities for device type and brand
-func (ds *DeviceService) getDeviceCapabilities(deviceType model.DeviceType, brand model.DeviceBrand) model.JSONArray {
-	// Base capabilities from device type
-	capabilities := []interface{}{}
+// normalizeDeviceName trims a device name; blank names are stored as NULL
+func normalizeDeviceName(name *string) *string {
+	if name == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*name)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+// withConfigTemplate returns a copy of config with missing settings taken
+// from the brand and model template, when the template is for the same
+// connection type. Site specific fields such as host or port are never
+// filled in.
+func withConfigTemplate(config map[string]interface{}, brand model.DeviceBrand, deviceModel string, connectionType model.ConnectionType) map[string]interface{} {
+	template, _, ok := devicetypes.FindConfigTemplate(string(brand), deviceModel)
+	if !ok || template.ConnectionType != string(connectionType) {
+		return config
+	}
+
+	required := make(map[string]bool, len(template.RequiredFields))
+	for _, field := range template.RequiredFields {
+		required[field] = true
+	}
+
+	merged := make(map[string]interface{}, len(config)+len(template.ConnectionConfig))
+	for key, value := range template.ConnectionConfig {
+		if !required[key] {
+			merged[key] = value
+		}
+	}
+	for key, value := range config {
+		merged[key] = value
+	}
+	return merged
+}
+
+// withPrintSettings returns a copy of config carrying the given print defaults
+func withPrintSettings(config map[string]interface{}, paperWidth *int, contentType *string) model.JSONObject {
+	merged := make(model.JSONObject, len(config)+2)
+	for key, value := range config {
+		merged[key] = value
+	}
+	if paperWidth != nil {
+		merged["paper_width"] = *paperWidth
+	}
+	if contentType != nil {
+		merged["default_content_type"] = strings.ToUpper(*contentType)
+	}
+	return merged
+}
+
+// validatePrintSettings validates print defaults stored in a device config
+func validatePrintSettings(config map[string]interface{}) error {
+	if value, exists := config["paper_width"]; exists {
+		width, ok := value.(float64)
+		if intWidth, isInt := value.(int); isInt {
+			width, ok = float64(intWidth), true
+		}
+		if !ok || !isSupportedPaperWidth(width) {
+			return fmt.Errorf("paper_width must be 58 or 80")
+		}
+	}
+	if value, exists := config["default_content_type"]; exists {
+		contentType, ok := value.(string)
+		if !ok || !isSupportedContentType(contentType) {
+			return fmt.Errorf("unsupported default_content_type: %v", value)
+		}
+	}
+	if value, exists := config["line_spacing"]; exists {
+		spacing, ok := value.(float64)
+		if intSpacing, isInt := value.(int); isInt {
+			spacing, ok = float64(intSpacing), true
+		}
+		if !ok || spacing < 0 || spacing > 255 || spacing != float64(int(spacing)) {
+			return fmt.Errorf("line_spacing must be an integer between 0 and 255")
+		}
+	}
+	if value, exists := config["compact_mode"]; exists {
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("compact_mode must be a boolean")
+		}
+	}
+	if value, exists := config["cut_feed_lines"]; exists {
+		lines, ok := value.(float64)
+		if intLines, isInt := value.(int); isInt {
+			lines, ok = float64(intLines), true
+		}
+		if !ok || lines < 0 || lines > 20 || lines != float64(int(lines)) {
+			return fmt.Errorf("cut_feed_lines must be an integer between 0 and 20")
+		}
+	}
+	if value, exists := config["auto_cut"]; exists {
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("auto_cut must be a boolean")
+		}
+	}
+	if value, exists := config["font"]; exists {
+		font, ok := value.(string)
+		if !ok || (!strings.EqualFold(font, "A") && !strings.EqualFold(font, "B")) {
+			return fmt.Errorf("font must be A or B")
+		}
+	}
+	for _, key := range []string{"max_content_bytes", "max_job_bytes"} {
+		if value, exists := config[key]; exists {
+			limit, ok := value.(float64)
+			if intLimit, isInt := value.(int); isInt {
+				limit, ok = float64(intLimit), true
+			}
+			if !ok || limit < 1 {
+				return fmt.Errorf("%s must be a positive number of bytes", key)
+			}
+		}
+	}
+	return nil
+}
+
+// isSupportedPaperWidth reports whether width is a supported paper width in mm
+func isSupportedPaperWidth(width float64) bool {
+	return width == 58 || width == 80
+}
+
+// isSupportedContentType reports whether contentType is a known print content type
+func isSupportedContentType(contentType string) bool {
+	switch driver.ContentType(strings.ToUpper(contentType)) {
+	case driver.ContentTypeText, driver.ContentTypeHTML, driver.ContentTypeESCPOS,
+		driver.ContentTypeReceipt, driver.ContentTypeLabel, driver.ContentTypePDF:
+		return true
+	}
+	return false
+}
+
+// sealConnectionConfig encrypts secret connection config values when an
+// encryption key is configured
+func (ds *DeviceService) sealConnectionConfig(config map[string]interface{}) (model.JSONObject, error) {
+	if ds.configCipher == nil {
+		return model.JSONObject(config), nil
+	}
+
+	sealed, err := ds.configCipher.EncryptConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt connection config: %w", err)
+	}
+	return sealed, nil
+}
+
+// stripSecretConfig returns a copy of config without secret keys and the
+// names of the keys that were left out
+func stripSecretConfig(config model.JSONObject) (map[string]interface{}, []string) {
+	stripped := make(map[string]interface{}, len(config))
+	var omitted []string
 
-	switch deviceType {
-	case model.DeviceTypePrinter:
-		capabilities = append(capabilities, "PRINT", "STATUS")
-		if brand == model.BrandEpson || brand == model.BrandStar {
-			capabilities = append(capabilities, "CUT", "DRAWER", "BEEP")
+	for key, value := range config {
+		if model.IsSecretConfigKey(key) {
+			omitted = append(omitted, key)
+			continue
 		}
-	case model.DeviceTypePOS:
-		capabilities = append(capabilities, "PAYMENT", "DISPLAY", "STATUS")
-	case model.DeviceTypeScanner:
-		capabilities = append(capabilities, "SCAN", "BEEP", "STATUS")
-	case model.DeviceTypeCashDrawer:
-		capabilities = append(capabilities, "DRAWER", "STATUS")
-	case model.DeviceTypeDisplay:
-		capabilities = append(capabilities, "DISPLAY", "STATUS")
+		stripped[key] = value
 	}
 
-	return model.JSONArray(capabilities)
+	sort.Strings(omitted)
+	return stripped, omitted
 }
 
 // updateDeviceError updates device with error information
@@ -411,12 +1677,43 @@ func (ds *DeviceService) updateDeviceError(ctx context.Context, device *model.De
 
 // startHealthMonitoring starts health monitoring for a device
 func (ds *DeviceService) startHealthMonitoring(device *model.Device, driverInstance driver.DeviceDriver) {
-	deviceLogger := utils.NewDeviceLogger(ds.logger.Logger, device.DeviceID, string(device.DeviceType), string(device.Brand))
+	deviceLogger := utils.NewDeviceLogger(ds.logger.Logger, device)
 
-	ticker := time.NewTicker(ds.config.Device.HealthCheckInterval)
+	defer utils.RecoverPanic(deviceLogger.Logger, zap.String("goroutine", "device_health_monitoring"))
+
+	// Start at a random point of the interval, so devices connected together,
+	// e.g. a branch coming back after an outage, aren't pinged in lockstep
+	interval := ds.config.Device.HealthCheckInterval
+	time.Sleep(interval - driver.Jitter(interval, 1))
+
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	detector := newErrorRateDetector(ds.config.Device.ErrorRateAnomaly)
+
+	heartbeatActive := false
 	for range ticker.C {
+		// Stop once the driver was disconnected or replaced by a reconnect
+		if current, ok := ds.driverPool.Get(device.DeviceID); !ok || current != driverInstance {
+			return
+		}
+
+		if detector != nil {
+			ds.checkErrorRate(deviceLogger, device.DeviceID, driverInstance, detector)
+		}
+
+		// A device agent pushing heartbeats replaces the active ping
+		if ds.HeartbeatActive(device.DeviceID) {
+			heartbeatActive = true
+			continue
+		}
+		if heartbeatActive {
+			heartbeatActive = false
+			deviceLogger.Warn("Heartbeat stream absent, falling back to active polling",
+				zap.Duration("heartbeat_timeout", ds.HeartbeatTimeout()),
+			)
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 
 		startTime := time.Now()
@@ -435,11 +1732,160 @@ func (ds *DeviceService) startHealthMonitoring(device *model.Device, driverInsta
 	}
 }
 
+// AuthenticateAgent checks the token a device agent presents against the
+// agent_token stored in the device's connection config
+func (ds *DeviceService) AuthenticateAgent(ctx context.Context, deviceID, token string) (*model.Device, error) {
+	device, err := ds.deviceRepo.GetByDeviceID(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("device not found: %w", err)
+	}
+
+	connectionConfig := device.ConnectionConfig
+	if ds.configCipher != nil {
+		if connectionConfig, err = ds.configCipher.DecryptConfig(device.ConnectionConfig); err != nil {
+			return nil, fmt.Errorf("failed to decrypt connection config: %w", err)
+		}
+	}
+
+	expected, ok := connectionConfig[AgentTokenKey].(string)
+	if !ok || expected == "" || utils.IsEncryptedValue(expected) || token == "" {
+		return nil, ErrAgentUnauthorized
+	}
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(token)) != 1 {
+		return nil, ErrAgentUnauthorized
+	}
+
+	return device, nil
+}
+
+// RecordHeartbeat stores an agent heartbeat: it refreshes the device's last
+// ping and applies the status the agent reports. Only those columns are
+// written; device is the snapshot taken when the agent connected and may be
+// stale otherwise.
+func (ds *DeviceService) RecordHeartbeat(ctx context.Context, device *model.Device, frame *HeartbeatFrame) error {
+	status := frame.Status
+	if status == "" {
+		status = model.DeviceStatusOnline
+	}
+	switch status {
+	case model.DeviceStatusOnline, model.DeviceStatusOffline, model.DeviceStatusError, model.DeviceStatusMaintenance:
+	default:
+		return fmt.Errorf("%w: unsupported status %s", ErrInvalidHeartbeat, status)
+	}
+
+	now := time.Now()
+	ds.heartbeatMu.Lock()
+	ds.heartbeats[device.DeviceID] = now
+	ds.heartbeatMu.Unlock()
+
+	if err := ds.deviceRepo.UpdateLastPing(ctx, device.ID, now); err != nil {
+		return fmt.Errorf("failed to update last ping: %w", err)
+	}
+	device.LastPing = &now
+
+	if status == device.Status {
+		return nil
+	}
+
+	previous := device.Status
+	if status == model.DeviceStatusError {
+		errorInfo := model.JSONObject{
+			"last_error": frame.ErrorMessage,
+			"error_time": now,
+			"source":     "heartbeat",
+		}
+		if err := ds.deviceRepo.UpdateError(ctx, device.ID, errorInfo); err != nil {
+			return fmt.Errorf("failed to update device status: %w", err)
+		}
+		device.ErrorInfo = errorInfo
+	} else if err := ds.deviceRepo.UpdateStatus(ctx, device.ID, status, "agent heartbeat"); err != nil {
+		return fmt.Errorf("failed to update device status: %w", err)
+	}
+	device.Status = status
+
+	ds.logger.Info("Device status changed by heartbeat",
+		zap.String("device_id", device.DeviceID),
+		zap.String("old_status", string(previous)),
+		zap.String("new_status", string(status)),
+	)
+	return nil
+}
+
+// HeartbeatActive reports whether a device agent sent a heartbeat within the
+// heartbeat timeout, in which case active health pings are skipped
+func (ds *DeviceService) HeartbeatActive(deviceID string) bool {
+	ds.heartbeatMu.RLock()
+	last, exists := ds.heartbeats[deviceID]
+	ds.heartbeatMu.RUnlock()
+
+	return exists && time.Since(last) < ds.HeartbeatTimeout()
+}
+
+// HeartbeatTimeout returns how long a heartbeat stream may stay silent
+// before active polling resumes
+func (ds *DeviceService) HeartbeatTimeout() time.Duration {
+	if ds.config.Device.HeartbeatTimeout <= 0 {
+		return defaultHeartbeatTimeout
+	}
+	return ds.config.Device.HeartbeatTimeout
+}
+
+// EndHeartbeatStream forgets a device's heartbeats once its agent
+// disconnects so active polling resumes right away
+func (ds *DeviceService) EndHeartbeatStream(deviceID string) {
+	ds.heartbeatMu.Lock()
+	defer ds.heartbeatMu.Unlock()
+	delete(ds.heartbeats, deviceID)
+}
+
 // Data Transfer Objects
 
+// HeartbeatFrame represents a status frame pushed by a device agent
+type HeartbeatFrame struct {
+	Status       model.DeviceStatus     `json:"status"` // defaults to ONLINE
+	ErrorMessage string                 `json:"error_message,omitempty"`
+	Details      map[string]interface{} `json:"details,omitempty"`
+}
+
+// CapabilityFlagsResult represents the capability toggles of a device
+type CapabilityFlagsResult struct {
+	DeviceID     string                    `json:"device_id"`
+	Flags        map[model.Capability]bool `json:"flags"`
+	Capabilities model.JSONArray           `json:"capabilities"`
+	AppliedLive  bool                      `json:"applied_live"`
+}
+
+// OperationPolicy represents the operation allow and deny lists of a device.
+// An empty allow list permits every operation that isn't denied.
+type OperationPolicy struct {
+	DeviceID string                `json:"device_id,omitempty"`
+	Allowed  []model.OperationType `json:"allowed"`
+	Denied   []model.OperationType `json:"denied"`
+
+	AllowRawPrint bool `json:"allow_raw_print"` // print jobs may send raw ESC/POS content
+}
+
+// WireLoggingResult represents the wire logging state of a device
+type WireLoggingResult struct {
+	DeviceID    string `json:"device_id"`
+	Enabled     bool   `json:"enabled"`
+	AppliedLive bool   `json:"applied_live"`
+}
+
+// ReconnectResult represents the outcome of a forced reconnect
+type ReconnectResult struct {
+	DeviceID     string             `json:"device_id"`
+	Status       model.DeviceStatus `json:"status"`
+	Connected    bool               `json:"connected"`
+	LastPing     *time.Time         `json:"last_ping,omitempty"`
+	PingDuration string             `json:"ping_duration"`
+	Duration     string             `json:"duration"`
+}
+
 // RegisterDeviceRequest represents device registration request
 type RegisterDeviceRequest struct {
 	DeviceID         string                 `json:"device_id"`
+	Name             *string                `json:"name,omitempty"`
 	DeviceType       model.DeviceType       `json:"device_type"`
 	Brand            model.DeviceBrand      `json:"brand"`
 	Model            string                 `json:"model"`
@@ -447,17 +1893,38 @@ type RegisterDeviceRequest struct {
 	ConnectionType   model.ConnectionType   `json:"connection_type"`
 	ConnectionConfig map[string]interface{} `json:"connection_config"`
 	BranchID         uuid.UUID              `json:"branch_id"`
+	TenantID         *uuid.UUID             `json:"tenant_id,omitempty"` // taken from the caller's token when scoped
 	Location         *string                `json:"location,omitempty"`
 	UserID           string                 `json:"user_id"`
+
+	// Print defaults, stored with the connection config
+	PaperWidth         *int    `json:"paper_width,omitempty"`          // 58 or 80 (mm)
+	DefaultContentType *string `json:"default_content_type,omitempty"` // TEXT, HTML, ESC_POS, RECEIPT, LABEL, PDF
+}
+
+// DeviceUpsertResult represents the outcome of an upsert: the device and
+// whether it was created or an existing one updated
+type DeviceUpsertResult struct {
+	Device  *model.Device `json:"device"`
+	Created bool          `json:"created"`
+}
+
+// UpdateDeviceRequest represents device update request
+type UpdateDeviceRequest struct {
+	Name            *string `json:"name,omitempty"`
+	Location        *string `json:"location,omitempty"`
+	FirmwareVersion *string `json:"firmware_version,omitempty"`
 }
 
 // DeviceFilter represents device listing filters
 type DeviceFilter struct {
 	BranchID   *uuid.UUID          `json:"branch_id,omitempty"`
+	TenantID   *uuid.UUID          `json:"tenant_id,omitempty"`
 	DeviceType *model.DeviceType   `json:"device_type,omitempty"`
 	Brand      *model.DeviceBrand  `json:"brand,omitempty"`
 	Status     *model.DeviceStatus `json:"status,omitempty"`
 	Location   *string             `json:"location,omitempty"`
+	SearchTerm *string             `json:"search_term,omitempty"` // matches device ID, name or model
 	Page       int                 `json:"page"`
 	PerPage    int                 `json:"per_page"`
 	SortBy     string              `json:"sort_by"`
@@ -469,10 +1936,12 @@ func (df *DeviceFilter) toRepoFilter() *repository.DeviceFilter {
 	// Implementation would convert DTO to repository filter
 	return &repository.DeviceFilter{
 		BranchID:   df.BranchID,
+		TenantID:   df.TenantID,
 		DeviceType: df.DeviceType,
 		Brand:      df.Brand,
 		Status:     df.Status,
 		Location:   df.Location,
+		SearchTerm: df.SearchTerm,
 		Page:       df.Page,
 		PerPage:    df.PerPage,
 		SortBy:     df.SortBy,
@@ -488,6 +1957,20 @@ type PaginationResult struct {
 	TotalPages int `json:"total_pages"`
 }
 
+// StatusHistoryRequest represents device status history parameters
+type StatusHistoryRequest struct {
+	Since *time.Time `json:"since,omitempty"`
+	Until *time.Time `json:"until,omitempty"`
+	Limit int        `json:"limit"`
+}
+
+// DeviceStatusHistory represents the status transitions of a device
+type DeviceStatusHistory struct {
+	DeviceID      string                     `json:"device_id"`
+	CurrentStatus model.DeviceStatus         `json:"current_status"`
+	Events        []*model.DeviceStatusEvent `json:"events"`
+}
+
 // DeviceHealth represents device health information
 type DeviceHealth struct {
 	DeviceID     string                 `json:"device_id"`
@@ -498,6 +1981,116 @@ type DeviceHealth struct {
 	ErrorRate    *float64               `json:"error_rate,omitempty"`
 	Uptime       *float64               `json:"uptime,omitempty"`
 	Metrics      map[string]interface{} `json:"metrics,omitempty"`
+	Source       string                 `json:"source"`
+}
+
+// Device health sources
+const (
+	HealthSourceLive   = "live"
+	HealthSourceStored = "stored"
+)
+
+// DeviceConnection represents the live connection state of a device
+type DeviceConnection struct {
+	*internalDriver.ConnectionState
+	Status         model.DeviceStatus   `json:"status"`
+	ConnectionType model.ConnectionType `json:"connection_type"`
+	LastPing       *time.Time           `json:"last_ping,omitempty"`
+}
+
+// DeviceExportVersion is the current device export format version
+const DeviceExportVersion = 1
+
+// Device import item statuses
+const (
+	ImportStatusCreated     = "created"
+	ImportStatusConflict    = "conflict"
+	ImportStatusUnsupported = "unsupported"
+	ImportStatusInvalid     = "invalid"
+	ImportStatusFailed      = "failed"
+)
+
+// DeviceDefinition represents a portable device definition
+type DeviceDefinition struct {
+	DeviceID         string                 `json:"device_id"`
+	Name             *string                `json:"name,omitempty"`
+	DeviceType       model.DeviceType       `json:"device_type"`
+	Brand            model.DeviceBrand      `json:"brand"`
+	Model            string                 `json:"model"`
+	FirmwareVersion  *string                `json:"firmware_version,omitempty"`
+	ConnectionType   model.ConnectionType   `json:"connection_type"`
+	ConnectionConfig map[string]interface{} `json:"connection_config"`
+	BranchID         uuid.UUID              `json:"branch_id"`
+	Location         *string                `json:"location,omitempty"`
+	OmittedSecrets   []string               `json:"omitted_secrets,omitempty"`
+}
+
+// DeviceExport represents exported device definitions
+type DeviceExport struct {
+	Version    int                `json:"version"`
+	ExportedAt time.Time          `json:"exported_at"`
+	BranchID   *uuid.UUID         `json:"branch_id,omitempty"`
+	Devices    []DeviceDefinition `json:"devices"`
+}
+
+// DeviceImportRequest represents device import request
+type DeviceImportRequest struct {
+	Version         int                  `json:"version"`
+	Devices         []DeviceDefinition   `json:"devices"`
+	BranchMapping   map[string]uuid.UUID `json:"branch_mapping,omitempty"` // source branch ID -> target branch ID
+	DefaultBranchID *uuid.UUID           `json:"default_branch_id,omitempty"`
+	DryRun          bool                 `json:"dry_run"`
+	UserID          string               `json:"user_id,omitempty"`
+}
+
+// DeviceImportItem represents the import outcome of a single device
+type DeviceImportItem struct {
+	DeviceID string     `json:"device_id"`
+	ID       *uuid.UUID `json:"id,omitempty"`
+	BranchID uuid.UUID  `json:"branch_id"`
+	Status   string     `json:"status"`
+	Message  string     `json:"message,omitempty"`
+
+	Suggestions []internalDriver.DriverSuggestion `json:"suggestions,omitempty"` // closest supported drivers when unsupported
+}
+
+// DeviceImportResult represents device import results
+type DeviceImportResult struct {
+	DryRun    bool               `json:"dry_run"`
+	Total     int                `json:"total"`
+	Created   int                `json:"created"`
+	Conflicts int                `json:"conflicts"`
+	Failed    int                `json:"failed"`
+	Results   []DeviceImportItem `json:"results"`
+}
+
+// Status check sources
+const (
+	StatusCheckSourcePing      = "ping"
+	StatusCheckSourceHeartbeat = "heartbeat"
+)
+
+// StatusCheckResult represents the outcome of a single device health check.
+// Heartbeat sourced results keep the stored status without pinging.
+type StatusCheckResult struct {
+	DeviceID       string             `json:"device_id"`
+	PreviousStatus model.DeviceStatus `json:"previous_status"`
+	Status         model.DeviceStatus `json:"status"`
+	Source         string             `json:"source"`
+	ResponseTime   *int               `json:"response_time_ms,omitempty"`
+	Error          string             `json:"error,omitempty"`
+	ErrorCode      string             `json:"error_code,omitempty"` // e.g. DRIVER_NOT_FOUND
+	CheckedAt      time.Time          `json:"checked_at"`
+}
+
+// StatusRefreshResult represents the outcome of a bulk status refresh
+type StatusRefreshResult struct {
+	BranchID   *uuid.UUID           `json:"branch_id,omitempty"`
+	Total      int                  `json:"total"`
+	Healthy    int                  `json:"healthy"`
+	Failed     int                  `json:"failed"`
+	DurationMs int64                `json:"duration_ms"`
+	Results    []*StatusCheckResult `json:"results"`
 }
 
 // TestResult represents device test result
@@ -505,5 +2098,6 @@ type TestResult struct {
 	Success      bool               `json:"success"`
 	Duration     string             `json:"duration"`
 	ErrorMessage string             `json:"error_message,omitempty"`
+	ErrorCode    string             `json:"error_code,omitempty"` // e.g. DRIVER_NOT_FOUND
 	DeviceInfo   *driver.DeviceInfo `json:"device_info,omitempty"`
 }
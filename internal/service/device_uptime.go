@@ -0,0 +1,170 @@
+// internal/service/device_uptime.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"device-service/internal/repository"
+)
+
+// ErrInvalidReportPeriod is returned for report periods that are empty,
+// reversed or too long
+var ErrInvalidReportPeriod = errors.New("invalid report period")
+
+// Uptime report periods
+const (
+	defaultUptimePeriod = 30 * 24 * time.Hour
+	maxUptimePeriod     = 366 * 24 * time.Hour
+)
+
+// GetUptimeReport reports the connectivity SLA of devices over a period:
+// uptime percentage, outages, mean time between failures and mean time to
+// recovery per device, aggregated per branch. Time in MAINTENANCE is planned
+// and left out of the uptime percentage. The period defaults to the 30 days
+// before To, and To to now. A To in the future is clamped to now, so the
+// current status isn't projected over time that hasn't passed yet.
+func (ds *DeviceService) GetUptimeReport(ctx context.Context, req *UptimeReportRequest) (*UptimeReport, error) {
+	now := time.Now().UTC()
+	to := now
+	if req.To != nil && req.To.Before(now) {
+		to = req.To.UTC()
+	}
+	from := to.Add(-defaultUptimePeriod)
+	if req.From != nil {
+		from = req.From.UTC()
+	}
+
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidReportPeriod)
+	}
+	if to.Sub(from) > maxUptimePeriod {
+		return nil, fmt.Errorf("%w: period must be at most %d days", ErrInvalidReportPeriod, int(maxUptimePeriod.Hours()/24))
+	}
+
+	stats, err := ds.statusEvents.GetUptimeStats(ctx, &repository.UptimeFilter{
+		BranchID:  req.BranchID,
+		StartDate: from,
+		EndDate:   to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get uptime report: %w", err)
+	}
+
+	report := &UptimeReport{
+		From:     from,
+		To:       to,
+		BranchID: req.BranchID,
+		Devices:  make([]DeviceUptime, 0, len(stats)),
+		Branches: []BranchUptime{},
+	}
+
+	branchIndex := make(map[uuid.UUID]int)
+	for _, stat := range stats {
+		device := DeviceUptime{
+			DeviceID:    stat.DeviceID,
+			Name:        stat.Name,
+			BranchID:    stat.BranchID,
+			UptimeStats: newUptimeStats(stat.MonitoredSeconds, stat.UpSeconds, stat.MaintenanceSeconds, stat.Outages),
+		}
+		report.Devices = append(report.Devices, device)
+
+		// Stats come ordered by branch
+		i, ok := branchIndex[stat.BranchID]
+		if !ok {
+			i = len(report.Branches)
+			branchIndex[stat.BranchID] = i
+			report.Branches = append(report.Branches, BranchUptime{BranchID: stat.BranchID})
+		}
+		branch := &report.Branches[i]
+		branch.DeviceCount++
+		branch.MonitoredSeconds += stat.MonitoredSeconds
+		branch.UpSeconds += stat.UpSeconds
+		branch.MaintenanceSeconds += stat.MaintenanceSeconds
+		branch.Outages += stat.Outages
+	}
+
+	for i := range report.Branches {
+		branch := &report.Branches[i]
+		branch.UptimeStats = newUptimeStats(branch.MonitoredSeconds, branch.UpSeconds, branch.MaintenanceSeconds, branch.Outages)
+	}
+
+	return report, nil
+}
+
+// newUptimeStats derives the SLA figures from the time spent online and in
+// maintenance. Any other time (OFFLINE, ERROR, CONNECTING) counts as down:
+// the device wasn't usable. MTBF and MTTR are left out when there was no
+// outage.
+func newUptimeStats(monitored, up, maintenance float64, outages int) UptimeStats {
+	stats := UptimeStats{
+		MonitoredSeconds:   monitored,
+		UpSeconds:          up,
+		DownSeconds:        math.Max(monitored-maintenance-up, 0),
+		MaintenanceSeconds: maintenance,
+		Outages:            outages,
+	}
+
+	if expected := monitored - maintenance; expected > 0 {
+		uptime := up / expected * 100
+		stats.UptimePercent = &uptime
+	}
+	if outages > 0 {
+		mtbf := up / float64(outages)
+		mttr := stats.DownSeconds / float64(outages)
+		stats.MTBFSeconds = &mtbf
+		stats.MTTRSeconds = &mttr
+	}
+
+	return stats
+}
+
+// UptimeReportRequest represents uptime report parameters
+type UptimeReportRequest struct {
+	BranchID *uuid.UUID `json:"branch_id,omitempty"`
+	From     *time.Time `json:"from,omitempty"`
+	To       *time.Time `json:"to,omitempty"`
+}
+
+// UptimeStats represents connectivity SLA figures. UptimePercent is ONLINE
+// time over monitored time less maintenance.
+type UptimeStats struct {
+	UptimePercent      *float64 `json:"uptime_percent,omitempty"`
+	Outages            int      `json:"outages"`
+	MTBFSeconds        *float64 `json:"mtbf_seconds,omitempty"` // mean time between failures
+	MTTRSeconds        *float64 `json:"mttr_seconds,omitempty"` // mean time to recovery
+	MonitoredSeconds   float64  `json:"monitored_seconds"`
+	UpSeconds          float64  `json:"up_seconds"`
+	DownSeconds        float64  `json:"down_seconds"`
+	MaintenanceSeconds float64  `json:"maintenance_seconds"`
+}
+
+// DeviceUptime represents the connectivity SLA of a device
+type DeviceUptime struct {
+	DeviceID string    `json:"device_id"`
+	Name     *string   `json:"name,omitempty"`
+	BranchID uuid.UUID `json:"branch_id"`
+	UptimeStats
+}
+
+// BranchUptime represents the connectivity SLA of a branch's devices taken
+// together
+type BranchUptime struct {
+	BranchID    uuid.UUID `json:"branch_id"`
+	DeviceCount int       `json:"device_count"`
+	UptimeStats
+}
+
+// UptimeReport represents a connectivity SLA report
+type UptimeReport struct {
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	BranchID *uuid.UUID     `json:"branch_id,omitempty"`
+	Branches []BranchUptime `json:"branches"`
+	Devices  []DeviceUptime `json:"devices"`
+}
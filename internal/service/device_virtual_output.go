@@ -0,0 +1,62 @@
+// internal/service/device_virtual_output.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"device-service/internal/model"
+	"device-service/internal/protocol"
+)
+
+var (
+	// ErrNotVirtualDevice is returned when output is requested for a device
+	// that isn't connected over a VIRTUAL connection
+	ErrNotVirtualDevice = errors.New("device is not a virtual device")
+
+	// ErrNoCapturedOutput is returned when a virtual device hasn't been
+	// connected since startup or since its output was cleared
+	ErrNoCapturedOutput = errors.New("no captured output")
+)
+
+// GetVirtualOutput returns the raw bytes (e.g. the ESC/POS stream) sent to a
+// VIRTUAL device since it was first connected or last cleared. The output is
+// kept in memory and lost on restart, disconnect or reconnect.
+func (ds *DeviceService) GetVirtualOutput(ctx context.Context, deviceID string) ([]byte, error) {
+	if err := ds.requireVirtualDevice(ctx, deviceID); err != nil {
+		return nil, err
+	}
+
+	output, ok := protocol.CapturedOutput(deviceID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoCapturedOutput, deviceID)
+	}
+	return output, nil
+}
+
+// ClearVirtualOutput discards the output captured for a VIRTUAL device, e.g.
+// before printing the receipt a test checks
+func (ds *DeviceService) ClearVirtualOutput(ctx context.Context, deviceID string) error {
+	if err := ds.requireVirtualDevice(ctx, deviceID); err != nil {
+		return err
+	}
+
+	protocol.ClearCapturedOutput(deviceID)
+	ds.logger.Info("Virtual device output cleared", zap.String("device_id", deviceID))
+	return nil
+}
+
+// requireVirtualDevice checks that a device exists and is VIRTUAL
+func (ds *DeviceService) requireVirtualDevice(ctx context.Context, deviceID string) error {
+	device, err := ds.deviceRepo.GetByDeviceID(ctx, deviceID)
+	if err != nil {
+		return fmt.Errorf("device not found: %w", err)
+	}
+	if device.ConnectionType != model.ConnectionTypeVirtual {
+		return fmt.Errorf("%w: connected over %s", ErrNotVirtualDevice, device.ConnectionType)
+	}
+	return nil
+}
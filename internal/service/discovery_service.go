@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,6 +20,7 @@ import (
 	"device-service/internal/repository"
 	"device-service/internal/utils"
 	"device-service/pkg/devicetypes"
+	pkgdriver "device-service/pkg/driver"
 )
 
 // DiscoveryService handles device discovery operations - Now much cleaner!
@@ -70,7 +72,15 @@ func (ds *DiscoveryService) initializeScanners() {
 	// }
 
 	// Register TCP scanner
-	if tcpScanner := tcp.NewScanner(ds.logger.Logger, nil); tcpScanner.IsAvailable() {
+	scan := ds.config.Device.Discovery
+	tcpScanner := tcp.NewScanner(ds.logger.Logger, &tcp.Config{
+		ScanTimeout:   scan.ScanTimeout,
+		NetworkRanges: scan.NetworkRanges,
+		CommonPorts:   scan.Ports,
+		ConnTimeout:   scan.ConnectTimeout,
+		Concurrency:   scan.Concurrency,
+	})
+	if tcpScanner.IsAvailable() {
 		ds.scannerManager.RegisterScanner(tcpScanner)
 	}
 
@@ -80,17 +90,19 @@ func (ds *DiscoveryService) initializeScanners() {
 }
 
 // ScanDevices scans for available devices - Much simpler now!
-func (ds *DiscoveryService) ScanDevices(ctx context.Context, req *ScanRequest) ([]*DiscoveredDevice, error) {
+func (ds *DiscoveryService) ScanDevices(ctx context.Context, req *ScanRequest) (*ScanResult, error) {
 	ds.logger.Info("Starting device scan", zap.String("type", req.ScanType))
 
 	var devices []*discovery.DiscoveredDevice
+	var runs []*discovery.ScannerRun
 	var err error
 
+	start := time.Now()
 	switch req.ScanType {
 	case "all":
-		devices, err = ds.scannerManager.ScanAll(ctx)
+		devices, runs, err = ds.scannerManager.ScanAll(ctx)
 	case "serial", "usb", "tcp":
-		devices, err = ds.scannerManager.ScanByType(ctx, req.ScanType)
+		devices, runs, err = ds.scannerManager.ScanByType(ctx, req.ScanType)
 	default:
 		return nil, fmt.Errorf("unsupported scan type: %s", req.ScanType)
 	}
@@ -100,14 +112,21 @@ func (ds *DiscoveryService) ScanDevices(ctx context.Context, req *ScanRequest) (
 	}
 
 	// Convert to service DTOs
-	result := make([]*DiscoveredDevice, len(devices))
+	result := &ScanResult{
+		ScanType:     req.ScanType,
+		DurationMs:   time.Since(start).Milliseconds(),
+		Scanners:     runs,
+		DevicesFound: len(devices),
+		Devices:      make([]*DiscoveredDevice, len(devices)),
+	}
 	for i, device := range devices {
-		result[i] = ds.convertToServiceDTO(device)
+		result.Devices[i] = ds.convertToServiceDTO(device)
 	}
 
 	ds.logger.Info("Device scan completed",
-		zap.Int("devices_found", len(result)),
+		zap.Int("devices_found", result.DevicesFound),
 		zap.String("scan_type", req.ScanType),
+		zap.Int64("duration_ms", result.DurationMs),
 	)
 
 	return result, nil
@@ -178,16 +197,21 @@ func (ds *DiscoveryService) AutoSetupDevices(ctx context.Context, req *AutoSetup
 		Timeout:  "30s",
 	}
 
-	devices, err := ds.ScanDevices(ctx, scanReq)
+	scan, err := ds.ScanDevices(ctx, scanReq)
 	if err != nil {
 		return nil, fmt.Errorf("device scan failed: %w", err)
 	}
+	devices := scan.Devices
 
 	result := &AutoSetupResult{
 		TotalScanned:      len(devices),
 		SuccessfullySetup: 0,
 		Failed:            0,
+		ScanDurationMs:    scan.DurationMs,
+		Scanners:          scan.Scanners,
+		FilteredBy:        map[string]int{},
 		SetupDevices:      []*SetupDeviceResult{},
+		FilteredDevices:   []*FilteredDevice{},
 		Errors:            []string{},
 	}
 
@@ -214,12 +238,24 @@ func (ds *DiscoveryService) AutoSetupDevices(ctx context.Context, req *AutoSetup
 		}
 
 		// Apply device filter if specified
-		if !ds.shouldSetupDevice(device, req.DeviceFilter) {
+		if ok, filter, reason := ds.shouldSetupDevice(device, req.DeviceFilter); !ok {
 			ds.logger.Debug("Device filtered out by device filter",
 				zap.String("device_id", deviceID),
 				zap.String("brand", string(device.Brand)),
 				zap.String("model", device.Model),
+				zap.String("filter", filter),
 			)
+			result.Filtered++
+			result.FilteredBy[filter]++
+			result.FilteredDevices = append(result.FilteredDevices, &FilteredDevice{
+				DeviceID:       deviceID,
+				ConnectionType: device.ConnectionType,
+				Brand:          device.Brand,
+				Model:          device.Model,
+				Confidence:     device.Confidence,
+				Filter:         filter,
+				Reason:         reason,
+			})
 			continue
 		}
 
@@ -228,6 +264,7 @@ func (ds *DiscoveryService) AutoSetupDevices(ctx context.Context, req *AutoSetup
 		if err == nil && existingDevice != nil {
 			setupResult.Status = "ALREADY_EXISTS"
 			setupResult.Error = "Device already registered in system"
+			result.AlreadyExists++
 			result.SetupDevices = append(result.SetupDevices, setupResult)
 			continue
 		}
@@ -294,6 +331,8 @@ func (ds *DiscoveryService) AutoSetupDevices(ctx context.Context, req *AutoSetup
 
 	ds.logger.Info("Auto-setup process completed",
 		zap.Int("total_scanned", result.TotalScanned),
+		zap.Int("filtered", result.Filtered),
+		zap.Int("already_exists", result.AlreadyExists),
 		zap.Int("successfully_setup", result.SuccessfullySetup),
 		zap.Int("failed", result.Failed),
 	)
@@ -301,43 +340,43 @@ func (ds *DiscoveryService) AutoSetupDevices(ctx context.Context, req *AutoSetup
 	return result, nil
 }
 
-// shouldSetupDevice checks if device matches the filter criteria
-func (ds *DiscoveryService) shouldSetupDevice(device *DiscoveredDevice, filter map[string]string) bool {
-	if filter == nil {
-		return true
-	}
-
+// shouldSetupDevice checks if device matches the filter criteria and the
+// configured minimum confidence. For a device that doesn't, it returns the
+// filter key that excluded it and why.
+func (ds *DiscoveryService) shouldSetupDevice(device *DiscoveredDevice, filter map[string]string) (bool, string, string) {
 	// Check brand filter
 	if brandFilter, exists := filter["brand"]; exists {
 		if string(device.Brand) != brandFilter {
-			return false
+			return false, "brand", fmt.Sprintf("brand %s is not %s", device.Brand, brandFilter)
 		}
 	}
 
 	// Check device type filter
 	if typeFilter, exists := filter["device_type"]; exists {
 		if string(device.DeviceType) != typeFilter {
-			return false
+			return false, "device_type", fmt.Sprintf("device type %s is not %s", device.DeviceType, typeFilter)
 		}
 	}
 
-	// Check minimum confidence filter
+	// Check minimum confidence, the configured one unless the filter overrides it
+	minConfidence := ds.config.Device.AutoSetupMinConfidence
 	if confidenceFilter, exists := filter["min_confidence"]; exists {
-		if minConfidence, err := strconv.ParseFloat(confidenceFilter, 64); err == nil {
-			if device.Confidence < minConfidence {
-				return false
-			}
+		if filterConfidence, err := strconv.ParseFloat(confidenceFilter, 64); err == nil {
+			minConfidence = filterConfidence
 		}
 	}
+	if device.Confidence < minConfidence {
+		return false, "min_confidence", fmt.Sprintf("confidence %.2f is below %.2f", device.Confidence, minConfidence)
+	}
 
 	// Check connection type filter
 	if connectionFilter, exists := filter["connection_type"]; exists {
 		if string(device.ConnectionType) != connectionFilter {
-			return false
+			return false, "connection_type", fmt.Sprintf("connection type %s is not %s", device.ConnectionType, connectionFilter)
 		}
 	}
 
-	return true
+	return true, "", ""
 }
 
 // registerDeviceWithService registers device using DeviceService
@@ -350,6 +389,11 @@ func (ds *DiscoveryService) registerDeviceWithService(ctx context.Context, req *
 		zap.String("device_type", string(req.DeviceType)),
 	)
 
+	// Settings discovery could not detect are taken from the model template
+	if req.ConnectionConfig != nil {
+		req.ConnectionConfig = withConfigTemplate(req.ConnectionConfig, req.Brand, req.Model, req.ConnectionType)
+	}
+
 	// Validate request
 	if err := ds.validateRegisterRequest(req); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
@@ -363,7 +407,7 @@ func (ds *DiscoveryService) registerDeviceWithService(ctx context.Context, req *
 
 	// Verify driver support
 	if !ds.driverRegistry.IsSupported(req.Brand, req.DeviceType, req.Model) {
-		return nil, fmt.Errorf("unsupported device: %s %s %s", req.Brand, req.DeviceType, req.Model)
+		return nil, newUnsupportedDeviceError(ds.driverRegistry, req.Brand, req.DeviceType, req.Model)
 	}
 
 	// Create device model
@@ -376,7 +420,7 @@ func (ds *DiscoveryService) registerDeviceWithService(ctx context.Context, req *
 		FirmwareVersion:  req.FirmwareVersion,
 		ConnectionType:   req.ConnectionType,
 		ConnectionConfig: model.JSONObject(req.ConnectionConfig),
-		Capabilities:     ds.getDeviceCapabilities(req.DeviceType, req.Brand),
+		Capabilities:     pkgdriver.CapabilityArray(pkgdriver.DefaultCapabilities(req.DeviceType, req.Brand)),
 		BranchID:         req.BranchID,
 		Location:         req.Location,
 		Status:           model.DeviceStatusOffline,
@@ -440,22 +484,7 @@ func (ds *DiscoveryService) validateRegisterRequest(req *RegisterDeviceRequest)
 	}
 
 	// Validate device type
-	validTypes := []model.DeviceType{
-		model.DeviceTypePOS,
-		model.DeviceTypePrinter,
-		model.DeviceTypeScanner,
-		model.DeviceTypeCashRegister,
-		model.DeviceTypeCashDrawer,
-		model.DeviceTypeDisplay,
-	}
-	isValidType := false
-	for _, validType := range validTypes {
-		if req.DeviceType == validType {
-			isValidType = true
-			break
-		}
-	}
-	if !isValidType {
+	if !req.DeviceType.IsValid() {
 		return fmt.Errorf("invalid device_type: %s", req.DeviceType)
 	}
 
@@ -548,7 +577,7 @@ func (ds *DiscoveryService) autoConnectDevice(ctx context.Context, deviceID stri
 	}
 
 	// Create device logger for better tracking
-	deviceLogger := utils.NewDeviceLogger(ds.logger.Logger, device.DeviceID, string(device.DeviceType), string(device.Brand))
+	deviceLogger := utils.NewDeviceLogger(ds.logger.Logger, device)
 
 	// Validate connection configuration
 	if len(device.ConnectionConfig) == 0 {
@@ -556,7 +585,7 @@ func (ds *DiscoveryService) autoConnectDevice(ctx context.Context, deviceID stri
 	}
 
 	// Create driver instance
-	driverInstance, err := ds.driverRegistry.CreateDriver(device, device.ConnectionConfig)
+	driverInstance, err := ds.driverRegistry.CreateLazyDriver(ctx, device, device.ConnectionConfig)
 	if err != nil {
 		deviceLogger.LogConnection("create_driver", false, err)
 		return fmt.Errorf("failed to create driver: %w", err)
@@ -564,7 +593,7 @@ func (ds *DiscoveryService) autoConnectDevice(ctx context.Context, deviceID stri
 
 	// Update status to connecting
 	device.Status = model.DeviceStatusConnecting
-	if err := ds.deviceRepo.UpdateStatus(ctx, device.ID, device.Status); err != nil {
+	if err := ds.deviceRepo.UpdateStatus(ctx, device.ID, device.Status, "auto-connect"); err != nil {
 		deviceLogger.Error("Failed to update device status to connecting", zap.Error(err))
 	}
 
@@ -620,6 +649,8 @@ func (ds *DiscoveryService) autoConnectDevice(ctx context.Context, deviceID stri
 
 	// Close the driver connection for now - it will be reopened when needed
 	go func() {
+		defer utils.RecoverPanic(deviceLogger.Logger, zap.String("goroutine", "auto_connect_disconnect"))
+
 		time.Sleep(1 * time.Second) // Give it a moment
 		if err := driverInstance.Disconnect(context.Background()); err != nil {
 			deviceLogger.Warn("Failed to disconnect after auto-connect test", zap.Error(err))
@@ -629,29 +660,6 @@ func (ds *DiscoveryService) autoConnectDevice(ctx context.Context, deviceID stri
 	return nil
 }
 
-// getDeviceCapabilities returns capabilities for device type and brand
-func (ds *DiscoveryService) getDeviceCapabilities(deviceType model.DeviceType, brand model.DeviceBrand) model.JSONArray {
-	capabilities := []interface{}{}
-
-	switch deviceType {
-	case model.DeviceTypePrinter:
-		capabilities = append(capabilities, "PRINT", "STATUS")
-		if brand == model.BrandEpson || brand == model.BrandStar || brand == model.BrandKodpos {
-			capabilities = append(capabilities, "CUT", "DRAWER", "BEEP")
-		}
-	case model.DeviceTypePOS:
-		capabilities = append(capabilities, "PAYMENT", "DISPLAY", "STATUS")
-	case model.DeviceTypeScanner:
-		capabilities = append(capabilities, "SCAN", "BEEP", "STATUS")
-	case model.DeviceTypeCashDrawer:
-		capabilities = append(capabilities, "DRAWER", "STATUS")
-	case model.DeviceTypeDisplay:
-		capabilities = append(capabilities, "DISPLAY", "STATUS")
-	}
-
-	return model.JSONArray(capabilities)
-}
-
 // GetSupportedDevices returns list of supported devices
 func (ds *DiscoveryService) GetSupportedDevices() *SupportedDevicesResponse {
 	drivers := ds.driverRegistry.ListDrivers()
@@ -691,6 +699,22 @@ func (ds *DiscoveryService) GetDeviceCapabilities(brand, deviceType string) ([]s
 	return nil, fmt.Errorf("device type not supported: %s", deviceType)
 }
 
+// GetConfigTemplate returns the connection config template of a brand and
+// model, or the brand's default template for unlisted models
+func (ds *DiscoveryService) GetConfigTemplate(brand, deviceModel string) (*ConfigTemplateResponse, error) {
+	template, exact, ok := devicetypes.FindConfigTemplate(brand, deviceModel)
+	if !ok {
+		return nil, fmt.Errorf("no config template for brand: %s", brand)
+	}
+
+	return &ConfigTemplateResponse{
+		Brand:          model.DeviceBrand(strings.ToUpper(brand)),
+		Model:          deviceModel,
+		ModelSpecific:  exact,
+		ConfigTemplate: template,
+	}, nil
+}
+
 // DTOs for Discovery Service
 
 // ScanRequest represents device scan request
@@ -719,13 +743,40 @@ type AutoSetupRequest struct {
 	AutoConnect  bool              `json:"auto_connect"`
 }
 
-// AutoSetupResult represents auto-setup result
+// ScanResult represents device scan results
+type ScanResult struct {
+	ScanType     string                  `json:"scan_type"`
+	DurationMs   int64                   `json:"duration_ms"`
+	Scanners     []*discovery.ScannerRun `json:"scanners"`
+	DevicesFound int                     `json:"devices_found"`
+	Devices      []*DiscoveredDevice     `json:"devices"`
+}
+
+// AutoSetupResult represents auto-setup result. Every scanned device ends up
+// filtered, already existing, set up or failed.
 type AutoSetupResult struct {
-	TotalScanned      int                  `json:"total_scanned"`
-	SuccessfullySetup int                  `json:"successfully_setup"`
-	Failed            int                  `json:"failed"`
-	SetupDevices      []*SetupDeviceResult `json:"setup_devices"`
-	Errors            []string             `json:"errors,omitempty"`
+	TotalScanned      int                     `json:"total_scanned"`
+	Filtered          int                     `json:"filtered"`
+	FilteredBy        map[string]int          `json:"filtered_by"` // device filter key -> devices excluded by it
+	AlreadyExists     int                     `json:"already_exists"`
+	SuccessfullySetup int                     `json:"successfully_setup"`
+	Failed            int                     `json:"failed"`
+	ScanDurationMs    int64                   `json:"scan_duration_ms"`
+	Scanners          []*discovery.ScannerRun `json:"scanners"`
+	SetupDevices      []*SetupDeviceResult    `json:"setup_devices"`
+	FilteredDevices   []*FilteredDevice       `json:"filtered_devices"`
+	Errors            []string                `json:"errors,omitempty"`
+}
+
+// FilteredDevice represents a discovered device excluded by the device filter
+type FilteredDevice struct {
+	DeviceID       string               `json:"device_id"`
+	ConnectionType model.ConnectionType `json:"connection_type"`
+	Brand          model.DeviceBrand    `json:"brand"`
+	Model          string               `json:"model"`
+	Confidence     float64              `json:"confidence"`
+	Filter         string               `json:"filter"` // brand, device_type, min_confidence or connection_type
+	Reason         string               `json:"reason"`
 }
 
 // SetupDeviceResult represents individual device setup result
@@ -734,10 +785,19 @@ type SetupDeviceResult struct {
 	ConnectionType model.ConnectionType `json:"connection_type"`
 	Brand          model.DeviceBrand    `json:"brand"`
 	Model          string               `json:"model"`
-	Status         string               `json:"status"` // SUCCESS, FAILED
+	Status         string               `json:"status"` // SUCCESS, FAILED, ALREADY_EXISTS
 	Error          string               `json:"error,omitempty"`
 }
 
+// ConfigTemplateResponse represents a connection config template. Required
+// fields are site specific and left empty.
+type ConfigTemplateResponse struct {
+	Brand         model.DeviceBrand `json:"brand"`
+	Model         string            `json:"model"`
+	ModelSpecific bool              `json:"model_specific"` // false when the brand default is returned
+	devicetypes.ConfigTemplate
+}
+
 // SupportedDevicesResponse represents supported devices response
 type SupportedDevicesResponse struct {
 	TotalBrands  int                            `json:"total_brands"`
@@ -0,0 +1,150 @@
+// internal/service/error_rate_anomaly.go
+package service
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"device-service/internal/config"
+	"device-service/internal/utils"
+	driver "device-service/pkg/driver"
+)
+
+// SetErrorRateAnomalyHandler sets the handler receiving error rate early
+// warnings. It must be set before devices are connected.
+func (ds *DeviceService) SetErrorRateAnomalyHandler(handler func(*ErrorRateAnomaly)) {
+	ds.anomalyHandler = handler
+}
+
+// checkErrorRate feeds a connected device's health metrics to its error rate
+// detector and reports an anomaly it finds
+func (ds *DeviceService) checkErrorRate(deviceLogger *utils.DeviceLogger, deviceID string, driverInstance driver.DeviceDriver, detector *errorRateDetector) {
+	metrics, err := driverInstance.GetHealthMetrics()
+	if err != nil || metrics == nil {
+		return
+	}
+
+	anomaly := detector.observe(time.Now(), metrics)
+	if anomaly == nil {
+		return
+	}
+	anomaly.DeviceID = deviceID
+
+	deviceLogger.Warn("Device error rate rising sharply",
+		zap.String("window", anomaly.Window),
+		zap.Float64("error_rate", anomaly.ErrorRate),
+		zap.Float64("baseline_rate", anomaly.BaselineRate),
+		zap.Int64("operations", anomaly.Operations),
+		zap.Int("health_score", anomaly.HealthScore),
+	)
+
+	if ds.anomalyHandler != nil {
+		ds.anomalyHandler(anomaly)
+	}
+}
+
+// errorRateSample holds a device's cumulative operation counters at one
+// health check
+type errorRateSample struct {
+	at         time.Time
+	errors     int64
+	operations int64
+}
+
+// errorRateDetector flags a sharp rise of one device's error rate by
+// comparing the rate over the last window with the rate before it. It is
+// used by the device's health monitor goroutine only.
+type errorRateDetector struct {
+	window        time.Duration
+	threshold     float64
+	minOperations int64
+	samples       []errorRateSample
+	warned        bool
+}
+
+// newErrorRateDetector returns a detector, or nil when detection is disabled
+func newErrorRateDetector(cfg config.ErrorRateAnomalyConfig) *errorRateDetector {
+	if cfg.Threshold <= 0 || cfg.Window <= 0 {
+		return nil
+	}
+	return &errorRateDetector{
+		window:        cfg.Window,
+		threshold:     cfg.Threshold,
+		minOperations: cfg.MinOperations,
+	}
+}
+
+// observe records the counters of a health check and returns an anomaly when
+// the error rate over the last window rose by at least the threshold. A
+// device is warned about once, until its rate settles below the threshold.
+func (d *errorRateDetector) observe(now time.Time, metrics *driver.HealthMetrics) *ErrorRateAnomaly {
+	current := errorRateSample{at: now, errors: metrics.ErrorCount, operations: metrics.TotalOperations}
+
+	// Counters going backwards were reset; start over
+	if n := len(d.samples); n > 0 && current.operations < d.samples[n-1].operations {
+		d.samples = d.samples[:0]
+		d.warned = false
+	}
+	d.samples = append(d.samples, current)
+
+	// Keep the newest sample at or before the window start as its baseline
+	windowStart := now.Add(-d.window)
+	first := 0
+	for i, sample := range d.samples {
+		if sample.at.After(windowStart) {
+			break
+		}
+		first = i
+	}
+	d.samples = d.samples[first:]
+
+	start := d.samples[0]
+	if start.at.After(windowStart) {
+		return nil // the samples don't cover a full window yet
+	}
+
+	operations := current.operations - start.operations
+	if operations <= 0 || operations < d.minOperations {
+		return nil
+	}
+	errorCount := current.errors - start.errors
+
+	errorRate := float64(errorCount) / float64(operations)
+	baselineRate := 0.0
+	if start.operations > 0 {
+		baselineRate = float64(start.errors) / float64(start.operations)
+	}
+
+	if errorRate-baselineRate < d.threshold {
+		d.warned = false
+		return nil
+	}
+	if d.warned {
+		return nil
+	}
+	d.warned = true
+
+	return &ErrorRateAnomaly{
+		Window:       d.window.String(),
+		ErrorRate:    errorRate,
+		BaselineRate: baselineRate,
+		Errors:       errorCount,
+		Operations:   operations,
+		HealthScore:  metrics.HealthScore,
+		DetectedAt:   now,
+	}
+}
+
+// ErrorRateAnomaly is an early warning about a device whose error rate rose
+// sharply over the detection window
+type ErrorRateAnomaly struct {
+	DeviceID     string    `json:"device_id"`
+	Window       string    `json:"window"`
+	ErrorRate    float64   `json:"error_rate"`    // over the window
+	BaselineRate float64   `json:"baseline_rate"` // before the window
+	Errors       int64     `json:"errors"`
+	Operations   int64     `json:"operations"`
+	HealthScore  int       `json:"health_score"`
+	DetectedAt   time.Time `json:"detected_at"`
+}
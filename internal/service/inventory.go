@@ -0,0 +1,176 @@
+// internal/service/inventory.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"device-service/internal/model"
+	"device-service/internal/utils"
+)
+
+// Inventory snapshot limits
+const (
+	inventoryConcurrency   = 8
+	inventoryDeviceTimeout = 10 * time.Second
+)
+
+// CreateInventorySnapshot records the identity, firmware, status and
+// capabilities of every device and stores them as an audit record. Online
+// devices are read live, concurrently and each bounded by a timeout; the
+// others, and online devices that can't be reached, are recorded as
+// registered.
+func (ds *DeviceService) CreateInventorySnapshot(ctx context.Context, requestedBy string) (*model.InventorySnapshot, error) {
+	devices, err := ds.listAllDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &model.InventorySnapshot{
+		ID:          uuid.New(),
+		RequestedBy: requestedBy,
+		DeviceCount: len(devices),
+		Devices:     make(model.InventoryDevices, len(devices)),
+		StartedAt:   time.Now(),
+	}
+	if tenantID, ok := model.TenantFromContext(ctx); ok {
+		snapshot.TenantID = &tenantID
+	}
+
+	semaphore := make(chan struct{}, inventoryConcurrency)
+	var wg sync.WaitGroup
+	for i, device := range devices {
+		snapshot.Devices[i] = registeredInventoryDevice(device)
+		if device.Status != model.DeviceStatusOnline {
+			continue
+		}
+
+		wg.Add(1)
+		go func(entry *model.InventoryDevice, device *model.Device) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			defer utils.RecoverPanic(ds.logger.Logger, zap.String("goroutine", "inventory_device"), zap.String("device_id", device.DeviceID))
+
+			readCtx, cancel := context.WithTimeout(ctx, inventoryDeviceTimeout)
+			defer cancel()
+
+			ds.readLiveInventory(readCtx, entry, device)
+		}(&snapshot.Devices[i], device)
+	}
+	wg.Wait()
+
+	for _, entry := range snapshot.Devices {
+		if entry.Live {
+			snapshot.LiveCount++
+		}
+	}
+	snapshot.CompletedAt = time.Now()
+
+	if err := ds.inventoryRepo.Create(ctx, snapshot); err != nil {
+		return nil, err
+	}
+
+	ds.logger.Info("Inventory snapshot created",
+		zap.String("snapshot_id", snapshot.ID.String()),
+		zap.Int("devices", snapshot.DeviceCount),
+		zap.Int("live", snapshot.LiveCount),
+	)
+	return snapshot, nil
+}
+
+// GetInventorySnapshot retrieves an inventory snapshot
+func (ds *DeviceService) GetInventorySnapshot(ctx context.Context, id uuid.UUID) (*model.InventorySnapshot, error) {
+	return ds.inventoryRepo.GetByID(ctx, id)
+}
+
+// registeredInventoryDevice records a device as registered
+func registeredInventoryDevice(device *model.Device) model.InventoryDevice {
+	entry := model.InventoryDevice{
+		ID:             device.ID,
+		DeviceID:       device.DeviceID,
+		Name:           device.Name,
+		BranchID:       device.BranchID,
+		DeviceType:     device.DeviceType,
+		Brand:          device.Brand,
+		Model:          device.Model,
+		ConnectionType: device.ConnectionType,
+		Status:         device.Status,
+		LastPing:       device.LastPing,
+		Capabilities:   []model.Capability{},
+	}
+	if device.FirmwareVersion != nil {
+		entry.FirmwareVersion = *device.FirmwareVersion
+	}
+	for _, capability := range device.Capabilities {
+		if name, ok := capability.(string); ok {
+			entry.Capabilities = append(entry.Capabilities, model.Capability(name))
+		}
+	}
+	return entry
+}
+
+// readLiveInventory completes an entry with what the device reports about
+// itself. Reads that outlive ctx are abandoned and leave the entry as
+// registered.
+func (ds *DeviceService) readLiveInventory(ctx context.Context, entry *model.InventoryDevice, device *model.Device) {
+	type liveInventory struct {
+		entry model.InventoryDevice
+		err   error
+	}
+
+	done := make(chan liveInventory, 1)
+	go func() {
+		defer utils.RecoverPanic(ds.logger.Logger, zap.String("goroutine", "inventory_device_read"), zap.String("device_id", device.DeviceID))
+
+		live := *entry
+		driverInstance, release, err := ds.driverPool.Acquire(ctx, device)
+		if err != nil {
+			done <- liveInventory{err: fmt.Errorf("failed to acquire driver: %w", err)}
+			return
+		}
+		info, err := driverInstance.GetDeviceInfo()
+		if err != nil {
+			release(err)
+			done <- liveInventory{err: fmt.Errorf("failed to get device info: %w", err)}
+			return
+		}
+		status, err := driverInstance.GetStatus()
+		release(err)
+		if err != nil {
+			done <- liveInventory{err: fmt.Errorf("failed to get device status: %w", err)}
+			return
+		}
+
+		live.Live = true
+		if info.FirmwareVersion != "" {
+			live.FirmwareVersion = info.FirmwareVersion
+		}
+		live.SerialNumber = info.SerialNumber
+		live.HardwareVersion = info.HardwareVersion
+		if capabilities := driverInstance.GetCapabilities(); len(capabilities) > 0 {
+			live.Capabilities = capabilities
+		}
+		live.Status = status.Status
+		live.Ready = &status.IsReady
+		live.ErrorCode = status.ErrorCode
+		done <- liveInventory{entry: live}
+	}()
+
+	select {
+	case result := <-done:
+		if result.err != nil {
+			entry.Error = result.err.Error()
+			return
+		}
+		*entry = result.entry
+	case <-ctx.Done():
+		entry.Error = fmt.Sprintf("device did not answer within %s", inventoryDeviceTimeout)
+	}
+}
@@ -0,0 +1,68 @@
+// internal/service/offline_expiry.go
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"device-service/internal/model"
+)
+
+// OfflineOperationExpired reports an offline operation that was dead-lettered
+// instead of synced because it stayed queued past its expiry or the maximum
+// queue age
+type OfflineOperationExpired struct {
+	OperationID   uuid.UUID           `json:"operation_id"`
+	DeviceID      string              `json:"device_id"`
+	OperationType model.OperationType `json:"operation_type"`
+	QueuedAt      time.Time           `json:"queued_at"`
+	QueuedFor     string              `json:"queued_for"`
+	ExpiredAt     time.Time           `json:"expired_at"`
+}
+
+// SetOfflineExpiredHandler sets the handler alerted about dead-lettered
+// offline operations. It must be set before the offline sync starts.
+func (os *OperationService) SetOfflineExpiredHandler(handler func(*OfflineOperationExpired)) {
+	os.offlineExpiredHandler = handler
+}
+
+// ReportExpiredOfflineOperations logs each dead-lettered offline operation
+// and passes it to the expired handler
+func (os *OperationService) ReportExpiredOfflineOperations(ctx context.Context, operations []*model.OfflineOperation) {
+	now := time.Now()
+	deviceCodes := make(map[uuid.UUID]string)
+
+	for _, operation := range operations {
+		deviceCode, ok := deviceCodes[operation.DeviceID]
+		if !ok {
+			deviceCode = operation.DeviceID.String()
+			if device, err := os.deviceRepo.GetByID(ctx, operation.DeviceID); err == nil {
+				deviceCode = device.DeviceID
+			}
+			deviceCodes[operation.DeviceID] = deviceCode
+		}
+
+		expired := &OfflineOperationExpired{
+			OperationID:   operation.ID,
+			DeviceID:      deviceCode,
+			OperationType: operation.OperationType,
+			QueuedAt:      operation.CreatedAt,
+			QueuedFor:     now.Sub(operation.CreatedAt).Round(time.Second).String(),
+			ExpiredAt:     now,
+		}
+
+		os.logger.Warn("Offline operation expired before it could be synced",
+			zap.String("operation_id", operation.ID.String()),
+			zap.String("device_id", deviceCode),
+			zap.String("operation_type", string(operation.OperationType)),
+			zap.String("queued_for", expired.QueuedFor),
+		)
+
+		if os.offlineExpiredHandler != nil {
+			os.offlineExpiredHandler(expired)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+// internal/service/operation_diagnostics.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"device-service/internal/model"
+	pkgdriver "device-service/pkg/driver"
+)
+
+// ErrSelfTestNotAllowed is returned when a device cannot run a self-test in
+// its current state
+var ErrSelfTestNotAllowed = errors.New("self-test not allowed")
+
+// Self-test step outcomes
+const (
+	SelfTestPassed  = "passed"
+	SelfTestFailed  = "failed"
+	SelfTestSkipped = "skipped"
+)
+
+// selfTestStep is one sub-test, run when the device has its capability
+type selfTestStep struct {
+	name          string
+	capability    model.Capability
+	operationType model.OperationType
+	data          func(device *model.Device) map[string]interface{}
+}
+
+// selfTestSteps run in order: the status read first, so a failing printer
+// explains the failures of the steps after it
+var selfTestSteps = []selfTestStep{
+	{
+		name:          "status",
+		capability:    model.CapabilityStatus,
+		operationType: model.OperationTypeStatusCheck,
+	},
+	{
+		name:          "print",
+		capability:    model.CapabilityPrint,
+		operationType: model.OperationTypePrint,
+		data: func(device *model.Device) map[string]interface{} {
+			return map[string]interface{}{
+				"content": fmt.Sprintf("SELF TEST\n%s %s\n%s\n",
+					device.Brand, device.Model, time.Now().Format(time.RFC3339)),
+				"content_type": "TEXT",
+			}
+		},
+	},
+	{
+		name:          "cutter",
+		capability:    model.CapabilityCut,
+		operationType: model.OperationTypeCut,
+	},
+	{
+		name:          "drawer",
+		capability:    model.CapabilityDrawer,
+		operationType: model.OperationTypeOpenDrawer,
+	},
+	{
+		name:          "buzzer",
+		capability:    model.CapabilityBeep,
+		operationType: model.OperationTypeBeep,
+		data: func(device *model.Device) map[string]interface{} {
+			return map[string]interface{}{"count": 1}
+		},
+	},
+}
+
+// SelfTest exercises each capability of a device end to end: a status read,
+// a short test print, the cutter, a drawer pulse and the buzzer. Sub-tests
+// run as regular operations sharing one correlation ID; a failing sub-test
+// doesn't stop the ones after it. Capabilities the device lacks or has
+// switched off, and operations its policy forbids, are reported as skipped.
+// A locked device only runs them with its lock token.
+func (os *OperationService) SelfTest(ctx context.Context, deviceID uuid.UUID, lockToken string) (*SelfTestReport, error) {
+	device, err := os.deviceRepo.GetByID(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("device not found: %w", err)
+	}
+	if device.Status != model.DeviceStatusOnline {
+		return nil, fmt.Errorf("%w: device is not online: %s", ErrSelfTestNotAllowed, device.Status)
+	}
+
+	startTime := time.Now()
+	correlationID := uuid.New()
+	report := &SelfTestReport{
+		DeviceID:      device.DeviceID,
+		CorrelationID: correlationID,
+		Passed:        true,
+		Steps:         make([]SelfTestStepResult, 0, len(selfTestSteps)),
+	}
+
+	for _, step := range selfTestSteps {
+		stepResult := SelfTestStepResult{
+			Name:          step.name,
+			OperationType: step.operationType,
+		}
+
+		if !device.HasCapability(step.capability) {
+			stepResult.Status = SelfTestSkipped
+			stepResult.Message = fmt.Sprintf("device has no %s capability", step.capability)
+			report.Skipped++
+			report.Steps = append(report.Steps, stepResult)
+			continue
+		}
+
+		var data map[string]interface{}
+		if step.data != nil {
+			data = step.data(device)
+		}
+
+		stepStart := time.Now()
+		response, err := os.ExecuteOperation(ctx, &OperationRequest{
+			DeviceID:      device.ID,
+			OperationType: step.operationType,
+			Data:          data,
+			CorrelationID: &correlationID,
+			LockToken:     lockToken,
+		})
+		stepResult.DurationMs = time.Since(stepStart).Milliseconds()
+
+		switch {
+		case errors.Is(err, ErrCapabilityDisabled),
+			pkgdriver.ErrorCodeOf(err) == pkgdriver.ErrorCodeNotPermitted:
+			stepResult.Status = SelfTestSkipped
+			stepResult.Message = err.Error()
+			report.Skipped++
+		case err != nil:
+			stepResult.Status = SelfTestFailed
+			stepResult.Message = err.Error()
+			stepResult.ErrorCode = string(pkgdriver.ErrorCodeOf(err))
+			report.Failed++
+			report.Passed = false
+		default:
+			stepResult.Status = SelfTestPassed
+			stepResult.OperationID = &response.OperationID
+			stepResult.Result = response.Result
+			report.PassedCount++
+		}
+
+		report.Steps = append(report.Steps, stepResult)
+	}
+
+	report.DurationMs = time.Since(startTime).Milliseconds()
+
+	os.logger.Info("Device self-test completed",
+		zap.String("device_id", device.DeviceID),
+		zap.String("correlation_id", correlationID.String()),
+		zap.Bool("passed", report.Passed),
+		zap.Int("failed", report.Failed),
+		zap.Int("skipped", report.Skipped),
+	)
+
+	return report, nil
+}
+
+// SelfTestStepResult represents the outcome of one self-test sub-test
+type SelfTestStepResult struct {
+	Name          string                 `json:"name"`
+	OperationType model.OperationType    `json:"operation_type"`
+	Status        string                 `json:"status"` // passed, failed or skipped
+	OperationID   *uuid.UUID             `json:"operation_id,omitempty"`
+	DurationMs    int64                  `json:"duration_ms"`
+	ErrorCode     string                 `json:"error_code,omitempty"`
+	Message       string                 `json:"message,omitempty"`
+	Result        map[string]interface{} `json:"result,omitempty"`
+}
+
+// SelfTestReport represents a device self-test report. Passed is true when
+// no sub-test failed.
+type SelfTestReport struct {
+	DeviceID      string               `json:"device_id"`
+	CorrelationID uuid.UUID            `json:"correlation_id"`
+	Passed        bool                 `json:"passed"`
+	PassedCount   int                  `json:"passed_count"`
+	Failed        int                  `json:"failed"`
+	Skipped       int                  `json:"skipped"`
+	DurationMs    int64                `json:"duration_ms"`
+	Steps         []SelfTestStepResult `json:"steps"`
+}
@@ -0,0 +1,294 @@
+// internal/service/operation_queue.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrDeviceQueueFull is returned when a device already has the maximum
+// number of operations waiting
+var ErrDeviceQueueFull = errors.New("device queue full")
+
+// recentWaitSamples is the number of wait and run times averages are taken over
+const recentWaitSamples = 50
+
+// deviceQueues runs operations of a device one at a time, since a device
+// holds a single connection, and tracks how long operations wait for it.
+type deviceQueues struct {
+	maxDepth int // 0 means unbounded
+	queues   map[uuid.UUID]*deviceQueue
+	mu       sync.Mutex
+}
+
+// deviceQueue is the execution slot and wait statistics of one device
+type deviceQueue struct {
+	slot    chan struct{}
+	waiting map[uuid.UUID]time.Time // operation ID -> enqueued at
+	waits   []time.Duration         // ring of recent wait times
+	next    int
+
+	runningSince time.Time       // when the running operation got the slot
+	runs         []time.Duration // ring of recent run times
+	nextRun      int
+
+	lock *DeviceLock // exclusive reservation, nil when the device is free
+}
+
+// DeviceQueueStats describes how backed up a device is
+type DeviceQueueStats struct {
+	DeviceID        uuid.UUID `json:"device_id"`
+	Depth           int       `json:"depth"`
+	MaxDepth        int       `json:"max_depth"` // 0 means unbounded
+	Running         bool      `json:"running"`
+	OldestQueuedAge float64   `json:"oldest_queued_age_seconds"`
+	AverageWait     float64   `json:"average_wait_seconds"` // over recent operations
+	AverageRun      float64   `json:"average_run_seconds"`  // over recent operations
+	WaitSamples     int       `json:"wait_samples"`
+	Locked          bool      `json:"locked"` // reserved for exclusive use
+}
+
+// newDeviceQueues creates the per-device operation queues
+func newDeviceQueues(maxDepth int) *deviceQueues {
+	return &deviceQueues{
+		maxDepth: maxDepth,
+		queues:   make(map[uuid.UUID]*deviceQueue),
+	}
+}
+
+// enter waits until the operation may run on the device and returns the
+// func that frees the device again. It fails right away with
+// ErrDeviceQueueFull when too many operations are waiting, and with the
+// context error when the caller gives up while queued. While the device is
+// locked only operations presenting the lock token enter; others fail with
+// ErrDeviceLocked, also when the lock was taken while they waited. queued is
+// called when the device is busy and the operation has to wait.
+func (dq *deviceQueues) enter(ctx context.Context, deviceID, operationID uuid.UUID, lockToken string, queued func()) (func(), error) {
+	enqueuedAt := time.Now()
+
+	dq.mu.Lock()
+	queue := dq.queueFor(deviceID)
+	if err := queue.checkLock(lockToken, enqueuedAt); err != nil {
+		dq.mu.Unlock()
+		return nil, err
+	}
+	if dq.maxDepth > 0 && len(queue.waiting) >= dq.maxDepth {
+		dq.mu.Unlock()
+		return nil, fmt.Errorf("%w: %d operations waiting", ErrDeviceQueueFull, dq.maxDepth)
+	}
+	queue.waiting[operationID] = enqueuedAt
+	dq.mu.Unlock()
+
+	select {
+	case queue.slot <- struct{}{}:
+	default:
+		if queued != nil {
+			queued()
+		}
+		select {
+		case queue.slot <- struct{}{}:
+		case <-ctx.Done():
+			dq.mu.Lock()
+			delete(queue.waiting, operationID)
+			dq.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+
+	dq.mu.Lock()
+	delete(queue.waiting, operationID)
+	if err := queue.checkLock(lockToken, time.Now()); err != nil {
+		dq.mu.Unlock()
+		<-queue.slot
+		return nil, err
+	}
+	queue.recordWait(time.Since(enqueuedAt))
+	queue.runningSince = time.Now()
+	dq.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			dq.mu.Lock()
+			queue.recordRun(time.Since(queue.runningSince))
+			dq.mu.Unlock()
+			<-queue.slot
+		})
+	}, nil
+}
+
+// queueFor returns the queue of a device, creating it on first use; callers
+// must hold the queues lock
+func (dq *deviceQueues) queueFor(deviceID uuid.UUID) *deviceQueue {
+	queue, exists := dq.queues[deviceID]
+	if !exists {
+		queue = &deviceQueue{
+			slot:    make(chan struct{}, 1),
+			waiting: make(map[uuid.UUID]time.Time),
+		}
+		dq.queues[deviceID] = queue
+	}
+	return queue
+}
+
+// position returns the place of a waiting operation in its device's queue,
+// 1 being next, and the estimated time until it runs. The estimate is
+// unknown (nil) until operations of the device have run. ok is false when
+// the operation isn't waiting.
+func (dq *deviceQueues) position(deviceID, operationID uuid.UUID) (position int, estimatedWait *float64, ok bool) {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	queue, exists := dq.queues[deviceID]
+	if !exists {
+		return 0, nil, false
+	}
+	enqueuedAt, waiting := queue.waiting[operationID]
+	if !waiting {
+		return 0, nil, false
+	}
+
+	position = 1
+	for id, at := range queue.waiting {
+		if at.Before(enqueuedAt) || (at.Equal(enqueuedAt) && id.String() < operationID.String()) {
+			position++
+		}
+	}
+
+	averageRun, known := queue.averageRun()
+	if !known {
+		return position, nil, true
+	}
+	wait := time.Duration(position-1) * averageRun
+	if len(queue.slot) > 0 {
+		if remaining := averageRun - time.Since(queue.runningSince); remaining > 0 {
+			wait += remaining
+		}
+	}
+	seconds := math.Round(wait.Seconds()*10) / 10
+	return position, &seconds, true
+}
+
+// waitingOperations returns the IDs of the operations waiting for a device
+func (dq *deviceQueues) waitingOperations(deviceID uuid.UUID) []uuid.UUID {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	queue, exists := dq.queues[deviceID]
+	if !exists {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(queue.waiting))
+	for id := range queue.waiting {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// recordWait adds a wait time; callers must hold the queues lock
+func (q *deviceQueue) recordWait(wait time.Duration) {
+	if len(q.waits) < recentWaitSamples {
+		q.waits = append(q.waits, wait)
+		return
+	}
+	q.waits[q.next] = wait
+	q.next = (q.next + 1) % recentWaitSamples
+}
+
+// recordRun adds a run time; callers must hold the queues lock
+func (q *deviceQueue) recordRun(run time.Duration) {
+	if len(q.runs) < recentWaitSamples {
+		q.runs = append(q.runs, run)
+		return
+	}
+	q.runs[q.nextRun] = run
+	q.nextRun = (q.nextRun + 1) % recentWaitSamples
+}
+
+// averageRun returns the average recent run time; callers must hold the
+// queues lock
+func (q *deviceQueue) averageRun() (time.Duration, bool) {
+	if len(q.runs) == 0 {
+		return 0, false
+	}
+	var total time.Duration
+	for _, run := range q.runs {
+		total += run
+	}
+	return total / time.Duration(len(q.runs)), true
+}
+
+// stats builds the statistics of a queue; callers must hold the queues lock
+func (q *deviceQueue) stats(deviceID uuid.UUID, maxDepth int, now time.Time) *DeviceQueueStats {
+	stats := &DeviceQueueStats{
+		DeviceID:    deviceID,
+		Depth:       len(q.waiting),
+		MaxDepth:    maxDepth,
+		Running:     len(q.slot) > 0,
+		WaitSamples: len(q.waits),
+		Locked:      q.activeLock(now) != nil,
+	}
+
+	var oldest time.Time
+	for _, enqueuedAt := range q.waiting {
+		if oldest.IsZero() || enqueuedAt.Before(oldest) {
+			oldest = enqueuedAt
+		}
+	}
+	if !oldest.IsZero() {
+		stats.OldestQueuedAge = now.Sub(oldest).Seconds()
+	}
+
+	if len(q.waits) > 0 {
+		var total time.Duration
+		for _, wait := range q.waits {
+			total += wait
+		}
+		stats.AverageWait = (total / time.Duration(len(q.waits))).Seconds()
+	}
+	if averageRun, ok := q.averageRun(); ok {
+		stats.AverageRun = averageRun.Seconds()
+	}
+
+	return stats
+}
+
+// get returns the queue statistics of a device. A device without
+// operations so far has an empty queue.
+func (dq *deviceQueues) get(deviceID uuid.UUID) *DeviceQueueStats {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	queue, exists := dq.queues[deviceID]
+	if !exists {
+		return &DeviceQueueStats{DeviceID: deviceID, MaxDepth: dq.maxDepth}
+	}
+	return queue.stats(deviceID, dq.maxDepth, time.Now())
+}
+
+// list returns the queue statistics of every device that ran operations,
+// deepest queue first
+func (dq *deviceQueues) list() []*DeviceQueueStats {
+	dq.mu.Lock()
+	now := time.Now()
+	stats := make([]*DeviceQueueStats, 0, len(dq.queues))
+	for deviceID, queue := range dq.queues {
+		stats = append(stats, queue.stats(deviceID, dq.maxDepth, now))
+	}
+	dq.mu.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Depth != stats[j].Depth {
+			return stats[i].Depth > stats[j].Depth
+		}
+		return stats[i].DeviceID.String() < stats[j].DeviceID.String()
+	})
+	return stats
+}
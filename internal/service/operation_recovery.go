@@ -0,0 +1,202 @@
+// internal/service/operation_recovery.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/google/uuid"
+
+	"device-service/internal/model"
+	"device-service/internal/utils"
+	pkgdriver "device-service/pkg/driver"
+)
+
+// Recovery actions for operations interrupted by a service restart
+const (
+	RecoveryActionRequeued       = "REQUEUED"
+	RecoveryActionManualReview   = "MANUAL_REVIEW"
+	RecoveryActionReconciliation = "RECONCILIATION"
+)
+
+// SetOperationRecoveryHandler sets the handler told about each operation
+// settled by RecoverInterruptedOperations. It must be set before recovery
+// runs.
+func (os *OperationService) SetOperationRecoveryHandler(handler func(*RecoveredOperation)) {
+	os.recoveryHandler = handler
+}
+
+// RecoverInterruptedOperations settles operations a previous run left in
+// PENDING or PROCESSING. Every one of them is marked FAILED; operations that
+// never reached the device, and idempotent ones, are then executed again,
+// linked to the original through the parent ID. Anything that may have
+// changed the device state is left for manual review, and payments and
+// refunds are flagged for reconciliation, since re-executing them could move
+// money twice.
+func (os *OperationService) RecoverInterruptedOperations(ctx context.Context, startedAt time.Time) (*RecoveryResult, error) {
+	operations, err := os.operationRepo.ListActive(ctx, startedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interrupted operations: %w", err)
+	}
+
+	result := &RecoveryResult{
+		Total:      len(operations),
+		Operations: make([]RecoveredOperation, 0, len(operations)),
+	}
+	deviceCodes := make(map[uuid.UUID]string)
+
+	for _, operation := range operations {
+		previousStatus := operation.Status
+		action, code, message := recoveryAction(operation)
+
+		completedAt := time.Now()
+		operation.Status = model.OperationStatusFailed
+		operation.CompletedAt = &completedAt
+		operation.ErrorMessage = &message
+		operation.Result = model.JSONObject{
+			"error_code":      string(code),
+			"recovery_action": action,
+		}
+
+		if err := os.operationRepo.TransitionStatus(ctx, operation,
+			model.OperationStatusPending, model.OperationStatusProcessing,
+		); err != nil {
+			os.logger.Error("Failed to mark interrupted operation",
+				zap.String("operation_id", operation.ID.String()),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		os.auditLogger.LogOperationRecovery(
+			operation.ID.String(),
+			operation.DeviceID.String(),
+			string(operation.OperationType),
+			string(previousStatus),
+			action,
+		)
+
+		switch action {
+		case RecoveryActionRequeued:
+			result.Requeued++
+			go os.requeueOperation(operation)
+		case RecoveryActionReconciliation:
+			result.Reconciliation++
+		default:
+			result.ManualReview++
+		}
+
+		deviceCode, ok := deviceCodes[operation.DeviceID]
+		if !ok {
+			deviceCode = operation.DeviceID.String()
+			if device, err := os.deviceRepo.GetByID(ctx, operation.DeviceID); err == nil {
+				deviceCode = device.DeviceID
+			}
+			deviceCodes[operation.DeviceID] = deviceCode
+		}
+
+		recovered := RecoveredOperation{
+			OperationID:    operation.ID.String(),
+			DeviceID:       deviceCode,
+			OperationType:  operation.OperationType,
+			PreviousStatus: previousStatus,
+			Action:         action,
+			ErrorCode:      code,
+		}
+		result.Operations = append(result.Operations, recovered)
+
+		if os.recoveryHandler != nil {
+			os.recoveryHandler(&recovered)
+		}
+	}
+
+	if result.Total > 0 {
+		os.logger.Warn("Recovered operations interrupted by restart",
+			zap.Int("total", result.Total),
+			zap.Int("requeued", result.Requeued),
+			zap.Int("manual_review", result.ManualReview),
+			zap.Int("reconciliation", result.Reconciliation),
+		)
+	}
+
+	return result, nil
+}
+
+// requeueOperation executes an interrupted operation again
+func (os *OperationService) requeueOperation(original *model.DeviceOperation) {
+	defer utils.RecoverPanic(os.logger.Logger, zap.String("goroutine", "requeue_operation"), zap.String("operation_id", original.ID.String()))
+
+	correlationID := original.CorrelationID
+	if correlationID == nil {
+		correlationID = &original.ID
+	}
+
+	ctx := model.WithOperationSource(context.Background(), model.OperationSourceRecovery)
+	_, err := os.ExecuteOperation(ctx, &OperationRequest{
+		DeviceID:          original.DeviceID,
+		OperationType:     original.OperationType,
+		Data:              map[string]interface{}(original.OperationData),
+		Priority:          original.Priority,
+		CorrelationID:     correlationID,
+		ParentOperationID: &original.ID,
+	})
+	if err != nil {
+		os.logger.Warn("Requeued operation failed",
+			zap.String("original_operation_id", original.ID.String()),
+			zap.Error(err),
+		)
+	}
+}
+
+// recoveryAction decides how an interrupted operation is recovered and
+// returns the error code and message it is failed with. A PENDING operation
+// never reached the device, so only a PROCESSING one with side effects needs
+// review.
+func recoveryAction(operation *model.DeviceOperation) (string, pkgdriver.ErrorCode, string) {
+	switch {
+	case isFinancialOperation(operation.OperationType):
+		return RecoveryActionReconciliation, pkgdriver.ErrorCodeReconciliationRequired,
+			"interrupted by service restart; outcome unknown, reconcile with the payment provider before retrying"
+	case operation.Status == model.OperationStatusPending:
+		return RecoveryActionRequeued, pkgdriver.ErrorCodeInterrupted,
+			"interrupted by service restart before reaching the device; requeued"
+	case isIdempotentOperation(operation.OperationType):
+		return RecoveryActionRequeued, pkgdriver.ErrorCodeInterrupted,
+			"interrupted by service restart; requeued"
+	default:
+		return RecoveryActionManualReview, pkgdriver.ErrorCodeInterrupted,
+			"interrupted by service restart; may have reached the device, review before retrying"
+	}
+}
+
+// isIdempotentOperation reports whether running an operation twice has the
+// same effect as running it once
+func isIdempotentOperation(operationType model.OperationType) bool {
+	switch operationType {
+	case model.OperationTypeStatusCheck, model.OperationTypeDisplayText, model.OperationTypeWeigh:
+		return true
+	}
+	return false
+}
+
+// RecoveredOperation represents how one interrupted operation was recovered
+type RecoveredOperation struct {
+	OperationID    string                `json:"operation_id"`
+	DeviceID       string                `json:"device_id"`
+	OperationType  model.OperationType   `json:"operation_type"`
+	PreviousStatus model.OperationStatus `json:"previous_status"`
+	Action         string                `json:"action"`
+	ErrorCode      pkgdriver.ErrorCode   `json:"error_code"`
+}
+
+// RecoveryResult represents the outcome of startup operation recovery
+type RecoveryResult struct {
+	Total          int                  `json:"total"`
+	Requeued       int                  `json:"requeued"`
+	ManualReview   int                  `json:"manual_review"`
+	Reconciliation int                  `json:"reconciliation"`
+	Operations     []RecoveredOperation `json:"operations"`
+}
@@ -0,0 +1,34 @@
+// internal/service/operation_recovery_test.go
+package service
+
+import (
+	"testing"
+
+	"device-service/internal/model"
+)
+
+func TestRecoveryAction(t *testing.T) {
+	tests := []struct {
+		name          string
+		operationType model.OperationType
+		status        model.OperationStatus
+		want          string
+	}{
+		{name: "pending print", operationType: model.OperationTypePrint, status: model.OperationStatusPending, want: RecoveryActionRequeued},
+		{name: "processing print", operationType: model.OperationTypePrint, status: model.OperationStatusProcessing, want: RecoveryActionManualReview},
+		{name: "pending drawer", operationType: model.OperationTypeOpenDrawer, status: model.OperationStatusPending, want: RecoveryActionRequeued},
+		{name: "processing drawer", operationType: model.OperationTypeOpenDrawer, status: model.OperationStatusProcessing, want: RecoveryActionManualReview},
+		{name: "processing status check", operationType: model.OperationTypeStatusCheck, status: model.OperationStatusProcessing, want: RecoveryActionRequeued},
+		{name: "pending payment", operationType: model.OperationTypePayment, status: model.OperationStatusPending, want: RecoveryActionReconciliation},
+		{name: "processing refund", operationType: model.OperationTypeRefund, status: model.OperationStatusProcessing, want: RecoveryActionReconciliation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, _, _ := recoveryAction(&model.DeviceOperation{OperationType: tt.operationType, Status: tt.status})
+			if action != tt.want {
+				t.Errorf("recoveryAction() = %s, want %s", action, tt.want)
+			}
+		})
+	}
+}
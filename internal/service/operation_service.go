@@ -3,17 +3,64 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
 	"device-service/internal/config"
 	"device-service/internal/driver"
 	"device-service/internal/model"
 	"device-service/internal/repository"
+	"device-service/internal/tracing"
 	"device-service/internal/utils"
+	pkgdriver "device-service/pkg/driver"
+)
+
+var (
+	// ErrStatusConflict is returned when an operation changed status concurrently
+	ErrStatusConflict = repository.ErrStatusConflict
+
+	// ErrReplayNotAllowed is returned when replaying an operation needs explicit consent
+	ErrReplayNotAllowed = errors.New("operation replay not allowed")
+
+	// ErrBroadcastNotAllowed is returned for operation types that must not fan out
+	ErrBroadcastNotAllowed = errors.New("operation broadcast not allowed")
+
+	// ErrInvalidPriority is returned for priorities outside the known range
+	ErrInvalidPriority = errors.New("invalid operation priority")
+
+	// ErrInvalidMetadata is returned for operation metadata over the size limits
+	ErrInvalidMetadata = errors.New("invalid operation metadata")
+
+	// ErrInvalidTimeSeries is returned for unsupported time series parameters
+	ErrInvalidTimeSeries = errors.New("invalid time series request")
+
+	// ErrInvalidSummaryPeriod is returned for unsupported summary periods
+	ErrInvalidSummaryPeriod = errors.New("invalid summary period")
+
+	// ErrInvalidLatestCount is returned for an unsupported number of latest
+	// operations
+	ErrInvalidLatestCount = errors.New("invalid latest operation count")
+
+	// ErrInvalidChain is returned for operation chains that cannot run as given
+	ErrInvalidChain = errors.New("invalid operation chain")
+
+	// ErrCapabilityDisabled is returned when the operation needs a capability
+	// that was switched off on the device
+	ErrCapabilityDisabled = pkgdriver.ErrCapabilityDisabled
+
+	// ErrDriverNotFound is returned when no driver is registered for the
+	// device's brand, type and model
+	ErrDriverNotFound = driver.ErrDriverNotFound
 )
 
 // OperationService handles device operation business logic
@@ -21,9 +68,20 @@ type OperationService struct {
 	operationRepo  repository.OperationRepository
 	deviceRepo     repository.DeviceRepository
 	driverRegistry *driver.Registry
+	driverPool     *driver.Pool
+	queues         *deviceQueues
+	printDedup     *printDedup
 	config         *config.Config
 	logger         *utils.ServiceLogger
 	auditLogger    *utils.AuditLogger
+
+	progressHandler       func(device *model.Device, progress *model.OperationProgress)
+	offlineExpiredHandler func(*OfflineOperationExpired)
+	recoveryHandler       func(*RecoveredOperation)
+
+	// Devices with a running scan session; a scanner streams to one session
+	scanSessions  map[string]bool
+	scanSessionMu sync.Mutex
 }
 
 // NewOperationService creates a new operation service instance
@@ -31,6 +89,7 @@ func NewOperationService(
 	operationRepo repository.OperationRepository,
 	deviceRepo repository.DeviceRepository,
 	driverRegistry *driver.Registry,
+	driverPool *driver.Pool,
 	config *config.Config,
 	logger *zap.Logger,
 ) *OperationService {
@@ -38,14 +97,55 @@ func NewOperationService(
 		operationRepo:  operationRepo,
 		deviceRepo:     deviceRepo,
 		driverRegistry: driverRegistry,
+		driverPool:     driverPool,
+		queues:         newDeviceQueues(config.Device.MaxQueueDepth),
+		printDedup:     newPrintDedup(),
+		scanSessions:   make(map[string]bool),
 		config:         config,
 		logger:         utils.NewServiceLogger(logger, "operation-service"),
 		auditLogger:    utils.NewAuditLogger(logger),
 	}
 }
 
-// ExecuteOperation executes an operation on a device
-func (os *OperationService) ExecuteOperation(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+// ExecuteOperation executes an operation on a device. With a print dedup
+// window configured, a print request marked Deduplicate that is identical to
+// one just sent to the device is answered with that print's response unless
+// it is forced.
+func (os *OperationService) ExecuteOperation(ctx context.Context, req *OperationRequest) (response *OperationResponse, err error) {
+	ctx, span := tracing.Start(ctx, "operation.execute",
+		attribute.String("device.id", req.DeviceID.String()),
+		attribute.String("operation.type", string(req.OperationType)),
+	)
+	defer func() {
+		if err != nil {
+			span.SetAttributes(attribute.String("operation.error_code", OperationErrorCode(err)))
+		}
+		tracing.End(span, err)
+	}()
+
+	if window := os.config.Device.PrintDedupWindow; window > 0 {
+		if key := printDedupKey(req); key != "" {
+			return os.printDedup.do(ctx, key, window, func() (*OperationResponse, error) {
+				return os.executeOperation(ctx, req)
+			})
+		}
+	}
+	return os.executeOperation(ctx, req)
+}
+
+// executeOperation executes an operation on a device
+func (os *OperationService) executeOperation(ctx context.Context, req *OperationRequest) (*OperationResponse, error) {
+	// Requests without a priority run with the operation type's default
+	if req.Priority == 0 {
+		req.Priority = DefaultPriority(req.OperationType)
+	}
+	if err := validatePriority(req.Priority); err != nil {
+		return nil, err
+	}
+	if err := validateMetadata(req.Metadata); err != nil {
+		return nil, err
+	}
+
 	// Create operation record
 	operation := &model.DeviceOperation{
 		ID:            uuid.New(),
@@ -57,12 +157,17 @@ func (os *OperationService) ExecuteOperation(ctx context.Context, req *Operation
 		StartedAt:     time.Now(),
 		CorrelationID: req.CorrelationID,
 		CreatedAt:     time.Now(),
+
+		ParentOperationID: req.ParentOperationID,
+		Metadata:          model.OperationMetadata(req.Metadata),
+		Source:            model.OperationSourceFromContext(ctx),
 	}
 
 	// Save operation to database
 	if err := os.operationRepo.Create(ctx, operation); err != nil {
 		return nil, fmt.Errorf("failed to create operation: %w", err)
 	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("operation.id", operation.ID.String()))
 
 	// Create operation logger
 	opLogger := utils.NewOperationLogger(os.logger.Logger, string(req.OperationType), operation.ID.String())
@@ -84,26 +189,106 @@ func (os *OperationService) ExecuteOperation(ctx context.Context, req *Operation
 		return nil, err
 	}
 
-	// Create driver instance
-	driverInstance, err := os.driverRegistry.CreateDriver(device, device.ConnectionConfig)
+	// Reject operations the device type cannot perform before touching it
+	if err := checkOperationSupported(device, req.OperationType); err != nil {
+		os.updateOperationError(ctx, operation, err)
+		opLogger.Error(err)
+		return nil, err
+	}
+
+	// Enforce the device's operation policy on top of its capabilities
+	if err := os.checkOperationPermitted(ctx, device, req.OperationType, req.Data); err != nil {
+		os.updateOperationError(ctx, operation, err)
+		opLogger.Error(err)
+		return nil, err
+	}
+
+	// Report every state change from here on, ending with the final status
+	defer func() { os.notifyProgress(device, operation) }()
+
+	// Wait for the operations queued before this one on the device
+	leave, err := os.queues.enter(ctx, req.DeviceID, operation.ID, req.LockToken, func() {
+		os.notifyProgress(device, operation)
+	})
 	if err != nil {
+		if errors.Is(err, ErrDeviceQueueFull) {
+			err = pkgdriver.NewOperationError(pkgdriver.ErrorCodeQueueFull, err)
+		}
+		if errors.Is(err, ErrDeviceLocked) {
+			err = pkgdriver.NewOperationError(pkgdriver.ErrorCodeDeviceLocked, err)
+		}
 		os.updateOperationError(ctx, operation, err)
 		opLogger.Error(err)
-		return nil, fmt.Errorf("failed to create driver: %w", err)
+		return nil, err
+	}
+	defer leave()
+
+	// Acquire the device's live driver, creating one if none is cached. A new
+	// driver connects while it is created.
+	acquireCtx, acquireSpan := tracing.Start(ctx, "driver.acquire", attribute.String("device.code", device.DeviceID))
+	driverInstance, release, err := os.driverPool.Acquire(acquireCtx, device)
+	tracing.End(acquireSpan, err)
+	if err != nil {
+		os.updateOperationError(ctx, operation, err)
+		opLogger.Error(err)
+		return nil, fmt.Errorf("failed to acquire driver: %w", err)
+	}
+
+	// Release the driver on every return, a driver panic included, so its
+	// in-flight slot is never leaked. releaseErr reports the outcome to the
+	// pool's circuit breaker.
+	var releaseErr error
+	defer func() { release(releaseErr) }()
+
+	// A print asking to be verified needs status readback; refuse it
+	// before anything is printed
+	verify := printVerificationRequested(req)
+	if verify {
+		if err := checkPrintVerifiable(driverInstance); err != nil {
+			os.updateOperationError(ctx, operation, err)
+			opLogger.Error(err)
+			return nil, err
+		}
 	}
 
-	// Update operation status to processing
+	// Update operation status to processing, unless it was cancelled
+	// meanwhile. The device is only reached once this is recorded, since
+	// recovery re-executes operations a restart left in PENDING.
 	operation.Status = model.OperationStatusProcessing
-	if err := os.operationRepo.UpdateStatus(ctx, operation.ID, operation.Status); err != nil {
-		os.logger.Error("Failed to update operation status", zap.Error(err))
+	if err := os.operationRepo.TransitionStatus(ctx, operation, model.OperationStatusPending); err != nil {
+		if errors.Is(err, repository.ErrStatusConflict) {
+			os.reloadStatus(ctx, operation)
+			opLogger.Error(err)
+			return nil, fmt.Errorf("operation no longer pending: %w", err)
+		}
+		os.updateOperationError(ctx, operation, err)
+		opLogger.Error(err)
+		return nil, fmt.Errorf("failed to update operation status: %w", err)
 	}
+	os.notifyProgress(device, operation)
+	os.notifyQueuePositions(device)
 
 	// Execute operation with timeout
-	timeout := os.getOperationTimeout(req.OperationType)
+	timeout := os.getOperationTimeout(device.ConnectionType, req.OperationType)
 	execCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	result, err := driverInstance.ExecuteOperation(execCtx, operation)
+	result, err := os.executeOnDriver(execCtx, driverInstance, operation)
+	if err == nil && verify {
+		var printerStatus *pkgdriver.PrinterStatus
+		if printerStatus, err = verifyPrint(execCtx, driverInstance); err == nil {
+			if result.Data == nil {
+				result.Data = make(map[string]interface{})
+			}
+			result.Data[pkgdriver.ResultKeyVerified] = true
+			result.Data[pkgdriver.ResultKeyPrinterStatus] = printerStatus
+		}
+	}
+	if !errors.Is(err, ErrCapabilityDisabled) {
+		// A disabled capability is an operator decision, not a device
+		// fault; it keeps the circuit closed
+		releaseErr = err
+	}
 	if err != nil {
 		os.updateOperationError(ctx, operation, err)
 		opLogger.Error(err)
@@ -116,8 +301,20 @@ func (os *OperationService) ExecuteOperation(ctx context.Context, req *Operation
 	operation.CompletedAt = &completedAt
 	operation.Result = model.JSONObject(result.Data)
 
-	if err := os.operationRepo.Update(ctx, operation); err != nil {
-		os.logger.Error("Failed to update operation", zap.Error(err))
+	// A cancellation that landed while the device was working wins; the
+	// completion must not resurrect it. The outcome is recorded even when the
+	// request's deadline passed meanwhile.
+	settleCtx := context.WithoutCancel(ctx)
+	if err := os.operationRepo.TransitionStatus(settleCtx, operation, model.OperationStatusProcessing); err != nil {
+		if errors.Is(err, repository.ErrStatusConflict) {
+			os.logger.Warn("Operation completed on device after status changed",
+				zap.String("operation_id", operation.ID.String()),
+				zap.Error(err),
+			)
+			os.reloadStatus(settleCtx, operation)
+		} else {
+			os.logger.Error("Failed to update operation", zap.Error(err))
+		}
 	}
 	duration, err := time.ParseDuration(result.Duration)
 	if err != nil {
@@ -139,23 +336,100 @@ func (os *OperationService) ExecuteOperation(ctx context.Context, req *Operation
 		)
 	}
 
-	return &OperationResponse{
+	response := &OperationResponse{
 		OperationID: operation.ID,
 		Success:     true,
 		Result:      result.Data,
 		Duration:    result.Duration,
-	}, nil
+		Metadata:    req.Metadata,
+	}
+	if req.IncludeRaw && len(result.RawResponse) > 0 {
+		response.RawResponse = base64.StdEncoding.EncodeToString(result.RawResponse)
+	}
+
+	return response, nil
 }
 
-// GetOperation retrieves operation details
+// GetDeviceQueue returns how many operations wait for a device and for how long
+func (os *OperationService) GetDeviceQueue(deviceID uuid.UUID) *DeviceQueueStats {
+	return os.queues.get(deviceID)
+}
+
+// ListDeviceQueues returns the queue statistics of all devices that ran
+// operations, deepest queue first
+func (os *OperationService) ListDeviceQueues() []*DeviceQueueStats {
+	return os.queues.list()
+}
+
+// GetOperation retrieves operation details with the operation's progress
 func (os *OperationService) GetOperation(ctx context.Context, operationID uuid.UUID) (*model.DeviceOperation, error) {
 	operation, err := os.operationRepo.GetByID(ctx, operationID)
 	if err != nil {
 		return nil, fmt.Errorf("operation not found: %w", err)
 	}
+	operation.Progress = os.operationProgress(operation)
 	return operation, nil
 }
 
+// SetOperationProgressHandler sets the handler receiving operation progress:
+// queued with the queue position, processing, then the final status. Waiting
+// operations are reported again whenever their position changes. It must be
+// set before operations are executed. The handler gets the operation's
+// device, so it can route the progress without looking the device up.
+func (os *OperationService) SetOperationProgressHandler(handler func(device *model.Device, progress *model.OperationProgress)) {
+	os.progressHandler = handler
+}
+
+// operationProgress reports the progress of an operation. A pending operation
+// is queued; its position is known while it waits on this instance.
+func (os *OperationService) operationProgress(operation *model.DeviceOperation) *model.OperationProgress {
+	progress := &model.OperationProgress{OperationID: operation.ID}
+
+	switch operation.Status {
+	case model.OperationStatusPending:
+		progress.State = model.OperationStateQueued
+		if position, wait, ok := os.queues.position(operation.DeviceID, operation.ID); ok {
+			progress.QueuePosition = &position
+			progress.EstimatedWait = wait
+		}
+	case model.OperationStatusProcessing:
+		progress.State = model.OperationStateProcessing
+	default:
+		progress.State = strings.ToLower(string(operation.Status))
+	}
+	return progress
+}
+
+// notifyProgress hands the progress of an operation to the progress handler
+func (os *OperationService) notifyProgress(device *model.Device, operation *model.DeviceOperation) {
+	if os.progressHandler != nil {
+		os.progressHandler(device, os.operationProgress(operation))
+	}
+}
+
+// notifyQueuePositions reports the new positions of the operations waiting
+// for a device after the queue moved on
+func (os *OperationService) notifyQueuePositions(device *model.Device) {
+	if os.progressHandler == nil {
+		return
+	}
+	for _, operationID := range os.queues.waitingOperations(device.ID) {
+		os.notifyProgress(device, &model.DeviceOperation{
+			ID:       operationID,
+			DeviceID: device.ID,
+			Status:   model.OperationStatusPending,
+		})
+	}
+}
+
+// reloadStatus refreshes the status of an operation another request changed,
+// e.g. cancelled it, so its progress reports that status
+func (os *OperationService) reloadStatus(ctx context.Context, operation *model.DeviceOperation) {
+	if current, err := os.operationRepo.GetByID(ctx, operation.ID); err == nil {
+		operation.Status = current.Status
+	}
+}
+
 // ListOperations lists operations with filtering
 func (os *OperationService) ListOperations(ctx context.Context, filter *OperationFilter) ([]*model.DeviceOperation, *PaginationResult, error) {
 	operations, total, err := os.operationRepo.List(ctx, filter.toRepoFilter())
@@ -173,6 +447,150 @@ func (os *OperationService) ListOperations(ctx context.Context, filter *Operatio
 	return operations, pagination, nil
 }
 
+// GroupOperationErrors groups failed operations matching the filter by error text
+func (os *OperationService) GroupOperationErrors(ctx context.Context, filter *OperationFilter, limit int) ([]*repository.OperationErrorGroup, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	groups, err := os.operationRepo.GroupErrors(ctx, filter.toRepoFilter(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to group operation errors: %w", err)
+	}
+	return groups, nil
+}
+
+// OperationErrorCode returns the standardized device error code of an
+// operation failure (e.g. PAPER_OUT), or an empty string when unclassified
+func OperationErrorCode(err error) string {
+	return string(pkgdriver.ErrorCodeOf(err))
+}
+
+// Time series bucket intervals
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+)
+
+// maxTimeSeriesBuckets bounds the size of a time series response
+const maxTimeSeriesBuckets = 1000
+
+// GetOperationTimeSeries returns operation counts by status and average
+// duration per hour or day. Buckets without operations are included with zero
+// counts so the series can be charted as is.
+func (os *OperationService) GetOperationTimeSeries(ctx context.Context, req *TimeSeriesRequest) (*OperationTimeSeries, error) {
+	var step time.Duration
+	switch req.Interval {
+	case IntervalHour:
+		step = time.Hour
+	case IntervalDay:
+		step = 24 * time.Hour
+	default:
+		return nil, fmt.Errorf("%w: interval must be %s or %s", ErrInvalidTimeSeries, IntervalHour, IntervalDay)
+	}
+
+	end := time.Now().UTC()
+	if req.End != nil {
+		end = req.End.UTC()
+	}
+	start := end.Add(-24 * step)
+	if req.Start != nil {
+		start = req.Start.UTC()
+	}
+
+	// Align to bucket boundaries; the end bucket is included
+	start = start.Truncate(step)
+	end = end.Truncate(step).Add(step)
+
+	if !start.Before(end) {
+		return nil, fmt.Errorf("%w: start must be before end", ErrInvalidTimeSeries)
+	}
+	if count := int(end.Sub(start) / step); count > maxTimeSeriesBuckets {
+		return nil, fmt.Errorf("%w: range spans %d buckets, at most %d allowed", ErrInvalidTimeSeries, count, maxTimeSeriesBuckets)
+	}
+
+	buckets, err := os.operationRepo.GetOperationTimeSeries(ctx, &repository.OperationTimeSeriesFilter{
+		Interval:  req.Interval,
+		DeviceID:  req.DeviceID,
+		Source:    req.Source,
+		StartDate: start,
+		EndDate:   end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operation time series: %w", err)
+	}
+
+	byStart := make(map[time.Time]*repository.OperationTimeBucket, len(buckets))
+	for _, bucket := range buckets {
+		byStart[bucket.BucketStart] = bucket
+	}
+
+	series := &OperationTimeSeries{
+		Interval: req.Interval,
+		Start:    start,
+		End:      end,
+		DeviceID: req.DeviceID,
+		Source:   req.Source,
+	}
+	for t := start; t.Before(end); t = t.Add(step) {
+		bucket, ok := byStart[t]
+		if !ok {
+			bucket = &repository.OperationTimeBucket{
+				BucketStart: t,
+				ByStatus:    map[model.OperationStatus]int{},
+			}
+		}
+		series.Buckets = append(series.Buckets, bucket)
+	}
+
+	return series, nil
+}
+
+// maxSummaryPeriod bounds how far back an operation summary looks
+const maxSummaryPeriod = 90 * 24 * time.Hour
+
+// GetDeviceOperationSummary returns operation counts, success rate and
+// response time of a device over the last period
+func (os *OperationService) GetDeviceOperationSummary(ctx context.Context, deviceID uuid.UUID, period time.Duration) (*DeviceOperationSummary, error) {
+	if period <= 0 || period > maxSummaryPeriod {
+		return nil, fmt.Errorf("%w: must be positive and at most %s", ErrInvalidSummaryPeriod, maxSummaryPeriod)
+	}
+
+	summary, err := os.operationRepo.GetDeviceOperationSummary(ctx, deviceID, period)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operation summary: %w", err)
+	}
+
+	return &DeviceOperationSummary{
+		DeviceID:          summary.DeviceID,
+		Period:            period.String(),
+		Since:             time.Now().Add(-period).UTC(),
+		TotalOperations:   summary.TotalOps,
+		SuccessRate:       summary.SuccessRate,
+		ErrorCount:        summary.ErrorCount,
+		AvgResponseTimeMs: float64(summary.AvgResponseTime) / float64(time.Millisecond),
+		LastOperation:     summary.LastOperation,
+	}, nil
+}
+
+// maxLatestOperations bounds how many latest operations can be requested
+const maxLatestOperations = 50
+
+// GetLatestOperations returns the newest operations of a device, newest
+// first. It reads only the device's newest index entries, so it is cheaper
+// than listing operations for e.g. a "last print" display.
+func (os *OperationService) GetLatestOperations(ctx context.Context, deviceID uuid.UUID, count int) ([]*model.DeviceOperation, error) {
+	if count < 1 || count > maxLatestOperations {
+		return nil, fmt.Errorf("%w: must be between 1 and %d", ErrInvalidLatestCount, maxLatestOperations)
+	}
+
+	operations, err := os.operationRepo.ListByDevice(ctx, deviceID, count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest operations: %w", err)
+	}
+	return operations, nil
+}
+
 // CancelOperation cancels a pending operation
 func (os *OperationService) CancelOperation(ctx context.Context, operationID uuid.UUID, reason string) error {
 	operation, err := os.operationRepo.GetByID(ctx, operationID)
@@ -181,7 +599,7 @@ func (os *OperationService) CancelOperation(ctx context.Context, operationID uui
 	}
 
 	if operation.Status != model.OperationStatusPending && operation.Status != model.OperationStatusProcessing {
-		return fmt.Errorf("cannot cancel operation in status: %s", operation.Status)
+		return fmt.Errorf("%w: cannot cancel operation in status %s", ErrStatusConflict, operation.Status)
 	}
 
 	completedAt := time.Now()
@@ -189,7 +607,10 @@ func (os *OperationService) CancelOperation(ctx context.Context, operationID uui
 	operation.CompletedAt = &completedAt
 	operation.ErrorMessage = &reason
 
-	if err := os.operationRepo.Update(ctx, operation); err != nil {
+	// The operation may have completed since it was read; only cancel if still active
+	if err := os.operationRepo.TransitionStatus(ctx, operation,
+		model.OperationStatusPending, model.OperationStatusProcessing,
+	); err != nil {
 		return fmt.Errorf("failed to cancel operation: %w", err)
 	}
 
@@ -201,33 +622,519 @@ func (os *OperationService) CancelOperation(ctx context.Context, operationID uui
 	return nil
 }
 
+// ReplayOperation re-executes a stored operation, optionally on another device.
+// The new operation is linked to the original through its parent ID and shares
+// the original's correlation ID.
+func (os *OperationService) ReplayOperation(ctx context.Context, operationID uuid.UUID, req *ReplayRequest) (*OperationResponse, error) {
+	original, err := os.operationRepo.GetByID(ctx, operationID)
+	if err != nil {
+		return nil, fmt.Errorf("operation not found: %w", err)
+	}
+
+	// Replaying money movements must be an explicit decision
+	if isFinancialOperation(original.OperationType) && !req.AllowPayment {
+		return nil, fmt.Errorf("%w: %s operations require allow_payment", ErrReplayNotAllowed, original.OperationType)
+	}
+
+	deviceID := original.DeviceID
+	if req.DeviceID != nil {
+		deviceID = *req.DeviceID
+	}
+
+	correlationID := original.CorrelationID
+	if correlationID == nil {
+		correlationID = &original.ID
+	}
+
+	os.logger.Info("Replaying operation",
+		zap.String("original_operation_id", original.ID.String()),
+		zap.String("device_id", deviceID.String()),
+		zap.String("operation_type", string(original.OperationType)),
+		zap.String("user_id", req.UserID),
+	)
+
+	ctx = model.WithOperationSource(ctx, model.OperationSourceReplay)
+	return os.ExecuteOperation(ctx, &OperationRequest{
+		DeviceID:          deviceID,
+		OperationType:     original.OperationType,
+		Data:              map[string]interface{}(original.OperationData),
+		Priority:          original.Priority,
+		CorrelationID:     correlationID,
+		ParentOperationID: &original.ID,
+		Metadata:          map[string]string(original.Metadata),
+		LockToken:         req.LockToken,
+	})
+}
+
 // Helper methods
 
-// updateOperationError updates operation with error
+// BroadcastOperation executes the same operation on every matching device of
+// a branch. Each device runs independently, so a slow or offline device only
+// fails its own result and never holds back the others.
+func (os *OperationService) BroadcastOperation(ctx context.Context, branchID uuid.UUID, req *BroadcastRequest) (*BroadcastResponse, error) {
+	if isFinancialOperation(req.OperationType) {
+		return nil, fmt.Errorf("%w: %s", ErrBroadcastNotAllowed, req.OperationType)
+	}
+	if req.Priority != 0 {
+		if err := validatePriority(req.Priority); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateMetadata(req.Metadata); err != nil {
+		return nil, err
+	}
+
+	devices, err := os.deviceRepo.ListByBranch(ctx, branchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branch devices: %w", err)
+	}
+
+	targets := make([]*model.Device, 0, len(devices))
+	for _, device := range devices {
+		if req.DeviceType != nil && device.DeviceType != *req.DeviceType {
+			continue
+		}
+		if req.Capability != nil && !device.HasCapability(*req.Capability) {
+			continue
+		}
+		targets = append(targets, device)
+	}
+
+	correlationID := uuid.New()
+	if req.CorrelationID != nil {
+		correlationID = *req.CorrelationID
+	}
+
+	response := &BroadcastResponse{
+		BranchID:      branchID,
+		CorrelationID: correlationID,
+		Total:         len(targets),
+		Results:       make([]BroadcastResult, len(targets)),
+	}
+
+	var wg sync.WaitGroup
+	for i, device := range targets {
+		wg.Add(1)
+		go func(i int, device *model.Device) {
+			defer wg.Done()
+			defer utils.RecoverPanic(os.logger.Logger,
+				zap.String("goroutine", "broadcast_operation"),
+				zap.String("device_id", device.DeviceID),
+			)
+
+			// Until the device answers its result reports the broadcast as aborted
+			result := &response.Results[i]
+			*result = BroadcastResult{
+				DeviceID:     device.ID,
+				DeviceCode:   device.DeviceID,
+				ErrorMessage: "broadcast execution aborted",
+			}
+
+			// Each device gets its own copy, since executing an operation
+			// may fill in defaults
+			opResponse, err := os.ExecuteOperation(ctx, &OperationRequest{
+				DeviceID:      device.ID,
+				OperationType: req.OperationType,
+				Data:          copyOperationData(req.Data),
+				Priority:      req.Priority,
+				CorrelationID: &correlationID,
+				Metadata:      req.Metadata,
+				LockToken:     req.LockToken,
+			})
+			if err != nil {
+				result.ErrorMessage = err.Error()
+				result.ErrorCode = OperationErrorCode(err)
+				return
+			}
+
+			result.Success = opResponse.Success
+			result.OperationID = &opResponse.OperationID
+			result.Duration = opResponse.Duration
+			result.ErrorMessage = opResponse.ErrorMessage
+		}(i, device)
+	}
+	wg.Wait()
+
+	for _, result := range response.Results {
+		if result.Success {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+
+	os.logger.Info("Broadcast operation completed",
+		zap.String("branch_id", branchID.String()),
+		zap.String("operation_type", string(req.OperationType)),
+		zap.String("correlation_id", correlationID.String()),
+		zap.Int("total", response.Total),
+		zap.Int("succeeded", response.Succeeded),
+		zap.Int("failed", response.Failed),
+	)
+
+	return response, nil
+}
+
+// maxChainSteps caps how many operations a single chain may run
+const maxChainSteps = 10
+
+// ChainOperations executes operations one after another under a shared
+// correlation ID. A step runs only when every step before it succeeded, so
+// the chain stops at the first failure and reports the remaining steps as
+// skipped. Step inputs copy values from earlier step results into the step's
+// data, e.g. a payment's auth code into the receipt print that follows it.
+func (os *OperationService) ChainOperations(ctx context.Context, req *ChainRequest) (*ChainResponse, error) {
+	if err := validateChain(req); err != nil {
+		return nil, err
+	}
+
+	correlationID := uuid.New()
+	if req.CorrelationID != nil {
+		correlationID = *req.CorrelationID
+	}
+
+	response := &ChainResponse{
+		CorrelationID: correlationID,
+		Total:         len(req.Steps),
+		Success:       true,
+		Steps:         make([]ChainStepResult, len(req.Steps)),
+	}
+
+	var parentOperationID *uuid.UUID
+	for i, step := range req.Steps {
+		result := &response.Steps[i]
+		*result = ChainStepResult{
+			Step:          i,
+			DeviceID:      step.DeviceID,
+			OperationType: step.OperationType,
+			Status:        ChainStepSkipped,
+		}
+		if !response.Success {
+			continue
+		}
+
+		data, err := chainStepData(step, response.Steps[:i])
+		if err == nil {
+			var opResponse *OperationResponse
+			opResponse, err = os.ExecuteOperation(ctx, &OperationRequest{
+				DeviceID:          step.DeviceID,
+				OperationType:     step.OperationType,
+				Data:              data,
+				Priority:          step.Priority,
+				CorrelationID:     &correlationID,
+				ParentOperationID: parentOperationID,
+				Metadata:          req.Metadata,
+				LockToken:         req.LockToken,
+			})
+			if err == nil {
+				result.Status = ChainStepSuccess
+				result.OperationID = &opResponse.OperationID
+				result.Result = opResponse.Result
+				result.Duration = opResponse.Duration
+				parentOperationID = &opResponse.OperationID
+				response.Completed++
+				continue
+			}
+		}
+
+		result.Status = ChainStepFailed
+		result.ErrorMessage = err.Error()
+		result.ErrorCode = OperationErrorCode(err)
+		response.Success = false
+		response.FailedStep = &result.Step
+	}
+
+	os.logger.Info("Operation chain completed",
+		zap.String("correlation_id", correlationID.String()),
+		zap.Int("total", response.Total),
+		zap.Int("completed", response.Completed),
+		zap.Bool("success", response.Success),
+	)
+
+	return response, nil
+}
+
+// validateChain rejects chains that could not run to completion as given,
+// before any of their steps touches a device
+func validateChain(req *ChainRequest) error {
+	if len(req.Steps) == 0 {
+		return fmt.Errorf("%w: no steps", ErrInvalidChain)
+	}
+	if len(req.Steps) > maxChainSteps {
+		return fmt.Errorf("%w: %d steps, at most %d allowed", ErrInvalidChain, len(req.Steps), maxChainSteps)
+	}
+	if err := validateMetadata(req.Metadata); err != nil {
+		return err
+	}
+
+	for i, step := range req.Steps {
+		if step.Priority != 0 {
+			if err := validatePriority(step.Priority); err != nil {
+				return err
+			}
+		}
+		for key, ref := range step.Inputs {
+			source, _, err := parseChainInput(ref)
+			if err != nil {
+				return fmt.Errorf("%w: step %d input %s: %v", ErrInvalidChain, i, key, err)
+			}
+			if source >= i {
+				return fmt.Errorf("%w: step %d input %s must refer to an earlier step", ErrInvalidChain, i, key)
+			}
+		}
+	}
+	return nil
+}
+
+// copyOperationData deep copies operation data, including nested objects and
+// arrays, so it can be changed without affecting other operations
+func copyOperationData(data map[string]interface{}) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		copied[key] = copyOperationValue(value)
+	}
+	return copied
+}
+
+// copyOperationValue deep copies a decoded JSON value
+func copyOperationValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return copyOperationData(v)
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyOperationValue(item)
+		}
+		return copied
+	}
+	return value
+}
+
+// chainStepData returns a step's operation data with its inputs filled in
+// from the results of the steps before it
+func chainStepData(step ChainStep, previous []ChainStepResult) (map[string]interface{}, error) {
+	data := make(map[string]interface{}, len(step.Data)+len(step.Inputs))
+	for key, value := range step.Data {
+		data[key] = value
+	}
+
+	for key, ref := range step.Inputs {
+		source, resultKey, _ := parseChainInput(ref)
+		value, exists := previous[source].Result[resultKey]
+		if !exists {
+			return nil, pkgdriver.NewOperationError(pkgdriver.ErrorCodeInvalidRequest,
+				fmt.Errorf("%w: step %d result has no %s for input %s", ErrInvalidChain, source, resultKey, key))
+		}
+		data[key] = value
+	}
+	return data, nil
+}
+
+// parseChainInput splits a step input reference of the form
+// "<step>.<result key>", e.g. "0.auth_code"
+func parseChainInput(ref string) (int, string, error) {
+	stepPart, resultKey, found := strings.Cut(ref, ".")
+	if !found || resultKey == "" {
+		return 0, "", fmt.Errorf("reference %q is not <step>.<result key>", ref)
+	}
+	step, err := strconv.Atoi(stepPart)
+	if err != nil || step < 0 {
+		return 0, "", fmt.Errorf("reference %q has an invalid step", ref)
+	}
+	return step, resultKey, nil
+}
+
+// isFinancialOperation reports whether an operation moves money
+func isFinancialOperation(operationType model.OperationType) bool {
+	return operationType == model.OperationTypePayment || operationType == model.OperationTypeRefund
+}
+
+// DefaultPriority returns the priority an operation type runs with when the
+// request doesn't set one
+func DefaultPriority(operationType model.OperationType) model.OperationPriority {
+	switch operationType {
+	case model.OperationTypePayment, model.OperationTypeRefund:
+		return model.PriorityUltraCritical
+	case model.OperationTypePrint, model.OperationTypeCut, model.OperationTypeOpenDrawer, model.OperationTypeWeigh:
+		return model.PriorityHigh
+	default:
+		return model.PriorityNormal
+	}
+}
+
+// validatePriority rejects priorities outside the known range as an invalid
+// request
+func validatePriority(priority model.OperationPriority) error {
+	if priority.IsValid() {
+		return nil
+	}
+	return pkgdriver.NewOperationError(pkgdriver.ErrorCodeInvalidRequest,
+		fmt.Errorf("%w: %d, expected %d-%d", ErrInvalidPriority, priority, model.PriorityUltraCritical, model.PriorityBackground))
+}
+
+// Operation metadata limits, keeping tags small enough to index and filter
+const (
+	maxMetadataKeys        = 20
+	maxMetadataKeyLength   = 64
+	maxMetadataValueLength = 256
+)
+
+// validateMetadata rejects metadata with too many tags, empty keys or keys
+// and values over the length limits
+func validateMetadata(metadata map[string]string) error {
+	invalid := func(format string, args ...interface{}) error {
+		return pkgdriver.NewOperationError(pkgdriver.ErrorCodeInvalidRequest,
+			fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidMetadata}, args...)...))
+	}
+
+	if len(metadata) > maxMetadataKeys {
+		return invalid("%d keys, at most %d allowed", len(metadata), maxMetadataKeys)
+	}
+	for key, value := range metadata {
+		if key == "" || len(key) > maxMetadataKeyLength {
+			return invalid("key %q must be 1-%d characters", key, maxMetadataKeyLength)
+		}
+		if len(value) > maxMetadataValueLength {
+			return invalid("value of %q is longer than %d characters", key, maxMetadataValueLength)
+		}
+	}
+	return nil
+}
+
+// checkOperationSupported rejects operations a device cannot perform.
+// Weighing is only accepted by scales reporting the WEIGH capability and
+// beeping by devices reporting the BEEP capability.
+func checkOperationSupported(device *model.Device, operationType model.OperationType) error {
+	supported := true
+	switch operationType {
+	case model.OperationTypeWeigh:
+		supported = device.DeviceType == model.DeviceTypeScale && device.HasCapability(model.CapabilityWeigh)
+	case model.OperationTypeBeep:
+		supported = device.HasCapability(model.CapabilityBeep)
+	}
+	if !supported {
+		return pkgdriver.NewOperationError(pkgdriver.ErrorCodeUnsupported,
+			fmt.Errorf("device %s (%s) does not support %s", device.DeviceID, device.DeviceType, operationType))
+	}
+	return nil
+}
+
+// checkOperationPermitted rejects operations the device's allow and deny
+// lists forbid, even when the device is capable of them, and raw ESC/POS
+// print jobs unless the device opted in and the caller holds the raw print
+// scope (see scopeGranted). A print that also cuts or opens the drawer needs
+// those operations permitted as well.
+//
+// A print without a content_type is sent as the device's
+// default_content_type, so a device defaulting to ESC_POS is gated the same
+// way.
+func (os *OperationService) checkOperationPermitted(ctx context.Context, device *model.Device, operationType model.OperationType, data map[string]interface{}) error {
+	for _, operation := range append([]model.OperationType{operationType}, impliedOperations(operationType, data)...) {
+		if !pkgdriver.OperationPermitted(device.ConnectionConfig, operation) {
+			return pkgdriver.NewOperationError(pkgdriver.ErrorCodeNotPermitted,
+				fmt.Errorf("operation not permitted on this device: %s on %s", operation, device.DeviceID))
+		}
+	}
+
+	if operationType == model.OperationTypePrint && strings.EqualFold(printContentType(device, data), "ESC_POS") {
+		if !pkgdriver.RawPrintAllowed(device.ConnectionConfig) {
+			return pkgdriver.NewOperationError(pkgdriver.ErrorCodeNotPermitted,
+				fmt.Errorf("raw ESC/POS printing is not enabled on device %s", device.DeviceID))
+		}
+		if !scopeGranted(ctx, &os.config.Security, model.ScopeRawPrint) {
+			return pkgdriver.NewOperationError(pkgdriver.ErrorCodeNotPermitted,
+				fmt.Errorf("raw ESC/POS printing requires the %s scope", model.ScopeRawPrint))
+		}
+	}
+	return nil
+}
+
+// printContentType returns the content type a print is sent as: the
+// request's content_type, else the device's default_content_type, the way
+// the driver resolves it
+func printContentType(device *model.Device, data map[string]interface{}) string {
+	if contentType, ok := data["content_type"].(string); ok {
+		return contentType
+	}
+	contentType, _ := device.ConnectionConfig["default_content_type"].(string)
+	return contentType
+}
+
+// impliedOperations returns the operations a print performs through its
+// cut and open_drawer flags
+func impliedOperations(operationType model.OperationType, data map[string]interface{}) []model.OperationType {
+	if operationType != model.OperationTypePrint {
+		return nil
+	}
+
+	var implied []model.OperationType
+	if cut, _ := data["cut"].(bool); cut {
+		implied = append(implied, model.OperationTypeCut)
+	}
+	if openDrawer, _ := data["open_drawer"].(bool); openDrawer {
+		implied = append(implied, model.OperationTypeOpenDrawer)
+	}
+	return implied
+}
+
+// updateOperationError updates operation with error. The update outlives the
+// caller's context, so an operation failed by a deadline doesn't stay
+// PROCESSING.
 func (os *OperationService) updateOperationError(ctx context.Context, operation *model.DeviceOperation, err error) {
 	completedAt := time.Now()
 	operation.Status = model.OperationStatusFailed
 	operation.CompletedAt = &completedAt
 	errorMsg := err.Error()
 	operation.ErrorMessage = &errorMsg
+	if code := OperationErrorCode(err); code != "" {
+		operation.Result = model.JSONObject{"error_code": code}
+	}
 
-	if updateErr := os.operationRepo.Update(ctx, operation); updateErr != nil {
+	if updateErr := os.operationRepo.TransitionStatus(context.WithoutCancel(ctx), operation,
+		model.OperationStatusPending, model.OperationStatusProcessing,
+	); updateErr != nil {
 		os.logger.Error("Failed to update operation error", zap.Error(updateErr))
 	}
 }
 
-// getOperationTimeout returns timeout for operation type
-func (os *OperationService) getOperationTimeout(operationType model.OperationType) time.Duration {
-	switch operationType {
-	case model.OperationTypePayment:
-		return 60 * time.Second
-	case model.OperationTypePrint:
-		return 30 * time.Second
-	case model.OperationTypeScan:
-		return 30 * time.Second
-	default:
-		return os.config.Device.OperationTimeout
+// executeOnDriver executes an operation on a driver, turning a driver panic
+// into an error so the operation fails instead of staying PROCESSING
+func (os *OperationService) executeOnDriver(ctx context.Context, driverInstance pkgdriver.DeviceDriver, operation *model.DeviceOperation) (result *pkgdriver.OperationResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			os.logger.Error("Driver panicked executing operation",
+				zap.String("operation_id", operation.ID.String()),
+				zap.Any("panic", r),
+				zap.Stack("stacktrace"),
+			)
+			result, err = nil, fmt.Errorf("driver panicked: %v", r)
+		}
+	}()
+	return driverInstance.ExecuteOperation(ctx, operation)
+}
+
+// getOperationTimeout returns the timeout of an operation type on a
+// connection type from the configured timeout matrix, so fast USB devices
+// fail sooner than devices behind slow network or Bluetooth links
+func (os *OperationService) getOperationTimeout(connectionType model.ConnectionType, operationType model.OperationType) time.Duration {
+	timeouts := os.config.Device.OperationTimeouts
+	connection := strings.ToLower(string(connectionType))
+	operation := strings.ToLower(string(operationType))
+
+	for _, key := range [][2]string{
+		{connection, operation},
+		{"default", operation},
+		{connection, "default"},
+	} {
+		if timeout, ok := timeouts[key[0]][key[1]]; ok {
+			return timeout
+		}
 	}
+	return os.config.Device.OperationTimeout
 }
 
 // DTOs for Operation Service
@@ -235,10 +1142,115 @@ func (os *OperationService) getOperationTimeout(operationType model.OperationTyp
 // OperationRequest represents operation execution request
 type OperationRequest struct {
 	DeviceID      uuid.UUID               `json:"device_id"`
+	OperationType model.OperationType     `json:"operation_type"`
+	Data          map[string]interface{}  `json:"data"`
+	Priority      model.OperationPriority `json:"priority"` // 0 uses DefaultPriority
+	CorrelationID *uuid.UUID              `json:"correlation_id,omitempty"`
+
+	ParentOperationID *uuid.UUID `json:"parent_operation_id,omitempty"`
+
+	// Metadata tags the operation for client correlation, e.g. an order_id.
+	// It is stored and returned as is and never sent to the device.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// LockToken lets the operation run on a device locked by its caller
+	LockToken string `json:"-"`
+
+	// Deduplicate answers a print identical to one just sent to the device
+	// with that print's response, see device.print_dedup_window
+	Deduplicate bool `json:"-"`
+
+	// IncludeRaw adds the raw device response to the operation response
+	IncludeRaw bool `json:"-"`
+}
+
+// ReplayRequest represents operation replay request
+type ReplayRequest struct {
+	DeviceID     *uuid.UUID `json:"device_id,omitempty"` // defaults to the original device
+	AllowPayment bool       `json:"allow_payment"`
+	UserID       string     `json:"user_id,omitempty"`
+	LockToken    string     `json:"-"` // for replays on a locked device
+}
+
+// BroadcastRequest represents a branch-wide operation request
+type BroadcastRequest struct {
 	OperationType model.OperationType     `json:"operation_type"`
 	Data          map[string]interface{}  `json:"data"`
 	Priority      model.OperationPriority `json:"priority"`
+	DeviceType    *model.DeviceType       `json:"device_type,omitempty"`
+	Capability    *model.Capability       `json:"capability,omitempty"`
 	CorrelationID *uuid.UUID              `json:"correlation_id,omitempty"`
+	Metadata      map[string]string       `json:"metadata,omitempty"` // tags every device's operation
+	LockToken     string                  `json:"-"`                  // runs on devices locked by the caller
+}
+
+// BroadcastResult represents the outcome of a broadcast on a single device
+type BroadcastResult struct {
+	DeviceID     uuid.UUID  `json:"device_id"`
+	DeviceCode   string     `json:"device_code"`
+	OperationID  *uuid.UUID `json:"operation_id,omitempty"`
+	Success      bool       `json:"success"`
+	Duration     string     `json:"duration,omitempty"`
+	ErrorCode    string     `json:"error_code,omitempty"`
+	ErrorMessage string     `json:"error_message,omitempty"`
+}
+
+// BroadcastResponse represents branch-wide operation results
+type BroadcastResponse struct {
+	BranchID      uuid.UUID         `json:"branch_id"`
+	CorrelationID uuid.UUID         `json:"correlation_id"`
+	Total         int               `json:"total"`
+	Succeeded     int               `json:"succeeded"`
+	Failed        int               `json:"failed"`
+	Results       []BroadcastResult `json:"results"`
+}
+
+// ChainStep represents one operation of a chain. Inputs maps data keys to
+// earlier step results as "<step>.<result key>", e.g. "0.auth_code".
+type ChainStep struct {
+	DeviceID      uuid.UUID               `json:"device_id"`
+	OperationType model.OperationType     `json:"operation_type"`
+	Data          map[string]interface{}  `json:"data"`
+	Priority      model.OperationPriority `json:"priority"` // 0 uses DefaultPriority
+	Inputs        map[string]string       `json:"inputs,omitempty"`
+}
+
+// ChainRequest represents a chain of dependent operations
+type ChainRequest struct {
+	Steps         []ChainStep       `json:"steps"`
+	CorrelationID *uuid.UUID        `json:"correlation_id,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"` // tags every step's operation
+	LockToken     string            `json:"-"`                  // runs steps on devices locked by the caller
+}
+
+// Chain step statuses
+const (
+	ChainStepSuccess = "SUCCESS"
+	ChainStepFailed  = "FAILED"
+	ChainStepSkipped = "SKIPPED" // not run because an earlier step failed
+)
+
+// ChainStepResult represents the outcome of a single chain step
+type ChainStepResult struct {
+	Step          int                    `json:"step"`
+	DeviceID      uuid.UUID              `json:"device_id"`
+	OperationType model.OperationType    `json:"operation_type"`
+	Status        string                 `json:"status"`
+	OperationID   *uuid.UUID             `json:"operation_id,omitempty"`
+	Result        map[string]interface{} `json:"result,omitempty"`
+	Duration      string                 `json:"duration,omitempty"`
+	ErrorCode     string                 `json:"error_code,omitempty"`
+	ErrorMessage  string                 `json:"error_message,omitempty"`
+}
+
+// ChainResponse represents operation chain results
+type ChainResponse struct {
+	CorrelationID uuid.UUID         `json:"correlation_id"`
+	Total         int               `json:"total"`
+	Completed     int               `json:"completed"`
+	Success       bool              `json:"success"`
+	FailedStep    *int              `json:"failed_step,omitempty"`
+	Steps         []ChainStepResult `json:"steps"`
 }
 
 // OperationResponse represents operation execution response
@@ -248,6 +1260,40 @@ type OperationResponse struct {
 	Result       map[string]interface{} `json:"result,omitempty"`
 	Duration     string                 `json:"duration"`
 	ErrorMessage string                 `json:"error_message,omitempty"`
+	RawResponse  string                 `json:"raw_response,omitempty"` // base64, only with ?include=raw
+	Metadata     map[string]string      `json:"metadata,omitempty"`     // as sent with the request
+	Deduplicated bool                   `json:"deduplicated,omitempty"` // a duplicate print answered with the first print's response
+}
+
+// TimeSeriesRequest represents operation time series parameters
+type TimeSeriesRequest struct {
+	Interval string                 `json:"interval"` // hour or day
+	Start    *time.Time             `json:"start,omitempty"`
+	End      *time.Time             `json:"end,omitempty"`
+	DeviceID *uuid.UUID             `json:"device_id,omitempty"`
+	Source   *model.OperationSource `json:"source,omitempty"`
+}
+
+// OperationTimeSeries represents bucketed operation statistics
+type OperationTimeSeries struct {
+	Interval string                            `json:"interval"`
+	Start    time.Time                         `json:"start"`
+	End      time.Time                         `json:"end"`
+	DeviceID *uuid.UUID                        `json:"device_id,omitempty"`
+	Source   *model.OperationSource            `json:"source,omitempty"`
+	Buckets  []*repository.OperationTimeBucket `json:"buckets"`
+}
+
+// DeviceOperationSummary represents operation statistics of a device over a period
+type DeviceOperationSummary struct {
+	DeviceID          uuid.UUID  `json:"device_id"`
+	Period            string     `json:"period"`
+	Since             time.Time  `json:"since"`
+	TotalOperations   int        `json:"total_operations"`
+	SuccessRate       float64    `json:"success_rate"` // 0-1
+	ErrorCount        int        `json:"error_count"`
+	AvgResponseTimeMs float64    `json:"average_response_time_ms"`
+	LastOperation     *time.Time `json:"last_operation,omitempty"`
 }
 
 // OperationFilter represents operation listing filters
@@ -257,6 +1303,9 @@ type OperationFilter struct {
 	Status        *model.OperationStatus   `json:"status,omitempty"`
 	Priority      *model.OperationPriority `json:"priority,omitempty"`
 	CorrelationID *uuid.UUID               `json:"correlation_id,omitempty"`
+	ErrorContains *string                  `json:"error_contains,omitempty"` // case-insensitive match on error_message
+	Source        *model.OperationSource   `json:"source,omitempty"`
+	Metadata      map[string]string        `json:"metadata,omitempty"` // operations carrying all of these tags
 	StartDate     *time.Time               `json:"start_date,omitempty"`
 	EndDate       *time.Time               `json:"end_date,omitempty"`
 	Page          int                      `json:"page"`
@@ -273,6 +1322,9 @@ func (of *OperationFilter) toRepoFilter() *repository.OperationFilter {
 		Status:        of.Status,
 		Priority:      of.Priority,
 		CorrelationID: of.CorrelationID,
+		ErrorContains: of.ErrorContains,
+		Source:        of.Source,
+		Metadata:      of.Metadata,
 		StartDate:     of.StartDate,
 		EndDate:       of.EndDate,
 		Page:          of.Page,
@@ -0,0 +1,134 @@
+// internal/service/operation_service_test.go
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"device-service/internal/config"
+	"device-service/internal/model"
+	"device-service/internal/repository"
+	"device-service/internal/utils"
+	pkgdriver "device-service/pkg/driver"
+)
+
+// fakeOperationRepository keeps operations in memory and applies status
+// transitions conditionally, like the database does
+type fakeOperationRepository struct {
+	repository.OperationRepository
+
+	mu         sync.Mutex
+	operations map[uuid.UUID]model.DeviceOperation
+	// stale, when set, is returned by GetByID instead of the stored
+	// operation, as if it changed after being read
+	stale *model.DeviceOperation
+}
+
+func (r *fakeOperationRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.DeviceOperation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stale != nil {
+		operation := *r.stale
+		return &operation, nil
+	}
+	operation, ok := r.operations[id]
+	if !ok {
+		return nil, errors.New("operation not found")
+	}
+	return &operation, nil
+}
+
+func (r *fakeOperationRepository) TransitionStatus(ctx context.Context, operation *model.DeviceOperation, from ...model.OperationStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.operations[operation.ID]
+	if !ok {
+		return errors.New("operation not found")
+	}
+	for _, status := range from {
+		if stored.Status == status {
+			r.operations[operation.ID] = *operation
+			return nil
+		}
+	}
+	return repository.ErrStatusConflict
+}
+
+func TestCheckOperationPermittedRawPrint(t *testing.T) {
+	rawScope := model.WithScopes(context.Background(), []string{model.ScopeRawPrint})
+	allowRaw := map[string]interface{}{pkgdriver.AllowRawPrintKey: true}
+	rawPrint := map[string]interface{}{"content_type": "ESC_POS"}
+
+	tests := []struct {
+		name    string
+		authOff bool
+		ctx     context.Context
+		config  map[string]interface{}
+		data    map[string]interface{}
+		wantErr bool
+	}{
+		{name: "text print", ctx: context.Background(), data: map[string]interface{}{"content": "x"}},
+		{name: "raw print not enabled", ctx: rawScope, data: map[string]interface{}{"content_type": "esc_pos"}, wantErr: true},
+		{name: "raw print without scope", ctx: context.Background(), config: map[string]interface{}{pkgdriver.AllowRawPrintKey: true}, data: map[string]interface{}{"content_type": "ESC_POS"}, wantErr: true},
+		{name: "raw print permitted", ctx: rawScope, config: map[string]interface{}{pkgdriver.AllowRawPrintKey: true}, data: map[string]interface{}{"content_type": "ESC_POS"}},
+		{name: "raw default content type", ctx: context.Background(), config: map[string]interface{}{"default_content_type": "ESC_POS"}, data: map[string]interface{}{"content": "x"}, wantErr: true},
+		{name: "text overrides raw default", ctx: context.Background(), config: map[string]interface{}{"default_content_type": "ESC_POS"}, data: map[string]interface{}{"content_type": "TEXT"}},
+		{name: "auth disabled", authOff: true, ctx: context.Background(), config: allowRaw, data: rawPrint},
+		{name: "auth disabled, raw print not enabled", authOff: true, ctx: context.Background(), data: rawPrint, wantErr: true},
+		{name: "offline sync", ctx: model.WithOperationSource(context.Background(), model.OperationSourceOfflineSync), config: allowRaw, data: rawPrint},
+		{name: "recovery", ctx: model.WithOperationSource(context.Background(), model.OperationSourceRecovery), config: allowRaw, data: rawPrint},
+		{name: "replay without scope", ctx: model.WithOperationSource(context.Background(), model.OperationSourceReplay), config: allowRaw, data: rawPrint, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os := &OperationService{config: &config.Config{Security: config.SecurityConfig{AuthEnabled: !tt.authOff}}}
+			device := &model.Device{DeviceID: "printer-1", ConnectionConfig: tt.config}
+			err := os.checkOperationPermitted(tt.ctx, device, model.OperationTypePrint, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkOperationPermitted() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCancelOperation(t *testing.T) {
+	tests := []struct {
+		name       string
+		stored     model.OperationStatus
+		read       model.OperationStatus // status the operation had when read
+		wantErr    error
+		wantStatus model.OperationStatus
+	}{
+		{name: "pending", stored: model.OperationStatusPending, read: model.OperationStatusPending, wantStatus: model.OperationStatusCancelled},
+		{name: "processing", stored: model.OperationStatusProcessing, read: model.OperationStatusProcessing, wantStatus: model.OperationStatusCancelled},
+		{name: "finished", stored: model.OperationStatusSuccess, read: model.OperationStatusSuccess, wantErr: ErrStatusConflict, wantStatus: model.OperationStatusSuccess},
+		{name: "finished after read", stored: model.OperationStatusSuccess, read: model.OperationStatusProcessing, wantErr: ErrStatusConflict, wantStatus: model.OperationStatusSuccess},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operationID := uuid.New()
+			repo := &fakeOperationRepository{operations: map[uuid.UUID]model.DeviceOperation{
+				operationID: {ID: operationID, Status: tt.stored},
+			}}
+			if tt.read != tt.stored {
+				repo.stale = &model.DeviceOperation{ID: operationID, Status: tt.read}
+			}
+			os := &OperationService{operationRepo: repo, logger: utils.NewServiceLogger(zap.NewNop(), "operation-service")}
+
+			err := os.CancelOperation(context.Background(), operationID, "test")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CancelOperation() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := repo.operations[operationID].Status; got != tt.wantStatus {
+				t.Errorf("stored status = %s, want %s", got, tt.wantStatus)
+			}
+		})
+	}
+}
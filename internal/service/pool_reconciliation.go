@@ -0,0 +1,45 @@
+// internal/service/pool_reconciliation.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"device-service/internal/model"
+)
+
+// activeDeviceStatuses are the statuses whose devices keep their cached
+// driver. Offline and maintenance devices, and deleted ones, don't.
+var activeDeviceStatuses = []model.DeviceStatus{
+	model.DeviceStatusOnline,
+	model.DeviceStatusConnecting,
+	model.DeviceStatusError, // still reconnecting through its driver
+}
+
+// ReconcileDriverPool closes cached drivers whose device was deleted or set
+// offline or into maintenance without going through the service, e.g. by
+// another instance or straight in the database, so their connections don't
+// leak. It returns how many drivers were closed.
+func (ds *DeviceService) ReconcileDriverPool(ctx context.Context) (int, error) {
+	asOf := time.Now()
+
+	active := make(map[string]bool)
+	for _, status := range activeDeviceStatuses {
+		devices, err := ds.deviceRepo.ListByStatus(ctx, status)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list %s devices: %w", status, err)
+		}
+		for _, device := range devices {
+			active[device.DeviceID] = true
+		}
+	}
+
+	evicted := ds.driverPool.EvictInactive(active, asOf)
+	if evicted > 0 {
+		ds.logger.Info("Closed drivers of inactive devices", zap.Int("evicted", evicted))
+	}
+	return evicted, nil
+}
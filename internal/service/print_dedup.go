@@ -0,0 +1,120 @@
+// internal/service/print_dedup.go
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"device-service/internal/model"
+)
+
+// PrintForceKey is the print operation data flag that prints even when an
+// identical print was just sent to the device
+const PrintForceKey = "force"
+
+// printDedup suppresses identical prints sent to a device within a short
+// window, e.g. from a double tap on a POS print button. A duplicate arriving
+// while the first print still runs waits for it; either way it gets the
+// first print's response instead of printing again.
+type printDedup struct {
+	mu      sync.Mutex
+	entries map[string]*printDedupEntry
+}
+
+// printDedupEntry is a print that identical prints are answered with
+type printDedupEntry struct {
+	done      chan struct{} // closed when the print finished
+	response  *OperationResponse
+	expiresAt time.Time // set once done
+}
+
+func newPrintDedup() *printDedup {
+	return &printDedup{entries: make(map[string]*printDedupEntry)}
+}
+
+// printDedupKey returns the key identical prints to a device share, or an
+// empty key when the request isn't subject to deduplication. Only direct
+// print requests are; chain steps, broadcasts and replays print what they
+// were asked to, and forced prints skip it too. The lock token is part of
+// the key, so a print never gets the response of another lock holder's.
+func printDedupKey(req *OperationRequest) string {
+	if req.OperationType != model.OperationTypePrint || !req.Deduplicate {
+		return ""
+	}
+	if force, _ := req.Data[PrintForceKey].(bool); force {
+		return ""
+	}
+
+	// Maps marshal with sorted keys, so equal payloads hash equally
+	payload, err := json.Marshal(req.Data)
+	if err != nil {
+		return ""
+	}
+	hash := sha256.New()
+	hash.Write(payload)
+	hash.Write([]byte{0})
+	hash.Write([]byte(req.LockToken))
+	return req.DeviceID.String() + ":" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// do runs print unless an identical print is running or finished within the
+// window, whose response is then returned instead. Failed prints don't
+// suppress a retry.
+func (pd *printDedup) do(ctx context.Context, key string, window time.Duration, print func() (*OperationResponse, error)) (*OperationResponse, error) {
+	for {
+		pd.mu.Lock()
+		now := time.Now()
+		for entryKey, entry := range pd.entries {
+			if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+				delete(pd.entries, entryKey)
+			}
+		}
+
+		entry, exists := pd.entries[key]
+		if !exists {
+			entry = &printDedupEntry{done: make(chan struct{})}
+			pd.entries[key] = entry
+			pd.mu.Unlock()
+			return pd.run(key, entry, window, print)
+		}
+		pd.mu.Unlock()
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if entry.response != nil {
+			duplicate := *entry.response
+			duplicate.Deduplicated = true
+			return &duplicate, nil
+		}
+		// The first print failed and was forgotten; try again
+	}
+}
+
+// run executes the print an entry stands for and keeps its response for the
+// window. The entry is settled even when print panics, so duplicates waiting
+// on it retry instead of blocking.
+func (pd *printDedup) run(key string, entry *printDedupEntry, window time.Duration, print func() (*OperationResponse, error)) (response *OperationResponse, err error) {
+	printed := false
+	defer func() {
+		pd.mu.Lock()
+		if printed && err == nil {
+			entry.response = response
+			entry.expiresAt = time.Now().Add(window)
+		} else {
+			delete(pd.entries, key)
+		}
+		pd.mu.Unlock()
+		close(entry.done)
+	}()
+
+	response, err = print()
+	printed = true
+	return response, err
+}
@@ -0,0 +1,116 @@
+// internal/service/print_dedup_test.go
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPrintDedupConcurrentIdenticalPrints(t *testing.T) {
+	pd := newPrintDedup()
+	operationID := uuid.New()
+	release := make(chan struct{})
+	var prints atomic.Int32
+
+	print := func() (*OperationResponse, error) {
+		prints.Add(1)
+		<-release
+		return &OperationResponse{OperationID: operationID, Success: true}, nil
+	}
+
+	const requests = 5
+	responses := make([]*OperationResponse, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			response, err := pd.do(context.Background(), "printer:key", time.Minute, print)
+			if err != nil {
+				t.Errorf("do() error = %v", err)
+			}
+			responses[i] = response
+		}(i)
+	}
+
+	// Let every duplicate reach the running print before it finishes
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := prints.Load(); got != 1 {
+		t.Fatalf("printed %d times, want 1", got)
+	}
+	deduplicated := 0
+	for _, response := range responses {
+		if response == nil || response.OperationID != operationID {
+			t.Fatalf("response = %+v, want the first print's", response)
+		}
+		if response.Deduplicated {
+			deduplicated++
+		}
+	}
+	if deduplicated != requests-1 {
+		t.Errorf("%d responses deduplicated, want %d", deduplicated, requests-1)
+	}
+}
+
+func TestPrintDedupRetriesFailedPrint(t *testing.T) {
+	pd := newPrintDedup()
+
+	_, err := pd.do(context.Background(), "printer:key", time.Minute, func() (*OperationResponse, error) {
+		return nil, errors.New("paper out")
+	})
+	if err == nil {
+		t.Fatal("do() error = nil, want the print's error")
+	}
+
+	printed := false
+	response, err := pd.do(context.Background(), "printer:key", time.Minute, func() (*OperationResponse, error) {
+		printed = true
+		return &OperationResponse{Success: true}, nil
+	})
+	if err != nil {
+		t.Fatalf("do() error = %v", err)
+	}
+	if !printed || response.Deduplicated {
+		t.Error("a print after a failed one was suppressed")
+	}
+}
+
+func TestPrintDedupRecoversFromPanickingPrint(t *testing.T) {
+	pd := newPrintDedup()
+	started := make(chan struct{})
+	panicked := make(chan struct{})
+
+	go func() {
+		defer close(panicked)
+		defer func() { _ = recover() }()
+		_, _ = pd.do(context.Background(), "printer:key", time.Minute, func() (*OperationResponse, error) {
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+			panic("driver panic")
+		})
+	}()
+	<-started
+
+	// A duplicate waiting on the panicking print retries once it settles
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	response, err := pd.do(ctx, "printer:key", time.Minute, func() (*OperationResponse, error) {
+		return &OperationResponse{Success: true}, nil
+	})
+	if err != nil {
+		t.Fatalf("do() error = %v", err)
+	}
+	if response.Deduplicated {
+		t.Error("duplicate answered with the panicked print's response")
+	}
+	<-panicked
+}
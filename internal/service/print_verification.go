@@ -0,0 +1,93 @@
+// internal/service/print_verification.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"device-service/internal/model"
+	pkgdriver "device-service/pkg/driver"
+)
+
+// ErrPrintNotVerified is returned when a print asked to be verified and the
+// printer can't confirm it produced the output
+var ErrPrintNotVerified = errors.New("print not verified")
+
+// PrintVerifyKey is the print operation data flag asking the service to
+// read back the printer status after printing, e.g. for legally required
+// receipts
+const PrintVerifyKey = "verify"
+
+// printVerifyStatusTimeout bounds the status readback after a printer failed
+// to drain, when the operation's own deadline may already be gone
+const printVerifyStatusTimeout = 5 * time.Second
+
+// printVerificationRequested reports whether an operation is a print asking
+// to be verified
+func printVerificationRequested(req *OperationRequest) bool {
+	if req.OperationType != model.OperationTypePrint {
+		return false
+	}
+	verify, _ := req.Data[PrintVerifyKey].(bool)
+	return verify
+}
+
+// checkPrintVerifiable rejects a verified print before anything is printed
+// when the printer can't read back its status
+func checkPrintVerifiable(driverInstance pkgdriver.DeviceDriver) error {
+	if _, ok := driverInstance.(pkgdriver.PrinterStatusReporter); ok {
+		for _, capability := range driverInstance.GetCapabilities() {
+			if capability == model.CapabilityStatusReadback {
+				return nil
+			}
+		}
+	}
+	return pkgdriver.NewOperationError(pkgdriver.ErrorCodeUnsupported,
+		fmt.Errorf("%w: printer can't read back its status", ErrPrintNotVerified))
+}
+
+// verifyPrint waits for the printer to work through the print and reads
+// back its status. It fails when the status shows the content can't have
+// printed, with the code of the printer's fault (e.g. PAPER_OUT).
+func verifyPrint(ctx context.Context, driverInstance pkgdriver.DeviceDriver) (*pkgdriver.PrinterStatus, error) {
+	var drainErr error
+	if drainer, ok := driverInstance.(pkgdriver.PrintDrainer); ok {
+		drainErr = drainer.WaitDrained(ctx)
+	}
+
+	// A printer stopped by a fault never drains, but its status says why
+	statusCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), printVerifyStatusTimeout)
+	defer cancel()
+
+	status, err := driverInstance.(pkgdriver.PrinterStatusReporter).GetPrinterStatus(statusCtx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read back printer status: %w", ErrPrintNotVerified, err)
+	}
+
+	if code, reason := printStatusFault(status); code != "" {
+		return status, pkgdriver.NewOperationError(code, fmt.Errorf("%w: %s", ErrPrintNotVerified, reason))
+	}
+	if drainErr != nil {
+		return status, fmt.Errorf("%w: %w", ErrPrintNotVerified, drainErr)
+	}
+	return status, nil
+}
+
+// printStatusFault returns the printer fault that keeps content from
+// printing, or an empty code when there is none. A paper near end sensor
+// alone doesn't stop printing.
+func printStatusFault(status *pkgdriver.PrinterStatus) (pkgdriver.ErrorCode, string) {
+	switch {
+	case !status.PaperPresent:
+		return pkgdriver.ErrorCodePaperOut, "printer is out of paper"
+	case status.CoverOpen:
+		return pkgdriver.ErrorCodeCoverOpen, "printer cover is open"
+	case status.CutterError:
+		return pkgdriver.ErrorCodeCutterError, "printer reported a cutter error"
+	case !status.Online:
+		return pkgdriver.ErrorCodeDeviceOffline, "printer is offline"
+	}
+	return "", ""
+}
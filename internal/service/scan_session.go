@@ -0,0 +1,160 @@
+// internal/service/scan_session.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"device-service/internal/model"
+	"device-service/pkg/driver"
+)
+
+var (
+	// ErrScanSessionUnsupported is returned when a device's driver can't
+	// stream scans
+	ErrScanSessionUnsupported = errors.New("scan session not supported")
+
+	// ErrScanSessionActive is returned when the device already streams to
+	// another scan session
+	ErrScanSessionActive = errors.New("scan session already active")
+)
+
+// Reasons a scan session ends
+const (
+	ScanSessionEnded       = "ended"        // the client ended it or went away
+	ScanSessionIdleTimeout = "idle_timeout" // no scan within the idle timeout
+	ScanSessionMaxDuration = "max_duration" // ran for the maximum duration
+	ScanSessionFailed      = "failed"       // the scanner failed
+)
+
+var (
+	errScanSessionIdle    = errors.New(ScanSessionIdleTimeout)
+	errScanSessionExpired = errors.New(ScanSessionMaxDuration)
+)
+
+// ScanSessionRequest represents continuous scan session options
+type ScanSessionRequest struct {
+	IdleTimeout time.Duration `json:"idle_timeout"` // 0 uses the configured default
+	LockToken   string        `json:"-"`            // runs on a device locked by the caller
+}
+
+// ScanSessionHandlers receive the events of a running scan session. OnStart
+// runs before the first read and OnScan on the driver's read path; neither
+// may block.
+type ScanSessionHandlers struct {
+	OnStart func(idleTimeout, maxDuration time.Duration)
+	OnScan  func(*driver.ScanResult)
+}
+
+// ScanSessionSummary describes how a scan session went
+type ScanSessionSummary struct {
+	DeviceID string  `json:"device_id"`
+	Scans    int     `json:"scans"`
+	Reason   string  `json:"reason"` // ended, idle_timeout, max_duration or failed
+	Duration float64 `json:"duration_seconds"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// RunScanSession streams every barcode a scanner reads to the handlers until
+// ctx is done, no scan arrives within the idle timeout or the session
+// reaches its maximum duration. The session holds the device's operation
+// slot for its whole run, so operations sent to the scanner meanwhile queue
+// behind it, and it starts on a locked device only with the lock token.
+// Errors are returned only when the session can't start; failures of a
+// running session are reported in the summary.
+func (os *OperationService) RunScanSession(ctx context.Context, deviceID string, req *ScanSessionRequest, handlers ScanSessionHandlers) (*ScanSessionSummary, error) {
+	sessionConfig := os.config.Device.ScanSession
+	idleTimeout := req.IdleTimeout
+	if idleTimeout <= 0 || idleTimeout > sessionConfig.MaxDuration {
+		idleTimeout = sessionConfig.IdleTimeout
+	}
+
+	device, err := os.deviceRepo.GetByDeviceID(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("device not found: %w", err)
+	}
+	if !device.HasCapability(model.CapabilityScan) {
+		return nil, fmt.Errorf("%w: device has no %s capability", ErrScanSessionUnsupported, model.CapabilityScan)
+	}
+	if err := os.checkOperationPermitted(ctx, device, model.OperationTypeScan, nil); err != nil {
+		return nil, err
+	}
+
+	os.scanSessionMu.Lock()
+	if os.scanSessions[device.DeviceID] {
+		os.scanSessionMu.Unlock()
+		return nil, ErrScanSessionActive
+	}
+	os.scanSessions[device.DeviceID] = true
+	os.scanSessionMu.Unlock()
+	defer func() {
+		os.scanSessionMu.Lock()
+		delete(os.scanSessions, device.DeviceID)
+		os.scanSessionMu.Unlock()
+	}()
+
+	// Wait for the operations queued on the device, like any operation
+	leave, err := os.queues.enter(ctx, device.ID, uuid.New(), req.LockToken, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start scan session: %w", err)
+	}
+	defer leave()
+
+	// Holding the driver keeps the pool from evicting it mid-session
+	driverInstance, release, err := os.driverPool.Acquire(ctx, device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire driver: %w", err)
+	}
+	streamer, ok := driverInstance.(driver.ScanStreamer)
+	if !ok {
+		release(nil)
+		return nil, ErrScanSessionUnsupported
+	}
+
+	sessionCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	maxTimer := time.AfterFunc(sessionConfig.MaxDuration, func() { cancel(errScanSessionExpired) })
+	defer maxTimer.Stop()
+	idleTimer := time.AfterFunc(idleTimeout, func() { cancel(errScanSessionIdle) })
+	defer idleTimer.Stop()
+
+	os.logger.Info("Scan session started",
+		zap.String("device_id", device.DeviceID),
+		zap.Duration("idle_timeout", idleTimeout),
+	)
+	if handlers.OnStart != nil {
+		handlers.OnStart(idleTimeout, sessionConfig.MaxDuration)
+	}
+
+	startedAt := time.Now()
+	summary := &ScanSessionSummary{DeviceID: device.DeviceID, Reason: ScanSessionEnded}
+	err = streamer.StreamScans(sessionCtx, func(result *driver.ScanResult) {
+		idleTimer.Reset(idleTimeout)
+		summary.Scans++
+		handlers.OnScan(result)
+	})
+	release(err)
+
+	switch cause := context.Cause(sessionCtx); {
+	case err != nil && sessionCtx.Err() == nil:
+		summary.Reason = ScanSessionFailed
+		summary.Error = err.Error()
+	case errors.Is(cause, errScanSessionIdle):
+		summary.Reason = ScanSessionIdleTimeout
+	case errors.Is(cause, errScanSessionExpired):
+		summary.Reason = ScanSessionMaxDuration
+	}
+	summary.Duration = time.Since(startedAt).Seconds()
+
+	os.logger.Info("Scan session ended",
+		zap.String("device_id", device.DeviceID),
+		zap.String("reason", summary.Reason),
+		zap.Int("scans", summary.Scans),
+	)
+	return summary, nil
+}
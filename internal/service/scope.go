@@ -0,0 +1,28 @@
+// internal/service/scope.go
+package service
+
+import (
+	"context"
+
+	"device-service/internal/config"
+	"device-service/internal/model"
+)
+
+// scopeGranted reports whether the caller behind ctx holds scope. Scopes come
+// from bearer tokens, so with auth disabled every caller holds them, as on
+// the admin routes.
+//
+// Offline sync and recovery run operations for the service rather than for
+// a caller: the offline queue is filled outside the API, and recovered
+// operations passed these checks when first started. They hold every scope,
+// leaving them gated by the device's own policy.
+func scopeGranted(ctx context.Context, security *config.SecurityConfig, scope string) bool {
+	if !security.AuthEnabled {
+		return true
+	}
+	switch model.OperationSourceFromContext(ctx) {
+	case model.OperationSourceOfflineSync, model.OperationSourceRecovery:
+		return true
+	}
+	return model.HasScope(ctx, scope)
+}
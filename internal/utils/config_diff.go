@@ -0,0 +1,96 @@
+// internal/utils/config_diff.go
+package utils
+
+import (
+	"reflect"
+	"sort"
+
+	"device-service/internal/model"
+)
+
+// Config change kinds
+const (
+	ConfigKeyAdded   = "added"
+	ConfigKeyRemoved = "removed"
+	ConfigKeyChanged = "changed"
+)
+
+// ConfigChange represents one key that differs between two configurations.
+// Nested keys are joined with dots, e.g. "print_settings.density".
+type ConfigChange struct {
+	Key      string      `json:"key"`
+	Change   string      `json:"change"` // added, removed or changed
+	OldValue interface{} `json:"old_value,omitempty"`
+	NewValue interface{} `json:"new_value,omitempty"`
+}
+
+// DiffConfig returns the keys added, removed or changed from oldConfig to
+// newConfig, sorted by key. Nested objects are compared key by key; values
+// of secret keys are redacted, so a changed secret shows up without its
+// value.
+func DiffConfig(oldConfig, newConfig map[string]interface{}) []ConfigChange {
+	changes := []ConfigChange{}
+	diffConfig("", oldConfig, newConfig, false, &changes)
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// diffConfig appends the differences below prefix to changes. secret is set
+// once a secret key was passed on the way down.
+func diffConfig(prefix string, oldConfig, newConfig map[string]interface{}, secret bool, changes *[]ConfigChange) {
+	for key, oldValue := range oldConfig {
+		path := prefix + key
+		isSecret := secret || model.IsSecretConfigKey(key)
+
+		newValue, ok := newConfig[key]
+		if !ok {
+			*changes = append(*changes, ConfigChange{
+				Key:      path,
+				Change:   ConfigKeyRemoved,
+				OldValue: diffValue(oldValue, isSecret),
+			})
+			continue
+		}
+
+		oldNested, oldIsObject := oldValue.(map[string]interface{})
+		newNested, newIsObject := newValue.(map[string]interface{})
+		if oldIsObject && newIsObject {
+			diffConfig(path+".", oldNested, newNested, isSecret, changes)
+			continue
+		}
+
+		if !reflect.DeepEqual(oldValue, newValue) {
+			*changes = append(*changes, ConfigChange{
+				Key:      path,
+				Change:   ConfigKeyChanged,
+				OldValue: diffValue(oldValue, isSecret),
+				NewValue: diffValue(newValue, isSecret),
+			})
+		}
+	}
+
+	for key, newValue := range newConfig {
+		if _, ok := oldConfig[key]; ok {
+			continue
+		}
+		*changes = append(*changes, ConfigChange{
+			Key:      prefix + key,
+			Change:   ConfigKeyAdded,
+			NewValue: diffValue(newValue, secret || model.IsSecretConfigKey(key)),
+		})
+	}
+}
+
+// diffValue returns value as it may appear in a diff
+func diffValue(value interface{}, secret bool) interface{} {
+	if secret && value != nil {
+		return model.RedactedValue
+	}
+	if nested, ok := value.(map[string]interface{}); ok {
+		return map[string]interface{}(model.JSONObject(nested).RedactSecrets())
+	}
+	return value
+}
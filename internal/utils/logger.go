@@ -12,6 +12,7 @@ import (
 	"gopkg.in/natefinch/lumberjack.v2"
 
 	"device-service/internal/config"
+	"device-service/internal/model"
 )
 
 // LoggerManager manages application logging
@@ -174,20 +175,24 @@ type DeviceLogger struct {
 	brand      string
 }
 
-// NewDeviceLogger creates a device-specific logger
-func NewDeviceLogger(baseLogger *zap.Logger, deviceID, deviceType, brand string) *DeviceLogger {
+// NewDeviceLogger creates a device-specific logger. Its entries carry how the
+// device is connected and a hash of its redacted connection config, which
+// changes in the logs where the device was reconfigured.
+func NewDeviceLogger(baseLogger *zap.Logger, device *model.Device) *DeviceLogger {
 	logger := baseLogger.With(
-		zap.String("device_id", deviceID),
-		zap.String("device_type", deviceType),
-		zap.String("brand", brand),
+		zap.String("device_id", device.DeviceID),
+		zap.String("device_type", string(device.DeviceType)),
+		zap.String("brand", string(device.Brand)),
+		zap.String("connection_type", string(device.ConnectionType)),
+		zap.String("config_hash", device.ConnectionConfig.RedactedHash()),
 		zap.String("component", "device"),
 	)
 
 	return &DeviceLogger{
 		Logger:     logger,
-		deviceID:   deviceID,
-		deviceType: deviceType,
-		brand:      brand,
+		deviceID:   device.DeviceID,
+		deviceType: string(device.DeviceType),
+		brand:      string(device.Brand),
 	}
 }
 
@@ -414,13 +419,16 @@ func (al *AuditLogger) LogDeviceRegistration(deviceID, deviceType, brand, userID
 	)
 }
 
-// LogDeviceConfiguration logs device configuration changes
-func (al *AuditLogger) LogDeviceConfiguration(deviceID, userID string, oldConfig, newConfig interface{}) {
+// LogDeviceConfiguration logs device configuration changes as a diff of the
+// keys that changed, with secret values redacted
+func (al *AuditLogger) LogDeviceConfiguration(deviceID, userID string, oldConfig, newConfig map[string]interface{}) {
+	changes := DiffConfig(oldConfig, newConfig)
+
 	al.logger.Info("Device configuration changed",
 		zap.String("device_id", deviceID),
 		zap.String("user_id", userID),
-		zap.Any("old_config", oldConfig),
-		zap.Any("new_config", newConfig),
+		zap.Int("change_count", len(changes)),
+		zap.Any("changes", changes),
 		zap.String("action", "configure_device"),
 	)
 }
@@ -437,6 +445,19 @@ func (al *AuditLogger) LogPaymentTransaction(deviceID, transactionID string, amo
 	)
 }
 
+// LogOperationRecovery logs how an operation interrupted by a service
+// restart was recovered
+func (al *AuditLogger) LogOperationRecovery(operationID, deviceID, operationType, previousStatus, recoveryAction string) {
+	al.logger.Info("Operation recovered after restart",
+		zap.String("operation_id", operationID),
+		zap.String("device_id", deviceID),
+		zap.String("operation_type", operationType),
+		zap.String("previous_status", previousStatus),
+		zap.String("recovery_action", recoveryAction),
+		zap.String("action", "recover_operation"),
+	)
+}
+
 // SecurityLogger provides security-related logging
 type SecurityLogger struct {
 	logger *zap.Logger
@@ -526,6 +547,21 @@ func LogPanic(logger *zap.Logger) {
 		)
 	}
 }
+
+// RecoverPanic logs and recovers from panics without exiting the process.
+// Use it as a deferred call at the top of goroutines so a single fault is
+// isolated instead of crashing the service.
+func RecoverPanic(logger *zap.Logger, fields ...zap.Field) {
+	if r := recover(); r != nil {
+		allFields := append([]zap.Field{
+			zap.Any("panic", r),
+			zap.Stack("stacktrace"),
+		}, fields...)
+		logger.Error("Recovered from panic", allFields...)
+	}
+}
+
+// CloseLogger flushes any buffered log entries
 func CloseLogger(logger *zap.Logger) error {
 	return logger.Sync()
 }
@@ -2,12 +2,23 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
 )
 
+// MIMEMsgPack is the content type of msgpack encoded responses
+const MIMEMsgPack = "application/msgpack"
+
+// ResponseEncodingKey is the context key holding the negotiated response
+// content type; responses are JSON unless it is MIMEMsgPack
+const ResponseEncodingKey = "response_encoding"
+
 // APIResponse represents standard API response structure
 type APIResponse struct {
 	Success   bool        `json:"success"`
@@ -20,9 +31,10 @@ type APIResponse struct {
 
 // APIError represents error information
 type APIError struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
-	Details string `json:"details,omitempty"`
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Details string       `json:"details,omitempty"`
+	Fields  []FieldError `json:"fields,omitempty"` // invalid request body fields
 }
 
 // SuccessResponse sends a successful response
@@ -35,19 +47,36 @@ func SuccessResponse(c *gin.Context, statusCode int, message string, data interf
 		RequestID: getRequestID(c),
 	}
 
-	c.JSON(statusCode, response)
+	writeResponse(c, statusCode, response)
 }
 
 // ErrorResponse sends an error response
 func ErrorResponse(c *gin.Context, statusCode int, message string, err error) {
+	ErrorResponseWithCode(c, statusCode, getErrorCode(statusCode), message, err)
+}
+
+// ErrorResponseWithCode sends an error response with a specific error code,
+// e.g. a device error code instead of the HTTP derived one
+func ErrorResponseWithCode(c *gin.Context, statusCode int, code string, message string, err error) {
+	// A body cut off by the body size limit fails binding; report the limit
+	var maxBytesErr *http.MaxBytesError
+	if statusCode == http.StatusBadRequest && errors.As(err, &maxBytesErr) {
+		statusCode = http.StatusRequestEntityTooLarge
+		code = getErrorCode(statusCode)
+		message = "Request body too large"
+	}
+
 	apiError := &APIError{
-		Code:    getErrorCode(statusCode),
+		Code:    code,
 		Message: message,
 	}
 
 	if err != nil {
 		apiError.Details = err.Error()
 	}
+	if statusCode == http.StatusBadRequest {
+		apiError.Fields = FieldErrors(err)
+	}
 
 	response := APIResponse{
 		Success:   false,
@@ -57,7 +86,31 @@ func ErrorResponse(c *gin.Context, statusCode int, message string, err error) {
 		RequestID: getRequestID(c),
 	}
 
-	c.JSON(statusCode, response)
+	writeResponse(c, statusCode, response)
+}
+
+// ErrorResponseWithData sends an error response with a specific error code
+// and data telling the client how to recover, e.g. supported alternatives
+func ErrorResponseWithData(c *gin.Context, statusCode int, code string, message string, err error, data interface{}) {
+	apiError := &APIError{
+		Code:    code,
+		Message: message,
+	}
+
+	if err != nil {
+		apiError.Details = err.Error()
+	}
+
+	response := APIResponse{
+		Success:   false,
+		Message:   message,
+		Data:      data,
+		Error:     apiError,
+		Timestamp: time.Now(),
+		RequestID: getRequestID(c),
+	}
+
+	writeResponse(c, statusCode, response)
 }
 
 // ValidationErrorResponse sends validation error response
@@ -76,7 +129,66 @@ func ValidationErrorResponse(c *gin.Context, errors map[string]string) {
 		RequestID: getRequestID(c),
 	}
 
-	c.JSON(http.StatusBadRequest, response)
+	writeResponse(c, http.StatusBadRequest, response)
+}
+
+// writeResponse encodes a response as negotiated for the request
+func writeResponse(c *gin.Context, statusCode int, response APIResponse) {
+	if c.GetString(ResponseEncodingKey) == MIMEMsgPack {
+		if body, err := encodeMsgPack(response); err == nil {
+			c.Data(statusCode, MIMEMsgPack, body)
+			return
+		}
+	}
+	c.JSON(statusCode, response)
+}
+
+// msgpackHandle writes the current msgpack spec (str8, bin) rather than the
+// legacy raw format
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+// encodeMsgPack encodes v as msgpack with exactly the fields and values of
+// its JSON encoding. Going through JSON keeps custom JSON marshalers, such as
+// the redaction of device secrets, in effect for msgpack clients too.
+func encodeMsgPack(v interface{}) ([]byte, error) {
+	jsonBody, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(jsonBody))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := codec.NewEncoder(&buf, msgpackHandle).Encode(msgpackNumbers(generic)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// msgpackNumbers turns decoded JSON numbers into msgpack integers or floats
+func msgpackNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = msgpackNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = msgpackNumbers(item)
+		}
+	}
+	return value
 }
 
 // getRequestID extracts request ID from context
@@ -100,6 +212,8 @@ func getErrorCode(statusCode int) string {
 		return "NOT_FOUND"
 	case http.StatusConflict:
 		return "CONFLICT"
+	case http.StatusRequestEntityTooLarge:
+		return "PAYLOAD_TOO_LARGE"
 	case http.StatusTooManyRequests:
 		return "RATE_LIMIT_EXCEEDED"
 	case http.StatusInternalServerError:
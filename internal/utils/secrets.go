@@ -0,0 +1,137 @@
+// internal/utils/secrets.go
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"device-service/internal/model"
+)
+
+// encryptedPrefix marks a connection config value encrypted by ConfigCipher
+const encryptedPrefix = "enc:v1:"
+
+// ConfigCipher encrypts secret connection config values at rest with AES-GCM.
+// Keys are selected by model.IsSecretConfigKey.
+type ConfigCipher struct {
+	aead cipher.AEAD
+}
+
+// NewConfigCipher creates a cipher from a passphrase; the AES-256 key is
+// derived with SHA-256
+func NewConfigCipher(passphrase string) (*ConfigCipher, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("encryption key is empty")
+	}
+
+	key := sha256.Sum256([]byte(passphrase))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	return &ConfigCipher{aead: aead}, nil
+}
+
+// EncryptConfig returns a copy of config with secret values encrypted.
+// Values that are already encrypted are kept as is.
+func (cc *ConfigCipher) EncryptConfig(config map[string]interface{}) (model.JSONObject, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	encrypted := make(model.JSONObject, len(config))
+	for key, value := range config {
+		if !model.IsSecretConfigKey(key) || IsEncryptedValue(value) || value == nil {
+			encrypted[key] = value
+			continue
+		}
+
+		sealed, err := cc.encryptValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt %s: %w", key, err)
+		}
+		encrypted[key] = sealed
+	}
+	return encrypted, nil
+}
+
+// DecryptConfig returns a copy of config with encrypted values decrypted
+func (cc *ConfigCipher) DecryptConfig(config map[string]interface{}) (model.JSONObject, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	decrypted := make(model.JSONObject, len(config))
+	for key, value := range config {
+		if !IsEncryptedValue(value) {
+			decrypted[key] = value
+			continue
+		}
+
+		plain, err := cc.decryptValue(value.(string))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w", key, err)
+		}
+		decrypted[key] = plain
+	}
+	return decrypted, nil
+}
+
+// IsEncryptedValue reports whether a config value was encrypted by ConfigCipher
+func IsEncryptedValue(value interface{}) bool {
+	s, ok := value.(string)
+	return ok && strings.HasPrefix(s, encryptedPrefix)
+}
+
+// encryptValue seals the JSON encoding of value so its type survives decryption
+func (cc *ConfigCipher) encryptValue(value interface{}) (string, error) {
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, cc.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := cc.aead.Seal(nonce, nonce, plaintext, nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptValue opens a value produced by encryptValue
+func (cc *ConfigCipher) decryptValue(value string) (interface{}, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := cc.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	plaintext, err := cc.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(plaintext, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
@@ -0,0 +1,159 @@
+// internal/utils/validation.go
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes one invalid field of a request body, so clients can
+// point at the form field instead of showing the raw validator message
+type FieldError struct {
+	Field   string `json:"field"` // JSON path, e.g. steps[0].device_id
+	Rule    string `json:"rule"`  // failed rule, e.g. required, min, type
+	Message string `json:"message"`
+}
+
+// RegisterJSONFieldNames makes binding validation errors name fields by
+// their JSON names rather than their Go struct field names
+func RegisterJSONFieldNames() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			return ""
+		case "":
+			return field.Name
+		}
+		return name
+	})
+}
+
+// FieldErrors translates request binding errors into field errors. Errors
+// that don't concern a field, e.g. malformed JSON, yield none.
+func FieldErrors(err error) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			fields = append(fields, FieldError{
+				Field:   fieldPath(fieldErr.Namespace()),
+				Rule:    fieldErr.Tag(),
+				Message: ruleMessage(fieldErr),
+			})
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []FieldError{{
+			Field:   jsonFieldPath(typeErr.Field),
+			Rule:    "type",
+			Message: fmt.Sprintf("must be %s, got %s", jsonTypeName(typeErr.Type), typeErr.Value),
+		}}
+	}
+	return nil
+}
+
+// fieldPath drops the struct name a validator namespace starts with,
+// e.g. ChainOperationRequest.steps[0].device_id becomes steps[0].device_id
+func fieldPath(namespace string) string {
+	if _, path, found := strings.Cut(namespace, "."); found {
+		return path
+	}
+	return namespace
+}
+
+// jsonFieldPath writes the array indexes of a JSON decoding path the way
+// validator paths do, e.g. steps.0.device_id becomes steps[0].device_id
+func jsonFieldPath(path string) string {
+	var out strings.Builder
+	for i, part := range strings.Split(path, ".") {
+		switch {
+		case part != "" && strings.Trim(part, "0123456789") == "":
+			out.WriteString("[" + part + "]")
+		case i > 0:
+			out.WriteString("." + part)
+		default:
+			out.WriteString(part)
+		}
+	}
+	return out.String()
+}
+
+// ruleMessage describes a failed validation rule in plain words
+func ruleMessage(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+
+	// Size rules count characters of strings and items of lists and objects
+	unit := ""
+	switch fieldErr.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		if unit != "" {
+			return fmt.Sprintf("must have at least %s%s", param, unit)
+		}
+		return fmt.Sprintf("must be at least %s", param)
+	case "max", "lte":
+		if unit != "" {
+			return fmt.Sprintf("must have at most %s%s", param, unit)
+		}
+		return fmt.Sprintf("must be at most %s", param)
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", param)
+	case "lt":
+		return fmt.Sprintf("must be less than %s", param)
+	case "len":
+		return fmt.Sprintf("must have exactly %s%s", param, unit)
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.ReplaceAll(param, " ", ", "))
+	case "uuid", "uuid4":
+		return "must be a UUID"
+	case "email":
+		return "must be an email address"
+	case "url":
+		return "must be a URL"
+	case "ip":
+		return "must be an IP address"
+	}
+	return fmt.Sprintf("failed the %s rule", fieldErr.Tag())
+}
+
+// jsonTypeName names the JSON type a Go type is decoded from
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return t.String()
+}
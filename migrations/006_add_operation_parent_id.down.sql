@@ -0,0 +1,3 @@
+-- migrations/006_add_operation_parent_id.down.sql
+DROP INDEX IF EXISTS idx_operations_parent_operation_id;
+ALTER TABLE device_operations DROP COLUMN IF EXISTS parent_operation_id;
@@ -0,0 +1,6 @@
+-- migrations/006_add_operation_parent_id.up.sql
+-- Link replayed operations to the operation they were replayed from
+ALTER TABLE device_operations
+    ADD COLUMN IF NOT EXISTS parent_operation_id UUID REFERENCES device_operations(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_operations_parent_operation_id ON device_operations(parent_operation_id);
@@ -0,0 +1,2 @@
+-- migrations/007_add_operation_error_search_index.down.sql
+DROP INDEX IF EXISTS idx_operations_error_message_trgm;
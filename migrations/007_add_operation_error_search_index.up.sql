@@ -0,0 +1,7 @@
+-- migrations/007_add_operation_error_search_index.up.sql
+-- Trigram index so error_message ILIKE '%...%' searches do not scan the table
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_operations_error_message_trgm
+    ON device_operations USING GIN (error_message gin_trgm_ops)
+    WHERE error_message IS NOT NULL;
@@ -0,0 +1,12 @@
+-- migrations/008_add_scale_device_type.down.sql
+ALTER TABLE IF EXISTS offline_operations DROP CONSTRAINT IF EXISTS offline_operations_operation_type_check;
+ALTER TABLE IF EXISTS offline_operations ADD CONSTRAINT offline_operations_operation_type_check
+    CHECK (operation_type IN ('PRINT', 'PAYMENT', 'SCAN', 'STATUS_CHECK', 'OPEN_DRAWER', 'DISPLAY_TEXT', 'BEEP', 'REFUND', 'CUT'));
+
+ALTER TABLE device_operations DROP CONSTRAINT IF EXISTS device_operations_operation_type_check;
+ALTER TABLE device_operations ADD CONSTRAINT device_operations_operation_type_check
+    CHECK (operation_type IN ('PRINT', 'PAYMENT', 'SCAN', 'STATUS_CHECK', 'OPEN_DRAWER', 'DISPLAY_TEXT', 'BEEP', 'REFUND', 'CUT'));
+
+ALTER TABLE devices DROP CONSTRAINT IF EXISTS devices_device_type_check;
+ALTER TABLE devices ADD CONSTRAINT devices_device_type_check
+    CHECK (device_type IN ('POS', 'PRINTER', 'SCANNER', 'CASH_REGISTER', 'CASH_DRAWER', 'DISPLAY'));
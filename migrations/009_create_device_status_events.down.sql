@@ -0,0 +1,3 @@
+-- migrations/009_create_device_status_events.down.sql
+DROP INDEX IF EXISTS idx_status_events_device_date;
+DROP TABLE IF EXISTS device_status_events;
@@ -0,0 +1,12 @@
+-- migrations/009_create_device_status_events.up.sql
+-- Record every device status transition for status history and uptime
+CREATE TABLE IF NOT EXISTS device_status_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    device_id UUID NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+    old_status VARCHAR(20) NOT NULL,
+    new_status VARCHAR(20) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_events_device_date ON device_status_events(device_id, created_at);
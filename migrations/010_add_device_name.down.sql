@@ -0,0 +1,3 @@
+-- migrations/010_add_device_name.down.sql
+DROP INDEX IF EXISTS idx_devices_name;
+ALTER TABLE devices DROP COLUMN IF EXISTS name;
@@ -0,0 +1,5 @@
+-- migrations/010_add_device_name.up.sql
+-- Friendly device names staff search by, e.g. "Register 3 Printer"
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS name VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_devices_name ON devices(name);
@@ -0,0 +1,3 @@
+-- migrations/011_add_offline_sync_claim.down.sql
+DROP INDEX IF EXISTS idx_offline_ops_claimed_until;
+ALTER TABLE IF EXISTS offline_operations DROP COLUMN IF EXISTS claimed_until;
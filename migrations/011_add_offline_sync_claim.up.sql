@@ -0,0 +1,6 @@
+-- migrations/011_add_offline_sync_claim.up.sql
+-- Offline operations are claimed while a sync runs them, so overlapping sync
+-- cycles don't execute one twice; an expired claim frees a crashed sync's work
+ALTER TABLE IF EXISTS offline_operations ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_offline_ops_claimed_until ON offline_operations(claimed_until);
@@ -0,0 +1,5 @@
+-- migrations/012_add_tenant_id.down.sql
+DROP INDEX IF EXISTS idx_operations_tenant_created;
+DROP INDEX IF EXISTS idx_devices_tenant_branch;
+ALTER TABLE device_operations DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE devices DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,8 @@
+-- migrations/012_add_tenant_id.up.sql
+-- Tenants (organizations) group branches above branch_id. Rows without a
+-- tenant predate multi-tenancy and are only visible to platform-wide callers.
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS tenant_id UUID;
+ALTER TABLE device_operations ADD COLUMN IF NOT EXISTS tenant_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_devices_tenant_branch ON devices(tenant_id, branch_id);
+CREATE INDEX IF NOT EXISTS idx_operations_tenant_created ON device_operations(tenant_id, created_at);
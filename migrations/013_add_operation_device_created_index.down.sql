@@ -0,0 +1,2 @@
+-- migrations/013_add_operation_device_created_index.down.sql
+DROP INDEX IF EXISTS idx_operations_device_created;
@@ -0,0 +1,4 @@
+-- migrations/013_add_operation_device_created_index.up.sql
+-- Serves a device's newest operations (ORDER BY created_at DESC LIMIT n)
+-- straight from the index
+CREATE INDEX IF NOT EXISTS idx_operations_device_created ON device_operations(device_id, created_at DESC);
@@ -0,0 +1,3 @@
+-- migrations/014_add_operation_metadata.down.sql
+DROP INDEX IF EXISTS idx_operations_metadata;
+ALTER TABLE device_operations DROP COLUMN IF EXISTS metadata;
@@ -0,0 +1,5 @@
+-- migrations/014_add_operation_metadata.up.sql
+-- Client supplied key/value tags (e.g. order_id) echoed back and filterable
+ALTER TABLE device_operations ADD COLUMN IF NOT EXISTS metadata JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_operations_metadata ON device_operations USING GIN (metadata jsonb_path_ops);
@@ -0,0 +1,3 @@
+-- migrations/015_create_inventory_snapshots.down.sql
+DROP INDEX IF EXISTS idx_inventory_snapshots_tenant_created;
+DROP TABLE IF EXISTS inventory_snapshots;
@@ -0,0 +1,15 @@
+-- migrations/015_create_inventory_snapshots.up.sql
+-- Point-in-time inventories of the device fleet, kept as audit records
+CREATE TABLE IF NOT EXISTS inventory_snapshots (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID,
+    requested_by VARCHAR(255),
+    device_count INTEGER NOT NULL,
+    live_count INTEGER NOT NULL,
+    devices JSONB NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_snapshots_tenant_created ON inventory_snapshots(tenant_id, created_at);
@@ -0,0 +1,3 @@
+-- migrations/016_add_operation_source.down.sql
+DROP INDEX IF EXISTS idx_operations_source_created;
+ALTER TABLE device_operations DROP COLUMN IF EXISTS source;
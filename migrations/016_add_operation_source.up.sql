@@ -0,0 +1,6 @@
+-- migrations/016_add_operation_source.up.sql
+-- Channel an operation was started through (REST, WEBSOCKET, OFFLINE_SYNC,
+-- REPLAY or RECOVERY); NULL for operations recorded before it
+ALTER TABLE device_operations ADD COLUMN IF NOT EXISTS source VARCHAR(20);
+
+CREATE INDEX IF NOT EXISTS idx_operations_source_created ON device_operations(source, created_at);
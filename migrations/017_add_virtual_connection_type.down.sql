@@ -0,0 +1,4 @@
+-- migrations/017_add_virtual_connection_type.down.sql
+ALTER TABLE devices DROP CONSTRAINT IF EXISTS devices_connection_type_check;
+ALTER TABLE devices ADD CONSTRAINT devices_connection_type_check
+    CHECK (connection_type IN ('SERIAL', 'USB', 'TCP', 'BLUETOOTH'));
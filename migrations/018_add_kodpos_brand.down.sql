@@ -0,0 +1,4 @@
+-- migrations/018_add_kodpos_brand.down.sql
+ALTER TABLE devices DROP CONSTRAINT IF EXISTS devices_brand_check;
+ALTER TABLE devices ADD CONSTRAINT devices_brand_check
+    CHECK (brand IN ('EPSON', 'STAR', 'INGENICO', 'PAX', 'CITIZEN', 'BIXOLON', 'VERIFONE', 'GENERIC'));
@@ -0,0 +1,149 @@
+// pkg/devicetypes/templates.go
+package devicetypes
+
+import "strings"
+
+// ConfigTemplate holds the usual connection settings of a device model, so
+// identical devices can be registered without re-entering them
+type ConfigTemplate struct {
+	DeviceType       string                 `json:"device_type"`
+	ConnectionType   string                 `json:"connection_type"`
+	ConnectionConfig map[string]interface{} `json:"connection_config"`
+	RequiredFields   []string               `json:"required_fields,omitempty"` // site specific, left empty in the template
+}
+
+// anyModel keys the template used for models without their own
+const anyModel = "*"
+
+// ConfigTemplates defines connection config templates by brand and model.
+// The "*" model is the brand's fallback.
+var ConfigTemplates = map[string]map[string]ConfigTemplate{
+	"EPSON": {
+		anyModel:    tcpPrinterTemplate(),
+		"TM-T88VI":  usbPrinterTemplate("0x04B8", "0x0214"),
+		"TM-T88V":   usbPrinterTemplate("0x04B8", "0x0203"),
+		"TM-T20III": usbPrinterTemplate("0x04B8", "0x0215"),
+		"TM-T82III": usbPrinterTemplate("0x04B8", "0x0216"),
+		"TM-P20":    serialTemplate("PRINTER", 115200, 8, "none"),
+		"TM-P80":    serialTemplate("PRINTER", 115200, 8, "none"),
+	},
+	"STAR": {
+		anyModel:     tcpPrinterTemplate(),
+		"TSP143III":  usbPrinterTemplate("0x0519", "0x0001"),
+		"TSP143IIIU": usbPrinterTemplate("0x0519", "0x0002"),
+		"TSP654II":   usbPrinterTemplate("0x0519", "0x0003"),
+	},
+	"CITIZEN": {
+		anyModel:    tcpPrinterTemplate(),
+		"CT-S310II": usbPrinterTemplate("0x1CBE", "0x0001"),
+		"CT-S4000":  usbPrinterTemplate("0x1CBE", "0x0002"),
+	},
+	"BIXOLON": {
+		anyModel: tcpPrinterTemplate(),
+	},
+	"INGENICO": {
+		anyModel: serialTemplate("POS", 115200, 8, "none"),
+	},
+	"VERIFONE": {
+		anyModel: serialTemplate("POS", 115200, 8, "none"),
+	},
+	"PAX": {
+		anyModel: {
+			DeviceType:     "POS",
+			ConnectionType: "TCP",
+			ConnectionConfig: map[string]interface{}{
+				"host":          "",
+				"port":          8080,
+				"timeout":       "10s",
+				"read_timeout":  "60s",
+				"write_timeout": "30s",
+				"keep_alive":    true,
+			},
+			RequiredFields: []string{"host"},
+		},
+	},
+	"GENERIC": {
+		// Toledo 8217 protocol scales
+		anyModel: serialTemplate("SCALE", 9600, 7, "even"),
+	},
+}
+
+// FindConfigTemplate returns the template of a brand and model, falling back
+// to the brand's default. exact reports whether the model has its own
+// template.
+func FindConfigTemplate(brand, model string) (template ConfigTemplate, exact bool, ok bool) {
+	models, exists := ConfigTemplates[strings.ToUpper(brand)]
+	if !exists {
+		return ConfigTemplate{}, false, false
+	}
+
+	for name, template := range models {
+		if name != anyModel && strings.EqualFold(name, model) {
+			return template.clone(), true, true
+		}
+	}
+	if template, exists := models[anyModel]; exists {
+		return template.clone(), false, true
+	}
+	return ConfigTemplate{}, false, false
+}
+
+// clone copies the template so callers can fill it in
+func (t ConfigTemplate) clone() ConfigTemplate {
+	config := make(map[string]interface{}, len(t.ConnectionConfig))
+	for key, value := range t.ConnectionConfig {
+		config[key] = value
+	}
+	t.ConnectionConfig = config
+	t.RequiredFields = append([]string(nil), t.RequiredFields...)
+	return t
+}
+
+// tcpPrinterTemplate is a network receipt printer on the raw print port
+func tcpPrinterTemplate() ConfigTemplate {
+	return ConfigTemplate{
+		DeviceType:     "PRINTER",
+		ConnectionType: "TCP",
+		ConnectionConfig: map[string]interface{}{
+			"host":          "",
+			"port":          9100,
+			"timeout":       "10s",
+			"read_timeout":  "30s",
+			"write_timeout": "30s",
+			"keep_alive":    true,
+		},
+		RequiredFields: []string{"host"},
+	}
+}
+
+// usbPrinterTemplate is a USB printer class device
+func usbPrinterTemplate(vendorID, productID string) ConfigTemplate {
+	return ConfigTemplate{
+		DeviceType:     "PRINTER",
+		ConnectionType: "USB",
+		ConnectionConfig: map[string]interface{}{
+			"vendor_id":  vendorID,
+			"product_id": productID,
+			"interface":  0,
+			"endpoint":   1,
+			"timeout":    "5s",
+		},
+	}
+}
+
+// serialTemplate is a serial device with one stop bit
+func serialTemplate(deviceType string, baudRate, dataBits int, parity string) ConfigTemplate {
+	return ConfigTemplate{
+		DeviceType:     deviceType,
+		ConnectionType: "SERIAL",
+		ConnectionConfig: map[string]interface{}{
+			"port":      "",
+			"baud_rate": baudRate,
+			"data_bits": dataBits,
+			"stop_bits": 1,
+			"parity":    parity,
+			"timeout":   "5s",
+		},
+		RequiredFields: []string{"port"},
+	}
+}
@@ -58,6 +58,9 @@ var DeviceCapabilities = map[string][]string{
 	"DISPLAY": {
 		"DISPLAY", "STATUS",
 	},
+	"SCALE": {
+		"WEIGH", "STATUS",
+	},
 }
 
 // BrandModels defines supported models for each brand
@@ -115,6 +118,7 @@ var DefaultTimeouts = map[string]int{
 	"STATUS_CHECK": 5,
 	"DRAWER_OPEN":  3,
 	"DISPLAY":      2,
+	"WEIGH":        5,
 }
 
 // Health score calculation weights
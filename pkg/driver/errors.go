@@ -0,0 +1,73 @@
+// pkg/driver/errors.go
+package driver
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrCapabilityDisabled is returned when an operation needs a capability
+// that was switched off on the device
+var ErrCapabilityDisabled = errors.New("capability disabled")
+
+// ErrorCode classifies device failures so clients can react to them (e.g.
+// prompt "load paper") without parsing error messages. Drivers report these
+// codes in OperationError, OperationResult.ErrorCode and DeviceStatus.ErrorCode.
+type ErrorCode string
+
+const (
+	ErrorCodePaperOut               ErrorCode = "PAPER_OUT"
+	ErrorCodeCoverOpen              ErrorCode = "COVER_OPEN"
+	ErrorCodeCutterError            ErrorCode = "CUTTER_ERROR"
+	ErrorCodeHardwareError          ErrorCode = "HARDWARE_ERROR"
+	ErrorCodeDeviceOffline          ErrorCode = "DEVICE_OFFLINE"
+	ErrorCodeConnectionLost         ErrorCode = "CONNECTION_LOST"
+	ErrorCodeTimeout                ErrorCode = "TIMEOUT"
+	ErrorCodeUnsupported            ErrorCode = "UNSUPPORTED"
+	ErrorCodeCapabilityDisabled     ErrorCode = "CAPABILITY_DISABLED"
+	ErrorCodeInvalidRequest         ErrorCode = "INVALID_REQUEST"
+	ErrorCodeQueueFull              ErrorCode = "QUEUE_FULL"
+	ErrorCodeDeviceLocked           ErrorCode = "DEVICE_LOCKED"           // reserved by another client
+	ErrorCodeNotPermitted           ErrorCode = "OPERATION_NOT_PERMITTED" // forbidden by the device's operation policy
+	ErrorCodeInterrupted            ErrorCode = "INTERRUPTED"             // cut short by a service restart
+	ErrorCodeReconciliationRequired ErrorCode = "RECONCILIATION_REQUIRED" // money movement with unknown outcome
+	ErrorCodeDriverNotFound         ErrorCode = "DRIVER_NOT_FOUND"        // no driver registered for the device
+)
+
+// OperationError is a device failure tagged with an error code
+type OperationError struct {
+	Code ErrorCode
+	Err  error
+}
+
+// NewOperationError tags err with code
+func NewOperationError(code ErrorCode, err error) *OperationError {
+	return &OperationError{Code: code, Err: err}
+}
+
+func (e *OperationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCodeOf returns the error code of err, or an empty code when the
+// failure isn't classified
+func ErrorCodeOf(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+
+	var opErr *OperationError
+	switch {
+	case errors.As(err, &opErr):
+		return opErr.Code
+	case errors.Is(err, ErrCapabilityDisabled):
+		return ErrorCodeCapabilityDisabled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorCodeTimeout
+	}
+	return ""
+}
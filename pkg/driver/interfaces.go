@@ -37,6 +37,31 @@ type DeviceDriver interface {
 	Close() error
 }
 
+// WireLoggingKey is the connection config key of the per-device flag that
+// enables hex dumps of protocol traffic
+const WireLoggingKey = "wire_logging"
+
+// WireLogToggler is implemented by drivers that can log their raw protocol
+// traffic; the flag can be switched while the driver is connected
+type WireLogToggler interface {
+	SetWireLogging(enabled bool)
+	WireLogging() bool
+}
+
+// PrinterStatusReporter is implemented by printer drivers that can query the
+// printer's real-time status on demand
+type PrinterStatusReporter interface {
+	GetPrinterStatus(ctx context.Context) (*PrinterStatus, error)
+}
+
+// PrintDrainer is implemented by printer drivers that can wait until the
+// printer has processed all data sent to it. WaitDrained returns an error
+// when the printer doesn't get there before ctx is done, e.g. because it
+// stopped on an error.
+type PrintDrainer interface {
+	WaitDrained(ctx context.Context) error
+}
+
 // PrinterDriver extends DeviceDriver for printer-specific operations
 type PrinterDriver interface {
 	DeviceDriver
@@ -86,6 +111,15 @@ type ScannerDriver interface {
 	SetTriggerMode(mode TriggerMode) error
 }
 
+// ScanStreamer is implemented by scanner drivers that can read barcodes
+// continuously. StreamScans calls onScan for every decoded barcode, in read
+// order and one call at a time, until ctx is done and then returns nil; it
+// returns early with an error when the scanner fails. onScan is never called
+// after StreamScans returns.
+type ScanStreamer interface {
+	StreamScans(ctx context.Context, onScan func(*ScanResult)) error
+}
+
 // DisplayDriver extends DeviceDriver for customer display operations
 type DisplayDriver interface {
 	DeviceDriver
@@ -99,3 +133,11 @@ type DisplayDriver interface {
 	SetBrightness(ctx context.Context, level int) error
 	SetContrast(ctx context.Context, level int) error
 }
+
+// ScaleDriver extends DeviceDriver for weighing scale operations
+type ScaleDriver interface {
+	DeviceDriver
+
+	// Weighing operations
+	ReadWeight(ctx context.Context) (*WeightReading, error)
+}
@@ -2,11 +2,135 @@
 package driver
 
 import (
+	"context"
+	"math/rand"
+	"strings"
 	"time"
 
 	"device-service/internal/model"
 )
 
+// CapabilityFlags maps capabilities that can be toggled at runtime to the
+// connection config key holding their flag
+var CapabilityFlags = map[model.Capability]string{
+	model.CapabilityCut:      "enable_cutter",
+	model.CapabilityDrawer:   "enable_drawer",
+	model.CapabilityLogo:     "logo_enabled",
+	model.CapabilityTwoColor: "two_color_enabled",
+}
+
+// CapabilityFlag returns the toggle of a capability stored in a connection
+// config; ok is false when the config doesn't set it
+func CapabilityFlag(settings map[string]interface{}, capability model.Capability) (enabled bool, ok bool) {
+	key, toggleable := CapabilityFlags[capability]
+	if !toggleable {
+		return false, false
+	}
+	enabled, ok = settings[key].(bool)
+	return enabled, ok
+}
+
+// Connection config keys of the per-device operation policy. Raw ESC/POS
+// print jobs bypass text sanitization, so devices must opt in to them.
+const (
+	AllowedOperationsKey = "allowed_operations"
+	DeniedOperationsKey  = "denied_operations"
+	AllowRawPrintKey     = "allow_raw_print"
+)
+
+// RawPrintAllowed reports whether a connection config permits print jobs
+// with raw ESC/POS content
+func RawPrintAllowed(settings map[string]interface{}) bool {
+	allowed, _ := settings[AllowRawPrintKey].(bool)
+	return allowed
+}
+
+// OperationList returns the operation types listed under key in a connection
+// config. Lists read back from the database hold []interface{}.
+func OperationList(settings map[string]interface{}, key string) []model.OperationType {
+	operations := []model.OperationType{}
+	switch list := settings[key].(type) {
+	case []string:
+		for _, operation := range list {
+			operations = append(operations, model.OperationType(operation))
+		}
+	case []interface{}:
+		for _, operation := range list {
+			if name, ok := operation.(string); ok {
+				operations = append(operations, model.OperationType(name))
+			}
+		}
+	}
+	return operations
+}
+
+// OperationPermitted reports whether the operation policy in a connection
+// config permits an operation. A denied operation is never permitted; when
+// the allow list is set, only the operations on it are.
+func OperationPermitted(settings map[string]interface{}, operationType model.OperationType) bool {
+	for _, denied := range OperationList(settings, DeniedOperationsKey) {
+		if denied == operationType {
+			return false
+		}
+	}
+
+	allowed := OperationList(settings, AllowedOperationsKey)
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, operation := range allowed {
+		if operation == operationType {
+			return true
+		}
+	}
+	return false
+}
+
+// escposPrinterBrands are printers driven over ESC/POS, which adds paper
+// cutting and cash drawer kicks
+var escposPrinterBrands = map[model.DeviceBrand]bool{
+	model.BrandEpson:  true,
+	model.BrandStar:   true,
+	model.BrandKodpos: true,
+}
+
+// DefaultCapabilities returns the capabilities a device of the given type
+// and brand supports. Registration stores them and drivers report them, so
+// a device's stored capabilities match its driver.
+func DefaultCapabilities(deviceType model.DeviceType, brand model.DeviceBrand) []model.Capability {
+	switch deviceType {
+	case model.DeviceTypePrinter:
+		capabilities := []model.Capability{model.CapabilityPrint, model.CapabilityStatus}
+		if escposPrinterBrands[brand] {
+			capabilities = append(capabilities, model.CapabilityCut, model.CapabilityDrawer)
+		}
+		if brand == model.BrandEpson || brand == model.BrandKodpos {
+			capabilities = append(capabilities, model.CapabilityBeep)
+		}
+		return capabilities
+	case model.DeviceTypePOS:
+		return []model.Capability{model.CapabilityPayment, model.CapabilityDisplay, model.CapabilityStatus}
+	case model.DeviceTypeScanner:
+		return []model.Capability{model.CapabilityScan, model.CapabilityBeep, model.CapabilityStatus}
+	case model.DeviceTypeCashDrawer:
+		return []model.Capability{model.CapabilityDrawer, model.CapabilityStatus}
+	case model.DeviceTypeDisplay:
+		return []model.Capability{model.CapabilityDisplay, model.CapabilityStatus}
+	case model.DeviceTypeScale:
+		return []model.Capability{model.CapabilityWeigh, model.CapabilityStatus}
+	}
+	return []model.Capability{}
+}
+
+// CapabilityArray converts capabilities to the JSON array stored with a device
+func CapabilityArray(capabilities []model.Capability) model.JSONArray {
+	array := make(model.JSONArray, 0, len(capabilities))
+	for _, capability := range capabilities {
+		array = append(array, string(capability))
+	}
+	return array
+}
+
 // Core data structures
 
 // DeviceInfo contains basic device information
@@ -21,6 +145,16 @@ type DeviceInfo struct {
 	Manufacturer    string               `json:"manufacturer"`
 }
 
+// OptionalTime returns t, or nil for the zero time, so timestamps of events
+// that never happened (e.g. the last ping of a never connected device) are
+// left out of responses instead of showing as 0001-01-01
+func OptionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
 // DeviceStatus represents current device status
 type DeviceStatus struct {
 	Status       model.DeviceStatus `json:"status"`
@@ -28,9 +162,25 @@ type DeviceStatus struct {
 	HasError     bool               `json:"has_error"`
 	ErrorCode    string             `json:"error_code,omitempty"`
 	ErrorMessage string             `json:"error_message,omitempty"`
-	LastResponse time.Time          `json:"last_response"`
+	LastResponse *time.Time         `json:"last_response,omitempty"` // nil if the device never responded
 	Temperature  *float64           `json:"temperature,omitempty"`
 	Voltage      *float64           `json:"voltage,omitempty"`
+
+	// Printer specific real-time state, when the device reports it
+	PaperStatus PaperStatus            `json:"paper_status,omitempty"`
+	DrawerOpen  *bool                  `json:"drawer_open,omitempty"`
+	Details     map[string]interface{} `json:"details,omitempty"`
+}
+
+// PrinterStatus is a printer's real-time state as reported by the device
+type PrinterStatus struct {
+	Online       bool      `json:"online"`
+	PaperPresent bool      `json:"paper_present"`
+	PaperNearEnd bool      `json:"paper_near_end"`
+	CoverOpen    bool      `json:"cover_open"`
+	CutterError  bool      `json:"cutter_error"`
+	DrawerOpen   bool      `json:"drawer_open"`
+	CheckedAt    time.Time `json:"checked_at"`
 }
 
 // OperationResult represents the result of a device operation
@@ -41,8 +191,53 @@ type OperationResult struct {
 	Data         map[string]interface{} `json:"data,omitempty"`
 	Duration     string                 `json:"duration"`
 	Timestamp    time.Time              `json:"timestamp"`
+
+	// RawResponse holds the bytes the device sent back, if any
+	RawResponse []byte `json:"raw_response,omitempty"`
 }
 
+// Standard OperationResult.Data keys. Drivers must report these keys for the
+// matching operations so clients can rely on one schema across brands;
+// driver specific extras may be added next to them.
+const (
+	// Every operation
+	ResultKeyDurationMs = "duration_ms" // int64, time spent on the device
+
+	// PRINT
+	ResultKeyPrinted       = "printed"        // bool
+	ResultKeyContentLength = "content_length" // int, bytes of printed content
+	ResultKeyLinesPrinted  = "lines_printed"  // int
+	ResultKeyCopies        = "copies"         // int
+	ResultKeyVerified      = "verified"       // bool, set by the service on prints asked to verify
+	ResultKeyPrinterStatus = "printer_status" // PrinterStatus read back after a verified print
+
+	// PRINT and CUT
+	ResultKeyCutPerformed = "cut_performed" // bool
+	ResultKeyCutType      = "cut_type"      // string, FULL or PARTIAL
+
+	// PRINT and OPEN_DRAWER
+	ResultKeyDrawerOpened = "drawer_opened" // bool
+	ResultKeyDrawerPin    = "drawer_pin"    // int
+
+	// BEEP
+	ResultKeyBeepPerformed  = "beep_performed"   // bool
+	ResultKeyBeepCount      = "beep_count"       // int
+	ResultKeyBeepDurationMs = "beep_duration_ms" // int, per beep
+
+	// STATUS
+	ResultKeyStatus         = "status"          // DeviceStatus
+	ResultKeyDetailedStatus = "detailed_status" // map, device specific
+
+	// WEIGH
+	ResultKeyWeight = "weight" // float64, omitted while the scale is in motion
+	ResultKeyUnit   = "unit"   // WeightUnit
+	ResultKeyStable = "stable" // bool
+
+	// SCAN
+	ResultKeyScanData = "scan_data" // string, the decoded barcode
+	ResultKeyScanType = "scan_type" // ScanType
+)
+
 // HealthMetrics contains device health information
 type HealthMetrics struct {
 	HealthScore     int           `json:"health_score"` // 0-100
@@ -53,6 +248,135 @@ type HealthMetrics struct {
 	UptimePercent   float64       `json:"uptime_percent"`
 	LastErrorTime   *time.Time    `json:"last_error_time,omitempty"`
 	LastSuccessTime *time.Time    `json:"last_success_time,omitempty"`
+
+	Transport *TransportStats `json:"transport,omitempty"`
+}
+
+// TransportStats contains transport-level counters of the active connection
+type TransportStats struct {
+	ConnectionType model.ConnectionType `json:"connection_type"`
+	BytesWritten   int64                `json:"bytes_written"`
+	BytesRead      int64                `json:"bytes_read"`
+	WriteErrors    int64                `json:"write_errors"`
+	ReadErrors     int64                `json:"read_errors"`
+	Reconnects     int64                `json:"reconnects"` // times the driver reopened its connection
+	AverageLatency time.Duration        `json:"average_latency"`
+	LastActivity   time.Time            `json:"last_activity"`
+}
+
+// ConnectionPolicy defines how a driver establishes its device connection.
+// It is resolved centrally by the driver registry and handed to each driver
+// factory so all drivers share the same connect/retry behaviour.
+type ConnectionPolicy struct {
+	ConnectTimeout    time.Duration   `json:"connect_timeout"`    // per attempt
+	MaxRetries        int             `json:"max_retries"`        // retries after the first attempt
+	InitialBackoff    time.Duration   `json:"initial_backoff"`    // delay before the first retry
+	MaxBackoff        time.Duration   `json:"max_backoff"`        // upper bound for retry delay
+	BackoffMultiplier float64         `json:"backoff_multiplier"` // growth factor between retries
+	BackoffJitter     float64         `json:"backoff_jitter"`     // fraction of each delay randomized away (0-1)
+	PingTimeout       time.Duration   `json:"ping_timeout"`       // per ping, so hung devices fail fast
+	ConnectStrategy   ConnectStrategy `json:"connect_strategy"`   // connect while the driver is created or on first use
+}
+
+// ConnectStrategy decides when a new driver opens its device connection
+type ConnectStrategy string
+
+// Connect strategies. Eager drivers connect while they are created; lazy
+// drivers are created unconnected and connect on first use.
+const (
+	ConnectEager ConnectStrategy = "eager"
+	ConnectLazy  ConnectStrategy = "lazy"
+)
+
+// IsValid reports whether s is a known connect strategy
+func (s ConnectStrategy) IsValid() bool {
+	switch s {
+	case ConnectEager, ConnectLazy:
+		return true
+	}
+	return false
+}
+
+// DefaultConnectionPolicy returns the policy used when none is configured
+func DefaultConnectionPolicy() ConnectionPolicy {
+	return ConnectionPolicy{
+		ConnectTimeout:    15 * time.Second,
+		MaxRetries:        2,
+		InitialBackoff:    time.Second,
+		MaxBackoff:        10 * time.Second,
+		BackoffMultiplier: 2.0,
+		BackoffJitter:     0.5,
+		PingTimeout:       3 * time.Second,
+		ConnectStrategy:   ConnectEager,
+	}
+}
+
+// Lazy reports whether drivers connect on first use instead of while they
+// are created. An unset strategy is eager.
+func (p ConnectionPolicy) Lazy() bool {
+	return p.ConnectStrategy == ConnectLazy
+}
+
+// PingContext bounds a single ping by the ping timeout. A shorter deadline
+// already on ctx still applies; a zero timeout leaves ctx unbounded.
+func (p ConnectionPolicy) PingContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.PingTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, p.PingTimeout)
+}
+
+// Attempts returns the total number of connection attempts allowed
+func (p ConnectionPolicy) Attempts() int {
+	if p.MaxRetries < 0 {
+		return 1
+	}
+	return p.MaxRetries + 1
+}
+
+// Backoff returns the delay to wait before the given retry (1-based). With
+// jitter, devices that lost their connection together don't all retry at
+// the same moment.
+func (p ConnectionPolicy) Backoff(retry int) time.Duration {
+	return Jitter(p.backoff(retry), p.BackoffJitter)
+}
+
+// backoff returns the exponential delay before a retry, without jitter
+func (p ConnectionPolicy) backoff(retry int) time.Duration {
+	if retry < 1 || p.InitialBackoff <= 0 {
+		return 0
+	}
+
+	multiplier := p.BackoffMultiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < retry; i++ {
+		delay *= multiplier
+		if p.MaxBackoff > 0 && delay >= float64(p.MaxBackoff) {
+			return p.MaxBackoff
+		}
+	}
+
+	if p.MaxBackoff > 0 && time.Duration(delay) > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return time.Duration(delay)
+}
+
+// Jitter shortens a delay by a random part of up to fraction of it, so a
+// fraction of 0.5 returns a delay between d/2 and d. Fractions outside 0-1
+// are clamped.
+func Jitter(d time.Duration, fraction float64) time.Duration {
+	if d <= 0 || fraction <= 0 {
+		return d
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+	return d - time.Duration(rand.Float64()*fraction*float64(d))
 }
 
 // EventHandler handles device events
@@ -74,6 +398,23 @@ type PrintContent struct {
 	Encoding string                 `json:"encoding,omitempty"`
 }
 
+// PrintEncodingRaw sends print text as received, without transcoding, for
+// clients that already encode it for the printer's code page
+const PrintEncodingRaw = "RAW"
+
+// PrintEncodings are the code pages print text can be transcoded to
+var PrintEncodings = []string{"PC437", "PC850", "PC852", "PC858", "PC866", "WPC1252", "WPC1254", PrintEncodingRaw}
+
+// IsPrintEncoding reports whether name is a supported print encoding
+func IsPrintEncoding(name string) bool {
+	for _, encoding := range PrintEncodings {
+		if strings.EqualFold(name, encoding) {
+			return true
+		}
+	}
+	return false
+}
+
 // ContentType defines the type of print content
 type ContentType string
 
@@ -81,8 +422,9 @@ const (
 	ContentTypeText    ContentType = "TEXT"
 	ContentTypeHTML    ContentType = "HTML"
 	ContentTypeESCPOS  ContentType = "ESC_POS"
-	ContentTypeImage   ContentType = "IMAGE"
 	ContentTypeReceipt ContentType = "RECEIPT"
+	ContentTypeLabel   ContentType = "LABEL" // positioned elements, see the EPSON LabelData payload
+	ContentTypePDF     ContentType = "PDF"   // base64 PDF, rasterized; image based pages only
 )
 
 // CutType defines paper cutting options
@@ -263,3 +605,21 @@ type DisplayText struct {
 	ClearAfter bool                   `json:"clear_after"`
 	Options    map[string]interface{} `json:"options,omitempty"`
 }
+
+// Scale-specific types
+
+// WeightUnit defines the unit a scale reports weight in
+type WeightUnit string
+
+const (
+	WeightUnitKilogram WeightUnit = "kg"
+	WeightUnitPound    WeightUnit = "lb"
+)
+
+// WeightReading represents one weight reading of a scale
+type WeightReading struct {
+	Weight    float64    `json:"weight"`
+	Unit      WeightUnit `json:"unit"`
+	Stable    bool       `json:"stable"` // false while the scale is in motion; Weight is then unknown
+	Timestamp time.Time  `json:"timestamp"`
+}